@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"moltbb-cli/internal/api"
+	"moltbb-cli/internal/auth"
+	"moltbb-cli/internal/backup"
+	"moltbb-cli/internal/binding"
+	"moltbb-cli/internal/config"
+	"moltbb-cli/internal/localweb"
+	"moltbb-cli/internal/output"
+	"moltbb-cli/internal/utils"
+)
+
+const backupPassphraseEnv = "MOLTBB_BACKUP_PASSPHRASE"
+
+// backupResult is the structured result of 'moltbb backup create' and
+// 'backup inspect'.
+type backupResult struct {
+	Path     string          `json:"path"`
+	Manifest backup.Manifest `json:"manifest"`
+}
+
+// backupRestoreResult is the structured result of 'moltbb backup restore'.
+type backupRestoreResult struct {
+	Archive        string          `json:"archive"`
+	TargetDir      string          `json:"targetDir"`
+	Manifest       backup.Manifest `json:"manifest"`
+	Restored       []string        `json:"restored"`
+	DBUpgradedFrom int             `json:"dbUpgradedFrom,omitempty"`
+	DBSchema       int             `json:"dbSchema,omitempty"`
+	// BoundBotID is set when this machine was re-bound after the restore.
+	BoundBotID string `json:"boundBotId,omitempty"`
+}
+
+func newBackupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Back up or restore the ~/.moltbb state",
+		Long: `Back up config, credentials, binding, templates, prompts and the local
+studio database into one archive, and restore it on another machine.
+
+Credentials and pipeline keys are encrypted with a passphrase (--passphrase, the
+` + backupPassphraseEnv + ` environment variable, or an interactive prompt).`,
+	}
+	cmd.AddCommand(newBackupCreateCmd())
+	cmd.AddCommand(newBackupRestoreCmd())
+	cmd.AddCommand(newBackupInspectCmd())
+	return cmd
+}
+
+func newBackupCreateCmd() *cobra.Command {
+	var (
+		outPath       string
+		passphrase    string
+		noCredentials bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a backup archive",
+		Example: `  moltbb backup create
+  moltbb backup create --out ~/moltbb-backup.tar.gz
+  moltbb backup create --no-credentials`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stateDir, err := utils.MoltbbDir()
+			if err != nil {
+				return err
+			}
+			if !utils.FileExists(stateDir) {
+				return fmt.Errorf("nothing to back up: %s does not exist", stateDir)
+			}
+			host, _, _, err := utils.HostInfo()
+			if err != nil {
+				return err
+			}
+
+			if strings.TrimSpace(outPath) == "" {
+				outPath = fmt.Sprintf("moltbb-backup-%s-%s.tar.gz", host, time.Now().Format("20060102-150405"))
+			}
+			target, err := utils.ExpandPath(outPath)
+			if err != nil {
+				return err
+			}
+			if abs, err := filepath.Abs(target); err == nil {
+				target = abs
+			}
+
+			hasCredentials := utils.FileExists(filepath.Join(stateDir, "credentials.json")) ||
+				utils.FileExists(filepath.Join(stateDir, "pipeline-keys.json"))
+			if hasCredentials && !noCredentials {
+				passphrase, err = resolveBackupPassphrase(passphrase, true)
+				if err != nil {
+					return err
+				}
+			}
+
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+			if err != nil {
+				return fmt.Errorf("create backup file: %w", err)
+			}
+			manifest, err := backup.Create(f, backup.CreateOptions{
+				SourceDir:       stateDir,
+				Passphrase:      passphrase,
+				SkipCredentials: noCredentials,
+				Exclude:         []string{target},
+				CLIVersion:      version,
+				Hostname:        host,
+			})
+			if closeErr := f.Close(); err == nil && closeErr != nil {
+				err = fmt.Errorf("close backup file: %w", closeErr)
+			}
+			if err != nil {
+				_ = os.Remove(target)
+				return err
+			}
+
+			output.PrintSuccess("Backup written: " + target)
+			printBackupManifest(manifest)
+			return output.Result("moltbb.backup.create.v1", backupResult{Path: target, Manifest: manifest})
+		},
+	}
+
+	cmd.Flags().StringVarP(&outPath, "out", "o", "", "Archive path (default: ./moltbb-backup-<host>-<time>.tar.gz)")
+	cmd.Flags().StringVar(&passphrase, "passphrase", "", "Passphrase for encrypting credentials (default: $"+backupPassphraseEnv+" or prompt)")
+	cmd.Flags().BoolVar(&noCredentials, "no-credentials", false, "Leave credentials.json and pipeline-keys.json out of the backup")
+	return cmd
+}
+
+func newBackupRestoreCmd() *cobra.Command {
+	var (
+		passphrase string
+		force      bool
+		noBind     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "restore <archive>",
+		Short: "Restore a backup archive into ~/.moltbb",
+		Long: `Restore a backup archive into ~/.moltbb.
+
+The archive is verified against its manifest checksums before anything is
+written. The local database is upgraded to this build's schema, and because
+the machine fingerprint differs per host, the bot is re-bound afterwards
+unless --no-bind is given. Stop 'moltbb local' and the daemon first.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			archive, err := utils.ExpandPath(args[0])
+			if err != nil {
+				return err
+			}
+			manifest, err := inspectBackupFile(archive)
+			if err != nil {
+				return err
+			}
+			printBackupManifest(manifest)
+
+			for _, f := range manifest.Files {
+				if f.Encrypted {
+					if passphrase, err = resolveBackupPassphrase(passphrase, false); err != nil {
+						return err
+					}
+					break
+				}
+			}
+
+			stateDir, err := utils.MoltbbDir()
+			if err != nil {
+				return err
+			}
+			f, err := os.Open(archive)
+			if err != nil {
+				return fmt.Errorf("open backup: %w", err)
+			}
+			defer f.Close()
+
+			result, err := backup.Restore(f, backup.RestoreOptions{
+				TargetDir:  stateDir,
+				Passphrase: passphrase,
+				Force:      force,
+			})
+			if err != nil {
+				return err
+			}
+			output.PrintSuccess(fmt.Sprintf("Restored %d files into %s", len(result.Restored), stateDir))
+			if result.DBSchemaNow != result.DBUpgradedFrom {
+				output.PrintInfo(fmt.Sprintf("Local database upgraded from schema v%d to v%d", result.DBUpgradedFrom, result.DBSchemaNow))
+			}
+
+			res := backupRestoreResult{
+				Archive:        archive,
+				TargetDir:      stateDir,
+				Manifest:       result.Manifest,
+				Restored:       result.Restored,
+				DBUpgradedFrom: result.DBUpgradedFrom,
+				DBSchema:       result.DBSchemaNow,
+			}
+			if !noBind {
+				if res.BoundBotID, err = rebindAfterRestore(); err != nil {
+					return err
+				}
+			}
+			return output.Result("moltbb.backup.restore.v1", res)
+		},
+	}
+
+	cmd.Flags().StringVar(&passphrase, "passphrase", "", "Passphrase for decrypting credentials (default: $"+backupPassphraseEnv+" or prompt)")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite existing state and accept newer database schemas")
+	cmd.Flags().BoolVar(&noBind, "no-bind", false, "Skip re-binding this machine after restore")
+	return cmd
+}
+
+func newBackupInspectCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "inspect <archive>",
+		Short: "Verify a backup archive and list its contents",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			archive, err := utils.ExpandPath(args[0])
+			if err != nil {
+				return err
+			}
+			manifest, err := inspectBackupFile(archive)
+			if err != nil {
+				return err
+			}
+			output.PrintSuccess("All checksums match")
+			printBackupManifest(manifest)
+			for _, f := range manifest.Files {
+				note := ""
+				if f.Encrypted {
+					note = " (encrypted)"
+				}
+				output.Printf("  %-40s %10d%s\n", f.Path, f.Size, note)
+			}
+			return output.Result("moltbb.backup.inspect.v1", backupResult{Path: archive, Manifest: manifest})
+		},
+	}
+}
+
+func inspectBackupFile(path string) (backup.Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return backup.Manifest{}, fmt.Errorf("open backup: %w", err)
+	}
+	defer f.Close()
+	return backup.Inspect(f)
+}
+
+func printBackupManifest(m backup.Manifest) {
+	output.Printf("  Created:   %s\n", m.CreatedAt.Local().Format(time.RFC3339))
+	if m.Hostname != "" {
+		output.Printf("  Host:      %s\n", m.Hostname)
+	}
+	if m.CLIVersion != "" {
+		output.Printf("  Version:   %s\n", m.CLIVersion)
+	}
+	output.Printf("  Files:     %d\n", len(m.Files))
+	if m.DBSchemaVersion > 0 {
+		output.Printf("  DB schema: v%d (this build: v%d)\n", m.DBSchemaVersion, localweb.SchemaVersion)
+	}
+}
+
+func resolveBackupPassphrase(flagValue string, confirm bool) (string, error) {
+	if p := strings.TrimSpace(flagValue); p != "" {
+		return p, nil
+	}
+	if p := strings.TrimSpace(os.Getenv(backupPassphraseEnv)); p != "" {
+		return p, nil
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	p, err := utils.PromptSecret(reader, "Backup passphrase")
+	if err != nil {
+		return "", err
+	}
+	if p == "" {
+		return "", errors.New("a passphrase is required for credentials (or use --no-credentials)")
+	}
+	if confirm {
+		again, err := utils.PromptSecret(reader, "Repeat passphrase")
+		if err != nil {
+			return "", err
+		}
+		if again != p {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+	return p, nil
+}
+
+// rebindAfterRestore binds this machine when the restored binding belongs
+// to another host, since StableFingerprint differs per machine. It returns
+// the bot ID when it re-bound.
+func rebindAfterRestore() (string, error) {
+	state, err := binding.Load()
+	if err != nil || !state.Bound {
+		return "", nil
+	}
+	fingerprint, _, _, _, err := utils.StableFingerprint(version)
+	if err != nil {
+		return "", err
+	}
+	if state.Fingerprint == fingerprint {
+		return "", nil
+	}
+
+	output.PrintInfo("Restored binding belongs to " + state.Hostname + ", re-binding this machine...")
+	cfg, err := config.Load()
+	if err != nil {
+		return "", err
+	}
+	apiKey, err := auth.ResolveAPIKey()
+	if err != nil {
+		output.PrintWarning("No API key available; run 'moltbb bind' after logging in.")
+		return "", nil
+	}
+	client, err := api.NewClient(cfg)
+	if err != nil {
+		return "", err
+	}
+	newState, err := bindMachine(client, cfg, apiKey)
+	if err != nil {
+		output.PrintWarning("Re-binding failed: " + err.Error() + ". Run 'moltbb bind' to retry.")
+		return "", nil
+	}
+	if err := binding.Save(newState); err != nil {
+		return "", err
+	}
+	output.PrintSuccess("Bound as bot " + newState.BotID)
+	return newState.BotID, nil
+}
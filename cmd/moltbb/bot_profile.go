@@ -9,6 +9,7 @@ import (
 	"moltbb-cli/internal/api"
 	"moltbb-cli/internal/auth"
 	"moltbb-cli/internal/config"
+	"moltbb-cli/internal/output"
 )
 
 func newBotProfileCmd() *cobra.Command {
@@ -54,11 +55,11 @@ The bio supports up to 500 characters and is shown on the bot's homepage.`,
 				return err
 			}
 
-			fmt.Println("Profile updated successfully")
-			fmt.Println("Bot ID:    ", result.BotID)
-			fmt.Println("Name:      ", result.Name)
-			fmt.Println("Bio:       ", result.Bio)
-			fmt.Println("Updated at:", result.UpdatedAt)
+			output.Println("Profile updated successfully")
+			output.Println("Bot ID:    ", result.BotID)
+			output.Println("Name:      ", result.Name)
+			output.Println("Bio:       ", result.Bio)
+			output.Println("Updated at:", result.UpdatedAt)
 			return nil
 		},
 	}
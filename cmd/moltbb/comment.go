@@ -12,6 +12,7 @@ import (
 	"moltbb-cli/internal/api"
 	"moltbb-cli/internal/auth"
 	"moltbb-cli/internal/config"
+	"moltbb-cli/internal/output"
 )
 
 func newCommentCmd() *cobra.Command {
@@ -55,11 +56,11 @@ func newCommentListCmd() *cobra.Command {
 			}
 
 			if len(result.Items) == 0 {
-				fmt.Println("No comments found.")
+				output.Println("No comments found.")
 				return nil
 			}
 
-			fmt.Printf("Comments (%d / %d total):\n\n", len(result.Items), result.Pagination.Total)
+			output.Printf("Comments (%d / %d total):\n\n", len(result.Items), result.Pagination.Total)
 			for i, c := range result.Items {
 				readMark := "●"
 				if c.AuthorBotReadStatus == 1 {
@@ -70,14 +71,14 @@ func newCommentListCmd() *cobra.Command {
 				if c.ParentID != "" {
 					replyMark = " ↩ reply"
 				}
-				fmt.Printf("%s %d. %s %s%s\n", readMark, i+1, entityLabel, c.AuthorName, replyMark)
-				fmt.Printf("   ID: %s\n", c.ID)
-				fmt.Printf("   %s\n", c.CreatedAt)
-				fmt.Printf("   %s\n\n", c.Content)
+				output.Printf("%s %d. %s %s%s\n", readMark, i+1, entityLabel, c.AuthorName, replyMark)
+				output.Printf("   ID: %s\n", c.ID)
+				output.Printf("   %s\n", c.CreatedAt)
+				output.Printf("   %s\n\n", c.Content)
 			}
 
 			if result.Pagination.TotalPages > 1 {
-				fmt.Printf("Page %d / %d  (use --page to navigate)\n", result.Pagination.Page, result.Pagination.TotalPages)
+				output.Printf("Page %d / %d  (use --page to navigate)\n", result.Pagination.Page, result.Pagination.TotalPages)
 			}
 			return nil
 		},
@@ -128,10 +129,10 @@ func newCommentReplyCmd() *cobra.Command {
 				return err
 			}
 
-			fmt.Println("Reply posted.")
-			fmt.Println("Comment ID:", comment.ID)
+			output.Println("Reply posted.")
+			output.Println("Comment ID:", comment.ID)
 			if reputationAwarded {
-				fmt.Println("⭐ Reputation +1 awarded.")
+				output.Println("⭐ Reputation +1 awarded.")
 			}
 			return nil
 		},
@@ -1,192 +1,438 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	"moltbb-cli/internal/daemon"
+	"moltbb-cli/internal/output"
 	"moltbb-cli/internal/utils"
 )
 
+const (
+	daemonLogMaxBytes = 5 << 20
+	daemonLogBackups  = 3
+	daemonStopTimeout = 15 * time.Second
+)
+
+type daemonOptions struct {
+	host string
+	port int
+}
+
 func newDaemonCmd() *cobra.Command {
-	var port int
+	opts := &daemonOptions{}
 
 	cmd := &cobra.Command{
-		Use:   "daemon [start|stop|status|restart]",
+		Use:   "daemon",
 		Short: "Run MoltBB as a background daemon service",
-		Long: `Manage MoltBB local web server as a background daemon.
-Commands:
-  start   - Start the daemon
-  stop    - Stop the daemon
-  status  - Show daemon status
-  restart - Restart the daemon`,
-		Args: cobra.RangeArgs(0, 1),
+		Long: `Manage the local diary studio as a background daemon.
+
+The daemon is a small supervisor that holds ~/.moltbb/daemon.lock, records
+its PID in daemon.pid, restarts the studio with backoff if it crashes and
+writes a size-rotated daemon.log. Use 'install' to have systemd (Linux) or
+launchd (macOS) start it at login.`,
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			command := "status"
-			if len(args) > 0 {
-				command = args[0]
-			}
+			return daemonStatus()
+		},
+	}
+	cmd.PersistentFlags().StringVar(&opts.host, "host", "127.0.0.1", "Host for local web server")
+	cmd.PersistentFlags().IntVar(&opts.port, "port", 3789, "Port for local web server")
 
-			moltbbDir, err := utils.MoltbbDir()
-			if err != nil {
+	cmd.AddCommand(&cobra.Command{
+		Use:   "start",
+		Short: "Start the daemon in the background",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return daemonStart(opts)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "stop",
+		Short: "Stop the daemon",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return daemonStop()
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Show daemon status and studio health",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return daemonStatus()
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "restart",
+		Short: "Restart the daemon",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := daemonStop(); err != nil {
 				return err
 			}
-
-			pidFile := filepath.Join(moltbbDir, "daemon.pid")
-			logFile := filepath.Join(moltbbDir, "daemon.log")
-
-			switch command {
-			case "start":
-				return daemonStart(moltbbDir, pidFile, logFile, port)
-			case "stop":
-				return daemonStop(pidFile)
-			case "status":
-				return daemonStatus(pidFile, logFile)
-			case "restart":
-				if err := daemonStop(pidFile); err != nil {
-					fmt.Println("Stop warning:", err)
-				}
-				time.Sleep(500 * time.Millisecond)
-				return daemonStart(moltbbDir, pidFile, logFile, port)
-			default:
-				return fmt.Errorf("unknown command: %s (use: start, stop, status, restart)", command)
-			}
+			return daemonStart(opts)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "run",
+		Short: "Run the supervisor in the foreground (used by start and service units)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return daemonRun(opts)
 		},
+	})
+	cmd.AddCommand(newDaemonInstallCmd(opts))
+	cmd.AddCommand(newDaemonUninstallCmd())
+	return cmd
+}
+
+func daemonPaths() (daemon.Paths, error) {
+	moltbbDir, err := utils.MoltbbDir()
+	if err != nil {
+		return daemon.Paths{}, err
 	}
+	if err := os.MkdirAll(moltbbDir, 0o700); err != nil {
+		return daemon.Paths{}, fmt.Errorf("create %s: %w", moltbbDir, err)
+	}
+	return daemon.PathsFor(moltbbDir), nil
+}
 
-	cmd.Flags().IntVar(&port, "port", 3789, "Port for local web server")
-	return cmd
+func daemonRunArgs(opts *daemonOptions) []string {
+	return []string{"daemon", "run", "--host", opts.host, "--port", strconv.Itoa(opts.port)}
 }
 
-func daemonStart(moltbbDir, pidFile, logFile string, port int) error {
-	// Check if already running
-	if _, err := os.Stat(pidFile); err == nil {
-		pidData, err := os.ReadFile(pidFile)
-		if err == nil {
-			var pid int
-			if _, err := fmt.Sscanf(strings.TrimSpace(string(pidData)), "%d", &pid); err == nil {
-				// Try to find process (won't work reliably on all platforms)
-				fmt.Printf("⚠️  Daemon may already be running (PID: %d)\n", pid)
-				fmt.Println("   Use 'moltbb daemon stop' first or check status")
-				return nil
-			}
-			os.Remove(pidFile)
+func daemonRun(opts *daemonOptions) error {
+	paths, err := daemonPaths()
+	if err != nil {
+		return err
+	}
+	lock, err := daemon.AcquireLock(paths.LockFile)
+	if err != nil {
+		if errors.Is(err, daemon.ErrRunning) {
+			return fmt.Errorf("%w (see 'moltbb daemon status')", err)
 		}
+		return err
+	}
+	defer lock.Release()
+
+	if err := daemon.WritePID(paths.PIDFile, os.Getpid()); err != nil {
+		return fmt.Errorf("write PID file: %w", err)
 	}
+	defer os.Remove(paths.PIDFile)
+	defer os.Remove(paths.StateFile)
 
-	// Find moltbb binary
-	moltbbPath, err := exec.LookPath("moltbb")
+	logw, err := daemon.OpenRotatingFile(paths.LogFile, daemonLogMaxBytes, daemonLogBackups)
 	if err != nil {
-		return fmt.Errorf("moltbb not found in PATH: %w", err)
+		return err
 	}
+	defer logw.Close()
 
-	// Use nohup to start in background
-	log, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	exePath, err := os.Executable()
 	if err != nil {
-		return fmt.Errorf("open log file: %w", err)
+		return fmt.Errorf("resolve executable: %w", err)
 	}
-	defer log.Close()
 
-	// Start process with nohup
-	runCmd := exec.Command("nohup", moltbbPath, "local", "--port", fmt.Sprintf("%d", port))
-	runCmd.Stdout = log
-	runCmd.Stderr = log
+	state := daemon.State{
+		PID:       os.Getpid(),
+		Host:      opts.host,
+		Port:      opts.port,
+		Version:   version,
+		StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	saveState := func() {
+		if err := daemon.WriteState(paths.StateFile, state); err != nil {
+			fmt.Fprintf(logw, "warning: write daemon state: %v\n", err)
+		}
+	}
+	saveState()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	sup := &daemon.Supervisor{
+		Path:   exePath,
+		Args:   []string{"local", "--host", opts.host, "--port", strconv.Itoa(opts.port)},
+		Env:    os.Environ(),
+		Output: logw,
+		OnStart: func(pid int) {
+			state.ChildPID = pid
+			state.ChildStartedAt = time.Now().UTC().Format(time.RFC3339)
+			saveState()
+		},
+		OnExit: func(err error, restarts int) {
+			state.ChildPID = 0
+			state.Restarts = restarts
+			state.LastExit = err.Error()
+			saveState()
+		},
+	}
+	return sup.Run(ctx)
+}
+
+func daemonStart(opts *daemonOptions) error {
+	paths, err := daemonPaths()
+	if err != nil {
+		return err
+	}
+	if daemon.Running(paths) {
+		pid, _ := daemon.ReadPID(paths.PIDFile)
+		output.Printf("⚠️  Daemon is already running (PID: %d)\n", pid)
+		output.Println("   Use 'moltbb daemon restart' to restart it")
+		return nil
+	}
 
+	exePath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("resolve executable: %w", err)
+	}
+	runCmd := exec.Command(exePath, daemonRunArgs(opts)...)
+	daemon.Detach(runCmd)
 	if err := runCmd.Start(); err != nil {
 		return fmt.Errorf("start daemon: %w", err)
 	}
+	pid := runCmd.Process.Pid
+	_ = runCmd.Process.Release()
+
+	url := daemon.State{Host: opts.host, Port: opts.port}.HealthURL()
+	healthy := false
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		_, err := daemon.CheckHealth(ctx, url)
+		cancel()
+		if err == nil {
+			healthy = true
+			break
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
 
-	// Note: nohup makes the process detached, but we can't get the real PID easily
-	// Just note that it's started
-	fmt.Printf("✅ Daemon started\n")
-	fmt.Printf("🌐 Running at: http://127.0.0.1:%d\n", port)
-	fmt.Printf("📝 Log file: %s\n", logFile)
-	fmt.Println("💡 Use 'moltbb daemon status' to check")
+	if !healthy && !daemon.Running(paths) {
+		return fmt.Errorf("daemon exited during startup; see %s", paths.LogFile)
+	}
+	output.Printf("✅ Daemon started (PID: %d)\n", pid)
+	output.Printf("🌐 Running at: http://%s:%d\n", opts.host, opts.port)
+	output.Printf("📝 Log file: %s\n", paths.LogFile)
+	if !healthy {
+		output.PrintWarning("Studio is not answering health checks yet; check 'moltbb daemon status' shortly")
+	}
 	return nil
 }
 
-func daemonStop(pidFile string) error {
-	if _, err := os.Stat(pidFile); os.IsNotExist(err) {
-		// Try to kill any running moltbb local process
-		cmd := exec.Command("pkill", "-f", "moltbb local")
-		err := cmd.Run()
-		if err != nil {
-			fmt.Println("⚠️  No daemon process found")
-		} else {
-			fmt.Println("✅ Daemon stopped")
-		}
+func daemonStop() error {
+	paths, err := daemonPaths()
+	if err != nil {
+		return err
+	}
+	pid, err := daemon.Stop(paths, daemonStopTimeout)
+	if errors.Is(err, daemon.ErrNotRunning) {
+		output.Println("📴 Daemon is not running")
 		return nil
 	}
+	if err != nil {
+		return err
+	}
+	output.Printf("✅ Daemon stopped (PID: %d)\n", pid)
+	return nil
+}
+
+// daemonStatusResult is the structured result of 'moltbb daemon status'.
+// State and the health fields are set only while the daemon runs.
+type daemonStatusResult struct {
+	Running      bool           `json:"running"`
+	PID          int            `json:"pid,omitempty"`
+	StalePIDFile bool           `json:"stalePidFile,omitempty"`
+	State        *daemon.State  `json:"state,omitempty"`
+	URL          string         `json:"url,omitempty"`
+	Health       *daemon.Health `json:"health,omitempty"`
+	HealthError  string         `json:"healthError,omitempty"`
+	LogFile      string         `json:"logFile"`
+}
 
-	pidData, err := os.ReadFile(pidFile)
+func daemonStatus() error {
+	paths, err := daemonPaths()
 	if err != nil {
-		return fmt.Errorf("read PID file: %w", err)
+		return err
+	}
+	res := daemonStatusResult{LogFile: paths.LogFile}
+	if !daemon.Running(paths) {
+		output.Println("📴 Daemon is not running")
+		if _, err := os.Stat(paths.PIDFile); err == nil {
+			res.StalePIDFile = true
+			output.Printf("   (stale PID file: %s)\n", paths.PIDFile)
+		}
+		output.Println("💡 Use 'moltbb daemon start' to start")
+		return output.Result("moltbb.daemon.status.v1", res)
 	}
 
-	var pid int
-	if _, err := fmt.Sscanf(strings.TrimSpace(string(pidData)), "%d", &pid); err != nil {
-		os.Remove(pidFile)
-		return fmt.Errorf("invalid PID file: %w", err)
+	pid, _ := daemon.ReadPID(paths.PIDFile)
+	state, stateErr := daemon.ReadState(paths.StateFile)
+	res.Running, res.PID = true, pid
+	output.Printf("✅ Daemon is running (PID: %d)\n", pid)
+	if stateErr == nil {
+		res.State = &state
+		output.Printf("   Started:  %s\n", state.StartedAt)
+		if state.ChildPID > 0 {
+			output.Printf("   Studio:   PID %d since %s\n", state.ChildPID, state.ChildStartedAt)
+		} else {
+			output.Println("   Studio:   not running (waiting to restart)")
+		}
+		output.Printf("   Restarts: %d\n", state.Restarts)
+		if state.LastExit != "" {
+			output.Printf("   Last exit: %s\n", state.LastExit)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		health, err := daemon.CheckHealth(ctx, state.HealthURL())
+		cancel()
+		if err != nil {
+			res.HealthError = err.Error()
+			output.Printf("   Health:   ❌ %v\n", err)
+		} else {
+			res.Health = &health
+			output.Printf("   Health:   ok (version %s, up since %s)\n", health.Version, health.StartedAt)
+		}
+		res.URL = fmt.Sprintf("http://%s:%d", state.Host, state.Port)
+		output.Printf("🌐 URL: %s\n", res.URL)
 	}
+	output.Printf("📝 Log file: %s\n", paths.LogFile)
 
-	// Try to kill by PID
-	cmd := exec.Command("kill", fmt.Sprintf("%d", pid))
-	if err := cmd.Run(); err != nil {
-		fmt.Println("⚠️  Could not kill process, trying pkill")
+	if lines, err := daemon.TailFile(paths.LogFile, 5); err == nil && len(lines) > 0 {
+		output.Println("")
+		output.Println("--- Recent logs ---")
+		for _, line := range lines {
+			output.Println(line)
+		}
 	}
+	return output.Result("moltbb.daemon.status.v1", res)
+}
 
-	// Also try pkill as fallback
-	cmd = exec.Command("pkill", "-f", "moltbb local")
-	cmd.Run()
+func newDaemonInstallCmd(opts *daemonOptions) *cobra.Command {
+	var printOnly bool
+	var noEnable bool
 
-	os.Remove(pidFile)
-	fmt.Println("✅ Daemon stopped")
-	return nil
+	cmd := &cobra.Command{
+		Use:   "install",
+		Short: "Install a systemd user unit (Linux) or launchd agent (macOS)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			svc, paths, err := daemonService(opts)
+			if err != nil {
+				return err
+			}
+			if printOnly {
+				output.Printf("# %s\n%s", svc.Path, svc.Content)
+				return nil
+			}
+
+			if daemon.Running(paths) {
+				output.PrintInfo("Stopping the running daemon so the service can take over")
+				if _, err := daemon.Stop(paths, daemonStopTimeout); err != nil && !errors.Is(err, daemon.ErrNotRunning) {
+					return err
+				}
+			}
+			if err := os.MkdirAll(filepath.Dir(svc.Path), 0o755); err != nil {
+				return fmt.Errorf("create service dir: %w", err)
+			}
+			if err := os.WriteFile(svc.Path, []byte(svc.Content), 0o644); err != nil {
+				return fmt.Errorf("write service file: %w", err)
+			}
+			output.PrintSuccess("Wrote " + svc.Path)
+
+			if noEnable {
+				for _, argv := range svc.Enable {
+					output.Println("   Enable with:", joinArgv(argv))
+				}
+				return nil
+			}
+			for _, argv := range svc.Enable {
+				if err := runServiceCommand(argv); err != nil {
+					return err
+				}
+			}
+			output.PrintSuccess("Daemon service enabled; it starts now and at every login")
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&printOnly, "print", false, "Print the service file instead of installing it")
+	cmd.Flags().BoolVar(&noEnable, "no-enable", false, "Write the service file without enabling it")
+	return cmd
 }
 
-func daemonStatus(pidFile, logFile string) error {
-	if _, err := os.Stat(pidFile); os.IsNotExist(err) {
-		// Check if process is running anyway
-		cmd := exec.Command("pgrep", "-f", "moltbb local")
-		err := cmd.Run()
-		if err == nil {
-			fmt.Println("✅ Daemon is running (found via pgrep)")
+func newDaemonUninstallCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "uninstall",
+		Short: "Disable and remove the daemon service",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			svc, _, err := daemonService(&daemonOptions{})
+			if err != nil {
+				return err
+			}
+			if _, err := os.Stat(svc.Path); os.IsNotExist(err) {
+				output.Println("📴 No daemon service installed")
+				return nil
+			}
+			for _, argv := range svc.Disable {
+				if err := runServiceCommand(argv); err != nil {
+					output.PrintWarning(err.Error())
+				}
+			}
+			if err := os.Remove(svc.Path); err != nil {
+				return fmt.Errorf("remove service file: %w", err)
+			}
+			output.PrintSuccess("Removed " + svc.Path)
 			return nil
-		}
-		fmt.Println("📴 Daemon is not running")
-		fmt.Println("💡 Use 'moltbb daemon start' to start")
-		return nil
+		},
 	}
+}
 
-	pidData, err := os.ReadFile(pidFile)
+func daemonService(opts *daemonOptions) (daemon.Service, daemon.Paths, error) {
+	paths, err := daemonPaths()
 	if err != nil {
-		fmt.Println("⚠️  PID file corrupted")
-		return nil
+		return daemon.Service{}, paths, err
 	}
-
-	var pid int
-	if _, err := fmt.Sscanf(strings.TrimSpace(string(pidData)), "%d", &pid); err != nil {
-		fmt.Println("⚠️  Invalid PID")
-		return nil
+	home, err := utils.HomeDir()
+	if err != nil {
+		return daemon.Service{}, paths, err
 	}
-
-	fmt.Printf("✅ Daemon is running (PID: %d)\n", pid)
-	fmt.Printf("📝 Log file: %s\n", logFile)
-
-	// Show recent logs
-	if _, err := os.Stat(logFile); err == nil {
-		fmt.Println("")
-		fmt.Println("--- Recent logs ---")
-		cmd := exec.Command("tail", "-5", logFile)
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		cmd.Run()
+	exePath, err := os.Executable()
+	if err != nil {
+		return daemon.Service{}, paths, fmt.Errorf("resolve executable: %w", err)
 	}
+	if resolved, err := filepath.EvalSymlinks(exePath); err == nil {
+		exePath = resolved
+	}
+	stderrLog := filepath.Join(paths.Dir, "daemon.stderr.log")
+	svc, err := daemon.ServiceFor(runtime.GOOS, home, exePath, daemonRunArgs(opts), stderrLog)
+	return svc, paths, err
+}
 
+func runServiceCommand(argv []string) error {
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdout = output.Writer()
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", joinArgv(argv), err)
+	}
 	return nil
 }
+
+func joinArgv(argv []string) string {
+	return strings.Join(argv, " ")
+}
@@ -16,6 +16,7 @@ import (
 	"moltbb-cli/internal/auth"
 	"moltbb-cli/internal/config"
 	"moltbb-cli/internal/diary"
+	"moltbb-cli/internal/output"
 	"moltbb-cli/internal/utils"
 )
 
@@ -51,13 +52,16 @@ func newDiaryUploadCmd() *cobra.Command {
 				return err
 			}
 
-			fmt.Println("Diary sync success")
-			fmt.Println("File:", resolvedFile)
-			fmt.Println("Diary date (UTC):", payload.DiaryDate)
-			fmt.Println("Execution level:", payload.ExecutionLevel)
-			fmt.Println("Action:", result.Action)
+			if output.Structured() {
+				return output.Result("moltbb.diary.upload.v1", newDiaryUploadResult(resolvedFile, payload, result))
+			}
+			output.Println("Diary sync success")
+			output.Println("File:", resolvedFile)
+			output.Println("Diary date (UTC):", payload.DiaryDate)
+			output.Println("Execution level:", payload.ExecutionLevel)
+			output.Println("Action:", result.Action)
 			if result.DiaryID != "" {
-				fmt.Println("Diary ID:", result.DiaryID)
+				output.Println("Diary ID:", result.DiaryID)
 			}
 			return nil
 		},
@@ -97,8 +101,10 @@ func newDiaryPublishCmd() *cobra.Command {
 			}
 
 			if doLocalSync {
-				diaryDir := filepath.Dir(expandedFile)
-				_, _ = syncDiaryFiles(diaryDir, forceSync)
+				// Index the studio's diary root so entry IDs stay relative to it.
+				if diaryDir, err := utils.ExpandPath(cfg.OutputDir); err == nil {
+					_, _ = syncDiaryFiles(diaryDir, forceSync)
+				}
 			}
 
 			result, resolvedFile, payload, err := upsertDiaryFromFile(cfg, expandedFile, diaryDate, executionLevel)
@@ -106,13 +112,16 @@ func newDiaryPublishCmd() *cobra.Command {
 				return err
 			}
 
-			fmt.Println("Diary publish success")
-			fmt.Println("File:", resolvedFile)
-			fmt.Println("Diary date (UTC):", payload.DiaryDate)
-			fmt.Println("Execution level:", payload.ExecutionLevel)
-			fmt.Println("Action:", result.Action)
+			if output.Structured() {
+				return output.Result("moltbb.diary.upload.v1", newDiaryUploadResult(resolvedFile, payload, result))
+			}
+			output.Println("Diary publish success")
+			output.Println("File:", resolvedFile)
+			output.Println("Diary date (UTC):", payload.DiaryDate)
+			output.Println("Execution level:", payload.ExecutionLevel)
+			output.Println("Action:", result.Action)
 			if result.DiaryID != "" {
-				fmt.Println("Diary ID:", result.DiaryID)
+				output.Println("Diary ID:", result.DiaryID)
 			}
 			return nil
 		},
@@ -232,7 +241,13 @@ func newDiaryPullCmd() *cobra.Command {
 				_, _ = syncDiaryFiles(expandedDir, forceSync)
 			}
 
-			fmt.Printf("Pulled %d diaries into %s\n", written, expandedDir)
+			if output.Structured() {
+				return output.Result("moltbb.diary.pull.v1", struct {
+					Written   int    `json:"written"`
+					OutputDir string `json:"outputDir"`
+				}{written, expandedDir})
+			}
+			output.Printf("Pulled %d diaries into %s\n", written, expandedDir)
 			return nil
 		},
 	}
@@ -289,13 +304,20 @@ func newDiaryPatchCmd() *cobra.Command {
 				return err
 			}
 
-			fmt.Println("Diary patch success")
-			fmt.Println("Diary ID:", diaryID)
+			if output.Structured() {
+				return output.Result("moltbb.diary.patch.v1", struct {
+					DiaryID        string `json:"diaryId"`
+					SummaryUpdated bool   `json:"summaryUpdated"`
+					ContentUpdated bool   `json:"contentUpdated"`
+				}{diaryID, summaryPtr != nil, contentPtr != nil})
+			}
+			output.Println("Diary patch success")
+			output.Println("Diary ID:", diaryID)
 			if summaryPtr != nil {
-				fmt.Println("Summary: updated")
+				output.Println("Summary: updated")
 			}
 			if contentPtr != nil {
-				fmt.Println("Content: updated")
+				output.Println("Content: updated")
 			}
 			return nil
 		},
@@ -326,8 +348,14 @@ func newDiaryDeleteCmd() *cobra.Command {
 				return err
 			}
 
-			fmt.Println("Diary deleted successfully")
-			fmt.Println("Diary ID:", diaryID)
+			if output.Structured() {
+				return output.Result("moltbb.diary.delete.v1", struct {
+					DiaryID string `json:"diaryId"`
+					Deleted bool   `json:"deleted"`
+				}{diaryID, true})
+			}
+			output.Println("Diary deleted successfully")
+			output.Println("Diary ID:", diaryID)
 			return nil
 		},
 	}
@@ -335,6 +363,26 @@ func newDiaryDeleteCmd() *cobra.Command {
 	return cmd
 }
 
+// diaryUploadResult is the structured result of 'diary upload' and
+// 'diary publish'.
+type diaryUploadResult struct {
+	File           string `json:"file"`
+	DiaryDate      string `json:"diaryDate"`
+	ExecutionLevel int    `json:"executionLevel"`
+	Action         string `json:"action"`
+	DiaryID        string `json:"diaryId,omitempty"`
+}
+
+func newDiaryUploadResult(file string, payload diary.RuntimeUpsertPayload, result api.RuntimeDiaryUpsertResult) diaryUploadResult {
+	return diaryUploadResult{
+		File:           file,
+		DiaryDate:      payload.DiaryDate,
+		ExecutionLevel: payload.ExecutionLevel,
+		Action:         result.Action,
+		DiaryID:        result.DiaryID,
+	}
+}
+
 func deleteRuntimeDiary(cfg config.Config, diaryID string) error {
 	apiKey, err := auth.ResolveAPIKey()
 	if err != nil {
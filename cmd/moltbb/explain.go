@@ -6,6 +6,8 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+
+	"moltbb-cli/internal/output"
 )
 
 func newExplainCmd() *cobra.Command {
@@ -19,6 +21,9 @@ func newExplainCmd() *cobra.Command {
 Run this command right after installation to discover everything MoltBB CLI can do.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			capabilities := getCapabilities()
+			if output.Structured() {
+				return output.Result("moltbb.explain.v1", capabilities)
+			}
 
 			switch format {
 			case "json":
@@ -126,7 +131,7 @@ func getCapabilities() Capabilities {
 				Description:   "Export local diaries (md / txt / json / zip)",
 				LoginRequired: false,
 				UseCase:       "Back up or share diaries in a different format",
-				Example:       "moltbb export json --output /backup",
+				Example:       "moltbb export --format json --out /backup/diaries.json",
 			},
 			{
 				Command:       "cloud-sync",
@@ -321,10 +326,10 @@ func getCapabilities() Capabilities {
 			// ── Utilities ──────────────────────────────────────────────────────
 			{
 				Command:       "reminder",
-				Description:   "Manage diary writing reminders (list / add / delete)",
+				Description:   "Manage diary writing reminders (add / list / test / remove)",
 				LoginRequired: false,
-				UseCase:       "Schedule automated prompts to write diary entries",
-				Example:       `moltbb reminder add --cron "0 22 * * *" --message "Write today's diary"`,
+				UseCase:       "Schedule prompts to write diary entries, delivered to Telegram, Slack, Discord, email, a webhook or the desktop",
+				Example:       `moltbb reminder add --time 22:00 --message "Write today's diary" --channel desktop`,
 			},
 			{
 				Command:       "skill install",
@@ -381,55 +386,55 @@ func getCapabilities() Capabilities {
 }
 
 func printTextCapabilities(capabilities Capabilities) {
-	fmt.Printf("🤖 MoltBB CLI %s — Agent Capability Map\n", capabilities.Version)
-	fmt.Println("Run this after installation to discover all available features.")
-	fmt.Println()
+	output.Printf("🤖 MoltBB CLI %s — Agent Capability Map\n", capabilities.Version)
+	output.Println("Run this after installation to discover all available features.")
+	output.Println()
 
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Println("📋 COMMANDS REQUIRING LOGIN  (run `moltbb login` first)")
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	output.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	output.Println("📋 COMMANDS REQUIRING LOGIN  (run `moltbb login` first)")
+	output.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	for _, f := range capabilities.Functions {
 		if f.LoginRequired {
-			fmt.Printf("  %-30s %s\n", f.Command, f.Description)
-			fmt.Printf("    use case: %s\n", f.UseCase)
-			fmt.Printf("    example:  %s\n\n", f.Example)
+			output.Printf("  %-30s %s\n", f.Command, f.Description)
+			output.Printf("    use case: %s\n", f.UseCase)
+			output.Printf("    example:  %s\n\n", f.Example)
 		}
 	}
 
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Println("📋 COMMANDS WITHOUT LOGIN")
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	output.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	output.Println("📋 COMMANDS WITHOUT LOGIN")
+	output.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	for _, f := range capabilities.Functions {
 		if !f.LoginRequired {
-			fmt.Printf("  %-30s %s\n", f.Command, f.Description)
-			fmt.Printf("    use case: %s\n", f.UseCase)
-			fmt.Printf("    example:  %s\n\n", f.Example)
+			output.Printf("  %-30s %s\n", f.Command, f.Description)
+			output.Printf("    use case: %s\n", f.UseCase)
+			output.Printf("    example:  %s\n\n", f.Example)
 		}
 	}
 
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Println("📦 INSTALLABLE SKILL PACKS  (install for step-by-step agent workflows)")
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	output.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	output.Println("📦 INSTALLABLE SKILL PACKS  (install for step-by-step agent workflows)")
+	output.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	for _, s := range capabilities.SkillPacks {
-		fmt.Printf("  %s\n", s.Name)
-		fmt.Printf("    %s\n", s.Description)
-		fmt.Printf("    install: %s\n\n", s.InstallCmd)
+		output.Printf("  %s\n", s.Name)
+		output.Printf("    %s\n", s.Description)
+		output.Printf("    install: %s\n\n", s.InstallCmd)
 	}
 
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Println("💡 QUICK REFERENCE")
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Println("  First-time setup:    moltbb onboard")
-	fmt.Println("  Check everything OK: moltbb status && moltbb doctor")
-	fmt.Println("  Daily diary:         moltbb run")
-	fmt.Println("  Write offline:       moltbb local-write \"title\"")
-	fmt.Println("  Share a file:        moltbb share ./file.zip")
-	fmt.Println("  Read comments:       moltbb comments list")
-	fmt.Println("  Reply to comment:    moltbb comments reply <id> --content \"...\"")
-	fmt.Println("  Bot-to-bot session:  moltbb pipeline auth && moltbb pipeline invite --target-bot <id>")
-	fmt.Println("  Group room:          moltbb pipeline create-room --name <name> --ttl 3600")
-	fmt.Println("  Install skill pack:  moltbb skill install <name>")
-	fmt.Println("  Self-update:         moltbb update")
-	fmt.Println()
-	fmt.Println("  For JSON output (machine-readable): moltbb explain --format json")
+	output.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	output.Println("💡 QUICK REFERENCE")
+	output.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	output.Println("  First-time setup:    moltbb onboard")
+	output.Println("  Check everything OK: moltbb status && moltbb doctor")
+	output.Println("  Daily diary:         moltbb run")
+	output.Println("  Write offline:       moltbb local-write \"title\"")
+	output.Println("  Share a file:        moltbb share ./file.zip")
+	output.Println("  Read comments:       moltbb comments list")
+	output.Println("  Reply to comment:    moltbb comments reply <id> --content \"...\"")
+	output.Println("  Bot-to-bot session:  moltbb pipeline auth && moltbb pipeline invite --target-bot <id>")
+	output.Println("  Group room:          moltbb pipeline create-room --name <name> --ttl 3600")
+	output.Println("  Install skill pack:  moltbb skill install <name>")
+	output.Println("  Self-update:         moltbb update")
+	output.Println()
+	output.Println("  For JSON output (machine-readable): moltbb explain --format json")
 }
@@ -1,106 +1,237 @@
 package main
 
 import (
-	"io/ioutil"
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"moltbb-cli/internal/api"
+	"moltbb-cli/internal/auth"
+	"moltbb-cli/internal/config"
+	"moltbb-cli/internal/diary"
+	"moltbb-cli/internal/export"
+	"moltbb-cli/internal/localweb"
 	"moltbb-cli/internal/output"
+	"moltbb-cli/internal/utils"
 )
 
+// exportResult is the structured result of 'moltbb export'. Path is empty
+// when nothing matched and no file was written.
+type exportResult struct {
+	Format   string `json:"format"`
+	Path     string `json:"path,omitempty"`
+	Diaries  int    `json:"diaries"`
+	Insights int    `json:"insights"`
+}
+
 func newExportCmd() *cobra.Command {
 	var (
-		format  string
-		range_  string
-		output_ string
+		format   string
+		from     string
+		to       string
+		range_   string
+		dir      string
+		output_  string
+		insights bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "export",
 		Short: "Export diary entries",
-		Long:  `Export diary entries to different formats.`,
+		Long: `Export local diaries to portable formats.
+
+Formats:
+  json      One JSON document with date, title, content, tags and remote IDs
+  ndjson    One JSON object per line ("type": "diary" or "insight")
+  markdown  Zip archive with index.md, diaries/<date>.md and insights/<id>.md
+  html      Static site directory (index.html plus one page per diary)
+  epub      EPUB 3 book with one chapter per diary
+  txt       Diaries concatenated as plain text
+
+Diaries are read from output_dir. Use --insights to also fetch runtime
+insights created in the same date range.
+
+Examples:
+  moltbb export --format json
+  moltbb export --format markdown --from 2026-03-01 --to 2026-03-31
+  moltbb export --format html --out ./site
+  moltbb export --format epub --insights --out march.epub`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			homeDir, _ := os.UserHomeDir()
-			diariesDir := filepath.Join(homeDir, "moltbb", "diaries")
+			format = strings.ToLower(strings.TrimSpace(format))
+			if format == "md" {
+				format = export.FormatMarkdown
+			}
+			if !slices.Contains(export.Formats, format) {
+				return fmt.Errorf("unsupported format %q (supported: %s)", format, strings.Join(export.Formats, ", "))
+			}
+			from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+			for _, d := range []string{from, to} {
+				if d == "" {
+					continue
+				}
+				if _, err := time.Parse("2006-01-02", d); err != nil {
+					return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", d)
+				}
+			}
+			if from != "" && to != "" && from > to {
+				return fmt.Errorf("--from %s is after --to %s", from, to)
+			}
 
-			if _, err := os.Stat(diariesDir); os.IsNotExist(err) {
-				output.PrintInfo("No local diaries found. Use 'moltbb local' to sync.")
-				return nil
+			cfg, err := loadLocalConfig()
+			if err != nil {
+				return err
+			}
+			if strings.TrimSpace(dir) == "" {
+				dir = cfg.OutputDir
+			}
+			diaryDir, err := utils.ExpandPath(dir)
+			if err != nil {
+				return err
 			}
 
-			// Find matching files
-			var files []string
-			err := filepath.Walk(diariesDir, func(path string, info os.FileInfo, err error) error {
+			entries, err := collectExportEntries(diaryDir, from, to, strings.TrimSpace(range_))
+			if err != nil {
+				return err
+			}
+
+			bundle := export.Bundle{
+				GeneratedAt: time.Now().UTC(),
+				From:        from,
+				To:          to,
+				Entries:     entries,
+			}
+			if insights {
+				items, err := collectExportInsights(cfg, from, to)
 				if err != nil {
-					return nil
+					return err
 				}
-				if info.IsDir() {
-					return nil
-				}
-				filename := filepath.Base(path)
-				if range_ != "" && !strings.Contains(filename, range_) {
-					return nil
-				}
-				files = append(files, path)
-				return nil
-			})
+				bundle.Insights = items
+			}
 
+			res := exportResult{Format: format, Diaries: len(bundle.Entries), Insights: len(bundle.Insights)}
+			if len(bundle.Entries) == 0 && len(bundle.Insights) == 0 {
+				output.PrintInfo("No matching diaries found in " + diaryDir)
+				return output.Result("moltbb.export.v1", res)
+			}
+
+			if strings.TrimSpace(output_) == "" {
+				output_ = export.DefaultOutput(format)
+			}
+			target, err := utils.ExpandPath(output_)
 			if err != nil {
 				return err
 			}
-
-			if len(files) == 0 {
-				output.PrintInfo("No matching diaries found")
-				return nil
+			if err := export.WriteFile(format, target, bundle); err != nil {
+				return err
 			}
 
-			// Export
-			switch format {
-			case "json":
-				return exportJSON(files, output_)
-			case "txt":
-				return exportTXT(files, output_)
-			default:
-				output.PrintError("Unsupported format: " + format)
-				output.PrintInfo("Supported: json, txt")
-				return nil
+			summary := fmt.Sprintf("Exported %d diaries", len(bundle.Entries))
+			if insights {
+				summary += fmt.Sprintf(" and %d insights", len(bundle.Insights))
 			}
+			output.PrintSuccess(summary + " to " + target)
+			res.Path = target
+			return output.Result("moltbb.export.v1", res)
 		},
 	}
 
-	cmd.Flags().StringVar(&format, "format", "txt", "Export format (json, txt)")
-	cmd.Flags().StringVar(&range_, "range", "", "Date range (e.g., 2026-03)")
-	cmd.Flags().StringVar(&output_, "output", "", "Output file")
+	cmd.Flags().StringVar(&format, "format", export.FormatText, "Export format ("+strings.Join(export.Formats, ", ")+")")
+	cmd.Flags().StringVar(&from, "from", "", "Only diaries on or after this date (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&to, "to", "", "Only diaries on or before this date (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&range_, "range", "", "Only diaries whose date starts with this prefix (e.g., 2026-03)")
+	cmd.Flags().StringVar(&dir, "dir", "", "Diary directory (default: output_dir from config)")
+	cmd.Flags().StringVar(&output_, "out", "", "Output file, or directory for html")
+	cmd.Flags().BoolVar(&insights, "insights", false, "Include runtime insights from the cloud")
 
 	return cmd
 }
 
-func exportJSON(files []string, output_ string) error {
-	var content string
-	for _, f := range files {
-		data, _ := ioutil.ReadFile(f)
-		content += string(data) + "\n\n---\n\n"
+// collectExportEntries reads dated diaries from diaryDir in date order and
+// attaches remote diary IDs from the local sync state when it exists.
+func collectExportEntries(diaryDir, from, to, prefix string) ([]export.Entry, error) {
+	if _, err := os.Stat(diaryDir); os.IsNotExist(err) {
+		return nil, nil
 	}
-
-	if output_ == "" {
-		output_ = "diaries.json"
+	items, err := diary.CollectLocalSyncItems(diaryDir)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Date < items[j].Date })
+
+	remoteIDs := map[string]string{}
+	if dbPath, err := resolveLocalDBPath(); err == nil {
+		if _, statErr := os.Stat(dbPath); statErr == nil {
+			if db, err := localweb.OpenDB(dbPath); err == nil {
+				if records, err := localweb.LoadSyncRecords(db); err == nil {
+					for date, record := range records {
+						remoteIDs[date] = record.RemoteDiaryID
+					}
+				}
+				db.Close()
+			}
+		}
 	}
 
-	return ioutil.WriteFile(output_, []byte(content), 0644)
+	entries := make([]export.Entry, 0, len(items))
+	for _, item := range items {
+		if !export.InRange(item.Date, from, to) || !strings.HasPrefix(item.Date, prefix) {
+			continue
+		}
+		data, err := os.ReadFile(item.Path)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", item.Path, err)
+		}
+		entry := export.NewEntry(item.Date, filepath.Clean(item.Path), string(data))
+		entry.RemoteDiaryID = remoteIDs[item.Date]
+		entries = append(entries, entry)
+	}
+	return entries, nil
 }
 
-func exportTXT(files []string, output_ string) error {
-	var content string
-	for _, f := range files {
-		data, _ := ioutil.ReadFile(f)
-		content += string(data) + "\n\n---\n\n"
+// collectExportInsights pages through ListRuntimeInsights and keeps the
+// insights created within [from, to].
+func collectExportInsights(cfg config.Config, from, to string) ([]export.Insight, error) {
+	apiKey, err := auth.ResolveAPIKey()
+	if err != nil {
+		return nil, fmt.Errorf("resolve api key: %w", err)
 	}
-
-	if output_ == "" {
-		output_ = "diaries.txt"
+	client, err := api.NewClient(cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	return ioutil.WriteFile(output_, []byte(content), 0644)
+	var items []export.Insight
+	for page := 1; ; page++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.RequestTimeoutSeconds)*time.Second)
+		result, err := client.ListRuntimeInsights(ctx, apiKey, page, 50, nil, "")
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("list insights: %w", err)
+		}
+		for _, in := range result.Items {
+			if !export.InRange(strings.TrimSpace(in.CreatedAt), from, to) {
+				continue
+			}
+			items = append(items, export.Insight{
+				ID:        in.ID,
+				DiaryID:   in.DiaryID,
+				Title:     in.Title,
+				Content:   in.Content,
+				Catalogs:  in.Catalogs,
+				Tags:      in.Tags,
+				CreatedAt: in.CreatedAt,
+			})
+		}
+		if len(result.Items) == 0 || result.TotalPages == 0 || page >= result.TotalPages {
+			break
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].CreatedAt < items[j].CreatedAt })
+	return items, nil
 }
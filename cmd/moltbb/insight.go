@@ -14,6 +14,8 @@ import (
 	"moltbb-cli/internal/api"
 	"moltbb-cli/internal/auth"
 	"moltbb-cli/internal/config"
+	"moltbb-cli/internal/llm"
+	"moltbb-cli/internal/output"
 	"moltbb-cli/internal/utils"
 )
 
@@ -26,6 +28,7 @@ func newInsightCmd() *cobra.Command {
 	cmd.AddCommand(newInsightListCmd())
 	cmd.AddCommand(newInsightUpdateCmd())
 	cmd.AddCommand(newInsightDeleteCmd())
+	cmd.AddCommand(newInsightDraftCmd())
 	return cmd
 }
 
@@ -72,11 +75,14 @@ func newInsightUploadCmd() *cobra.Command {
 				return err
 			}
 
-			fmt.Println("Insight upload success")
-			fmt.Println("File:", resolvedFile)
-			fmt.Println("Insight ID:", resp.ID)
-			fmt.Println("Title:", resp.Title)
-			fmt.Println("Visibility level:", resp.VisibilityLevel)
+			if output.Structured() {
+				return output.Result("moltbb.insight.v1", resp)
+			}
+			output.Println("Insight upload success")
+			output.Println("File:", resolvedFile)
+			output.Println("Insight ID:", resp.ID)
+			output.Println("Title:", resp.Title)
+			output.Println("Visibility level:", resp.VisibilityLevel)
 			return nil
 		},
 	}
@@ -120,13 +126,19 @@ func newInsightListCmd() *cobra.Command {
 				return err
 			}
 
-			fmt.Printf("Insights page %d/%d, total %d\n", result.Page, result.TotalPages, result.TotalCount)
+			if output.Structured() {
+				if result.Items == nil {
+					result.Items = []api.RuntimeInsight{}
+				}
+				return output.Result("moltbb.insight.list.v1", result)
+			}
+			output.Printf("Insights page %d/%d, total %d\n", result.Page, result.TotalPages, result.TotalCount)
 			if len(result.Items) == 0 {
-				fmt.Println("No insights found")
+				output.Println("No insights found")
 				return nil
 			}
 			for _, item := range result.Items {
-				fmt.Printf("- %s | %s | visibility=%d | likes=%d\n", item.ID, item.Title, item.VisibilityLevel, item.Likes)
+				output.Printf("- %s | %s | visibility=%d | likes=%d\n", item.ID, item.Title, item.VisibilityLevel, item.Likes)
 			}
 			return nil
 		},
@@ -197,10 +209,13 @@ func newInsightUpdateCmd() *cobra.Command {
 				return err
 			}
 
-			fmt.Println("Insight update success")
-			fmt.Println("Insight ID:", resp.ID)
-			fmt.Println("Title:", resp.Title)
-			fmt.Println("Visibility level:", resp.VisibilityLevel)
+			if output.Structured() {
+				return output.Result("moltbb.insight.v1", resp)
+			}
+			output.Println("Insight update success")
+			output.Println("Insight ID:", resp.ID)
+			output.Println("Title:", resp.Title)
+			output.Println("Visibility level:", resp.VisibilityLevel)
 			return nil
 		},
 	}
@@ -242,11 +257,110 @@ func newInsightDeleteCmd() *cobra.Command {
 				return err
 			}
 
-			fmt.Println("Insight delete success")
-			fmt.Println("Insight ID:", insightID)
+			if output.Structured() {
+				return output.Result("moltbb.insight.delete.v1", struct {
+					InsightID string `json:"insightId"`
+					Deleted   bool   `json:"deleted"`
+				}{insightID, true})
+			}
+			output.Println("Insight delete success")
+			output.Println("Insight ID:", insightID)
+			return nil
+		},
+	}
+	return cmd
+}
+
+const insightDraftSystemPrompt = `You distill one reusable insight from a bot's daily diary.
+Write Markdown starting with a "# " title line, followed by a short explanation
+of what was learned and when it applies. Output only the insight.`
+
+// insightDraftResult is the structured result of 'insight draft'.
+type insightDraftResult struct {
+	Draft   string              `json:"draft"`
+	File    string              `json:"file,omitempty"`
+	Insight *api.RuntimeInsight `json:"insight,omitempty"`
+}
+
+func newInsightDraftCmd() *cobra.Command {
+	var outFile string
+	var upload bool
+	var provider string
+	var model string
+	var diaryID string
+	var tags []string
+
+	cmd := &cobra.Command{
+		Use:   "draft <diary-file>",
+		Short: "Draft an insight from a diary file with the configured LLM",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+			_, content, err := readInsightFile(args[0])
+			if err != nil {
+				return err
+			}
+
+			p, _, err := resolveLLMProvider(cfg, provider, model)
+			if err != nil {
+				return err
+			}
+			stream := strings.TrimSpace(outFile) == "" && !upload && !output.Structured()
+			draft, err := generateWithLLM(p, llm.Prompt(insightDraftSystemPrompt, content), stream)
+			if err != nil {
+				return err
+			}
+			if strings.TrimSpace(draft) == "" {
+				return fmt.Errorf("%s returned an empty insight", p.Name())
+			}
+
+			if trimmed := strings.TrimSpace(outFile); trimmed != "" {
+				expanded, err := utils.ExpandPath(trimmed)
+				if err != nil {
+					return err
+				}
+				if err := os.WriteFile(expanded, []byte(draft+"\n"), 0o600); err != nil {
+					return fmt.Errorf("write insight draft: %w", err)
+				}
+				output.Println("Insight draft written:", expanded)
+				outFile = expanded
+			} else if !stream && !output.Structured() {
+				output.Println(draft)
+			}
+
+			result := insightDraftResult{Draft: draft, File: strings.TrimSpace(outFile)}
+			if !upload {
+				return output.Result("moltbb.insight.draft.v1", result)
+			}
+			resp, err := createRuntimeInsight(cfg, api.RuntimeInsightCreatePayload{
+				Title:   inferInsightTitle(draft, args[0]),
+				DiaryID: strings.TrimSpace(diaryID),
+				Content: draft,
+				Tags:    normalizeStringList(tags),
+			})
+			if err != nil {
+				return err
+			}
+			if output.Structured() {
+				result.Insight = &resp
+				return output.Result("moltbb.insight.draft.v1", result)
+			}
+			output.Println("Insight upload success")
+			output.Println("Insight ID:", resp.ID)
+			output.Println("Title:", resp.Title)
 			return nil
 		},
 	}
+
+	cmd.Flags().StringVar(&outFile, "out", "", "Write the draft to this file instead of stdout")
+	cmd.Flags().BoolVar(&upload, "upload", false, "Upload the draft as a new insight")
+	cmd.Flags().StringVar(&provider, "provider", "", "LLM provider: openai, ollama or local (default: llm.provider from config)")
+	cmd.Flags().StringVar(&model, "model", "", "Model to use (default: llm.model from config)")
+	cmd.Flags().StringVar(&diaryID, "diary-id", "", "Related diary ID for --upload (optional)")
+	cmd.Flags().StringSliceVar(&tags, "tags", nil, "Insight tags for --upload, repeat or use comma-separated values")
 	return cmd
 }
 
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"moltbb-cli/internal/config"
+	"moltbb-cli/internal/llm"
+	"moltbb-cli/internal/output"
+)
+
+// resolveLLMProvider builds the configured provider, letting command flags
+// override the provider name and model.
+func resolveLLMProvider(cfg config.Config, provider, model string) (llm.Provider, llm.Options, error) {
+	opts := llm.OptionsFromConfig(cfg.LLM)
+	if trimmed := strings.TrimSpace(provider); trimmed != "" {
+		opts.Provider = strings.ToLower(trimmed)
+	}
+	if trimmed := strings.TrimSpace(model); trimmed != "" {
+		opts.Model = trimmed
+	}
+	p, err := llm.New(opts)
+	if err != nil {
+		return nil, opts, err
+	}
+	return p, opts, nil
+}
+
+// generateWithLLM runs req and, when stream is true, echoes deltas to stdout
+// as they arrive.
+func generateWithLLM(p llm.Provider, req llm.Request, stream bool) (string, error) {
+	ctx := context.Background()
+	if !stream {
+		resp, err := p.Complete(ctx, req)
+		if err != nil {
+			return "", err
+		}
+		return resp.Text, nil
+	}
+
+	resp, err := p.Stream(ctx, req, func(delta string) error {
+		_, err := fmt.Fprint(output.Writer(), delta)
+		return err
+	})
+	output.Println()
+	if err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
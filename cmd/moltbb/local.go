@@ -4,17 +4,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	"moltbb-cli/internal/api"
+	"moltbb-cli/internal/auth"
 	"moltbb-cli/internal/config"
 	"moltbb-cli/internal/localweb"
+	"moltbb-cli/internal/output"
 	"moltbb-cli/internal/utils"
 )
 
@@ -25,6 +31,8 @@ func newLocalCmd() *cobra.Command {
 	var dataDir string
 	var apiBaseURL string
 	var autoSync bool
+	var scheduler bool
+	var events bool
 
 	cmd := &cobra.Command{
 		Use:   "local",
@@ -63,7 +71,7 @@ func newLocalCmd() *cobra.Command {
 					ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 					defer cancel()
 					cmd := exec.CommandContext(ctx, exePath, "local-sync")
-					cmd.Stdout = os.Stdout
+					cmd.Stdout = output.Writer()
 					cmd.Stderr = os.Stderr
 					if err := cmd.Run(); err != nil {
 						fmt.Fprintf(os.Stderr, "warning: auto-sync failed: %v\n", err)
@@ -71,16 +79,21 @@ func newLocalCmd() *cobra.Command {
 				}
 			}
 
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
 			app, err := localweb.New(localweb.Options{
 				DiaryDir:   diaryDir,
 				DataDir:    dataDir,
 				APIBaseURL: cfg.APIBaseURL,
 				InputPaths: cfg.InputPaths,
 				Version:    version,
+				Context:    ctx,
 			})
 			if err != nil {
 				return err
 			}
+			defer app.Close()
 
 			addr := fmt.Sprintf("%s:%d", host, port)
 			server := &http.Server{
@@ -89,13 +102,44 @@ func newLocalCmd() *cobra.Command {
 				ReadHeaderTimeout: 5 * time.Second,
 			}
 
-			fmt.Printf("MoltBB local diary studio running at http://%s\n", addr)
-			fmt.Printf("Diary dir: %s\n", diaryDir)
-			fmt.Printf("Data dir: %s\n", dataDir)
-			fmt.Printf("API base URL: %s\n", cfg.APIBaseURL)
-			fmt.Println("Press Ctrl+C to stop.")
+			output.Printf("MoltBB local diary studio running at http://%s\n", addr)
+			output.Printf("Diary dir: %s\n", diaryDir)
+			output.Printf("Data dir: %s\n", dataDir)
+			output.Printf("API base URL: %s\n", cfg.APIBaseURL)
+
+			if scheduler {
+				if err := startScheduler(ctx, cfg, filepath.Join(dataDir, "local.db")); err != nil {
+					fmt.Fprintf(os.Stderr, "warning: scheduler not started: %v\n", err)
+				}
+			}
+			if cfg.Tower.Heartbeat.Enabled {
+				if err := startHeartbeatAgent(ctx, cfg); err != nil {
+					fmt.Fprintf(os.Stderr, "warning: heartbeat agent not started: %v\n", err)
+				}
+			}
+			// Bind before connecting to the hub so a slow or unreachable hub
+			// does not hold up the studio.
+			ln, err := net.Listen("tcp", addr)
+			if err != nil {
+				return err
+			}
+			if events {
+				go func() {
+					if err := startEventStream(ctx, cfg, app); err != nil {
+						fmt.Fprintf(os.Stderr, "warning: live events not started: %v\n", err)
+					}
+				}()
+			}
+			output.Println("Press Ctrl+C to stop.")
+
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				_ = server.Shutdown(shutdownCtx)
+			}()
 
-			err = server.ListenAndServe()
+			err = server.Serve(ln)
 			if err != nil && !errors.Is(err, http.ErrServerClosed) {
 				return err
 			}
@@ -109,9 +153,32 @@ func newLocalCmd() *cobra.Command {
 	cmd.Flags().StringVar(&dataDir, "data-dir", "", "Local data directory (default: ~/.moltbb/local-web)")
 	cmd.Flags().StringVar(&apiBaseURL, "api-base-url", "", "Temporary API base URL override for local web (does not modify config)")
 	cmd.Flags().BoolVar(&autoSync, "auto-sync", true, "Auto run local-sync on startup")
+	cmd.Flags().BoolVar(&scheduler, "scheduler", true, "Run scheduled jobs from config.yaml unless another moltbb process does (see 'moltbb schedule')")
+	cmd.Flags().BoolVar(&events, "events", true, "Relay pipeline hub events to the studio (/api/events)")
+	cmd.AddCommand(newLocalDBCmd())
 	return cmd
 }
 
+// startEventStream connects the studio's /api/events stream to the hub.
+// Unread-count polling runs even when no hub token is available.
+func startEventStream(ctx context.Context, cfg config.Config, app *localweb.Server) error {
+	if strings.HasPrefix(cfg.APIBaseURL, "http://") {
+		cfg.AllowInsecureHTTP = true
+	}
+	client, err := api.NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	apiKey, _ := auth.ResolveAPIKey()
+	token, _ := auth.ResolveToken()
+	return app.StartEvents(ctx, localweb.EventsOptions{
+		Client:       client,
+		Token:        token,
+		RefreshToken: refreshBotToken(client),
+		APIKey:       apiKey,
+	})
+}
+
 func loadLocalConfig() (config.Config, error) {
 	cfg, err := config.Load()
 	if err == nil {
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"moltbb-cli/internal/localweb"
+	"moltbb-cli/internal/output"
+	"moltbb-cli/internal/utils"
+)
+
+func newLocalDBCmd() *cobra.Command {
+	var dbPath string
+
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: "Inspect or migrate the local studio database",
+	}
+	cmd.PersistentFlags().StringVar(&dbPath, "db", "", "Database path (default: ~/.moltbb/local-web/local.db)")
+
+	cmd.AddCommand(newLocalDBStatusCmd(&dbPath))
+	cmd.AddCommand(newLocalDBMigrateCmd(&dbPath))
+	return cmd
+}
+
+// localDBStatusResult is the structured result of 'moltbb local db status'.
+type localDBStatusResult struct {
+	Path          string                    `json:"path"`
+	Exists        bool                      `json:"exists"`
+	SchemaVersion int                       `json:"schemaVersion"`
+	BuildVersion  int                       `json:"buildVersion"`
+	Migrations    []localweb.MigrationState `json:"migrations"`
+	Pending       int                       `json:"pending"`
+}
+
+func newLocalDBStatusCmd(dbPath *string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show applied and pending schema migrations",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := resolveLocalDBFlag(*dbPath)
+			if err != nil {
+				return err
+			}
+			res := localDBStatusResult{Path: path, BuildVersion: localweb.SchemaVersion, Migrations: []localweb.MigrationState{}}
+			if !utils.FileExists(path) {
+				output.PrintInfo("No local database yet: " + path)
+				return output.Result("moltbb.local.db.status.v1", res)
+			}
+			db, err := localweb.OpenRawDB(path)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			states, err := localweb.MigrationStatus(db)
+			if err != nil {
+				return err
+			}
+			current, err := localweb.DBSchemaVersion(db)
+			if err != nil {
+				return err
+			}
+
+			output.Printf("Database: %s\n", path)
+			output.Printf("Schema:   v%d (this build: v%d)\n\n", current, localweb.SchemaVersion)
+			res.Exists, res.SchemaVersion, res.Migrations = true, current, states
+			for _, st := range states {
+				if st.Applied {
+					output.Printf("  ✓ %03d %-28s %s\n", st.Version, st.Name, st.AppliedAt)
+					continue
+				}
+				res.Pending++
+				output.Printf("  • %03d %-28s pending\n", st.Version, st.Name)
+			}
+			if res.Pending > 0 {
+				output.Printf("\n%d pending; run 'moltbb local db migrate' (the studio also migrates on start).\n", res.Pending)
+			}
+			return output.Result("moltbb.local.db.status.v1", res)
+		},
+	}
+}
+
+func newLocalDBMigrateCmd(dbPath *string) *cobra.Command {
+	var (
+		dryRun   bool
+		noBackup bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply pending schema migrations",
+		Long: `Apply pending schema migrations to the local studio database.
+
+A copy of the database is saved next to it before anything changes
+(local.db.bak-v<version>-<time>) unless --no-backup is given.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := resolveLocalDBFlag(*dbPath)
+			if err != nil {
+				return err
+			}
+			existed := utils.FileExists(path)
+			db, err := localweb.OpenRawDB(path)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			states, err := localweb.MigrationStatus(db)
+			if err != nil {
+				return err
+			}
+			var pending []string
+			for _, st := range states {
+				if !st.Applied {
+					pending = append(pending, fmt.Sprintf("%03d %s", st.Version, st.Name))
+				}
+			}
+			if len(pending) == 0 {
+				output.PrintSuccess(fmt.Sprintf("Schema is up to date (v%d)", localweb.SchemaVersion))
+				return nil
+			}
+			if dryRun {
+				output.PrintInfo("Pending migrations:")
+				for _, p := range pending {
+					output.Println("  • " + p)
+				}
+				return nil
+			}
+
+			if existed && !noBackup {
+				current, err := localweb.DBSchemaVersion(db)
+				if err != nil {
+					return err
+				}
+				backupPath := fmt.Sprintf("%s.bak-v%d-%s", path, current, time.Now().Format("20060102-150405"))
+				if _, err := db.Exec(`VACUUM INTO ?`, backupPath); err != nil {
+					return fmt.Errorf("back up database before migrating: %w", err)
+				}
+				output.PrintInfo("Backup saved: " + backupPath)
+			}
+
+			applied, err := localweb.Migrate(db)
+			for _, st := range applied {
+				output.Printf("  ✓ %03d %s\n", st.Version, st.Name)
+			}
+			if err != nil {
+				return err
+			}
+			output.PrintSuccess(fmt.Sprintf("Applied %d migration(s); schema is now v%d", len(applied), localweb.SchemaVersion))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only list pending migrations")
+	cmd.Flags().BoolVar(&noBackup, "no-backup", false, "Skip the pre-migration database copy")
+	return cmd
+}
+
+func resolveLocalDBFlag(flagValue string) (string, error) {
+	if strings.TrimSpace(flagValue) != "" {
+		return utils.ExpandPath(flagValue)
+	}
+	return resolveLocalDBPath()
+}
@@ -1,17 +1,12 @@
 package main
 
 import (
-	"database/sql"
 	"fmt"
-	"os"
-	"path/filepath"
-	"strings"
-	"time"
 
 	"github.com/spf13/cobra"
-	_ "modernc.org/sqlite"
-	"moltbb-cli/internal/config"
+	"moltbb-cli/internal/localweb"
 	"moltbb-cli/internal/output"
+	"moltbb-cli/internal/utils"
 )
 
 func newLocalSyncCmd() *cobra.Command {
@@ -20,151 +15,66 @@ func newLocalSyncCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "local-sync",
 		Short: "Sync local diary files to local database",
-		Long:  `Read local diary files and save to local SQLite database.`,
+		Long: `Index the Markdown diaries in output_dir into the local studio database.
+
+New and changed files are added; --force rebuilds the whole index.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg, err := config.Load()
+			cfg, err := loadLocalConfig()
 			if err != nil {
 				return err
 			}
-
-			// Find diary files - use diaries_dir or output_dir
-			diaryPaths := []string{}
-			diaryDir := cfg.DiariesDir
-			if diaryDir == "" {
-				diaryDir = cfg.OutputDir
-			}
-			// Resolve relative path
-			if diaryDir != "" && !filepath.IsAbs(diaryDir) {
-				homeDir, _ := os.UserHomeDir()
-				diaryDir = filepath.Join(homeDir, "moltbb", diaryDir)
+			diaryDir, err := utils.ExpandPath(cfg.OutputDir)
+			if err != nil {
+				return err
 			}
-			if diaryDir != "" {
-				diaryPaths = []string{diaryDir}
-			} else {
-				homeDir, _ := os.UserHomeDir()
-				diaryPaths = []string{filepath.Join(homeDir, "moltbb", "diaries")}
+			dbPath, err := resolveLocalDBPath()
+			if err != nil {
+				return err
 			}
-
-			output.PrintInfo(fmt.Sprintf("Scanning: %v", diaryPaths))
-
-			var synced int
-			for _, diaryPath := range diaryPaths {
-				count, err := syncDiaryFiles(diaryPath, force)
+			output.PrintInfo("Scanning: " + diaryDir)
+			if force {
+				count, err := syncDiaryFilesAt(dbPath, diaryDir, true)
 				if err != nil {
-					output.PrintError(fmt.Sprintf("Error scanning %s: %v", diaryPath, err))
-					continue
+					return err
 				}
-				synced += count
+				output.PrintSuccess(fmt.Sprintf("Reindexed %d diary entries in local database", count))
+				return nil
 			}
-
-			output.Success(fmt.Sprintf("Synced %d diary entries to local database", synced))
+			count, err := syncDiaryFilesAt(dbPath, diaryDir, false)
+			if err != nil {
+				return err
+			}
+			output.PrintSuccess(fmt.Sprintf("Synced local database: %d new or changed", count))
 			return nil
 		},
 	}
 
-	cmd.Flags().BoolVar(&force, "force", false, "Force overwrite existing entries")
+	cmd.Flags().BoolVar(&force, "force", false, "Rebuild the whole index instead of syncing changes")
 
 	return cmd
 }
 
-func syncDiaryFiles(diaryPath string, force bool) (int, error) {
-	// Open local database
-	homeDir, _ := os.UserHomeDir()
-	dbPath := filepath.Join(homeDir, ".moltbb", "local-web", "local.db")
+// syncDiaryFiles indexes the diaries under diaryDir into the default local
+// database and returns how many entries were written.
+func syncDiaryFiles(diaryDir string, force bool) (int, error) {
+	dbPath, err := resolveLocalDBPath()
+	if err != nil {
+		return 0, err
+	}
+	return syncDiaryFilesAt(dbPath, diaryDir, force)
+}
 
-	db, err := sql.Open("sqlite", dbPath)
+func syncDiaryFilesAt(dbPath, diaryDir string, force bool) (int, error) {
+	db, err := localweb.OpenDB(dbPath)
 	if err != nil {
-		return 0, fmt.Errorf("open db: %w", err)
+		return 0, err
 	}
 	defer db.Close()
 
-	var count int
-
-	err = filepath.Walk(diaryPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
-		if info.IsDir() {
-			return nil
-		}
-
-		ext := strings.ToLower(filepath.Ext(path))
-		if ext != ".md" && ext != ".txt" {
-			return nil
-		}
-
-		// Extract date from filename (e.g., 2026-03-03.md)
-		filename := filepath.Base(path)
-		date := strings.TrimSuffix(filename, filepath.Ext(filename))
-
-		// Validate date format
-		if len(date) != 10 || date[4] != '-' || date[7] != '-' {
-			return nil
-		}
-
-		// Read file content
-		content, err := os.ReadFile(path)
-		if err != nil {
-			return nil
-		}
-
-		text := string(content)
-
-		// Extract title (first line)
-		title := text
-		lines := strings.Split(text, "\n")
-		if len(lines) > 0 {
-			title = strings.TrimSpace(lines[0])
-			title = strings.TrimPrefix(title, "# ")
-			if len(title) > 100 {
-				title = title[:100]
-			}
-		}
-
-		// Preview (first 200 chars)
-		preview := text
-		if len(preview) > 200 {
-			preview = preview[:200]
-		}
-
-		// Check if entry exists
-		if !force {
-			var exists bool
-			err = db.QueryRow("SELECT EXISTS(SELECT 1 FROM diary_entries WHERE date = ?)", date).Scan(&exists)
-			if err == nil && exists {
-				return nil // Skip existing
-			}
-		}
-
-		// Insert or update
-		if force {
-			_, err = db.Exec(`
-				UPDATE diary_entries 
-				SET title = ?, preview = ?, content_text = ?, modified_at = datetime('now')
-				WHERE date = ?`,
-				title, preview, text, date)
-		}
-
-		uniqueID := date + "-" + fmt.Sprintf("%d", time.Now().Unix())
-		relPath := date + ".md"
-		_, err = db.Exec(`
-			INSERT OR REPLACE INTO diary_entries (id, rel_path, filename, date, title, preview, content_text, size, modified_at, indexed_at)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))`,
-			uniqueID, relPath, relPath, date, title, preview, text, len(text))
-
-		if err != nil {
-			// Ignore duplicate rel_path errors to avoid crashing local service.
-			if strings.Contains(err.Error(), "UNIQUE constraint failed: diary_entries.rel_path") {
-				return nil
-			}
-			return nil
-		}
-
-		count++
-		fmt.Printf("Synced: %s\n", date)
-
-		return nil
-	})
-
-	return count, err
+	repo := localweb.NewDiaryRepository(db, diaryDir)
+	if force {
+		return repo.Reindex()
+	}
+	added, updated, err := repo.Sync()
+	return added + updated, err
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"moltbb-cli/internal/config"
+	"moltbb-cli/internal/output"
 	"moltbb-cli/internal/utils"
 )
 
@@ -63,8 +64,8 @@ The diary will be saved to the configured output directory.`,
 
 			// Check if file exists
 			if _, err := os.Stat(filePath); err == nil {
-				fmt.Printf("📝 Diary already exists: %s\n", filePath)
-				fmt.Println("Use --force to overwrite or edit manually.")
+				output.Printf("📝 Diary already exists: %s\n", filePath)
+				output.Println("Use --force to overwrite or edit manually.")
 				return nil
 			}
 
@@ -93,8 +94,8 @@ The diary will be saved to the configured output directory.`,
 				return fmt.Errorf("write diary: %w", err)
 			}
 
-			fmt.Printf("✅ Created diary: %s\n", filePath)
-			fmt.Println("💡 Use 'moltbb local' to preview")
+			output.Printf("✅ Created diary: %s\n", filePath)
+			output.Println("💡 Use 'moltbb local' to preview")
 			return nil
 		},
 	}
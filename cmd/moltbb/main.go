@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -15,7 +16,9 @@ import (
 	"moltbb-cli/internal/binding"
 	"moltbb-cli/internal/config"
 	"moltbb-cli/internal/diary"
+	"moltbb-cli/internal/llm"
 	"moltbb-cli/internal/output"
+	"moltbb-cli/internal/parser"
 	"moltbb-cli/internal/utils"
 )
 
@@ -47,7 +50,9 @@ func main() {
 	root.AddCommand(newDoctorCmd())
 	root.AddCommand(newSyncCmd())
 	root.AddCommand(newExportCmd())
+	root.AddCommand(newBackupCmd())
 	root.AddCommand(newDaemonCmd())
+	root.AddCommand(newScheduleCmd())
 	root.AddCommand(newTowerCmd())
 	root.AddCommand(newPipelineCmd())
 	root.AddCommand(newMessageCmd())
@@ -60,6 +65,7 @@ func main() {
 	root.AddCommand(newTemplateCmd())
 	root.AddCommand(newCommentCmd())
 	root.AddCommand(newExportCmd())
+	root.AddCommand(newBackupCmd())
 	root.AddCommand(&cobra.Command{
 		Use:   "completion [shell]",
 		Short: "Generate completion script for your shell",
@@ -107,21 +113,24 @@ PowerShell:
 		RunE: func(cmd *cobra.Command, args []string) error {
 			switch args[0] {
 			case "bash":
-				return root.GenBashCompletion(os.Stdout)
+				return root.GenBashCompletion(output.Writer())
 			case "zsh":
-				return root.GenZshCompletion(os.Stdout)
+				return root.GenZshCompletion(output.Writer())
 			case "fish":
-				return root.GenFishCompletion(os.Stdout, true)
+				return root.GenFishCompletion(output.Writer(), true)
 			case "powershell":
-				return root.GenPowerShellCompletion(os.Stdout)
+				return root.GenPowerShellCompletion(output.Writer())
 			}
 			return nil
 		},
 	})
 
+	addOutputFlag(root)
 	if err := root.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, "Error:", err)
-		os.Exit(1)
+		exitWithError(err)
+	}
+	if err := output.Done(); err != nil {
+		exitWithError(err)
 	}
 }
 
@@ -164,9 +173,9 @@ func newInitCmd() *cobra.Command {
 				return err
 			}
 
-			fmt.Println("Initialized MoltBB CLI config")
-			fmt.Println("Config:", cfgPath)
-			fmt.Println("API endpoint:", cfg.APIBaseURL)
+			output.Println("Initialized MoltBB CLI config")
+			output.Println("Config:", cfgPath)
+			output.Println("API endpoint:", cfg.APIBaseURL)
 			return nil
 		},
 	}
@@ -217,8 +226,8 @@ func newLoginCmd() *cobra.Command {
 			}
 
 			credPath, _ := utils.CredentialsPath()
-			fmt.Println("Login success")
-			fmt.Println("Credentials stored at:", credPath)
+			output.Println("Login success")
+			output.Println("Credentials stored at:", credPath)
 			return nil
 		},
 	}
@@ -280,9 +289,9 @@ func newBindCmd() *cobra.Command {
 				return err
 			}
 
-			fmt.Println("Bind success")
-			fmt.Println("Bot ID:", resp.BotID)
-			fmt.Println("Activation status:", resp.ActivationStatus)
+			output.Println("Bind success")
+			output.Println("Bot ID:", resp.BotID)
+			output.Println("Activation status:", resp.ActivationStatus)
 			return nil
 		},
 	}
@@ -295,11 +304,32 @@ func newRunCmd() *cobra.Command {
 	var memoryDir string
 	var memoryFile string
 	var executionLevel int
+	var mode string
+	var maxLines int
+	var llmProvider string
+	var llmModel string
+	var force bool
 
 	cmd := &cobra.Command{
 		Use:   "run",
-		Short: "Generate agent prompt packet",
+		Short: "Generate agent prompt packet or an offline diary",
+		Long: `Generate the daily diary input.
+
+Modes:
+  agent    Write a prompt packet for an external agent (default)
+  offline  Parse the configured input_paths for the day and write a
+           deterministic YYYY-MM-DD.md diary without an LLM agent
+  llm      Write the prompt packet, then have the configured LLM provider
+           (llm: in config.yaml) write YYYY-MM-DD.md from it
+
+The offline and llm modes refuse to replace an existing YYYY-MM-DD.md
+unless --force is given.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			mode = strings.ToLower(strings.TrimSpace(mode))
+			if mode != "agent" && mode != "offline" && mode != "llm" {
+				return fmt.Errorf("invalid --mode %q (use agent, offline or llm)", mode)
+			}
+
 			cfg, err := config.Load()
 			if err != nil {
 				return err
@@ -310,60 +340,197 @@ func newRunCmd() *cobra.Command {
 				return err
 			}
 
+			loc, err := cfg.Location()
+			if err != nil {
+				return err
+			}
 			date := strings.TrimSpace(runDate)
 			if date == "" {
-				date = time.Now().Local().Format("2006-01-02")
+				date = time.Now().In(loc).Format("2006-01-02")
 			}
 			if _, err := time.Parse("2006-01-02", date); err != nil {
 				return fmt.Errorf("invalid --date, expected YYYY-MM-DD: %w", err)
 			}
 
-			promptPath, err := diary.WritePromptPacket(date, host, cfg.APIBaseURL, cfg.OutputDir, cfg.Template, cfg.InputPaths)
-			if err != nil {
-				return err
+			if mode != "agent" && !force {
+				outDir, err := utils.ExpandPath(cfg.OutputDir)
+				if err != nil {
+					return err
+				}
+				if path := filepath.Join(outDir, date+".md"); utils.FileExists(path) {
+					return fmt.Errorf("diary already exists: %s (use --force to overwrite)", path)
+				}
 			}
 
-			summary := diary.AgentManagedSummary(len(cfg.InputPaths))
-			fmt.Println("Agent prompt packet generated:", promptPath)
-			fmt.Println("Summary:", summary)
-
-			if !autoUpload {
-				return nil
+			res := runResult{Mode: mode, Date: date}
+			if mode == "offline" {
+				if err := runOfflineDiary(cfg, loc, host, maxLines, autoUpload, executionLevel, &res); err != nil {
+					return err
+				}
+				return output.Result("moltbb.run.v1", res)
 			}
 
-			resolvedFile, found, err := resolveMemoryDiaryFile(memoryDir, memoryFile, date)
+			promptPath, err := diary.WritePromptPacket(date, host, cfg.APIBaseURL, cfg.OutputDir, cfg.Template, cfg.InputPaths, dayTranscriptExcerpt(date, loc))
 			if err != nil {
-				return fmt.Errorf("resolve memory diary file: %w", err)
-			}
-			if !found {
-				fmt.Println("Auto upload skipped: no diary markdown found in memory directory.")
-				fmt.Println("Hint: use `moltbb diary upload <file>` for manual sync.")
-				return nil
+				return err
 			}
+			res.PromptPath = promptPath
 
-			result, _, payload, err := upsertDiaryFromFile(cfg, resolvedFile, date, executionLevel)
-			if err != nil {
-				fmt.Printf("Auto upload skipped: %v\n", err)
-				fmt.Println("Hint: run `moltbb diary upload " + resolvedFile + "` after fixing API key/network.")
-				return nil
+			if mode == "llm" {
+				output.Println("Prompt packet generated:", promptPath)
+				if err := runLLMDiary(cfg, llmProvider, llmModel, autoUpload, executionLevel, &res); err != nil {
+					return err
+				}
+				return output.Result("moltbb.run.v1", res)
 			}
 
-			fmt.Printf("Auto upload success: %s %s (executionLevel=%d)\n", result.Action, payload.DiaryDate, payload.ExecutionLevel)
-			if result.DiaryID != "" {
-				fmt.Println("Diary ID:", result.DiaryID)
+			res.Summary = diary.AgentManagedSummary(len(cfg.InputPaths))
+			output.Println("Agent prompt packet generated:", promptPath)
+			output.Println("Summary:", res.Summary)
+
+			if autoUpload {
+				resolvedFile, found, err := resolveMemoryDiaryFile(memoryDir, memoryFile, date)
+				if err != nil {
+					return fmt.Errorf("resolve memory diary file: %w", err)
+				}
+				if found {
+					res.DiaryPath = resolvedFile
+					autoUploadDiaryFile(cfg, resolvedFile, date, executionLevel, &res)
+				} else {
+					res.UploadSkipped = "no diary markdown found in memory directory"
+					output.Println("Auto upload skipped: no diary markdown found in memory directory.")
+					output.Println("Hint: use `moltbb diary upload <file>` for manual sync.")
+				}
 			}
-			return nil
+			return output.Result("moltbb.run.v1", res)
 		},
 	}
 
-	cmd.Flags().StringVar(&runDate, "date", "", "Diary date in UTC (YYYY-MM-DD, default: today)")
-	cmd.Flags().BoolVar(&autoUpload, "auto-upload", true, "Auto-upload diary from memory/daily after packet generation")
+	cmd.Flags().StringVar(&runDate, "date", "", "Diary date (YYYY-MM-DD, default: today in configured timezone)")
+	cmd.Flags().BoolVar(&autoUpload, "auto-upload", true, "Auto-upload the diary (memory/daily file in agent mode, generated file in offline mode)")
 	cmd.Flags().StringVar(&memoryDir, "memory-dir", "memory/daily", "OpenClaw memory daily directory")
 	cmd.Flags().StringVar(&memoryFile, "memory-file", "", "Explicit memory diary file path (overrides --memory-dir)")
 	cmd.Flags().IntVar(&executionLevel, "execution-level", 0, "Execution level for auto-upload diary payload (0-4)")
+	cmd.Flags().StringVar(&mode, "mode", "agent", "Run mode: agent (prompt packet), offline (render diary from logs) or llm (write diary with LLM)")
+	cmd.Flags().IntVar(&maxLines, "max-lines", 5000, "Maximum log lines to ingest in offline mode")
+	cmd.Flags().StringVar(&llmProvider, "provider", "", "LLM provider for --mode=llm (default: llm.provider from config)")
+	cmd.Flags().StringVar(&llmModel, "model", "", "LLM model for --mode=llm (default: llm.model from config)")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite an existing diary in offline and llm modes")
 	return cmd
 }
 
+const runDiarySystemPrompt = `You are the bot described in the prompt packet, writing your own daily diary.
+Follow the packet's instructions and output only the finished diary in Markdown.`
+
+// runResult is the structured result of 'moltbb run'.
+type runResult struct {
+	Mode       string `json:"mode"`
+	Date       string `json:"date"`
+	PromptPath string `json:"promptPath,omitempty"`
+	DiaryPath  string `json:"diaryPath,omitempty"`
+	Summary    string `json:"summary,omitempty"`
+	// Upload is set when the diary was uploaded, UploadSkipped when the
+	// upload was attempted and did not happen.
+	Upload        *diaryUploadResult `json:"upload,omitempty"`
+	UploadSkipped string             `json:"uploadSkipped,omitempty"`
+}
+
+func runLLMDiary(cfg config.Config, provider, model string, autoUpload bool, executionLevel int, res *runResult) error {
+	packet, err := os.ReadFile(res.PromptPath)
+	if err != nil {
+		return fmt.Errorf("read prompt packet: %w", err)
+	}
+
+	p, _, err := resolveLLMProvider(cfg, provider, model)
+	if err != nil {
+		return err
+	}
+	output.Println("Writing diary with", p.Name()+"...")
+	text, err := generateWithLLM(p, llm.Prompt(runDiarySystemPrompt, string(packet)), false)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("%s returned an empty diary", p.Name())
+	}
+
+	if err := os.MkdirAll(cfg.OutputDir, 0o700); err != nil {
+		return fmt.Errorf("create output dir: %w", err)
+	}
+	diaryPath := filepath.Join(cfg.OutputDir, res.Date+".md")
+	if err := os.WriteFile(diaryPath, []byte(strings.TrimSpace(text)+"\n"), 0o600); err != nil {
+		return fmt.Errorf("write diary: %w", err)
+	}
+	res.DiaryPath = diaryPath
+	output.Println("Diary generated:", diaryPath)
+
+	if autoUpload {
+		autoUploadDiaryFile(cfg, diaryPath, res.Date, executionLevel, res)
+	}
+	return nil
+}
+
+func runOfflineDiary(cfg config.Config, loc *time.Location, host string, maxLines int, autoUpload bool, executionLevel int, res *runResult) error {
+	result, err := parser.ParseOpenClawLogsForDay(cfg.InputPaths, parser.DayOptions{
+		Date:     res.Date,
+		Location: loc,
+		MaxLines: maxLines,
+	})
+	if err != nil {
+		return err
+	}
+
+	doc := diary.Build(result, host)
+	diaryPath, err := diary.Write(doc, cfg.OutputDir)
+	if err != nil {
+		return err
+	}
+
+	res.DiaryPath, res.Summary = diaryPath, doc.Summary
+	output.Println("Offline diary generated:", diaryPath)
+	output.Println("Summary:", doc.Summary)
+
+	if autoUpload {
+		autoUploadDiaryFile(cfg, diaryPath, res.Date, executionLevel, res)
+	}
+	return nil
+}
+
+// autoUploadDiaryFile uploads a diary and records the outcome in res;
+// failures only print a hint because the local file is already written.
+func autoUploadDiaryFile(cfg config.Config, diaryPath, date string, executionLevel int, res *runResult) {
+	upsertResult, _, payload, err := upsertDiaryFromFile(cfg, diaryPath, date, executionLevel)
+	if err != nil {
+		res.UploadSkipped = err.Error()
+		output.Printf("Auto upload skipped: %v\n", err)
+		output.Println("Hint: run `moltbb diary upload " + diaryPath + "` after fixing API key/network.")
+		return
+	}
+	upload := newDiaryUploadResult(diaryPath, payload, upsertResult)
+	res.Upload = &upload
+
+	output.Printf("Auto upload success: %s %s (executionLevel=%d)\n", upsertResult.Action, payload.DiaryDate, payload.ExecutionLevel)
+	if upsertResult.DiaryID != "" {
+		output.Println("Diary ID:", upsertResult.DiaryID)
+	}
+}
+
+// statusResult is the structured result of 'moltbb status'.
+type statusResult struct {
+	Version    string   `json:"version"`
+	ConfigPath string   `json:"configPath"`
+	ConfigOK   bool     `json:"configOk"`
+	APIBaseURL string   `json:"apiBaseUrl,omitempty"`
+	InputPaths []string `json:"inputPaths,omitempty"`
+	OutputDir  string   `json:"outputDir,omitempty"`
+	APIKeyOK   bool     `json:"apiKeyOk"`
+	APIKey     string   `json:"apiKeyMasked,omitempty"`
+	Bound      bool     `json:"bound"`
+	BotID      string   `json:"botId,omitempty"`
+	Activation string   `json:"activation,omitempty"`
+	Ready      bool     `json:"ready"`
+}
+
 func newStatusCmd() *cobra.Command {
 	var card bool
 	cmd := &cobra.Command{
@@ -371,47 +538,62 @@ func newStatusCmd() *cobra.Command {
 		Short: "Show config, auth and binding status",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if card {
-				fmt.Println(buildStatusCard().render())
+				c := buildStatusCard()
+				if output.Structured() {
+					return output.Result("moltbb.status-card.v1", c)
+				}
+				output.Println(c.render())
 				return nil
 			}
 
 			cfgPath, _ := utils.ConfigPath()
-			configOK := false
-			apiKeyOK := false
-			boundOK := false
+			st := statusResult{Version: version, ConfigPath: cfgPath}
+			if cfg, err := config.Load(); err == nil {
+				st.ConfigOK = true
+				st.APIBaseURL = cfg.APIBaseURL
+				st.InputPaths = cfg.InputPaths
+				st.OutputDir = cfg.OutputDir
+			}
+			if key, err := auth.ResolveAPIKey(); err == nil {
+				st.APIKeyOK = true
+				st.APIKey = maskAPIKey(key)
+			}
+			if state, err := binding.Load(); err == nil && state.Bound {
+				st.Bound = true
+				st.BotID = state.BotID
+				st.Activation = state.ActivationStatus
+			}
+			st.Ready = st.ConfigOK && st.APIKeyOK && st.Bound
+			if output.Structured() {
+				return output.Result("moltbb.status.v1", st)
+			}
 
 			output.PrintSection("MoltBB Status")
-			fmt.Println("Version:", version)
-			fmt.Println("Config:", cfgPath)
+			output.Println("Version:", version)
+			output.Println("Config:", cfgPath)
 
-			cfg, cfgErr := config.Load()
-			if cfgErr != nil {
+			if !st.ConfigOK {
 				output.PrintWarning("Config: missing or invalid (run `moltbb onboard`)")
 			} else {
-				configOK = true
 				output.PrintSuccess("Config loaded")
-				fmt.Println("  API endpoint:", cfg.APIBaseURL)
-				fmt.Println("  Input paths:", strings.Join(cfg.InputPaths, ", "))
-				fmt.Println("  Output dir:", cfg.OutputDir)
+				output.Println("  API endpoint:", st.APIBaseURL)
+				output.Println("  Input paths:", strings.Join(st.InputPaths, ", "))
+				output.Println("  Output dir:", st.OutputDir)
 			}
 
-			key, err := auth.ResolveAPIKey()
-			if err != nil {
+			if !st.APIKeyOK {
 				output.PrintWarning("API key: not configured")
 			} else {
-				apiKeyOK = true
 				output.PrintSuccess("API key configured")
-				fmt.Printf("  Key: %s\n", maskAPIKey(key))
+				output.Printf("  Key: %s\n", st.APIKey)
 			}
 
-			state, err := binding.Load()
-			if err != nil || !state.Bound {
+			if !st.Bound {
 				output.PrintWarning("Binding: not bound")
 			} else {
-				boundOK = true
 				output.PrintSuccess("Bot bound")
-				fmt.Println("  Bot ID:", state.BotID)
-				fmt.Println("  Activation:", state.ActivationStatus)
+				output.Println("  Bot ID:", st.BotID)
+				output.Println("  Activation:", st.Activation)
 			}
 
 			output.PrintSection("Onboard Checks")
@@ -422,11 +604,11 @@ func newStatusCmd() *cobra.Command {
 					output.PrintError(name + ": Not Ready")
 				}
 			}
-			checkStatus("Config", configOK)
-			checkStatus("API Key", apiKeyOK)
-			checkStatus("Binding", boundOK)
+			checkStatus("Config", st.ConfigOK)
+			checkStatus("API Key", st.APIKeyOK)
+			checkStatus("Binding", st.Bound)
 
-			if configOK && apiKeyOK && boundOK {
+			if st.Ready {
 				output.PrintSuccess("All checks passed!")
 			} else {
 				output.PrintWarning("Run `moltbb onboard` to complete setup")
@@ -439,19 +621,29 @@ func newStatusCmd() *cobra.Command {
 	return cmd
 }
 
+// doctorCheck is one diagnostic of 'moltbb doctor'.
+type doctorCheck struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
 func newDoctorCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "doctor",
 		Short: "Run local diagnostics for config, permissions and API connectivity",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var failed bool
+			var checks []doctorCheck
 
 			check := func(name string, fn func() error) {
 				if err := fn(); err != nil {
 					failed = true
-					fmt.Printf("[FAIL] %s: %v\n", name, err)
+					checks = append(checks, doctorCheck{Name: name, Error: err.Error()})
+					output.Printf("[FAIL] %s: %v\n", name, err)
 				} else {
-					fmt.Printf("[ OK ] %s\n", name)
+					checks = append(checks, doctorCheck{Name: name, OK: true})
+					output.Printf("[ OK ] %s\n", name)
 				}
 			}
 
@@ -500,10 +692,13 @@ func newDoctorCmd() *cobra.Command {
 				return err
 			})
 
+			result := struct {
+				Checks []doctorCheck `json:"checks"`
+			}{checks}
 			if failed {
-				return errors.New("doctor checks failed")
+				return output.WithResult(errors.New("doctor checks failed"), "moltbb.doctor.v1", result)
 			}
-			return nil
+			return output.Result("moltbb.doctor.v1", result)
 		},
 	}
 	return cmd
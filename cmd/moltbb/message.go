@@ -59,16 +59,19 @@ func newMessageSendCmd() *cobra.Command {
 				return err
 			}
 
+			if output.Structured() {
+				return output.Result("moltbb.message.send.v1", result)
+			}
 			if jsonOutput {
 				printMessageSendJSON(result)
 				return nil
 			}
 
 			output.PrintSuccess("Message sent successfully")
-			fmt.Println("ID:   ", result.ID)
-			fmt.Println("From: ", result.FromBotName)
-			fmt.Println("To:   ", result.ToBotName)
-			fmt.Println("Sent: ", formatMsgTime(result.SendTime))
+			output.Println("ID:   ", result.ID)
+			output.Println("From: ", result.FromBotName)
+			output.Println("To:   ", result.ToBotName)
+			output.Println("Sent: ", formatMsgTime(result.SendTime))
 			return nil
 		},
 	}
@@ -116,19 +119,25 @@ func newMessageListCmd() *cobra.Command {
 				return err
 			}
 
+			if output.Structured() {
+				if result.Items == nil {
+					result.Items = []api.BotMessage{}
+				}
+				return output.Result("moltbb.message.list.v1", result)
+			}
 			if jsonOutput {
 				printMessagesJSON(result.Items)
 				return nil
 			}
 
 			if len(result.Items) == 0 {
-				fmt.Println("No messages found.")
+				output.Println("No messages found.")
 				return nil
 			}
 
 			output.PrintSection(fmt.Sprintf("Messages (%d total)", result.TotalCount))
-			fmt.Printf("%-36s  %-8s  %-20s  %s\n", "ID", "STATUS", "SEND TIME", "TITLE")
-			fmt.Println(strings.Repeat("-", 100))
+			output.Printf("%-36s  %-8s  %-20s  %s\n", "ID", "STATUS", "SEND TIME", "TITLE")
+			output.Println(strings.Repeat("-", 100))
 			for _, m := range result.Items {
 				statusLabel := formatMessageStatus(m.Status)
 				sendTime := formatMsgTime(m.SendTime)
@@ -136,9 +145,9 @@ func newMessageListCmd() *cobra.Command {
 				if len(title) > 50 {
 					title = title[:47] + "..."
 				}
-				fmt.Printf("%-36s  %-8s  %-20s  %s\n", m.ID, statusLabel, sendTime, title)
+				output.Printf("%-36s  %-8s  %-20s  %s\n", m.ID, statusLabel, sendTime, title)
 			}
-			fmt.Printf("\nPage %d/%d  (pageSize %d)\n",
+			output.Printf("\nPage %d/%d  (pageSize %d)\n",
 				result.Page, calcTotalPages(result.TotalCount, result.PageSize), result.PageSize)
 			return nil
 		},
@@ -181,22 +190,25 @@ func newMessageReadCmd() *cobra.Command {
 				return err
 			}
 
+			if output.Structured() {
+				return output.Result("moltbb.message.v1", msg)
+			}
 			if jsonOutput {
 				printMessagesJSON([]api.BotMessage{msg})
 				return nil
 			}
 
 			output.PrintSection("Message")
-			fmt.Println("ID:      ", msg.ID)
-			fmt.Println("Title:   ", msg.Title)
-			fmt.Println("Status:  ", formatMessageStatus(msg.Status))
-			fmt.Println("From:    ", resolveSender(msg))
-			fmt.Println("Sent:    ", formatMsgTime(msg.SendTime))
+			output.Println("ID:      ", msg.ID)
+			output.Println("Title:   ", msg.Title)
+			output.Println("Status:  ", formatMessageStatus(msg.Status))
+			output.Println("From:    ", resolveSender(msg))
+			output.Println("Sent:    ", formatMsgTime(msg.SendTime))
 			if msg.ReadTime != nil && *msg.ReadTime != "" {
-				fmt.Println("Read at: ", formatMsgTime(*msg.ReadTime))
+				output.Println("Read at: ", formatMsgTime(*msg.ReadTime))
 			}
-			fmt.Println()
-			fmt.Println(msg.Content)
+			output.Println()
+			output.Println(msg.Content)
 			return nil
 		},
 	}
@@ -267,13 +279,15 @@ func newMessageUnreadCmd() *cobra.Command {
 				return err
 			}
 
+			if output.Structured() {
+				return output.Result("moltbb.message.unread.v1", unreadCount{count})
+			}
 			if jsonOutput {
-				fmt.Printf(`{"unread":%d}%s`, count, "\n")
-				return nil
+				return printJSONLine(unreadCount{count})
 			}
 
 			if count == 0 {
-				fmt.Println("No unread messages.")
+				output.Println("No unread messages.")
 			} else {
 				output.PrintSuccess(fmt.Sprintf("Unread messages: %d", count))
 			}
@@ -338,34 +352,48 @@ func resolveSender(m api.BotMessage) string {
 	return fmt.Sprintf("%s (%s)", m.SenderID, senderType)
 }
 
+type unreadCount struct {
+	Unread int `json:"unread"`
+}
+
+// printMessagesJSON prints messages in the --json shape, where missing
+// sender names and read times are empty strings.
 func printMessagesJSON(msgs []api.BotMessage) {
-	fmt.Print("[")
-	for i, m := range msgs {
-		if i > 0 {
-			fmt.Print(",")
+	type jsonMessage struct {
+		ID         string `json:"id"`
+		Title      string `json:"title"`
+		Content    string `json:"content"`
+		SenderID   string `json:"senderId"`
+		SenderType int    `json:"senderType"`
+		SenderName string `json:"senderName"`
+		SendTime   string `json:"sendTime"`
+		ReadTime   string `json:"readTime"`
+		Status     int    `json:"status"`
+	}
+	out := make([]jsonMessage, 0, len(msgs))
+	for _, m := range msgs {
+		jm := jsonMessage{
+			ID:         m.ID,
+			Title:      m.Title,
+			Content:    m.Content,
+			SenderID:   m.SenderID,
+			SenderType: m.SenderType,
+			SendTime:   m.SendTime,
+			Status:     m.Status,
 		}
-		senderName := ""
 		if m.SenderName != nil {
-			senderName = *m.SenderName
+			jm.SenderName = *m.SenderName
 		}
-		readTime := ""
 		if m.ReadTime != nil {
-			readTime = *m.ReadTime
+			jm.ReadTime = *m.ReadTime
 		}
-		content := strings.ReplaceAll(m.Content, `"`, `\"`)
-		content = strings.ReplaceAll(content, "\n", `\n`)
-		title := strings.ReplaceAll(m.Title, `"`, `\"`)
-		fmt.Printf(`{"id":%q,"title":%q,"content":%q,"senderId":%q,"senderType":%d,"senderName":%q,"sendTime":%q,"readTime":%q,"status":%d}`,
-			m.ID, title, content, m.SenderID, m.SenderType, senderName, m.SendTime, readTime, m.Status)
+		out = append(out, jm)
 	}
-	fmt.Println("]")
+	_ = printJSONLine(out)
 }
 
 func printMessageSendJSON(result api.BotMessageSendResult) {
-	fmt.Printf(
-		`{"id":%q,"toBotId":%q,"toBotName":%q,"fromBotId":%q,"fromBotName":%q,"sendTime":%q}`+"\n",
-		result.ID, result.ToBotID, result.ToBotName, result.FromBotID, result.FromBotName, result.SendTime,
-	)
+	_ = printJSONLine(result)
 }
 
 func calcTotalPages(total, pageSize int) int {
@@ -18,6 +18,7 @@ import (
 	"moltbb-cli/internal/auth"
 	"moltbb-cli/internal/binding"
 	"moltbb-cli/internal/config"
+	"moltbb-cli/internal/output"
 	"moltbb-cli/internal/utils"
 )
 
@@ -59,7 +60,7 @@ func newOnboardCmd() *cobra.Command {
 	cmd.Flags().StringVar(&opts.scheduleOS, "schedule-os", "", "Scheduling target OS: linux|macos|windows")
 	cmd.Flags().BoolVar(&opts.generateScheduleFile, "generate-schedule-files", false, "Generate scheduling snippets into ~/.moltbb/examples")
 	cmd.Flags().IntVar(&opts.scheduleHour, "schedule-hour", 0, "Hour for scheduled diary writing: 20, 21, or 22 (0 = interactive prompt)")
-	cmd.Flags().BoolVar(&opts.installSchedule, "install-schedule", false, "Auto-install system schedule (cron/launchd) for diary writing")
+	cmd.Flags().BoolVar(&opts.installSchedule, "install-schedule", false, "Add a daily diary run to the built-in scheduler (runs in moltbb daemon)")
 	cmd.Flags().BoolVar(&opts.startDaemon, "start-daemon", false, "Start local web dashboard after onboarding")
 
 	return cmd
@@ -86,11 +87,11 @@ func runOnboard(opts onboardOptions) error {
 		existingBinding = &state
 	}
 
-	fmt.Println("MoltBB onboard wizard")
-	fmt.Println("Detected files:")
-	fmt.Printf("- config: %s (%v)\n", cfgPath, cfgErr == nil)
-	fmt.Printf("- credentials: %s (%v)\n", credPath, existingCred != nil)
-	fmt.Printf("- binding: %s (%v)\n", bindPath, existingBinding != nil)
+	output.Println("MoltBB onboard wizard")
+	output.Println("Detected files:")
+	output.Printf("- config: %s (%v)\n", cfgPath, cfgErr == nil)
+	output.Printf("- credentials: %s (%v)\n", credPath, existingCred != nil)
+	output.Printf("- binding: %s (%v)\n", bindPath, existingBinding != nil)
 
 	if opts.nonInteractive {
 		return runOnboardNonInteractive(opts, existingCfg, cfgExists, existingCred, existingBinding)
@@ -101,7 +102,7 @@ func runOnboard(opts onboardOptions) error {
 func runOnboardInteractive(opts onboardOptions, cfg config.Config, existingCred *auth.Credentials, existingBinding *binding.State) error {
 	reader := bufio.NewReader(os.Stdin)
 
-	fmt.Println("\nStep A: Server endpoint")
+	output.Println("\nStep A: Server endpoint")
 	defaultEndpoint := cfg.APIBaseURL
 	if strings.TrimSpace(opts.apiBaseURL) != "" {
 		defaultEndpoint = strings.TrimSpace(opts.apiBaseURL)
@@ -132,10 +133,10 @@ func runOnboardInteractive(opts onboardOptions, cfg config.Config, existingCred
 			}
 			continue
 		}
-		fmt.Println("Invalid URL. Expected http(s)://...")
+		output.Println("Invalid URL. Expected http(s)://...")
 	}
 
-	fmt.Println("\nStep B: Local diary settings")
+	output.Println("\nStep B: Local diary settings")
 	inputDefault := strings.Join(cfg.InputPaths, ",")
 	if strings.TrimSpace(opts.inputPaths) != "" {
 		inputDefault = opts.inputPaths
@@ -151,7 +152,7 @@ func runOnboardInteractive(opts onboardOptions, cfg config.Config, existingCred
 		if len(inputPaths) > 0 {
 			break
 		}
-		fmt.Println("At least one input path is required.")
+		output.Println("At least one input path is required.")
 	}
 	warnMissingInputPaths(inputPaths)
 
@@ -184,11 +185,11 @@ func runOnboardInteractive(opts onboardOptions, cfg config.Config, existingCred
 		return err
 	}
 
-	fmt.Println("\nStep C: Credentials (API key)")
+	output.Println("\nStep C: Credentials (API key)")
 	keyValidated := false
 	validatedKey := ""
 	if existingCred != nil {
-		fmt.Printf("Current API key: %s\n", maskAPIKey(existingCred.APIKey))
+		output.Printf("Current API key: %s\n", maskAPIKey(existingCred.APIKey))
 	}
 
 	setOrUpdate, err := utils.PromptYesNo(reader, "Do you want to set/update Bot API key now?", false)
@@ -208,7 +209,7 @@ func runOnboardInteractive(opts onboardOptions, cfg config.Config, existingCred
 				return promptErr
 			}
 			if strings.TrimSpace(inputKey) == "" {
-				fmt.Println("API key is empty.")
+				output.Println("API key is empty.")
 				continue
 			}
 			resp, validateErr := validateAPIKey(client, cfg, inputKey)
@@ -218,14 +219,14 @@ func runOnboardInteractive(opts onboardOptions, cfg config.Config, existingCred
 				}
 				validatedKey = inputKey
 				keyValidated = true
-				fmt.Println("API key validated and stored.")
+				output.Println("API key validated and stored.")
 				break
 			}
 
 			if validateErr != nil {
-				fmt.Printf("API key validation failed: %v\n", validateErr)
+				output.Printf("API key validation failed: %v\n", validateErr)
 			} else {
-				fmt.Println("API key validation failed: API reported key as invalid.")
+				output.Println("API key validation failed: API reported key as invalid.")
 			}
 			retry, retryErr := utils.PromptYesNo(reader, "Retry API key input?", true)
 			if retryErr != nil {
@@ -245,14 +246,14 @@ func runOnboardInteractive(opts onboardOptions, cfg config.Config, existingCred
 			if validateErr == nil && resp.Valid {
 				validatedKey = existingCred.APIKey
 				keyValidated = true
-				fmt.Println("Existing API key validated.")
+				output.Println("Existing API key validated.")
 			} else {
-				fmt.Println("Existing API key validation failed, binding will be skipped.")
+				output.Println("Existing API key validation failed, binding will be skipped.")
 			}
 		}
 	}
 
-	fmt.Println("\nStep D: Binding / Activation")
+	output.Println("\nStep D: Binding / Activation")
 	bound := existingBinding != nil && existingBinding.Bound
 	if keyValidated {
 		bindNow, promptErr := utils.PromptYesNo(reader, "Bind/activate this bot on this machine now?", true)
@@ -268,19 +269,19 @@ func runOnboardInteractive(opts onboardOptions, cfg config.Config, existingCred
 				return saveErr
 			}
 			bound = true
-			fmt.Printf("Bound bot_id=%s status=%s\n", state.BotID, state.ActivationStatus)
+			output.Printf("Bound bot_id=%s status=%s\n", state.BotID, state.ActivationStatus)
 		} else {
 			if saveErr := binding.Save(binding.State{Bound: false, Version: version}); saveErr != nil {
 				return saveErr
 			}
 			bound = false
-			fmt.Println("Binding marked as not bound.")
+			output.Println("Binding marked as not bound.")
 		}
 	} else {
-		fmt.Println("Skipped binding because no validated API key is available.")
+		output.Println("Skipped binding because no validated API key is available.")
 	}
 
-	fmt.Println("\nStep E: Scheduling — Auto diary writing")
+	output.Println("\nStep E: Scheduling — Auto diary writing")
 	detectedOS := detectedScheduleOS()
 	selectedOS, err := utils.PromptString(reader, "Scheduling OS (linux/macos/windows)", detectedOS)
 	if err != nil {
@@ -294,17 +295,16 @@ func runOnboardInteractive(opts onboardOptions, cfg config.Config, existingCred
 	if err != nil {
 		return err
 	}
-	printScheduleSnippet(selectedOS, selectedHour)
-
-	installNow, promptErr := utils.PromptYesNo(reader, fmt.Sprintf("Auto-install schedule now (%s)?", selectedOS), true)
+	installNow, promptErr := utils.PromptYesNo(reader, "Schedule the daily diary run in the built-in scheduler (moltbb daemon)?", true)
 	if promptErr != nil {
 		return promptErr
 	}
 	if installNow {
-		if instErr := installScheduleForOS(selectedOS, selectedHour); instErr != nil {
-			fmt.Printf("[WARN] schedule install: %v\n", instErr)
+		if instErr := installDiarySchedule(selectedHour); instErr != nil {
+			output.Printf("[WARN] schedule install: %v\n", instErr)
 		}
 	} else {
+		printScheduleSnippet(selectedOS, selectedHour)
 		generateFiles, genPromptErr := utils.PromptYesNo(reader, "Generate scheduling example files in ~/.moltbb/examples?", false)
 		if genPromptErr != nil {
 			return genPromptErr
@@ -314,12 +314,12 @@ func runOnboardInteractive(opts onboardOptions, cfg config.Config, existingCred
 			if genErr != nil {
 				return genErr
 			}
-			fmt.Println("Generated scheduling examples in:", path)
+			output.Println("Generated scheduling examples in:", path)
 		}
 	}
 
-	fmt.Println("\nStep F: Local web dashboard")
-	fmt.Println("The local dashboard lets you browse and manage your diaries/insights at http://127.0.0.1:3789")
+	output.Println("\nStep F: Local web dashboard")
+	output.Println("The local dashboard lets you browse and manage your diaries/insights at http://127.0.0.1:3789")
 	startNow, daemonPromptErr := utils.PromptYesNo(reader, "Start local web dashboard now?", true)
 	if daemonPromptErr != nil {
 		return daemonPromptErr
@@ -327,10 +327,10 @@ func runOnboardInteractive(opts onboardOptions, cfg config.Config, existingCred
 	if startNow {
 		startDaemonNow()
 	} else {
-		fmt.Println("You can start it later with: moltbb daemon start")
+		output.Println("You can start it later with: moltbb daemon start")
 	}
 
-	fmt.Println("\nStep G: Final summary")
+	output.Println("\nStep G: Final summary")
 	return printOnboardSummary(cfg, keyValidated || existingCred != nil, bound)
 }
 
@@ -458,8 +458,8 @@ func runOnboardNonInteractive(opts onboardOptions, cfg config.Config, cfgExists
 	}
 	scheduleHour := normalizeScheduleHour(opts.scheduleHour)
 	if opts.installSchedule {
-		if instErr := installScheduleForOS(selectedOS, scheduleHour); instErr != nil {
-			fmt.Printf("[WARN] schedule install: %v\n", instErr)
+		if instErr := installDiarySchedule(scheduleHour); instErr != nil {
+			output.Printf("[WARN] schedule install: %v\n", instErr)
 		}
 	} else {
 		printScheduleSnippet(selectedOS, scheduleHour)
@@ -468,7 +468,7 @@ func runOnboardNonInteractive(opts onboardOptions, cfg config.Config, cfgExists
 			if genErr != nil {
 				return genErr
 			}
-			fmt.Println("Generated scheduling examples in:", path)
+			output.Println("Generated scheduling examples in:", path)
 		}
 	}
 
@@ -519,11 +519,11 @@ func warnMissingInputPaths(paths []string) {
 	for _, p := range paths {
 		expanded, err := utils.ExpandPath(p)
 		if err != nil {
-			fmt.Printf("[WARN] input path invalid: %s (%v)\n", p, err)
+			output.Printf("[WARN] input path invalid: %s (%v)\n", p, err)
 			continue
 		}
 		if _, err := os.Stat(expanded); err != nil {
-			fmt.Printf("[WARN] input path missing: %s\n", expanded)
+			output.Printf("[WARN] input path missing: %s\n", expanded)
 		}
 	}
 }
@@ -531,40 +531,40 @@ func warnMissingInputPaths(paths []string) {
 func warnOutputDir(outputDir string) {
 	expanded, err := utils.ExpandPath(outputDir)
 	if err != nil {
-		fmt.Printf("[WARN] output_dir invalid: %v\n", err)
+		output.Printf("[WARN] output_dir invalid: %v\n", err)
 		return
 	}
 	if err := utils.EnsureDir(expanded, 0o700); err != nil {
-		fmt.Printf("[WARN] output_dir not writable: %s (%v)\n", expanded, err)
+		output.Printf("[WARN] output_dir not writable: %s (%v)\n", expanded, err)
 	}
 }
 
 func printOnboardSummary(cfg config.Config, hasKey, bound bool) error {
 	cfgPath, _ := utils.ConfigPath()
-	fmt.Println("Onboard summary:")
-	fmt.Println("- config path:", cfgPath)
-	fmt.Println("- output_dir:", cfg.OutputDir)
-	fmt.Printf("- api key configured: %v\n", hasKey)
-	fmt.Printf("- bound: %v\n", bound)
+	output.Println("Onboard summary:")
+	output.Println("- config path:", cfgPath)
+	output.Println("- output_dir:", cfg.OutputDir)
+	output.Printf("- api key configured: %v\n", hasKey)
+	output.Printf("- bound: %v\n", bound)
 
 	// 判断设置是否完成：需要同时有 API key 和绑定
 	setupComplete := hasKey && bound
-	fmt.Printf("\nSetup complete: %v\n", setupComplete)
+	output.Printf("\nSetup complete: %v\n", setupComplete)
 
 	if !setupComplete {
-		fmt.Println("\nSetup incomplete. Please complete the following:")
+		output.Println("\nSetup incomplete. Please complete the following:")
 		if !hasKey {
-			fmt.Println("  ⚠ Missing API key:")
-			fmt.Println("    - Register a new bot to get an API key")
-			fmt.Println("    - Or provide your existing API key using 'moltbb login --apikey <key>'")
+			output.Println("  ⚠ Missing API key:")
+			output.Println("    - Register a new bot to get an API key")
+			output.Println("    - Or provide your existing API key using 'moltbb login --apikey <key>'")
 		}
 		if !bound {
-			fmt.Println("  ⚠ Missing binding:")
-			fmt.Println("    - Run 'moltbb bind' to bind this machine as the bot owner")
+			output.Println("  ⚠ Missing binding:")
+			output.Println("    - Run 'moltbb bind' to bind this machine as the bot owner")
 		}
-		fmt.Println("\nAfter completing the above steps, run 'moltbb status' to verify.")
+		output.Println("\nAfter completing the above steps, run 'moltbb status' to verify.")
 	} else {
-		fmt.Println("✓ All setup complete! You can now run 'moltbb run'")
+		output.Println("✓ All setup complete! You can now run 'moltbb run'")
 	}
 
 	return nil
@@ -595,8 +595,8 @@ func normalizeScheduleOS(value string) string {
 }
 
 func printScheduleSnippet(osType string, hour int) {
-	fmt.Printf("Scheduling snippet (daily at %02d:00):\n", hour)
-	fmt.Println(scheduleSnippet(osType, hour))
+	output.Printf("Scheduling snippet (daily at %02d:00):\n", hour)
+	output.Println(scheduleSnippet(osType, hour))
 }
 
 func scheduleSnippet(osType string, hour int) string {
@@ -674,7 +674,7 @@ func promptScheduleHour(reader *bufio.Reader) (int, error) {
 		case "22":
 			return 22, nil
 		default:
-			fmt.Println("Please enter 20, 21, or 22.")
+			output.Println("Please enter 20, 21, or 22.")
 		}
 	}
 }
@@ -687,39 +687,81 @@ func normalizeScheduleHour(h int) int {
 	return 21
 }
 
-// installScheduleForOS installs the diary writing schedule for the detected OS.
-func installScheduleForOS(osType string, hour int) error {
-	switch osType {
-	case "macos":
-		return installLaunchdSchedule(hour)
+// installDiarySchedule adds (or moves) the daily "diary" run job in the
+// built-in scheduler. The scheduler only runs while a studio does, so a
+// cron/launchd entry runs the job as well when no scheduler is running.
+func installDiarySchedule(hour int) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	job := config.ScheduleJob{Name: "diary", Kind: "run", Cron: fmt.Sprintf("0 %d * * *", hour)}
+	replaced := false
+	for i := range cfg.Schedule.Jobs {
+		if cfg.Schedule.Jobs[i].Name == job.Name {
+			cfg.Schedule.Jobs[i] = job
+			replaced = true
+		}
+	}
+	if !replaced {
+		cfg.Schedule.Jobs = append(cfg.Schedule.Jobs, job)
+	}
+	if err := config.Save(cfg); err != nil {
+		return err
+	}
+	output.Printf("✅ Diary run scheduled daily at %02d:00 (runs inside 'moltbb daemon'; see 'moltbb schedule list')\n", hour)
+	if err := installFallbackSchedule(hour); err != nil {
+		output.Printf("[WARN] fallback schedule not installed: %v\n", err)
+	}
+	return nil
+}
+
+// fallbackScheduleArgs is what the cron/launchd entry runs: the diary job,
+// unless a studio's scheduler is running it already.
+var fallbackScheduleArgs = []string{"schedule", "run-now", "diary", "--if-idle"}
+
+// installFallbackSchedule installs (or replaces) the cron/launchd entry
+// that runs the diary job while no studio is running. Older versions'
+// entries ran 'moltbb run' directly and are replaced.
+func installFallbackSchedule(hour int) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return installLaunchdFallback(hour)
 	case "linux":
-		return installCronSchedule(hour)
+		return installCronFallback(hour)
 	default:
-		fmt.Println("Auto-install not supported on Windows. Please configure Task Scheduler manually using the snippet above.")
+		output.Println("No fallback schedule on this OS; the diary job runs while 'moltbb daemon' is running.")
 		return nil
 	}
 }
 
-func installLaunchdSchedule(hour int) error {
-	home := os.Getenv("HOME")
+func installLaunchdFallback(hour int) error {
+	home, err := utils.HomeDir()
+	if err != nil {
+		return err
+	}
 	plistDir := filepath.Join(home, "Library", "LaunchAgents")
 	plistPath := filepath.Join(plistDir, "com.moltbb.run.plist")
 	logDir := filepath.Join(home, ".moltbb", "logs")
 
+	var args strings.Builder
+	for _, arg := range append([]string{moltbbBinaryPath()}, fallbackScheduleArgs...) {
+		args.WriteString("<string>" + arg + "</string>")
+	}
 	content := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
 <!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
 <plist version="1.0">
 <dict>
   <key>Label</key><string>com.moltbb.run</string>
   <key>ProgramArguments</key>
-  <array><string>%s</string><string>run</string></array>
+  <array>%s</array>
   <key>StartCalendarInterval</key>
   <dict><key>Hour</key><integer>%d</integer><key>Minute</key><integer>0</integer></dict>
   <key>StandardOutPath</key><string>%s/launchd.out.log</string>
   <key>StandardErrorPath</key><string>%s/launchd.err.log</string>
 </dict>
 </plist>
-`, moltbbBinaryPath(), hour, logDir, logDir)
+`, args.String(), hour, logDir, logDir)
 
 	if err := os.MkdirAll(plistDir, 0o755); err != nil {
 		return fmt.Errorf("create LaunchAgents dir: %w", err)
@@ -730,51 +772,37 @@ func installLaunchdSchedule(hour int) error {
 	if err := os.WriteFile(plistPath, []byte(content), 0o644); err != nil {
 		return fmt.Errorf("write plist: %w", err)
 	}
-
-	// Unload first (ignore error if not loaded)
 	exec.Command("launchctl", "unload", plistPath).Run() //nolint:errcheck
-
-	loadCmd := exec.Command("launchctl", "load", plistPath)
-	loadCmd.Stdout = os.Stdout
-	loadCmd.Stderr = os.Stderr
-	if err := loadCmd.Run(); err != nil {
-		fmt.Printf("[WARN] launchctl load: %v\n", err)
-		fmt.Println("Run manually: launchctl load", plistPath)
-	} else {
-		fmt.Printf("✅ Schedule installed: daily at %02d:00\n", hour)
+	if err := exec.Command("launchctl", "load", plistPath).Run(); err != nil {
+		return fmt.Errorf("launchctl load %s: %w", plistPath, err)
 	}
+	output.Println("✅ Fallback launchd schedule installed:", plistPath)
 	return nil
 }
 
-func installCronSchedule(hour int) error {
-	existingBytes, _ := exec.Command("crontab", "-l").Output()
-	existing := string(existingBytes)
-
-	moltbbPath := moltbbBinaryPath()
-	newLine := fmt.Sprintf("0 %d * * * %s run >> ~/.moltbb/logs/cron.log 2>&1  # moltbb-diary", hour, moltbbPath)
+func installCronFallback(hour int) error {
+	existing, _ := exec.Command("crontab", "-l").Output()
+	line := fmt.Sprintf("0 %d * * * %s %s >> ~/.moltbb/logs/cron.log 2>&1  # moltbb-diary",
+		hour, moltbbBinaryPath(), strings.Join(fallbackScheduleArgs, " "))
 
-	// Remove any existing moltbb diary cron line
 	var kept []string
-	for _, line := range strings.Split(existing, "\n") {
-		if !strings.Contains(line, "moltbb run") && !strings.Contains(line, "# moltbb-diary") {
-			kept = append(kept, line)
+	for _, l := range strings.Split(string(existing), "\n") {
+		if !strings.Contains(l, "# moltbb-diary") {
+			kept = append(kept, l)
 		}
 	}
-	// Trim trailing blank lines then append new entry
 	for len(kept) > 0 && strings.TrimSpace(kept[len(kept)-1]) == "" {
 		kept = kept[:len(kept)-1]
 	}
-	kept = append(kept, newLine, "")
-	newCrontab := strings.Join(kept, "\n")
+	kept = append(kept, line, "")
 
 	cmd := exec.Command("crontab", "-")
-	cmd.Stdin = strings.NewReader(newCrontab)
-	cmd.Stdout = os.Stdout
+	cmd.Stdin = strings.NewReader(strings.Join(kept, "\n"))
 	cmd.Stderr = os.Stderr
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("install cron job: %w", err)
+		return fmt.Errorf("install cron entry: %w", err)
 	}
-	fmt.Printf("✅ Cron job installed: daily at %02d:00\n", hour)
+	output.Println("✅ Fallback cron entry installed (# moltbb-diary)")
 	return nil
 }
 
@@ -790,15 +818,15 @@ func moltbbBinaryPath() string {
 func startDaemonNow() {
 	moltbbPath, err := exec.LookPath("moltbb")
 	if err != nil {
-		fmt.Println("[WARN] moltbb not found in PATH, cannot start daemon automatically.")
-		fmt.Println("Run manually: moltbb daemon start")
+		output.Println("[WARN] moltbb not found in PATH, cannot start daemon automatically.")
+		output.Println("Run manually: moltbb daemon start")
 		return
 	}
 	cmd := exec.Command(moltbbPath, "daemon", "start")
-	cmd.Stdout = os.Stdout
+	cmd.Stdout = output.Writer()
 	cmd.Stderr = os.Stderr
 	if err := cmd.Run(); err != nil {
-		fmt.Printf("[WARN] daemon start: %v\n", err)
-		fmt.Println("Run manually: moltbb daemon start")
+		output.Printf("[WARN] daemon start: %v\n", err)
+		output.Println("Run manually: moltbb daemon start")
 	}
 }
@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -16,6 +18,8 @@ import (
 	"moltbb-cli/internal/auth"
 	"moltbb-cli/internal/binding"
 	"moltbb-cli/internal/config"
+	"moltbb-cli/internal/e2e"
+	"moltbb-cli/internal/localweb"
 	"moltbb-cli/internal/output"
 )
 
@@ -38,6 +42,11 @@ Room mode supports short commands for create/send/info and a persistent
 	cmd.AddCommand(newPipelineEndCmd())
 	cmd.AddCommand(newPipelineHistoryCmd())
 	cmd.AddCommand(newPipelineStatusCmd())
+	cmd.AddCommand(newPipelineKeysCmd())
+	cmd.AddCommand(newPipelineServeCmd())
+	cmd.AddCommand(newPipelineTranscriptCmd())
+	cmd.AddCommand(newPipelineChatCmd())
+	cmd.AddCommand(newPipelineModerateCmd())
 	// Room Mode commands
 	cmd.AddCommand(newPipelineCreateRoomCmd())
 	cmd.AddCommand(newPipelineJoinRoomCmd())
@@ -100,8 +109,9 @@ func newPipelineConnectCmd() *cobra.Command {
 		Use:   "connect",
 		Short: "Connect to pipeline and listen for messages",
 		Long: `Establish a persistent WebSocket connection to the pipeline system.
-Displays incoming invitations and messages in real time.
-Press Ctrl+C to disconnect.`,
+Displays incoming invitations and messages in real time. If the connection
+drops it reconnects with backoff, refreshing the bot JWT when it has expired
+and joining the pipeline again. Press Ctrl+C to disconnect.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cfg, err := config.Load()
 			if err != nil {
@@ -116,26 +126,15 @@ Press Ctrl+C to disconnect.`,
 				return err
 			}
 
-			ctx, cancel := context.WithCancel(context.Background())
-			defer cancel()
-
-			sc, err := client.ConnectToHub(ctx, token)
-			if err != nil {
-				return fmt.Errorf("connect to pipeline: %w", err)
-			}
-			defer sc.Close()
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
 
-			// Join the pipeline group
-			joinCtx, joinCancel := context.WithTimeout(ctx, 10*time.Second)
-			if err := sc.InvokeVoid(joinCtx, "JoinPipeline"); err != nil {
-				joinCancel()
-				return fmt.Errorf("join pipeline: %w", err)
-			}
-			joinCancel()
-
-			output.PrintSuccess("Connected to pipeline")
-			fmt.Println("Listening for invitations and messages… (Ctrl+C to exit)")
-			fmt.Println()
+			sc := newPipelineHub(client, token, func(ctx context.Context, sc *api.SignalRConn) error {
+				if err := sc.InvokeVoid(ctx, "JoinPipeline"); err != nil {
+					return fmt.Errorf("join pipeline: %w", err)
+				}
+				return nil
+			}, nil)
 
 			// Register push-event handlers
 			sc.On("Pipeline.InvitationReceived", func(args []json.RawMessage) {
@@ -147,10 +146,10 @@ Press Ctrl+C to disconnect.`,
 					return
 				}
 				ts := time.Now().Format("2006-01-02 15:04:05")
-				fmt.Printf("[%s] Invitation received from %s\n", ts, inv.InitiatorBotId)
-				fmt.Printf("  Session Token: %s\n", inv.SessionToken)
-				fmt.Printf("  Accept with:   moltbb pipeline accept %s\n", inv.SessionToken)
-				fmt.Printf("  Reject with:   moltbb pipeline reject %s\n\n", inv.SessionToken)
+				output.Printf("[%s] Invitation received from %s\n", ts, inv.InitiatorBotId)
+				output.Printf("  Session Token: %s\n", inv.SessionToken)
+				output.Printf("  Accept with:   moltbb pipeline accept %s\n", inv.SessionToken)
+				output.Printf("  Reject with:   moltbb pipeline reject %s\n\n", inv.SessionToken)
 			})
 
 			sc.On("Pipeline.MessageReceived", func(args []json.RawMessage) {
@@ -162,8 +161,26 @@ Press Ctrl+C to disconnect.`,
 					return
 				}
 				ts := time.Now().Format("2006-01-02 15:04:05")
-				fmt.Printf("[%s] Message from %s in session %s:\n", ts, msg.SenderBotId, msg.SessionToken)
-				fmt.Printf("  %s\n\n", msg.Content)
+				opened, err := openPipelineMessage(msg)
+				if err != nil {
+					output.PrintWarning(fmt.Sprintf("[%s] Message from %s in session %s: %v", ts, msg.SenderBotId, msg.SessionToken, err))
+					return
+				}
+				if opened.KeyEvent {
+					if opened.Notice != "" {
+						output.Printf("[%s] %s\n\n", ts, opened.Notice)
+					}
+					// Answer with our key so the peer can encrypt too.
+					go announceOnHub(ctx, sc, msg.SessionToken, msg.SenderBotId)
+					return
+				}
+				lock := ""
+				if opened.Sealed {
+					lock = " 🔒"
+				}
+				output.Printf("[%s] Message from %s in session %s:%s\n", ts, msg.SenderBotId, msg.SessionToken, lock)
+				output.Printf("  %s\n\n", opened.Text)
+				archiveSessionMessage(localweb.TranscriptReceived, msg, opened.Text, opened.Sealed)
 			})
 
 			sc.On("Pipeline.SessionAccepted", func(args []json.RawMessage) {
@@ -175,7 +192,8 @@ Press Ctrl+C to disconnect.`,
 					return
 				}
 				ts := time.Now().Format("2006-01-02 15:04:05")
-				fmt.Printf("[%s] Session accepted: %s (Status: %s)\n\n", ts, sess.SessionToken, sess.Status)
+				output.Printf("[%s] Session accepted: %s (Status: %s)\n\n", ts, sess.SessionToken, sess.Status)
+				go announceOnHub(ctx, sc, sess.SessionToken, sess.ResponderBotId)
 			})
 
 			sc.On("Pipeline.SessionRejected", func(args []json.RawMessage) {
@@ -190,9 +208,9 @@ Press Ctrl+C to disconnect.`,
 				}
 				ts := time.Now().Format("2006-01-02 15:04:05")
 				if reason != "" {
-					fmt.Printf("[%s] Session rejected: %s (Reason: %s)\n\n", ts, token, reason)
+					output.Printf("[%s] Session rejected: %s (Reason: %s)\n\n", ts, token, reason)
 				} else {
-					fmt.Printf("[%s] Session rejected: %s\n\n", ts, token)
+					output.Printf("[%s] Session rejected: %s\n\n", ts, token)
 				}
 			})
 
@@ -209,18 +227,27 @@ Press Ctrl+C to disconnect.`,
 				if meta.DurationSeconds != nil {
 					dur = formatDuration(*meta.DurationSeconds)
 				}
-				fmt.Printf("[%s] Session ended: %s (Messages: %d, Duration: %s)\n\n",
+				output.Printf("[%s] Session ended: %s (Messages: %d, Duration: %s)\n\n",
 					ts, meta.SessionToken, meta.MessageCount, dur)
 			})
 
-			// Wait for Ctrl+C or connection drop
-			quit := make(chan os.Signal, 1)
-			signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+			if err := sc.Connect(ctx); err != nil {
+				return fmt.Errorf("connect to pipeline: %w", err)
+			}
+			defer sc.Close()
+
+			output.PrintSuccess("Connected to pipeline")
+			output.Println("Listening for invitations and messages… (Ctrl+C to exit)")
+			output.Println()
+
+			// Wait for Ctrl+C, or for the connection to give up reconnecting
 			select {
-			case <-quit:
-				fmt.Println("\nDisconnecting…")
+			case <-ctx.Done():
+				output.Println("\nDisconnecting…")
 			case <-sc.Done():
-				output.PrintWarning("Connection closed by server")
+				if err := sc.Err(); err != nil {
+					return fmt.Errorf("pipeline connection: %w", err)
+				}
 			}
 			return nil
 		},
@@ -262,27 +289,36 @@ func newPipelineInviteCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if err := updateKeyring(func(kr *e2e.Keyring) error {
+				kr.SetSessionPeer(inv.SessionToken, responderBotId)
+				return nil
+			}); err != nil {
+				output.PrintWarning(fmt.Sprintf("Could not record session peer for encryption: %v", err))
+			}
 
+			if output.Structured() {
+				return output.Result("moltbb.pipeline.invite.v1", inv)
+			}
 			if jsonOutput {
 				b, _ := json.Marshal(inv)
-				fmt.Println(string(b))
+				output.Println(string(b))
 				return nil
 			}
 
 			output.PrintSuccess("Invitation sent successfully!")
-			fmt.Println()
-			fmt.Println("Session Token:", inv.SessionToken)
-			fmt.Println("Responder Bot:", inv.ResponderBotId)
-			fmt.Println("Status:        Pending")
+			output.Println()
+			output.Println("Session Token:", inv.SessionToken)
+			output.Println("Responder Bot:", inv.ResponderBotId)
+			output.Println("Status:        Pending")
 			if inv.ExpiresAt != "" {
-				fmt.Println("Expires At:   ", inv.ExpiresAt)
-			}
-			fmt.Println()
-			fmt.Println("The other bot can accept with:")
-			fmt.Println("  moltbb pipeline accept", inv.SessionToken)
-			fmt.Println()
-			fmt.Println("Or reject with:")
-			fmt.Println("  moltbb pipeline reject", inv.SessionToken)
+				output.Println("Expires At:   ", inv.ExpiresAt)
+			}
+			output.Println()
+			output.Println("The other bot can accept with:")
+			output.Println("  moltbb pipeline accept", inv.SessionToken)
+			output.Println()
+			output.Println("Or reject with:")
+			output.Println("  moltbb pipeline reject", inv.SessionToken)
 			return nil
 		},
 	}
@@ -322,23 +358,30 @@ func newPipelineAcceptCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			keyErr := announceKey(ctx, oneShotSender(client, token), sess.SessionToken, sess.InitiatorBotId)
 
+			if output.Structured() {
+				return output.Result("moltbb.pipeline.accept.v1", sess)
+			}
 			if jsonOutput {
 				b, _ := json.Marshal(sess)
-				fmt.Println(string(b))
+				output.Println(string(b))
 				return nil
 			}
 
 			output.PrintSuccess("Session accepted!")
-			fmt.Println()
-			fmt.Println("Session Token:", sess.SessionToken)
-			fmt.Println("Status:       ", sess.Status)
-			fmt.Println("Participants:")
-			fmt.Printf("  - %s (Initiator)\n", sess.InitiatorBotName)
-			fmt.Printf("  - %s (You)\n", sess.ResponderBotName)
-			fmt.Println()
-			fmt.Println("Send messages with:")
-			fmt.Println("  moltbb pipeline send", sess.SessionToken, `"Your message"`)
+			if keyErr != nil {
+				output.PrintWarning(fmt.Sprintf("Encryption key not shared; sends fail until it is (or use --plain): %v", keyErr))
+			}
+			output.Println()
+			output.Println("Session Token:", sess.SessionToken)
+			output.Println("Status:       ", sess.Status)
+			output.Println("Participants:")
+			output.Printf("  - %s (Initiator)\n", sess.InitiatorBotName)
+			output.Printf("  - %s (You)\n", sess.ResponderBotName)
+			output.Println()
+			output.Println("Send messages with:")
+			output.Println("  moltbb pipeline send", sess.SessionToken, `"Your message"`)
 			return nil
 		},
 	}
@@ -379,16 +422,22 @@ func newPipelineRejectCmd() *cobra.Command {
 				return err
 			}
 
+			rejected := struct {
+				SessionToken string `json:"sessionToken"`
+				Reason       string `json:"reason"`
+			}{sessionToken, reason}
+			if output.Structured() {
+				return output.Result("moltbb.pipeline.reject.v1", rejected)
+			}
 			if jsonOutput {
-				fmt.Printf(`{"sessionToken":%q,"reason":%q}%s`, sessionToken, reason, "\n")
-				return nil
+				return printJSONLine(rejected)
 			}
 
 			output.PrintSuccess("Session rejected")
-			fmt.Println()
-			fmt.Println("Session Token:", sessionToken)
+			output.Println()
+			output.Println("Session Token:", sessionToken)
 			if reason != "" {
-				fmt.Println("Reason:       ", reason)
+				output.Println("Reason:       ", reason)
 			}
 			return nil
 		},
@@ -402,13 +451,20 @@ func newPipelineRejectCmd() *cobra.Command {
 
 func newPipelineSendCmd() *cobra.Command {
 	var messageFile string
+	var peerBotID string
+	var plain bool
 	var jsonOutput bool
 
 	cmd := &cobra.Command{
 		Use:   "send <session-token> <message>",
 		Short: "Send a message in an active session",
 		Long: `Send a message to the other bot in the active session.
-You can provide the message as an argument or read from a file with --file.`,
+You can provide the message as an argument or read from a file with --file.
+
+Messages are end-to-end encrypted once the other bot's key is known (see
+"moltbb pipeline keys"). The first send in a session shares your public key;
+until the peer's key arrives the send fails, unless you opt out of
+encryption with --plain.`,
 		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			sessionToken := strings.TrimSpace(args[0])
@@ -426,7 +482,7 @@ You can provide the message as an argument or read from a file with --file.`,
 				return fmt.Errorf("message is required (pass as argument or use --file)")
 			}
 
-			if len(content) > 1048576 {
+			if len(content) > maxPipelineMessageBytes {
 				return fmt.Errorf("message exceeds 1 MB limit (%d bytes)", len(content))
 			}
 
@@ -446,25 +502,45 @@ You can provide the message as an argument or read from a file with --file.`,
 			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 			defer cancel()
 
-			resp, err := client.PipelineSendMessage(ctx, token, sessionToken, content, nil)
+			var meta *api.PipelineEncryptionMeta
+			body := content
+			if !plain {
+				body, meta, err = sealPipelineMessage(ctx, client, token, sessionToken, strings.TrimSpace(peerBotID), content)
+				if err != nil {
+					return fmt.Errorf("%w (use --plain to send unencrypted)", err)
+				}
+			}
+			if len(body) > maxPipelineMessageBytes {
+				return fmt.Errorf("encrypted message exceeds 1 MB limit (%d bytes)", len(body))
+			}
+
+			resp, err := client.PipelineSendMessage(ctx, token, sessionToken, body, meta)
 			if err != nil {
 				return err
 			}
+			archiveSessionMessage(localweb.TranscriptSent, *resp, content, meta != nil)
 
+			if output.Structured() {
+				return output.Result("moltbb.pipeline.send.v1", resp)
+			}
 			if jsonOutput {
 				b, _ := json.Marshal(resp)
-				fmt.Println(string(b))
+				output.Println(string(b))
 				return nil
 			}
 
 			output.PrintSuccess("Message sent")
-			fmt.Println()
-			fmt.Println("Session:", resp.SessionToken)
-			fmt.Println("To:     ", resp.RecipientBotId)
+			output.Println()
+			output.Println("Session:", resp.SessionToken)
+			output.Println("To:     ", resp.RecipientBotId)
 			if resp.SentAt != "" {
-				fmt.Println("Sent:   ", resp.SentAt)
+				output.Println("Sent:   ", resp.SentAt)
+			}
+			output.Printf("Size:    %d bytes\n", len(content))
+			if meta != nil {
+				_, peerKey, _ := strings.Cut(meta.KeyId, ":")
+				output.Println("E2E:     🔒 encrypted for key", e2e.Fingerprint(peerKey))
 			}
-			fmt.Printf("Size:    %d bytes\n", len(content))
 			if resp.Queued {
 				output.PrintInfo("Recipient is offline — message queued for delivery")
 			}
@@ -472,6 +548,8 @@ You can provide the message as an argument or read from a file with --file.`,
 		},
 	}
 	cmd.Flags().StringVarP(&messageFile, "file", "f", "", "Read message content from file")
+	cmd.Flags().StringVar(&peerBotID, "to", "", "Bot ID of the other party, when it cannot be worked out")
+	cmd.Flags().BoolVar(&plain, "plain", false, "Send without end-to-end encryption")
 	cmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output as JSON")
 	return cmd
 }
@@ -509,19 +587,22 @@ func newPipelineEndCmd() *cobra.Command {
 				return err
 			}
 
+			if output.Structured() {
+				return output.Result("moltbb.pipeline.end.v1", meta)
+			}
 			if jsonOutput {
 				b, _ := json.Marshal(meta)
-				fmt.Println(string(b))
+				output.Println(string(b))
 				return nil
 			}
 
 			output.PrintSuccess("Session ended")
-			fmt.Println()
-			fmt.Println("Session Token:", meta.SessionToken)
-			fmt.Println("Status:       ", meta.Status)
-			fmt.Printf("Messages:      %d\n", meta.MessageCount)
+			output.Println()
+			output.Println("Session Token:", meta.SessionToken)
+			output.Println("Status:       ", meta.Status)
+			output.Printf("Messages:      %d\n", meta.MessageCount)
 			if meta.DurationSeconds != nil {
-				fmt.Println("Duration:     ", formatDuration(*meta.DurationSeconds))
+				output.Println("Duration:     ", formatDuration(*meta.DurationSeconds))
 			}
 			return nil
 		},
@@ -562,9 +643,12 @@ func newPipelineHistoryCmd() *cobra.Command {
 				return err
 			}
 
+			if output.Structured() {
+				return output.Result("moltbb.pipeline.history.v1", result)
+			}
 			if jsonOutput {
 				b, _ := json.Marshal(result.Items)
-				fmt.Println(string(b))
+				output.Println(string(b))
 				return nil
 			}
 
@@ -575,16 +659,16 @@ func newPipelineHistoryCmd() *cobra.Command {
 			if totalPages == 0 {
 				totalPages = 1
 			}
-			fmt.Printf("Session History (Page %d/%d)\n\n", result.Page, totalPages)
+			output.Printf("Session History (Page %d/%d)\n\n", result.Page, totalPages)
 
 			if len(result.Items) == 0 {
-				fmt.Println("No sessions found.")
+				output.Println("No sessions found.")
 				return nil
 			}
 
-			fmt.Printf("%-20s  %-22s  %-10s  %8s  %8s  %10s\n",
+			output.Printf("%-20s  %-22s  %-10s  %8s  %8s  %10s\n",
 				"TOKEN", "PARTNER", "STATUS", "MESSAGES", "DURATION", "DATE")
-			fmt.Println(strings.Repeat("-", 86))
+			output.Println(strings.Repeat("-", 86))
 
 			for _, s := range result.Items {
 				partner := partnerName(s)
@@ -604,12 +688,12 @@ func newPipelineHistoryCmd() *cobra.Command {
 						date = s.CreatedAt[:10]
 					}
 				}
-				fmt.Printf("%-20s  %-22s  %-10s  %8d  %8s  %10s\n",
+				output.Printf("%-20s  %-22s  %-10s  %8d  %8s  %10s\n",
 					token, partner, s.Status, s.MessageCount, dur, date)
 			}
 
-			fmt.Println()
-			fmt.Printf("Total: %d sessions\n", result.TotalCount)
+			output.Println()
+			output.Printf("Total: %d sessions\n", result.TotalCount)
 			return nil
 		},
 	}
@@ -655,30 +739,33 @@ func newPipelineStatusCmd() *cobra.Command {
 				return err
 			}
 
+			if output.Structured() {
+				return output.Result("moltbb.pipeline.status.v1", st)
+			}
 			if jsonOutput {
 				b, _ := json.Marshal(st)
-				fmt.Println(string(b))
+				output.Println(string(b))
 				return nil
 			}
 
 			output.PrintSection("Pipeline Status")
-			fmt.Println("Bot ID:         ", st.BotId)
+			output.Println("Bot ID:         ", st.BotId)
 			onlineStatus := "Offline"
 			if st.IsOnline {
 				onlineStatus = "Online"
 			}
-			fmt.Println("Status:         ", onlineStatus)
+			output.Println("Status:         ", onlineStatus)
 			if st.LastHeartbeat != nil && *st.LastHeartbeat != "" {
 				if t, err := time.Parse(time.RFC3339, *st.LastHeartbeat); err == nil {
-					fmt.Printf("Last Heartbeat:  %s (%s ago)\n",
+					output.Printf("Last Heartbeat:  %s (%s ago)\n",
 						t.UTC().Format("2006-01-02 15:04:05 UTC"),
 						formatTimeAgo(t))
 				} else {
-					fmt.Println("Last Heartbeat: ", *st.LastHeartbeat)
+					output.Println("Last Heartbeat: ", *st.LastHeartbeat)
 				}
 			}
-			fmt.Println("Queued Messages:", st.QueuedMessagesCount)
-			fmt.Println("Active Sessions:", st.ActiveSessionsCount)
+			output.Println("Queued Messages:", st.QueuedMessagesCount)
+			output.Println("Active Sessions:", st.ActiveSessionsCount)
 			return nil
 		},
 	}
@@ -760,23 +847,26 @@ inactivity timeout.`,
 				return err
 			}
 
+			if output.Structured() {
+				return output.Result("moltbb.pipeline.create-room.v1", room)
+			}
 			if jsonOutput {
 				b, _ := json.Marshal(room)
-				fmt.Println(string(b))
+				output.Println(string(b))
 				return nil
 			}
 
 			output.PrintSuccess("Room created!")
-			fmt.Println()
-			fmt.Printf("  Room Code:   %s\n", room.RoomCode)
-			fmt.Printf("  Capacity:    %d bots\n", room.Capacity)
-			fmt.Printf("  Password:    %v\n", room.HasPassword)
+			output.Println()
+			output.Printf("  Room Code:   %s\n", room.RoomCode)
+			output.Printf("  Capacity:    %d bots\n", room.Capacity)
+			output.Printf("  Password:    %v\n", room.HasPassword)
 			if room.ExpiresAt != "" {
-				fmt.Printf("  Expires At:  %s\n", room.ExpiresAt)
+				output.Printf("  Expires At:  %s\n", room.ExpiresAt)
 			}
-			fmt.Println()
-			fmt.Println("Share this code with other bots:")
-			fmt.Printf("  moltbb pipeline join-room %s\n", room.RoomCode)
+			output.Println()
+			output.Println("Share this code with other bots:")
+			output.Printf("  moltbb pipeline join-room %s\n", room.RoomCode)
 			return nil
 		},
 	}
@@ -801,7 +891,8 @@ Without --listen, this command performs a one-shot join and returns immediately.
 With --listen, it keeps a long-lived SignalR connection open, joins the room on
 that same connection, prints the current participant list, fetches recent cached
 messages when supported by the server, and then streams new room messages until
-you press Ctrl+C.`,
+you press Ctrl+C. If the connection drops it reconnects, joins the room again
+and prints the messages sent in the meantime.`,
 		Example: `  moltbb pipeline auth
   moltbb pipeline join-room room-ab12cd
   moltbb pipeline join-room room-ab12cd --password secret
@@ -836,61 +927,69 @@ you press Ctrl+C.`,
 					result.Participants = participants
 				}
 
+				if output.Structured() {
+					return output.Result("moltbb.pipeline.join-room.v1", result)
+				}
 				if jsonOutput {
 					b, _ := json.Marshal(result)
-					fmt.Println(string(b))
+					output.Println(string(b))
 					return nil
 				}
 
 				output.PrintSuccess("Joined room: " + result.RoomCode)
-				fmt.Println()
+				output.Println()
 				printRoomParticipants(result.Participants)
-				fmt.Println()
-				fmt.Println("Send messages with:")
-				fmt.Printf("  moltbb pipeline send-room-message %s \"Your message\"\n", roomCode)
-				fmt.Println("Leave with:")
-				fmt.Printf("  moltbb pipeline leave-room %s\n", roomCode)
+				output.Println()
+				output.Println("Send messages with:")
+				output.Printf("  moltbb pipeline send-room-message %s \"Your message\"\n", roomCode)
+				output.Println("Leave with:")
+				output.Printf("  moltbb pipeline leave-room %s\n", roomCode)
 				return nil
 			}
 
 			// --listen mode: stay connected and print incoming messages
-			listenCtx, listenCancel := context.WithCancel(context.Background())
+			listenCtx, listenCancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 			defer listenCancel()
 
-			sc, err := client.ConnectToHub(listenCtx, token)
-			if err != nil {
-				return fmt.Errorf("connect to hub: %w", err)
-			}
-			defer sc.Close()
-
-			if err := sc.InvokeVoid(listenCtx, "JoinPipeline"); err != nil {
-				return fmt.Errorf("join pipeline: %w", err)
-			}
+			cursor := api.NewRoomCursor()
+			selfBotID := boundBotID()
+			var sc *api.ReconnectingConn
+			sc = newPipelineHub(client, token, func(ctx context.Context, conn *api.SignalRConn) error {
+				if err := conn.InvokeVoid(ctx, "JoinPipeline"); err != nil {
+					return fmt.Errorf("join pipeline: %w", err)
+				}
+				_, err := api.RoomHub{Conn: conn}.Join(ctx, roomCode, password)
+				return err
+			}, func(change api.StateChange) {
+				if change.State == api.StateConnected && change.Attempt > 0 {
+					backfillRoom(client, sc.Token(), roomCode, cursor)
+				}
+			})
 
 			sc.On("Room.MessageReceived", func(rawArgs []json.RawMessage) {
 				if len(rawArgs) == 0 {
 					return
 				}
 				var msg struct {
-					RoomCode      string `json:"roomCode"`
-					SenderBotId   string `json:"senderBotId"`
-					SenderBotName string `json:"senderBotName"`
-					Content       string `json:"content"`
-					Payload       string `json:"payload"`
+					api.RoomMessageDto
+					Payload string `json:"payload"`
 				}
 				if err := json.Unmarshal(rawArgs[0], &msg); err != nil {
 					return
 				}
-				body := msg.Content
-				if body == "" {
-					body = msg.Payload
+				if msg.Content == "" {
+					msg.Content = msg.Payload
+				}
+				if !cursor.Observe(msg.RoomMessageDto) {
+					return
 				}
+				archiveRoomMessage(roomCode, msg.RoomMessageDto, selfBotID)
 				sender := msg.SenderBotName
 				if sender == "" {
 					sender = msg.SenderBotId
 				}
 				ts := time.Now().Format("15:04:05")
-				fmt.Printf("[%s] 💬 %s: %s\n", ts, sender, body)
+				output.Printf("[%s] 💬 %s: %s\n", ts, sender, msg.Content)
 			})
 
 			sc.On("Room.ParticipantJoined", func(rawArgs []json.RawMessage) {
@@ -903,7 +1002,7 @@ you press Ctrl+C.`,
 				if err := json.Unmarshal(rawArgs[0], &ev); err != nil {
 					return
 				}
-				fmt.Printf("👤 %s joined the room\n", ev.BotId)
+				output.Printf("👤 %s joined the room\n", ev.BotId)
 			})
 
 			sc.On("Room.ParticipantLeft", func(rawArgs []json.RawMessage) {
@@ -917,27 +1016,30 @@ you press Ctrl+C.`,
 				if err := json.Unmarshal(rawArgs[0], &ev); err != nil {
 					return
 				}
-				fmt.Printf("👋 %s left the room\n", ev.BotId)
+				output.Printf("👋 %s left the room\n", ev.BotId)
 			})
 
+			roomClosed := make(chan struct{})
+			var closeOnce sync.Once
 			sc.On("Room.Closed", func(rawArgs []json.RawMessage) {
-				fmt.Println("🚪 Room has been closed")
-				listenCancel()
+				output.Println("🚪 Room has been closed")
+				closeOnce.Do(func() { close(roomClosed) })
 			})
 
-			if _, err := sc.Invoke(listenCtx, "JoinRoom", roomCode, password); err != nil {
-				return fmt.Errorf("join room: %w", err)
+			if err := sc.Connect(listenCtx); err != nil {
+				return fmt.Errorf("connect to hub: %w", err)
 			}
+			defer sc.Close()
 
 			participantsCtx, participantsCancel := context.WithTimeout(context.Background(), 10*time.Second)
-			participants, err := client.RoomGetParticipants(participantsCtx, token, roomCode)
+			participants, err := client.RoomGetParticipants(participantsCtx, sc.Token(), roomCode)
 			participantsCancel()
 			if err != nil {
 				return fmt.Errorf("get participants: %w", err)
 			}
 
 			backlogCtx, backlogCancel := context.WithTimeout(context.Background(), 10*time.Second)
-			recentMessages, err := client.RoomGetMessages(backlogCtx, token, roomCode, 20)
+			recentMessages, err := client.RoomGetMessages(backlogCtx, sc.Token(), roomCode, 20)
 			backlogCancel()
 			if err != nil && !supportsNoBacklog(err) {
 				return fmt.Errorf("get recent messages: %w", err)
@@ -946,42 +1048,41 @@ you press Ctrl+C.`,
 				output.PrintWarning("Server does not support room backlog yet; listening in real time only")
 				recentMessages = nil
 			}
+			for _, msg := range recentMessages {
+				cursor.Observe(msg)
+				archiveRoomMessage(roomCode, msg, selfBotID)
+			}
 
+			if output.Structured() {
+				return output.Result("moltbb.pipeline.join-room.v1", roomSnapshot{roomCode, participants, recentMessages})
+			}
 			if jsonOutput {
-				b, _ := json.Marshal(struct {
-					RoomCode     string                   `json:"roomCode"`
-					Participants []api.RoomParticipantDto `json:"participants"`
-					Messages     []api.RoomMessageDto     `json:"messages"`
-				}{
-					RoomCode:     roomCode,
-					Participants: participants,
-					Messages:     recentMessages,
-				})
-				fmt.Println(string(b))
+				b, _ := json.Marshal(roomSnapshot{roomCode, participants, recentMessages})
+				output.Println(string(b))
 				return nil
 			}
 
 			output.PrintSuccess("Joined room: " + roomCode)
-			fmt.Println()
+			output.Println()
 			printRoomParticipants(participants)
-			fmt.Println()
+			output.Println()
 			printRoomBacklog(recentMessages)
-			fmt.Println()
+			output.Println()
 
-			fmt.Println("💬 Listening for room messages… (Ctrl+C to leave)")
-			fmt.Println()
+			output.Println("💬 Listening for room messages… (Ctrl+C to leave)")
+			output.Println()
 
-			quit := make(chan os.Signal, 1)
-			signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 			select {
-			case <-quit:
-				fmt.Printf("\nLeaving room %s…\n", roomCode)
+			case <-listenCtx.Done():
+				output.Printf("\nLeaving room %s…\n", roomCode)
 				leaveCtx, leaveCancel := context.WithTimeout(context.Background(), 5*time.Second)
 				defer leaveCancel()
-				_ = client.RoomLeave(leaveCtx, token, roomCode)
+				_ = client.RoomLeave(leaveCtx, sc.Token(), roomCode)
+			case <-roomClosed:
 			case <-sc.Done():
-				output.PrintWarning("Connection closed by server")
-			case <-listenCtx.Done():
+				if err := sc.Err(); err != nil {
+					return fmt.Errorf("room connection: %w", err)
+				}
 			}
 			return nil
 		},
@@ -993,7 +1094,7 @@ you press Ctrl+C.`,
 }
 
 func printRoomParticipants(participants []api.RoomParticipantDto) {
-	fmt.Printf("👥 Participants (%d):\n", len(participants))
+	output.Printf("👥 Participants (%d):\n", len(participants))
 	for _, p := range participants {
 		role := ""
 		if p.IsCreator {
@@ -1003,38 +1104,69 @@ func printRoomParticipants(participants []api.RoomParticipantDto) {
 		if p.IsOnline {
 			online = "online"
 		}
-		fmt.Printf("  - %s%s, %s\n", p.BotName, role, online)
+		output.Printf("  - %s%s, %s\n", p.BotName, role, online)
 	}
 }
 
 func printRoomBacklog(messages []api.RoomMessageDto) {
 	if len(messages) == 0 {
-		fmt.Println("🕘 Recent messages: none")
+		output.Println("🕘 Recent messages: none")
 		return
 	}
 
-	fmt.Printf("🕘 Recent messages (%d):\n", len(messages))
+	output.Printf("🕘 Recent messages (%d):\n", len(messages))
 	for _, msg := range messages {
-		sender := msg.SenderBotName
-		if sender == "" {
-			sender = msg.SenderBotId
-		}
-		ts := msg.SentAt
-		if parsed, err := time.Parse(time.RFC3339Nano, msg.SentAt); err == nil {
-			ts = parsed.Local().Format("15:04:05")
-		}
-		fmt.Printf("  [%s] %s: %s\n", ts, sender, msg.Content)
+		printRoomMessage(msg)
 	}
 }
 
-func supportsNoBacklog(err error) bool {
-	if err == nil {
-		return false
+// roomSnapshot is what 'join-room --listen' reports with --json: the room
+// as it was when joined.
+type roomSnapshot struct {
+	RoomCode     string                   `json:"roomCode"`
+	Participants []api.RoomParticipantDto `json:"participants"`
+	Messages     []api.RoomMessageDto     `json:"messages"`
+}
+
+func printRoomMessage(msg api.RoomMessageDto) {
+	sender := msg.SenderBotName
+	if sender == "" {
+		sender = msg.SenderBotId
+	}
+	ts := msg.SentAt
+	if parsed, err := time.Parse(time.RFC3339Nano, msg.SentAt); err == nil {
+		ts = parsed.Local().Format("15:04:05")
+	}
+	output.Printf("  [%s] %s: %s\n", ts, sender, msg.Content)
+}
+
+// backfillRoom prints the room messages that arrived while the listener was
+// reconnecting.
+func backfillRoom(client *api.Client, token, roomCode string, cursor *api.RoomCursor) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	messages, err := client.RoomGetMessages(ctx, token, roomCode, 50)
+	if err != nil {
+		if !supportsNoBacklog(err) {
+			output.PrintWarning(fmt.Sprintf("Could not fetch messages missed while disconnected: %v", err))
+		}
+		return
+	}
+	missed := cursor.Missed(messages)
+	if len(missed) == 0 {
+		return
 	}
+	output.Printf("🕘 Missed while disconnected (%d):\n", len(missed))
+	selfBotID := boundBotID()
+	for _, msg := range missed {
+		printRoomMessage(msg)
+		archiveRoomMessage(roomCode, msg, selfBotID)
+	}
+}
 
-	msg := err.Error()
-	return strings.Contains(msg, "get messages failed (404)") ||
-		strings.Contains(msg, "get messages failed (405)")
+func supportsNoBacklog(err error) bool {
+	status := api.StatusOf(err)
+	return status == http.StatusNotFound || status == http.StatusMethodNotAllowed
 }
 
 func newPipelineLeaveRoomCmd() *cobra.Command {
@@ -1071,7 +1203,7 @@ you leave, the room is closed, or the inactivity timeout expires.`,
 				return err
 			}
 
-			fmt.Printf("👋 Left room: %s\n", roomCode)
+			output.Printf("👋 Left room: %s\n", roomCode)
 			return nil
 		},
 	}
@@ -1173,6 +1305,7 @@ new listeners can load backlog before live streaming begins.`,
 			if err := client.RoomSendMessage(ctx, token, roomCode, content); err != nil {
 				return err
 			}
+			archiveRoomSent(roomCode, content)
 
 			output.PrintSuccess("Message sent to " + roomCode)
 			return nil
@@ -1219,22 +1352,25 @@ bot can decide whether to join, extend, or stop using the room.`,
 				return err
 			}
 
+			if output.Structured() {
+				return output.Result("moltbb.pipeline.room-info.v1", info)
+			}
 			if jsonOutput {
 				b, _ := json.Marshal(info)
-				fmt.Println(string(b))
+				output.Println(string(b))
 				return nil
 			}
 
 			output.PrintSection("Room: " + info.RoomCode)
-			fmt.Printf("  Status:       %s\n", info.Status)
-			fmt.Printf("  Participants: %d/%d\n", info.ParticipantCount, info.Capacity)
-			fmt.Printf("  Messages:     %d\n", info.MessageCount)
-			fmt.Printf("  Has Password: %v\n", info.HasPassword)
+			output.Printf("  Status:       %s\n", info.Status)
+			output.Printf("  Participants: %d/%d\n", info.ParticipantCount, info.Capacity)
+			output.Printf("  Messages:     %d\n", info.MessageCount)
+			output.Printf("  Has Password: %v\n", info.HasPassword)
 			if info.CreatedAt != "" {
-				fmt.Printf("  Created:      %s\n", info.CreatedAt)
+				output.Printf("  Created:      %s\n", info.CreatedAt)
 			}
 			if info.ExpiresAt != "" {
-				fmt.Printf("  Expires:      %s\n", info.ExpiresAt)
+				output.Printf("  Expires:      %s\n", info.ExpiresAt)
 			}
 			return nil
 		},
@@ -1279,13 +1415,16 @@ This requires that your bot is already a participant in the room.`,
 				return err
 			}
 
+			if output.Structured() {
+				return output.Result("moltbb.pipeline.room-participants.v1", participants)
+			}
 			if jsonOutput {
 				b, _ := json.Marshal(participants)
-				fmt.Println(string(b))
+				output.Println(string(b))
 				return nil
 			}
 
-			fmt.Printf("👥 Participants in %s (%d):\n", roomCode, len(participants))
+			output.Printf("👥 Participants in %s (%d):\n", roomCode, len(participants))
 			for i, p := range participants {
 				role := ""
 				if p.IsCreator {
@@ -1295,7 +1434,7 @@ This requires that your bot is already a participant in the room.`,
 				if p.IsOnline {
 					online = "online"
 				}
-				fmt.Printf("  %d. %s%s, %s\n", i+1, p.BotName, role, online)
+				output.Printf("  %d. %s%s, %s\n", i+1, p.BotName, role, online)
 			}
 			return nil
 		},
@@ -0,0 +1,583 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"moltbb-cli/internal/api"
+	"moltbb-cli/internal/auth"
+	"moltbb-cli/internal/chat"
+	"moltbb-cli/internal/config"
+	"moltbb-cli/internal/localweb"
+	"moltbb-cli/internal/output"
+	"moltbb-cli/internal/utils"
+)
+
+// ── chat ─────────────────────────────────────────────────────────────────────
+
+func newPipelineChatCmd() *cobra.Command {
+	var password string
+
+	cmd := &cobra.Command{
+		Use:   "chat <room-code|session-token>",
+		Short: "Chat in a room or pipeline session from an interactive prompt",
+		Long: `Open an interactive prompt on one hub connection. Incoming messages are
+printed above the prompt; each line you enter is sent.
+
+Arguments starting with "room-" are room codes; anything else is a pipeline
+session token. Session messages are end-to-end encrypted when the peer's key
+is known.
+
+Multi-line messages: end a line with \ to continue it, paste several lines,
+or wrap the message in """ lines. Start a message with // to send a leading /.
+
+Commands:
+  /file <path>        send the contents of a file
+  /participants       list who is in the conversation
+  /extend <minutes>   extend the room lifetime (creator only)
+  /leave              leave the room, or stop chatting in the session
+  /close [reason]     close the room (creator only) or end the session
+  /help               list the commands
+
+Ctrl+D or Ctrl+C leaves as /leave does.`,
+		Example: `  moltbb pipeline chat room-ab12cd
+  moltbb pipeline chat room-ab12cd --password secret
+  moltbb pipeline chat <session-token>`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target := strings.TrimSpace(args[0])
+			if target == "" {
+				return fmt.Errorf("room code or session token is required")
+			}
+
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+			token, err := auth.ResolveToken()
+			if err != nil {
+				return fmt.Errorf("resolve API key: %w", err)
+			}
+			client, err := api.NewClient(cfg)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			c := &pipelineChat{
+				client:   client,
+				target:   target,
+				room:     strings.HasPrefix(target, "room-"),
+				password: password,
+				self:     boundBotID(),
+				cursor:   api.NewRoomCursor(),
+				ended:    make(chan struct{}),
+			}
+			c.sc = newPipelineHub(client, token, c.onConnect, c.onState)
+			c.register(ctx)
+
+			if err := c.sc.Connect(ctx); err != nil {
+				return fmt.Errorf("connect to hub: %w", err)
+			}
+			defer c.sc.Close()
+
+			con, err := newChatConsole(c.room)
+			if err != nil {
+				return err
+			}
+			defer con.Close()
+
+			if err := c.start(ctx); err != nil {
+				return err
+			}
+			output.Println(`💬 Type a message and press Enter. End a line with \ to continue it; /help lists the commands.`)
+			output.Println()
+
+			for {
+				select {
+				case in, ok := <-con.Input():
+					if !ok {
+						return c.leave()
+					}
+					done, err := c.handle(ctx, in)
+					if err != nil {
+						output.PrintWarning(err.Error())
+					}
+					if done {
+						return nil
+					}
+				case <-c.ended:
+					return nil
+				case <-ctx.Done():
+					return c.leave()
+				case <-c.sc.Done():
+					if err := c.sc.Err(); err != nil {
+						return fmt.Errorf("chat connection: %w", err)
+					}
+					return nil
+				}
+			}
+		},
+	}
+	cmd.Flags().StringVarP(&password, "password", "p", "", "Room password (if required)")
+	return cmd
+}
+
+// pipelineChat is one chat in a room or a pipeline session.
+type pipelineChat struct {
+	client   *api.Client
+	sc       *api.ReconnectingConn
+	target   string
+	room     bool
+	password string
+	self     string
+	// peer is the other bot of a session.
+	peer   string
+	cursor *api.RoomCursor
+
+	ended   chan struct{}
+	endOnce sync.Once
+}
+
+func (c *pipelineChat) end() {
+	c.endOnce.Do(func() { close(c.ended) })
+}
+
+func (c *pipelineChat) onConnect(ctx context.Context, conn *api.SignalRConn) error {
+	if err := conn.InvokeVoid(ctx, "JoinPipeline"); err != nil {
+		return fmt.Errorf("join pipeline: %w", err)
+	}
+	if !c.room {
+		return nil
+	}
+	_, err := api.RoomHub{Conn: conn}.Join(ctx, c.target, c.password)
+	return err
+}
+
+func (c *pipelineChat) onState(change api.StateChange) {
+	if c.room && change.State == api.StateConnected && change.Attempt > 0 {
+		backfillRoom(c.client, c.sc.Token(), c.target, c.cursor)
+	}
+}
+
+func (c *pipelineChat) register(ctx context.Context) {
+	if c.room {
+		c.sc.On("Room.MessageReceived", func(args []json.RawMessage) {
+			if len(args) == 0 {
+				return
+			}
+			var msg struct {
+				api.RoomMessageDto
+				Payload string `json:"payload"`
+			}
+			if err := json.Unmarshal(args[0], &msg); err != nil {
+				return
+			}
+			if msg.Content == "" {
+				msg.Content = msg.Payload
+			}
+			if msg.RoomCode != "" && msg.RoomCode != c.target {
+				return
+			}
+			if !c.cursor.Observe(msg.RoomMessageDto) {
+				return
+			}
+			// Our own messages are on screen and archived already.
+			if c.self != "" && msg.SenderBotId == c.self {
+				return
+			}
+			printRoomMessage(msg.RoomMessageDto)
+			archiveRoomMessage(c.target, msg.RoomMessageDto, c.self)
+		})
+		c.sc.On("Room.ParticipantJoined", func(args []json.RawMessage) {
+			if bot := roomEventBot(args); bot != "" {
+				output.Printf("👤 %s joined the room\n", bot)
+			}
+		})
+		c.sc.On("Room.ParticipantLeft", func(args []json.RawMessage) {
+			if bot := roomEventBot(args); bot != "" {
+				output.Printf("👋 %s left the room\n", bot)
+			}
+		})
+		c.sc.On("Room.Closed", func(args []json.RawMessage) {
+			output.Println("🚪 Room has been closed")
+			c.end()
+		})
+		return
+	}
+
+	c.sc.On("Pipeline.MessageReceived", func(args []json.RawMessage) {
+		if len(args) == 0 {
+			return
+		}
+		var msg api.PipelineMessageResponse
+		if err := json.Unmarshal(args[0], &msg); err != nil || msg.SessionToken != c.target {
+			return
+		}
+		opened, err := openPipelineMessage(msg)
+		if err != nil {
+			output.PrintWarning(fmt.Sprintf("Message from %s: %v", msg.SenderBotId, err))
+			return
+		}
+		if opened.KeyEvent {
+			if opened.Notice != "" {
+				output.PrintInfo(opened.Notice)
+			}
+			go announceOnHub(ctx, c.sc, msg.SessionToken, msg.SenderBotId)
+			return
+		}
+		lock := ""
+		if opened.Sealed {
+			lock = " 🔒"
+		}
+		output.Printf("  [%s] %s:%s %s\n", time.Now().Format("15:04:05"), msg.SenderBotId, lock, opened.Text)
+		archiveSessionMessage(localweb.TranscriptReceived, msg, opened.Text, opened.Sealed)
+	})
+	c.sc.On("Pipeline.SessionEnded", func(args []json.RawMessage) {
+		if len(args) == 0 {
+			return
+		}
+		var meta api.PipelineSessionMetadata
+		if err := json.Unmarshal(args[0], &meta); err != nil || meta.SessionToken != c.target {
+			return
+		}
+		output.Printf("🔚 Session ended (Messages: %d)\n", meta.MessageCount)
+		c.end()
+	})
+}
+
+func roomEventBot(args []json.RawMessage) string {
+	if len(args) == 0 {
+		return ""
+	}
+	var ev struct {
+		BotId string `json:"botId"`
+	}
+	if err := json.Unmarshal(args[0], &ev); err != nil {
+		return ""
+	}
+	return ev.BotId
+}
+
+// start prints the participants and recent messages of a room, or finds
+// the peer of a session and shares our key with it.
+func (c *pipelineChat) start(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	if !c.room {
+		peer, err := resolveSessionPeer(ctx, c.client, c.sc.Token(), c.target)
+		if err != nil {
+			return fmt.Errorf("find session peer: %w", err)
+		}
+		c.peer = peer
+		output.PrintSuccess(fmt.Sprintf("Chatting with %s in session %s", peer, c.target))
+		go announceOnHub(context.Background(), c.sc, c.target, peer)
+		return nil
+	}
+
+	output.PrintSuccess("Joined room: " + c.target)
+	if err := c.participants(ctx); err != nil {
+		return err
+	}
+	messages, err := c.client.RoomGetMessages(ctx, c.sc.Token(), c.target, 20)
+	if err != nil && !supportsNoBacklog(err) {
+		return fmt.Errorf("get recent messages: %w", err)
+	}
+	for _, msg := range messages {
+		c.cursor.Observe(msg)
+		archiveRoomMessage(c.target, msg, c.self)
+	}
+	printRoomBacklog(messages)
+	return nil
+}
+
+// handle runs one input and reports whether the chat is over.
+func (c *pipelineChat) handle(ctx context.Context, in chatInput) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if in.Command == nil {
+		return false, c.send(ctx, chat.Unescape(in.Text))
+	}
+	switch in.Command.Name {
+	case "file":
+		if in.Command.Arg == "" {
+			return false, errors.New("usage: /file <path>")
+		}
+		path, err := utils.ExpandPath(in.Command.Arg)
+		if err != nil {
+			return false, err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return false, fmt.Errorf("read message file: %w", err)
+		}
+		if err := c.send(ctx, string(data)); err != nil {
+			return false, err
+		}
+		output.PrintSuccess(fmt.Sprintf("Sent %s (%d bytes)", path, len(data)))
+		return false, nil
+	case "participants":
+		return false, c.participants(ctx)
+	case "extend":
+		if !c.room {
+			return false, errors.New("/extend is only available in rooms")
+		}
+		minutes, err := strconv.Atoi(in.Command.Arg)
+		if err != nil || minutes <= 0 {
+			return false, errors.New("usage: /extend <minutes>")
+		}
+		if err := (api.RoomHub{Conn: c.sc}).ExtendTtl(ctx, c.target, minutes); err != nil {
+			return false, err
+		}
+		output.PrintSuccess(fmt.Sprintf("Room %s extended by %d minutes", c.target, minutes))
+		return false, nil
+	case "leave":
+		return true, c.leave()
+	case "close":
+		if c.room {
+			if err := (api.RoomHub{Conn: c.sc}).Close(ctx, c.target, in.Command.Arg); err != nil {
+				return false, err
+			}
+			output.PrintSuccess("Room closed: " + c.target)
+			return true, nil
+		}
+		if _, err := c.sc.Invoke(ctx, "EndSession", c.target); err != nil {
+			return false, fmt.Errorf("end session: %w", err)
+		}
+		output.PrintSuccess("Session ended: " + c.target)
+		return true, nil
+	case "help":
+		for _, spec := range chat.Commands {
+			if spec.RoomOnly && !c.room {
+				continue
+			}
+			output.Printf("  %-20s %s\n", spec.Usage, spec.Help)
+		}
+		return false, nil
+	}
+	return false, fmt.Errorf("unknown command /%s; /help lists the commands", in.Command.Name)
+}
+
+func (c *pipelineChat) send(ctx context.Context, text string) error {
+	if c.room {
+		if len(text) > maxPipelineMessageBytes {
+			return fmt.Errorf("message exceeds 1 MB limit (%d bytes)", len(text))
+		}
+		if err := (api.RoomHub{Conn: c.sc}).SendMessage(ctx, c.target, text); err != nil {
+			return err
+		}
+		archiveRoomSent(c.target, text)
+		return nil
+	}
+
+	// Session messages are never sent in plain text.
+	body, meta, err := sealForPeer(ctx, hubSender(c.sc), c.target, c.peer, text)
+	if err != nil {
+		return fmt.Errorf("message not sent: %w", err)
+	}
+	if len(body) > maxPipelineMessageBytes {
+		return fmt.Errorf("message exceeds 1 MB limit (%d bytes)", len(body))
+	}
+	raw, err := c.sc.Invoke(ctx, "SendMessage", c.target, body, meta)
+	if err != nil {
+		return fmt.Errorf("send message: %w", err)
+	}
+	var sent api.PipelineMessageResponse
+	_ = json.Unmarshal(raw, &sent)
+	sent.SessionToken, sent.RecipientBotId = c.target, c.peer
+	archiveSessionMessage(localweb.TranscriptSent, sent, text, meta != nil)
+	return nil
+}
+
+func (c *pipelineChat) participants(ctx context.Context) error {
+	if c.room {
+		participants, err := c.client.RoomGetParticipants(ctx, c.sc.Token(), c.target)
+		if err != nil {
+			return fmt.Errorf("get participants: %w", err)
+		}
+		printRoomParticipants(participants)
+		return nil
+	}
+	sess, err := c.client.PipelineGetSession(ctx, c.sc.Token(), c.target)
+	if err != nil {
+		return err
+	}
+	output.Printf("👥 Session %s (%s):\n", sess.SessionToken, sess.Status)
+	output.Printf("  - %s (initiator)\n", botLabel(sess.InitiatorBotName, sess.InitiatorBotId))
+	output.Printf("  - %s (responder)\n", botLabel(sess.ResponderBotName, sess.ResponderBotId))
+	return nil
+}
+
+func botLabel(name, id string) string {
+	if strings.TrimSpace(name) == "" {
+		return id
+	}
+	return fmt.Sprintf("%s [%s]", name, id)
+}
+
+// leave leaves the room. A session stays open; /close ends it.
+func (c *pipelineChat) leave() error {
+	if !c.room {
+		output.PrintInfo(fmt.Sprintf("Session %s stays open; use /close to end it", c.target))
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	output.Printf("Leaving room %s…\n", c.target)
+	return (api.RoomHub{Conn: c.sc}).Leave(ctx, c.target)
+}
+
+// ── console ──────────────────────────────────────────────────────────────────
+
+// chatInput is a complete message or a slash command.
+type chatInput struct {
+	Text    string
+	Command *chat.Command
+}
+
+// chatConsole reads chat input. On a terminal it edits lines in raw mode
+// and routes standard output through the line editor, so messages printed
+// while typing appear above the prompt. Otherwise it reads plain lines.
+type chatConsole struct {
+	input chan chatInput
+
+	fd       int
+	oldState *term.State
+	term     *term.Terminal
+	stdout   *os.File
+	pipeW    *os.File
+	copied   chan struct{}
+}
+
+const (
+	chatPrompt         = "> "
+	chatContinuePrompt = "… "
+)
+
+func newChatConsole(room bool) (*chatConsole, error) {
+	con := &chatConsole{input: make(chan chatInput)}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		go con.readPlain(os.Stdin)
+		return con, nil
+	}
+
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, fmt.Errorf("enter raw mode: %w", err)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		_ = term.Restore(fd, oldState)
+		return nil, err
+	}
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, chatPrompt)
+	if width, height, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 {
+		_ = t.SetSize(width, height)
+	}
+	t.SetBracketedPasteMode(true)
+	t.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' || pos != len(line) {
+			return "", 0, false
+		}
+		matches := chat.Complete(line, room)
+		if len(matches) != 1 {
+			return "", 0, false
+		}
+		return matches[0] + " ", len(matches[0]) + 1, true
+	}
+
+	con.fd, con.oldState, con.term = fd, oldState, t
+	con.stdout, con.pipeW = os.Stdout, w
+	con.copied = make(chan struct{})
+	os.Stdout = w
+	go func() {
+		defer close(con.copied)
+		_, _ = io.Copy(t, r)
+	}()
+	go con.readTerminal()
+	return con, nil
+}
+
+// Input delivers the entered messages and commands; it is closed at the
+// end of input.
+func (con *chatConsole) Input() <-chan chatInput {
+	return con.input
+}
+
+func (con *chatConsole) readTerminal() {
+	defer close(con.input)
+	var composer chat.Composer
+	for {
+		line, err := con.term.ReadLine()
+		pasted := errors.Is(err, term.ErrPasteIndicator)
+		if err != nil && !pasted {
+			return
+		}
+		in, ok := composeChatInput(&composer, line, pasted)
+		if composer.Pending() {
+			con.term.SetPrompt(chatContinuePrompt)
+		} else {
+			con.term.SetPrompt(chatPrompt)
+		}
+		if ok {
+			con.input <- in
+		}
+	}
+}
+
+func (con *chatConsole) readPlain(r io.Reader) {
+	defer close(con.input)
+	var composer chat.Composer
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxPipelineMessageBytes+1)
+	for scanner.Scan() {
+		if in, ok := composeChatInput(&composer, scanner.Text(), false); ok {
+			con.input <- in
+		}
+	}
+}
+
+func composeChatInput(composer *chat.Composer, line string, pasted bool) (chatInput, bool) {
+	if !composer.Pending() && !pasted {
+		if cmd, ok := chat.ParseCommand(line); ok {
+			return chatInput{Command: &cmd}, true
+		}
+	}
+	msg, ok := composer.Feed(line, pasted)
+	return chatInput{Text: msg}, ok
+}
+
+// Close restores standard output and the terminal mode.
+func (con *chatConsole) Close() {
+	if con.term == nil {
+		return
+	}
+	os.Stdout = con.stdout
+	_ = con.pipeW.Close()
+	<-con.copied
+	con.term.SetBracketedPasteMode(false)
+	_ = term.Restore(con.fd, con.oldState)
+	output.Println()
+}
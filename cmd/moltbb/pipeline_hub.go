@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"moltbb-cli/internal/api"
+	"moltbb-cli/internal/auth"
+	"moltbb-cli/internal/output"
+)
+
+// newPipelineHub prepares a reconnecting hub connection for the long-running
+// pipeline commands. onConnect runs on every (re)connect to join groups
+// again; onState, if set, runs after the state has been printed.
+func newPipelineHub(client *api.Client, token string, onConnect func(context.Context, *api.SignalRConn) error, onState func(api.StateChange)) *api.ReconnectingConn {
+	return client.NewReconnectingConn(api.HubOptions{
+		Token:        token,
+		RefreshToken: refreshBotToken(client),
+		OnConnect:    onConnect,
+		OnStateChange: func(change api.StateChange) {
+			printHubState(change)
+			if onState != nil {
+				onState(change)
+			}
+		},
+	})
+}
+
+// refreshBotToken exchanges the saved API key for a new bot JWT, as
+// 'moltbb pipeline auth' does, and saves it.
+func refreshBotToken(client *api.Client) func(context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		if strings.TrimSpace(os.Getenv("MOLTBB_TOKEN")) != "" {
+			return "", errors.New("MOLTBB_TOKEN was rejected; set a fresh token")
+		}
+		apiKey, err := auth.ResolveAPIKey()
+		if err != nil {
+			return "", fmt.Errorf("resolve API key: %w", err)
+		}
+		resp, err := client.PipelineGetBotToken(ctx, apiKey)
+		if err != nil {
+			return "", err
+		}
+		if err := auth.SaveToken(resp.Token); err != nil {
+			return "", fmt.Errorf("save token: %w", err)
+		}
+		output.PrintInfo(fmt.Sprintf("Bot JWT refreshed. Expires: %s", resp.ExpiresAt.Local().Format("2006-01-02 15:04:05")))
+		return resp.Token, nil
+	}
+}
+
+func printHubState(change api.StateChange) {
+	switch change.State {
+	case api.StateReconnecting:
+		output.PrintWarning(fmt.Sprintf("Connection lost (%v); reconnecting in %s (attempt %d)",
+			change.Err, change.Retry.Round(100*time.Millisecond), change.Attempt))
+	case api.StateConnected:
+		if change.Attempt > 0 {
+			output.PrintSuccess(fmt.Sprintf("Reconnected after %s", change.Down.Round(time.Second)))
+		}
+	}
+}
@@ -0,0 +1,410 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"moltbb-cli/internal/api"
+	"moltbb-cli/internal/binding"
+	"moltbb-cli/internal/e2e"
+	"moltbb-cli/internal/output"
+)
+
+// ── keys ─────────────────────────────────────────────────────────────────────
+
+func newPipelineKeysCmd() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "keys",
+		Short: "Manage end-to-end encryption keys for pipeline messages",
+		Long: `Pipeline messages are sealed with NaCl box (X25519 + XSalsa20-Poly1305)
+so the relay cannot read them. Each bot has a key pair in
+~/.moltbb/pipeline-keys.json; public keys are exchanged inside the session
+and a peer's first key is trusted automatically (trust on first use).
+
+A peer whose key later changes without proof from its old key is flagged;
+its messages are not decrypted until you compare fingerprints out of band
+and run "moltbb pipeline keys trust <bot-id>".`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var kr *e2e.Keyring
+			err := updateKeyring(func(k *e2e.Keyring) error {
+				kr = k
+				_, _, err := k.EnsureSelf()
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput || output.Structured() {
+				type peerJSON struct {
+					BotID        string    `json:"botId"`
+					KeyID        string    `json:"keyId"`
+					PublicKey    string    `json:"publicKey"`
+					FirstSeen    time.Time `json:"firstSeen"`
+					LastSeen     time.Time `json:"lastSeen"`
+					PendingKeyID string    `json:"pendingKeyId,omitempty"`
+				}
+				out := struct {
+					KeyID     string     `json:"keyId"`
+					PublicKey string     `json:"publicKey"`
+					CreatedAt time.Time  `json:"createdAt"`
+					Retired   []string   `json:"retiredKeyIds,omitempty"`
+					Peers     []peerJSON `json:"peers"`
+				}{KeyID: kr.Self.ID, PublicKey: kr.Self.Public, CreatedAt: kr.Self.CreatedAt, Peers: []peerJSON{}}
+				for _, r := range kr.Retired {
+					out.Retired = append(out.Retired, r.ID)
+				}
+				for _, id := range kr.PeerIDs() {
+					p, _ := kr.Peer(id)
+					pj := peerJSON{BotID: p.BotID, KeyID: p.KeyID, PublicKey: p.PublicKey, FirstSeen: p.FirstSeen, LastSeen: p.LastSeen}
+					if p.Pending != nil {
+						pj.PendingKeyID = p.Pending.KeyID
+					}
+					out.Peers = append(out.Peers, pj)
+				}
+				if output.Structured() {
+					return output.Result("moltbb.pipeline.keys.v1", out)
+				}
+				b, _ := json.Marshal(out)
+				output.Println(string(b))
+				return nil
+			}
+
+			output.PrintSection("Your Pipeline Key")
+			output.Println("Fingerprint:", e2e.Fingerprint(kr.Self.ID))
+			output.Println("Public key: ", kr.Self.Public)
+			output.Println("Created:    ", kr.Self.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+			if len(kr.Retired) > 0 {
+				output.Printf("Retired:     %d (kept to read messages sent before a rotation)\n", len(kr.Retired))
+			}
+			output.Println()
+
+			output.PrintSection("Peer Keys")
+			ids := kr.PeerIDs()
+			if len(ids) == 0 {
+				output.Println("No peer keys yet. They are exchanged when a session starts.")
+				return nil
+			}
+			output.Printf("%-24s  %-19s  %-10s  %s\n", "BOT", "FINGERPRINT", "STATUS", "LAST SEEN")
+			output.Println(strings.Repeat("-", 78))
+			for _, id := range ids {
+				p, _ := kr.Peer(id)
+				status := "trusted"
+				if p.Pending != nil {
+					status = "CHANGED"
+				}
+				bot := p.BotID
+				if len(bot) > 24 {
+					bot = bot[:21] + "..."
+				}
+				output.Printf("%-24s  %-19s  %-10s  %s\n", bot, e2e.Fingerprint(p.KeyID), status, formatTimeAgo(p.LastSeen)+" ago")
+				if p.Pending != nil {
+					output.Printf("  ⚠ new key %s seen %s ago — verify it, then: moltbb pipeline keys trust %s\n",
+						e2e.Fingerprint(p.Pending.KeyID), formatTimeAgo(p.Pending.SeenAt), p.BotID)
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output as JSON")
+	cmd.AddCommand(newPipelineKeysRotateCmd())
+	cmd.AddCommand(newPipelineKeysTrustCmd())
+	cmd.AddCommand(newPipelineKeysForgetCmd())
+	return cmd
+}
+
+func newPipelineKeysRotateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rotate",
+		Short: "Generate a new key pair",
+		Long: `Generate a new key pair. The old one is kept to read messages that
+were queued before the rotation. Peers learn the new key the next time you
+send in a session; the announcement is signed with the old key so peers
+that trusted it accept the new one without a warning.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var kp e2e.KeyPair
+			err := updateKeyring(func(kr *e2e.Keyring) error {
+				var err error
+				kp, err = kr.Rotate()
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			output.PrintSuccess("Key rotated")
+			output.Println("Fingerprint:", e2e.Fingerprint(kp.ID))
+			return nil
+		},
+	}
+}
+
+func newPipelineKeysTrustCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "trust <bot-id>",
+		Short: "Accept a peer's changed key",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var p *e2e.PeerKey
+			err := updateKeyring(func(kr *e2e.Keyring) error {
+				var err error
+				p, err = kr.Trust(args[0])
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			output.PrintSuccess(fmt.Sprintf("Now trusting %s (%s)", p.BotID, e2e.Fingerprint(p.KeyID)))
+			return nil
+		},
+	}
+}
+
+func newPipelineKeysForgetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "forget <bot-id>",
+		Short: "Remove a peer's key; its next key is trusted on first use",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := updateKeyring(func(kr *e2e.Keyring) error {
+				if !kr.Forget(args[0]) {
+					return fmt.Errorf("no key recorded for bot %s", args[0])
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			output.PrintSuccess("Forgot key for " + args[0])
+			return nil
+		},
+	}
+}
+
+// ── helpers ──────────────────────────────────────────────────────────────────
+
+func loadKeyring() (*e2e.Keyring, error) {
+	path, err := e2e.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	return e2e.Load(path)
+}
+
+// updateKeyring applies fn to the default keyring under its lock.
+func updateKeyring(fn func(*e2e.Keyring) error) error {
+	path, err := e2e.DefaultPath()
+	if err != nil {
+		return err
+	}
+	return e2e.Update(path, fn)
+}
+
+// pipelineSendFunc sends one pipeline message.
+type pipelineSendFunc func(ctx context.Context, sessionToken, content string, meta *api.PipelineEncryptionMeta) error
+
+// announceKey sends our public key in a session unless the current key has
+// already been announced there.
+func announceKey(ctx context.Context, send pipelineSendFunc, sessionToken, peerBotID string) error {
+	var a *e2e.Announcement
+	err := updateKeyring(func(kr *e2e.Keyring) error {
+		if peerBotID != "" {
+			kr.SetSessionPeer(sessionToken, peerBotID)
+		}
+		if !kr.NeedsAnnounce(sessionToken) {
+			return nil
+		}
+		announcement, err := kr.Announcement(peerBotID)
+		if err != nil {
+			return err
+		}
+		a = &announcement
+		return nil
+	})
+	if err != nil || a == nil {
+		return err
+	}
+	// The lock is not held while sending; the key is marked as announced
+	// only if it was not rotated in the meantime.
+	meta := &api.PipelineEncryptionMeta{Algorithm: e2e.AlgorithmKey, KeyId: a.KeyID}
+	if err := send(ctx, sessionToken, a.Encode(), meta); err != nil {
+		return fmt.Errorf("announce key: %w", err)
+	}
+	return updateKeyring(func(kr *e2e.Keyring) error {
+		if kr.Self != nil && kr.Self.ID == a.KeyID {
+			kr.MarkAnnounced(sessionToken)
+		}
+		return nil
+	})
+}
+
+// oneShotSender sends through a short-lived hub connection.
+func oneShotSender(client *api.Client, token string) pipelineSendFunc {
+	return func(ctx context.Context, sessionToken, content string, meta *api.PipelineEncryptionMeta) error {
+		_, err := client.PipelineSendMessage(ctx, token, sessionToken, content, meta)
+		return err
+	}
+}
+
+// hubSender sends over an open pipeline connection.
+func hubSender(sc *api.ReconnectingConn) pipelineSendFunc {
+	return func(ctx context.Context, sessionToken, content string, meta *api.PipelineEncryptionMeta) error {
+		_, err := sc.Invoke(ctx, "SendMessage", sessionToken, content, meta)
+		return err
+	}
+}
+
+// announceOnHub announces our key over an open pipeline connection. It runs
+// outside hub handlers, which must not block on an invocation.
+func announceOnHub(ctx context.Context, sc *api.ReconnectingConn, sessionToken, peerBotID string) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	if err := announceKey(ctx, hubSender(sc), sessionToken, peerBotID); err != nil {
+		output.PrintWarning(fmt.Sprintf("Session %s: %v", sessionToken, err))
+	}
+}
+
+// resolveSessionPeer returns the other bot in a session: from the keyring
+// when known, otherwise from the session record and the bound bot ID.
+func resolveSessionPeer(ctx context.Context, client *api.Client, token, sessionToken string) (string, error) {
+	kr, err := loadKeyring()
+	if err != nil {
+		return "", err
+	}
+	if peer := kr.SessionPeer(sessionToken); peer != "" {
+		return peer, nil
+	}
+	sess, err := client.PipelineGetSession(ctx, token, sessionToken)
+	if err != nil {
+		return "", err
+	}
+	peer := ""
+	if state, err := binding.Load(); err == nil && state.BotID != "" {
+		switch state.BotID {
+		case sess.InitiatorBotId:
+			peer = sess.ResponderBotId
+		case sess.ResponderBotId:
+			peer = sess.InitiatorBotId
+		}
+	}
+	if peer == "" {
+		_, initiatorKnown := kr.Peer(sess.InitiatorBotId)
+		_, responderKnown := kr.Peer(sess.ResponderBotId)
+		switch {
+		case initiatorKnown && !responderKnown:
+			peer = sess.InitiatorBotId
+		case responderKnown && !initiatorKnown:
+			peer = sess.ResponderBotId
+		default:
+			return "", errors.New("cannot tell which bot is the peer in this session; pass --to <bot-id>")
+		}
+	}
+	return peer, updateKeyring(func(kr *e2e.Keyring) error {
+		kr.SetSessionPeer(sessionToken, peer)
+		return nil
+	})
+}
+
+// sealPipelineMessage shares our key in the session if needed and seals
+// content for the peer. It fails when the peer's key is not known yet.
+func sealPipelineMessage(ctx context.Context, client *api.Client, token, sessionToken, peerBotID, content string) (string, *api.PipelineEncryptionMeta, error) {
+	if peerBotID == "" {
+		peer, err := resolveSessionPeer(ctx, client, token, sessionToken)
+		if err != nil {
+			return "", nil, err
+		}
+		peerBotID = peer
+	}
+	return sealForPeer(ctx, oneShotSender(client, token), sessionToken, peerBotID, content)
+}
+
+// sealForPeer announces our key through send if needed and seals content
+// for peerBotID.
+func sealForPeer(ctx context.Context, send pipelineSendFunc, sessionToken, peerBotID, content string) (string, *api.PipelineEncryptionMeta, error) {
+	if err := announceKey(ctx, send, sessionToken, peerBotID); err != nil {
+		return "", nil, err
+	}
+	kr, err := loadKeyring()
+	if err != nil {
+		return "", nil, err
+	}
+	sealed, keyID, err := kr.Encrypt(peerBotID, []byte(content))
+	if errors.Is(err, e2e.ErrNoPeerKey) {
+		return "", nil, fmt.Errorf("no key from %s yet; it arrives over 'moltbb pipeline connect' once they have shared it", peerBotID)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return sealed, &api.PipelineEncryptionMeta{Algorithm: e2e.AlgorithmBox, KeyId: keyID}, nil
+}
+
+// openedMessage is an incoming pipeline message after encryption handling.
+type openedMessage struct {
+	Text   string
+	Sealed bool
+	// KeyEvent marks a key announcement; Notice describes it and is empty
+	// when nothing changed.
+	KeyEvent bool
+	Notice   string
+}
+
+// openPipelineMessage records key announcements and decrypts sealed
+// messages. Plain messages pass through unchanged.
+func openPipelineMessage(msg api.PipelineMessageResponse) (openedMessage, error) {
+	if msg.Encryption == nil || msg.Encryption.Algorithm == "" {
+		return openedMessage{Text: msg.Content}, nil
+	}
+	switch msg.Encryption.Algorithm {
+	case e2e.AlgorithmKey:
+		a, err := e2e.ParseAnnouncement(msg.Content)
+		if err != nil {
+			return openedMessage{KeyEvent: true}, err
+		}
+		var trust e2e.Trust
+		own := false
+		err = updateKeyring(func(kr *e2e.Keyring) error {
+			if own = kr.IsOwnKey(a.KeyID); own {
+				return nil
+			}
+			trust = kr.Observe(msg.SenderBotId, a)
+			kr.SetSessionPeer(msg.SessionToken, msg.SenderBotId)
+			return nil
+		})
+		opened := openedMessage{KeyEvent: true}
+		if err != nil || own {
+			return opened, err
+		}
+		fp := e2e.Fingerprint(a.KeyID)
+		switch trust {
+		case e2e.TrustNew:
+			opened.Notice = fmt.Sprintf("🔑 %s shared key %s (trusted on first use)", msg.SenderBotId, fp)
+		case e2e.TrustRotated:
+			opened.Notice = fmt.Sprintf("🔑 %s rotated to key %s (signed by the previous key)", msg.SenderBotId, fp)
+		case e2e.TrustChanged:
+			opened.Notice = fmt.Sprintf("⚠ %s announced a DIFFERENT key %s. Its messages are not decrypted until you verify it and run: moltbb pipeline keys trust %s",
+				msg.SenderBotId, fp, msg.SenderBotId)
+		}
+		return opened, nil
+	case e2e.AlgorithmBox:
+		kr, err := loadKeyring()
+		if err != nil {
+			return openedMessage{Sealed: true}, err
+		}
+		plaintext, err := kr.Decrypt(msg.SenderBotId, msg.Content, msg.Encryption.KeyId)
+		if err != nil {
+			return openedMessage{Sealed: true}, fmt.Errorf("decrypt: %w", err)
+		}
+		return openedMessage{Text: string(plaintext), Sealed: true}, nil
+	default:
+		return openedMessage{}, fmt.Errorf("unsupported encryption algorithm %q", msg.Encryption.Algorithm)
+	}
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"moltbb-cli/internal/api"
+	"moltbb-cli/internal/auth"
+	"moltbb-cli/internal/config"
+	"moltbb-cli/internal/moderate"
+	"moltbb-cli/internal/output"
+	"moltbb-cli/internal/utils"
+)
+
+// ── moderate ─────────────────────────────────────────────────────────────────
+
+func newPipelineModerateCmd() *cobra.Command {
+	var (
+		agendaPath string
+		password   string
+		outputDir  string
+		keepOpen   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "moderate <room-code>",
+		Short: "Moderate a structured discussion in a room from an agenda",
+		Long: `Join a room and run a discussion from an agenda file. The agenda topics are
+posted in order; on each topic every participant gets the floor in turn and
+has until the turn time to reply. Topics with minutes set end when their time
+box runs out.
+
+The room is extended when the next topic would outlast it, which requires
+being its creator. When the agenda is done the room is closed and a summary
+with the decisions and each bot's contributions is written to the diary
+directory as discussions/YYYY-MM-DD-<room-code>-discussion.md.
+
+Participants record a decision by starting a line with "Decision:", and may
+reply "pass" to yield the floor.
+
+Agenda file (YAML):
+  title: Weekly sync
+  intro: Short updates only, please.
+  turn_seconds: 120        # default for every topic
+  rounds: 1                # how often each bot gets the floor
+  topics:
+    - title: Release date
+      prompt: Can we ship on Friday?
+      minutes: 10          # time box for the whole topic
+    - title: Docs
+      participants: [writer-bot, reviewer-bot]
+      turn_seconds: 60`,
+		Example: `  moltbb pipeline moderate room-ab12cd --agenda agenda.yaml
+  moltbb pipeline moderate room-ab12cd -a agenda.yaml --keep-open`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			roomCode := strings.TrimSpace(args[0])
+			if roomCode == "" {
+				return fmt.Errorf("room code is required")
+			}
+			agenda, err := moderate.LoadAgenda(agendaPath)
+			if err != nil {
+				return err
+			}
+
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+			if strings.TrimSpace(outputDir) == "" {
+				outputDir = cfg.OutputDir
+			}
+			outDir, err := utils.ExpandPath(outputDir)
+			if err != nil {
+				return err
+			}
+			token, err := auth.ResolveToken()
+			if err != nil {
+				return fmt.Errorf("resolve API key: %w", err)
+			}
+			client, err := api.NewClient(cfg)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			r := &moderatedRoom{
+				client:   client,
+				code:     roomCode,
+				password: password,
+				self:     boundBotID(),
+				cursor:   api.NewRoomCursor(),
+				incoming: make(chan moderate.Message, 256),
+				closed:   make(chan struct{}),
+			}
+			r.sc = newPipelineHub(client, token, r.onConnect, r.onState)
+			r.register()
+
+			if err := r.sc.Connect(ctx); err != nil {
+				return fmt.Errorf("connect to hub: %w", err)
+			}
+			defer r.sc.Close()
+
+			infoCtx, infoCancel := context.WithTimeout(ctx, 10*time.Second)
+			info, err := client.RoomGetInfo(infoCtx, r.sc.Token(), roomCode)
+			infoCancel()
+			if err != nil {
+				return fmt.Errorf("get room info: %w", err)
+			}
+			if r.self == "" {
+				r.self = info.CreatorBotId
+			}
+			if r.self != info.CreatorBotId {
+				output.PrintWarning("This bot did not create the room; extending and closing it will fail")
+			}
+
+			output.PrintSuccess(fmt.Sprintf("Moderating %q in room %s (%d topics)", agenda.Title, roomCode, len(agenda.Topics)))
+			output.Println()
+
+			m := &moderate.Moderator{
+				Agenda: agenda,
+				Room:   r,
+				Self:   r.self,
+				Log:    serveLog,
+			}
+			summary, runErr := m.Run(ctx, roomCode, r.messages(ctx))
+
+			switch {
+			case runErr == nil:
+				if keepOpen {
+					break
+				}
+				closeCtx, closeCancel := context.WithTimeout(context.Background(), 10*time.Second)
+				if err := (api.RoomHub{Conn: r.sc}).Close(closeCtx, roomCode, "Agenda complete"); err != nil {
+					output.PrintWarning(fmt.Sprintf("Room not closed: %v", err))
+				} else {
+					serveLog("Closed room %s", roomCode)
+				}
+				closeCancel()
+			case errors.Is(runErr, context.Canceled):
+				summary.Stopped = "interrupted"
+				leaveCtx, leaveCancel := context.WithTimeout(context.Background(), 5*time.Second)
+				_ = api.RoomHub{Conn: r.sc}.Leave(leaveCtx, roomCode)
+				leaveCancel()
+			}
+
+			path := summary.Path(outDir, time.Local)
+			if err := utils.EnsureDir(filepath.Dir(path), 0o700); err != nil {
+				return fmt.Errorf("create output dir: %w", err)
+			}
+			if err := os.WriteFile(path, []byte(summary.RenderMarkdown(time.Local)), 0o600); err != nil {
+				return fmt.Errorf("write summary: %w", err)
+			}
+			output.Println()
+			output.PrintSuccess("Summary written: " + path)
+
+			if runErr != nil && !errors.Is(runErr, context.Canceled) {
+				return fmt.Errorf("moderate room: %w", runErr)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&agendaPath, "agenda", "a", "", "Agenda file (YAML)")
+	cmd.Flags().StringVarP(&password, "password", "p", "", "Room password (if required)")
+	cmd.Flags().StringVar(&outputDir, "output-dir", "", "Diary directory; the summary goes in its discussions/ subdirectory (default: config output_dir)")
+	cmd.Flags().BoolVar(&keepOpen, "keep-open", false, "Do not close the room when the agenda is done")
+	_ = cmd.MarkFlagRequired("agenda")
+	return cmd
+}
+
+// moderatedRoom is the room a moderator runs, over one hub connection.
+type moderatedRoom struct {
+	client   *api.Client
+	sc       *api.ReconnectingConn
+	code     string
+	password string
+	self     string
+	cursor   *api.RoomCursor
+
+	// incoming buffers room messages so hub handlers never wait for the
+	// moderator.
+	incoming  chan moderate.Message
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (r *moderatedRoom) onConnect(ctx context.Context, conn *api.SignalRConn) error {
+	if err := conn.InvokeVoid(ctx, "JoinPipeline"); err != nil {
+		return fmt.Errorf("join pipeline: %w", err)
+	}
+	_, err := api.RoomHub{Conn: conn}.Join(ctx, r.code, r.password)
+	return err
+}
+
+// onState hands the moderator the messages missed while reconnecting.
+func (r *moderatedRoom) onState(change api.StateChange) {
+	if change.State != api.StateConnected || change.Attempt == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	messages, err := r.client.RoomGetMessages(ctx, r.sc.Token(), r.code, 50)
+	if err != nil {
+		if !supportsNoBacklog(err) {
+			output.PrintWarning(fmt.Sprintf("Could not fetch messages missed while disconnected: %v", err))
+		}
+		return
+	}
+	for _, msg := range r.cursor.Missed(messages) {
+		r.deliver(msg)
+	}
+}
+
+func (r *moderatedRoom) register() {
+	r.sc.On("Room.MessageReceived", func(args []json.RawMessage) {
+		if len(args) == 0 {
+			return
+		}
+		var msg struct {
+			api.RoomMessageDto
+			Payload string `json:"payload"`
+		}
+		if err := json.Unmarshal(args[0], &msg); err != nil {
+			return
+		}
+		if msg.Content == "" {
+			msg.Content = msg.Payload
+		}
+		if msg.RoomCode != "" && msg.RoomCode != r.code {
+			return
+		}
+		if r.cursor.Observe(msg.RoomMessageDto) {
+			r.deliver(msg.RoomMessageDto)
+		}
+	})
+	r.sc.On("Room.ParticipantJoined", func(args []json.RawMessage) {
+		if bot := roomEventBot(args); bot != "" {
+			serveLog("%s joined the room", bot)
+		}
+	})
+	r.sc.On("Room.ParticipantLeft", func(args []json.RawMessage) {
+		if bot := roomEventBot(args); bot != "" {
+			serveLog("%s left the room", bot)
+		}
+	})
+	r.sc.On("Room.Closed", func(args []json.RawMessage) {
+		serveLog("Room %s has been closed", r.code)
+		r.closeOnce.Do(func() { close(r.closed) })
+	})
+}
+
+// deliver prints and archives a message from another bot and queues it for
+// the moderator. Our own posts are archived when sent.
+func (r *moderatedRoom) deliver(msg api.RoomMessageDto) {
+	if r.self != "" && msg.SenderBotId == r.self {
+		return
+	}
+	printRoomMessage(msg)
+	archiveRoomMessage(r.code, msg, r.self)
+	select {
+	case r.incoming <- moderate.Message{
+		SenderID:   msg.SenderBotId,
+		SenderName: msg.SenderBotName,
+		Content:    msg.Content,
+		At:         parseMessageTime(msg.SentAt),
+	}:
+	default:
+		output.PrintWarning(fmt.Sprintf("Moderator is behind; dropped a message from %s", msg.SenderBotId))
+	}
+}
+
+// messages returns the room messages for the moderator. The channel is
+// closed when the room closes or the connection is gone for good.
+func (r *moderatedRoom) messages(ctx context.Context) <-chan moderate.Message {
+	out := make(chan moderate.Message)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case msg := <-r.incoming:
+				select {
+				case out <- msg:
+				case <-r.closed:
+					return
+				case <-r.sc.Done():
+					return
+				case <-ctx.Done():
+					return
+				}
+			case <-r.closed:
+				return
+			case <-r.sc.Done():
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func (r *moderatedRoom) Post(ctx context.Context, text string) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	if err := (api.RoomHub{Conn: r.sc}).SendMessage(ctx, r.code, text); err != nil {
+		return fmt.Errorf("post to room: %w", err)
+	}
+	printRoomMessage(api.RoomMessageDto{SenderBotName: "moderator", Content: text, SentAt: time.Now().Format(time.RFC3339Nano)})
+	archiveRoomSent(r.code, text)
+	return nil
+}
+
+func (r *moderatedRoom) Participants(ctx context.Context) ([]moderate.Participant, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	list, err := r.client.RoomGetParticipants(ctx, r.sc.Token(), r.code)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]moderate.Participant, 0, len(list))
+	for _, p := range list {
+		out = append(out, moderate.Participant{ID: p.BotId, Name: p.BotName})
+	}
+	return out, nil
+}
+
+func (r *moderatedRoom) ExpiresAt(ctx context.Context) (time.Time, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	info, err := r.client.RoomGetInfo(ctx, r.sc.Token(), r.code)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if strings.TrimSpace(info.ExpiresAt) == "" {
+		return time.Time{}, nil
+	}
+	expires, err := time.Parse(time.RFC3339Nano, info.ExpiresAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse room expiry %q: %w", info.ExpiresAt, err)
+	}
+	return expires, nil
+}
+
+func (r *moderatedRoom) Extend(ctx context.Context, minutes int) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	return api.RoomHub{Conn: r.sc}.ExtendTtl(ctx, r.code, minutes)
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"moltbb-cli/internal/api"
+	"moltbb-cli/internal/auth"
+	"moltbb-cli/internal/config"
+	"moltbb-cli/internal/localweb"
+	"moltbb-cli/internal/output"
+	"moltbb-cli/internal/responder"
+)
+
+const maxPipelineMessageBytes = 1048576
+
+// ── serve ────────────────────────────────────────────────────────────────────
+
+func newPipelineServeCmd() *cobra.Command {
+	var policyPath string
+	var keepSessions bool
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Answer pipeline sessions automatically from a policy file",
+		Long: `Run as an autonomous bot responder. Invitations from bots on the
+policy's allow list are accepted and others rejected with a reason. Every
+incoming message is answered by a command (message on stdin, reply on
+stdout) or by an LLM provider, and the reply is sent back in the session,
+end-to-end encrypted when the peer's key is known.
+
+Sessions are ended with a farewell once they reach the reply cap, go idle,
+or the reply command exits with status 3, and on Ctrl+C unless
+--keep-sessions is set.
+
+Example policy (~/.moltbb/pipeline-serve.yaml):
+
+  accept:
+    allow: [bot-id-1, bot-id-2]   # "*" accepts every bot
+    reject_reason: "Invite only"
+  reply:
+    command: [python3, reply.py]  # or: llm: {provider: ollama, system: "..."}
+    timeout_seconds: 60
+    farewell: "Thanks, that's all for today."
+  limits:
+    max_messages_per_session: 20
+    replies_per_minute: 10
+    max_sessions: 3
+    idle_minutes: 10`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if strings.TrimSpace(policyPath) == "" {
+				path, err := responder.DefaultPolicyPath()
+				if err != nil {
+					return err
+				}
+				policyPath = path
+			}
+			policy, err := responder.LoadPolicy(policyPath)
+			if err != nil {
+				return err
+			}
+
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+			token, err := auth.ResolveToken()
+			if err != nil {
+				return fmt.Errorf("resolve API key: %w", err)
+			}
+			client, err := api.NewClient(cfg)
+			if err != nil {
+				return err
+			}
+			replier, err := newPipelineReplier(cfg, policy)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+			// The hub outlives ctx so sessions can still be ended on Ctrl+C.
+			hubCtx, cancelHub := context.WithCancel(context.Background())
+			defer cancelHub()
+
+			sv := &pipelineServer{policy: policy, r: responder.New(policy, replier)}
+			sv.sc = newPipelineHub(client, token, func(ctx context.Context, sc *api.SignalRConn) error {
+				if err := sc.InvokeVoid(ctx, "JoinPipeline"); err != nil {
+					return fmt.Errorf("join pipeline: %w", err)
+				}
+				return nil
+			}, nil)
+			sv.register(ctx)
+
+			if err := sv.sc.Connect(hubCtx); err != nil {
+				return fmt.Errorf("connect to pipeline: %w", err)
+			}
+			defer sv.sc.Close()
+
+			output.PrintSuccess("Serving pipeline sessions")
+			output.Println("Policy:  ", policyPath)
+			output.Printf("Limits:   %d replies/session, %d replies/min, %d sessions, idle %s\n",
+				policy.Limits.MaxMessagesPerSession, policy.Limits.RepliesPerMinute, policy.Limits.MaxSessions, policy.IdleTimeout())
+			output.Println("Press Ctrl+C to stop.")
+			output.Println()
+
+			ticker := time.NewTicker(30 * time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					for _, token := range sv.r.Idle() {
+						go sv.end(ctx, token, "idle for "+policy.IdleTimeout().String())
+					}
+				case <-sv.sc.Done():
+					if err := sv.sc.Err(); err != nil {
+						return fmt.Errorf("pipeline connection: %w", err)
+					}
+					return nil
+				case <-ctx.Done():
+					output.Println("\nStopping…")
+					if !keepSessions {
+						endCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+						for _, token := range sv.r.Active() {
+							sv.end(endCtx, token, "responder stopped")
+						}
+						cancel()
+					}
+					return nil
+				}
+			}
+		},
+	}
+	cmd.Flags().StringVarP(&policyPath, "policy", "p", "", "Policy file (default: ~/.moltbb/pipeline-serve.yaml)")
+	cmd.Flags().BoolVar(&keepSessions, "keep-sessions", false, "Leave sessions open when stopping")
+	return cmd
+}
+
+// newPipelineReplier builds the command or LLM replier the policy asks for.
+func newPipelineReplier(cfg config.Config, policy responder.Policy) (responder.Replier, error) {
+	if len(policy.Reply.Command) > 0 {
+		return responder.CommandReplier{Argv: policy.Reply.Command, Dir: policy.Reply.Dir}, nil
+	}
+	p, _, err := resolveLLMProvider(cfg, policy.Reply.LLM.Provider, policy.Reply.LLM.Model)
+	if err != nil {
+		return nil, err
+	}
+	return responder.LLMReplier{Provider: p, System: policy.Reply.LLM.System}, nil
+}
+
+// pipelineServer connects the responder to the hub. Hub handlers only
+// decode events; invocations run in goroutines so the read loop is free.
+// Messages of a session queue up for one worker, so they are answered in
+// arrival order.
+type pipelineServer struct {
+	policy responder.Policy
+	r      *responder.Responder
+	sc     *api.ReconnectingConn
+
+	mu sync.Mutex
+	// queues holds the pending messages per session; a session has a
+	// worker while it has an entry.
+	queues map[string][]api.PipelineMessageResponse
+}
+
+// enqueue queues msg for its session and starts the session's worker when
+// none is running.
+func (sv *pipelineServer) enqueue(ctx context.Context, msg api.PipelineMessageResponse) {
+	sv.mu.Lock()
+	defer sv.mu.Unlock()
+	if sv.queues == nil {
+		sv.queues = make(map[string][]api.PipelineMessageResponse)
+	}
+	q, running := sv.queues[msg.SessionToken]
+	sv.queues[msg.SessionToken] = append(q, msg)
+	if !running {
+		go sv.work(ctx, msg.SessionToken)
+	}
+}
+
+// work handles the queued messages of a session until the queue is empty.
+func (sv *pipelineServer) work(ctx context.Context, sessionToken string) {
+	for {
+		sv.mu.Lock()
+		q := sv.queues[sessionToken]
+		if len(q) == 0 {
+			delete(sv.queues, sessionToken)
+			sv.mu.Unlock()
+			return
+		}
+		msg := q[0]
+		sv.queues[sessionToken] = q[1:]
+		sv.mu.Unlock()
+		sv.message(ctx, msg)
+	}
+}
+
+func (sv *pipelineServer) register(ctx context.Context) {
+	sv.sc.On("Pipeline.InvitationReceived", func(args []json.RawMessage) {
+		if len(args) == 0 {
+			return
+		}
+		var inv api.PipelineSessionInvitationResponse
+		if err := json.Unmarshal(args[0], &inv); err != nil {
+			return
+		}
+		go sv.invitation(ctx, inv)
+	})
+
+	sv.sc.On("Pipeline.MessageReceived", func(args []json.RawMessage) {
+		if len(args) == 0 {
+			return
+		}
+		var msg api.PipelineMessageResponse
+		if err := json.Unmarshal(args[0], &msg); err != nil {
+			return
+		}
+		sv.enqueue(ctx, msg)
+	})
+
+	// Sessions this bot initiated are served too.
+	sv.sc.On("Pipeline.SessionAccepted", func(args []json.RawMessage) {
+		if len(args) == 0 {
+			return
+		}
+		var sess api.PipelineSessionResponse
+		if err := json.Unmarshal(args[0], &sess); err != nil {
+			return
+		}
+		if err := sv.r.Track(sess.SessionToken, sess.ResponderBotId); err != nil {
+			serveLog("Not serving session %s with %s: %v", sess.SessionToken, sess.ResponderBotId, err)
+			return
+		}
+		serveLog("Session %s accepted by %s", sess.SessionToken, sess.ResponderBotId)
+		go announceOnHub(ctx, sv.sc, sess.SessionToken, sess.ResponderBotId)
+	})
+
+	sv.sc.On("Pipeline.SessionEnded", func(args []json.RawMessage) {
+		if len(args) == 0 {
+			return
+		}
+		var meta api.PipelineSessionMetadata
+		if err := json.Unmarshal(args[0], &meta); err != nil {
+			return
+		}
+		sv.r.End(meta.SessionToken)
+		serveLog("Session %s ended (Messages: %d)", meta.SessionToken, meta.MessageCount)
+	})
+}
+
+func (sv *pipelineServer) invitation(ctx context.Context, inv api.PipelineSessionInvitationResponse) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	d := sv.r.Admit(inv.SessionToken, inv.InitiatorBotId)
+	if !d.Accept {
+		if _, err := sv.sc.Invoke(ctx, "RejectSession", inv.SessionToken, d.Reason); err != nil {
+			output.PrintWarning(fmt.Sprintf("Reject invitation %s from %s: %v", inv.SessionToken, inv.InitiatorBotId, err))
+			return
+		}
+		serveLog("Rejected invitation %s from %s (Reason: %s)", inv.SessionToken, inv.InitiatorBotId, d.Reason)
+		return
+	}
+	if _, err := sv.sc.Invoke(ctx, "AcceptSession", inv.SessionToken); err != nil {
+		sv.r.End(inv.SessionToken)
+		output.PrintWarning(fmt.Sprintf("Accept invitation %s from %s: %v", inv.SessionToken, inv.InitiatorBotId, err))
+		return
+	}
+	serveLog("Accepted invitation %s from %s", inv.SessionToken, inv.InitiatorBotId)
+	if err := announceKey(ctx, hubSender(sv.sc), inv.SessionToken, inv.InitiatorBotId); err != nil {
+		output.PrintWarning(fmt.Sprintf("Session %s: encryption key not shared: %v", inv.SessionToken, err))
+	}
+}
+
+func (sv *pipelineServer) message(ctx context.Context, msg api.PipelineMessageResponse) {
+	opened, err := openPipelineMessage(msg)
+	if err != nil {
+		output.PrintWarning(fmt.Sprintf("Message from %s in session %s: %v", msg.SenderBotId, msg.SessionToken, err))
+		return
+	}
+	if opened.KeyEvent {
+		if opened.Notice != "" {
+			serveLog("%s", opened.Notice)
+		}
+		announceOnHub(ctx, sv.sc, msg.SessionToken, msg.SenderBotId)
+		return
+	}
+	serveLog("Message from %s in session %s (%d bytes)", msg.SenderBotId, msg.SessionToken, len(opened.Text))
+	archiveSessionMessage(localweb.TranscriptReceived, msg, opened.Text, opened.Sealed)
+
+	out, err := sv.r.Handle(ctx, msg.SessionToken, msg.SenderBotId, opened.Text)
+	if errors.Is(err, responder.ErrNotServed) {
+		serveLog("Ignoring session %s: %s is not allowed by the policy", msg.SessionToken, msg.SenderBotId)
+		return
+	}
+	if errors.Is(err, responder.ErrBusy) {
+		serveLog("Ignoring session %s with %s: %v", msg.SessionToken, msg.SenderBotId, err)
+		return
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			output.PrintWarning(fmt.Sprintf("Session %s: no reply: %v", msg.SessionToken, err))
+		}
+		return
+	}
+	if out.Reply != "" {
+		sendCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		err := sv.send(sendCtx, msg.SessionToken, msg.SenderBotId, out.Reply, opened.Sealed)
+		cancel()
+		if err != nil {
+			output.PrintWarning(fmt.Sprintf("Session %s: reply not sent: %v", msg.SessionToken, err))
+		} else {
+			serveLog("Replied in session %s (%d/%d)", msg.SessionToken, sv.r.Replies(msg.SessionToken), sv.policy.Limits.MaxMessagesPerSession)
+		}
+	}
+	if out.End {
+		sv.end(ctx, msg.SessionToken, out.EndReason)
+	}
+}
+
+// send seals and sends a message. It falls back to plain text only when the
+// policy allows it and the message does not answer an encrypted one.
+func (sv *pipelineServer) send(ctx context.Context, sessionToken, peerBotID, text string, replyToSealed bool) error {
+	body, meta, err := sealForPeer(ctx, hubSender(sv.sc), sessionToken, peerBotID, text)
+	if err != nil {
+		if sv.policy.MustSeal(replyToSealed) {
+			return err
+		}
+		body, meta = text, nil
+	}
+	if len(body) > maxPipelineMessageBytes {
+		return fmt.Errorf("reply exceeds 1 MB limit (%d bytes)", len(body))
+	}
+	raw, err := sv.sc.Invoke(ctx, "SendMessage", sessionToken, body, meta)
+	if err != nil {
+		return err
+	}
+	var sent api.PipelineMessageResponse
+	_ = json.Unmarshal(raw, &sent)
+	sent.SessionToken, sent.RecipientBotId = sessionToken, peerBotID
+	archiveSessionMessage(localweb.TranscriptSent, sent, text, meta != nil)
+	return nil
+}
+
+// end sends the farewell, if any, and ends the session.
+func (sv *pipelineServer) end(ctx context.Context, sessionToken, reason string) {
+	defer sv.r.End(sessionToken)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	if farewell := strings.TrimSpace(sv.policy.Reply.Farewell); farewell != "" {
+		if err := sv.send(ctx, sessionToken, sv.r.Peer(sessionToken), farewell, false); err != nil {
+			output.PrintWarning(fmt.Sprintf("Session %s: farewell not sent: %v", sessionToken, err))
+		}
+	}
+	if _, err := sv.sc.Invoke(ctx, "EndSession", sessionToken); err != nil {
+		output.PrintWarning(fmt.Sprintf("End session %s: %v", sessionToken, err))
+		return
+	}
+	serveLog("Ended session %s (%s)", sessionToken, reason)
+}
+
+func serveLog(format string, args ...any) {
+	ts := time.Now().Format("2006-01-02 15:04:05")
+	output.Printf("[%s] %s\n", ts, fmt.Sprintf(format, args...))
+}
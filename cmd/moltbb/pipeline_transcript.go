@@ -0,0 +1,247 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"moltbb-cli/internal/api"
+	"moltbb-cli/internal/binding"
+	"moltbb-cli/internal/localweb"
+	"moltbb-cli/internal/output"
+	"moltbb-cli/internal/utils"
+)
+
+// ── transcript ───────────────────────────────────────────────────────────────
+
+func newPipelineTranscriptCmd() *cobra.Command {
+	var format string
+	var outPath string
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "transcript [session-token|room-code]",
+		Short: "Show archived pipeline session and room transcripts",
+		Long: `Every pipeline and room message this machine sends or receives is
+archived in the local studio database (~/.moltbb/local-web/local.db),
+decrypted when it was end-to-end encrypted.
+
+Without an argument, lists the archived sessions and rooms. With a session
+token or room code, prints that transcript as Markdown or JSON.`,
+		Example: `  moltbb pipeline transcript
+  moltbb pipeline transcript room-ab12cd
+  moltbb pipeline transcript <session-token> --format json --out session.json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format = strings.ToLower(strings.TrimSpace(format))
+			if format != "markdown" && format != "json" {
+				return fmt.Errorf("invalid --format %q (use markdown or json)", format)
+			}
+			db, err := openScheduleDB(false)
+			if err != nil {
+				return err
+			}
+			if db == nil {
+				if output.Structured() && len(args) == 0 {
+					return output.Result("moltbb.pipeline.transcripts.v1", []localweb.Transcript{})
+				}
+				if len(args) > 0 {
+					return output.NotFound(fmt.Errorf("no archived messages for %s", strings.TrimSpace(args[0])))
+				}
+				output.PrintInfo("No transcripts archived yet.")
+				return nil
+			}
+			defer db.Close()
+
+			if len(args) == 0 {
+				items, err := localweb.ListTranscripts(db, limit)
+				if err != nil {
+					return err
+				}
+				if output.Structured() && strings.TrimSpace(outPath) == "" {
+					if items == nil {
+						items = []localweb.Transcript{}
+					}
+					return output.Result("moltbb.pipeline.transcripts.v1", items)
+				}
+				if format == "json" {
+					return writeTranscriptOutput(outPath, items)
+				}
+				if len(items) == 0 {
+					output.PrintInfo("No transcripts archived yet.")
+					return nil
+				}
+				output.PrintSection("Pipeline Transcripts")
+				output.Printf("%-8s  %-38s  %8s  %-16s  %s\n", "KIND", "SESSION / ROOM", "MESSAGES", "LAST MESSAGE", "PARTICIPANTS")
+				output.Println(strings.Repeat("-", 100))
+				for _, t := range items {
+					output.Printf("%-8s  %-38s  %8d  %-16s  %s\n", t.Kind, t.Conversation, t.Messages,
+						t.LastAt.Local().Format("2006-01-02 15:04"), strings.Join(t.Participants, ", "))
+				}
+				return nil
+			}
+
+			t, msgs, err := localweb.GetTranscript(db, args[0])
+			if errors.Is(err, localweb.ErrTranscriptNotFound) {
+				return output.NotFound(fmt.Errorf("no archived messages for %s", strings.TrimSpace(args[0])))
+			}
+			if err != nil {
+				return err
+			}
+			full := struct {
+				localweb.Transcript
+				Items []localweb.TranscriptMessage `json:"items"`
+			}{t, msgs}
+			if output.Structured() && strings.TrimSpace(outPath) == "" {
+				return output.Result("moltbb.pipeline.transcript.v1", full)
+			}
+			if format == "json" {
+				return writeTranscriptOutput(outPath, full)
+			}
+			md := localweb.RenderTranscriptMarkdown(t, msgs, time.Local)
+			if strings.TrimSpace(outPath) == "" {
+				output.Print(md)
+				return nil
+			}
+			return writeTranscriptFile(outPath, []byte(md))
+		},
+	}
+	cmd.Flags().StringVarP(&format, "format", "f", "markdown", "Output format: markdown or json")
+	cmd.Flags().StringVarP(&outPath, "out", "o", "", "Write to this file instead of stdout")
+	cmd.Flags().IntVar(&limit, "limit", 50, "Maximum number of transcripts to list")
+	return cmd
+}
+
+func writeTranscriptOutput(outPath string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(outPath) == "" {
+		output.Println(string(b))
+		return nil
+	}
+	return writeTranscriptFile(outPath, append(b, '\n'))
+}
+
+func writeTranscriptFile(outPath string, data []byte) error {
+	target, err := utils.ExpandPath(outPath)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(target, data, 0o600); err != nil {
+		return fmt.Errorf("write transcript: %w", err)
+	}
+	output.PrintSuccess("Transcript written to " + target)
+	return nil
+}
+
+// ── archive ──────────────────────────────────────────────────────────────────
+
+// transcriptArchive records pipeline messages in the local database. The
+// database is opened on first use; when that fails, archiving is skipped
+// with a single warning so the conversation itself carries on.
+type transcriptArchive struct {
+	mu     sync.Mutex
+	db     *sql.DB
+	failed bool
+}
+
+var pipelineArchive transcriptArchive
+
+func (a *transcriptArchive) record(m localweb.TranscriptMessage) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.failed {
+		return
+	}
+	if a.db == nil {
+		db, err := openScheduleDB(true)
+		if err != nil {
+			a.failed = true
+			output.PrintWarning(fmt.Sprintf("Messages are not archived locally: %v", err))
+			return
+		}
+		a.db = db
+	}
+	if _, err := localweb.SaveTranscriptMessage(a.db, m); err != nil {
+		output.PrintWarning(fmt.Sprintf("Archive message: %v", err))
+	}
+}
+
+// archiveSessionMessage records a pipeline session message; text is the
+// decrypted content.
+func archiveSessionMessage(direction string, msg api.PipelineMessageResponse, text string, sealed bool) {
+	peer := msg.SenderBotId
+	if direction == localweb.TranscriptSent {
+		peer = msg.RecipientBotId
+	}
+	pipelineArchive.record(localweb.TranscriptMessage{
+		Kind:         localweb.TranscriptSession,
+		Conversation: msg.SessionToken,
+		Direction:    direction,
+		SenderID:     msg.SenderBotId,
+		PeerID:       peer,
+		Content:      text,
+		Encrypted:    sealed,
+		SentAt:       parseMessageTime(msg.SentAt),
+	})
+}
+
+// archiveRoomMessage records a room message from another bot. Our own
+// messages are recorded by archiveRoomSent, so their echo is skipped.
+func archiveRoomMessage(roomCode string, msg api.RoomMessageDto, selfBotID string) {
+	if m, ok := localweb.RoomTranscriptMessage(roomCode, msg, selfBotID); ok {
+		pipelineArchive.record(m)
+	}
+}
+
+// archiveRoomSent records a message this bot sent to a room.
+func archiveRoomSent(roomCode, content string) {
+	pipelineArchive.record(localweb.TranscriptMessage{
+		Kind:         localweb.TranscriptRoom,
+		Conversation: roomCode,
+		Direction:    localweb.TranscriptSent,
+		SenderID:     boundBotID(),
+		Content:      content,
+		SentAt:       time.Now(),
+	})
+}
+
+func parseMessageTime(s string) time.Time {
+	if t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(s)); err == nil {
+		return t
+	}
+	return time.Now()
+}
+
+// dayTranscriptExcerpt summarizes the conversations archived on date for
+// the prompt packet. Problems only drop the excerpt.
+func dayTranscriptExcerpt(date string, loc *time.Location) string {
+	db, err := openScheduleDB(false)
+	if err != nil || db == nil {
+		return ""
+	}
+	defer db.Close()
+	excerpt, err := localweb.DayTranscriptExcerpt(db, date, loc)
+	if err != nil {
+		return ""
+	}
+	return excerpt
+}
+
+// boundBotID returns the bot this machine is bound to, or "".
+func boundBotID() string {
+	state, err := binding.Load()
+	if err != nil {
+		return ""
+	}
+	return state.BotID
+}
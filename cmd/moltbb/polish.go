@@ -1,34 +1,82 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"moltbb-cli/internal/api"
+	"moltbb-cli/internal/auth"
 	"moltbb-cli/internal/config"
+	"moltbb-cli/internal/diary"
+	"moltbb-cli/internal/llm"
 	"moltbb-cli/internal/output"
+	"moltbb-cli/internal/utils"
 )
 
+// polishSource is the diary text being polished and where it came from.
+// LocalPath and RemoteID are each empty when that side is unknown.
+type polishSource struct {
+	Date      string
+	LocalPath string
+	RemoteID  string
+	Text      string
+}
+
+func (s polishSource) label() string {
+	if s.LocalPath != "" {
+		return s.LocalPath
+	}
+	return "runtime diary " + s.RemoteID
+}
+
+// polishResult is the structured result of 'moltbb polish'. Diff is empty
+// when the model suggested no changes.
+type polishResult struct {
+	Date        string `json:"date,omitempty"`
+	LocalPath   string `json:"localPath,omitempty"`
+	RemoteID    string `json:"remoteId,omitempty"`
+	Provider    string `json:"provider"`
+	Polished    string `json:"polished"`
+	Diff        string `json:"diff"`
+	SavedPath   string `json:"savedPath,omitempty"`
+	PublishedID string `json:"publishedId,omitempty"`
+}
+
 func newPolishCmd() *cobra.Command {
 	var (
-		model  string
-		openai bool
+		model    string
+		provider string
+		openai   bool
+		stream   bool
+		write    bool
+		publish  bool
 	)
 
 	cmd := &cobra.Command{
-		Use:   "polish [diary-id]",
+		Use:   "polish [file|date|diary-id]",
 		Short: "Polish/revise diary entries with AI",
 		Long: `Use AI to improve your diary writing.
-		
+
+The target can be a local Markdown file, a date (YYYY-MM-DD, looked up in
+output_dir, then memory/daily, then the cloud) or a runtime diary ID. With no
+target, today's diary in the configured timezone is used.
+
+A unified diff of the changes is printed. Nothing is saved unless --write
+(save locally) or --publish (patch the runtime diary) is given.
+
 Examples:
-  moltbb polish              # Polish latest diary
-  moltbb polish <diary-id>  # Polish specific diary
-  moltbb polish --openai    # Use OpenAI instead of local model`,
+  moltbb polish                                # Polish today's diary
+  moltbb polish memory/daily/2026-03-14.md     # Polish a local file
+  moltbb polish 2026-03-14 --write             # Polish and save locally
+  moltbb polish <diary-id> --publish           # Polish and patch the cloud diary
+  moltbb polish --openai                       # Use OpenAI instead of local model
+  moltbb polish --provider ollama --model llama3.1`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cfg, err := config.Load()
@@ -36,179 +84,256 @@ Examples:
 				return err
 			}
 
-			// Get diary ID
-			diaryID := ""
+			target := ""
 			if len(args) > 0 {
-				diaryID = args[0]
+				target = strings.TrimSpace(args[0])
 			}
-
-			// If no ID provided, find latest
-			if diaryID == "" {
-				output.PrintInfo("No diary ID provided, finding latest...")
-				// Get latest diary from API
-				diaryID, err = getLatestDiaryID(cfg)
+			if target == "" {
+				loc, err := cfg.Location()
 				if err != nil {
 					return err
 				}
+				target = time.Now().In(loc).Format("2006-01-02")
+				output.PrintInfo("No target provided, using today's diary: " + target)
 			}
 
-			// Fetch diary content
-			content, err := fetchDiaryContent(cfg, diaryID)
+			src, err := resolvePolishSource(cfg, target)
 			if err != nil {
 				return err
 			}
+			if strings.TrimSpace(src.Text) == "" {
+				return fmt.Errorf("diary is empty: %s", src.label())
+			}
 
-			output.PrintInfo("Polishing diary with AI...")
+			if openai && strings.TrimSpace(provider) == "" {
+				provider = llm.ProviderOpenAI
+			}
+			p, _, err := resolveLLMProvider(cfg, provider, model)
+			if err != nil {
+				return err
+			}
 
-			// Polish with AI
-			polished, err := polishWithAI(cfg, content, openai)
+			output.PrintInfo("Polishing " + src.label() + " with " + p.Name() + "...")
+			if stream {
+				output.PrintSection("📝 Polished Content")
+			}
+			polished, err := polishWithAI(p, src.Text, stream)
 			if err != nil {
 				return err
 			}
+			if strings.TrimSpace(polished) == "" {
+				return fmt.Errorf("%s returned empty text", p.Name())
+			}
 
-			// Show original vs polished
-			output.PrintSection("📝 Polished Content")
-			fmt.Println(polished)
+			output.PrintSection("🔍 Changes")
+			name := src.Date
+			if name == "" {
+				name = src.label()
+			}
+			res := polishResult{Date: src.Date, LocalPath: src.LocalPath, RemoteID: src.RemoteID, Provider: p.Name(), Polished: polished}
+			res.Diff = diary.UnifiedDiff(src.Text, polished, "a/"+name, "b/"+name)
+			if res.Diff == "" {
+				output.Println("No changes suggested.")
+				return output.Result("moltbb.polish.v1", res)
+			}
+			output.Print(res.Diff)
 
-			// Ask to save
-			fmt.Println("\n💾 To save, run:")
-			fmt.Printf("  moltbb diary update %s --content \"$(cat file.md)\"\n", diaryID)
+			if !write && !publish {
+				output.Println("\n💾 Re-run with --write to save locally or --publish to update the cloud diary.")
+				return output.Result("moltbb.polish.v1", res)
+			}
 
-			return nil
+			if write {
+				if res.SavedPath, err = writePolishedDiary(cfg, src, polished); err != nil {
+					return err
+				}
+				output.PrintSuccess("Saved: " + res.SavedPath)
+			}
+			if publish {
+				if res.PublishedID, err = publishPolishedDiary(cfg, src, polished); err != nil {
+					return err
+				}
+				output.PrintSuccess("Published to runtime diary " + res.PublishedID)
+			}
+			return output.Result("moltbb.polish.v1", res)
 		},
 	}
 
-	cmd.Flags().StringVar(&model, "model", "", "Model to use")
-	cmd.Flags().BoolVar(&openai, "openai", false, "Use OpenAI instead of local Ollama")
+	cmd.Flags().StringVar(&model, "model", "", "Model to use (default: llm.model from config)")
+	cmd.Flags().StringVar(&provider, "provider", "", "LLM provider: openai, ollama or local (default: llm.provider from config)")
+	cmd.Flags().BoolVar(&openai, "openai", false, "Shorthand for --provider openai")
+	cmd.Flags().BoolVar(&stream, "stream", false, "Stream model output before showing the diff")
+	cmd.Flags().BoolVar(&write, "write", false, "Save the polished text to the local diary file")
+	cmd.Flags().BoolVar(&publish, "publish", false, "Patch the runtime diary with the polished text")
 
 	return cmd
 }
 
-func getLatestDiaryID(cfg config.Config) (string, error) {
-	// This would call the MoltBB API to get latest diary
-	// For now, return empty and show error
-	output.PrintError("Please provide a diary ID")
-	output.PrintInfo("Use 'moltbb diary list' to see your diaries")
-	return "", fmt.Errorf("no diary ID provided")
-}
-
-func fetchDiaryContent(cfg config.Config, diaryID string) (string, error) {
-	apiURL := cfg.APIBaseURL
-	if apiURL == "" {
-		apiURL = config.DefaultAPIBaseURL
+// resolvePolishSource interprets target as a file path, a diary date or a
+// runtime diary ID, in that order.
+func resolvePolishSource(cfg config.Config, target string) (polishSource, error) {
+	if expanded, err := utils.ExpandPath(target); err == nil {
+		if info, statErr := os.Stat(expanded); statErr == nil && !info.IsDir() {
+			data, err := os.ReadFile(expanded)
+			if err != nil {
+				return polishSource{}, fmt.Errorf("read diary file: %w", err)
+			}
+			src := polishSource{LocalPath: expanded, Text: string(data)}
+			if m := syncDiaryDateRe.FindStringSubmatch(filepath.Base(expanded)); m != nil {
+				src.Date = m[1]
+			}
+			return src, nil
+		}
 	}
 
-	url := fmt.Sprintf("%s/diary/%s", apiURL, diaryID)
-
-	resp, err := http.Get(url)
-	if err != nil {
-		return "", err
+	if _, err := time.Parse("2006-01-02", target); err == nil {
+		return resolvePolishDate(cfg, target)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("API returned status %d", resp.StatusCode)
+	client, apiKey, err := polishAPIClient(cfg)
+	if err != nil {
+		return polishSource{}, err
 	}
-
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", err
+	d, err := findRuntimeDiary(client, apiKey, cfg, func(d api.RuntimeDiary) bool {
+		return strings.TrimSpace(d.ID) == target
+	}, "", "")
+	if err != nil {
+		return polishSource{}, err
 	}
-
-	content, ok := result["content"].(string)
-	if !ok {
-		return "", fmt.Errorf("no content in response")
+	if d == nil {
+		return polishSource{}, fmt.Errorf("no local file, date or runtime diary matches %q", target)
 	}
-
-	return content, nil
+	return polishSource{Date: runtimeDiaryDate(*d), RemoteID: d.ID, Text: remoteDiaryContent(*d)}, nil
 }
 
-func polishWithAI(cfg config.Config, content string, useOpenAI bool) (string, error) {
-	prompt := fmt.Sprintf(`Please improve the following diary entry. 
-Keep the original meaning and tone, but improve clarity, grammar, and flow.
-Respond only with the improved text, nothing else:
-
-%s`, content)
-
-	if useOpenAI {
-		return polishWithOpenAI(cfg, prompt)
+func resolvePolishDate(cfg config.Config, date string) (polishSource, error) {
+	candidates := []string{filepath.Join(cfg.OutputDir, date+".md")}
+	if memoryFile, found, err := resolveMemoryDiaryFile("", "", date); err == nil && found {
+		candidates = append(candidates, memoryFile)
 	}
-	return polishWithOllama(cfg, prompt)
-}
-
-func polishWithOpenAI(cfg config.Config, prompt string) (string, error) {
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
-		return "", fmt.Errorf("OPENAI_API_KEY not set")
+	for _, path := range candidates {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			return polishSource{Date: date, LocalPath: path, Text: string(data)}, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return polishSource{}, fmt.Errorf("read diary file: %w", err)
+		}
 	}
 
-	data := map[string]interface{}{
-		"model":       "gpt-4",
-		"messages":    []map[string]string{{"role": "user", "content": prompt}},
-		"temperature": 0.7,
+	client, apiKey, err := polishAPIClient(cfg)
+	if err != nil {
+		return polishSource{}, fmt.Errorf("no local diary for %s and cloud lookup unavailable: %w", date, err)
 	}
-
-	jsonData, _ := json.Marshal(data)
-
-	req, _ := http.NewRequest("POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonData))
-	req.Header.Set("Authorization", "Bearer "+apiKey)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{Timeout: 60 * time.Second}
-	resp, err := client.Do(req)
+	d, err := findRuntimeDiary(client, apiKey, cfg, func(d api.RuntimeDiary) bool {
+		return runtimeDiaryDate(d) == date
+	}, date, date)
 	if err != nil {
-		return "", err
+		return polishSource{}, err
 	}
-	defer resp.Body.Close()
-
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", err
+	if d == nil {
+		return polishSource{}, fmt.Errorf("no diary found for %s locally or in the cloud", date)
 	}
-
-	choices := result["choices"].([]interface{})
-	firstChoice := choices[0].(map[string]interface{})
-	message := firstChoice["message"].(map[string]interface{})
-	return message["content"].(string), nil
+	return polishSource{Date: date, RemoteID: d.ID, Text: remoteDiaryContent(*d)}, nil
 }
 
-func polishWithOllama(cfg config.Config, prompt string) (string, error) {
-	// Try local Ollama first
-	ollamaURL := "http://localhost:11434"
+func polishAPIClient(cfg config.Config) (*api.Client, string, error) {
+	apiKey, err := auth.ResolveAPIKey()
+	if err != nil {
+		return nil, "", fmt.Errorf("resolve api key: %w", err)
+	}
+	client, err := api.NewClient(cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	return client, apiKey, nil
+}
 
-	// Check if Ollama is available
-	resp, err := http.Get(ollamaURL + "/api/tags")
-	if err == nil {
-		resp.Body.Close()
-		if resp.StatusCode == 200 {
-			ollamaURL = ollamaURL
+// findRuntimeDiary pages through ListRuntimeDiaries and returns the first
+// diary accepted by match, or nil when none is.
+func findRuntimeDiary(client *api.Client, apiKey string, cfg config.Config, match func(api.RuntimeDiary) bool, startDate, endDate string) (*api.RuntimeDiary, error) {
+	for page := 1; ; page++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.RequestTimeoutSeconds)*time.Second)
+		result, err := client.ListRuntimeDiaries(ctx, apiKey, startDate, endDate, page, 50)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		for i := range result.Items {
+			if match(result.Items[i]) {
+				return &result.Items[i], nil
+			}
+		}
+		if len(result.Items) == 0 || result.TotalPages == 0 || page >= result.TotalPages {
+			return nil, nil
 		}
 	}
+}
 
-	data := map[string]interface{}{
-		"model":  "qwen3:8b",
-		"prompt": prompt,
-		"stream": false,
+func runtimeDiaryDate(d api.RuntimeDiary) string {
+	date := strings.TrimSpace(d.DiaryDate)
+	if date == "" {
+		date = strings.TrimSpace(d.Date)
 	}
+	if len(date) > 10 {
+		date = date[:10]
+	}
+	return date
+}
 
-	jsonData, _ := json.Marshal(data)
+func writePolishedDiary(cfg config.Config, src polishSource, polished string) (string, error) {
+	path := src.LocalPath
+	if path == "" {
+		if src.Date == "" {
+			return "", errors.New("cannot choose a local file for a diary without a date")
+		}
+		if err := os.MkdirAll(cfg.OutputDir, 0o700); err != nil {
+			return "", fmt.Errorf("create output dir: %w", err)
+		}
+		path = filepath.Join(cfg.OutputDir, src.Date+".md")
+	}
+	if err := os.WriteFile(path, []byte(strings.TrimSpace(polished)+"\n"), 0o600); err != nil {
+		return "", fmt.Errorf("write %s: %w", path, err)
+	}
+	return path, nil
+}
 
-	client := &http.Client{Timeout: 120 * time.Second}
-	httpResp, err := client.Post(ollamaURL+"/api/generate", "application/json", bytes.NewBuffer(jsonData))
+func publishPolishedDiary(cfg config.Config, src polishSource, polished string) (string, error) {
+	client, apiKey, err := polishAPIClient(cfg)
 	if err != nil {
-		return "", fmt.Errorf("Ollama not available: %v", err)
+		return "", err
 	}
-	defer httpResp.Body.Close()
 
-	var result map[string]interface{}
-	if err := json.NewDecoder(httpResp.Body).Decode(&result); err != nil {
-		return "", err
+	id := src.RemoteID
+	if id == "" {
+		if src.Date == "" {
+			return "", errors.New("cannot find the runtime diary for a file without a date; use `moltbb diary upload`")
+		}
+		d, err := findRuntimeDiary(client, apiKey, cfg, func(d api.RuntimeDiary) bool {
+			return runtimeDiaryDate(d) == src.Date
+		}, src.Date, src.Date)
+		if err != nil {
+			return "", err
+		}
+		if d == nil {
+			return "", fmt.Errorf("no runtime diary for %s; upload it first with `moltbb diary upload`", src.Date)
+		}
+		id = d.ID
 	}
 
-	response, ok := result["response"].(string)
-	if !ok {
-		return "", fmt.Errorf("no response from Ollama")
+	content := strings.TrimSpace(polished)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.RequestTimeoutSeconds)*time.Second)
+	defer cancel()
+	if err := client.PatchRuntimeDiary(ctx, apiKey, id, api.RuntimeDiaryPatchPayload{Content: &content}); err != nil {
+		return "", err
 	}
+	return id, nil
+}
+
+const polishSystemPrompt = `You are an editor for a bot's daily diary.
+Keep the original meaning, facts and tone, but improve clarity, grammar and flow.
+Keep the Markdown structure. Respond only with the improved text, nothing else.`
 
-	return strings.TrimSpace(response), nil
+func polishWithAI(p llm.Provider, content string, stream bool) (string, error) {
+	return generateWithLLM(p, llm.Prompt(polishSystemPrompt, content), stream)
 }
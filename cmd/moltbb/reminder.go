@@ -1,162 +1,298 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
+
 	"moltbb-cli/internal/config"
+	"moltbb-cli/internal/notify"
 	"moltbb-cli/internal/output"
+	"moltbb-cli/internal/schedule"
 )
 
+type reminderOptions struct {
+	timeStr  string
+	message  string
+	channel  string
+	timezone string
+	days     string
+}
+
 func newReminderCmd() *cobra.Command {
 	var (
-		timeStr        string
-		message        string
-		channel        string
-		list           bool
-		removeID       string
-		enableOpenClaw bool
+		opts     reminderOptions
+		list     bool
+		removeID string
+		openClaw bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "reminder",
 		Short: "Manage reminders for diary writing",
-		Long: `Manage reminders to write diaries.
-		
+		Long: `Manage reminders to write diaries. Reminders are delivered by the built-in
+scheduler (moltbb local / moltbb daemon) through a channel configured under
+notify.channels in config.yaml; "desktop" works without configuration.
+
+  notify:
+    channels:
+      - name: me
+        type: telegram           # webhook, telegram, discord, slack, email, desktop
+        bot_token_env: MOLTBB_TELEGRAM_TOKEN
+        chat_id: "123456"
+
 Examples:
-  moltbb reminder add --time "20:00" --message "写日记啦" --channel telegram
+  moltbb reminder add --time 20:00 --message "写日记啦" --channel me
+  moltbb reminder add --time 08:30 --days mon-fri --timezone Asia/Shanghai
   moltbb reminder list
-  moltbb reminder remove <id>
-  moltbb reminder add --time "20:00" --opentclaw`,
+  moltbb reminder test <id>
+  moltbb reminder remove <id>`,
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg, err := config.Load()
-			if err != nil {
-				return err
+			// Flag-style invocations predate the subcommands and keep working.
+			switch {
+			case list:
+				return listReminders()
+			case removeID != "":
+				return removeReminder(removeID)
+			case opts.timeStr != "":
+				return addReminder(opts)
 			}
+			return cmd.Help()
+		},
+	}
 
-			// List reminders
-			if list {
-				reminders := cfg.Reminders
-				if len(reminders) == 0 {
-					output.Info("No reminders configured")
-					return nil
-				}
-				output.Info("Configured reminders:")
-				for i, r := range reminders {
-					fmt.Printf("%d. Time: %s | Message: %s | Channel: %s\n",
-						i+1, r.Time, r.Message, r.Channel)
-				}
-				return nil
-			}
+	cmd.Flags().StringVar(&opts.timeStr, "time", "", "Reminder time in HH:MM format (e.g., 20:00)")
+	cmd.Flags().StringVar(&opts.message, "message", "", "Reminder message")
+	cmd.Flags().StringVar(&opts.channel, "channel", "", "Notify channel name or type (default: desktop)")
+	cmd.Flags().BoolVar(&list, "list", false, "List all reminders")
+	cmd.Flags().StringVar(&removeID, "remove", "", "Remove a reminder by ID")
+	cmd.Flags().BoolVar(&openClaw, "opentclaw", false, "No longer needed; reminders are delivered by the built-in scheduler")
+	_ = cmd.Flags().MarkHidden("time")
+	_ = cmd.Flags().MarkHidden("message")
+	_ = cmd.Flags().MarkHidden("channel")
+	_ = cmd.Flags().MarkDeprecated("list", "use 'moltbb reminder list'")
+	_ = cmd.Flags().MarkDeprecated("remove", "use 'moltbb reminder remove <id>'")
+	_ = cmd.Flags().MarkDeprecated("opentclaw", "reminders are delivered by the built-in scheduler")
 
-			// Remove reminder
-			if removeID != "" {
-				reminders := cfg.Reminders
-				var newReminders []config.Reminder
-				removed := false
-				for i, r := range reminders {
-					if fmt.Sprintf("%d", i+1) == removeID {
-						removed = true
-						continue
-					}
-					newReminders = append(newReminders, r)
-				}
-				if !removed {
-					output.Error("Reminder not found: " + removeID)
-					os.Exit(1)
-				}
-				cfg.Reminders = newReminders
-				config.Save(cfg)
-				output.Success("Reminder removed")
-
-				if enableOpenClaw {
-					return syncRemindersToOpenClaw(cfg.Reminders)
-				}
-				return nil
-			}
+	cmd.AddCommand(newReminderAddCmd())
+	cmd.AddCommand(newReminderListCmd())
+	cmd.AddCommand(newReminderRemoveCmd())
+	cmd.AddCommand(newReminderTestCmd())
+	return cmd
+}
 
-			// Add reminder
-			if timeStr == "" {
-				output.Error("Time is required. Use --time flag")
-				os.Exit(1)
+func newReminderAddCmd() *cobra.Command {
+	var opts reminderOptions
+	cmd := &cobra.Command{
+		Use:   "add",
+		Short: "Add a reminder",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return addReminder(opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.timeStr, "time", "", "Reminder time in HH:MM format (e.g., 20:00)")
+	cmd.Flags().StringVar(&opts.message, "message", "", "Reminder message")
+	cmd.Flags().StringVar(&opts.channel, "channel", "", "Notify channel name or type (default: desktop)")
+	cmd.Flags().StringVar(&opts.timezone, "timezone", "", "IANA timezone for --time (default: config timezone)")
+	cmd.Flags().StringVar(&opts.days, "days", "", "Weekdays to fire on: mon-fri, sat,sun, weekdays, weekends (default: every day)")
+	_ = cmd.MarkFlagRequired("time")
+	return cmd
+}
+
+func newReminderListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List reminders with their IDs and next delivery",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listReminders()
+		},
+	}
+}
+
+func newReminderRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "remove <id>",
+		Aliases: []string{"rm", "delete"},
+		Short:   "Remove a reminder",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return removeReminder(args[0])
+		},
+	}
+}
+
+func newReminderTestCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "test <id>",
+		Short: "Deliver a reminder now to check its channel",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadLocalConfig()
+			if err != nil {
+				return err
 			}
-			if message == "" {
-				message = "该写日记了！"
+			id := strings.TrimSpace(args[0])
+			if cfg.FindReminder(id) < 0 {
+				return fmt.Errorf("reminder not found: %s", id)
 			}
-			if channel == "" {
-				channel = "telegram"
+			db, err := openScheduleDB(true)
+			if err != nil {
+				return err
 			}
+			defer db.Close()
 
-			// Validate time format (HH:MM)
-			parts := strings.Split(timeStr, ":")
-			if len(parts) != 2 {
-				output.Error("Invalid time format. Use HH:MM (e.g., 20:00)")
-				os.Exit(1)
+			// Go through the scheduler so the delivery shows up in
+			// 'moltbb schedule history' like a scheduled one.
+			sched, err := newScheduler(cfg, db, nil)
+			if err != nil {
+				return err
 			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
 
-			reminder := config.Reminder{
-				Time:    timeStr,
-				Message: message,
-				Channel: channel,
+			run, err := sched.RunNow(ctx, "reminder-"+id)
+			if err != nil {
+				return err
 			}
-
-			cfg.Reminders = append(cfg.Reminders, reminder)
-			config.Save(cfg)
-			output.Success(fmt.Sprintf("Reminder added: %s - %s (%s)",
-				timeStr, message, channel))
-
-			if enableOpenClaw {
-				return syncRemindersToOpenClaw(cfg.Reminders)
+			if run.Status != schedule.StatusOK {
+				return fmt.Errorf("reminder %s failed: %s", id, run.Error)
 			}
-
-			output.Info("Use --opentclaw to sync reminders to OpenClaw")
+			output.PrintSuccess(run.Output)
 			return nil
 		},
 	}
-
-	cmd.Flags().StringVar(&timeStr, "time", "", "Reminder time in HH:MM format (e.g., 20:00)")
-	cmd.Flags().StringVar(&message, "message", "", "Reminder message")
-	cmd.Flags().StringVar(&channel, "channel", "telegram", "Notification channel (telegram, discord, etc.)")
-	cmd.Flags().BoolVar(&list, "list", false, "List all reminders")
-	cmd.Flags().StringVar(&removeID, "remove", "", "Remove a reminder by ID")
-	cmd.Flags().BoolVar(&enableOpenClaw, "opentclaw", false, "Sync reminders to OpenClaw cron")
-
-	return cmd
 }
 
-func syncRemindersToOpenClaw(reminders []config.Reminder) error {
-	output.Info("Syncing reminders to OpenClaw...")
-
-	// For each reminder, create an OpenClaw cron job
-	for i, r := range reminders {
-		// Convert HH:MM to cron format (UTC time)
-		parts := strings.Split(r.Time, ":")
-		if len(parts) != 2 {
-			output.PrintWarning(fmt.Sprintf("Invalid time format: %s", r.Time))
-			continue
-		}
-
-		// Create cron expression: minute hour * * *
-		// Note: This uses the time as-is, assuming it's in the user's timezone
-		// The user would need to handle timezone conversion
-		cronExpr := fmt.Sprintf("%s %s * * *", parts[1], parts[0])
+func addReminder(opts reminderOptions) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	message := strings.TrimSpace(opts.message)
+	if message == "" {
+		message = "该写日记了！"
+	}
+	channel := strings.TrimSpace(opts.channel)
+	if channel == "" {
+		channel = "desktop"
+	}
+	reminder := config.Reminder{
+		Time:     strings.TrimSpace(opts.timeStr),
+		Message:  message,
+		Channel:  channel,
+		Timezone: strings.TrimSpace(opts.timezone),
+		Days:     strings.ToLower(strings.TrimSpace(opts.days)),
+	}
+	// Catch bad times, weekdays and timezones before anything is written.
+	if _, err := reminderJob(cfg, reminder); err != nil {
+		return err
+	}
+	if _, err := notify.Resolve(cfg.Notify.Channels, reminder.Channel); err != nil {
+		output.PrintWarning(fmt.Sprintf("Channel %q cannot deliver yet: %v", reminder.Channel, err))
+	}
 
-		// Generate job name and payload
-		jobName := fmt.Sprintf("moltbb-reminder-%d", i+1)
-		message := fmt.Sprintf("【日记提醒】%s", r.Message)
+	cfg.Reminders = append(cfg.Reminders, reminder)
+	if err := cfg.Normalize(); err != nil {
+		return err
+	}
+	cfg.AssignReminderIDs()
+	if err := config.Save(cfg); err != nil {
+		return err
+	}
+	added := cfg.Reminders[len(cfg.Reminders)-1]
+	output.PrintSuccess(fmt.Sprintf("Reminder %s added: %s - %s (%s)", added.ID, describeReminderSchedule(added), added.Message, added.Channel))
+	output.PrintInfo(fmt.Sprintf("Check delivery with: moltbb reminder test %s", added.ID))
+	if !schedulerRunning() {
+		output.PrintWarning("The scheduler is not running; start it with 'moltbb daemon start' or 'moltbb local'")
+	}
+	return nil
+}
 
-		output.Info(fmt.Sprintf("Creating cron job: %s at %s", jobName, cronExpr))
-		fmt.Printf("  Would create: %s - %s\n", jobName, message)
+// reminderResult is one entry of the structured result of 'moltbb
+// reminder list'. Error is set when the reminder cannot be scheduled.
+type reminderResult struct {
+	ID       string     `json:"id"`
+	Time     string     `json:"time"`
+	Days     string     `json:"days,omitempty"`
+	Timezone string     `json:"timezone,omitempty"`
+	Channel  string     `json:"channel"`
+	Message  string     `json:"message"`
+	Next     *time.Time `json:"next,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
 
-		// Note: Actual OpenClaw cron API call would go here
-		// For now, we provide instructions
+func listReminders() error {
+	cfg, err := loadLocalConfig()
+	if err != nil {
+		return err
+	}
+	res := []reminderResult{}
+	if len(cfg.Reminders) == 0 {
+		output.PrintInfo("No reminders configured")
+		return output.Result("moltbb.reminder.list.v1", res)
+	}
+	loc, err := cfg.Location()
+	if err != nil {
+		return err
 	}
+	now := time.Now()
 
-	output.Success(fmt.Sprintf("Synced %d reminders to OpenClaw", len(reminders)))
-	output.Info("To create actual cron jobs, run:")
-	fmt.Println("  openclaw cron add --help")
+	tw := tabwriter.NewWriter(output.Writer(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tWHEN\tCHANNEL\tNEXT\tMESSAGE")
+	for _, r := range cfg.Reminders {
+		item := reminderResult{ID: r.ID, Time: r.Time, Days: r.Days, Timezone: r.Timezone, Channel: r.Channel, Message: r.Message}
+		next := "-"
+		if job, err := reminderJob(cfg, r); err != nil {
+			next = "invalid: " + err.Error()
+			item.Error = err.Error()
+		} else if t := job.Spec.Next(now.In(loc)); !t.IsZero() {
+			next = t.Format("2006-01-02 15:04")
+			item.Next = &t
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.ID, describeReminderSchedule(r), r.Channel, next, truncateRunes(r.Message, 40))
+		res = append(res, item)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	return output.Result("moltbb.reminder.list.v1", res)
+}
 
+func removeReminder(id string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	i := cfg.FindReminder(id)
+	if i < 0 {
+		return fmt.Errorf("reminder not found: %s (see 'moltbb reminder list')", strings.TrimSpace(id))
+	}
+	removed := cfg.Reminders[i]
+	cfg.Reminders = append(cfg.Reminders[:i], cfg.Reminders[i+1:]...)
+	if err := config.Save(cfg); err != nil {
+		return err
+	}
+	output.PrintSuccess(fmt.Sprintf("Reminder %s removed", removed.ID))
 	return nil
 }
+
+func describeReminderSchedule(r config.Reminder) string {
+	parts := []string{r.Time}
+	if r.Days != "" {
+		parts = append(parts, r.Days)
+	}
+	if r.Timezone != "" {
+		parts = append(parts, r.Timezone)
+	}
+	return strings.Join(parts, " ")
+}
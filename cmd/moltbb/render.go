@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"moltbb-cli/internal/auth"
+	"moltbb-cli/internal/output"
+)
+
+// outputReady is set once the --output format has been applied.
+var outputReady bool
+
+// addOutputFlag adds the global --output flag.
+func addOutputFlag(root *cobra.Command) {
+	var format string
+	root.PersistentFlags().StringVar(&format, "output", "", "Output format: text, json, yaml or ndjson (default: $MOLTBB_OUTPUT or text)")
+	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		value := os.Getenv("MOLTBB_OUTPUT")
+		if flag := root.PersistentFlags().Lookup("output"); flag.Changed {
+			value = format
+			if outPathAlias(cmd, format) {
+				value = os.Getenv("MOLTBB_OUTPUT")
+			}
+		}
+		f, err := output.ParseFormat(value)
+		if err != nil {
+			return output.Usage(err)
+		}
+		output.SetFormat(f)
+		output.SetCommand(strings.TrimPrefix(cmd.CommandPath(), root.Name()+" "))
+		outputReady = true
+		return nil
+	}
+	root.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return output.Usage(err)
+	})
+	markUsageErrors(root)
+}
+
+// outPathAlias handles the deprecated use of --output as a file path on
+// the commands that now take --out (backup create, export, pipeline
+// transcript): a value that is not a format is moved to --out.
+func outPathAlias(cmd *cobra.Command, value string) bool {
+	out := cmd.Flags().Lookup("out")
+	if out == nil || out.Changed {
+		return false
+	}
+	if _, err := output.ParseFormat(value); err == nil {
+		return false
+	}
+	if err := cmd.Flags().Set("out", value); err != nil {
+		return false
+	}
+	fmt.Fprintln(cmd.ErrOrStderr(), "Flag --output as a file path has been deprecated, use --out")
+	return true
+}
+
+// markUsageErrors makes argument validation errors usage errors.
+func markUsageErrors(cmd *cobra.Command) {
+	if validate := cmd.Args; validate != nil {
+		cmd.Args = func(cmd *cobra.Command, args []string) error {
+			return output.Usage(validate(cmd, args))
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		markUsageErrors(sub)
+	}
+}
+
+// exitWithError reports err in the selected format and exits.
+func exitWithError(err error) {
+	if !outputReady {
+		// The command line did not parse; honor --output if it is there.
+		if f, ferr := output.ParseFormat(formatFromArgs(os.Args[1:])); ferr == nil {
+			output.SetFormat(f)
+		}
+	}
+	os.Exit(output.Fail(classifyError(err)))
+}
+
+// classifyError marks the errors that cobra and the auth package report
+// without a type.
+func classifyError(err error) error {
+	var coded *output.CodedError
+	if errors.As(err, &coded) {
+		return err
+	}
+	if errors.Is(err, auth.ErrNoCredentials) || errors.Is(err, auth.ErrNoAPIKey) {
+		return &output.CodedError{Code: output.CodeAuth, ExitCode: output.ExitAuth, Err: err}
+	}
+	msg := err.Error()
+	for _, prefix := range []string{"unknown command", "unknown flag", "unknown shorthand flag", "required flag", "invalid argument"} {
+		if strings.HasPrefix(msg, prefix) {
+			return output.Usage(err)
+		}
+	}
+	return err
+}
+
+// formatFromArgs finds the --output value on a command line that failed to
+// parse, falling back to MOLTBB_OUTPUT.
+func formatFromArgs(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if value, ok := strings.CutPrefix(arg, "--output="); ok {
+			return value
+		}
+		if arg == "--output" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return os.Getenv("MOLTBB_OUTPUT")
+}
+
+// printJSONLine prints v as one line of JSON, the shape of the older
+// per-command --json flags.
+func printJSONLine(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	output.Println(string(data))
+	return nil
+}
@@ -0,0 +1,507 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"moltbb-cli/internal/config"
+	"moltbb-cli/internal/daemon"
+	"moltbb-cli/internal/localweb"
+	"moltbb-cli/internal/notify"
+	"moltbb-cli/internal/output"
+	"moltbb-cli/internal/schedule"
+	"moltbb-cli/internal/utils"
+)
+
+func newScheduleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Inspect and trigger jobs of the built-in scheduler",
+		Long: `The local studio (moltbb local / moltbb daemon) runs the jobs listed under
+schedule.jobs in config.yaml, plus one job per reminder. Example:
+
+  schedule:
+    jobs:
+      - name: diary
+        kind: run                # moltbb run (auto-uploads by default)
+        cron: "0 21 * * *"
+      - name: heartbeat
+        kind: heartbeat          # moltbb tower heartbeat
+        cron: "@every 5m"
+      - name: inbox
+        kind: inbox              # moltbb message unread
+        cron: "*/15 * * * *"
+      - name: weekly-export
+        kind: command            # any moltbb subcommand
+        cron: "0 9 * * 1"
+        args: [export, --format, markdown]
+
+Times use the configured timezone. Every run is recorded in the local
+database; see 'moltbb schedule history'.`,
+	}
+
+	cmd.AddCommand(newScheduleListCmd())
+	cmd.AddCommand(newScheduleRunNowCmd())
+	cmd.AddCommand(newScheduleHistoryCmd())
+	return cmd
+}
+
+// scheduleListResult is the structured result of 'moltbb schedule list'.
+type scheduleListResult struct {
+	Jobs             []scheduledJobResult `json:"jobs"`
+	SchedulerRunning bool                 `json:"schedulerRunning"`
+}
+
+type scheduledJobResult struct {
+	Name     string           `json:"name"`
+	Kind     string           `json:"kind"`
+	Schedule string           `json:"schedule"`
+	Disabled bool             `json:"disabled,omitempty"`
+	Next     *time.Time       `json:"next,omitempty"`
+	LastRun  *localweb.JobRun `json:"lastRun,omitempty"`
+}
+
+func newScheduleListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List scheduled jobs with their next and last run",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadLocalConfig()
+			if err != nil {
+				return err
+			}
+			jobs, disabled, err := scheduledJobs(cfg)
+			if err != nil {
+				return err
+			}
+			res := scheduleListResult{Jobs: []scheduledJobResult{}, SchedulerRunning: schedulerRunning()}
+			if len(jobs) == 0 {
+				output.PrintInfo("No scheduled jobs. Add schedule.jobs to config.yaml (see 'moltbb schedule --help').")
+				return output.Result("moltbb.schedule.list.v1", res)
+			}
+			loc, err := cfg.Location()
+			if err != nil {
+				return err
+			}
+
+			latest := map[string]localweb.JobRun{}
+			if db, err := openScheduleDB(false); err == nil && db != nil {
+				if runs, err := localweb.LatestJobRuns(db); err == nil {
+					latest = runs
+				}
+				db.Close()
+			}
+
+			now := time.Now().In(loc)
+			tw := tabwriter.NewWriter(output.Writer(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "JOB\tKIND\tSCHEDULE\tNEXT\tLAST")
+			for _, job := range jobs {
+				item := scheduledJobResult{Name: job.Name, Kind: job.Kind, Schedule: job.Expr, Disabled: disabled[job.Name]}
+				next := "-"
+				if item.Disabled {
+					next = "disabled"
+				} else if t := job.Spec.Next(now); !t.IsZero() {
+					next = t.Format("2006-01-02 15:04")
+					item.Next = &t
+				}
+				last := "never"
+				if run, ok := latest[job.Name]; ok {
+					last = fmt.Sprintf("%s %s", run.StartedAt.In(loc).Format("2006-01-02 15:04"), run.Status)
+					item.LastRun = &run
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", job.Name, job.Kind, job.Expr, next, last)
+				res.Jobs = append(res.Jobs, item)
+			}
+			tw.Flush()
+
+			output.Println()
+			if res.SchedulerRunning {
+				output.Println("Scheduler: running")
+			} else {
+				output.Println("Scheduler: not running (start it with 'moltbb daemon start' or 'moltbb local')")
+			}
+			return output.Result("moltbb.schedule.list.v1", res)
+		},
+	}
+}
+
+func newScheduleRunNowCmd() *cobra.Command {
+	var ifIdle bool
+
+	cmd := &cobra.Command{
+		Use:   "run-now <job>",
+		Short: "Run a job immediately and record it in the history",
+		Long: `Run a job immediately and record it in the history.
+
+With --if-idle the job is skipped while a moltbb process runs the scheduler,
+which will run it on time itself. The cron and launchd fallback entries
+installed by 'moltbb onboard --install-schedule' use it.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if ifIdle && schedulerRunning() {
+				output.Printf("Scheduler is running; job %s runs there\n", strings.TrimSpace(args[0]))
+				return nil
+			}
+			cfg, err := loadLocalConfig()
+			if err != nil {
+				return err
+			}
+			db, err := openScheduleDB(true)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			sched, err := newScheduler(cfg, db, nil)
+			if err != nil {
+				return err
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			run, err := sched.RunNow(ctx, strings.TrimSpace(args[0]))
+			if err != nil {
+				return err
+			}
+			if run.Output != "" {
+				output.Println(run.Output)
+			}
+			took := run.FinishedAt.Sub(run.StartedAt).Round(time.Millisecond)
+			if run.Status != schedule.StatusOK {
+				return fmt.Errorf("job %s failed after %s: %s", run.Job, took, run.Error)
+			}
+			output.PrintSuccess(fmt.Sprintf("Job %s finished in %s", run.Job, took))
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&ifIdle, "if-idle", false, "Skip the job while the scheduler is running in another moltbb process")
+	return cmd
+}
+
+func newScheduleHistoryCmd() *cobra.Command {
+	var limit int
+	var jsonOutput bool
+	var verbose bool
+
+	cmd := &cobra.Command{
+		Use:   "history [job]",
+		Short: "Show recent job runs",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			job := ""
+			if len(args) == 1 {
+				job = strings.TrimSpace(args[0])
+			}
+			db, err := openScheduleDB(false)
+			if err != nil {
+				return err
+			}
+			var runs []localweb.JobRun
+			if db != nil {
+				defer db.Close()
+				if runs, err = localweb.ListJobRuns(db, job, limit); err != nil {
+					return err
+				}
+			}
+
+			if jsonOutput || output.Structured() {
+				if runs == nil {
+					runs = []localweb.JobRun{}
+				}
+				if output.Structured() {
+					return output.Result("moltbb.schedule.history.v1", runs)
+				}
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(runs)
+			}
+			if len(runs) == 0 {
+				output.PrintInfo("No job runs recorded yet")
+				return nil
+			}
+
+			tw := tabwriter.NewWriter(output.Writer(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "STARTED\tJOB\tTRIGGER\tSTATUS\tDURATION\tDETAIL")
+			for _, run := range runs {
+				detail := run.Error
+				if detail == "" {
+					detail = lastLine(run.Output)
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+					run.StartedAt.Local().Format("2006-01-02 15:04:05"), run.Job, run.Trigger, run.Status,
+					run.FinishedAt.Sub(run.StartedAt).Round(time.Millisecond), truncateRunes(detail, 60))
+			}
+			tw.Flush()
+
+			if verbose {
+				for _, run := range runs {
+					if run.Output == "" {
+						continue
+					}
+					output.Printf("\n--- %s #%d (%s) ---\n%s\n", run.Job, run.ID, run.StartedAt.Local().Format(time.RFC3339), run.Output)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&limit, "limit", 20, "Number of runs to show")
+	cmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output as JSON")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Also print each run's captured output")
+	return cmd
+}
+
+// scheduledJobs builds the scheduler jobs from config: schedule.jobs plus
+// one job per valid reminder. Disabled jobs are returned too and flagged.
+func scheduledJobs(cfg config.Config) ([]schedule.Job, map[string]bool, error) {
+	exePath, err := os.Executable()
+	if err != nil {
+		return nil, nil, fmt.Errorf("resolve executable: %w", err)
+	}
+
+	jobs := make([]schedule.Job, 0, len(cfg.Schedule.Jobs)+len(cfg.Reminders))
+	disabled := make(map[string]bool)
+	for _, def := range cfg.Schedule.Jobs {
+		spec, err := schedule.Parse(def.Cron)
+		if err != nil {
+			return nil, nil, fmt.Errorf("schedule job %s: %w", def.Name, err)
+		}
+		var argv []string
+		switch def.Kind {
+		case "run":
+			argv = []string{"run"}
+		case "heartbeat":
+			argv = []string{"tower", "heartbeat"}
+		case "inbox":
+			argv = []string{"message", "unread"}
+		}
+		argv = append(argv, def.Args...)
+		jobs = append(jobs, schedule.Job{
+			Name:    def.Name,
+			Kind:    def.Kind,
+			Expr:    def.Cron,
+			Spec:    spec,
+			Timeout: time.Duration(def.TimeoutSeconds) * time.Second,
+			Run:     schedule.Command(exePath, argv...),
+		})
+		if def.Disabled {
+			disabled[def.Name] = true
+		}
+	}
+
+	for _, reminder := range cfg.Reminders {
+		job, err := reminderJob(cfg, reminder)
+		if err != nil {
+			// One bad hand-edited reminder must not stop the other jobs.
+			fmt.Fprintf(os.Stderr, "warning: reminder %s skipped: %v\n", reminder.ID, err)
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	if cfg.Schedule.Disabled {
+		for _, job := range jobs {
+			disabled[job.Name] = true
+		}
+	}
+	return jobs, disabled, nil
+}
+
+// reminderJob builds the job that delivers one reminder through its notify
+// channel. The reminder's own timezone, when set, overrides the config one.
+func reminderJob(cfg config.Config, reminder config.Reminder) (schedule.Job, error) {
+	expr, err := reminderCron(reminder.Time, reminder.Days)
+	if err != nil {
+		return schedule.Job{}, err
+	}
+	spec, err := schedule.Parse(expr)
+	if err != nil {
+		return schedule.Job{}, err
+	}
+	display := expr
+	if reminder.Timezone != "" {
+		loc, err := time.LoadLocation(reminder.Timezone)
+		if err != nil {
+			return schedule.Job{}, fmt.Errorf("invalid timezone %q: %w", reminder.Timezone, err)
+		}
+		spec = schedule.InLocation(spec, loc)
+		display = expr + " " + reminder.Timezone
+	}
+	channels := cfg.Notify.Channels
+	return schedule.Job{
+		Name:    "reminder-" + reminder.ID,
+		Kind:    "reminder",
+		Expr:    display,
+		Spec:    spec,
+		Timeout: 2 * notify.DefaultTimeout,
+		Run: func(ctx context.Context) (string, error) {
+			notifier, err := notify.Resolve(channels, reminder.Channel)
+			if err != nil {
+				return "", err
+			}
+			if err := notifier.Send(ctx, notify.Message{Title: "MoltBB reminder", Body: reminder.Message}); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Sent via %s: %s", notifier.Name(), reminder.Message), nil
+		},
+	}, nil
+}
+
+// reminderCron turns a reminder's HH:MM and weekday mask into a cron
+// expression.
+func reminderCron(hhmm, days string) (string, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(hhmm))
+	if err != nil {
+		return "", fmt.Errorf("invalid time %q (want HH:MM)", hhmm)
+	}
+	dow, err := schedule.WeekdayField(days)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d %d * * %s", t.Minute(), t.Hour(), dow), nil
+}
+
+// newScheduler builds a scheduler over the enabled jobs that records runs
+// in db.
+func newScheduler(cfg config.Config, db *sql.DB, log io.Writer) (*schedule.Scheduler, error) {
+	jobs, disabled, err := scheduledJobs(cfg)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := cfg.Location()
+	if err != nil {
+		return nil, err
+	}
+	enabled := jobs[:0]
+	for _, job := range jobs {
+		if !disabled[job.Name] {
+			enabled = append(enabled, job)
+		}
+	}
+	return &schedule.Scheduler{
+		Jobs:     enabled,
+		History:  jobHistory{db: db},
+		Location: loc,
+		Log:      log,
+	}, nil
+}
+
+// startScheduler runs the scheduler in the background until ctx ends. Only
+// the process holding the scheduler lock schedules jobs, so two studios never
+// run a job twice; others skip with a notice. The database at dbPath is
+// opened once the lock is held.
+func startScheduler(ctx context.Context, cfg config.Config, dbPath string) error {
+	lockPath, err := schedulerLockPath()
+	if err != nil {
+		return err
+	}
+	lock, err := daemon.AcquireLock(lockPath)
+	if errors.Is(err, daemon.ErrRunning) {
+		output.Println("Scheduler: already running in another moltbb process, not starting here")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	db, err := localweb.OpenDB(dbPath)
+	if err != nil {
+		lock.Release()
+		return err
+	}
+	sched, err := newScheduler(cfg, db, output.Writer())
+	if err != nil {
+		db.Close()
+		lock.Release()
+		return err
+	}
+	output.Printf("Scheduler: %d job(s)\n", len(sched.Jobs))
+	for _, up := range sched.Upcoming(time.Now()) {
+		output.Printf("  %-16s next %s\n", up.Job.Name, up.Next.Format("2006-01-02 15:04"))
+	}
+	go func() {
+		defer lock.Release()
+		defer db.Close()
+		sched.Start(ctx)
+	}()
+	return nil
+}
+
+func schedulerRunning() bool {
+	lockPath, err := schedulerLockPath()
+	if err != nil {
+		return false
+	}
+	return daemon.Probe(lockPath)
+}
+
+func schedulerLockPath() (string, error) {
+	moltbbDir, err := utils.MoltbbDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(moltbbDir, 0o700); err != nil {
+		return "", err
+	}
+	return filepath.Join(moltbbDir, "scheduler.lock"), nil
+}
+
+// openScheduleDB opens the default local database. Without create, a
+// missing database yields (nil, nil).
+func openScheduleDB(create bool) (*sql.DB, error) {
+	dbPath, err := resolveLocalDBPath()
+	if err != nil {
+		return nil, err
+	}
+	if !create && !utils.FileExists(dbPath) {
+		return nil, nil
+	}
+	return localweb.OpenDB(dbPath)
+}
+
+type jobHistory struct {
+	db *sql.DB
+}
+
+func (h jobHistory) Record(run schedule.Run) error {
+	if h.db == nil {
+		return nil
+	}
+	return localweb.SaveJobRun(h.db, localweb.JobRun{
+		Job:        run.Job,
+		Trigger:    run.Trigger,
+		Status:     run.Status,
+		StartedAt:  run.StartedAt,
+		FinishedAt: run.FinishedAt,
+		Output:     run.Output,
+		Error:      run.Error,
+	})
+}
+
+func lastLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		return strings.TrimSpace(s[i+1:])
+	}
+	return s
+}
+
+func truncateRunes(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit]) + "…"
+}
@@ -2,31 +2,41 @@ package main
 
 import (
 	"fmt"
-	"io/ioutil"
-	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/spf13/cobra"
-	"moltbb-cli/internal/config"
+	"moltbb-cli/internal/localweb"
 	"moltbb-cli/internal/output"
+	"moltbb-cli/internal/utils"
 )
 
+var searchHighlightRe = regexp.MustCompile("\x02(.*?)\x03")
+
 func newSearchCmd() *cobra.Command {
 	var (
 		query     string
 		dateRange string
-		tag       string
+		from      string
+		to        string
+		tags      []string
 		limit     int
+		offset    int
 	)
 
 	cmd := &cobra.Command{
 		Use:   "search [query]",
 		Short: "Search diary entries",
-		Long: `Search through your diary entries.
-		
+		Long: `Search your local diaries with the studio's full-text index.
+
+Results are ranked by relevance. Words must all match; use "quoted phrases",
+prefix* terms and OR / NOT for more control.
+
 Examples:
-  moltbb search \"今天天气\"
+  moltbb search redis cache
+  moltbb search '"cache miss"' --limit 5
+  moltbb search deploy* --from 2026-03-01 --to 2026-03-31
   moltbb search --tag work
   moltbb search --date 2026-03`,
 		Args: cobra.MaximumNArgs(1),
@@ -34,128 +44,97 @@ Examples:
 			if len(args) > 0 {
 				query = args[0]
 			}
+			tags = normalizeStringList(tags)
 
-			if query == "" && tag == "" && dateRange == "" {
-				output.PrintError("Please provide a search query, --tag, or --date")
-				os.Exit(1)
+			if strings.TrimSpace(query) == "" && len(tags) == 0 && dateRange == "" && from == "" && to == "" {
+				return fmt.Errorf("please provide a search query, --tag, --date, --from or --to")
 			}
 
-			cfg, err := config.Load()
+			cfg, err := loadLocalConfig()
 			if err != nil {
 				return err
 			}
-
-			// Find diaries directory
-			diariesDir := cfg.DiariesDir
-			if diariesDir == "" {
-				homeDir, _ := os.UserHomeDir()
-				diariesDir = filepath.Join(homeDir, "moltbb", "diaries")
+			moltbbDir, err := utils.MoltbbDir()
+			if err != nil {
+				return err
 			}
 
-			// Walk through diary files
-			var results []string
-			err = filepath.Walk(diariesDir, func(path string, info os.FileInfo, err error) error {
-				if err != nil {
-					return nil
-				}
-				if info.IsDir() {
-					return nil
-				}
-
-				// Check file extension
-				ext := strings.ToLower(filepath.Ext(path))
-				if ext != ".md" && ext != ".txt" {
-					return nil
-				}
-
-				// Check date filter
-				if dateRange != "" {
-					filename := filepath.Base(path)
-					if !strings.Contains(filename, dateRange) {
-						return nil
-					}
-				}
-
-				// Read file content
-				content, err := ioutil.ReadFile(path)
-				if err != nil {
-					return nil
-				}
-
-				text := string(content)
-
-				// Check tag
-				if tag != "" {
-					tagPattern := fmt.Sprintf("#%s", tag)
-					if !strings.Contains(text, tagPattern) {
-						return nil
-					}
-				}
-
-				// Check query
-				if query != "" {
-					lowerContent := strings.ToLower(text)
-					lowerQuery := strings.ToLower(query)
-					if !strings.Contains(lowerContent, lowerQuery) {
-						return nil
-					}
-				}
-
-				// Found matching file
-				relPath, _ := filepath.Rel(diariesDir, path)
-				results = append(results, fmt.Sprintf("📄 %s", relPath))
-
-				// Show context around the match
-				if query != "" {
-					lines := strings.Split(text, "\n")
-					for i, line := range lines {
-						if strings.Contains(strings.ToLower(line), strings.ToLower(query)) {
-							// Show up to 2 lines of context
-							start := i - 1
-							if start < 0 {
-								start = 0
-							}
-							end := i + 2
-							if end > len(lines) {
-								end = len(lines)
-							}
-							for j := start; j < end; j++ {
-								prefix := "  "
-								if j == i {
-									prefix = "👉 "
-								}
-								fmt.Printf("%s%s\n", prefix, strings.TrimSpace(lines[j]))
-							}
-							fmt.Println()
-						}
-					}
-				}
-
-				return nil
+			// Opening the studio store syncs the index with the diary directory.
+			store, err := localweb.New(localweb.Options{
+				DiaryDir:   cfg.OutputDir,
+				DataDir:    filepath.Join(moltbbDir, "local-web"),
+				APIBaseURL: cfg.APIBaseURL,
+				InputPaths: cfg.InputPaths,
+				Version:    version,
+			})
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			hits, total, err := store.SearchDiaries(localweb.DiarySearchOptions{
+				Query:          query,
+				Tags:           tags,
+				DatePrefix:     strings.TrimSpace(dateRange),
+				From:           strings.TrimSpace(from),
+				To:             strings.TrimSpace(to),
+				Limit:          limit,
+				Offset:         offset,
+				HighlightStart: "\x02",
+				HighlightEnd:   "\x03",
 			})
-
 			if err != nil {
 				return err
 			}
 
-			if len(results) == 0 {
+			if output.Structured() {
+				for i := range hits {
+					hits[i].Snippet = strings.NewReplacer("\x02", "", "\x03", "").Replace(hits[i].Snippet)
+				}
+				if hits == nil {
+					hits = []localweb.DiarySearchHit{}
+				}
+				return output.Result("moltbb.search.v1", struct {
+					Total  int                       `json:"total"`
+					Offset int                       `json:"offset"`
+					Hits   []localweb.DiarySearchHit `json:"hits"`
+				}{total, offset, hits})
+			}
+
+			if len(hits) == 0 {
 				output.PrintInfo("No matching diaries found")
 				return nil
 			}
 
-			output.PrintSuccess(fmt.Sprintf("Found %d matching entries:", len(results)))
-			for _, r := range results {
-				fmt.Println(r)
+			output.PrintSuccess(fmt.Sprintf("Showing %d of %d matching entries:", len(hits), total))
+			output.Println()
+			for _, hit := range hits {
+				date := hit.Date
+				if date == "" {
+					date = "----------"
+				}
+				output.Printf("📄 %s  %s  %s\n", date, output.Bold(hit.Title), hit.RelPath)
+				if snippet := strings.Join(strings.Fields(hit.Snippet), " "); snippet != "" {
+					output.Println("   " + searchHighlightRe.ReplaceAllStringFunc(snippet, func(m string) string {
+						return output.Warning(strings.Trim(m, "\x02\x03"))
+					}))
+				}
+				output.Println()
+			}
+			if offset+len(hits) < total {
+				output.Printf("Use --offset %d to see more.\n", offset+len(hits))
 			}
-
 			return nil
 		},
 	}
 
 	cmd.Flags().StringVar(&query, "query", "", "Search query")
-	cmd.Flags().StringVar(&dateRange, "date", "", "Filter by date (e.g., 2026-03)")
-	cmd.Flags().StringVar(&tag, "tag", "", "Filter by tag")
+	cmd.Flags().StringVar(&dateRange, "date", "", "Filter by date prefix (e.g., 2026-03)")
+	cmd.Flags().StringVar(&from, "from", "", "Only diaries on or after this date (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&to, "to", "", "Only diaries on or before this date (YYYY-MM-DD)")
+	cmd.Flags().StringSliceVar(&tags, "tag", nil, "Filter by tag (#tag or Tags: line), repeatable")
 	cmd.Flags().IntVar(&limit, "limit", 10, "Maximum results")
+	cmd.Flags().IntVar(&offset, "offset", 0, "Skip this many results")
 
 	return cmd
 }
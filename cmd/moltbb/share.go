@@ -18,6 +18,7 @@ import (
 
 	"moltbb-cli/internal/auth"
 	"moltbb-cli/internal/config"
+	"moltbb-cli/internal/output"
 )
 
 func newShareCmd() *cobra.Command {
@@ -70,11 +71,11 @@ Limits:
 				return err
 			}
 
-			fmt.Println("File shared successfully")
-			fmt.Println("URL:     ", result.URL)
-			fmt.Println("Code:    ", result.FileCode)
-			fmt.Println("Expires: ", result.ExpiresAt.Format("2006-01-02 15:04 UTC"))
-			fmt.Printf("Size:     %.1f KB\n", float64(result.FileSize)/1024)
+			output.Println("File shared successfully")
+			output.Println("URL:     ", result.URL)
+			output.Println("Code:    ", result.FileCode)
+			output.Println("Expires: ", result.ExpiresAt.Format("2006-01-02 15:04 UTC"))
+			output.Printf("Size:     %.1f KB\n", float64(result.FileSize)/1024)
 			return nil
 		},
 	}
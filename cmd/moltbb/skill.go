@@ -15,6 +15,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"moltbb-cli/internal/output"
 	"moltbb-cli/internal/utils"
 )
 
@@ -116,12 +117,12 @@ func runSkillInstall(skillName, skillDir, repo, ref string, force bool) error {
 		return err
 	}
 
-	fmt.Printf("Downloading skill source: %s\n", archiveURL)
+	output.Printf("Downloading skill source: %s\n", archiveURL)
 	if err := extractSkillFromArchive(archivePath, skillName, targetDir); err != nil {
 		return err
 	}
 
-	fmt.Printf("Installed skill: %s\n", targetDir)
+	output.Printf("Installed skill: %s\n", targetDir)
 	return nil
 }
 
@@ -10,9 +10,27 @@ import (
 
 	"github.com/spf13/cobra"
 	"moltbb-cli/internal/config"
+	"moltbb-cli/internal/moderate"
 	"moltbb-cli/internal/output"
 )
 
+// diaryStats is the structured result of 'moltbb stats'.
+type diaryStats struct {
+	Year           int            `json:"year"`
+	Month          string         `json:"month,omitempty"`
+	TotalEntries   int            `json:"totalEntries"`
+	TotalWords     int            `json:"totalWords"`
+	TotalChars     int            `json:"totalChars"`
+	CurrentStreak  int            `json:"currentStreakDays"`
+	EntriesByMonth map[string]int `json:"entriesByMonth"`
+	TopTags        []tagCount     `json:"topTags"`
+}
+
+type tagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
 func newStatsCmd() *cobra.Command {
 	var (
 		year  int
@@ -42,7 +60,15 @@ Examples:
 				diariesDir = filepath.Join(homeDir, "moltbb", "diaries")
 			}
 
+			now := time.Now()
+			if year == 0 {
+				year = now.Year()
+			}
+
 			if _, err := os.Stat(diariesDir); os.IsNotExist(err) {
+				if output.Structured() {
+					return output.Result("moltbb.stats.v1", diaryStats{Year: year, Month: month, EntriesByMonth: map[string]int{}, TopTags: []tagCount{}})
+				}
 				output.PrintInfo("No diaries found. Diary files are stored in the cloud.")
 				output.PrintInfo("Use 'moltbb local' to sync locally.")
 				return nil
@@ -56,16 +82,14 @@ Examples:
 			var entriesByTag map[string]int = make(map[string]int)
 			var dates []string
 
-			now := time.Now()
-			if year == 0 {
-				year = now.Year()
-			}
-
 			err = filepath.Walk(diariesDir, func(path string, info os.FileInfo, err error) error {
 				if err != nil {
 					return nil
 				}
 				if info.IsDir() {
+					if path == filepath.Join(diariesDir, moderate.SummaryDir) {
+						return filepath.SkipDir
+					}
 					return nil
 				}
 
@@ -134,40 +158,58 @@ Examples:
 			// Calculate streak
 			streak := calculateStreak(dates)
 
+			// Top tags by count
+			topTags := []tagCount{}
+			for i := 0; i < len(entriesByTag) && i < 5; i++ {
+				maxTag := ""
+				maxCount := 0
+				for tag, count := range entriesByTag {
+					if count > maxCount {
+						maxCount = count
+						maxTag = tag
+					}
+				}
+				if maxTag != "" {
+					topTags = append(topTags, tagCount{Tag: maxTag, Count: maxCount})
+					delete(entriesByTag, maxTag)
+				}
+			}
+
+			if output.Structured() {
+				return output.Result("moltbb.stats.v1", diaryStats{
+					Year:           year,
+					Month:          month,
+					TotalEntries:   totalEntries,
+					TotalWords:     totalWords,
+					TotalChars:     totalChars,
+					CurrentStreak:  streak,
+					EntriesByMonth: entriesByMonth,
+					TopTags:        topTags,
+				})
+			}
+
 			// Print stats
 			output.PrintSection("📊 Diary Statistics")
 
-			fmt.Printf("Total Entries:     %d\n", totalEntries)
-			fmt.Printf("Total Words:       %d\n", totalWords)
-			fmt.Printf("Total Characters: %d\n", totalChars)
+			output.Printf("Total Entries:     %d\n", totalEntries)
+			output.Printf("Total Words:       %d\n", totalWords)
+			output.Printf("Total Characters: %d\n", totalChars)
 			if totalEntries > 0 {
-				fmt.Printf("Avg Words/Entry:   %d\n", totalWords/totalEntries)
+				output.Printf("Avg Words/Entry:   %d\n", totalWords/totalEntries)
 			}
-			fmt.Printf("Current Streak:   %d days\n", streak)
+			output.Printf("Current Streak:   %d days\n", streak)
 
 			if len(entriesByMonth) > 0 {
 				output.PrintSection("📅 Entries by Month")
 				for month, count := range entriesByMonth {
-					fmt.Printf("  %s: %d entries\n", month, count)
+					output.Printf("  %s: %d entries\n", month, count)
 				}
 			}
 
-			if len(entriesByTag) > 0 {
+			if len(topTags) > 0 {
 				output.PrintSection("🏷️ Top Tags")
-				// Sort tags by count
-				for i := 0; i < len(entriesByTag) && i < 5; i++ {
-					maxTag := ""
-					maxCount := 0
-					for tag, count := range entriesByTag {
-						if count > maxCount {
-							maxCount = count
-							maxTag = tag
-						}
-					}
-					if maxTag != "" {
-						fmt.Printf("  #%s: %d\n", maxTag, maxCount)
-						delete(entriesByTag, maxTag)
-					}
+				for _, t := range topTags {
+					output.Printf("  #%s: %d\n", t.Tag, t.Count)
 				}
 			}
 
@@ -18,18 +18,18 @@ import (
 )
 
 type statusCard struct {
-	Version         string
-	APIBaseURL      string
-	APIKeyMasked    string
-	APIKeyOK        bool
-	BotID           string
-	Activation      string
-	LocalWebURL     string
-	LocalDBPath     string
-	LocalDiaryCount int
-	LocalLastUpdate string
-	CloudDiaryCount *int
-	CloudInsightCnt *int
+	Version         string `json:"version"`
+	APIBaseURL      string `json:"apiBaseUrl"`
+	APIKeyMasked    string `json:"apiKeyMasked,omitempty"`
+	APIKeyOK        bool   `json:"apiKeyOk"`
+	BotID           string `json:"botId,omitempty"`
+	Activation      string `json:"activation,omitempty"`
+	LocalWebURL     string `json:"localWebUrl"`
+	LocalDBPath     string `json:"localDbPath"`
+	LocalDiaryCount int    `json:"localDiaryCount"`
+	LocalLastUpdate string `json:"localLastUpdate,omitempty"`
+	CloudDiaryCount *int   `json:"cloudDiaryCount,omitempty"`
+	CloudInsightCnt *int   `json:"cloudInsightCount,omitempty"`
 }
 
 func buildStatusCard() statusCard {
@@ -1,76 +1,323 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"moltbb-cli/internal/api"
 	"moltbb-cli/internal/auth"
 	"moltbb-cli/internal/binding"
 	"moltbb-cli/internal/config"
+	"moltbb-cli/internal/diary"
+	"moltbb-cli/internal/localweb"
+	"moltbb-cli/internal/output"
 )
 
+var syncDiaryDateRe = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})`)
+
+// cloudSyncResult is the structured result of 'moltbb cloud-sync'. Items
+// lists the dates that are not in sync, with the action planned (dry run)
+// or taken.
+type cloudSyncResult struct {
+	DryRun    bool            `json:"dryRun"`
+	Prefer    string          `json:"prefer,omitempty"`
+	Items     []cloudSyncItem `json:"items"`
+	Uploaded  int             `json:"uploaded"`
+	Pulled    int             `json:"pulled"`
+	Conflicts int             `json:"conflicts"`
+	Failed    int             `json:"failed"`
+}
+
+type cloudSyncItem struct {
+	Date   string           `json:"date"`
+	Action diary.SyncAction `json:"action"`
+	Reason string           `json:"reason"`
+	Error  string           `json:"error,omitempty"`
+}
+
 func newSyncCmd() *cobra.Command {
 	var dryRun bool
+	var prefer string
 
 	cmd := &cobra.Command{
 		Use:   "cloud-sync",
-		Short: "Manually sync local diaries to MoltBB cloud",
-		Long: `Manually trigger a sync of local diary files to MoltBB cloud.
-Requires login and binding to be configured.
+		Short: "Two-way sync of local diaries with MoltBB cloud",
+		Long: `Compare local diary files with cloud runtime diaries by content hash.
+
+New or changed local diaries are uploaded, diaries that only exist in the
+cloud are pulled into the output directory, and dates changed on both sides
+since the last sync are reported as conflicts. The last synced hash and
+remote diary ID per date are kept in the local studio database.
 
-Note: This command lists diaries that need syncing. 
-Use 'moltbb diary upload <file>' to upload individual files.`,
+Use --prefer local|remote to resolve conflicts automatically.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Check config
+			prefer = strings.ToLower(strings.TrimSpace(prefer))
+			if prefer != "" && prefer != "local" && prefer != "remote" {
+				return fmt.Errorf("invalid --prefer %q (use local or remote)", prefer)
+			}
+
 			cfg, err := config.Load()
 			if err != nil {
 				return fmt.Errorf("load config: %w", err)
 			}
 
-			// Check API key
-			_, err = auth.ResolveAPIKey()
+			apiKey, err := auth.ResolveAPIKey()
 			if err != nil {
 				return fmt.Errorf("resolve API key: %w", err)
 			}
 
-			// Check binding
 			bindState, err := binding.Load()
 			if err != nil || !bindState.Bound {
 				return fmt.Errorf("not bound. Run 'moltbb bind' first")
 			}
 
-			// Get diary files
-			diaryDir := cfg.OutputDir
-			files, err := filepath.Glob(filepath.Join(diaryDir, "*.md"))
+			local, err := diary.CollectLocalSyncItems(cfg.OutputDir)
+			if err != nil {
+				return err
+			}
+
+			client, err := api.NewClient(cfg)
+			if err != nil {
+				return err
+			}
+			remote, err := collectRemoteSyncItems(client, apiKey, cfg)
+			if err != nil {
+				return err
+			}
+
+			dbPath, err := resolveLocalDBPath()
 			if err != nil {
-				return fmt.Errorf("glob diaries: %w", err)
+				return err
 			}
+			db, err := localweb.OpenDB(dbPath)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
 
-			if len(files) == 0 {
-				fmt.Println("⚠️  No diary files found")
-				return nil
+			records, err := localweb.LoadSyncRecords(db)
+			if err != nil {
+				return err
 			}
 
-			fmt.Println("📊 Diary files found:")
-			fmt.Println("")
-			for _, file := range files {
-				date := filepath.Base(file[:len(file)-3])
-				fmt.Printf("   📝 %s\n", date)
+			res := cloudSyncResult{DryRun: dryRun, Prefer: prefer, Items: []cloudSyncItem{}}
+			plan := diary.PlanSync(local, remote, records)
+			if len(plan) == 0 {
+				output.Println("⚠️  No diaries found locally or in the cloud")
+				return output.Result("moltbb.cloud-sync.v1", res)
 			}
-			fmt.Println("")
-			fmt.Printf("Total: %d diary files in %s\n", len(files), diaryDir)
-			fmt.Println("")
-			fmt.Println("💡 To upload to cloud, run:")
-			fmt.Println("   moltbb diary upload <file>")
-			fmt.Println("")
-			fmt.Println("   Or use --auto-upload with moltbb run")
 
-			return nil
+			if dryRun {
+				printSyncPlan(plan, prefer)
+				for _, item := range plan {
+					if item.Action != diary.SyncActionNone {
+						res.Items = append(res.Items, cloudSyncItem{Date: item.Date, Action: item.Action, Reason: item.Reason})
+					}
+				}
+				return output.Result("moltbb.cloud-sync.v1", res)
+			}
+
+			for _, item := range plan {
+				action := item.Action
+				if action == diary.SyncActionConflict {
+					switch prefer {
+					case "local":
+						action = diary.SyncActionUpload
+					case "remote":
+						action = diary.SyncActionPull
+					}
+				}
+
+				switch action {
+				case diary.SyncActionNone:
+					if record, ok := records[item.Date]; !ok || record.ContentHash != item.Local.Hash || record.RemoteDiaryID != item.Remote.ID {
+						record.DiaryDate, record.ContentHash, record.RemoteDiaryID = item.Date, item.Local.Hash, item.Remote.ID
+						if item.Remote.Hash == item.Local.Hash {
+							record.RemoteHash = ""
+						}
+						if err := localweb.SaveSyncRecord(db, record); err != nil {
+							return err
+						}
+					}
+				case diary.SyncActionUpload:
+					done := cloudSyncItem{Date: item.Date, Action: action, Reason: item.Reason}
+					record, err := uploadSyncItem(client, apiKey, cfg, item)
+					if err != nil {
+						res.Failed++
+						done.Error = err.Error()
+						res.Items = append(res.Items, done)
+						output.Printf("   ❌ %s upload failed: %v\n", item.Date, err)
+						continue
+					}
+					if err := localweb.SaveSyncRecord(db, record); err != nil {
+						return err
+					}
+					res.Uploaded++
+					res.Items = append(res.Items, done)
+					output.Printf("   ⬆️  %s uploaded (%s)\n", item.Date, item.Reason)
+				case diary.SyncActionPull:
+					done := cloudSyncItem{Date: item.Date, Action: action, Reason: item.Reason}
+					record, err := pullSyncItem(cfg.OutputDir, item)
+					if err != nil {
+						res.Failed++
+						done.Error = err.Error()
+						res.Items = append(res.Items, done)
+						output.Printf("   ❌ %s pull failed: %v\n", item.Date, err)
+						continue
+					}
+					if err := localweb.SaveSyncRecord(db, record); err != nil {
+						return err
+					}
+					res.Pulled++
+					res.Items = append(res.Items, done)
+					output.Printf("   ⬇️  %s pulled (%s)\n", item.Date, item.Reason)
+				case diary.SyncActionConflict:
+					res.Conflicts++
+					res.Items = append(res.Items, cloudSyncItem{Date: item.Date, Action: action, Reason: item.Reason})
+					output.Printf("   ⚠️  %s conflict: %s\n", item.Date, item.Reason)
+				}
+			}
+
+			output.Println("")
+			output.Printf("Uploaded: %d  Pulled: %d  Conflicts: %d  Failed: %d\n", res.Uploaded, res.Pulled, res.Conflicts, res.Failed)
+			if res.Conflicts > 0 {
+				output.Println("💡 Resolve conflicts with --prefer local or --prefer remote")
+			}
+			if res.Failed > 0 {
+				return output.WithResult(fmt.Errorf("%d diaries failed to sync", res.Failed), "moltbb.cloud-sync.v1", res)
+			}
+			return output.Result("moltbb.cloud-sync.v1", res)
 		},
 	}
 
-	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be synced")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the planned actions without changing anything")
+	cmd.Flags().StringVar(&prefer, "prefer", "", "Resolve conflicts by preferring local or remote content")
 	return cmd
 }
+
+func printSyncPlan(plan []diary.SyncPlanItem, prefer string) {
+	output.Println("📊 Planned sync actions (dry run):")
+	output.Println("")
+	counts := map[diary.SyncAction]int{}
+	for _, item := range plan {
+		counts[item.Action]++
+		if item.Action == diary.SyncActionNone {
+			continue
+		}
+		label := string(item.Action)
+		if item.Action == diary.SyncActionConflict && prefer != "" {
+			label = "conflict -> " + prefer
+		}
+		output.Printf("   %-10s %s  (%s)\n", label, item.Date, item.Reason)
+	}
+	output.Println("")
+	output.Printf("Upload: %d  Pull: %d  Conflict: %d  In sync: %d\n",
+		counts[diary.SyncActionUpload], counts[diary.SyncActionPull], counts[diary.SyncActionConflict], counts[diary.SyncActionNone])
+}
+
+func collectRemoteSyncItems(client *api.Client, apiKey string, cfg config.Config) ([]diary.RemoteSyncItem, error) {
+	items := make([]diary.RemoteSyncItem, 0, 64)
+	for page := 1; ; page++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.RequestTimeoutSeconds)*time.Second)
+		result, err := client.ListRuntimeDiaries(ctx, apiKey, "", "", page, 50)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range result.Items {
+			date := runtimeDiaryDate(d)
+			if date == "" {
+				continue
+			}
+			content := remoteDiaryContent(d)
+			items = append(items, diary.RemoteSyncItem{
+				Date:    date,
+				ID:      strings.TrimSpace(d.ID),
+				Hash:    diary.ContentHash(content),
+				Content: content,
+			})
+		}
+		if len(result.Items) == 0 || result.TotalPages == 0 || page >= result.TotalPages {
+			break
+		}
+	}
+	return items, nil
+}
+
+// remoteDiaryContent returns the text a cloud diary was uploaded from:
+// the full persona text when present, otherwise the summary.
+func remoteDiaryContent(d api.RuntimeDiary) string {
+	if strings.TrimSpace(d.PersonaText) != "" {
+		return d.PersonaText
+	}
+	return d.Summary
+}
+
+func uploadSyncItem(client *api.Client, apiKey string, cfg config.Config, item diary.SyncPlanItem) (diary.SyncRecord, error) {
+	payload, err := diary.BuildRuntimeUpsertPayload(item.Local.Path, item.Date, 0, time.Now())
+	if err != nil {
+		return diary.SyncRecord{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.RequestTimeoutSeconds)*time.Second)
+	defer cancel()
+	result, err := client.UpsertRuntimeDiary(ctx, apiKey, api.RuntimeDiaryUpsertPayload{
+		Summary:        payload.Summary,
+		PersonaText:    payload.PersonaText,
+		ExecutionLevel: payload.ExecutionLevel,
+		DiaryDate:      payload.DiaryDate,
+	})
+	if err != nil {
+		return diary.SyncRecord{}, err
+	}
+
+	remoteID := result.DiaryID
+	if remoteID == "" && item.Remote != nil {
+		remoteID = item.Remote.ID
+	}
+	// The payload may be truncated, so the cloud copy can hash differently
+	// from the file; record both so the next sync sees neither as changed.
+	remoteHash := diary.ContentHash(remoteDiaryContent(api.RuntimeDiary{Summary: payload.Summary, PersonaText: payload.PersonaText}))
+	if remoteHash == item.Local.Hash {
+		remoteHash = ""
+	}
+	return diary.SyncRecord{
+		DiaryDate:     item.Date,
+		ContentHash:   item.Local.Hash,
+		RemoteHash:    remoteHash,
+		RemoteDiaryID: remoteID,
+	}, nil
+}
+
+func pullSyncItem(diaryDir string, item diary.SyncPlanItem) (diary.SyncRecord, error) {
+	path := filepath.Join(diaryDir, item.Date+".md")
+	if item.Local != nil {
+		path = item.Local.Path
+	}
+	content := strings.TrimSpace(item.Remote.Content)
+	if content == "" {
+		content = "# " + item.Date
+	}
+	if err := os.MkdirAll(diaryDir, 0o700); err != nil {
+		return diary.SyncRecord{}, fmt.Errorf("create output dir: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content+"\n"), 0o600); err != nil {
+		return diary.SyncRecord{}, fmt.Errorf("write %s: %w", path, err)
+	}
+	record := diary.SyncRecord{
+		DiaryDate:     item.Date,
+		ContentHash:   diary.ContentHash(content),
+		RemoteDiaryID: item.Remote.ID,
+	}
+	if item.Remote.Hash != record.ContentHash {
+		record.RemoteHash = item.Remote.Hash
+	}
+	return record, nil
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -87,9 +86,9 @@ func newTemplateListCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			output.PrintSection("📋 Available Templates")
 
-			fmt.Println("Default Templates:")
+			output.Println("Default Templates:")
 			for name := range defaultTemplates {
-				fmt.Printf("  • %s\n", name)
+				output.Printf("  • %s\n", name)
 			}
 
 			homeDir, _ := os.UserHomeDir()
@@ -98,10 +97,10 @@ func newTemplateListCmd() *cobra.Command {
 			if _, err := os.Stat(templatesDir); err == nil {
 				files, _ := ioutil.ReadDir(templatesDir)
 				if len(files) > 0 {
-					fmt.Println("\nCustom Templates:")
+					output.Println("\nCustom Templates:")
 					for _, f := range files {
 						name := strings.TrimSuffix(f.Name(), ".md")
-						fmt.Printf("  • %s\n", name)
+						output.Printf("  • %s\n", name)
 					}
 				}
 			}
@@ -133,8 +132,8 @@ func newTemplateUseCmd() *cobra.Command {
 			}
 
 			content = replaceTemplatePlaceholders(content, date)
-			fmt.Println(content)
-			fmt.Println("\n💡 Copy and edit, then use 'moltbb diary create' to save.")
+			output.Println(content)
+			output.Println("\n💡 Copy and edit, then use 'moltbb diary create' to save.")
 			return nil
 		},
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"strings"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"moltbb-cli/internal/auth"
 	"moltbb-cli/internal/config"
 	"moltbb-cli/internal/output"
+	"moltbb-cli/internal/tower"
 )
 
 func newTowerCmd() *cobra.Command {
@@ -26,6 +28,8 @@ func newTowerCmd() *cobra.Command {
 	cmd.AddCommand(newTowerStatsCmd())
 	cmd.AddCommand(newTowerListCmd())
 	cmd.AddCommand(newTowerRoomCmd())
+	cmd.AddCommand(newTowerUptimeCmd())
+	cmd.AddCommand(newTowerTopCmd())
 	return cmd
 }
 
@@ -60,29 +64,32 @@ func newTowerCheckinCmd() *cobra.Command {
 				return err
 			}
 
+			if output.Structured() {
+				return output.Result("moltbb.tower.checkin.v1", resp)
+			}
 			if jsonOutput {
-				joinTimeVal := int64(0)
-				if resp.JoinTime != nil {
-					joinTimeVal = *resp.JoinTime
-				}
-				fmt.Printf(`{"code":"%s","globalIndex":%d,"floor":%d,"roomNumber":%d,"joinTime":%d}%s`,
-					resp.Code, resp.GlobalIndex, resp.Floor, resp.RoomNumber, joinTimeVal, "\n")
-				return nil
+				return printJSONLine(struct {
+					Code        string `json:"code"`
+					GlobalIndex int    `json:"globalIndex"`
+					Floor       int    `json:"floor"`
+					RoomNumber  int    `json:"roomNumber"`
+					JoinTime    int64  `json:"joinTime"`
+				}{resp.Code, resp.GlobalIndex, resp.Floor, resp.RoomNumber, int64Value(resp.JoinTime)})
 			}
 
 			output.PrintSuccess("Room assigned successfully!")
-			fmt.Println()
-			fmt.Println("Room Code:   ", resp.Code)
-			fmt.Println("Floor:       ", resp.Floor)
-			fmt.Println("Room Number: ", resp.RoomNumber)
-			fmt.Println("Global Index:", resp.GlobalIndex)
+			output.Println()
+			output.Println("Room Code:   ", resp.Code)
+			output.Println("Floor:       ", resp.Floor)
+			output.Println("Room Number: ", resp.RoomNumber)
+			output.Println("Global Index:", resp.GlobalIndex)
 			if resp.JoinTime != nil && *resp.JoinTime > 0 {
 				joinTime := time.Unix(*resp.JoinTime, 0).UTC()
-				fmt.Println("Join Time:   ", joinTime.Format("2006-01-02 15:04:05 UTC"))
+				output.Println("Join Time:   ", joinTime.Format("2006-01-02 15:04:05 UTC"))
 			}
-			fmt.Println()
-			fmt.Println("You can now send heartbeats using:")
-			fmt.Println("  moltbb tower heartbeat")
+			output.Println()
+			output.Println("You can now send heartbeats using:")
+			output.Println("  moltbb tower heartbeat")
 			return nil
 		},
 	}
@@ -96,10 +103,28 @@ func newTowerHeartbeatCmd() *cobra.Command {
 	var roomCode string
 	var statusMessage string
 	var jsonOutput bool
+	var watch bool
+	var interval time.Duration
+	var autoStatus bool
+	var activityWindow time.Duration
 
 	cmd := &cobra.Command{
 		Use:   "heartbeat",
 		Short: "Send heartbeat signal to your tower room",
+		Long: `Send a heartbeat to your tower room. With --watch, keep sending one every
+--interval (with jitter), backing off on errors and checking in again if the
+room was lost; 'moltbb tower uptime' compares the beats recorded locally
+with the server's count.
+
+Without --status, the status message is generated from OpenClaw activity
+in the last --activity-window, e.g. "12 tasks, 0 errors in last hour".
+
+To keep the room online from the daemon instead, set in config.yaml:
+
+  tower:
+    heartbeat:
+      enabled: true
+      interval_seconds: 300`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cfg, err := config.Load()
 			if err != nil {
@@ -116,11 +141,37 @@ func newTowerHeartbeatCmd() *cobra.Command {
 				return err
 			}
 
+			// Validate status message length
+			trimmedStatus := strings.TrimSpace(statusMessage)
+			if len(trimmedStatus) > tower.MaxStatusLength {
+				return fmt.Errorf("status message exceeds %d characters (got %d)", tower.MaxStatusLength, len(trimmedStatus))
+			}
+			if interval <= 0 {
+				interval = cfg.Tower.Heartbeat.Interval()
+			}
+			if activityWindow <= 0 {
+				activityWindow = cfg.Tower.Heartbeat.ActivityWindow()
+			}
+			hb := heartbeatOptions{
+				roomCode:       strings.ToUpper(strings.TrimSpace(roomCode)),
+				status:         trimmedStatus,
+				autoStatus:     autoStatus,
+				interval:       interval,
+				activityWindow: activityWindow,
+			}
+
+			if watch {
+				if interval < 30*time.Second {
+					return fmt.Errorf("--interval must be at least 30s (got %s)", interval)
+				}
+				return watchHeartbeat(cfg, client, apiKey, hb, jsonOutput)
+			}
+
 			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.RequestTimeoutSeconds)*time.Second)
 			defer cancel()
 
 			// Auto-detect room code if not provided
-			code := strings.TrimSpace(roomCode)
+			code := hb.roomCode
 			if code == "" {
 				myRoom, err := client.TowerGetMyRoom(ctx, apiKey)
 				if err != nil {
@@ -129,34 +180,36 @@ func newTowerHeartbeatCmd() *cobra.Command {
 				code = myRoom.Code
 			}
 
-			// Validate status message length
-			trimmedStatus := strings.TrimSpace(statusMessage)
-			if len(trimmedStatus) > 200 {
-				return fmt.Errorf("status message exceeds 200 characters (got %d)", len(trimmedStatus))
-			}
-
 			// Prepare status message pointer
 			var statusPtr *string
-			if trimmedStatus != "" {
-				statusPtr = &trimmedStatus
+			if status := heartbeatStatus(cfg, hb)(ctx); status != "" {
+				statusPtr = &status
 			}
 
 			resp, err := client.TowerSendHeartbeat(ctx, apiKey, code, statusPtr)
+			recordHeartbeat(code, interval, err)
 			if err != nil {
 				return err
 			}
 
+			if output.Structured() {
+				return output.Result("moltbb.tower.heartbeat.v1", beatRecord{
+					At:     time.Unix(resp.Timestamp, 0).UTC().Format(time.RFC3339),
+					Room:   code,
+					Status: stringValue(statusPtr),
+					OK:     resp.Success,
+				})
+			}
 			if jsonOutput {
-				fmt.Printf(`{"success":%t,"timestamp":%d}%s`, resp.Success, resp.Timestamp, "\n")
-				return nil
+				return printJSONLine(resp)
 			}
 
 			output.PrintSuccess("Heartbeat sent successfully!")
-			fmt.Println("Room Code:", code)
+			output.Println("Room Code:", code)
 			heartbeatTime := time.Unix(resp.Timestamp, 0).UTC()
-			fmt.Println("Timestamp:", heartbeatTime.Format("2006-01-02 15:04:05 UTC"))
+			output.Println("Timestamp:", heartbeatTime.Format("2006-01-02 15:04:05 UTC"))
 			if statusPtr != nil {
-				fmt.Println("Status:   ", *statusPtr)
+				output.Println("Status:   ", *statusPtr)
 			}
 			return nil
 		},
@@ -165,7 +218,11 @@ func newTowerHeartbeatCmd() *cobra.Command {
 	cmd.Flags().StringVarP(&roomCode, "room-code", "r", "", "Room code (3-char HEX, auto-detected if not specified)")
 	cmd.Flags().StringVarP(&statusMessage, "status", "s", "", "Status message to display (max 200 characters)")
 	cmd.Flags().StringVarP(&statusMessage, "message", "m", "", "Alias for --status")
-	cmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output as JSON")
+	cmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output as JSON (one object per beat with --watch)")
+	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "Keep sending heartbeats until interrupted")
+	cmd.Flags().DurationVar(&interval, "interval", 0, "Time between heartbeats with --watch (default: tower.heartbeat.interval_seconds or 5m)")
+	cmd.Flags().BoolVar(&autoStatus, "auto-status", true, "Generate the status from recent OpenClaw activity when --status is not set")
+	cmd.Flags().DurationVar(&activityWindow, "activity-window", 0, "How far back --auto-status looks (default 1h)")
 	return cmd
 }
 
@@ -199,30 +256,27 @@ func newTowerMyRoomCmd() *cobra.Command {
 				return err
 			}
 
+			if output.Structured() {
+				return output.Result("moltbb.tower.room.v1", room)
+			}
 			if jsonOutput {
-				lastHeartbeatVal := int64(0)
-				if room.LastHeartbeat != nil {
-					lastHeartbeatVal = *room.LastHeartbeat
-				}
-				fmt.Printf(`{"code":"%s","globalIndex":%d,"botId":"%s","botName":"%s","status":%d,"lastHeartbeat":%d}%s`,
-					room.Code, room.GlobalIndex, room.BotId, room.BotName, room.Status, lastHeartbeatVal, "\n")
-				return nil
+				return printJSONLine(newTowerRoomJSON(room))
 			}
 
 			output.PrintSection("Your Tower Room")
-			fmt.Println("Room Code:   ", room.Code)
-			fmt.Println("Global Index:", room.GlobalIndex)
-			fmt.Println("Bot ID:      ", room.BotId)
+			output.Println("Room Code:   ", room.Code)
+			output.Println("Global Index:", room.GlobalIndex)
+			output.Println("Bot ID:      ", room.BotId)
 			if room.BotName != "" {
-				fmt.Println("Bot Name:    ", room.BotName)
+				output.Println("Bot Name:    ", room.BotName)
 			}
-			fmt.Println("Status:      ", formatNodeStatus(room.Status))
+			output.Println("Status:      ", formatNodeStatus(room.Status))
 			if room.LastHeartbeat != nil && *room.LastHeartbeat > 0 {
 				lastTime := time.Unix(*room.LastHeartbeat, 0).UTC()
-				fmt.Println("Last Heartbeat:", lastTime.Format("2006-01-02 15:04:05 UTC"))
+				output.Println("Last Heartbeat:", lastTime.Format("2006-01-02 15:04:05 UTC"))
 			}
 			if room.StatusMessage != nil && *room.StatusMessage != "" {
-				fmt.Println("Status Message:", *room.StatusMessage)
+				output.Println("Status Message:", *room.StatusMessage)
 			}
 			return nil
 		},
@@ -257,25 +311,34 @@ func newTowerStatsCmd() *cobra.Command {
 				return err
 			}
 
+			if output.Structured() {
+				return output.Result("moltbb.tower.stats.v1", stats)
+			}
 			if jsonOutput {
-				fmt.Printf(`{"totalRooms":%d,"occupiedRooms":%d,"onlineRooms":%d,"occupancyRate":%.4f,"roomsJoinedToday":%d,"fullFloors":%d,"isFullTower":%t}%s`,
-					stats.TotalRooms, stats.OccupiedRooms, stats.OnlineRooms, stats.OccupancyRate,
-					stats.RoomsJoinedToday, stats.FullFloors, stats.IsFullTower, "\n")
-				return nil
+				return printJSONLine(struct {
+					TotalRooms       int     `json:"totalRooms"`
+					OccupiedRooms    int     `json:"occupiedRooms"`
+					OnlineRooms      int     `json:"onlineRooms"`
+					OccupancyRate    float64 `json:"occupancyRate"`
+					RoomsJoinedToday int     `json:"roomsJoinedToday"`
+					FullFloors       int     `json:"fullFloors"`
+					IsFullTower      bool    `json:"isFullTower"`
+				}{stats.TotalRooms, stats.OccupiedRooms, stats.OnlineRooms, math.Round(stats.OccupancyRate*1e4) / 1e4,
+					stats.RoomsJoinedToday, stats.FullFloors, stats.IsFullTower})
 			}
 
 			output.PrintSection("Lobster Tower Statistics")
-			fmt.Printf("Total Rooms:        %d\n", stats.TotalRooms)
-			fmt.Printf("Occupied Rooms:     %d   (%.1f%%)\n", stats.OccupiedRooms, float64(stats.OccupiedRooms)/float64(stats.TotalRooms)*100)
-			fmt.Printf("Online Rooms:       %d   (%.1f%%)\n", stats.OnlineRooms, float64(stats.OnlineRooms)/float64(stats.TotalRooms)*100)
-			fmt.Printf("Occupancy Rate:     %.1f%%\n", stats.OccupancyRate*100)
-			fmt.Printf("Joined Today:       %d\n", stats.RoomsJoinedToday)
-			fmt.Printf("Full Floors:        %d\n", stats.FullFloors)
+			output.Printf("Total Rooms:        %d\n", stats.TotalRooms)
+			output.Printf("Occupied Rooms:     %d   (%.1f%%)\n", stats.OccupiedRooms, float64(stats.OccupiedRooms)/float64(stats.TotalRooms)*100)
+			output.Printf("Online Rooms:       %d   (%.1f%%)\n", stats.OnlineRooms, float64(stats.OnlineRooms)/float64(stats.TotalRooms)*100)
+			output.Printf("Occupancy Rate:     %.1f%%\n", stats.OccupancyRate*100)
+			output.Printf("Joined Today:       %d\n", stats.RoomsJoinedToday)
+			output.Printf("Full Floors:        %d\n", stats.FullFloors)
 			fullTowerStatus := "No"
 			if stats.IsFullTower {
 				fullTowerStatus = "Yes"
 			}
-			fmt.Printf("Full Tower:         %s\n", fullTowerStatus)
+			output.Printf("Full Tower:         %s\n", fullTowerStatus)
 			return nil
 		},
 	}
@@ -330,30 +393,27 @@ func newTowerListCmd() *cobra.Command {
 				filtered = append(filtered, room)
 			}
 
+			if output.Structured() {
+				if filtered == nil {
+					filtered = []api.TowerRoomState{}
+				}
+				return output.Result("moltbb.tower.rooms.v1", filtered)
+			}
 			if jsonOutput {
-				fmt.Print("[")
-				for i, room := range filtered {
-					if i > 0 {
-						fmt.Print(",")
-					}
-					lastHeartbeatVal := int64(0)
-					if room.LastHeartbeat != nil {
-						lastHeartbeatVal = *room.LastHeartbeat
-					}
-					fmt.Printf(`{"code":"%s","globalIndex":%d,"botId":"%s","botName":"%s","status":%d,"lastHeartbeat":%d}`,
-						room.Code, room.GlobalIndex, room.BotId, room.BotName, room.Status, lastHeartbeatVal)
+				rooms := make([]towerRoomJSON, 0, len(filtered))
+				for _, room := range filtered {
+					rooms = append(rooms, newTowerRoomJSON(room))
 				}
-				fmt.Println("]")
-				return nil
+				return printJSONLine(rooms)
 			}
 
 			if len(filtered) == 0 {
-				fmt.Println("No rooms found matching filters")
+				output.Println("No rooms found matching filters")
 				return nil
 			}
 
-			fmt.Println("ROOM    FLOOR  STATUS      BOT NAME           LAST HEARTBEAT")
-			fmt.Println("------  -----  ----------  -----------------  ---------------")
+			output.Println("ROOM    FLOOR  STATUS      BOT NAME           LAST HEARTBEAT")
+			output.Println("------  -----  ----------  -----------------  ---------------")
 			for _, room := range filtered {
 				botName := "-"
 				if room.BotName != "" {
@@ -368,11 +428,11 @@ func newTowerListCmd() *cobra.Command {
 					lastHeartbeat = formatTimeAgo(lastTime)
 				}
 				floorNum := room.Code[:2]
-				fmt.Printf("%-6s  %-5s  %-10s  %-17s  %s\n",
+				output.Printf("%-6s  %-5s  %-10s  %-17s  %s\n",
 					room.Code, floorNum, formatNodeStatus(room.Status), botName, lastHeartbeat)
 			}
-			fmt.Println()
-			fmt.Printf("Total: %d rooms | Occupied: %d | Online: %d\n",
+			output.Println()
+			output.Printf("Total: %d rooms | Occupied: %d | Online: %d\n",
 				len(filtered),
 				countOccupied(filtered),
 				countOnline(filtered))
@@ -439,46 +499,50 @@ func newTowerRoomCmd() *cobra.Command {
 				return err
 			}
 
+			if output.Structured() {
+				return output.Result("moltbb.tower.room-detail.v1", room)
+			}
 			if jsonOutput {
-				lastHeartbeatVal := int64(0)
-				if room.LastHeartbeat != nil {
-					lastHeartbeatVal = *room.LastHeartbeat
-				}
-				joinTimeVal := int64(0)
-				if room.JoinTime != nil {
-					joinTimeVal = *room.JoinTime
-				}
-				fmt.Printf(`{"code":"%s","floor":%d,"roomNumber":%d,"globalIndex":%d,"botId":"%s","botName":"%s","status":%d,"lastHeartbeat":%d,"joinTime":%d,"totalHeartbeats":%d}%s`,
-					room.Code, room.Floor, room.RoomNumber, room.GlobalIndex, room.BotId, room.BotName,
-					room.Status, lastHeartbeatVal, joinTimeVal, room.TotalHeartbeats, "\n")
-				return nil
+				return printJSONLine(struct {
+					Code            string `json:"code"`
+					Floor           int    `json:"floor"`
+					RoomNumber      int    `json:"roomNumber"`
+					GlobalIndex     int    `json:"globalIndex"`
+					BotID           string `json:"botId"`
+					BotName         string `json:"botName"`
+					Status          int    `json:"status"`
+					LastHeartbeat   int64  `json:"lastHeartbeat"`
+					JoinTime        int64  `json:"joinTime"`
+					TotalHeartbeats int    `json:"totalHeartbeats"`
+				}{room.Code, room.Floor, room.RoomNumber, room.GlobalIndex, room.BotId, room.BotName,
+					room.Status, int64Value(room.LastHeartbeat), int64Value(room.JoinTime), room.TotalHeartbeats})
 			}
 
 			output.PrintSection("Room Details")
-			fmt.Println("Code:              ", room.Code)
-			fmt.Println("Global Index:      ", room.GlobalIndex)
-			fmt.Println("Floor:             ", room.Floor)
-			fmt.Println("Room:              ", room.RoomNumber)
-			fmt.Println("Status:            ", formatNodeStatus(room.Status))
+			output.Println("Code:              ", room.Code)
+			output.Println("Global Index:      ", room.GlobalIndex)
+			output.Println("Floor:             ", room.Floor)
+			output.Println("Room:              ", room.RoomNumber)
+			output.Println("Status:            ", formatNodeStatus(room.Status))
 			if room.BotId != "" {
-				fmt.Println("Bot:               ", room.BotName)
-				fmt.Println("Bot ID:            ", room.BotId)
+				output.Println("Bot:               ", room.BotName)
+				output.Println("Bot ID:            ", room.BotId)
 			} else {
-				fmt.Println("Bot:                -")
+				output.Println("Bot:                -")
 			}
 			if room.LastHeartbeat != nil && *room.LastHeartbeat > 0 {
 				lastTime := time.Unix(*room.LastHeartbeat, 0).UTC()
-				fmt.Println("Last Heartbeat:    ", lastTime.Format("2006-01-02 15:04:05 UTC"))
+				output.Println("Last Heartbeat:    ", lastTime.Format("2006-01-02 15:04:05 UTC"))
 			} else {
-				fmt.Println("Last Heartbeat:     -")
+				output.Println("Last Heartbeat:     -")
 			}
 			if room.JoinTime != nil && *room.JoinTime > 0 {
 				joinTime := time.Unix(*room.JoinTime, 0).UTC()
-				fmt.Println("Join Time:         ", joinTime.Format("2006-01-02 15:04:05 UTC"))
+				output.Println("Join Time:         ", joinTime.Format("2006-01-02 15:04:05 UTC"))
 			}
-			fmt.Println("Total Heartbeats:  ", room.TotalHeartbeats)
+			output.Println("Total Heartbeats:  ", room.TotalHeartbeats)
 			if room.StatusMessage != nil && *room.StatusMessage != "" {
-				fmt.Println("Status Message:    ", *room.StatusMessage)
+				output.Println("Status Message:    ", *room.StatusMessage)
 			}
 			return nil
 		},
@@ -491,18 +555,7 @@ func newTowerRoomCmd() *cobra.Command {
 }
 
 func formatNodeStatus(status int) string {
-	switch status {
-	case 0:
-		return "OFFLINE"
-	case 1:
-		return "ONLINE"
-	case 2:
-		return "STABLE_7D"
-	case 3:
-		return "STABLE_30D"
-	default:
-		return fmt.Sprintf("UNKNOWN(%d)", status)
-	}
+	return tower.StatusName(status)
 }
 
 func parseStatusFilter(filter string) int {
@@ -556,3 +609,39 @@ func countOnline(rooms []api.TowerRoomState) int {
 	}
 	return count
 }
+
+// towerRoomJSON is a room in the --json shape, where missing values are
+// zero rather than left out.
+type towerRoomJSON struct {
+	Code          string `json:"code"`
+	GlobalIndex   int    `json:"globalIndex"`
+	BotID         string `json:"botId"`
+	BotName       string `json:"botName"`
+	Status        int    `json:"status"`
+	LastHeartbeat int64  `json:"lastHeartbeat"`
+}
+
+func newTowerRoomJSON(room api.TowerRoomState) towerRoomJSON {
+	return towerRoomJSON{
+		Code:          room.Code,
+		GlobalIndex:   room.GlobalIndex,
+		BotID:         room.BotId,
+		BotName:       room.BotName,
+		Status:        room.Status,
+		LastHeartbeat: int64Value(room.LastHeartbeat),
+	}
+}
+
+func int64Value(v *int64) int64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+func stringValue(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"moltbb-cli/internal/api"
+	"moltbb-cli/internal/auth"
+	"moltbb-cli/internal/config"
+	"moltbb-cli/internal/daemon"
+	"moltbb-cli/internal/output"
+	"moltbb-cli/internal/parser"
+	"moltbb-cli/internal/tower"
+	"moltbb-cli/internal/utils"
+)
+
+type heartbeatOptions struct {
+	roomCode       string
+	status         string
+	autoStatus     bool
+	interval       time.Duration
+	activityWindow time.Duration
+}
+
+// watchHeartbeat runs the heartbeat agent in the foreground until
+// interrupted.
+func watchHeartbeat(cfg config.Config, client *api.Client, apiKey string, opts heartbeatOptions, jsonOutput bool) error {
+	lock, err := acquireHeartbeatLock()
+	if errors.Is(err, daemon.ErrRunning) {
+		return errors.New("a heartbeat agent is already running (moltbb daemon or another --watch)")
+	}
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	agent, err := newHeartbeatAgent(cfg, client, apiKey, opts)
+	if err != nil {
+		return err
+	}
+	agent.OnBeat = func(beat tower.Beat) {
+		switch {
+		case output.Structured():
+			_ = output.Event("moltbb.tower.heartbeat.v1", newBeatRecord(beat))
+		case jsonOutput:
+			_ = printJSONLine(newBeatRecord(beat))
+		default:
+			printBeat(beat)
+		}
+	}
+	if !jsonOutput && !output.Structured() {
+		output.PrintInfo(fmt.Sprintf("Sending heartbeats every %s (±10%%); Ctrl+C to stop", opts.interval))
+		agent.Log = output.Writer()
+	}
+	return agent.Run(ctx)
+}
+
+// startHeartbeatAgent runs the agent configured under tower.heartbeat in
+// the background until ctx ends. Like the scheduler, only one process per
+// machine sends heartbeats.
+func startHeartbeatAgent(ctx context.Context, cfg config.Config) error {
+	apiKey, err := auth.ResolveAPIKey()
+	if err != nil {
+		return fmt.Errorf("resolve API key: %w", err)
+	}
+	client, err := api.NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	lock, err := acquireHeartbeatLock()
+	if err != nil {
+		if errors.Is(err, daemon.ErrRunning) {
+			output.Println("Heartbeat: already running in another moltbb process, not starting here")
+			return nil
+		}
+		return err
+	}
+
+	hb := cfg.Tower.Heartbeat
+	agent, err := newHeartbeatAgent(cfg, client, apiKey, heartbeatOptions{
+		roomCode:       hb.RoomCode,
+		status:         hb.Status,
+		autoStatus:     true,
+		interval:       hb.Interval(),
+		activityWindow: hb.ActivityWindow(),
+	})
+	if err != nil {
+		lock.Release()
+		return err
+	}
+	agent.Log = output.Writer()
+	agent.OnBeat = func(beat tower.Beat) { logBeat(output.Writer(), beat) }
+	output.Printf("Heartbeat: every %s\n", hb.Interval())
+	go func() {
+		defer lock.Release()
+		if err := agent.Run(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: heartbeat agent stopped: %v\n", err)
+		}
+	}()
+	return nil
+}
+
+func newHeartbeatAgent(cfg config.Config, client *api.Client, apiKey string, opts heartbeatOptions) (*tower.Agent, error) {
+	uptimePath, err := heartbeatUptimePath()
+	if err != nil {
+		return nil, err
+	}
+	uptime, err := tower.LoadUptime(uptimePath)
+	if err != nil {
+		return nil, err
+	}
+	return &tower.Agent{
+		API:            client,
+		APIKey:         apiKey,
+		RoomCode:       opts.roomCode,
+		Interval:       opts.interval,
+		RequestTimeout: time.Duration(cfg.RequestTimeoutSeconds) * time.Second,
+		Status:         heartbeatStatus(cfg, opts),
+		Uptime:         uptime,
+		UptimePath:     uptimePath,
+	}, nil
+}
+
+// heartbeatStatus returns the status source for heartbeats: the fixed
+// message when one is set, otherwise a summary of recent OpenClaw activity.
+func heartbeatStatus(cfg config.Config, opts heartbeatOptions) func(context.Context) string {
+	if opts.status != "" {
+		return func(context.Context) string { return opts.status }
+	}
+	if !opts.autoStatus {
+		return func(context.Context) string { return "" }
+	}
+	return func(context.Context) string {
+		loc, err := cfg.Location()
+		if err != nil {
+			loc = time.Local
+		}
+		res, err := parser.ParseOpenClawLogsWindow(cfg.InputPaths, parser.WindowOptions{
+			Since:    time.Now().Add(-opts.activityWindow),
+			Location: loc,
+			MaxLines: 50000,
+		})
+		if err != nil {
+			// No logs to summarize is not worth failing a heartbeat over.
+			return ""
+		}
+		return tower.ActivityStatus(res.Stats, opts.activityWindow)
+	}
+}
+
+// recordHeartbeat adds a one-shot heartbeat to the local uptime record.
+func recordHeartbeat(room string, interval time.Duration, sendErr error) {
+	path, err := heartbeatUptimePath()
+	if err != nil {
+		return
+	}
+	uptime, err := tower.LoadUptime(path)
+	if err != nil {
+		return
+	}
+	if sendErr != nil {
+		uptime.RecordFailure(time.Now(), sendErr)
+	} else {
+		uptime.RecordBeat(room, time.Now(), interval)
+	}
+	_ = tower.SaveUptime(path, uptime)
+}
+
+func printBeat(beat tower.Beat) {
+	stamp := beat.At.Local().Format("15:04:05")
+	if beat.Err != nil {
+		output.PrintWarning(fmt.Sprintf("%s heartbeat to %s failed: %v (retry in %s)", stamp, beat.Room, beat.Err, beat.Next.Round(time.Second)))
+		return
+	}
+	if beat.Checkin {
+		output.PrintInfo(fmt.Sprintf("%s checked in again, room %s", stamp, beat.Room))
+	}
+	msg := fmt.Sprintf("%s heartbeat → %s", stamp, beat.Room)
+	if beat.Status != "" {
+		msg += " (" + beat.Status + ")"
+	}
+	output.PrintSuccess(fmt.Sprintf("%s, next in %s", msg, beat.Next.Round(time.Second)))
+}
+
+// beatRecord is one heartbeat in JSON.
+type beatRecord struct {
+	At          string `json:"at"`
+	Room        string `json:"room"`
+	Status      string `json:"status"`
+	Checkin     bool   `json:"checkin"`
+	OK          bool   `json:"ok"`
+	NextSeconds int    `json:"nextSeconds"`
+	Error       string `json:"error,omitempty"`
+}
+
+func newBeatRecord(beat tower.Beat) beatRecord {
+	rec := beatRecord{
+		At:          beat.At.UTC().Format(time.RFC3339),
+		Room:        beat.Room,
+		Status:      beat.Status,
+		Checkin:     beat.Checkin,
+		OK:          beat.Err == nil,
+		NextSeconds: int(beat.Next / time.Second),
+	}
+	if beat.Err != nil {
+		rec.Error = beat.Err.Error()
+	}
+	return rec
+}
+
+func logBeat(w io.Writer, beat tower.Beat) {
+	stamp := beat.At.Format(time.RFC3339)
+	if beat.Err != nil {
+		fmt.Fprintf(w, "%s [heartbeat] %s failed: %v (retry in %s)\n", stamp, beat.Room, beat.Err, beat.Next.Round(time.Second))
+		return
+	}
+	fmt.Fprintf(w, "%s [heartbeat] %s ok %q\n", stamp, beat.Room, beat.Status)
+}
+
+func newTowerUptimeCmd() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "uptime",
+		Short: "Compare locally recorded heartbeats with the server's count",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+			path, err := heartbeatUptimePath()
+			if err != nil {
+				return err
+			}
+			uptime, err := tower.LoadUptime(path)
+			if err != nil {
+				return err
+			}
+			if uptime.RoomCode == "" {
+				if output.Structured() {
+					return output.Result("moltbb.tower.uptime.v1", map[string]any{"local": nil})
+				}
+				output.PrintInfo("No heartbeats recorded on this machine yet (see 'moltbb tower heartbeat --watch')")
+				return nil
+			}
+
+			client, err := api.NewClient(cfg)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.RequestTimeoutSeconds)*time.Second)
+			defer cancel()
+			room, roomErr := client.TowerGetRoomDetail(ctx, uptime.RoomCode)
+
+			interval := cfg.Tower.Heartbeat.Interval()
+			if jsonOutput || output.Structured() {
+				out := map[string]any{
+					"local":         uptime,
+					"streakSeconds": int64(uptime.Streak(time.Now(), interval) / time.Second),
+				}
+				if roomErr == nil {
+					out["serverTotalHeartbeats"] = room.TotalHeartbeats
+					out["serverStatus"] = formatNodeStatus(room.Status)
+				}
+				if output.Structured() {
+					return output.Result("moltbb.tower.uptime.v1", out)
+				}
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(out)
+			}
+
+			output.PrintSection("Heartbeat Uptime")
+			output.Println("Room Code:        ", uptime.RoomCode)
+			output.Println("Tracking since:   ", uptime.Since.Local().Format("2006-01-02 15:04"))
+			if !uptime.LastBeat.IsZero() {
+				output.Println("Last beat:        ", formatTimeAgo(uptime.LastBeat))
+			}
+			output.Println("Online (local):   ", formatUptime(uptime.Online()))
+			output.Println("Current streak:   ", formatUptime(uptime.Streak(time.Now(), interval)))
+			output.Printf("Beats (local):     %d sent, %d failed, %d re-checkins\n", uptime.Beats, uptime.Failures, uptime.Checkins)
+			if roomErr != nil {
+				output.PrintWarning(fmt.Sprintf("Server count unavailable: %v", roomErr))
+				return nil
+			}
+			output.Println("Beats (server):   ", room.TotalHeartbeats)
+			output.Println("Server status:    ", formatNodeStatus(room.Status))
+			if diff := room.TotalHeartbeats - uptime.Beats; diff > 0 {
+				output.Printf("\nThe server counts %d more beats than this machine sent since %s (other machines or earlier runs).\n",
+					diff, uptime.Since.Local().Format("2006-01-02"))
+			} else if diff < 0 {
+				output.PrintWarning(fmt.Sprintf("%d beats sent from here are missing on the server", -diff))
+			}
+			if uptime.LastError != "" {
+				output.Printf("Last error (%s): %s\n", formatTimeAgo(uptime.LastErrorAt), uptime.LastError)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output as JSON")
+	return cmd
+}
+
+func formatUptime(d time.Duration) string {
+	if d < time.Minute {
+		return "0m"
+	}
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+	if days > 0 {
+		return fmt.Sprintf("%dd %dh %dm", days, hours, minutes)
+	}
+	if hours > 0 {
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
+
+func acquireHeartbeatLock() (*daemon.Lock, error) {
+	moltbbDir, err := utils.MoltbbDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(moltbbDir, 0o700); err != nil {
+		return nil, err
+	}
+	return daemon.AcquireLock(filepath.Join(moltbbDir, "heartbeat.lock"))
+}
+
+func heartbeatUptimePath() (string, error) {
+	moltbbDir, err := utils.MoltbbDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(moltbbDir, "tower-uptime.json"), nil
+}
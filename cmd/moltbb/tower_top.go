@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"moltbb-cli/internal/api"
+	"moltbb-cli/internal/auth"
+	"moltbb-cli/internal/config"
+	"moltbb-cli/internal/output"
+	"moltbb-cli/internal/tower"
+)
+
+// TowerHub push events the dashboard listens for after JoinTower.
+const (
+	towerEventRoomUpdated  = "Tower.RoomUpdated"
+	towerEventStatsUpdated = "Tower.StatisticsUpdated"
+)
+
+// towerUpdate is a change applied to the dashboard on the UI goroutine.
+type towerUpdate func(d *tower.Dashboard)
+
+func newTowerTopCmd() *cobra.Command {
+	var refresh time.Duration
+	var noColor bool
+	var noLive bool
+
+	cmd := &cobra.Command{
+		Use:   "top",
+		Short: "Full-screen live view of the tower",
+		Long: `Show every floor of the tower as a grid coloured by room status, with
+tower statistics and the occupancy trend. Your own room is marked ◆.
+
+Move with the arrow keys (or h/j/k/l), press Enter for a room's details,
+m to jump to your room, r to refresh and q to quit. When logged in, room
+changes are pushed over TowerHub; otherwise the view polls every --refresh.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inFd, outFd := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+			if !term.IsTerminal(inFd) || !term.IsTerminal(outFd) {
+				return errors.New("tower top needs an interactive terminal; use 'moltbb tower list' or 'moltbb tower stats'")
+			}
+			if refresh < 2*time.Second {
+				return fmt.Errorf("--refresh must be at least 2s (got %s)", refresh)
+			}
+
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+			client, err := api.NewClient(cfg)
+			if err != nil {
+				return err
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			dash := tower.NewDashboard(lookupMyRoom(ctx, cfg, client))
+			dash.Color = !noColor && os.Getenv("NO_COLOR") == ""
+			return runTowerTop(ctx, cfg, client, dash, refresh, !noLive, inFd, outFd)
+		},
+	}
+
+	cmd.Flags().DurationVar(&refresh, "refresh", 15*time.Second, "Polling interval (slower while live updates arrive)")
+	cmd.Flags().BoolVar(&noColor, "no-color", false, "Disable colours (also honours NO_COLOR)")
+	cmd.Flags().BoolVar(&noLive, "no-live", false, "Do not subscribe to TowerHub push events; poll only")
+	return cmd
+}
+
+func runTowerTop(ctx context.Context, cfg config.Config, client *api.Client, dash *tower.Dashboard, refresh time.Duration, live bool, inFd, outFd int) error {
+	oldState, err := term.MakeRaw(inFd)
+	if err != nil {
+		return fmt.Errorf("enter raw mode: %w", err)
+	}
+	defer term.Restore(inFd, oldState)
+	// Alternate screen, hidden cursor; undone on the way out.
+	output.Print("\x1b[?1049h\x1b[?25l")
+	defer output.Print("\x1b[?25h\x1b[?1049l")
+
+	updates := make(chan towerUpdate, 64)
+	send := func(u towerUpdate) {
+		select {
+		case updates <- u:
+		case <-ctx.Done():
+		}
+	}
+
+	keys := make(chan []byte)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				return
+			}
+			chunk := append([]byte(nil), buf[:n]...)
+			select {
+			case keys <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	if live {
+		go subscribeTowerHub(ctx, client, send)
+	}
+
+	timeout := time.Duration(cfg.RequestTimeoutSeconds) * time.Second
+	fetching := false
+	var lastFetch time.Time
+	fetch := func() {
+		if fetching {
+			return
+		}
+		fetching, lastFetch = true, time.Now()
+		go func() {
+			reqCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			rooms, roomsErr := client.TowerGetAllRooms(reqCtx)
+			stats, statsErr := client.TowerGetStatistics(reqCtx)
+			send(func(d *tower.Dashboard) {
+				fetching = false
+				if err := errors.Join(roomsErr, statsErr); err != nil {
+					d.Err = firstLine(err.Error())
+				} else {
+					d.Err = ""
+				}
+				if roomsErr == nil {
+					d.SetRooms(rooms)
+					d.Updated = time.Now()
+				}
+				if statsErr == nil {
+					d.SetStats(stats)
+				}
+			})
+		}()
+	}
+	openDetail := func(code string) {
+		go func() {
+			reqCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			detail, err := client.TowerGetRoomDetail(reqCtx, code)
+			send(func(d *tower.Dashboard) {
+				if d.Selected() != code {
+					return
+				}
+				if err != nil {
+					d.Detail, d.DetailErr = nil, firstLine(err.Error())
+					return
+				}
+				d.Detail, d.DetailErr = &detail, ""
+			})
+		}()
+	}
+
+	poll := time.NewTicker(refresh)
+	defer poll.Stop()
+	// Redraw every second so "x min ago" stays current and resizes apply.
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+
+	fetch()
+	for {
+		drawTowerTop(dash, outFd)
+		select {
+		case <-ctx.Done():
+			return nil
+		case u := <-updates:
+			u(dash)
+		case <-poll.C:
+			// Push events keep a live view current; poll less often then.
+			if !dash.Live || time.Since(lastFetch) >= 4*refresh {
+				fetch()
+			}
+		case <-tick.C:
+		case chunk := <-keys:
+			for _, key := range tower.ParseKeys(chunk) {
+				switch key {
+				case tower.KeyQuit:
+					return nil
+				case tower.KeyUp:
+					dash.Move(0, 1)
+				case tower.KeyDown:
+					dash.Move(0, -1)
+				case tower.KeyLeft:
+					dash.Move(-1, 0)
+				case tower.KeyRight:
+					dash.Move(1, 0)
+				case tower.KeyMine:
+					dash.JumpToMine()
+				case tower.KeyRefresh:
+					fetch()
+				case tower.KeyEscape:
+					dash.Detail, dash.DetailErr = nil, ""
+					continue
+				case tower.KeyEnter:
+					if dash.Detail != nil || dash.DetailErr != "" {
+						dash.Detail, dash.DetailErr = nil, ""
+					} else if code := dash.Selected(); code != "" {
+						openDetail(code)
+					}
+					continue
+				}
+				// Moving closes the detail panel; it belongs to the old room.
+				if key != tower.KeyRefresh {
+					dash.Detail, dash.DetailErr = nil, ""
+				}
+			}
+		}
+	}
+}
+
+// subscribeTowerHub feeds TowerHub push events into the dashboard until
+// ctx ends or the connection drops; the view falls back to polling then.
+func subscribeTowerHub(ctx context.Context, client *api.Client, send func(towerUpdate)) {
+	token, err := auth.ResolveToken()
+	if err != nil {
+		return
+	}
+	sc, err := client.ConnectToHub(ctx, token)
+	if err != nil {
+		return
+	}
+	defer sc.Close()
+
+	sc.On(towerEventRoomUpdated, func(args []json.RawMessage) {
+		var room api.TowerRoomState
+		if len(args) == 0 || json.Unmarshal(args[0], &room) != nil {
+			return
+		}
+		send(func(d *tower.Dashboard) {
+			d.UpdateRoom(room)
+			d.Updated = time.Now()
+		})
+	})
+	sc.On(towerEventStatsUpdated, func(args []json.RawMessage) {
+		var stats api.TowerStatistics
+		if len(args) == 0 || json.Unmarshal(args[0], &stats) != nil {
+			return
+		}
+		send(func(d *tower.Dashboard) { d.SetStats(stats) })
+	})
+
+	joinCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	err = sc.InvokeVoid(joinCtx, "JoinTower")
+	cancel()
+	if err != nil {
+		// Older servers have no tower group to join: no push events.
+		return
+	}
+	send(func(d *tower.Dashboard) { d.Live = true })
+	select {
+	case <-ctx.Done():
+	case <-sc.Done():
+	}
+	send(func(d *tower.Dashboard) { d.Live = false })
+}
+
+// lookupMyRoom returns our room code, or "" when not logged in or not
+// checked in.
+func lookupMyRoom(ctx context.Context, cfg config.Config, client *api.Client) string {
+	apiKey, err := auth.ResolveAPIKey()
+	if err != nil {
+		return ""
+	}
+	reqCtx, cancel := context.WithTimeout(ctx, time.Duration(cfg.RequestTimeoutSeconds)*time.Second)
+	defer cancel()
+	room, err := client.TowerGetMyRoom(reqCtx, apiKey)
+	if err != nil {
+		return ""
+	}
+	return room.Code
+}
+
+func drawTowerTop(dash *tower.Dashboard, outFd int) {
+	width, height, err := term.GetSize(outFd)
+	if err != nil || width <= 0 || height <= 0 {
+		width, height = 80, 24
+	}
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	for i, line := range dash.Render(width, height) {
+		if i > 0 {
+			b.WriteString("\r\n")
+		}
+		b.WriteString(line)
+		b.WriteString("\x1b[K")
+	}
+	b.WriteString("\x1b[J")
+	os.Stdout.WriteString(b.String())
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -17,6 +17,8 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+
+	"moltbb-cli/internal/output"
 )
 
 const defaultReleaseRepo = "codyard/moltbb-cli"
@@ -56,11 +58,11 @@ func runSelfUpdate(targetVersion, repo string, force, stopService bool) error {
 
 	// Stop moltbb-local service if requested
 	if stopService {
-		fmt.Println("Stopping moltbb-local service...")
+		output.Println("Stopping moltbb-local service...")
 		if err := stopMoltbbService(); err != nil {
-			fmt.Printf("Warning: failed to stop service: %v\n", err)
+			output.Printf("Warning: failed to stop service: %v\n", err)
 		} else {
-			fmt.Println("Service stopped.")
+			output.Println("Service stopped.")
 		}
 	}
 
@@ -70,7 +72,7 @@ func runSelfUpdate(targetVersion, repo string, force, stopService bool) error {
 	}
 
 	if !force && tag == version {
-		fmt.Printf("Already on %s. Use --force to reinstall.\n", version)
+		output.Printf("Already on %s. Use --force to reinstall.\n", version)
 		return nil
 	}
 
@@ -80,7 +82,7 @@ func runSelfUpdate(targetVersion, repo string, force, stopService bool) error {
 	}
 
 	downloadURL := fmt.Sprintf("https://github.com/%s/releases/download/%s/%s", repo, tag, assetName)
-	fmt.Printf("Downloading %s\n", downloadURL)
+	output.Printf("Downloading %s\n", downloadURL)
 
 	tmpDir, err := os.MkdirTemp("", "moltbb-update-*")
 	if err != nil {
@@ -109,8 +111,8 @@ func runSelfUpdate(targetVersion, repo string, force, stopService bool) error {
 		if err := copyFile(extractedBinary, pendingPath, 0o755); err != nil {
 			return err
 		}
-		fmt.Printf("Downloaded update to %s\n", pendingPath)
-		fmt.Println("Windows cannot replace a running executable in-place. Close current process and replace manually.")
+		output.Printf("Downloaded update to %s\n", pendingPath)
+		output.Println("Windows cannot replace a running executable in-place. Close current process and replace manually.")
 		return nil
 	}
 
@@ -123,16 +125,16 @@ func runSelfUpdate(targetVersion, repo string, force, stopService bool) error {
 		return fmt.Errorf("replace binary failed (try with proper permission): %w", err)
 	}
 
-	fmt.Printf("Updated successfully to %s\n", tag)
-	fmt.Printf("Binary path: %s\n", execPath)
+	output.Printf("Updated successfully to %s\n", tag)
+	output.Printf("Binary path: %s\n", execPath)
 
 	// Restart moltbb-local service if requested
 	if stopService {
-		fmt.Println("Starting moltbb-local service...")
+		output.Println("Starting moltbb-local service...")
 		if err := startMoltbbService(); err != nil {
-			fmt.Printf("Warning: failed to start service: %v\n", err)
+			output.Printf("Warning: failed to start service: %v\n", err)
 		} else {
-			fmt.Println("Service started.")
+			output.Println("Service started.")
 		}
 	}
 
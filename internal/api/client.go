@@ -3,7 +3,6 @@ package api
 import (
 	"bytes"
 	"context"
-	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,19 +10,17 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
 	"moltbb-cli/internal/config"
-
-	_ "modernc.org/sqlite"
 )
 
 type Client struct {
-	baseURL    string
-	httpClient *http.Client
-	retryCount int
+	baseURL     string
+	httpClient  *http.Client
+	retryCount  int
+	hubProtocol string
 }
 
 type envelope struct {
@@ -149,12 +146,12 @@ func (c *Client) GetInboxComments(ctx context.Context, apiKey string, unreadOnly
 		q += "&entityType=" + entityType
 	}
 
-	body, status, err := c.doRequestWithAPIKey(ctx, http.MethodGet, q, apiKey, nil)
+	body, status, header, err := c.doRequestWithAPIKey(ctx, http.MethodGet, q, apiKey, nil)
 	if err != nil {
 		return InboxCommentsResult{}, err
 	}
 	if status < 200 || status >= 300 {
-		return InboxCommentsResult{}, fmt.Errorf("fetch comments failed with status %d: %s", status, string(body))
+		return InboxCommentsResult{}, newError("fetch comments", status, header, body)
 	}
 
 	var result InboxCommentsResult
@@ -170,12 +167,12 @@ func (c *Client) ReplyToComment(ctx context.Context, apiKey, commentID, content
 		"entityId":   commentID,
 		"content":    content,
 	}
-	body, status, err := c.doJSONWithAPIKey(ctx, http.MethodPost, "/api/v1/runtime/comments", apiKey, payload)
+	body, status, header, err := c.doJSONWithAPIKey(ctx, http.MethodPost, "/api/v1/runtime/comments", apiKey, payload)
 	if err != nil {
 		return InboxComment{}, false, err
 	}
 	if status < 200 || status >= 300 {
-		return InboxComment{}, false, fmt.Errorf("reply failed with status %d: %s", status, string(body))
+		return InboxComment{}, false, newError("reply", status, header, body)
 	}
 
 	var wrapped struct {
@@ -189,11 +186,11 @@ func (c *Client) ReplyToComment(ctx context.Context, apiKey, commentID, content
 }
 
 type RuntimeInsightListResult struct {
-	Items      []RuntimeInsight
-	Page       int
-	PageSize   int
-	TotalCount int
-	TotalPages int
+	Items      []RuntimeInsight `json:"items"`
+	Page       int              `json:"page"`
+	PageSize   int              `json:"pageSize"`
+	TotalCount int              `json:"totalCount"`
+	TotalPages int              `json:"totalPages"`
 }
 
 func NewClient(cfg config.Config) (*Client, error) {
@@ -213,7 +210,8 @@ func NewClient(cfg config.Config) (*Client, error) {
 		httpClient: &http.Client{
 			Timeout: time.Duration(cfg.RequestTimeoutSeconds) * time.Second,
 		},
-		retryCount: cfg.RetryCount,
+		retryCount:  cfg.RetryCount,
+		hubProtocol: cfg.HubProtocol,
 	}, nil
 }
 
@@ -238,7 +236,7 @@ func (c *Client) Ping(ctx context.Context) error {
 
 func (c *Client) ValidateAPIKey(ctx context.Context, apiKey string) (ValidateResponse, error) {
 	payload := map[string]string{"api_key": apiKey}
-	body, status, err := c.doJSONWithAPIKey(ctx, http.MethodPost, "/api/v1/auth/validate", apiKey, payload)
+	body, status, header, err := c.doJSONWithAPIKey(ctx, http.MethodPost, "/api/v1/auth/validate", apiKey, payload)
 	if err != nil {
 		return ValidateResponse{}, err
 	}
@@ -246,23 +244,23 @@ func (c *Client) ValidateAPIKey(ctx context.Context, apiKey string) (ValidateRes
 	// Some deployments removed /auth/validate. Fallback to other auth-protected endpoints.
 	if status == http.StatusNotFound || status == http.StatusMethodNotAllowed {
 		// 1) MoltBB runtime insights (primary for moltbb backend)
-		body, status, err = c.doJSONWithAPIKey(ctx, http.MethodGet, "/api/v1/runtime/insights", apiKey, nil)
+		body, status, header, err = c.doJSONWithAPIKey(ctx, http.MethodGet, "/api/v1/runtime/insights", apiKey, nil)
 		if err == nil && status >= 200 && status < 300 {
 			return ValidateResponse{Valid: true}, nil
 		}
 		// 2) Moltbook agents/me (legacy for moltbook backend)
-		body, status, err = c.doJSONWithAPIKey(ctx, http.MethodGet, "/api/v1/agents/me", apiKey, nil)
+		body, status, header, err = c.doJSONWithAPIKey(ctx, http.MethodGet, "/api/v1/agents/me", apiKey, nil)
 		if err != nil {
 			return ValidateResponse{}, err
 		}
 		if status >= 200 && status < 300 {
 			return ValidateResponse{Valid: true}, nil
 		}
-		return ValidateResponse{}, fmt.Errorf("validate failed with status %d: %s", status, string(body))
+		return ValidateResponse{}, newError("validate", status, header, body)
 	}
 
 	if status < 200 || status >= 300 {
-		return ValidateResponse{}, fmt.Errorf("validate failed with status %d: %s", status, string(body))
+		return ValidateResponse{}, newError("validate", status, header, body)
 	}
 
 	var env envelope
@@ -288,7 +286,7 @@ func (c *Client) ValidateAPIKey(ctx context.Context, apiKey string) (ValidateRes
 }
 
 func (c *Client) BindBot(ctx context.Context, apiKey string, req BindRequest) (BindResponse, error) {
-	body, status, err := c.doJSONWithAPIKey(ctx, http.MethodPost, "/api/v1/bot/bind", apiKey, req)
+	body, status, header, err := c.doJSONWithAPIKey(ctx, http.MethodPost, "/api/v1/bot/bind", apiKey, req)
 	if err != nil {
 		return BindResponse{}, err
 	}
@@ -298,7 +296,7 @@ func (c *Client) BindBot(ctx context.Context, apiKey string, req BindRequest) (B
 	}
 
 	// Some deployments accept only API key on this endpoint.
-	bodyNoPayload, statusNoPayload, errNoPayload := c.doJSONWithAPIKey(ctx, http.MethodPost, "/api/v1/bot/bind", apiKey, map[string]any{})
+	bodyNoPayload, statusNoPayload, headerNoPayload, errNoPayload := c.doJSONWithAPIKey(ctx, http.MethodPost, "/api/v1/bot/bind", apiKey, map[string]any{})
 	if errNoPayload == nil && statusNoPayload >= 200 && statusNoPayload < 300 {
 		return decodeBindResponse(bodyNoPayload)
 	}
@@ -308,22 +306,22 @@ func (c *Client) BindBot(ctx context.Context, apiKey string, req BindRequest) (B
 
 	if legacyFallback && (status == http.StatusNotFound || statusNoPayload == http.StatusNotFound) {
 		// Compatibility with runtime API currently available on backend.
-		body, status, err = c.doJSONWithAPIKey(ctx, http.MethodPost, "/api/v1/runtime/activate", apiKey, req)
+		body, status, header, err = c.doJSONWithAPIKey(ctx, http.MethodPost, "/api/v1/runtime/activate", apiKey, req)
 		if err != nil {
 			return BindResponse{}, err
 		}
 	}
 	if status < 200 || status >= 300 {
 		if errNoPayload != nil {
-			return BindResponse{}, fmt.Errorf("bind failed with status %d: %s (no-payload retry error: %v)", status, string(body), errNoPayload)
+			return BindResponse{}, fmt.Errorf("%w (no-payload retry error: %v)", newError("bind", status, header, body), errNoPayload)
 		}
 		if statusNoPayload >= 200 && statusNoPayload < 300 {
 			return decodeBindResponse(bodyNoPayload)
 		}
 		if statusNoPayload >= 300 {
-			return BindResponse{}, fmt.Errorf("bind failed with status %d: %s (no-payload retry status %d: %s)", status, string(body), statusNoPayload, string(bodyNoPayload))
+			return BindResponse{}, fmt.Errorf("%w (no-payload retry: %v)", newError("bind", status, header, body), newError("bind", statusNoPayload, headerNoPayload, bodyNoPayload))
 		}
-		return BindResponse{}, fmt.Errorf("bind failed with status %d: %s", status, string(body))
+		return BindResponse{}, newError("bind", status, header, body)
 	}
 
 	return decodeBindResponse(body)
@@ -389,11 +387,6 @@ func (c *Client) UpsertRuntimeDiary(ctx context.Context, apiKey string, payload
 		return RuntimeDiaryUpsertResult{}, errors.New("summary is required")
 	}
 
-	// Save to local database first
-	if err := saveToLocalDB(diaryDate, payload.Summary); err != nil {
-		fmt.Printf("⚠️  Warning: failed to save to local DB: %v\n", err)
-	}
-
 	existingID, err := c.findRuntimeDiaryIDByDate(ctx, apiKey, diaryDate)
 	if err != nil {
 		return RuntimeDiaryUpsertResult{}, err
@@ -410,12 +403,12 @@ func (c *Client) UpsertRuntimeDiary(ctx context.Context, apiKey string, payload
 		}, nil
 	}
 
-	body, status, err := c.doJSONWithAPIKey(ctx, http.MethodPost, "/api/v1/runtime/diaries", apiKey, payload)
+	body, status, header, err := c.doJSONWithAPIKey(ctx, http.MethodPost, "/api/v1/runtime/diaries", apiKey, payload)
 	if err != nil {
 		return RuntimeDiaryUpsertResult{}, err
 	}
 	if status < 200 || status >= 300 {
-		return RuntimeDiaryUpsertResult{}, fmt.Errorf("upload diary failed with status %d: %s", status, string(body))
+		return RuntimeDiaryUpsertResult{}, newError("upload diary", status, header, body)
 	}
 
 	if diaryID, ok := parseCreatedDiaryID(body); ok {
@@ -462,12 +455,12 @@ func (c *Client) PatchRuntimeDiary(ctx context.Context, apiKey, diaryID string,
 		return errors.New("at least one field is required for diary patch")
 	}
 
-	body, status, err := c.doJSONWithAPIKey(ctx, http.MethodPatch, "/api/v1/runtime/diaries/"+id, apiKey, payload)
+	body, status, header, err := c.doJSONWithAPIKey(ctx, http.MethodPatch, "/api/v1/runtime/diaries/"+id, apiKey, payload)
 	if err != nil {
 		return err
 	}
 	if status < 200 || status >= 300 {
-		return fmt.Errorf("patch diary failed with status %d: %s", status, string(body))
+		return newError("patch diary", status, header, body)
 	}
 	return nil
 }
@@ -479,12 +472,12 @@ func (c *Client) CreateRuntimeInsight(ctx context.Context, apiKey string, payloa
 	if strings.TrimSpace(payload.Content) == "" {
 		return RuntimeInsight{}, errors.New("content is required")
 	}
-	body, status, err := c.doJSONWithAPIKey(ctx, http.MethodPost, "/api/v1/runtime/insights", apiKey, payload)
+	body, status, header, err := c.doJSONWithAPIKey(ctx, http.MethodPost, "/api/v1/runtime/insights", apiKey, payload)
 	if err != nil {
 		return RuntimeInsight{}, err
 	}
 	if status < 200 || status >= 300 {
-		return RuntimeInsight{}, fmt.Errorf("upload insight failed with status %d: %s", status, string(body))
+		return RuntimeInsight{}, newError("upload insight", status, header, body)
 	}
 	insight, err := decodeInsightResponse(body)
 	if err != nil {
@@ -504,12 +497,12 @@ func (c *Client) UpdateRuntimeInsight(ctx context.Context, apiKey, insightID str
 		return RuntimeInsight{}, errors.New("at least one field is required for insight update")
 	}
 
-	body, status, err := c.doJSONWithAPIKey(ctx, http.MethodPatch, "/api/v1/runtime/insights/"+id, apiKey, payload)
+	body, status, header, err := c.doJSONWithAPIKey(ctx, http.MethodPatch, "/api/v1/runtime/insights/"+id, apiKey, payload)
 	if err != nil {
 		return RuntimeInsight{}, err
 	}
 	if status < 200 || status >= 300 {
-		return RuntimeInsight{}, fmt.Errorf("update insight failed with status %d: %s", status, string(body))
+		return RuntimeInsight{}, newError("update insight", status, header, body)
 	}
 	insight, err := decodeInsightResponse(body)
 	if err != nil {
@@ -523,12 +516,12 @@ func (c *Client) DeleteRuntimeInsight(ctx context.Context, apiKey, insightID str
 	if id == "" {
 		return errors.New("insight id is required")
 	}
-	body, status, err := c.doRequestWithAPIKey(ctx, http.MethodDelete, "/api/v1/runtime/insights/"+id, apiKey, nil)
+	body, status, header, err := c.doRequestWithAPIKey(ctx, http.MethodDelete, "/api/v1/runtime/insights/"+id, apiKey, nil)
 	if err != nil {
 		return err
 	}
 	if status < 200 || status >= 300 {
-		return fmt.Errorf("delete insight failed with status %d: %s", status, string(body))
+		return newError("delete insight", status, header, body)
 	}
 	return nil
 }
@@ -538,12 +531,12 @@ func (c *Client) DeleteRuntimeDiary(ctx context.Context, apiKey, diaryID string)
 	if id == "" {
 		return errors.New("diary id is required")
 	}
-	body, status, err := c.doRequestWithAPIKey(ctx, http.MethodDelete, "/api/v1/runtime/diaries/"+id, apiKey, nil)
+	body, status, header, err := c.doRequestWithAPIKey(ctx, http.MethodDelete, "/api/v1/runtime/diaries/"+id, apiKey, nil)
 	if err != nil {
 		return err
 	}
 	if status < 200 || status >= 300 {
-		return fmt.Errorf("delete diary failed with status %d: %s", status, string(body))
+		return newError("delete diary", status, header, body)
 	}
 	return nil
 }
@@ -577,12 +570,12 @@ func (c *Client) ListRuntimeInsights(
 		path += "?" + encoded
 	}
 
-	body, status, err := c.doRequestWithAPIKey(ctx, http.MethodGet, path, apiKey, nil)
+	body, status, header, err := c.doRequestWithAPIKey(ctx, http.MethodGet, path, apiKey, nil)
 	if err != nil {
 		return RuntimeInsightListResult{}, err
 	}
 	if status < 200 || status >= 300 {
-		return RuntimeInsightListResult{}, fmt.Errorf("list insights failed with status %d: %s", status, string(body))
+		return RuntimeInsightListResult{}, newError("list insights", status, header, body)
 	}
 
 	var raw struct {
@@ -625,12 +618,12 @@ func (c *Client) ListRuntimeDiaries(ctx context.Context, apiKey, startDate, endD
 	query.Set("page", fmt.Sprintf("%d", page))
 	query.Set("pageSize", fmt.Sprintf("%d", pageSize))
 
-	body, status, err := c.doRequestWithAPIKey(ctx, http.MethodGet, "/api/v1/runtime/diaries?"+query.Encode(), apiKey, nil)
+	body, status, header, err := c.doRequestWithAPIKey(ctx, http.MethodGet, "/api/v1/runtime/diaries?"+query.Encode(), apiKey, nil)
 	if err != nil {
 		return RuntimeDiaryListResult{}, err
 	}
 	if status < 200 || status >= 300 {
-		return RuntimeDiaryListResult{}, fmt.Errorf("list runtime diaries failed with status %d: %s", status, string(body))
+		return RuntimeDiaryListResult{}, newError("list runtime diaries", status, header, body)
 	}
 
 	var raw struct {
@@ -677,12 +670,12 @@ func (c *Client) findRuntimeDiaryIDByDate(ctx context.Context, apiKey, diaryDate
 	query.Set("page", "1")
 	query.Set("pageSize", "1")
 
-	body, status, err := c.doRequestWithAPIKey(ctx, http.MethodGet, "/api/v1/runtime/diaries?"+query.Encode(), apiKey, nil)
+	body, status, header, err := c.doRequestWithAPIKey(ctx, http.MethodGet, "/api/v1/runtime/diaries?"+query.Encode(), apiKey, nil)
 	if err != nil {
 		return "", err
 	}
 	if status < 200 || status >= 300 {
-		return "", fmt.Errorf("query runtime diaries failed with status %d: %s", status, string(body))
+		return "", newError("query runtime diaries", status, header, body)
 	}
 
 	return parseFirstDiaryID(body), nil
@@ -693,12 +686,12 @@ func (c *Client) patchRuntimeDiary(ctx context.Context, apiKey, diaryID, summary
 		"summary":     summary,
 		"personaText": personaText,
 	}
-	body, status, err := c.doJSONWithAPIKey(ctx, http.MethodPatch, "/api/v1/runtime/diaries/"+diaryID, apiKey, payload)
+	body, status, header, err := c.doJSONWithAPIKey(ctx, http.MethodPatch, "/api/v1/runtime/diaries/"+diaryID, apiKey, payload)
 	if err != nil {
 		return 0, err
 	}
 	if status < 200 || status >= 300 {
-		return status, fmt.Errorf("patch diary failed with status %d: %s", status, string(body))
+		return status, newError("patch diary", status, header, body)
 	}
 	return status, nil
 }
@@ -821,17 +814,20 @@ func decodeInsightResponse(body []byte) (RuntimeInsight, error) {
 	return insight, json.Unmarshal(env.Data, &insight)
 }
 
-func (c *Client) doJSONWithAPIKey(ctx context.Context, method, path, apiKey string, payload any) ([]byte, int, error) {
+func (c *Client) doJSONWithAPIKey(ctx context.Context, method, path, apiKey string, payload any) ([]byte, int, http.Header, error) {
 	return c.doRequestWithAPIKey(ctx, method, path, apiKey, payload)
 }
 
-func (c *Client) doRequestWithAPIKey(ctx context.Context, method, path, apiKey string, payload any) ([]byte, int, error) {
+// doRequestWithAPIKey sends a request, retrying network errors and 5xx
+// responses. It returns the body, status and headers of the last response;
+// non-2xx statuses are left to the caller.
+func (c *Client) doRequestWithAPIKey(ctx context.Context, method, path, apiKey string, payload any) ([]byte, int, http.Header, error) {
 	var data []byte
 	var err error
 	if payload != nil {
 		data, err = json.Marshal(payload)
 		if err != nil {
-			return nil, 0, fmt.Errorf("marshal request: %w", err)
+			return nil, 0, nil, fmt.Errorf("marshal request: %w", err)
 		}
 	}
 
@@ -849,7 +845,7 @@ func (c *Client) doRequestWithAPIKey(ctx context.Context, method, path, apiKey s
 
 		req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bodyReader)
 		if err != nil {
-			return nil, 0, err
+			return nil, 0, nil, err
 		}
 		req.Header.Set("Accept", "application/json")
 		if payload != nil {
@@ -869,9 +865,9 @@ func (c *Client) doRequestWithAPIKey(ctx context.Context, method, path, apiKey s
 			if readErr != nil {
 				lastErr = readErr
 			} else if resp.StatusCode >= 500 && attempt < maxAttempts {
-				lastErr = fmt.Errorf("server returned %d", resp.StatusCode)
+				lastErr = newError(method+" "+path, resp.StatusCode, resp.Header, body)
 			} else {
-				return body, resp.StatusCode, nil
+				return body, resp.StatusCode, resp.Header, nil
 			}
 		}
 
@@ -880,7 +876,7 @@ func (c *Client) doRequestWithAPIKey(ctx context.Context, method, path, apiKey s
 		}
 	}
 
-	return nil, 0, fmt.Errorf("request failed after retries: %w", lastErr)
+	return nil, 0, nil, fmt.Errorf("request failed after retries: %w", lastErr)
 }
 
 // Tower API types
@@ -941,12 +937,12 @@ func (c *Client) TowerCheckin(ctx context.Context, apiKey string, roomCode strin
 		payload = map[string]string{"roomCode": roomCode}
 	}
 
-	body, status, err := c.doJSONWithAPIKey(ctx, http.MethodPost, "/api/v1/tower/checkin", apiKey, payload)
+	body, status, header, err := c.doJSONWithAPIKey(ctx, http.MethodPost, "/api/v1/tower/checkin", apiKey, payload)
 	if err != nil {
 		return TowerCheckinResponse{}, err
 	}
 	if status < 200 || status >= 300 {
-		return TowerCheckinResponse{}, fmt.Errorf("tower checkin failed with status %d: %s", status, string(body))
+		return TowerCheckinResponse{}, newError("tower checkin", status, header, body)
 	}
 	var resp TowerCheckinResponse
 	if err := decodeEnvelopeData(body, &resp); err != nil {
@@ -961,12 +957,12 @@ func (c *Client) TowerSendHeartbeat(ctx context.Context, apiKey, roomCode string
 	if statusMessage != nil {
 		payload["statusMessage"] = *statusMessage
 	}
-	body, status, err := c.doJSONWithAPIKey(ctx, http.MethodPost, "/api/v1/tower/heartbeat", apiKey, payload)
+	body, status, header, err := c.doJSONWithAPIKey(ctx, http.MethodPost, "/api/v1/tower/heartbeat", apiKey, payload)
 	if err != nil {
 		return TowerHeartbeatResponse{}, err
 	}
 	if status < 200 || status >= 300 {
-		return TowerHeartbeatResponse{}, fmt.Errorf("tower heartbeat failed with status %d: %s", status, string(body))
+		return TowerHeartbeatResponse{}, newError("tower heartbeat", status, header, body)
 	}
 	var resp TowerHeartbeatResponse
 	if err := decodeEnvelopeData(body, &resp); err != nil {
@@ -977,12 +973,12 @@ func (c *Client) TowerSendHeartbeat(ctx context.Context, apiKey, roomCode string
 
 // TowerGetMyRoom returns the authenticated bot's current room assignment
 func (c *Client) TowerGetMyRoom(ctx context.Context, apiKey string) (TowerRoomState, error) {
-	body, status, err := c.doRequestWithAPIKey(ctx, http.MethodGet, "/api/v1/tower/my-room", apiKey, nil)
+	body, status, header, err := c.doRequestWithAPIKey(ctx, http.MethodGet, "/api/v1/tower/my-room", apiKey, nil)
 	if err != nil {
 		return TowerRoomState{}, err
 	}
 	if status < 200 || status >= 300 {
-		return TowerRoomState{}, fmt.Errorf("tower get my room failed with status %d: %s", status, string(body))
+		return TowerRoomState{}, newError("tower get my room", status, header, body)
 	}
 	var resp TowerRoomState
 	if err := decodeEnvelopeData(body, &resp); err != nil {
@@ -993,12 +989,12 @@ func (c *Client) TowerGetMyRoom(ctx context.Context, apiKey string) (TowerRoomSt
 
 // TowerGetAllRooms returns all tower rooms with their current state
 func (c *Client) TowerGetAllRooms(ctx context.Context) ([]TowerRoomState, error) {
-	body, status, err := c.doRequestWithAPIKey(ctx, http.MethodGet, "/api/v1/tower", "", nil)
+	body, status, header, err := c.doRequestWithAPIKey(ctx, http.MethodGet, "/api/v1/tower", "", nil)
 	if err != nil {
 		return nil, err
 	}
 	if status < 200 || status >= 300 {
-		return nil, fmt.Errorf("tower get all rooms failed with status %d: %s", status, string(body))
+		return nil, newError("tower get all rooms", status, header, body)
 	}
 
 	// Handle nested structure: { success: true, data: { rooms: [...] } }
@@ -1022,12 +1018,12 @@ func (c *Client) TowerGetAllRooms(ctx context.Context) ([]TowerRoomState, error)
 
 // TowerGetStatistics returns tower-wide statistics
 func (c *Client) TowerGetStatistics(ctx context.Context) (TowerStatistics, error) {
-	body, status, err := c.doRequestWithAPIKey(ctx, http.MethodGet, "/api/v1/tower/stats", "", nil)
+	body, status, header, err := c.doRequestWithAPIKey(ctx, http.MethodGet, "/api/v1/tower/stats", "", nil)
 	if err != nil {
 		return TowerStatistics{}, err
 	}
 	if status < 200 || status >= 300 {
-		return TowerStatistics{}, fmt.Errorf("tower get statistics failed with status %d: %s", status, string(body))
+		return TowerStatistics{}, newError("tower get statistics", status, header, body)
 	}
 	var resp TowerStatistics
 	if err := decodeEnvelopeData(body, &resp); err != nil {
@@ -1039,12 +1035,12 @@ func (c *Client) TowerGetStatistics(ctx context.Context) (TowerStatistics, error
 // TowerGetRoomDetail returns detailed information about a specific room
 func (c *Client) TowerGetRoomDetail(ctx context.Context, roomCode string) (TowerRoomDetail, error) {
 	path := "/api/v1/tower/room/" + strings.TrimSpace(roomCode)
-	body, status, err := c.doRequestWithAPIKey(ctx, http.MethodGet, path, "", nil)
+	body, status, header, err := c.doRequestWithAPIKey(ctx, http.MethodGet, path, "", nil)
 	if err != nil {
 		return TowerRoomDetail{}, err
 	}
 	if status < 200 || status >= 300 {
-		return TowerRoomDetail{}, fmt.Errorf("tower get room detail failed with status %d: %s", status, string(body))
+		return TowerRoomDetail{}, newError("tower get room detail", status, header, body)
 	}
 	var resp TowerRoomDetail
 	if err := decodeEnvelopeData(body, &resp); err != nil {
@@ -1071,10 +1067,10 @@ type BotMessage struct {
 }
 
 type BotMessageListResult struct {
-	Items      []BotMessage
-	Page       int
-	PageSize   int
-	TotalCount int
+	Items      []BotMessage `json:"items"`
+	Page       int          `json:"page"`
+	PageSize   int          `json:"pageSize"`
+	TotalCount int          `json:"totalCount"`
 }
 
 type BotMessageSendResult struct {
@@ -1106,12 +1102,12 @@ func (c *Client) SendMessageByBotName(ctx context.Context, apiKey, toBotName, ti
 		"title":     title,
 		"content":   content,
 	}
-	body, httpStatus, err := c.doJSONWithAPIKey(ctx, http.MethodPost, "/api/v1/messages/send", apiKey, payload)
+	body, httpStatus, header, err := c.doJSONWithAPIKey(ctx, http.MethodPost, "/api/v1/messages/send", apiKey, payload)
 	if err != nil {
 		return BotMessageSendResult{}, err
 	}
 	if httpStatus < 200 || httpStatus >= 300 {
-		return BotMessageSendResult{}, fmt.Errorf("send message failed with status %d: %s", httpStatus, string(body))
+		return BotMessageSendResult{}, newError("send message", httpStatus, header, body)
 	}
 
 	var resp BotMessageSendResult
@@ -1137,12 +1133,12 @@ func (c *Client) ListMessages(ctx context.Context, apiKey string, status, page,
 	if enc := query.Encode(); enc != "" {
 		path += "?" + enc
 	}
-	body, httpStatus, err := c.doRequestWithAPIKey(ctx, http.MethodGet, path, apiKey, nil)
+	body, httpStatus, header, err := c.doRequestWithAPIKey(ctx, http.MethodGet, path, apiKey, nil)
 	if err != nil {
 		return BotMessageListResult{}, err
 	}
 	if httpStatus < 200 || httpStatus >= 300 {
-		return BotMessageListResult{}, fmt.Errorf("list messages failed with status %d: %s", httpStatus, string(body))
+		return BotMessageListResult{}, newError("list messages", httpStatus, header, body)
 	}
 
 	var raw struct {
@@ -1171,12 +1167,12 @@ func (c *Client) GetMessage(ctx context.Context, apiKey, messageID string) (BotM
 	if id == "" {
 		return BotMessage{}, fmt.Errorf("message id is required")
 	}
-	body, httpStatus, err := c.doRequestWithAPIKey(ctx, http.MethodGet, "/api/v1/messages/"+id, apiKey, nil)
+	body, httpStatus, header, err := c.doRequestWithAPIKey(ctx, http.MethodGet, "/api/v1/messages/"+id, apiKey, nil)
 	if err != nil {
 		return BotMessage{}, err
 	}
 	if httpStatus < 200 || httpStatus >= 300 {
-		return BotMessage{}, fmt.Errorf("get message failed with status %d: %s", httpStatus, string(body))
+		return BotMessage{}, newError("get message", httpStatus, header, body)
 	}
 	var msg BotMessage
 	if err := decodeEnvelopeData(body, &msg); err != nil {
@@ -1191,24 +1187,24 @@ func (c *Client) DeleteMessage(ctx context.Context, apiKey, messageID string) er
 	if id == "" {
 		return fmt.Errorf("message id is required")
 	}
-	body, httpStatus, err := c.doRequestWithAPIKey(ctx, http.MethodDelete, "/api/v1/messages/"+id, apiKey, nil)
+	body, httpStatus, header, err := c.doRequestWithAPIKey(ctx, http.MethodDelete, "/api/v1/messages/"+id, apiKey, nil)
 	if err != nil {
 		return err
 	}
 	if httpStatus < 200 || httpStatus >= 300 {
-		return fmt.Errorf("delete message failed with status %d: %s", httpStatus, string(body))
+		return newError("delete message", httpStatus, header, body)
 	}
 	return nil
 }
 
 // GetUnreadCount returns the number of unread messages for the bot.
 func (c *Client) GetUnreadCount(ctx context.Context, apiKey string) (int, error) {
-	body, httpStatus, err := c.doRequestWithAPIKey(ctx, http.MethodGet, "/api/v1/messages/unread-count", apiKey, nil)
+	body, httpStatus, header, err := c.doRequestWithAPIKey(ctx, http.MethodGet, "/api/v1/messages/unread-count", apiKey, nil)
 	if err != nil {
 		return 0, err
 	}
 	if httpStatus < 200 || httpStatus >= 300 {
-		return 0, fmt.Errorf("unread count failed with status %d: %s", httpStatus, string(body))
+		return 0, newError("unread count", httpStatus, header, body)
 	}
 	var raw struct {
 		Data struct {
@@ -1291,10 +1287,10 @@ type PipelineSessionMetadata struct {
 
 // PipelineSessionListResult holds a paginated list of session metadata.
 type PipelineSessionListResult struct {
-	Items      []PipelineSessionMetadata
-	Page       int
-	PageSize   int
-	TotalCount int
+	Items      []PipelineSessionMetadata `json:"items"`
+	Page       int                       `json:"page"`
+	PageSize   int                       `json:"pageSize"`
+	TotalCount int                       `json:"totalCount"`
 }
 
 // PipelineConnectionStatus describes a bot's current pipeline connection.
@@ -1322,12 +1318,12 @@ type BotTokenResponse struct {
 // PipelineGetBotToken exchanges the plain API key for a signed bot JWT.
 // The API key is sent as X-API-Key; no existing JWT is required.
 func (c *Client) PipelineGetBotToken(ctx context.Context, apiKey string) (BotTokenResponse, error) {
-	body, status, err := c.doRequestWithAPIKey(ctx, "POST", "/api/v1/pipeline/token", apiKey, map[string]any{})
+	body, status, header, err := c.doRequestWithAPIKey(ctx, "POST", "/api/v1/pipeline/token", apiKey, map[string]any{})
 	if err != nil {
 		return BotTokenResponse{}, err
 	}
 	if status < 200 || status >= 300 {
-		return BotTokenResponse{}, fmt.Errorf("get bot token failed (%d): %s", status, string(body))
+		return BotTokenResponse{}, newError("get bot token", status, header, body)
 	}
 	var resp BotTokenResponse
 	if err := json.Unmarshal(body, &resp); err != nil {
@@ -1343,12 +1339,12 @@ func (c *Client) PipelineGetSessionHistory(ctx context.Context, apiKey string, p
 	q.Set("pageSize", fmt.Sprintf("%d", pageSize))
 	path := "/api/v1/pipeline/sessions/history?" + q.Encode()
 
-	body, status, err := c.doRequestWithAPIKey(ctx, "GET", path, apiKey, nil)
+	body, status, header, err := c.doRequestWithAPIKey(ctx, "GET", path, apiKey, nil)
 	if err != nil {
 		return PipelineSessionListResult{}, err
 	}
 	if status < 200 || status >= 300 {
-		return PipelineSessionListResult{}, fmt.Errorf("pipeline history failed (%d): %s", status, string(body))
+		return PipelineSessionListResult{}, newError("pipeline history", status, header, body)
 	}
 
 	var raw struct {
@@ -1374,7 +1370,7 @@ func (c *Client) PipelineGetSessionHistory(ctx context.Context, apiKey string, p
 // PipelineGetSession returns a single session by its token.
 func (c *Client) PipelineGetSession(ctx context.Context, apiKey string, sessionToken string) (*PipelineSessionResponse, error) {
 	path := "/api/v1/pipeline/sessions/" + strings.TrimSpace(sessionToken)
-	body, status, err := c.doRequestWithAPIKey(ctx, "GET", path, apiKey, nil)
+	body, status, header, err := c.doRequestWithAPIKey(ctx, "GET", path, apiKey, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1382,7 +1378,7 @@ func (c *Client) PipelineGetSession(ctx context.Context, apiKey string, sessionT
 		return nil, fmt.Errorf("session not found")
 	}
 	if status < 200 || status >= 300 {
-		return nil, fmt.Errorf("get session failed (%d): %s", status, string(body))
+		return nil, newError("get session", status, header, body)
 	}
 	var resp PipelineSessionResponse
 	if err := decodeEnvelopeData(body, &resp); err != nil {
@@ -1394,12 +1390,12 @@ func (c *Client) PipelineGetSession(ctx context.Context, apiKey string, sessionT
 // PipelineGetConnectionStatus returns a bot's current pipeline connection status.
 func (c *Client) PipelineGetConnectionStatus(ctx context.Context, apiKey string, botId string) (*PipelineConnectionStatus, error) {
 	path := "/api/v1/pipeline/connections/" + strings.TrimSpace(botId) + "/status"
-	body, status, err := c.doRequestWithAPIKey(ctx, "GET", path, apiKey, nil)
+	body, status, header, err := c.doRequestWithAPIKey(ctx, "GET", path, apiKey, nil)
 	if err != nil {
 		return nil, err
 	}
 	if status < 200 || status >= 300 {
-		return nil, fmt.Errorf("get connection status failed (%d): %s", status, string(body))
+		return nil, newError("get connection status", status, header, body)
 	}
 	var resp PipelineConnectionStatus
 	if err := decodeEnvelopeData(body, &resp); err != nil {
@@ -1599,6 +1595,19 @@ func (c *Client) roomConnect(ctx context.Context, apiKey string) (*SignalRConn,
 	return sc, nil
 }
 
+// HubInvoker is a hub connection that can invoke server methods; both
+// *SignalRConn and *ReconnectingConn are one.
+type HubInvoker interface {
+	Invoke(ctx context.Context, target string, args ...any) (json.RawMessage, error)
+}
+
+// RoomHub runs the room hub methods on a connection the caller keeps open,
+// which must have joined the pipeline group. The Client.Room* methods use
+// it over a one-shot connection.
+type RoomHub struct {
+	Conn HubInvoker
+}
+
 // RoomCreate creates a new room and returns its code.
 func (c *Client) RoomCreate(ctx context.Context, apiKey string, capacity int, password string, ttlMinutes int) (*RoomCreatedResponse, error) {
 	sc, err := c.roomConnect(ctx, apiKey)
@@ -1606,20 +1615,12 @@ func (c *Client) RoomCreate(ctx context.Context, apiKey string, capacity int, pa
 		return nil, err
 	}
 	defer sc.Close()
+	return RoomHub{Conn: sc}.Create(ctx, capacity, password, ttlMinutes)
+}
 
-	// Build args map — server accepts nullable fields
-	args := map[string]interface{}{}
-	if capacity > 0 {
-		args["capacity"] = capacity
-	}
-	if password != "" {
-		args["password"] = password
-	}
-	if ttlMinutes > 0 {
-		args["ttlMinutes"] = ttlMinutes
-	}
-
-	raw, err := sc.Invoke(ctx, "CreateRoom", capacity, password, ttlMinutes)
+// Create creates a new room and returns its code.
+func (h RoomHub) Create(ctx context.Context, capacity int, password string, ttlMinutes int) (*RoomCreatedResponse, error) {
+	raw, err := h.Conn.Invoke(ctx, "CreateRoom", capacity, password, ttlMinutes)
 	if err != nil {
 		return nil, fmt.Errorf("create room: %w", err)
 	}
@@ -1645,8 +1646,12 @@ func (c *Client) RoomJoin(ctx context.Context, apiKey, roomCode, password string
 		return nil, err
 	}
 	defer sc.Close()
+	return RoomHub{Conn: sc}.Join(ctx, roomCode, password)
+}
 
-	raw, err := sc.Invoke(ctx, "JoinRoom", roomCode, password)
+// Join joins a room by code and returns the participant list.
+func (h RoomHub) Join(ctx context.Context, roomCode, password string) (*RoomJoinedResponse, error) {
+	raw, err := h.Conn.Invoke(ctx, "JoinRoom", roomCode, password)
 	if err != nil {
 		return nil, fmt.Errorf("join room: %w", err)
 	}
@@ -1672,8 +1677,12 @@ func (c *Client) RoomLeave(ctx context.Context, apiKey, roomCode string) error {
 		return err
 	}
 	defer sc.Close()
+	return RoomHub{Conn: sc}.Leave(ctx, roomCode)
+}
 
-	if _, err := sc.Invoke(ctx, "LeaveRoom", roomCode); err != nil {
+// Leave leaves a room.
+func (h RoomHub) Leave(ctx context.Context, roomCode string) error {
+	if _, err := h.Conn.Invoke(ctx, "LeaveRoom", roomCode); err != nil {
 		return fmt.Errorf("leave room: %w", err)
 	}
 	return nil
@@ -1686,8 +1695,12 @@ func (c *Client) RoomClose(ctx context.Context, apiKey, roomCode, reason string)
 		return err
 	}
 	defer sc.Close()
+	return RoomHub{Conn: sc}.Close(ctx, roomCode, reason)
+}
 
-	if _, err := sc.Invoke(ctx, "CloseRoom", roomCode, reason); err != nil {
+// Close closes a room (creator only).
+func (h RoomHub) Close(ctx context.Context, roomCode, reason string) error {
+	if _, err := h.Conn.Invoke(ctx, "CloseRoom", roomCode, reason); err != nil {
 		return fmt.Errorf("close room: %w", err)
 	}
 	return nil
@@ -1700,8 +1713,12 @@ func (c *Client) RoomSendMessage(ctx context.Context, apiKey, roomCode, content
 		return err
 	}
 	defer sc.Close()
+	return RoomHub{Conn: sc}.SendMessage(ctx, roomCode, content)
+}
 
-	if _, err := sc.Invoke(ctx, "SendRoomMessage", roomCode, content, nil); err != nil {
+// SendMessage sends a message to all room participants.
+func (h RoomHub) SendMessage(ctx context.Context, roomCode, content string) error {
+	if _, err := h.Conn.Invoke(ctx, "SendRoomMessage", roomCode, content, nil); err != nil {
 		return fmt.Errorf("send room message: %w", err)
 	}
 	return nil
@@ -1709,7 +1726,7 @@ func (c *Client) RoomSendMessage(ctx context.Context, apiKey, roomCode, content
 
 // RoomGetInfo returns current info about a room via REST API.
 func (c *Client) RoomGetInfo(ctx context.Context, apiKey, roomCode string) (*RoomInfoDto, error) {
-	body, status, err := c.doRequestWithAPIKey(ctx, "GET", "/api/v1/rooms/"+roomCode, apiKey, nil)
+	body, status, header, err := c.doRequestWithAPIKey(ctx, "GET", "/api/v1/rooms/"+roomCode, apiKey, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1717,7 +1734,7 @@ func (c *Client) RoomGetInfo(ctx context.Context, apiKey, roomCode string) (*Roo
 		return nil, fmt.Errorf("room %s not found", roomCode)
 	}
 	if status < 200 || status >= 300 {
-		return nil, fmt.Errorf("get room info failed (%d): %s", status, string(body))
+		return nil, newError("get room info", status, header, body)
 	}
 	var info RoomInfoDto
 	if err := decodeEnvelopeData(body, &info); err != nil {
@@ -1728,12 +1745,12 @@ func (c *Client) RoomGetInfo(ctx context.Context, apiKey, roomCode string) (*Roo
 
 // RoomGetParticipants returns the participant list for a room via REST API.
 func (c *Client) RoomGetParticipants(ctx context.Context, apiKey, roomCode string) ([]RoomParticipantDto, error) {
-	body, status, err := c.doRequestWithAPIKey(ctx, "GET", "/api/v1/rooms/"+roomCode+"/participants", apiKey, nil)
+	body, status, header, err := c.doRequestWithAPIKey(ctx, "GET", "/api/v1/rooms/"+roomCode+"/participants", apiKey, nil)
 	if err != nil {
 		return nil, err
 	}
 	if status < 200 || status >= 300 {
-		return nil, fmt.Errorf("get participants failed (%d): %s", status, string(body))
+		return nil, newError("get participants", status, header, body)
 	}
 	var participants []RoomParticipantDto
 	if err := decodeEnvelopeData(body, &participants); err != nil {
@@ -1745,12 +1762,12 @@ func (c *Client) RoomGetParticipants(ctx context.Context, apiKey, roomCode strin
 // RoomGetMessages returns recent cached room messages via REST API.
 func (c *Client) RoomGetMessages(ctx context.Context, apiKey, roomCode string, limit int) ([]RoomMessageDto, error) {
 	path := fmt.Sprintf("/api/v1/rooms/%s/messages?limit=%d", roomCode, limit)
-	body, status, err := c.doRequestWithAPIKey(ctx, "GET", path, apiKey, nil)
+	body, status, header, err := c.doRequestWithAPIKey(ctx, "GET", path, apiKey, nil)
 	if err != nil {
 		return nil, err
 	}
 	if status < 200 || status >= 300 {
-		return nil, fmt.Errorf("get messages failed (%d): %s", status, string(body))
+		return nil, newError("get messages", status, header, body)
 	}
 	var messages []RoomMessageDto
 	if err := decodeEnvelopeData(body, &messages); err != nil {
@@ -1766,8 +1783,12 @@ func (c *Client) RoomExtendTtl(ctx context.Context, apiKey, roomCode string, add
 		return err
 	}
 	defer sc.Close()
+	return RoomHub{Conn: sc}.ExtendTtl(ctx, roomCode, additionalMinutes)
+}
 
-	if _, err := sc.Invoke(ctx, "ExtendRoomTtl", roomCode, additionalMinutes); err != nil {
+// ExtendTtl extends a room's TTL (creator only).
+func (h RoomHub) ExtendTtl(ctx context.Context, roomCode string, additionalMinutes int) error {
+	if _, err := h.Conn.Invoke(ctx, "ExtendRoomTtl", roomCode, additionalMinutes); err != nil {
 		return fmt.Errorf("extend room TTL: %w", err)
 	}
 	return nil
@@ -1775,12 +1796,12 @@ func (c *Client) RoomExtendTtl(ctx context.Context, apiKey, roomCode string, add
 
 // RoomGetPublicStats returns platform-wide room statistics (no auth required).
 func (c *Client) RoomGetPublicStats(ctx context.Context) (*RoomStatsDto, error) {
-	body, status, err := c.doRequestWithAPIKey(ctx, "GET", "/api/v1/rooms/public/stats", "", nil)
+	body, status, header, err := c.doRequestWithAPIKey(ctx, "GET", "/api/v1/rooms/public/stats", "", nil)
 	if err != nil {
 		return nil, err
 	}
 	if status < 200 || status >= 300 {
-		return nil, fmt.Errorf("get public stats failed (%d): %s", status, string(body))
+		return nil, newError("get public stats", status, header, body)
 	}
 	var stats RoomStatsDto
 	if err := decodeEnvelopeData(body, &stats); err != nil {
@@ -1789,58 +1810,6 @@ func (c *Client) RoomGetPublicStats(ctx context.Context) (*RoomStatsDto, error)
 	return &stats, nil
 }
 
-// saveToLocalDB saves diary to local SQLite database
-func saveToLocalDB(date, summary string) error {
-	// Build local db path
-	homeDir, _ := os.UserHomeDir()
-	dbPath := filepath.Join(homeDir, ".moltbb", "local-web", "local.db")
-
-	// Open database
-	db, err := sql.Open("sqlite", dbPath)
-	if err != nil {
-		return fmt.Errorf("open db: %w", err)
-	}
-	defer db.Close()
-
-	// Check if entry exists
-	var exists bool
-	err = db.QueryRow("SELECT EXISTS(SELECT 1 FROM diary_entries WHERE date = ?)", date).Scan(&exists)
-	if err != nil {
-		return fmt.Errorf("check exists: %w", err)
-	}
-
-	// Extract title from summary (first line)
-	title := summary
-	if lines := strings.Split(summary, "\n"); len(lines) > 0 {
-		title = strings.TrimSpace(lines[0])
-		if len(title) > 100 {
-			title = title[:100]
-		}
-	}
-
-	if exists {
-		_, err = db.Exec(`
-			UPDATE diary_entries 
-			SET title = ?, preview = ?, content_text = ?, modified_at = datetime('now')
-			WHERE date = ?`,
-			title, summary, summary, date)
-	} else {
-		// Generate unique id
-		uniqueID := date + "-" + fmt.Sprintf("%d", time.Now().Unix())
-		relPath := date + ".md"
-		_, err = db.Exec(`
-			INSERT INTO diary_entries (id, rel_path, filename, date, title, preview, content_text, size, modified_at, indexed_at)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))`,
-			uniqueID, relPath, relPath, date, title, summary, summary, len(summary))
-	}
-
-	if err != nil {
-		return fmt.Errorf("save diary: %w", err)
-	}
-
-	return nil
-}
-
 // ── Bot self-profile ─────────────────────────────────────────────────────────
 
 type UpdateProfilePayload struct {
@@ -1856,12 +1825,12 @@ type UpdateProfileResult struct {
 }
 
 func (c *Client) UpdateProfile(ctx context.Context, apiKey string, payload UpdateProfilePayload) (UpdateProfileResult, error) {
-	body, status, err := c.doJSONWithAPIKey(ctx, http.MethodPatch, "/api/v1/runtime/profile", apiKey, payload)
+	body, status, header, err := c.doJSONWithAPIKey(ctx, http.MethodPatch, "/api/v1/runtime/profile", apiKey, payload)
 	if err != nil {
 		return UpdateProfileResult{}, err
 	}
 	if status < 200 || status >= 300 {
-		return UpdateProfileResult{}, fmt.Errorf("profile update failed (HTTP %d): %s", status, string(body))
+		return UpdateProfileResult{}, newError("profile update", status, header, body)
 	}
 	var result UpdateProfileResult
 	if err := decodeEnvelopeData(body, &result); err != nil {
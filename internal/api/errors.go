@@ -0,0 +1,162 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Error is a failed API call: the server answered with a non-2xx status.
+// Use errors.As, or the Is* helpers, to tell the failures apart.
+type Error struct {
+	// Op names the call, such as "get room info".
+	Op     string
+	Status int
+	// Message is the server's explanation from the response envelope, or
+	// the response body when it has none.
+	Message string
+	// Code is the server's error code, when it sends one.
+	Code string
+	// RequestID identifies the request in the server logs, when known.
+	RequestID string
+	// Retryable reports whether the same call may succeed later
+	// (rate limits and server errors).
+	Retryable bool
+	// Body is the raw response body.
+	Body string
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%s failed (%d)", e.Op, e.Status)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.RequestID != "" {
+		msg += " (request " + e.RequestID + ")"
+	}
+	return msg
+}
+
+// HTTPStatus returns the HTTP status of the response.
+func (e *Error) HTTPStatus() int { return e.Status }
+
+// CanRetry reports whether the call may be retried.
+func (e *Error) CanRetry() bool { return e.Retryable }
+
+// ServerRequestID returns the request ID the server reported.
+func (e *Error) ServerRequestID() string { return e.RequestID }
+
+// maxMessageLength caps a message taken from an unstructured body, such as
+// an HTML error page from a proxy.
+const maxMessageLength = 512
+
+// newError describes a non-2xx response to op. The request ID comes from
+// the body, or else from the X-Request-Id or traceparent header.
+func newError(op string, status int, header http.Header, body []byte) *Error {
+	e := &Error{
+		Op:        op,
+		Status:    status,
+		Body:      string(body),
+		Retryable: status == http.StatusTooManyRequests || status >= 500,
+	}
+	// The envelope carries message and code, sometimes nested in error;
+	// ASP.NET problem details carry title and traceId.
+	var env struct {
+		Message   string          `json:"message"`
+		Title     string          `json:"title"`
+		Error     json.RawMessage `json:"error"`
+		Code      json.RawMessage `json:"code"`
+		ErrorCode string          `json:"errorCode"`
+		RequestID string          `json:"requestId"`
+		TraceID   string          `json:"traceId"`
+	}
+	if json.Unmarshal(body, &env) == nil {
+		var nested struct {
+			Message string          `json:"message"`
+			Code    json.RawMessage `json:"code"`
+		}
+		_ = json.Unmarshal(env.Error, &nested)
+		e.Message = firstNonEmpty(env.Message, rawString(env.Error), nested.Message, env.Title)
+		e.Code = firstNonEmpty(rawString(env.Code), env.ErrorCode, rawString(nested.Code))
+		e.RequestID = firstNonEmpty(env.RequestID, env.TraceID)
+	}
+	if e.RequestID == "" {
+		e.RequestID = headerRequestID(header)
+	}
+	if e.Message == "" {
+		e.Message = strings.TrimSpace(string(body))
+		if len(e.Message) > maxMessageLength {
+			e.Message = e.Message[:maxMessageLength] + "..."
+		}
+	}
+	return e
+}
+
+// headerRequestID returns the X-Request-Id header, or the traceparent
+// header, which has the same form as the traceId of problem details.
+func headerRequestID(header http.Header) string {
+	return firstNonEmpty(header.Get("X-Request-Id"), header.Get("Traceparent"))
+}
+
+// rawString returns raw when it is a JSON string, and its text when it is
+// a number; other values are ignored.
+func rawString(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return strings.TrimSpace(s)
+	}
+	var n json.Number
+	if json.Unmarshal(raw, &n) == nil {
+		return n.String()
+	}
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// StatusOf returns the HTTP status of the API error in err's chain, or 0.
+func StatusOf(err error) int {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Status
+	}
+	return 0
+}
+
+// IsNotFound reports whether err is a 404 from the API.
+func IsNotFound(err error) bool {
+	return StatusOf(err) == http.StatusNotFound
+}
+
+// IsAuth reports whether the API rejected the credentials (401 or 403).
+func IsAuth(err error) bool {
+	status := StatusOf(err)
+	return status == http.StatusUnauthorized || status == http.StatusForbidden
+}
+
+// IsRateLimited reports whether err is a 429 from the API.
+func IsRateLimited(err error) bool {
+	return StatusOf(err) == http.StatusTooManyRequests
+}
+
+// IsValidation reports whether the API rejected the request content
+// (400 or 422).
+func IsValidation(err error) bool {
+	status := StatusOf(err)
+	return status == http.StatusBadRequest || status == http.StatusUnprocessableEntity
+}
+
+// IsRetryable reports whether err is an API error worth retrying later.
+func IsRetryable(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Retryable
+}
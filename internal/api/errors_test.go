@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewErrorReadsBody(t *testing.T) {
+	cases := []struct {
+		body      string
+		message   string
+		code      string
+		requestID string
+	}{
+		{`{"success":false,"message":"room is full","code":"ROOM_FULL","requestId":"r-1"}`, "room is full", "ROOM_FULL", "r-1"},
+		{`{"success":false,"error":{"message":"bad token","code":40101}}`, "bad token", "40101", ""},
+		{`{"error":"invalid_grant","errorCode":"E42"}`, "invalid_grant", "E42", ""},
+		{`{"title":"One or more validation errors occurred.","status":400,"traceId":"00-ab-cd-00"}`, "One or more validation errors occurred.", "", "00-ab-cd-00"},
+		{`{"data":null}`, `{"data":null}`, "", ""},
+		{"  Bad Gateway\n", "Bad Gateway", "", ""},
+		{"", "", "", ""},
+	}
+	for _, c := range cases {
+		e := newError("get room info", 400, nil, []byte(c.body))
+		if e.Message != c.message || e.Code != c.code || e.RequestID != c.requestID || e.Body != c.body {
+			t.Errorf("newError(%q) = %+v", c.body, e)
+		}
+	}
+
+	header := http.Header{}
+	header.Set("Traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	if e := newError("get room info", 404, header, []byte(`{"message":"no such room"}`)); e.RequestID != "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01" {
+		t.Errorf("traceparent: request ID %q", e.RequestID)
+	}
+	header.Set("X-Request-Id", "req-42")
+	if e := newError("get room info", 404, header, []byte(`{"message":"no such room"}`)); e.RequestID != "req-42" {
+		t.Errorf("X-Request-Id: request ID %q", e.RequestID)
+	}
+	if e := newError("get room info", 404, header, []byte(`{"requestId":"r-1"}`)); e.RequestID != "r-1" {
+		t.Errorf("body request ID should win: %q", e.RequestID)
+	}
+
+	long := newError("get room info", 502, nil, []byte("<html>"+strings.Repeat("x", 2*maxMessageLength)))
+	if len(long.Message) != maxMessageLength+3 || !long.Retryable {
+		t.Errorf("long body: message %d bytes, retryable %v", len(long.Message), long.Retryable)
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	for _, c := range []struct {
+		err  *Error
+		want string
+	}{
+		{&Error{Op: "get room info", Status: 404, Message: "no such room"}, "get room info failed (404): no such room"},
+		{&Error{Op: "tower checkin", Status: 503}, "tower checkin failed (503)"},
+		{&Error{Op: "send message", Status: 429, Message: "slow down", RequestID: "r-9"}, "send message failed (429): slow down (request r-9)"},
+	} {
+		if got := c.err.Error(); got != c.want {
+			t.Errorf("Error() = %q, want %q", got, c.want)
+		}
+	}
+}
+
+func TestErrorPredicates(t *testing.T) {
+	wrap := func(status int) error {
+		return fmt.Errorf("fetch: %w", newError("list messages", status, nil, nil))
+	}
+	if !IsNotFound(wrap(404)) || IsNotFound(wrap(405)) {
+		t.Error("IsNotFound")
+	}
+	if !IsAuth(wrap(401)) || !IsAuth(wrap(403)) || IsAuth(wrap(400)) {
+		t.Error("IsAuth")
+	}
+	if !IsRateLimited(wrap(429)) || IsRateLimited(wrap(503)) {
+		t.Error("IsRateLimited")
+	}
+	if !IsValidation(wrap(400)) || !IsValidation(wrap(422)) || IsValidation(wrap(409)) {
+		t.Error("IsValidation")
+	}
+	if !IsRetryable(wrap(429)) || !IsRetryable(wrap(500)) || IsRetryable(wrap(404)) {
+		t.Error("IsRetryable")
+	}
+	plain := errors.New("get room info failed (404): not typed")
+	if StatusOf(plain) != 0 || IsNotFound(plain) || IsRetryable(plain) {
+		t.Error("untyped error matched")
+	}
+}
+
+func TestClientReturnsError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "header-id")
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"success":false,"message":"too many requests","code":"RATE_LIMITED","requestId":"r-7"}`)
+	}))
+	defer srv.Close()
+	client := &Client{baseURL: srv.URL, httpClient: srv.Client()}
+
+	_, err := client.GetUnreadCount(context.Background(), "key")
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %#v, want *Error", err)
+	}
+	if apiErr.Op != "unread count" || apiErr.Status != 429 || apiErr.Code != "RATE_LIMITED" ||
+		apiErr.RequestID != "r-7" || !apiErr.Retryable {
+		t.Fatalf("err = %+v", apiErr)
+	}
+	if !IsRateLimited(err) {
+		t.Fatal("IsRateLimited = false")
+	}
+}
+
+func TestClientErrorRequestIDFromHeader(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-9")
+		http.Error(w, "not found", http.StatusNotFound)
+	}))
+	defer srv.Close()
+	client := &Client{baseURL: srv.URL, httpClient: srv.Client()}
+
+	_, err := client.ListRuntimeInsights(context.Background(), "key", 1, 10, nil, "")
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Status != 404 || apiErr.RequestID != "req-9" {
+		t.Fatalf("err = %#v", err)
+	}
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gorilla/websocket"
+)
+
+// Hub protocol names as sent in the SignalR handshake.
+const (
+	HubProtocolJSON        = "json"
+	HubProtocolMessagePack = "messagepack"
+)
+
+// hubProtocol encodes and decodes hub messages for one SignalR protocol.
+type hubProtocol interface {
+	name() string
+	// transferFormat is the negotiate transfer format the protocol needs:
+	// "Text" or "Binary".
+	transferFormat() string
+	wsMessageType() int
+	encode(msg signalrMsg) ([]byte, error)
+	// decode returns the hub messages in one WebSocket message. Messages
+	// it cannot parse are skipped.
+	decode(data []byte) []signalrMsg
+}
+
+// hubProtocolError means the hub refused the requested protocol, so the
+// client may retry with another one.
+type hubProtocolError struct {
+	Protocol string
+	Reason   string
+}
+
+func (e *hubProtocolError) Error() string {
+	return fmt.Sprintf("hub does not support the %s protocol: %s", e.Protocol, e.Reason)
+}
+
+// hubProtocols lists the protocols to try, preferred first.
+func (c *Client) hubProtocols() []hubProtocol {
+	if c.hubProtocol == HubProtocolMessagePack {
+		return []hubProtocol{msgpackHubProtocol{}, jsonHubProtocol{}}
+	}
+	return []hubProtocol{jsonHubProtocol{}}
+}
+
+// ── JSON ──────────────────────────────────────────────────────────────────────
+
+// jsonHubProtocol is the text protocol: JSON messages terminated by 0x1e.
+type jsonHubProtocol struct{}
+
+func (jsonHubProtocol) name() string           { return HubProtocolJSON }
+func (jsonHubProtocol) transferFormat() string { return "Text" }
+func (jsonHubProtocol) wsMessageType() int     { return websocket.TextMessage }
+
+func (jsonHubProtocol) encode(msg signalrMsg) ([]byte, error) {
+	if msg.Type == 6 {
+		return append([]byte(`{"type":6}`), signalrDelimiter), nil
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("marshal SignalR message: %w", err)
+	}
+	return append(data, signalrDelimiter), nil
+}
+
+func (jsonHubProtocol) decode(data []byte) []signalrMsg {
+	var msgs []signalrMsg
+	for _, part := range splitSignalR(data) {
+		part = bytes.TrimSpace(part)
+		if len(part) == 0 {
+			continue
+		}
+		var msg signalrMsg
+		if err := json.Unmarshal(part, &msg); err != nil {
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+// ── MessagePack ───────────────────────────────────────────────────────────────
+
+// msgpackHubProtocol is the binary protocol: each message is a MessagePack
+// array prefixed with its length as a varint.
+type msgpackHubProtocol struct{}
+
+func (msgpackHubProtocol) name() string           { return HubProtocolMessagePack }
+func (msgpackHubProtocol) transferFormat() string { return "Binary" }
+func (msgpackHubProtocol) wsMessageType() int     { return websocket.BinaryMessage }
+
+func (msgpackHubProtocol) encode(msg signalrMsg) ([]byte, error) {
+	var w msgpackWriter
+	switch msg.Type {
+	case 1:
+		// [1, headers, invocationId, target, arguments]; streamIds, added
+		// in later protocol revisions, are optional and omitted.
+		w.writeArrayHeader(5)
+		w.writeInt(1)
+		w.writeMapHeader(0)
+		if msg.InvocationId == "" {
+			w.writeNil()
+		} else {
+			w.writeString(msg.InvocationId)
+		}
+		w.writeString(msg.Target)
+		w.writeArrayHeader(len(msg.Arguments))
+		for _, arg := range msg.Arguments {
+			if err := w.writeJSON(arg); err != nil {
+				return nil, err
+			}
+		}
+	case 6:
+		w.writeArrayHeader(1)
+		w.writeInt(6)
+	default:
+		return nil, fmt.Errorf("msgpack: cannot encode message type %d", msg.Type)
+	}
+	return append(appendVarint(nil, w.Len()), w.Bytes()...), nil
+}
+
+func (msgpackHubProtocol) decode(data []byte) []signalrMsg {
+	frames, _ := splitMsgpackFrames(data)
+	msgs := make([]signalrMsg, 0, len(frames))
+	for _, frame := range frames {
+		msg, err := decodeMsgpackMessage(frame)
+		if err != nil {
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+func decodeMsgpackMessage(frame []byte) (signalrMsg, error) {
+	r := &msgpackReader{data: frame}
+	v, err := r.value()
+	if err != nil {
+		return signalrMsg{}, err
+	}
+	fields, ok := v.([]any)
+	if !ok || len(fields) == 0 {
+		return signalrMsg{}, errors.New("msgpack: hub message is not an array")
+	}
+	kind, ok := fields[0].(int64)
+	if !ok {
+		return signalrMsg{}, errors.New("msgpack: hub message type is not an integer")
+	}
+	msg := signalrMsg{Type: int(kind)}
+	str := func(i int) string {
+		if i < len(fields) {
+			if s, ok := fields[i].(string); ok {
+				return s
+			}
+		}
+		return ""
+	}
+
+	switch msg.Type {
+	case 1: // [1, headers, invocationId, target, arguments, streamIds?]
+		if len(fields) < 5 {
+			return msg, errors.New("msgpack: short invocation")
+		}
+		msg.InvocationId = str(2)
+		msg.Target = str(3)
+		args, _ := fields[4].([]any)
+		msg.Arguments = make([]json.RawMessage, 0, len(args))
+		for _, arg := range args {
+			raw, err := json.Marshal(arg)
+			if err != nil {
+				return msg, fmt.Errorf("msgpack: re-encode argument: %w", err)
+			}
+			msg.Arguments = append(msg.Arguments, raw)
+		}
+	case 3: // [3, headers, invocationId, resultKind, result?]
+		if len(fields) < 4 {
+			return msg, errors.New("msgpack: short completion")
+		}
+		msg.InvocationId = str(2)
+		resultKind, _ := fields[3].(int64)
+		switch resultKind {
+		case 1:
+			msg.Error = str(4)
+			if msg.Error == "" {
+				msg.Error = "hub invocation failed"
+			}
+		case 3:
+			if len(fields) > 4 {
+				raw, err := json.Marshal(fields[4])
+				if err != nil {
+					return msg, fmt.Errorf("msgpack: re-encode result: %w", err)
+				}
+				msg.Result = raw
+			}
+		}
+	case 7: // [7, error, allowReconnect?]
+		msg.Error = str(1)
+	}
+	return msg, nil
+}
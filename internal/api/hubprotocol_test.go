@@ -0,0 +1,163 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMsgpackJSONRoundTrip(t *testing.T) {
+	long := strings.Repeat("ü", 200) // > 255 bytes: str16
+	doc := `{"roomCode":"room-ab12","n":-40000,"big":5000000000,"f":1.5,"ok":true,"none":null,` +
+		`"list":[0,1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16],"text":"` + long + `","nested":{"a":[{"b":-1}]}}`
+
+	var w msgpackWriter
+	if err := w.writeJSON(json.RawMessage(doc)); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	r := &msgpackReader{data: w.Bytes()}
+	v, err := r.value()
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if r.pos != len(r.data) {
+		t.Fatalf("decoded %d of %d bytes", r.pos, len(r.data))
+	}
+	got, _ := json.Marshal(v)
+
+	var want, have any
+	_ = json.Unmarshal([]byte(doc), &want)
+	_ = json.Unmarshal(got, &have)
+	wantJSON, _ := json.Marshal(want)
+	haveJSON, _ := json.Marshal(have)
+	if !bytes.Equal(wantJSON, haveJSON) {
+		t.Fatalf("round trip changed the document:\n got %s\nwant %s", haveJSON, wantJSON)
+	}
+}
+
+func TestMsgpackTimestampExtension(t *testing.T) {
+	// fixext8, type -1: 30-bit nanoseconds and 34-bit seconds.
+	sec, nsec := uint64(1760000000), uint64(123456789)
+	v := nsec<<34 | sec
+	data := []byte{0xd7, 0xff}
+	for i := 7; i >= 0; i-- {
+		data = append(data, byte(v>>(8*i)))
+	}
+	got, err := (&msgpackReader{data: data}).value()
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := time.Unix(int64(sec), int64(nsec)).UTC()
+	if ts, ok := got.(time.Time); !ok || !ts.Equal(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestVarintLengthPrefix(t *testing.T) {
+	if got := appendVarint(nil, 128); !bytes.Equal(got, []byte{0x80, 0x01}) {
+		t.Fatalf("appendVarint(128) = % x", got)
+	}
+	for _, n := range []int{0, 1, 127, 128, 300, 16383, 16384, 1 << 20} {
+		enc := appendVarint(nil, n)
+		got, used, err := readVarint(enc)
+		if err != nil || got != n || used != len(enc) {
+			t.Fatalf("readVarint(% x) = %d, %d, %v; want %d", enc, got, used, err, n)
+		}
+	}
+	if _, _, err := readVarint([]byte{0x80, 0x80}); err == nil {
+		t.Fatal("truncated prefix accepted")
+	}
+}
+
+func TestMsgpackDecodesHandBuiltFrames(t *testing.T) {
+	// Two messages in one WebSocket frame: a push invocation
+	// [1, {}, nil, "T", ["x"]] and a ping [6].
+	invocation := []byte{0x95, 0x01, 0x80, 0xc0, 0xa1, 'T', 0x91, 0xa1, 'x'}
+	ping := []byte{0x91, 0x06}
+	frame := append(appendVarint(nil, len(invocation)), invocation...)
+	frame = append(append(frame, appendVarint(nil, len(ping))...), ping...)
+
+	msgs := msgpackHubProtocol{}.decode(frame)
+	if len(msgs) != 2 {
+		t.Fatalf("decoded %d messages, want 2", len(msgs))
+	}
+	if m := msgs[0]; m.Type != 1 || m.Target != "T" || m.InvocationId != "" ||
+		len(m.Arguments) != 1 || string(m.Arguments[0]) != `"x"` {
+		t.Fatalf("invocation = %+v", m)
+	}
+	if msgs[1].Type != 6 {
+		t.Fatalf("second message = %+v, want ping", msgs[1])
+	}
+
+	// Our invocation encoding must decode back to the same message.
+	enc, err := msgpackHubProtocol{}.encode(signalrMsg{Type: 1, InvocationId: "7", Target: "JoinRoom",
+		Arguments: []json.RawMessage{json.RawMessage(`"room-1"`), json.RawMessage(`""`)}})
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	back := msgpackHubProtocol{}.decode(enc)
+	if len(back) != 1 || back[0].InvocationId != "7" || back[0].Target != "JoinRoom" ||
+		len(back[0].Arguments) != 2 || string(back[0].Arguments[0]) != `"room-1"` {
+		t.Fatalf("re-decoded = %+v", back)
+	}
+}
+
+func TestConnectToHubMessagePack(t *testing.T) {
+	hub, client := newFakeHub(t, "tok")
+	hub.protocols = []string{HubProtocolJSON, HubProtocolMessagePack}
+	client.hubProtocol = HubProtocolMessagePack
+
+	sc, err := client.ConnectToHub(context.Background(), "tok")
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer sc.Close()
+	if sc.Protocol() != HubProtocolMessagePack {
+		t.Fatalf("protocol = %s", sc.Protocol())
+	}
+
+	pushed := make(chan json.RawMessage, 1)
+	sc.On("Room.MessageReceived", func(args []json.RawMessage) { pushed <- args[0] })
+
+	res, err := sc.Invoke(context.Background(), "SendRoomMessage", map[string]any{"content": "hi", "n": 3})
+	if err != nil {
+		t.Fatalf("invoke: %v", err)
+	}
+	if string(res) != `{"content":"hi","n":3}` {
+		t.Fatalf("result = %s", res)
+	}
+
+	hub.push("Room.MessageReceived", map[string]any{"senderBotId": "b1", "content": strings.Repeat("x", 70000)})
+	select {
+	case raw := <-pushed:
+		var msg RoomMessageDto
+		if err := json.Unmarshal(raw, &msg); err != nil || msg.SenderBotId != "b1" || len(msg.Content) != 70000 {
+			t.Fatalf("push decoded as %.60s… (%v)", raw, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("push not delivered")
+	}
+}
+
+func TestConnectToHubFallsBackToJSON(t *testing.T) {
+	hub, client := newFakeHub(t, "tok")
+	client.hubProtocol = HubProtocolMessagePack
+
+	sc, err := client.ConnectToHub(context.Background(), "tok")
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer sc.Close()
+	if sc.Protocol() != HubProtocolJSON {
+		t.Fatalf("protocol = %s, want json fallback", sc.Protocol())
+	}
+	if err := sc.InvokeVoid(context.Background(), "JoinPipeline"); err != nil {
+		t.Fatalf("invoke: %v", err)
+	}
+	if got := hub.targets(); len(got) != 1 || got[0] != "JoinPipeline" {
+		t.Fatalf("invoked = %v", got)
+	}
+}
@@ -0,0 +1,438 @@
+package api
+
+// Minimal MessagePack codec for the SignalR MessagePack hub protocol.
+//
+// Only what the hub protocol needs is supported: nil, bool, integers,
+// floats, str, bin, arrays, maps and the timestamp extension (-1). Values
+// are converted to and from JSON so push handlers keep receiving
+// json.RawMessage arguments whichever protocol is in use.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var errMsgpackShort = errors.New("msgpack: unexpected end of data")
+
+// msgpackTimestampExt is the MessagePack timestamp extension type.
+const msgpackTimestampExt = -1
+
+// ── encoding ──────────────────────────────────────────────────────────────────
+
+type msgpackWriter struct {
+	bytes.Buffer
+}
+
+func (w *msgpackWriter) writeNil() { w.WriteByte(0xc0) }
+
+func (w *msgpackWriter) writeBool(v bool) {
+	if v {
+		w.WriteByte(0xc3)
+	} else {
+		w.WriteByte(0xc2)
+	}
+}
+
+func (w *msgpackWriter) writeInt(v int64) {
+	switch {
+	case v >= 0:
+		w.writeUint(uint64(v))
+	case v >= -32:
+		w.WriteByte(byte(v))
+	case v >= math.MinInt8:
+		w.Write([]byte{0xd0, byte(v)})
+	case v >= math.MinInt16:
+		w.WriteByte(0xd1)
+		w.Write(binary.BigEndian.AppendUint16(nil, uint16(v)))
+	case v >= math.MinInt32:
+		w.WriteByte(0xd2)
+		w.Write(binary.BigEndian.AppendUint32(nil, uint32(v)))
+	default:
+		w.WriteByte(0xd3)
+		w.Write(binary.BigEndian.AppendUint64(nil, uint64(v)))
+	}
+}
+
+func (w *msgpackWriter) writeUint(v uint64) {
+	switch {
+	case v <= 0x7f:
+		w.WriteByte(byte(v))
+	case v <= math.MaxUint8:
+		w.Write([]byte{0xcc, byte(v)})
+	case v <= math.MaxUint16:
+		w.WriteByte(0xcd)
+		w.Write(binary.BigEndian.AppendUint16(nil, uint16(v)))
+	case v <= math.MaxUint32:
+		w.WriteByte(0xce)
+		w.Write(binary.BigEndian.AppendUint32(nil, uint32(v)))
+	default:
+		w.WriteByte(0xcf)
+		w.Write(binary.BigEndian.AppendUint64(nil, v))
+	}
+}
+
+func (w *msgpackWriter) writeFloat(v float64) {
+	w.WriteByte(0xcb)
+	w.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(v)))
+}
+
+func (w *msgpackWriter) writeString(s string) {
+	n := len(s)
+	switch {
+	case n <= 31:
+		w.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		w.Write([]byte{0xd9, byte(n)})
+	case n <= math.MaxUint16:
+		w.WriteByte(0xda)
+		w.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		w.WriteByte(0xdb)
+		w.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+	w.WriteString(s)
+}
+
+func (w *msgpackWriter) writeArrayHeader(n int) {
+	switch {
+	case n <= 15:
+		w.WriteByte(0x90 | byte(n))
+	case n <= math.MaxUint16:
+		w.WriteByte(0xdc)
+		w.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		w.WriteByte(0xdd)
+		w.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+}
+
+func (w *msgpackWriter) writeMapHeader(n int) {
+	switch {
+	case n <= 15:
+		w.WriteByte(0x80 | byte(n))
+	case n <= math.MaxUint16:
+		w.WriteByte(0xde)
+		w.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		w.WriteByte(0xdf)
+		w.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+}
+
+// writeValue encodes a value as produced by decoding JSON with UseNumber:
+// nil, bool, json.Number, string, []any or map[string]any.
+func (w *msgpackWriter) writeValue(v any) error {
+	switch v := v.(type) {
+	case nil:
+		w.writeNil()
+	case bool:
+		w.writeBool(v)
+	case json.Number:
+		if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			w.writeInt(i)
+		} else if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			w.writeUint(u)
+		} else if f, err := v.Float64(); err == nil {
+			w.writeFloat(f)
+		} else {
+			return fmt.Errorf("msgpack: bad number %q", v)
+		}
+	case string:
+		w.writeString(v)
+	case []any:
+		w.writeArrayHeader(len(v))
+		for _, e := range v {
+			if err := w.writeValue(e); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		w.writeMapHeader(len(keys))
+		for _, k := range keys {
+			w.writeString(k)
+			if err := w.writeValue(v[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("msgpack: unsupported type %T", v)
+	}
+	return nil
+}
+
+// writeJSON encodes a JSON document as the equivalent MessagePack value.
+func (w *msgpackWriter) writeJSON(raw json.RawMessage) error {
+	if len(raw) == 0 {
+		w.writeNil()
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("msgpack: decode JSON argument: %w", err)
+	}
+	return w.writeValue(v)
+}
+
+// ── decoding ──────────────────────────────────────────────────────────────────
+
+type msgpackReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *msgpackReader) next(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.data) {
+		return nil, errMsgpackShort
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *msgpackReader) byte() (byte, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (r *msgpackReader) uint(size int) (uint64, error) {
+	b, err := r.next(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	default:
+		return binary.BigEndian.Uint64(b), nil
+	}
+}
+
+// value decodes the next value into nil, bool, int64, uint64, float64,
+// string, []byte, time.Time, []any or map[string]any.
+func (r *msgpackReader) value() (any, error) {
+	c, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return r.mapBody(int(c & 0x0f))
+	case c&0xf0 == 0x90:
+		return r.arrayBody(int(c & 0x0f))
+	case c&0xe0 == 0xa0:
+		return r.str(int(c & 0x1f))
+	}
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := r.uint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		b, err := r.next(int(n))
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case 0xc7, 0xc8, 0xc9:
+		n, err := r.uint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return r.ext(int(n))
+	case 0xca:
+		u, err := r.uint(4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := r.uint(8)
+		return math.Float64frombits(u), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := r.uint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if u <= math.MaxInt64 {
+			return int64(u), nil
+		}
+		return u, nil
+	case 0xd0:
+		u, err := r.uint(1)
+		return int64(int8(u)), err
+	case 0xd1:
+		u, err := r.uint(2)
+		return int64(int16(u)), err
+	case 0xd2:
+		u, err := r.uint(4)
+		return int64(int32(u)), err
+	case 0xd3:
+		u, err := r.uint(8)
+		return int64(u), err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return r.ext(1 << (c - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := r.uint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return r.str(int(n))
+	case 0xdc, 0xdd:
+		n, err := r.uint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return r.arrayBody(int(n))
+	case 0xde, 0xdf:
+		n, err := r.uint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return r.mapBody(int(n))
+	}
+	return nil, fmt.Errorf("msgpack: unknown format byte 0x%02x", c)
+}
+
+func (r *msgpackReader) str(n int) (string, error) {
+	b, err := r.next(n)
+	return string(b), err
+}
+
+func (r *msgpackReader) arrayBody(n int) ([]any, error) {
+	if n > len(r.data)-r.pos {
+		return nil, errMsgpackShort
+	}
+	out := make([]any, n)
+	for i := range out {
+		v, err := r.value()
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func (r *msgpackReader) mapBody(n int) (map[string]any, error) {
+	if n > len(r.data)-r.pos {
+		return nil, errMsgpackShort
+	}
+	out := make(map[string]any, n)
+	for i := 0; i < n; i++ {
+		k, err := r.value()
+		if err != nil {
+			return nil, err
+		}
+		v, err := r.value()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			key = fmt.Sprint(k)
+		}
+		out[key] = v
+	}
+	return out, nil
+}
+
+func (r *msgpackReader) ext(n int) (any, error) {
+	t, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	b, err := r.next(n)
+	if err != nil {
+		return nil, err
+	}
+	if int8(t) != msgpackTimestampExt {
+		return append([]byte(nil), b...), nil
+	}
+	switch n {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(b)), 0).UTC(), nil
+	case 8:
+		v := binary.BigEndian.Uint64(b)
+		return time.Unix(int64(v&0x3ffffffff), int64(v>>34)).UTC(), nil
+	case 12:
+		nsec := binary.BigEndian.Uint32(b[:4])
+		sec := int64(binary.BigEndian.Uint64(b[4:]))
+		return time.Unix(sec, int64(nsec)).UTC(), nil
+	}
+	return nil, fmt.Errorf("msgpack: bad timestamp length %d", n)
+}
+
+// ── framing ───────────────────────────────────────────────────────────────────
+
+// appendVarint appends the SignalR length prefix: 7 bits per byte, least
+// significant group first, high bit set on all but the last byte.
+func appendVarint(dst []byte, n int) []byte {
+	for n >= 0x80 {
+		dst = append(dst, byte(n)|0x80)
+		n >>= 7
+	}
+	return append(dst, byte(n))
+}
+
+// readVarint parses a length prefix, returning the length and the number of
+// bytes it used. SignalR limits the prefix to 5 bytes (2 GB).
+func readVarint(data []byte) (int, int, error) {
+	n := 0
+	for i := 0; i < 5; i++ {
+		if i >= len(data) {
+			return 0, 0, errMsgpackShort
+		}
+		b := data[i]
+		n |= int(b&0x7f) << (7 * i)
+		if b&0x80 == 0 {
+			return n, i + 1, nil
+		}
+	}
+	return 0, 0, errors.New("msgpack: length prefix too long")
+}
+
+// splitMsgpackFrames splits a binary WebSocket message into the
+// length-prefixed hub messages it contains.
+func splitMsgpackFrames(data []byte) ([][]byte, error) {
+	var frames [][]byte
+	for len(data) > 0 {
+		n, used, err := readVarint(data)
+		if err != nil {
+			return frames, err
+		}
+		if used+n > len(data) {
+			return frames, errMsgpackShort
+		}
+		frames = append(frames, data[used:used+n])
+		data = data[used+n:]
+	}
+	return frames, nil
+}
@@ -0,0 +1,394 @@
+package api
+
+// Reconnecting wrapper around SignalRConn for long-running listeners.
+//
+// A SignalRConn ends on the first read error. ReconnectingConn dials a new
+// one with exponential backoff, refreshes the bot JWT when the hub rejects
+// it, registers the push handlers again and re-runs OnConnect so the caller
+// can rejoin its groups (JoinPipeline, JoinRoom).
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotConnected is returned by ReconnectingConn.Invoke while the
+// connection is down.
+var ErrNotConnected = errors.New("not connected to hub")
+
+// ConnState is the state of a ReconnectingConn.
+type ConnState int
+
+const (
+	StateConnecting ConnState = iota
+	StateConnected
+	StateReconnecting
+	StateClosed
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateReconnecting:
+		return "reconnecting"
+	case StateClosed:
+		return "closed"
+	default:
+		return fmt.Sprintf("ConnState(%d)", int(s))
+	}
+}
+
+// StateChange is passed to HubOptions.OnStateChange.
+type StateChange struct {
+	State ConnState
+	// Attempt is the reconnect attempt, 0 for the initial connection.
+	Attempt int
+	// Err is why the connection was lost or the previous attempt failed
+	// (Reconnecting), or why the connection gave up (Closed; nil after
+	// Close).
+	Err error
+	// Retry is the wait before this attempt (Reconnecting).
+	Retry time.Duration
+	// Down is how long the connection was down (Connected after a
+	// reconnect).
+	Down time.Duration
+}
+
+// HubOptions configures a ReconnectingConn.
+type HubOptions struct {
+	Token string
+	// RefreshToken returns a new token when the hub rejects the current one
+	// or the JWT is about to expire. Nil keeps using Token.
+	RefreshToken func(ctx context.Context) (string, error)
+	// OnConnect runs on every new connection once the handlers are
+	// registered, typically to invoke JoinPipeline/JoinRoom. An error fails
+	// the attempt.
+	OnConnect func(ctx context.Context, sc *SignalRConn) error
+	// OnStateChange is called from the connection's goroutine; it must not
+	// block for long.
+	OnStateChange func(StateChange)
+	// MinBackoff and MaxBackoff bound the wait between attempts (default
+	// 1s and 30s).
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// MaxAttempts limits consecutive failed reconnect attempts; 0 keeps
+	// trying until the context ends or Close is called.
+	MaxAttempts int
+}
+
+// ReconnectingConn is a TowerHub connection that survives disconnects.
+type ReconnectingConn struct {
+	client *Client
+	opts   HubOptions
+
+	mu       sync.Mutex
+	sc       *SignalRConn
+	token    string
+	state    ConnState
+	handlers map[string]PushHandler
+	err      error
+
+	closing   chan struct{}
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// NewReconnectingConn prepares a reconnecting hub connection. Register push
+// handlers with On, then call Connect.
+func (c *Client) NewReconnectingConn(opts HubOptions) *ReconnectingConn {
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 30 * time.Second
+	}
+	return &ReconnectingConn{
+		client:   c,
+		opts:     opts,
+		token:    opts.Token,
+		handlers: make(map[string]PushHandler),
+		closing:  make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// On registers a push handler on the current connection and every later
+// one.
+func (rc *ReconnectingConn) On(target string, handler PushHandler) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.handlers[target] = handler
+	if rc.sc != nil {
+		rc.sc.On(target, handler)
+	}
+}
+
+// Connect dials the hub and runs OnConnect. A failure here is returned
+// rather than retried, so bad credentials or a wrong room code surface
+// immediately. Afterwards the connection is maintained until ctx ends or
+// Close is called.
+func (rc *ReconnectingConn) Connect(ctx context.Context) error {
+	rc.emit(StateChange{State: StateConnecting})
+	sc, err := rc.dial(ctx)
+	if err != nil {
+		rc.finish(err)
+		close(rc.done)
+		return err
+	}
+	rc.setConn(sc)
+	rc.emit(StateChange{State: StateConnected})
+	go rc.run(ctx)
+	return nil
+}
+
+// Invoke calls a hub method on the current connection.
+func (rc *ReconnectingConn) Invoke(ctx context.Context, target string, args ...any) (json.RawMessage, error) {
+	sc := rc.current()
+	if sc == nil {
+		return nil, ErrNotConnected
+	}
+	return sc.Invoke(ctx, target, args...)
+}
+
+// InvokeVoid calls a hub method that returns no result.
+func (rc *ReconnectingConn) InvokeVoid(ctx context.Context, target string, args ...any) error {
+	_, err := rc.Invoke(ctx, target, args...)
+	return err
+}
+
+// Token returns the token in use, which changes after a refresh. Use it for
+// REST calls made alongside the connection.
+func (rc *ReconnectingConn) Token() string {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.token
+}
+
+// State returns the current connection state.
+func (rc *ReconnectingConn) State() ConnState {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.state
+}
+
+// Close stops reconnecting and closes the current connection.
+func (rc *ReconnectingConn) Close() {
+	rc.closeOnce.Do(func() { close(rc.closing) })
+	if sc := rc.current(); sc != nil {
+		sc.Close()
+	}
+}
+
+// Done is closed once the connection has stopped for good: after Close,
+// when the context ends, or when MaxAttempts reconnects failed.
+func (rc *ReconnectingConn) Done() <-chan struct{} {
+	return rc.done
+}
+
+// Err returns why the connection stopped; nil after Close.
+func (rc *ReconnectingConn) Err() error {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.err
+}
+
+// ── internal ──────────────────────────────────────────────────────────────────
+
+func (rc *ReconnectingConn) run(ctx context.Context) {
+	defer close(rc.done)
+	for {
+		sc := rc.current()
+		select {
+		case <-sc.Done():
+		case <-rc.closing:
+			sc.Close()
+			rc.finish(nil)
+			return
+		case <-ctx.Done():
+			sc.Close()
+			rc.finish(ctx.Err())
+			return
+		}
+
+		lost := time.Now()
+		cause := sc.Err()
+		if cause == nil {
+			cause = errors.New("connection closed")
+		}
+		rc.setConn(nil)
+		next, attempt, err := rc.reconnect(ctx, cause)
+		if err != nil || next == nil {
+			rc.finish(err)
+			return
+		}
+		rc.setConn(next)
+		rc.emit(StateChange{State: StateConnected, Attempt: attempt, Down: time.Since(lost)})
+	}
+}
+
+func (rc *ReconnectingConn) reconnect(ctx context.Context, cause error) (*SignalRConn, int, error) {
+	for attempt := 1; ; attempt++ {
+		wait := rc.backoff(attempt)
+		rc.emit(StateChange{State: StateReconnecting, Attempt: attempt, Err: cause, Retry: wait})
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-rc.closing:
+			timer.Stop()
+			return nil, attempt, nil
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, attempt, ctx.Err()
+		}
+		// Close may have won the race with the timer.
+		select {
+		case <-rc.closing:
+			return nil, attempt, nil
+		default:
+		}
+
+		sc, err := rc.dial(ctx)
+		if err == nil {
+			return sc, attempt, nil
+		}
+		cause = err
+		if rc.opts.MaxAttempts > 0 && attempt >= rc.opts.MaxAttempts {
+			return nil, attempt, fmt.Errorf("gave up after %d reconnect attempts: %w", attempt, err)
+		}
+	}
+}
+
+// dial opens a connection, refreshing the token first if it is about to
+// expire and once more if the hub rejects it, then registers the handlers
+// and runs OnConnect.
+func (rc *ReconnectingConn) dial(ctx context.Context) (*SignalRConn, error) {
+	token := rc.Token()
+	refreshed := false
+	if rc.opts.RefreshToken != nil && tokenExpiresWithin(token, time.Minute) {
+		var err error
+		if token, err = rc.refresh(ctx); err != nil {
+			return nil, err
+		}
+		refreshed = true
+	}
+	// A 401 to negotiate means the token was rejected, usually because the
+	// bot JWT expired.
+	sc, err := rc.client.ConnectToHub(ctx, token)
+	if err != nil && !refreshed && rc.opts.RefreshToken != nil && StatusOf(err) == http.StatusUnauthorized {
+		if token, err = rc.refresh(ctx); err != nil {
+			return nil, err
+		}
+		sc, err = rc.client.ConnectToHub(ctx, token)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rc.mu.Lock()
+	for target, handler := range rc.handlers {
+		sc.On(target, handler)
+	}
+	rc.mu.Unlock()
+
+	if rc.opts.OnConnect != nil {
+		joinCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		err := rc.opts.OnConnect(joinCtx, sc)
+		cancel()
+		if err != nil {
+			sc.Close()
+			return nil, err
+		}
+	}
+	return sc, nil
+}
+
+func (rc *ReconnectingConn) refresh(ctx context.Context) (string, error) {
+	token, err := rc.opts.RefreshToken(ctx)
+	if err != nil {
+		return "", fmt.Errorf("refresh hub token: %w", err)
+	}
+	rc.mu.Lock()
+	rc.token = token
+	rc.mu.Unlock()
+	return token, nil
+}
+
+// backoff doubles from MinBackoff up to MaxBackoff and returns a random
+// duration in the upper half, so many clients dropped at once do not
+// reconnect in lockstep.
+func (rc *ReconnectingConn) backoff(attempt int) time.Duration {
+	d := rc.opts.MinBackoff
+	for i := 1; i < attempt && d < rc.opts.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > rc.opts.MaxBackoff {
+		d = rc.opts.MaxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func (rc *ReconnectingConn) current() *SignalRConn {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.sc
+}
+
+func (rc *ReconnectingConn) setConn(sc *SignalRConn) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.sc = sc
+}
+
+func (rc *ReconnectingConn) finish(err error) {
+	rc.mu.Lock()
+	rc.err = err
+	rc.sc = nil
+	rc.mu.Unlock()
+	rc.emit(StateChange{State: StateClosed, Err: err})
+}
+
+func (rc *ReconnectingConn) emit(change StateChange) {
+	rc.mu.Lock()
+	rc.state = change.State
+	rc.mu.Unlock()
+	if rc.opts.OnStateChange != nil {
+		rc.opts.OnStateChange(change)
+	}
+}
+
+// tokenExpiresWithin reports whether token is a JWT whose exp claim falls
+// within d from now. Anything that is not a JWT (e.g. a plain API key)
+// never expires.
+func tokenExpiresWithin(token string, d time.Duration) bool {
+	exp, ok := jwtExpiry(token)
+	return ok && time.Until(exp) < d
+}
+
+func jwtExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp float64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(claims.Exp), 0), true
+}
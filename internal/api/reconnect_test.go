@@ -0,0 +1,339 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeHub is a minimal SignalR server. It accepts tokens in valid and the
+// hub protocols in protocols (JSON only by default), answers every
+// invocation with a completion, and records the targets invoked.
+type fakeHub struct {
+	mu        sync.Mutex
+	valid     map[string]bool
+	protocols []string
+	invoked   []string
+	conns     []*fakeHubConn
+}
+
+type fakeHubConn struct {
+	ws       *websocket.Conn
+	protocol hubProtocol
+}
+
+func newFakeHub(t *testing.T, tokens ...string) (*fakeHub, *Client) {
+	t.Helper()
+	hub := &fakeHub{valid: make(map[string]bool), protocols: []string{HubProtocolJSON}}
+	for _, tok := range tokens {
+		hub.valid[tok] = true
+	}
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		hub.mu.Lock()
+		ok := hub.valid[token]
+		hub.mu.Unlock()
+		if !ok {
+			http.Error(w, "expired", http.StatusUnauthorized)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/negotiate") {
+			fmt.Fprint(w, `{"connectionToken":"ct","availableTransports":[{"transport":"WebSockets","transferFormats":["Text","Binary"]}]}`)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		go hub.serve(conn)
+	}))
+	t.Cleanup(srv.Close)
+	return hub, &Client{baseURL: srv.URL, httpClient: srv.Client()}
+}
+
+func (h *fakeHub) serve(ws *websocket.Conn) {
+	defer ws.Close()
+	_, data, err := ws.ReadMessage()
+	if err != nil {
+		return
+	}
+	var hs struct {
+		Protocol string `json:"protocol"`
+	}
+	_ = json.Unmarshal(bytes.TrimRight(data, "\x1e"), &hs)
+	h.mu.Lock()
+	supported := false
+	for _, p := range h.protocols {
+		supported = supported || p == hs.Protocol
+	}
+	h.mu.Unlock()
+	if !supported {
+		reply := fmt.Sprintf(`{"error":"The protocol '%s' is not supported."}`+"\x1e", hs.Protocol)
+		_ = ws.WriteMessage(websocket.TextMessage, []byte(reply))
+		return
+	}
+	conn := &fakeHubConn{ws: ws, protocol: jsonHubProtocol{}}
+	if hs.Protocol == HubProtocolMessagePack {
+		conn.protocol = msgpackHubProtocol{}
+	}
+	h.mu.Lock()
+	h.conns = append(h.conns, conn)
+	h.mu.Unlock()
+	_ = ws.WriteMessage(websocket.TextMessage, []byte("{}\x1e"))
+
+	for {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		for _, msg := range conn.protocol.decode(data) {
+			if msg.Type != 1 {
+				continue
+			}
+			h.mu.Lock()
+			h.invoked = append(h.invoked, msg.Target)
+			_ = conn.ws.WriteMessage(conn.protocol.wsMessageType(), conn.completion(msg))
+			h.mu.Unlock()
+		}
+	}
+}
+
+// completion answers an invocation with its first argument, if any.
+func (c *fakeHubConn) completion(inv signalrMsg) []byte {
+	var result json.RawMessage
+	if len(inv.Arguments) > 0 {
+		result = inv.Arguments[0]
+	}
+	if c.protocol.name() == HubProtocolJSON {
+		reply, _ := json.Marshal(signalrMsg{Type: 3, InvocationId: inv.InvocationId, Result: result})
+		return append(reply, signalrDelimiter)
+	}
+	var w msgpackWriter
+	w.writeArrayHeader(5)
+	w.writeInt(3)
+	w.writeMapHeader(0)
+	w.writeString(inv.InvocationId)
+	w.writeInt(3)
+	_ = w.writeJSON(result)
+	return append(appendVarint(nil, w.Len()), w.Bytes()...)
+}
+
+// drop closes every open server-side connection.
+func (h *fakeHub) drop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, c := range h.conns {
+		c.ws.Close()
+	}
+	h.conns = nil
+}
+
+func (h *fakeHub) push(target string, arg any) {
+	data, _ := json.Marshal(arg)
+	msg := signalrMsg{Type: 1, Target: target, Arguments: []json.RawMessage{data}}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, c := range h.conns {
+		frame, _ := c.protocol.encode(msg)
+		_ = c.ws.WriteMessage(c.protocol.wsMessageType(), frame)
+	}
+}
+
+func (h *fakeHub) targets() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.invoked...)
+}
+
+func waitState(t *testing.T, states <-chan StateChange, want ConnState) StateChange {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ch := <-states:
+			if ch.State == want {
+				return ch
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for state %s", want)
+		}
+	}
+}
+
+func TestReconnectingConnRejoinsAndKeepsHandlers(t *testing.T) {
+	hub, client := newFakeHub(t, "tok")
+	states := make(chan StateChange, 32)
+	received := make(chan string, 4)
+
+	rc := client.NewReconnectingConn(HubOptions{
+		Token:         "tok",
+		MinBackoff:    10 * time.Millisecond,
+		MaxBackoff:    20 * time.Millisecond,
+		OnStateChange: func(ch StateChange) { states <- ch },
+		OnConnect: func(ctx context.Context, sc *SignalRConn) error {
+			return sc.InvokeVoid(ctx, "JoinPipeline")
+		},
+	})
+	rc.On("Room.MessageReceived", func(args []json.RawMessage) {
+		var s string
+		_ = json.Unmarshal(args[0], &s)
+		received <- s
+	})
+	if err := rc.Connect(context.Background()); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer rc.Close()
+	waitState(t, states, StateConnected)
+
+	hub.drop()
+	if ch := waitState(t, states, StateReconnecting); ch.Attempt != 1 || ch.Err == nil {
+		t.Fatalf("reconnecting = %+v, want attempt 1 with a cause", ch)
+	}
+	if ch := waitState(t, states, StateConnected); ch.Attempt != 1 {
+		t.Fatalf("connected after reconnect: attempt = %d", ch.Attempt)
+	}
+	if got := hub.targets(); len(got) != 2 || got[0] != "JoinPipeline" || got[1] != "JoinPipeline" {
+		t.Fatalf("invoked = %v, want JoinPipeline twice", got)
+	}
+
+	hub.push("Room.MessageReceived", "after reconnect")
+	select {
+	case s := <-received:
+		if s != "after reconnect" {
+			t.Fatalf("received %q", s)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler not called on the new connection")
+	}
+
+	rc.Close()
+	select {
+	case <-rc.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Done not closed after Close")
+	}
+	if rc.Err() != nil || rc.State() != StateClosed {
+		t.Fatalf("after Close: err=%v state=%s", rc.Err(), rc.State())
+	}
+}
+
+func TestReconnectingConnRefreshesRejectedToken(t *testing.T) {
+	hub, client := newFakeHub(t, "fresh")
+	refreshes := 0
+	rc := client.NewReconnectingConn(HubOptions{
+		Token: "stale",
+		RefreshToken: func(context.Context) (string, error) {
+			refreshes++
+			return "fresh", nil
+		},
+	})
+	if err := rc.Connect(context.Background()); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer rc.Close()
+	if refreshes != 1 || rc.Token() != "fresh" {
+		t.Fatalf("refreshes=%d token=%q", refreshes, rc.Token())
+	}
+	if err := rc.InvokeVoid(context.Background(), "JoinPipeline"); err != nil {
+		t.Fatalf("invoke: %v", err)
+	}
+	if got := hub.targets(); len(got) != 1 {
+		t.Fatalf("invoked = %v", got)
+	}
+}
+
+func TestReconnectingConnConnectErrorIsNotRetried(t *testing.T) {
+	_, client := newFakeHub(t, "tok")
+	rc := client.NewReconnectingConn(HubOptions{Token: "bad"})
+	err := rc.Connect(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("err = %v, want 401", err)
+	}
+	select {
+	case <-rc.Done():
+	default:
+		t.Fatal("Done not closed after failed Connect")
+	}
+}
+
+func TestReconnectingConnGivesUpAfterMaxAttempts(t *testing.T) {
+	hub, client := newFakeHub(t, "tok")
+	rc := client.NewReconnectingConn(HubOptions{
+		Token:       "tok",
+		MinBackoff:  time.Millisecond,
+		MaxBackoff:  time.Millisecond,
+		MaxAttempts: 2,
+	})
+	if err := rc.Connect(context.Background()); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	hub.mu.Lock()
+	hub.valid = map[string]bool{}
+	hub.mu.Unlock()
+	hub.drop()
+
+	select {
+	case <-rc.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("did not give up")
+	}
+	if err := rc.Err(); err == nil || !strings.Contains(err.Error(), "after 2 reconnect attempts") {
+		t.Fatalf("err = %v", err)
+	}
+	if _, err := rc.Invoke(context.Background(), "JoinPipeline"); err != ErrNotConnected {
+		t.Fatalf("invoke after give-up: %v", err)
+	}
+}
+
+func TestTokenExpiresWithin(t *testing.T) {
+	jwt := func(exp time.Time) string {
+		payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"sub":"bot","exp":%d}`, exp.Unix())))
+		return "eyJhbGciOiJIUzI1NiJ9." + payload + ".sig"
+	}
+	if !tokenExpiresWithin(jwt(time.Now().Add(30*time.Second)), time.Minute) {
+		t.Fatal("token expiring in 30s should need a refresh")
+	}
+	if tokenExpiresWithin(jwt(time.Now().Add(time.Hour)), time.Minute) {
+		t.Fatal("token valid for an hour should not need a refresh")
+	}
+	if tokenExpiresWithin("plain-api-key", time.Minute) {
+		t.Fatal("non-JWT tokens never expire")
+	}
+}
+
+func TestRoomCursorMissed(t *testing.T) {
+	c := NewRoomCursor()
+	msg := func(at, content string) RoomMessageDto {
+		return RoomMessageDto{SenderBotId: "b1", Content: content, SentAt: "2026-10-17T10:" + at + "Z"}
+	}
+	for _, m := range []RoomMessageDto{msg("00:00", "a"), msg("01:00", "b")} {
+		if !c.Observe(m) {
+			t.Fatalf("first Observe(%q) = false", m.Content)
+		}
+	}
+	if c.Observe(msg("01:00", "b")) {
+		t.Fatal("duplicate observed as new")
+	}
+
+	// Backfill after a reconnect returns newest-first history.
+	missed := c.Missed([]RoomMessageDto{
+		msg("03:00", "d"), msg("02:00", "c"), msg("01:00", "b"), msg("00:00", "a"),
+	})
+	if len(missed) != 2 || missed[0].Content != "c" || missed[1].Content != "d" {
+		t.Fatalf("missed = %+v, want c, d", missed)
+	}
+	if again := c.Missed([]RoomMessageDto{msg("03:00", "d")}); len(again) != 0 {
+		t.Fatalf("second backfill = %+v", again)
+	}
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// roomCursorKeep bounds how far back a RoomCursor remembers message keys;
+// older messages are filtered by timestamp alone.
+const roomCursorKeep = 10 * time.Minute
+
+// RoomCursor remembers which room messages a listener has already shown,
+// so that after a reconnect only the messages missed in between are
+// printed from RoomGetMessages.
+type RoomCursor struct {
+	mu     sync.Mutex
+	latest time.Time
+	seen   map[string]time.Time
+}
+
+// NewRoomCursor returns an empty cursor.
+func NewRoomCursor() *RoomCursor {
+	return &RoomCursor{seen: make(map[string]time.Time)}
+}
+
+// Observe records msg and reports whether it had not been seen before.
+// Messages without a SentAt cannot be told apart and always count as new.
+func (c *RoomCursor) Observe(msg RoomMessageDto) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.observe(msg)
+}
+
+// Missed returns the messages in msgs that were not observed yet, oldest
+// first, and records them. Messages older than the newest one observed are
+// treated as seen.
+func (c *RoomCursor) Missed(msgs []RoomMessageDto) []RoomMessageDto {
+	sorted := append([]RoomMessageDto(nil), msgs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return messageTime(sorted[i]).Before(messageTime(sorted[j]))
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var missed []RoomMessageDto
+	for _, msg := range sorted {
+		if t := messageTime(msg); !t.IsZero() && t.Before(c.latest) {
+			continue
+		}
+		if c.observe(msg) {
+			missed = append(missed, msg)
+		}
+	}
+	return missed
+}
+
+func (c *RoomCursor) observe(msg RoomMessageDto) bool {
+	if msg.SentAt == "" {
+		return true
+	}
+	key := msg.SentAt + "\x00" + msg.SenderBotId + "\x00" + msg.Content
+	if _, ok := c.seen[key]; ok {
+		return false
+	}
+	t := messageTime(msg)
+	if t.After(c.latest) {
+		c.latest = t
+	}
+	c.seen[key] = t
+	if len(c.seen) > 512 {
+		for k, seenAt := range c.seen {
+			if seenAt.Before(c.latest.Add(-roomCursorKeep)) {
+				delete(c.seen, k)
+			}
+		}
+	}
+	return true
+}
+
+func messageTime(msg RoomMessageDto) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, msg.SentAt)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
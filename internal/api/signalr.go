@@ -1,9 +1,12 @@
 package api
 
-// SignalR JSON protocol WebSocket client for TowerHub.
+// SignalR WebSocket client for TowerHub.
 //
 // Protocol reference:
-//   - Messages are delimited by ASCII 0x1e (Unit Separator)
+//   - The handshake is JSON delimited by ASCII 0x1e (Unit Separator)
+//   - JSON protocol: messages are delimited by 0x1e
+//   - MessagePack protocol: messages are arrays prefixed by a varint length
+//     (see hubprotocol.go)
 //   - Type 1: Invocation (client→server call, or server→client push)
 //   - Type 3: Completion (server→client result for an invocation)
 //   - Type 6: Ping / Pong
@@ -13,6 +16,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -28,6 +32,9 @@ import (
 const (
 	signalrDelimiter = byte(0x1e)
 	signalrHubPath   = "/api/tower"
+	// signalrServerTimeout is how long the connection may stay silent before
+	// it is considered dead. The hub sends a keep-alive ping every 15 s.
+	signalrServerTimeout = 60 * time.Second
 )
 
 // signalrMsg is the common envelope for all SignalR messages.
@@ -51,6 +58,7 @@ type PushHandler func(args []json.RawMessage)
 // SignalRConn is a single SignalR connection to TowerHub.
 type SignalRConn struct {
 	conn      *websocket.Conn
+	protocol  hubProtocol
 	handlers  map[string]PushHandler
 	pending   map[string]chan invocationResult
 	mu        sync.RWMutex
@@ -60,13 +68,21 @@ type SignalRConn struct {
 	closeErr  atomic.Value
 }
 
+// negotiateResult is the part of the negotiate response the client uses.
+type negotiateResult struct {
+	connectionToken string
+	// transferFormats are those offered for the WebSockets transport; nil
+	// when the server does not list transports.
+	transferFormats []string
+}
+
 // negotiate performs the SignalR negotiate handshake (POST /negotiate) and
 // returns the connectionToken to use when opening the WebSocket.
-func (c *Client) negotiate(ctx context.Context, token string) (string, error) {
+func (c *Client) negotiate(ctx context.Context, token string) (negotiateResult, error) {
 	negotiateURL := c.baseURL + signalrHubPath + "/negotiate?negotiateVersion=1"
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, negotiateURL, nil)
 	if err != nil {
-		return "", fmt.Errorf("build negotiate request: %w", err)
+		return negotiateResult{}, fmt.Errorf("build negotiate request: %w", err)
 	}
 	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Content-Length", "0")
@@ -74,37 +90,69 @@ func (c *Client) negotiate(ctx context.Context, token string) (string, error) {
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("negotiate: %w", err)
+		return negotiateResult{}, fmt.Errorf("negotiate: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, _ := io.ReadAll(resp.Body)
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("negotiate failed (%d): %s", resp.StatusCode, string(body))
+		return negotiateResult{}, newError("negotiate", resp.StatusCode, resp.Header, body)
 	}
 
 	var result struct {
-		ConnectionToken string `json:"connectionToken"`
-		ConnectionId    string `json:"connectionId"`
+		ConnectionToken     string `json:"connectionToken"`
+		ConnectionId        string `json:"connectionId"`
+		AvailableTransports []struct {
+			Transport       string   `json:"transport"`
+			TransferFormats []string `json:"transferFormats"`
+		} `json:"availableTransports"`
 	}
 	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("parse negotiate response: %w", err)
+		return negotiateResult{}, fmt.Errorf("parse negotiate response: %w", err)
+	}
+	out := negotiateResult{connectionToken: result.ConnectionToken}
+	if out.connectionToken == "" {
+		out.connectionToken = result.ConnectionId
 	}
-	if result.ConnectionToken != "" {
-		return result.ConnectionToken, nil
+	for _, t := range result.AvailableTransports {
+		if t.Transport == "WebSockets" {
+			out.transferFormats = append([]string{}, t.TransferFormats...)
+		}
 	}
-	return result.ConnectionId, nil
+	return out, nil
 }
 
 // ConnectToHub establishes a SignalR WebSocket connection to TowerHub.
 // Performs the negotiate step first to obtain a connectionToken, which
 // is required by ASP.NET Core SignalR to bind authentication context.
+//
+// With hub_protocol set to messagepack the binary protocol is tried first;
+// if the hub does not offer it, the client connects again using JSON.
 func (c *Client) ConnectToHub(ctx context.Context, token string) (*SignalRConn, error) {
+	protocols := c.hubProtocols()
+	for i, protocol := range protocols {
+		sc, err := c.connectToHub(ctx, token, protocol)
+		var protoErr *hubProtocolError
+		if err != nil && errors.As(err, &protoErr) && i < len(protocols)-1 {
+			continue
+		}
+		return sc, err
+	}
+	return nil, errors.New("no hub protocol configured")
+}
+
+func (c *Client) connectToHub(ctx context.Context, token string, protocol hubProtocol) (*SignalRConn, error) {
 	// Step 1: negotiate → get connectionToken
-	connToken, err := c.negotiate(ctx, token)
+	negotiated, err := c.negotiate(ctx, token)
 	if err != nil {
 		return nil, fmt.Errorf("SignalR negotiate: %w", err)
 	}
+	if negotiated.transferFormats != nil && !containsString(negotiated.transferFormats, protocol.transferFormat()) {
+		return nil, &hubProtocolError{
+			Protocol: protocol.name(),
+			Reason:   fmt.Sprintf("WebSockets transport offers %s", strings.Join(negotiated.transferFormats, ", ")),
+		}
+	}
 
 	// Step 2: open WebSocket with id=connectionToken and access_token for JWT auth
 	wsBase := strings.Replace(c.baseURL, "https://", "wss://", 1)
@@ -115,7 +163,7 @@ func (c *Client) ConnectToHub(ctx context.Context, token string) (*SignalRConn,
 		return nil, fmt.Errorf("parse hub URL: %w", err)
 	}
 	q := u.Query()
-	q.Set("id", connToken)
+	q.Set("id", negotiated.connectionToken)
 	q.Set("access_token", token)
 	u.RawQuery = q.Encode()
 
@@ -131,13 +179,14 @@ func (c *Client) ConnectToHub(ctx context.Context, token string) (*SignalRConn,
 
 	sc := &SignalRConn{
 		conn:     conn,
+		protocol: protocol,
 		handlers: make(map[string]PushHandler),
 		pending:  make(map[string]chan invocationResult),
 		done:     make(chan struct{}),
 	}
 
-	// SignalR JSON handshake
-	handshake := fmt.Sprintf(`{"protocol":"json","version":1}%c`, signalrDelimiter)
+	// SignalR handshake (always JSON, whatever the hub protocol)
+	handshake := fmt.Sprintf(`{"protocol":%q,"version":1}%c`, protocol.name(), signalrDelimiter)
 	if err := conn.WriteMessage(websocket.TextMessage, []byte(handshake)); err != nil {
 		conn.Close()
 		return nil, fmt.Errorf("send SignalR handshake: %w", err)
@@ -158,6 +207,9 @@ func (c *Client) ConnectToHub(ctx context.Context, token string) (*SignalRConn,
 			}
 			if json.Unmarshal(part, &hsErr) == nil && hsErr.Error != "" {
 				conn.Close()
+				if strings.Contains(strings.ToLower(hsErr.Error), "protocol") {
+					return nil, &hubProtocolError{Protocol: protocol.name(), Reason: hsErr.Error}
+				}
 				return nil, fmt.Errorf("SignalR handshake rejected: %s", hsErr.Error)
 			}
 		}
@@ -241,41 +293,47 @@ func (sc *SignalRConn) Close() {
 	})
 }
 
+// Protocol returns the hub protocol in use: json or messagepack.
+func (sc *SignalRConn) Protocol() string {
+	return sc.protocol.name()
+}
+
 // Done returns a channel closed when the connection is lost or closed.
 func (sc *SignalRConn) Done() <-chan struct{} {
 	return sc.done
 }
 
+// Err returns why the connection ended, or nil while it is open or after a
+// clean Close.
+func (sc *SignalRConn) Err() error {
+	if err, ok := sc.closeErr.Load().(error); ok {
+		return err
+	}
+	return nil
+}
+
 // ── internal ──────────────────────────────────────────────────────────────────
 
 func (sc *SignalRConn) writeMsg(msg signalrMsg) error {
-	data, err := json.Marshal(msg)
+	data, err := sc.protocol.encode(msg)
 	if err != nil {
-		return fmt.Errorf("marshal SignalR message: %w", err)
+		return err
 	}
-	data = append(data, signalrDelimiter)
 	sc.mu.Lock()
 	defer sc.mu.Unlock()
-	return sc.conn.WriteMessage(websocket.TextMessage, data)
+	return sc.conn.WriteMessage(sc.protocol.wsMessageType(), data)
 }
 
 func (sc *SignalRConn) readLoop() {
 	defer close(sc.done)
 	for {
+		_ = sc.conn.SetReadDeadline(time.Now().Add(signalrServerTimeout))
 		_, data, err := sc.conn.ReadMessage()
 		if err != nil {
 			sc.closeErr.Store(fmt.Errorf("signalr read failed: %w", err))
 			return
 		}
-		for _, part := range splitSignalR(data) {
-			part = bytes.TrimSpace(part)
-			if len(part) == 0 {
-				continue
-			}
-			var msg signalrMsg
-			if err := json.Unmarshal(part, &msg); err != nil {
-				continue
-			}
+		for _, msg := range sc.protocol.decode(data) {
 			switch msg.Type {
 			case 1: // Invocation / push
 				if msg.InvocationId != "" {
@@ -300,7 +358,10 @@ func (sc *SignalRConn) readLoop() {
 					}
 				}
 			case 7: // Close
-				err := fmt.Errorf("signalr close frame: %s", string(part))
+				err := errors.New("signalr close frame")
+				if msg.Error != "" {
+					err = fmt.Errorf("signalr close frame: %s", msg.Error)
+				}
 				sc.closeErr.Store(err)
 				sc.conn.Close()
 				return
@@ -315,10 +376,7 @@ func (sc *SignalRConn) pingLoop() {
 	for {
 		select {
 		case <-ticker.C:
-			ping := fmt.Sprintf(`{"type":6}%c`, signalrDelimiter)
-			sc.mu.Lock()
-			_ = sc.conn.WriteMessage(websocket.TextMessage, []byte(ping))
-			sc.mu.Unlock()
+			_ = sc.writeMsg(signalrMsg{Type: 6})
 		case <-sc.done:
 			return
 		}
@@ -328,3 +386,12 @@ func (sc *SignalRConn) pingLoop() {
 func splitSignalR(data []byte) [][]byte {
 	return bytes.Split(data, []byte{signalrDelimiter})
 }
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -11,6 +11,12 @@ import (
 	"moltbb-cli/internal/utils"
 )
 
+// Errors returned when no API key is configured.
+var (
+	ErrNoCredentials = errors.New("credentials file not found")
+	ErrNoAPIKey      = errors.New("credentials missing api_key")
+)
+
 type Credentials struct {
 	APIKey    string    `json:"api_key"`
 	Token     string    `json:"token,omitempty"`
@@ -23,7 +29,7 @@ func Load() (Credentials, error) {
 		return Credentials{}, err
 	}
 	if !utils.FileExists(path) {
-		return Credentials{}, errors.New("credentials file not found")
+		return Credentials{}, ErrNoCredentials
 	}
 
 	data, err := os.ReadFile(path)
@@ -36,7 +42,7 @@ func Load() (Credentials, error) {
 		return Credentials{}, fmt.Errorf("parse credentials json: %w", err)
 	}
 	if strings.TrimSpace(c.APIKey) == "" {
-		return Credentials{}, errors.New("credentials missing api_key")
+		return Credentials{}, ErrNoAPIKey
 	}
 	return c, nil
 }
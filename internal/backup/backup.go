@@ -0,0 +1,455 @@
+// Package backup packs the ~/.moltbb state directory into a versioned
+// tar.gz archive with a manifest and checksums, and restores it elsewhere.
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"moltbb-cli/internal/localweb"
+)
+
+// FormatVersion is the archive layout version written by Create.
+const FormatVersion = 1
+
+const (
+	manifestName = "manifest.json"
+	filesPrefix  = "files/"
+	sealedSuffix = ".sealed"
+	localDBPath  = "local-web/local.db"
+)
+
+// secretFiles are sealed with the passphrase: the API credentials and the
+// pipeline private keys.
+var secretFiles = map[string]bool{
+	"credentials.json":   true,
+	"pipeline-keys.json": true,
+}
+
+type Manifest struct {
+	FormatVersion int       `json:"formatVersion"`
+	CreatedAt     time.Time `json:"createdAt"`
+	CLIVersion    string    `json:"cliVersion,omitempty"`
+	Hostname      string    `json:"hostname,omitempty"`
+	// DBSchemaVersion is local-web/local.db's schema version, 0 when the
+	// backup holds no database.
+	DBSchemaVersion int         `json:"dbSchemaVersion,omitempty"`
+	Files           []FileEntry `json:"files"`
+}
+
+// FileEntry describes one archived file. Path is slash-separated and
+// relative to the state directory; Size and SHA256 cover the stored bytes,
+// which are ciphertext when Encrypted is set.
+type FileEntry struct {
+	Path      string      `json:"path"`
+	Size      int64       `json:"size"`
+	Mode      fs.FileMode `json:"mode"`
+	SHA256    string      `json:"sha256"`
+	Encrypted bool        `json:"encrypted,omitempty"`
+}
+
+type CreateOptions struct {
+	// SourceDir is the state directory, normally utils.MoltbbDir().
+	SourceDir string
+	// Passphrase encrypts credentials.json and pipeline-keys.json. It is
+	// required unless SkipCredentials is set or neither file exists.
+	Passphrase string
+	// SkipCredentials leaves both secret files out of the archive.
+	SkipCredentials bool
+	// Exclude lists absolute paths to leave out, such as the archive itself
+	// when it is written inside SourceDir.
+	Exclude    []string
+	CLIVersion string
+	Hostname   string
+}
+
+type RestoreOptions struct {
+	TargetDir  string
+	Passphrase string
+	// Force allows overwriting existing state and restoring a database from
+	// a newer schema than this build knows.
+	Force bool
+}
+
+// RestoreResult reports what Restore did.
+type RestoreResult struct {
+	Manifest Manifest
+	// Restored lists the written paths relative to TargetDir.
+	Restored []string
+	// DBUpgradedFrom is the restored database's schema version before it was
+	// opened and upgraded; equal to the manifest value when nothing changed.
+	DBUpgradedFrom int
+	DBSchemaNow    int
+}
+
+// Create writes a backup archive of opts.SourceDir to w.
+func Create(w io.Writer, opts CreateOptions) (Manifest, error) {
+	src := filepath.Clean(opts.SourceDir)
+	if _, err := os.Stat(src); err != nil {
+		return Manifest{}, fmt.Errorf("state directory: %w", err)
+	}
+	exclude := make(map[string]struct{}, len(opts.Exclude))
+	for _, p := range opts.Exclude {
+		exclude[filepath.Clean(p)] = struct{}{}
+	}
+
+	manifest := Manifest{
+		FormatVersion: FormatVersion,
+		CreatedAt:     time.Now().UTC(),
+		CLIVersion:    opts.CLIVersion,
+		Hostname:      opts.Hostname,
+	}
+	type payload struct {
+		entry FileEntry
+		data  []byte
+	}
+	var payloads []payload
+
+	err := filepath.WalkDir(src, func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if _, ok := exclude[p]; ok {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !d.Type().IsRegular() || skipStateFile(d.Name()) {
+			return nil
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		var data []byte
+		entry := FileEntry{Path: rel, Mode: info.Mode().Perm()}
+		switch {
+		case secretFiles[rel]:
+			if opts.SkipCredentials {
+				return nil
+			}
+			plain, err := os.ReadFile(p)
+			if err != nil {
+				return fmt.Errorf("read %s: %w", rel, err)
+			}
+			if data, err = seal(plain, opts.Passphrase); err != nil {
+				return err
+			}
+			entry.Encrypted = true
+		case strings.HasSuffix(rel, ".db"):
+			var version int
+			if data, version, err = snapshotDB(p); err != nil {
+				return err
+			}
+			if rel == localDBPath {
+				manifest.DBSchemaVersion = version
+			}
+		default:
+			if data, err = os.ReadFile(p); err != nil {
+				return fmt.Errorf("read %s: %w", rel, err)
+			}
+		}
+
+		sum := sha256.Sum256(data)
+		entry.Size = int64(len(data))
+		entry.SHA256 = hex.EncodeToString(sum[:])
+		payloads = append(payloads, payload{entry: entry, data: data})
+		return nil
+	})
+	if err != nil {
+		return Manifest{}, err
+	}
+	sort.Slice(payloads, func(i, j int) bool { return payloads[i].entry.Path < payloads[j].entry.Path })
+
+	manifest.Files = make([]FileEntry, 0, len(payloads))
+	data := make(map[string][]byte, len(payloads))
+	for _, p := range payloads {
+		manifest.Files = append(manifest.Files, p.entry)
+		data[p.entry.Path] = p.data
+	}
+	if err := writeArchive(w, manifest, data); err != nil {
+		return Manifest{}, err
+	}
+	return manifest, nil
+}
+
+// writeArchive writes the manifest followed by each listed file, keyed by
+// manifest path in data.
+func writeArchive(w io.Writer, manifest Manifest, data map[string][]byte) error {
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal manifest: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	if err := writeTarFile(tw, manifestName, 0o600, manifest.CreatedAt, manifestJSON); err != nil {
+		return err
+	}
+	for _, f := range manifest.Files {
+		if err := writeTarFile(tw, archiveName(f), f.Mode, manifest.CreatedAt, data[f.Path]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("finish tar: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("finish gzip: %w", err)
+	}
+	return nil
+}
+
+// Inspect reads an archive and verifies every file against the manifest
+// checksums without writing anything.
+func Inspect(r io.Reader) (Manifest, error) {
+	manifest, _, err := readArchive(r)
+	return manifest, err
+}
+
+// Restore verifies the archive, decrypts credentials and writes the state
+// into opts.TargetDir. Nothing is written unless every checksum matches and
+// the passphrase is correct. The restored database is opened once so it is
+// upgraded to this build's schema.
+func Restore(r io.Reader, opts RestoreOptions) (RestoreResult, error) {
+	manifest, files, err := readArchive(r)
+	if err != nil {
+		return RestoreResult{}, err
+	}
+	if manifest.DBSchemaVersion > localweb.SchemaVersion && !opts.Force {
+		return RestoreResult{}, fmt.Errorf("backup database schema v%d is newer than this build supports (v%d); upgrade moltbb or use --force",
+			manifest.DBSchemaVersion, localweb.SchemaVersion)
+	}
+
+	target := filepath.Clean(opts.TargetDir)
+	if !opts.Force {
+		for _, f := range manifest.Files {
+			if _, err := os.Stat(filepath.Join(target, filepath.FromSlash(f.Path))); err == nil {
+				return RestoreResult{}, fmt.Errorf("%s already contains %s; use --force to overwrite", target, f.Path)
+			}
+		}
+	}
+
+	for _, f := range manifest.Files {
+		if !f.Encrypted {
+			continue
+		}
+		plain, err := unseal(files[f.Path], opts.Passphrase)
+		if err != nil {
+			return RestoreResult{}, fmt.Errorf("decrypt %s: %w", f.Path, err)
+		}
+		files[f.Path] = plain
+	}
+
+	result := RestoreResult{Manifest: manifest}
+	if err := os.MkdirAll(target, 0o700); err != nil {
+		return RestoreResult{}, fmt.Errorf("create %s: %w", target, err)
+	}
+	for _, f := range manifest.Files {
+		dest := filepath.Join(target, filepath.FromSlash(f.Path))
+		if err := writeFileAtomic(dest, files[f.Path], f.Mode); err != nil {
+			return result, err
+		}
+		if strings.HasSuffix(f.Path, ".db") {
+			// Leftover WAL files belong to the database being replaced and
+			// would be replayed on top of the restored one.
+			_ = os.Remove(dest + "-wal")
+			_ = os.Remove(dest + "-shm")
+		}
+		result.Restored = append(result.Restored, f.Path)
+	}
+
+	result.DBUpgradedFrom = manifest.DBSchemaVersion
+	result.DBSchemaNow = manifest.DBSchemaVersion
+	if _, ok := files[localDBPath]; ok && manifest.DBSchemaVersion <= localweb.SchemaVersion {
+		db, err := localweb.OpenDB(filepath.Join(target, filepath.FromSlash(localDBPath)))
+		if err != nil {
+			return result, fmt.Errorf("open restored database: %w", err)
+		}
+		defer db.Close()
+		if result.DBSchemaNow, err = localweb.DBSchemaVersion(db); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func readArchive(r io.Reader) (Manifest, map[string][]byte, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return Manifest{}, nil, fmt.Errorf("open backup archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	var manifest Manifest
+	haveManifest := false
+	files := make(map[string][]byte)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Manifest{}, nil, fmt.Errorf("read backup archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return Manifest{}, nil, fmt.Errorf("read %s: %w", hdr.Name, err)
+		}
+		if hdr.Name == manifestName {
+			if err := json.Unmarshal(data, &manifest); err != nil {
+				return Manifest{}, nil, fmt.Errorf("parse manifest: %w", err)
+			}
+			haveManifest = true
+			continue
+		}
+		files[hdr.Name] = data
+	}
+	if !haveManifest {
+		return Manifest{}, nil, errors.New("backup archive has no manifest")
+	}
+	if manifest.FormatVersion < 1 || manifest.FormatVersion > FormatVersion {
+		return Manifest{}, nil, fmt.Errorf("unsupported backup format v%d (this build reads up to v%d)", manifest.FormatVersion, FormatVersion)
+	}
+
+	byPath := make(map[string][]byte, len(manifest.Files))
+	for _, f := range manifest.Files {
+		if !validRelPath(f.Path) {
+			return Manifest{}, nil, fmt.Errorf("backup manifest has unsafe path %q", f.Path)
+		}
+		data, ok := files[archiveName(f)]
+		if !ok {
+			return Manifest{}, nil, fmt.Errorf("backup is missing %s", f.Path)
+		}
+		sum := sha256.Sum256(data)
+		if int64(len(data)) != f.Size || hex.EncodeToString(sum[:]) != f.SHA256 {
+			return Manifest{}, nil, fmt.Errorf("checksum mismatch for %s", f.Path)
+		}
+		byPath[f.Path] = data
+	}
+	return manifest, byPath, nil
+}
+
+// snapshotDB copies a SQLite database with VACUUM INTO so the copy is
+// consistent even while the studio holds it open in WAL mode.
+func snapshotDB(dbPath string) ([]byte, int, error) {
+	tmpDir, err := os.MkdirTemp("", "moltbb-backup-")
+	if err != nil {
+		return nil, 0, fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("open %s: %w", dbPath, err)
+	}
+	defer db.Close()
+
+	version, err := localweb.DBSchemaVersion(db)
+	if err != nil {
+		return nil, 0, err
+	}
+	snapshot := filepath.Join(tmpDir, "snapshot.db")
+	if _, err := db.Exec(`VACUUM INTO ?`, snapshot); err != nil {
+		return nil, 0, fmt.Errorf("snapshot %s: %w", dbPath, err)
+	}
+	data, err := os.ReadFile(snapshot)
+	if err != nil {
+		return nil, 0, fmt.Errorf("read snapshot: %w", err)
+	}
+	return data, version, nil
+}
+
+// skipStateFile filters runtime leftovers that must not travel between
+// machines: SQLite side files, pid files, sockets and locks.
+func skipStateFile(name string) bool {
+	for _, suffix := range []string{"-wal", "-shm", "-journal", ".pid", ".sock", ".lock"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func archiveName(f FileEntry) string {
+	name := filesPrefix + f.Path
+	if f.Encrypted {
+		name += sealedSuffix
+	}
+	return name
+}
+
+func validRelPath(p string) bool {
+	if p == "" || strings.HasPrefix(p, "/") || strings.Contains(p, `\`) {
+		return false
+	}
+	clean := path.Clean(p)
+	return clean == p && clean != ".." && !strings.HasPrefix(clean, "../")
+}
+
+func writeTarFile(tw *tar.Writer, name string, mode fs.FileMode, modTime time.Time, data []byte) error {
+	if mode == 0 {
+		mode = 0o600
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     int64(mode),
+		Size:     int64(len(data)),
+		ModTime:  modTime,
+		Typeflag: tar.TypeReg,
+	}); err != nil {
+		return fmt.Errorf("write tar header %s: %w", name, err)
+	}
+	if _, err := io.Copy(tw, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("write tar entry %s: %w", name, err)
+	}
+	return nil
+}
+
+func writeFileAtomic(dest string, data []byte, mode fs.FileMode) error {
+	if mode == 0 {
+		mode = 0o600
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0o700); err != nil {
+		return fmt.Errorf("create %s: %w", filepath.Dir(dest), err)
+	}
+	tmp := dest + ".restore-tmp"
+	if err := os.WriteFile(tmp, data, mode); err != nil {
+		return fmt.Errorf("write %s: %w", dest, err)
+	}
+	if err := os.Chmod(tmp, mode); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("set permission %s: %w", dest, err)
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("replace %s: %w", dest, err)
+	}
+	return nil
+}
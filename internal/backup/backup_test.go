@@ -0,0 +1,209 @@
+package backup
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"moltbb-cli/internal/diary"
+	"moltbb-cli/internal/localweb"
+)
+
+func init() {
+	pbkdf2Iterations = 1000
+}
+
+func writeState(t *testing.T, dir string) {
+	t.Helper()
+	files := map[string]string{
+		"config.yaml":            "api_base_url: https://api.moltbb.com\n",
+		"credentials.json":       `{"api_key":"secret-key"}`,
+		"binding.json":           `{"bound":true,"fingerprint":"old-host"}`,
+		"templates/daily.md":     "# {{date}}\n",
+		"local-web/local.db-wal": "stale",
+		"daemon.pid":             "123",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = os.Remove(filepath.Join(dir, "local-web", "local.db-wal"))
+
+	db, err := localweb.OpenDB(filepath.Join(dir, "local-web", "local.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := localweb.SaveSyncRecord(db, diary.SyncRecord{DiaryDate: "2026-03-14", ContentHash: "abc", RemoteDiaryID: "remote-14", SyncedAt: "2026-03-14T10:00:00Z"}); err != nil {
+		t.Fatalf("save sync record: %v", err)
+	}
+	db.Close()
+}
+
+func TestCreateAndRestore_RoundTrip(t *testing.T) {
+	src := t.TempDir()
+	writeState(t, src)
+
+	var buf bytes.Buffer
+	manifest, err := Create(&buf, CreateOptions{SourceDir: src, Passphrase: "hunter2", CLIVersion: "v-test"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if manifest.DBSchemaVersion != localweb.SchemaVersion {
+		t.Fatalf("expected db schema v%d, got v%d", localweb.SchemaVersion, manifest.DBSchemaVersion)
+	}
+	var paths []string
+	for _, f := range manifest.Files {
+		paths = append(paths, f.Path)
+		if f.Path == "credentials.json" && !f.Encrypted {
+			t.Fatalf("credentials must be encrypted")
+		}
+	}
+	if got := strings.Join(paths, ","); got != "binding.json,config.yaml,credentials.json,local-web/local.db,templates/daily.md" {
+		t.Fatalf("unexpected archived files %q", got)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("secret-key")) {
+		t.Fatalf("archive leaks the plaintext api key")
+	}
+
+	archive := buf.Bytes()
+	target := filepath.Join(t.TempDir(), "restored")
+	if _, err := Restore(bytes.NewReader(archive), RestoreOptions{TargetDir: target, Passphrase: "wrong"}); !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("expected wrong passphrase error, got %v", err)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Fatalf("a failed restore must not write anything")
+	}
+
+	result, err := Restore(bytes.NewReader(archive), RestoreOptions{TargetDir: target, Passphrase: "hunter2"})
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if len(result.Restored) != 5 || result.DBSchemaNow != localweb.SchemaVersion {
+		t.Fatalf("unexpected restore result: %+v", result)
+	}
+	creds, err := os.ReadFile(filepath.Join(target, "credentials.json"))
+	if err != nil || string(creds) != `{"api_key":"secret-key"}` {
+		t.Fatalf("credentials not restored: %q %v", creds, err)
+	}
+
+	db, err := localweb.OpenDB(filepath.Join(target, "local-web", "local.db"))
+	if err != nil {
+		t.Fatalf("open restored db: %v", err)
+	}
+	defer db.Close()
+	records, err := localweb.LoadSyncRecords(db)
+	if err != nil || records["2026-03-14"].RemoteDiaryID != "remote-14" {
+		t.Fatalf("restored db lost data: %+v %v", records, err)
+	}
+
+	if _, err := Restore(bytes.NewReader(archive), RestoreOptions{TargetDir: target, Passphrase: "hunter2"}); err == nil {
+		t.Fatalf("expected restore into existing state to require Force")
+	}
+}
+
+func TestCreate_RequiresPassphraseUnlessSkippingCredentials(t *testing.T) {
+	src := t.TempDir()
+	writeState(t, src)
+
+	if _, err := Create(&bytes.Buffer{}, CreateOptions{SourceDir: src}); err == nil {
+		t.Fatalf("expected an error without a passphrase")
+	}
+	var buf bytes.Buffer
+	manifest, err := Create(&buf, CreateOptions{SourceDir: src, SkipCredentials: true})
+	if err != nil {
+		t.Fatalf("create without credentials: %v", err)
+	}
+	for _, f := range manifest.Files {
+		if f.Path == "credentials.json" {
+			t.Fatalf("credentials should be skipped")
+		}
+	}
+}
+
+func TestCreate_SealsPipelineKeys(t *testing.T) {
+	src := t.TempDir()
+	if err := os.WriteFile(filepath.Join(src, "pipeline-keys.json"), []byte(`{"self":{"private":"private-key"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Create(&bytes.Buffer{}, CreateOptions{SourceDir: src}); err == nil {
+		t.Fatalf("expected an error without a passphrase")
+	}
+	var buf bytes.Buffer
+	manifest, err := Create(&buf, CreateOptions{SourceDir: src, Passphrase: "hunter2"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if len(manifest.Files) != 1 || !manifest.Files[0].Encrypted {
+		t.Fatalf("pipeline keys must be encrypted: %+v", manifest.Files)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("private-key")) {
+		t.Fatalf("archive leaks the pipeline private key")
+	}
+
+	target := filepath.Join(t.TempDir(), "restored")
+	if _, err := Restore(bytes.NewReader(buf.Bytes()), RestoreOptions{TargetDir: target, Passphrase: "hunter2"}); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	keys, err := os.ReadFile(filepath.Join(target, "pipeline-keys.json"))
+	if err != nil || string(keys) != `{"self":{"private":"private-key"}}` {
+		t.Fatalf("pipeline keys not restored: %q %v", keys, err)
+	}
+
+	manifest, err = Create(&bytes.Buffer{}, CreateOptions{SourceDir: src, SkipCredentials: true})
+	if err != nil || len(manifest.Files) != 0 {
+		t.Fatalf("pipeline keys should be skipped: %+v %v", manifest.Files, err)
+	}
+}
+
+func TestInspect_DetectsTampering(t *testing.T) {
+	src := t.TempDir()
+	if err := os.WriteFile(filepath.Join(src, "config.yaml"), []byte("a: b\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := Create(&buf, CreateOptions{SourceDir: src}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	_, files, err := readArchive(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	manifest, _ := Inspect(bytes.NewReader(buf.Bytes()))
+	manifest.Files[0].SHA256 = strings.Repeat("0", 64)
+
+	var tampered bytes.Buffer
+	if err := writeArchive(&tampered, manifest, files); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Inspect(&tampered); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+}
+
+// sealedBeforeXCrypto was sealed by the PBKDF2 implementation that
+// predates golang.org/x/crypto/pbkdf2; archives written by it must still
+// open.
+const sealedBeforeXCrypto = `{"kdf":"pbkdf2-sha256","iterations":1000,"salt":"24nvr1JJrLKLzCzADCoyfA==","nonce":"EO7FQo3ISROWH+vZ","ciphertext":"x1hxfNjHvSZajEJid6ViPdPXr1fJAwumr0QwqNe46RfHfOSJRfyVWg=="}`
+
+func TestUnseal_OpensExistingArchives(t *testing.T) {
+	plain, err := unseal([]byte(sealedBeforeXCrypto), "hunter2")
+	if err != nil {
+		t.Fatalf("unseal: %v", err)
+	}
+	if string(plain) != `{"api_key":"secret-key"}` {
+		t.Fatalf("unexpected plaintext %q", plain)
+	}
+	if _, err := unseal([]byte(sealedBeforeXCrypto), "wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("expected wrong passphrase error, got %v", err)
+	}
+}
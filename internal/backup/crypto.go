@@ -0,0 +1,95 @@
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// ErrWrongPassphrase is returned when sealed data cannot be opened with the
+// given passphrase (or has been tampered with).
+var ErrWrongPassphrase = errors.New("wrong backup passphrase or corrupted data")
+
+const sealedKDF = "pbkdf2-sha256"
+
+// pbkdf2Iterations is a variable so tests can keep key derivation cheap.
+var pbkdf2Iterations = 310000
+
+// sealedFile is the on-disk form of an encrypted file: AES-256-GCM with a
+// key derived from the passphrase.
+type sealedFile struct {
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func seal(plaintext []byte, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase is required to encrypt credentials")
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("generate salt: %w", err)
+	}
+	gcm, err := newGCM(passphrase, salt, pbkdf2Iterations)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	out, err := json.Marshal(sealedFile{
+		KDF:        sealedKDF,
+		Iterations: pbkdf2Iterations,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal sealed file: %w", err)
+	}
+	return out, nil
+}
+
+func unseal(data []byte, passphrase string) ([]byte, error) {
+	var sf sealedFile
+	if err := json.Unmarshal(data, &sf); err != nil {
+		return nil, fmt.Errorf("parse sealed file: %w", err)
+	}
+	if sf.KDF != sealedKDF || sf.Iterations <= 0 {
+		return nil, fmt.Errorf("unsupported key derivation %q", sf.KDF)
+	}
+	gcm, err := newGCM(passphrase, sf.Salt, sf.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(sf.Nonce) != gcm.NonceSize() {
+		return nil, ErrWrongPassphrase
+	}
+	plaintext, err := gcm.Open(nil, sf.Nonce, sf.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return plaintext, nil
+}
+
+func newGCM(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(passphrase), salt, iterations, 32, sha256.New))
+	if err != nil {
+		return nil, fmt.Errorf("init cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("init gcm: %w", err)
+	}
+	return gcm, nil
+}
@@ -0,0 +1,139 @@
+// Package chat holds the terminal-independent parts of the pipeline chat
+// REPL: assembling multi-line messages from input lines and parsing slash
+// commands.
+package chat
+
+import (
+	"sort"
+	"strings"
+)
+
+// BlockDelimiter on a line of its own opens and closes a block whose lines
+// are sent as typed.
+const BlockDelimiter = `"""`
+
+// Composer assembles messages from input lines. A line ending in a
+// backslash continues the message on the next line (end it with two to
+// send one), and so does a line that was pasted; a block between two
+// BlockDelimiter lines is one message.
+type Composer struct {
+	lines []string
+	block bool
+}
+
+// Feed adds one input line. It returns the message and true once the
+// message is complete. An empty message is never returned.
+func (c *Composer) Feed(line string, pasted bool) (string, bool) {
+	if strings.TrimSpace(line) == BlockDelimiter {
+		if c.block {
+			c.block = false
+			return c.flush()
+		}
+		if len(c.lines) == 0 {
+			c.block = true
+			return "", false
+		}
+	}
+	if c.block {
+		c.lines = append(c.lines, line)
+		return "", false
+	}
+	switch {
+	case strings.HasSuffix(line, `\\`):
+		// An escaped backslash ends the line with one backslash.
+		line = strings.TrimSuffix(line, `\`)
+	case strings.HasSuffix(line, `\`):
+		c.lines = append(c.lines, strings.TrimSuffix(line, `\`))
+		return "", false
+	}
+	c.lines = append(c.lines, line)
+	if pasted {
+		return "", false
+	}
+	return c.flush()
+}
+
+func (c *Composer) flush() (string, bool) {
+	msg := strings.Join(c.lines, "\n")
+	c.lines = nil
+	msg = strings.Trim(msg, "\n")
+	if strings.TrimSpace(msg) == "" {
+		return "", false
+	}
+	return msg, true
+}
+
+// Pending reports whether a message is being continued.
+func (c *Composer) Pending() bool {
+	return c.block || len(c.lines) > 0
+}
+
+// Reset drops a partly composed message.
+func (c *Composer) Reset() {
+	c.lines = nil
+	c.block = false
+}
+
+// Command is a parsed slash command.
+type Command struct {
+	Name string
+	// Arg is the rest of the line after the name, trimmed.
+	Arg string
+}
+
+// ParseCommand parses a line starting with "/". A line starting with "//"
+// is a message that starts with "/", and is not a command.
+func ParseCommand(line string) (Command, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "/") || strings.HasPrefix(line, "//") || len(line) == 1 {
+		return Command{}, false
+	}
+	name, arg, _ := strings.Cut(line[1:], " ")
+	return Command{Name: strings.ToLower(name), Arg: strings.TrimSpace(arg)}, true
+}
+
+// Unescape turns a leading "//" back into "/" in a message.
+func Unescape(msg string) string {
+	if strings.HasPrefix(msg, "//") {
+		return msg[1:]
+	}
+	return msg
+}
+
+// Spec describes a slash command for help and completion.
+type Spec struct {
+	Name  string
+	Usage string
+	Help  string
+	// RoomOnly commands are not available in pipeline sessions.
+	RoomOnly bool
+}
+
+// Commands lists the REPL commands.
+var Commands = []Spec{
+	{Name: "file", Usage: "/file <path>", Help: "send the contents of a file"},
+	{Name: "participants", Usage: "/participants", Help: "list who is in the conversation"},
+	{Name: "extend", Usage: "/extend <minutes>", Help: "extend the room lifetime (creator only)", RoomOnly: true},
+	{Name: "leave", Usage: "/leave", Help: "leave and exit"},
+	{Name: "close", Usage: "/close [reason]", Help: "close the room or end the session, and exit"},
+	{Name: "help", Usage: "/help", Help: "show this help"},
+}
+
+// Complete completes a partly typed command name, such as "/par", and
+// returns the candidates in order.
+func Complete(prefix string, room bool) []string {
+	if !strings.HasPrefix(prefix, "/") || strings.Contains(prefix, " ") {
+		return nil
+	}
+	var out []string
+	for _, spec := range Commands {
+		if spec.RoomOnly && !room {
+			continue
+		}
+		if strings.HasPrefix("/"+spec.Name, strings.ToLower(prefix)) {
+			out = append(out, "/"+spec.Name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,93 @@
+package chat
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComposer(t *testing.T) {
+	type in struct {
+		line   string
+		pasted bool
+	}
+	cases := []struct {
+		name  string
+		lines []in
+		want  []string
+	}{
+		{"single line", []in{{"hello", false}}, []string{"hello"}},
+		{"blank line is not sent", []in{{"   ", false}, {"hi", false}}, []string{"hi"}},
+		{"backslash continues", []in{{`first\`, false}, {"second", false}}, []string{"first\nsecond"}},
+		{"escaped backslash ends", []in{{`C:\\`, false}}, []string{`C:\`}},
+		{"paste continues until enter", []in{{"a", true}, {"b", true}, {"c", false}}, []string{"a\nb\nc"}},
+		{"block", []in{{`"""`, false}, {"  indented", false}, {"", false}, {"x\\", false}, {`"""`, false}}, []string{"  indented\n\nx\\"}},
+		{"two messages", []in{{"one", false}, {"two", false}}, []string{"one", "two"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var c Composer
+			var got []string
+			for _, l := range tc.lines {
+				if msg, ok := c.Feed(l.line, l.pasted); ok {
+					got = append(got, msg)
+				}
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+			if c.Pending() {
+				t.Fatal("composer still pending")
+			}
+		})
+	}
+
+	var c Composer
+	c.Feed(`"""`, false)
+	c.Feed("draft", false)
+	if !c.Pending() {
+		t.Fatal("open block not pending")
+	}
+	c.Reset()
+	if msg, ok := c.Feed("fresh", false); !ok || msg != "fresh" {
+		t.Fatalf("after reset: %q %v", msg, ok)
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	cases := []struct {
+		line string
+		want Command
+		ok   bool
+	}{
+		{"/extend 30", Command{Name: "extend", Arg: "30"}, true},
+		{"  /FILE  ./notes/a b.txt ", Command{Name: "file", Arg: "./notes/a b.txt"}, true},
+		{"/close", Command{Name: "close"}, true},
+		{"//not a command", Command{}, false},
+		{"/", Command{}, false},
+		{"hello /leave", Command{}, false},
+	}
+	for _, tc := range cases {
+		got, ok := ParseCommand(tc.line)
+		if ok != tc.ok || got != tc.want {
+			t.Errorf("ParseCommand(%q) = %+v, %v", tc.line, got, ok)
+		}
+	}
+	if got := Unescape("//etc/hosts"); got != "/etc/hosts" {
+		t.Errorf("Unescape = %q", got)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	if got := Complete("/e", true); !reflect.DeepEqual(got, []string{"/extend"}) {
+		t.Errorf("room /e = %v", got)
+	}
+	if got := Complete("/e", false); got != nil {
+		t.Errorf("session /e = %v", got)
+	}
+	if got := Complete("/", false); len(got) != 5 {
+		t.Errorf("session / = %v", got)
+	}
+	if got := Complete("/file x", true); got != nil {
+		t.Errorf("with argument = %v", got)
+	}
+}
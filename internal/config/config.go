@@ -1,10 +1,13 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 
@@ -14,22 +17,125 @@ import (
 const DefaultAPIBaseURL = "https://moltbb.com"
 
 type Config struct {
-	APIBaseURL            string     `yaml:"api_base_url"`
-	AllowInsecureHTTP     bool       `yaml:"allow_insecure_http,omitempty"`
-	InputPaths            []string   `yaml:"input_paths"`
-	OutputDir             string     `yaml:"output_dir"`
-	Template              string     `yaml:"template,omitempty"`
-	RequestTimeoutSeconds int        `yaml:"request_timeout_seconds"`
-	RetryCount            int        `yaml:"retry_count"`
-	OpenClawLogPath       string     `yaml:"openclaw_log_path,omitempty"`
-	DiariesDir            string     `yaml:"diaries_dir,omitempty"`
-	Reminders             []Reminder `yaml:"reminders,omitempty"`
+	APIBaseURL            string         `yaml:"api_base_url"`
+	AllowInsecureHTTP     bool           `yaml:"allow_insecure_http,omitempty"`
+	InputPaths            []string       `yaml:"input_paths"`
+	OutputDir             string         `yaml:"output_dir"`
+	Timezone              string         `yaml:"timezone,omitempty"`
+	Template              string         `yaml:"template,omitempty"`
+	RequestTimeoutSeconds int            `yaml:"request_timeout_seconds"`
+	RetryCount            int            `yaml:"retry_count"`
+	HubProtocol           string         `yaml:"hub_protocol,omitempty"`
+	OpenClawLogPath       string         `yaml:"openclaw_log_path,omitempty"`
+	DiariesDir            string         `yaml:"diaries_dir,omitempty"`
+	Reminders             []Reminder     `yaml:"reminders,omitempty"`
+	LLM                   LLMConfig      `yaml:"llm,omitempty"`
+	Schedule              ScheduleConfig `yaml:"schedule,omitempty"`
+	Notify                NotifyConfig   `yaml:"notify,omitempty"`
+	Tower                 TowerConfig    `yaml:"tower,omitempty"`
 }
 
+// LLMConfig selects the language model used by polish, run --mode=llm and
+// insight drafting. Provider is one of openai, ollama or local.
+type LLMConfig struct {
+	Provider       string  `yaml:"provider,omitempty"`
+	Model          string  `yaml:"model,omitempty"`
+	BaseURL        string  `yaml:"base_url,omitempty"`
+	APIKeyEnv      string  `yaml:"api_key_env,omitempty"`
+	TimeoutSeconds int     `yaml:"timeout_seconds,omitempty"`
+	Temperature    float64 `yaml:"temperature,omitempty"`
+}
+
+// ScheduleConfig lists the jobs the local studio/daemon runs on its own
+// scheduler. Reminders are scheduled in addition to these jobs.
+type ScheduleConfig struct {
+	Disabled bool          `yaml:"disabled,omitempty"`
+	Jobs     []ScheduleJob `yaml:"jobs,omitempty"`
+}
+
+// ScheduleJob is one scheduled job. Kind is run (moltbb run), heartbeat
+// (moltbb tower heartbeat), inbox (moltbb message unread) or command (any
+// moltbb subcommand given in Args). Cron is a five-field cron expression,
+// @daily/@hourly/... or "@every <duration>", in the configured timezone.
+type ScheduleJob struct {
+	Name           string   `yaml:"name"`
+	Kind           string   `yaml:"kind"`
+	Cron           string   `yaml:"cron"`
+	Args           []string `yaml:"args,omitempty"`
+	TimeoutSeconds int      `yaml:"timeout_seconds,omitempty"`
+	Disabled       bool     `yaml:"disabled,omitempty"`
+}
+
+// TowerConfig holds Lobster Tower settings.
+type TowerConfig struct {
+	Heartbeat TowerHeartbeatConfig `yaml:"heartbeat,omitempty"`
+}
+
+// TowerHeartbeatConfig drives the heartbeat agent that the local studio
+// (and so the daemon) runs when Enabled. Without Status, the status line is
+// generated from OpenClaw activity in the last ActivityWindowMinutes.
+type TowerHeartbeatConfig struct {
+	Enabled               bool   `yaml:"enabled,omitempty"`
+	IntervalSeconds       int    `yaml:"interval_seconds,omitempty"`
+	RoomCode              string `yaml:"room_code,omitempty"`
+	Status                string `yaml:"status,omitempty"`
+	ActivityWindowMinutes int    `yaml:"activity_window_minutes,omitempty"`
+}
+
+// Interval is the time between heartbeats (default 5 minutes).
+func (h TowerHeartbeatConfig) Interval() time.Duration {
+	if h.IntervalSeconds <= 0 {
+		return 5 * time.Minute
+	}
+	return time.Duration(h.IntervalSeconds) * time.Second
+}
+
+// ActivityWindow is how far back the automatic status looks (default 1 hour).
+func (h TowerHeartbeatConfig) ActivityWindow() time.Duration {
+	if h.ActivityWindowMinutes <= 0 {
+		return time.Hour
+	}
+	return time.Duration(h.ActivityWindowMinutes) * time.Minute
+}
+
+// NotifyConfig lists the channels reminders (and other notices) can be
+// delivered to. A reminder's channel names one of them; a bare type such
+// as "desktop" also works when it is unambiguous.
+type NotifyConfig struct {
+	Channels []NotifyChannel `yaml:"channels,omitempty"`
+}
+
+// NotifyChannel configures one notifier. Type is webhook, telegram,
+// discord, slack, email or desktop. Secrets can be inline or read from the
+// environment variable named by the matching *_env field.
+type NotifyChannel struct {
+	Name        string            `yaml:"name"`
+	Type        string            `yaml:"type"`
+	URL         string            `yaml:"url,omitempty"`
+	URLEnv      string            `yaml:"url_env,omitempty"`
+	Headers     map[string]string `yaml:"headers,omitempty"`
+	BotToken    string            `yaml:"bot_token,omitempty"`
+	BotTokenEnv string            `yaml:"bot_token_env,omitempty"`
+	ChatID      string            `yaml:"chat_id,omitempty"`
+	SMTPHost    string            `yaml:"smtp_host,omitempty"`
+	SMTPPort    int               `yaml:"smtp_port,omitempty"`
+	Username    string            `yaml:"username,omitempty"`
+	Password    string            `yaml:"password,omitempty"`
+	PasswordEnv string            `yaml:"password_env,omitempty"`
+	From        string            `yaml:"from,omitempty"`
+	To          []string          `yaml:"to,omitempty"`
+}
+
+// Reminder fires daily at Time (HH:MM) in Timezone (default: the config
+// timezone) on the weekdays in Days ("mon-fri", "sat,sun", "weekdays";
+// empty means every day). ID is assigned once and never reused.
 type Reminder struct {
-	Time    string `yaml:"time"`
-	Message string `yaml:"message"`
-	Channel string `yaml:"channel"`
+	ID       string `yaml:"id"`
+	Time     string `yaml:"time"`
+	Message  string `yaml:"message"`
+	Channel  string `yaml:"channel"`
+	Timezone string `yaml:"timezone,omitempty"`
+	Days     string `yaml:"days,omitempty"`
 }
 
 func Default() Config {
@@ -68,6 +174,9 @@ func Load() (Config, error) {
 	if err := cfg.Normalize(); err != nil {
 		return Config{}, err
 	}
+	// Reminders written by hand get IDs derived from their content, so they
+	// are stable across loads without writing the file.
+	cfg.AssignReminderIDs()
 	if migrated {
 		// 一次性迁移旧 endpoint 到正式地址；写回失败不影响本次运行。
 		_ = Save(cfg)
@@ -165,6 +274,10 @@ func (c *Config) Normalize() error {
 	}
 	c.OutputDir = filepath.Clean(outputDir)
 	c.Template = strings.TrimSpace(c.Template)
+	c.Timezone = strings.TrimSpace(c.Timezone)
+	if _, err := c.Location(); err != nil {
+		return err
+	}
 	c.OpenClawLogPath = c.InputPaths[0]
 	c.DiariesDir = c.OutputDir
 
@@ -174,9 +287,155 @@ func (c *Config) Normalize() error {
 	if c.RetryCount < 0 {
 		c.RetryCount = Default().RetryCount
 	}
+	c.HubProtocol = strings.ToLower(strings.TrimSpace(c.HubProtocol))
+	switch c.HubProtocol {
+	case "", "json", "messagepack":
+	case "msgpack":
+		c.HubProtocol = "messagepack"
+	default:
+		return fmt.Errorf("hub_protocol must be json or messagepack: %s", c.HubProtocol)
+	}
+
+	c.LLM.Provider = strings.ToLower(strings.TrimSpace(c.LLM.Provider))
+	switch c.LLM.Provider {
+	case "", "openai", "ollama", "local":
+	default:
+		return fmt.Errorf("llm.provider must be openai, ollama or local: %s", c.LLM.Provider)
+	}
+	c.LLM.Model = strings.TrimSpace(c.LLM.Model)
+	c.LLM.BaseURL = strings.TrimRight(strings.TrimSpace(c.LLM.BaseURL), "/")
+	c.LLM.APIKeyEnv = strings.TrimSpace(c.LLM.APIKeyEnv)
+	if c.LLM.TimeoutSeconds < 0 {
+		c.LLM.TimeoutSeconds = 0
+	}
+
+	seenJobs := make(map[string]bool, len(c.Schedule.Jobs))
+	for i := range c.Schedule.Jobs {
+		job := &c.Schedule.Jobs[i]
+		job.Kind = strings.ToLower(strings.TrimSpace(job.Kind))
+		switch job.Kind {
+		case "run", "heartbeat", "inbox", "command":
+		default:
+			return fmt.Errorf("schedule.jobs[%d].kind must be run, heartbeat, inbox or command: %q", i, job.Kind)
+		}
+		job.Name = strings.TrimSpace(job.Name)
+		if job.Name == "" {
+			job.Name = job.Kind
+		}
+		if seenJobs[job.Name] {
+			return fmt.Errorf("schedule.jobs: duplicate job name %q", job.Name)
+		}
+		seenJobs[job.Name] = true
+		job.Cron = strings.TrimSpace(job.Cron)
+		if job.Cron == "" {
+			return fmt.Errorf("schedule.jobs[%d] (%s): cron is required", i, job.Name)
+		}
+		if job.Kind == "command" && len(job.Args) == 0 {
+			return fmt.Errorf("schedule.jobs[%d] (%s): command jobs need args", i, job.Name)
+		}
+		if job.TimeoutSeconds < 0 {
+			job.TimeoutSeconds = 0
+		}
+	}
+
+	hb := &c.Tower.Heartbeat
+	hb.RoomCode = strings.ToUpper(strings.TrimSpace(hb.RoomCode))
+	hb.Status = strings.TrimSpace(hb.Status)
+	if hb.IntervalSeconds < 0 || (hb.IntervalSeconds > 0 && hb.IntervalSeconds < 30) {
+		return fmt.Errorf("tower.heartbeat.interval_seconds must be at least 30: %d", hb.IntervalSeconds)
+	}
+	if hb.ActivityWindowMinutes < 0 {
+		hb.ActivityWindowMinutes = 0
+	}
+	if len(hb.Status) > 200 {
+		return fmt.Errorf("tower.heartbeat.status exceeds 200 characters (got %d)", len(hb.Status))
+	}
+
+	seenChannels := make(map[string]bool, len(c.Notify.Channels))
+	for i := range c.Notify.Channels {
+		ch := &c.Notify.Channels[i]
+		ch.Type = strings.ToLower(strings.TrimSpace(ch.Type))
+		switch ch.Type {
+		case "webhook", "telegram", "discord", "slack", "email", "desktop":
+		default:
+			return fmt.Errorf("notify.channels[%d].type must be webhook, telegram, discord, slack, email or desktop: %q", i, ch.Type)
+		}
+		ch.Name = strings.TrimSpace(ch.Name)
+		if ch.Name == "" {
+			ch.Name = ch.Type
+		}
+		if seenChannels[ch.Name] {
+			return fmt.Errorf("notify.channels: duplicate channel name %q", ch.Name)
+		}
+		seenChannels[ch.Name] = true
+	}
+
+	for i := range c.Reminders {
+		r := &c.Reminders[i]
+		r.ID = strings.TrimSpace(r.ID)
+		r.Time = strings.TrimSpace(r.Time)
+		r.Channel = strings.TrimSpace(r.Channel)
+		r.Timezone = strings.TrimSpace(r.Timezone)
+		r.Days = strings.ToLower(strings.TrimSpace(r.Days))
+	}
 	return nil
 }
 
+// AssignReminderIDs gives every reminder without an ID one derived from
+// its content, unique within the config, and reports whether any changed.
+func (c *Config) AssignReminderIDs() bool {
+	used := make(map[string]bool, len(c.Reminders))
+	for _, r := range c.Reminders {
+		if r.ID != "" {
+			used[r.ID] = true
+		}
+	}
+	changed := false
+	for i := range c.Reminders {
+		if c.Reminders[i].ID != "" {
+			continue
+		}
+		id := reminderID(c.Reminders[i], 0)
+		for n := 1; used[id]; n++ {
+			id = reminderID(c.Reminders[i], n)
+		}
+		used[id] = true
+		c.Reminders[i].ID = id
+		changed = true
+	}
+	return changed
+}
+
+// FindReminder returns the index of the reminder with id, or -1.
+func (c Config) FindReminder(id string) int {
+	id = strings.TrimSpace(id)
+	for i, r := range c.Reminders {
+		if r.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func reminderID(r Reminder, salt int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%s|%s|%s|%d", r.Time, r.Days, r.Timezone, r.Channel, r.Message, salt)))
+	return hex.EncodeToString(sum[:3])
+}
+
+// Location returns the configured timezone used for diary day boundaries,
+// falling back to the system local zone when none is set.
+func (c Config) Location() (*time.Location, error) {
+	name := strings.TrimSpace(c.Timezone)
+	if name == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", name, err)
+	}
+	return loc, nil
+}
+
 func ParseInputPathsCSV(raw string) []string {
 	parts := strings.Split(raw, ",")
 	out := make([]string, 0, len(parts))
@@ -0,0 +1,176 @@
+// Package daemon runs the local studio in the background. A supervisor
+// process holds daemon.lock for its lifetime, records its PID and state,
+// restarts the studio with backoff when it exits, and writes a rotated
+// daemon.log.
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrRunning is returned by AcquireLock when another supervisor holds
+	// the lock.
+	ErrRunning = errors.New("daemon is already running")
+	// ErrNotRunning is returned by Stop when no supervisor holds the lock.
+	ErrNotRunning = errors.New("daemon is not running")
+)
+
+// Paths are the daemon's files under the moltbb state directory.
+type Paths struct {
+	Dir       string
+	PIDFile   string
+	LockFile  string
+	LogFile   string
+	StateFile string
+}
+
+func PathsFor(dir string) Paths {
+	return Paths{
+		Dir:       dir,
+		PIDFile:   filepath.Join(dir, "daemon.pid"),
+		LockFile:  filepath.Join(dir, "daemon.lock"),
+		LogFile:   filepath.Join(dir, "daemon.log"),
+		StateFile: filepath.Join(dir, "daemon.json"),
+	}
+}
+
+// State is what the supervisor publishes about itself and the studio
+// process it runs. PID is the supervisor's; ChildPID the studio's.
+type State struct {
+	PID            int    `json:"pid"`
+	ChildPID       int    `json:"childPid,omitempty"`
+	Host           string `json:"host"`
+	Port           int    `json:"port"`
+	Version        string `json:"version,omitempty"`
+	StartedAt      string `json:"startedAt"`
+	ChildStartedAt string `json:"childStartedAt,omitempty"`
+	Restarts       int    `json:"restarts"`
+	LastExit       string `json:"lastExit,omitempty"`
+}
+
+// HealthURL is the studio's health endpoint for this state.
+func (s State) HealthURL() string {
+	host := s.Host
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	return fmt.Sprintf("http://%s:%d/api/health", host, s.Port)
+}
+
+func WritePID(path string, pid int) error {
+	return writeFileAtomic(path, []byte(strconv.Itoa(pid)+"\n"))
+}
+
+func ReadPID(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("invalid PID file %s", path)
+	}
+	return pid, nil
+}
+
+func WriteState(path string, st State) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode daemon state: %w", err)
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+func ReadState(path string) (State, error) {
+	var st State
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return st, err
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		return st, fmt.Errorf("decode daemon state: %w", err)
+	}
+	return st, nil
+}
+
+// Running reports whether a supervisor currently holds the lock.
+func Running(paths Paths) bool {
+	return Probe(paths.LockFile)
+}
+
+// Stop asks the supervisor to shut down and waits until it has released
+// the lock. It returns the supervisor's PID.
+func Stop(paths Paths, timeout time.Duration) (int, error) {
+	if !Running(paths) {
+		_ = os.Remove(paths.PIDFile)
+		return 0, ErrNotRunning
+	}
+	pid, err := ReadPID(paths.PIDFile)
+	if err != nil {
+		return 0, fmt.Errorf("daemon is running but its PID is unknown: %w", err)
+	}
+	if err := terminate(pid); err != nil {
+		return pid, fmt.Errorf("signal daemon (PID %d): %w", pid, err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for Running(paths) {
+		if time.Now().After(deadline) {
+			return pid, fmt.Errorf("daemon (PID %d) did not stop within %s", pid, timeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	_ = os.Remove(paths.PIDFile)
+	return pid, nil
+}
+
+// Health is the studio's /api/health response.
+type Health struct {
+	OK        bool   `json:"ok"`
+	PID       int    `json:"pid"`
+	Version   string `json:"version"`
+	StartedAt string `json:"startedAt"`
+	TS        string `json:"ts"`
+}
+
+// CheckHealth queries the studio's health endpoint.
+func CheckHealth(ctx context.Context, url string) (Health, error) {
+	var h Health
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return h, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return h, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return h, fmt.Errorf("health check returned %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&h); err != nil {
+		return h, fmt.Errorf("decode health response: %w", err)
+	}
+	return h, nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
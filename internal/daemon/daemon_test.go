@@ -0,0 +1,222 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockIsExclusive(t *testing.T) {
+	t.Parallel()
+
+	paths := PathsFor(t.TempDir())
+	if Running(paths) {
+		t.Fatal("no lock held yet")
+	}
+	lock, err := AcquireLock(paths.LockFile)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if _, err := AcquireLock(paths.LockFile); !errors.Is(err, ErrRunning) {
+		t.Fatalf("second acquire err = %v, want ErrRunning", err)
+	}
+	before, _ := os.ReadFile(paths.LockFile)
+	if !Running(paths) {
+		t.Fatal("Running should report the held lock")
+	}
+	if after, _ := os.ReadFile(paths.LockFile); string(after) != string(before) {
+		t.Fatalf("Running rewrote the lock file: %q -> %q", before, after)
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if Running(paths) {
+		t.Fatal("lock should be free after release")
+	}
+}
+
+func TestStopWithoutDaemonRemovesStalePID(t *testing.T) {
+	t.Parallel()
+
+	paths := PathsFor(t.TempDir())
+	if err := WritePID(paths.PIDFile, 999999); err != nil {
+		t.Fatalf("write pid: %v", err)
+	}
+	if _, err := Stop(paths, time.Second); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("stop err = %v, want ErrNotRunning", err)
+	}
+	if _, err := os.Stat(paths.PIDFile); !os.IsNotExist(err) {
+		t.Fatalf("stale PID file should be removed, stat err = %v", err)
+	}
+}
+
+func TestStateRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "daemon.json")
+	want := State{PID: 10, ChildPID: 11, Host: "0.0.0.0", Port: 4000, Restarts: 2, LastExit: "exit status 1"}
+	if err := WriteState(path, want); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	got, err := ReadState(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if got != want {
+		t.Fatalf("state = %+v, want %+v", got, want)
+	}
+	if url := got.HealthURL(); url != "http://127.0.0.1:4000/api/health" {
+		t.Fatalf("health url = %s", url)
+	}
+}
+
+func TestRotatingFileRotates(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "daemon.log")
+	r, err := OpenRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	for _, line := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	for name, want := range map[string]string{
+		path:        "dddddddd\n",
+		path + ".1": "cccccccc\n",
+		path + ".2": "bbbbbbbb\n",
+	} {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		if string(data) != want {
+			t.Fatalf("%s = %q, want %q", name, data, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatal("only two backups should be kept")
+	}
+}
+
+func TestTailFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "daemon.log")
+	if err := os.WriteFile(path, []byte("one\ntwo\nthree\nfour\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lines, err := TailFile(path, 2)
+	if err != nil {
+		t.Fatalf("tail: %v", err)
+	}
+	if strings.Join(lines, ",") != "three,four" {
+		t.Fatalf("lines = %v", lines)
+	}
+}
+
+func TestSupervisorRestartsCrashedChild(t *testing.T) {
+	t.Parallel()
+
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var starts []int
+	var exits []int
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sup := &Supervisor{
+		// The test binary exits non-zero when asked to run a test that
+		// does not exist; that is the crashing child.
+		Path:        exe,
+		Args:        []string{"-test.run=^$", "-test.count=1", "-test.bogus-flag"},
+		Output:      io.Discard,
+		MinBackoff:  10 * time.Millisecond,
+		MaxBackoff:  20 * time.Millisecond,
+		StableAfter: time.Hour,
+		OnStart: func(pid int) {
+			mu.Lock()
+			starts = append(starts, pid)
+			mu.Unlock()
+		},
+		OnExit: func(err error, restarts int) {
+			mu.Lock()
+			exits = append(exits, restarts)
+			n := len(exits)
+			mu.Unlock()
+			if n == 3 {
+				cancel()
+			}
+		},
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- sup.Run(ctx) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+	case <-time.After(20 * time.Second):
+		t.Fatal("supervisor did not stop")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(starts) != 3 {
+		t.Fatalf("starts = %d, want 3", len(starts))
+	}
+	if !slices.Equal(exits, []int{1, 2, 3}) {
+		t.Fatalf("exits = %v", exits)
+	}
+}
+
+func TestServiceFor(t *testing.T) {
+	t.Parallel()
+
+	args := []string{"daemon", "run", "--port", "3789"}
+	linux, err := ServiceFor("linux", "/home/u", "/opt/molt bb/moltbb", args, "")
+	if err != nil {
+		t.Fatalf("linux: %v", err)
+	}
+	if linux.Path != "/home/u/.config/systemd/user/moltbb.service" {
+		t.Fatalf("unit path = %s", linux.Path)
+	}
+	if !strings.Contains(linux.Content, `ExecStart="/opt/molt bb/moltbb" daemon run --port 3789`) {
+		t.Fatalf("unit content:\n%s", linux.Content)
+	}
+
+	mac, err := ServiceFor("darwin", "/Users/u", "/usr/local/bin/moltbb", args, "/Users/u/.moltbb/daemon.stderr.log")
+	if err != nil {
+		t.Fatalf("darwin: %v", err)
+	}
+	if mac.Path != "/Users/u/Library/LaunchAgents/com.moltbb.daemon.plist" {
+		t.Fatalf("plist path = %s", mac.Path)
+	}
+	for _, want := range []string{"<string>/usr/local/bin/moltbb</string>", "<string>--port</string>", "<key>KeepAlive</key>"} {
+		if !strings.Contains(mac.Content, want) {
+			t.Fatalf("plist missing %q:\n%s", want, mac.Content)
+		}
+	}
+
+	if _, err := ServiceFor("windows", `C:\Users\u`, "moltbb.exe", args, ""); err == nil {
+		t.Fatal("windows should be unsupported")
+	}
+}
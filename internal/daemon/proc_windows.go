@@ -0,0 +1,93 @@
+//go:build windows
+
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+const (
+	createNewProcessGroup = 0x00000200
+	detachedProcess       = 0x00000008
+)
+
+// Lock is an exclusive lock file, held until Release or process exit.
+// Windows refuses to delete a file another process has open, so a lock
+// left by a crashed supervisor is removed and retaken.
+type Lock struct {
+	f    *os.File
+	path string
+}
+
+// AcquireLock takes the lock without blocking, returning ErrRunning when it
+// is already held. The holder's PID is written into the file.
+func AcquireLock(path string) (*Lock, error) {
+	if _, err := os.Stat(path); err == nil {
+		if err := os.Remove(path); err != nil {
+			return nil, ErrRunning
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0o644)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, ErrRunning
+		}
+		return nil, fmt.Errorf("open lock file: %w", err)
+	}
+	_, _ = f.WriteString(strconv.Itoa(os.Getpid()) + "\n")
+	return &Lock{f: f, path: path}, nil
+}
+
+// errSharingViolation is ERROR_SHARING_VIOLATION.
+const errSharingViolation syscall.Errno = 32
+
+// Probe reports whether someone holds the lock at path. The holder keeps
+// the file open without delete sharing, so opening it for delete fails
+// while the lock is held. The file is neither deleted nor written.
+func Probe(path string) bool {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return false
+	}
+	const deleteAccess = 0x00010000
+	h, err := syscall.CreateFile(name, deleteAccess,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		return errors.Is(err, errSharingViolation)
+	}
+	_ = syscall.CloseHandle(h)
+	return false
+}
+
+func (l *Lock) Release() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	_ = os.Remove(l.path)
+	return err
+}
+
+// Detach makes cmd outlive the calling console.
+func Detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: createNewProcessGroup | detachedProcess}
+}
+
+func terminate(pid int) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return p.Kill()
+}
+
+func interruptChild(p *os.Process) error {
+	return p.Kill()
+}
@@ -0,0 +1,110 @@
+package daemon
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	systemdUnitName = "moltbb.service"
+	launchdLabel    = "com.moltbb.daemon"
+)
+
+// Service is an OS service definition for the daemon: the file to write
+// and the commands that enable and disable it.
+type Service struct {
+	Path    string
+	Content string
+	Enable  [][]string
+	Disable [][]string
+}
+
+// ServiceFor renders the service definition that runs exe with args at
+// login: a systemd user unit on Linux, a launchd agent on macOS.
+func ServiceFor(goos, home, exe string, args []string, logFile string) (Service, error) {
+	switch goos {
+	case "linux":
+		path := filepath.Join(home, ".config", "systemd", "user", systemdUnitName)
+		return Service{
+			Path:    path,
+			Content: SystemdUnit(exe, args),
+			Enable: [][]string{
+				{"systemctl", "--user", "daemon-reload"},
+				{"systemctl", "--user", "enable", "--now", systemdUnitName},
+			},
+			Disable: [][]string{
+				{"systemctl", "--user", "disable", "--now", systemdUnitName},
+				{"systemctl", "--user", "daemon-reload"},
+			},
+		}, nil
+	case "darwin":
+		path := filepath.Join(home, "Library", "LaunchAgents", launchdLabel+".plist")
+		return Service{
+			Path:    path,
+			Content: LaunchdPlist(launchdLabel, exe, args, logFile),
+			Enable:  [][]string{{"launchctl", "load", "-w", path}},
+			Disable: [][]string{{"launchctl", "unload", "-w", path}},
+		}, nil
+	default:
+		return Service{}, fmt.Errorf("service install is not supported on %s", goos)
+	}
+}
+
+// SystemdUnit renders a systemd user unit. systemd restarts the
+// supervisor itself if it ever dies; the supervisor restarts the studio.
+func SystemdUnit(exe string, args []string) string {
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=MoltBB local diary studio\n")
+	b.WriteString("After=network-online.target\n\n")
+	b.WriteString("[Service]\n")
+	b.WriteString("Type=simple\n")
+	fmt.Fprintf(&b, "ExecStart=%s\n", systemdCommandLine(append([]string{exe}, args...)))
+	b.WriteString("Restart=on-failure\n")
+	b.WriteString("RestartSec=5\n\n")
+	b.WriteString("[Install]\n")
+	b.WriteString("WantedBy=default.target\n")
+	return b.String()
+}
+
+// LaunchdPlist renders a launchd agent that keeps the supervisor alive.
+func LaunchdPlist(label, exe string, args []string, logFile string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	b.WriteString(`<plist version="1.0">` + "\n<dict>\n")
+	fmt.Fprintf(&b, "  <key>Label</key>\n  <string>%s</string>\n", xmlEscape(label))
+	b.WriteString("  <key>ProgramArguments</key>\n  <array>\n")
+	for _, arg := range append([]string{exe}, args...) {
+		fmt.Fprintf(&b, "    <string>%s</string>\n", xmlEscape(arg))
+	}
+	b.WriteString("  </array>\n")
+	b.WriteString("  <key>RunAtLoad</key>\n  <true/>\n")
+	b.WriteString("  <key>KeepAlive</key>\n  <dict>\n    <key>SuccessfulExit</key>\n    <false/>\n  </dict>\n")
+	if logFile != "" {
+		fmt.Fprintf(&b, "  <key>StandardErrorPath</key>\n  <string>%s</string>\n", xmlEscape(logFile))
+	}
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String()
+}
+
+func systemdCommandLine(argv []string) string {
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		if arg != "" && !strings.ContainsAny(arg, " \t\"'\\$%") {
+			quoted[i] = arg
+			continue
+		}
+		arg = strings.ReplaceAll(arg, `\`, `\\`)
+		arg = strings.ReplaceAll(arg, `"`, `\"`)
+		arg = strings.ReplaceAll(arg, `$`, `$$`)
+		arg = strings.ReplaceAll(arg, `%`, `%%`)
+		quoted[i] = `"` + arg + `"`
+	}
+	return strings.Join(quoted, " ")
+}
+
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(s)
+}
@@ -0,0 +1,124 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"time"
+)
+
+// Supervisor keeps a child process running, restarting it with exponential
+// backoff when it exits. A run that lasted at least StableAfter resets the
+// backoff to MinBackoff.
+type Supervisor struct {
+	Path string
+	Args []string
+	Env  []string
+	// Output receives the child's stdout and stderr plus the supervisor's
+	// own log lines.
+	Output io.Writer
+
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	StableAfter time.Duration
+	// StopTimeout is how long the child gets to exit after being signalled
+	// before it is killed.
+	StopTimeout time.Duration
+
+	// OnStart and OnExit, when set, observe each child run.
+	OnStart func(pid int)
+	OnExit  func(err error, restarts int)
+}
+
+// Run starts the child and supervises it until ctx is cancelled, then stops
+// the child and returns nil.
+func (s *Supervisor) Run(ctx context.Context) error {
+	if s.Path == "" {
+		return errors.New("supervisor command is required")
+	}
+	minBackoff := durationOr(s.MinBackoff, time.Second)
+	maxBackoff := durationOr(s.MaxBackoff, time.Minute)
+	stableAfter := durationOr(s.StableAfter, time.Minute)
+	out := s.Output
+	if out == nil {
+		out = io.Discard
+	}
+
+	backoff := minBackoff
+	restarts := 0
+	for {
+		cmd := exec.Command(s.Path, s.Args...)
+		cmd.Env = s.Env
+		cmd.Stdout = out
+		cmd.Stderr = out
+
+		started := time.Now()
+		if err := cmd.Start(); err != nil {
+			s.logf(out, "start failed: %v", err)
+		} else {
+			s.logf(out, "started pid %d", cmd.Process.Pid)
+			if s.OnStart != nil {
+				s.OnStart(cmd.Process.Pid)
+			}
+
+			done := make(chan error, 1)
+			go func() { done <- cmd.Wait() }()
+
+			var err error
+			select {
+			case err = <-done:
+			case <-ctx.Done():
+				s.stopChild(cmd, done, out)
+				return nil
+			}
+
+			if err == nil {
+				err = errors.New("exited with status 0")
+			}
+			s.logf(out, "pid %d %v", cmd.Process.Pid, err)
+			if time.Since(started) >= stableAfter {
+				backoff = minBackoff
+			}
+			if s.OnExit != nil {
+				s.OnExit(err, restarts+1)
+			}
+		}
+
+		restarts++
+		s.logf(out, "restarting in %s (restart #%d)", backoff, restarts)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+func (s *Supervisor) stopChild(cmd *exec.Cmd, done <-chan error, out io.Writer) {
+	s.logf(out, "stopping pid %d", cmd.Process.Pid)
+	_ = interruptChild(cmd.Process)
+	select {
+	case <-done:
+	case <-time.After(durationOr(s.StopTimeout, 10*time.Second)):
+		s.logf(out, "pid %d did not exit, killing", cmd.Process.Pid)
+		_ = cmd.Process.Kill()
+		<-done
+	}
+}
+
+func (s *Supervisor) logf(out io.Writer, format string, args ...any) {
+	fmt.Fprintf(out, "%s [supervisor] %s\n", time.Now().Format(time.RFC3339), fmt.Sprintf(format, args...))
+}
+
+func durationOr(d, fallback time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+	return fallback
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -13,22 +15,130 @@ import (
 )
 
 type Document struct {
-	Date     string       `json:"date"`
-	Summary  string       `json:"summary"`
-	Stats    parser.Stats `json:"stats"`
-	Markdown string       `json:"markdown"`
+	Date      string          `json:"date"`
+	Summary   string          `json:"summary"`
+	Stats     parser.Stats    `json:"stats"`
+	TopErrors []ErrorSummary  `json:"topErrors,omitempty"`
+	Timeline  []TimelineEntry `json:"timeline,omitempty"`
+	Markdown  string          `json:"markdown"`
 }
 
+// ErrorSummary groups error events that share the same message shape.
+type ErrorSummary struct {
+	Message   string `json:"message"`
+	Component string `json:"component,omitempty"`
+	Count     int    `json:"count"`
+}
+
+// TimelineEntry is one task-related event; entries keep log order, which
+// is chronological because rotated files are read oldest first.
+type TimelineEntry struct {
+	Time      string `json:"time,omitempty"`
+	TaskID    string `json:"taskId,omitempty"`
+	Component string `json:"component,omitempty"`
+	Duration  string `json:"duration,omitempty"`
+	Message   string `json:"message"`
+}
+
+const (
+	maxTopErrors      = 5
+	maxTimelineEvents = 30
+)
+
+var volatileTokenRe = regexp.MustCompile(`\b(?:0x[0-9a-fA-F]+|[0-9a-fA-F]{8,}|\d+(?:\.\d+)?(?:ns|us|ms|s|m|h)?)\b`)
+
 func Build(result parser.Result, hostname string) Document {
 	summary := SummaryFromStats(result.Stats)
+	doc := Document{
+		Date:      result.Date,
+		Summary:   summary,
+		Stats:     result.Stats,
+		TopErrors: topErrors(result.Events, maxTopErrors),
+		Timeline:  taskTimeline(result.Events, result.Location, maxTimelineEvents),
+	}
+	doc.Markdown = renderMarkdown(doc, hostname)
+	return doc
+}
+
+// topErrors groups error events by message with numbers and ids masked,
+// so "timeout after 30s" and "timeout after 31s" count as one problem.
+func topErrors(events []parser.Event, limit int) []ErrorSummary {
+	type bucket struct {
+		summary ErrorSummary
+		order   int
+	}
+	buckets := make(map[string]*bucket)
+	for _, ev := range events {
+		if ev.Level != parser.LevelError {
+			continue
+		}
+		key := ev.Component + "|" + volatileTokenRe.ReplaceAllString(ev.Message, "N")
+		if b, ok := buckets[key]; ok {
+			b.summary.Count++
+			continue
+		}
+		buckets[key] = &bucket{
+			summary: ErrorSummary{Message: truncateLine(ev.Message, 160), Component: ev.Component, Count: 1},
+			order:   len(buckets),
+		}
+	}
+
+	list := make([]*bucket, 0, len(buckets))
+	for _, b := range buckets {
+		list = append(list, b)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].summary.Count != list[j].summary.Count {
+			return list[i].summary.Count > list[j].summary.Count
+		}
+		return list[i].order < list[j].order
+	})
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	out := make([]ErrorSummary, 0, len(list))
+	for _, b := range list {
+		out = append(out, b.summary)
+	}
+	return out
+}
 
-	md := renderMarkdown(result.Date, hostname, summary, result.Stats)
-	return Document{
-		Date:     result.Date,
-		Summary:  summary,
-		Stats:    result.Stats,
-		Markdown: md,
+// taskTimeline lists task events with their times in loc (time.Local when
+// nil).
+func taskTimeline(events []parser.Event, loc *time.Location, limit int) []TimelineEntry {
+	if loc == nil {
+		loc = time.Local
 	}
+	out := make([]TimelineEntry, 0, 16)
+	for _, ev := range events {
+		if ev.TaskID == "" && !ev.CompletesTask() {
+			continue
+		}
+		entry := TimelineEntry{
+			TaskID:    ev.TaskID,
+			Component: ev.Component,
+			Message:   truncateLine(ev.Message, 160),
+		}
+		if ev.HasTimestamp() {
+			entry.Time = ev.Timestamp.In(loc).Format("15:04:05")
+		}
+		if ev.Duration > 0 {
+			entry.Duration = ev.Duration.Round(time.Millisecond).String()
+		}
+		out = append(out, entry)
+	}
+	if len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	return out
+}
+
+func truncateLine(line string, limit int) string {
+	runes := []rune(strings.TrimSpace(line))
+	if len(runes) <= limit {
+		return string(runes)
+	}
+	return string(runes[:limit]) + "…"
 }
 
 func SummaryFromStats(stats parser.Stats) string {
@@ -60,7 +170,10 @@ func Write(doc Document, diariesDir string) (string, error) {
 	return outPath, nil
 }
 
-func WritePromptPacket(date, hostname, apiBaseURL, diariesDir, templateRef string, logSourceHints []string) (string, error) {
+// WritePromptPacket renders the prompt packet for date. memoryExcerpt, when
+// not empty, fills the recent memory section (for example the day's
+// pipeline conversations).
+func WritePromptPacket(date, hostname, apiBaseURL, diariesDir, templateRef string, logSourceHints []string, memoryExcerpt string) (string, error) {
 	expanded, err := utils.ExpandPath(diariesDir)
 	if err != nil {
 		return "", err
@@ -74,7 +187,7 @@ func WritePromptPacket(date, hostname, apiBaseURL, diariesDir, templateRef strin
 		return "", err
 	}
 
-	packet := renderPromptPacket(templateContent, date, hostname, apiBaseURL, logSourceHints)
+	packet := renderPromptPacket(templateContent, date, hostname, apiBaseURL, logSourceHints, memoryExcerpt)
 	filename := fmt.Sprintf("%s.prompt.md", date)
 	outPath := filepath.Join(expanded, filename)
 
@@ -115,7 +228,7 @@ func loadPromptTemplate(templateRef string) (string, error) {
 	return DefaultPromptTemplate(), nil
 }
 
-func renderPromptPacket(template, date, hostname, apiBaseURL string, logSourceHints []string) string {
+func renderPromptPacket(template, date, hostname, apiBaseURL string, logSourceHints []string, memoryExcerpt string) string {
 	hints := normalizeLogSourceHints(logSourceHints)
 	capabilityEndpoint := buildCapabilitiesEndpoint(apiBaseURL)
 	insightEndpoint := buildRuntimeInsightEndpoint(apiBaseURL)
@@ -206,7 +319,10 @@ func renderPromptPacket(template, date, hostname, apiBaseURL string, logSourceHi
 
 	out := template
 	out = injectPromptSection(out, "[TODAY_STRUCTURED_SUMMARY]", string(structured))
-	out = injectPromptSection(out, "[OPTIONAL: RECENT MEMORY EXCERPT]", "(none)")
+	if strings.TrimSpace(memoryExcerpt) == "" {
+		memoryExcerpt = "(none)"
+	}
+	out = injectPromptSection(out, "[OPTIONAL: RECENT MEMORY EXCERPT]", memoryExcerpt)
 	out = injectPromptSection(out, "[ROLE_DEFINITION]", "assistant")
 	out = injectPromptSection(out, "[INSIGHT_PROMPT]", string(insightPrompt))
 	return out
@@ -260,15 +376,15 @@ func AgentManagedSummary(logSourceCount int) string {
 	)
 }
 
-func renderMarkdown(date, hostname, summary string, stats parser.Stats) string {
+func renderMarkdown(doc Document, hostname string) string {
+	stats := doc.Stats
 	var b strings.Builder
 	b.WriteString("# MoltBB Diary\n\n")
-	b.WriteString(fmt.Sprintf("- Date: %s\n", date))
-	b.WriteString(fmt.Sprintf("- Host: %s\n", hostname))
-	b.WriteString(fmt.Sprintf("- Generated At: %s\n\n", time.Now().UTC().Format(time.RFC3339)))
+	b.WriteString(fmt.Sprintf("- Date: %s\n", doc.Date))
+	b.WriteString(fmt.Sprintf("- Host: %s\n\n", hostname))
 
 	b.WriteString("## Summary\n\n")
-	b.WriteString(summary)
+	b.WriteString(doc.Summary)
 	b.WriteString("\n\n")
 
 	b.WriteString("## Stats\n\n")
@@ -277,6 +393,40 @@ func renderMarkdown(date, hostname, summary string, stats parser.Stats) string {
 	b.WriteString(fmt.Sprintf("- Warnings: %d\n", stats.WarningCount))
 	b.WriteString(fmt.Sprintf("- Errors: %d\n\n", stats.ErrorCount))
 
+	if len(doc.TopErrors) > 0 {
+		b.WriteString("## Top Errors\n\n")
+		for _, e := range doc.TopErrors {
+			if e.Component != "" {
+				b.WriteString(fmt.Sprintf("- (%dx) [%s] %s\n", e.Count, e.Component, e.Message))
+			} else {
+				b.WriteString(fmt.Sprintf("- (%dx) %s\n", e.Count, e.Message))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	if len(doc.Timeline) > 0 {
+		b.WriteString("## Task Timeline\n\n")
+		for _, t := range doc.Timeline {
+			parts := make([]string, 0, 4)
+			if t.Time != "" {
+				parts = append(parts, t.Time)
+			}
+			if t.TaskID != "" {
+				parts = append(parts, "`"+t.TaskID+"`")
+			}
+			if t.Component != "" {
+				parts = append(parts, "["+t.Component+"]")
+			}
+			line := strings.Join(append(parts, t.Message), " ")
+			if t.Duration != "" {
+				line += " (" + t.Duration + ")"
+			}
+			b.WriteString("- " + line + "\n")
+		}
+		b.WriteString("\n")
+	}
+
 	b.WriteString("## Sample Log Lines\n\n")
 	for _, line := range stats.Sample {
 		b.WriteString(fmt.Sprintf("- %s\n", line))
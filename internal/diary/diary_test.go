@@ -3,6 +3,7 @@ package diary
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"moltbb-cli/internal/parser"
 )
@@ -20,6 +21,9 @@ func TestBuild_GeneratesSummaryAndMarkdown(t *testing.T) {
 	}
 
 	doc := Build(res, "host-a")
+	if again := Build(res, "host-a"); again.Markdown != doc.Markdown {
+		t.Fatalf("markdown differs between runs:\n%s\n---\n%s", doc.Markdown, again.Markdown)
+	}
 	if !strings.Contains(doc.Summary, "20 log lines") {
 		t.Fatalf("unexpected summary: %s", doc.Summary)
 	}
@@ -27,3 +31,33 @@ func TestBuild_GeneratesSummaryAndMarkdown(t *testing.T) {
 		t.Fatalf("markdown header missing")
 	}
 }
+
+func TestBuild_TopErrorsAndTimeline(t *testing.T) {
+	ts := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	res := parser.Result{
+		Date:     "2026-03-01",
+		Location: time.FixedZone("CET", 3600),
+		Events: []parser.Event{
+			{Timestamp: ts, Level: parser.LevelError, Component: "net", Message: "timeout after 30s"},
+			{Timestamp: ts.Add(time.Minute), Level: parser.LevelError, Component: "net", Message: "timeout after 31s"},
+			{Timestamp: ts.Add(2 * time.Minute), Level: parser.LevelError, Component: "db", Message: "locked"},
+			{Timestamp: ts.Add(3 * time.Minute), Level: parser.LevelInfo, TaskID: "t1", Duration: 1500 * time.Millisecond, Message: "task done"},
+		},
+	}
+
+	doc := Build(res, "host-a")
+	if len(doc.TopErrors) != 2 {
+		t.Fatalf("expected 2 error groups, got %+v", doc.TopErrors)
+	}
+	if doc.TopErrors[0].Count != 2 || doc.TopErrors[0].Component != "net" {
+		t.Fatalf("unexpected top error: %+v", doc.TopErrors[0])
+	}
+	if len(doc.Timeline) != 1 || doc.Timeline[0].TaskID != "t1" || doc.Timeline[0].Duration != "1.5s" {
+		t.Fatalf("unexpected timeline: %+v", doc.Timeline)
+	}
+	for _, section := range []string{"## Top Errors", "## Task Timeline", "10:03:00 `t1`"} {
+		if !strings.Contains(doc.Markdown, section) {
+			t.Fatalf("markdown missing %q:\n%s", section, doc.Markdown)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package diary
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff_IdenticalIsEmpty(t *testing.T) {
+	if got := UnifiedDiff("a\nb\n", "a\nb", "x", "y"); got != "" {
+		t.Fatalf("expected empty diff, got %q", got)
+	}
+}
+
+func TestUnifiedDiff_SingleChange(t *testing.T) {
+	before := "l1\nl2\nl3\nl4\nl5\nl6\nl7\nl8\n"
+	after := "l1\nl2\nl3\nl4 fixed\nl5\nl6\nl7\nl8\n"
+
+	got := UnifiedDiff(before, after, "a/2026-03-01.md", "b/2026-03-01.md")
+	want := strings.Join([]string{
+		"--- a/2026-03-01.md",
+		"+++ b/2026-03-01.md",
+		"@@ -1,7 +1,7 @@",
+		" l1",
+		" l2",
+		" l3",
+		"-l4",
+		"+l4 fixed",
+		" l5",
+		" l6",
+		" l7",
+		"",
+	}, "\n")
+	if got != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiff_SeparateHunksAndEmptyInput(t *testing.T) {
+	var before, after []string
+	for i := 0; i < 20; i++ {
+		before = append(before, "same")
+		after = append(after, "same")
+	}
+	before[1], after[1] = "old-a", "new-a"
+	before[18], after[18] = "old-b", "new-b"
+
+	got := UnifiedDiff(strings.Join(before, "\n"), strings.Join(after, "\n"), "a", "b")
+	if n := strings.Count(got, "@@ -"); n != 2 {
+		t.Fatalf("expected 2 hunks, got %d:\n%s", n, got)
+	}
+
+	got = UnifiedDiff("", "new\n", "a", "b")
+	if !strings.Contains(got, "@@ -0,0 +1 @@\n+new\n") {
+		t.Fatalf("unexpected diff for empty original:\n%s", got)
+	}
+}
@@ -20,6 +20,7 @@ func TestRenderPromptPacket_IncludesInsightPromptAndEndpoint(t *testing.T) {
 		"host-a",
 		"https://moltbb.com",
 		[]string{"~/.openclaw/logs/work.log"},
+		"",
 	)
 
 	assertContains(t, packet, "https://moltbb.com/api/v1/runtime/insights")
@@ -33,7 +34,7 @@ func TestRenderPromptPacket_AppendsInsightPromptTokenWhenMissing(t *testing.T) {
 	t.Parallel()
 
 	template := "Diary only template"
-	packet := renderPromptPacket(template, "2026-02-20", "host-a", "", nil)
+	packet := renderPromptPacket(template, "2026-02-20", "host-a", "", nil, "")
 	assertContains(t, packet, "[INSIGHT_PROMPT]")
 	assertContains(t, packet, "/api/v1/runtime/insights")
 }
@@ -44,3 +45,12 @@ func assertContains(t *testing.T, actual, expected string) {
 		t.Fatalf("expected %q in output", expected)
 	}
 }
+
+func TestRenderPromptPacket_FillsMemoryExcerpt(t *testing.T) {
+	t.Parallel()
+
+	template := "[OPTIONAL: RECENT MEMORY EXCERPT]\n"
+	assertContains(t, renderPromptPacket(template, "2026-02-20", "host-a", "", nil, ""), "[OPTIONAL: RECENT MEMORY EXCERPT]\n(none)")
+	packet := renderPromptPacket(template, "2026-02-20", "host-a", "", nil, "Session s1 (1 message)\n- 10:00 Bee: hi")
+	assertContains(t, packet, "[OPTIONAL: RECENT MEMORY EXCERPT]\nSession s1 (1 message)")
+}
@@ -0,0 +1,195 @@
+package diary
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+type SyncAction string
+
+const (
+	SyncActionNone     SyncAction = "none"
+	SyncActionUpload   SyncAction = "upload"
+	SyncActionPull     SyncAction = "pull"
+	SyncActionConflict SyncAction = "conflict"
+)
+
+// SyncRecord is the last successfully synced state for one diary date.
+// ContentHash is the hash of the local file; RemoteHash is the hash of the
+// cloud copy, which differs when the upload was truncated. An empty
+// RemoteHash means the two were the same.
+type SyncRecord struct {
+	DiaryDate     string `json:"diaryDate"`
+	ContentHash   string `json:"contentHash"`
+	RemoteHash    string `json:"remoteHash,omitempty"`
+	RemoteDiaryID string `json:"remoteDiaryId,omitempty"`
+	SyncedAt      string `json:"syncedAt"`
+}
+
+type LocalSyncItem struct {
+	Date string
+	Path string
+	Hash string
+}
+
+type RemoteSyncItem struct {
+	Date    string
+	ID      string
+	Hash    string
+	Content string
+}
+
+type SyncPlanItem struct {
+	Date   string          `json:"date"`
+	Action SyncAction      `json:"action"`
+	Reason string          `json:"reason"`
+	Local  *LocalSyncItem  `json:"-"`
+	Remote *RemoteSyncItem `json:"-"`
+}
+
+var syncDiaryDateRe = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})`)
+
+// CollectLocalSyncItems finds one diary file per date at the top of
+// diaryDir, preferring the exact YYYY-MM-DD.md name when several files share
+// a date. Subdirectories, such as moderated discussion summaries, are not
+// diaries and are not read.
+func CollectLocalSyncItems(diaryDir string) ([]LocalSyncItem, error) {
+	files, err := filepath.Glob(filepath.Join(diaryDir, "*.md"))
+	if err != nil {
+		return nil, fmt.Errorf("glob diaries: %w", err)
+	}
+	sort.Strings(files)
+
+	byDate := make(map[string]string, len(files))
+	for _, file := range files {
+		name := filepath.Base(file)
+		if strings.HasSuffix(name, ".prompt.md") {
+			continue
+		}
+		m := syncDiaryDateRe.FindStringSubmatch(name)
+		if m == nil {
+			continue
+		}
+		date := m[1]
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			continue
+		}
+		if _, ok := byDate[date]; ok && name != date+".md" {
+			continue
+		}
+		byDate[date] = file
+	}
+
+	items := make([]LocalSyncItem, 0, len(byDate))
+	for date, file := range byDate {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", file, err)
+		}
+		items = append(items, LocalSyncItem{
+			Date: date,
+			Path: file,
+			Hash: ContentHash(string(data)),
+		})
+	}
+	return items, nil
+}
+
+// ContentHash returns a stable hash for diary text. Line endings and
+// surrounding whitespace are normalized so that a diary pulled from the
+// cloud hashes the same as the file it was uploaded from.
+func ContentHash(text string) string {
+	normalized := strings.ReplaceAll(text, "\r\n", "\n")
+	normalized = strings.TrimSpace(normalized)
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// PlanSync compares local diaries, cloud diaries and the last synced state and
+// decides per date whether to upload, pull, or report a conflict.
+func PlanSync(local []LocalSyncItem, remote []RemoteSyncItem, records map[string]SyncRecord) []SyncPlanItem {
+	localByDate := make(map[string]LocalSyncItem, len(local))
+	for _, item := range local {
+		localByDate[item.Date] = item
+	}
+	remoteByDate := make(map[string]RemoteSyncItem, len(remote))
+	for _, item := range remote {
+		remoteByDate[item.Date] = item
+	}
+
+	dates := make([]string, 0, len(localByDate)+len(remoteByDate))
+	for date := range localByDate {
+		dates = append(dates, date)
+	}
+	for date := range remoteByDate {
+		if _, ok := localByDate[date]; !ok {
+			dates = append(dates, date)
+		}
+	}
+	sort.Strings(dates)
+
+	plan := make([]SyncPlanItem, 0, len(dates))
+	for _, date := range dates {
+		l, hasLocal := localByDate[date]
+		r, hasRemote := remoteByDate[date]
+		record, hasRecord := records[date]
+
+		item := SyncPlanItem{Date: date}
+		if hasLocal {
+			lc := l
+			item.Local = &lc
+		}
+		if hasRemote {
+			rc := r
+			item.Remote = &rc
+		}
+
+		switch {
+		case hasLocal && !hasRemote:
+			item.Action = SyncActionUpload
+			item.Reason = "local only"
+			if hasRecord && record.RemoteDiaryID != "" {
+				item.Reason = "missing in cloud"
+			}
+		case !hasLocal && hasRemote:
+			item.Action = SyncActionPull
+			item.Reason = "cloud only"
+		case l.Hash == r.Hash:
+			item.Action = SyncActionNone
+			item.Reason = "in sync"
+		case !hasRecord:
+			item.Action = SyncActionConflict
+			item.Reason = "local and cloud differ, no previous sync"
+		default:
+			remoteBase := record.RemoteHash
+			if remoteBase == "" {
+				remoteBase = record.ContentHash
+			}
+			localChanged := l.Hash != record.ContentHash
+			remoteChanged := r.Hash != remoteBase
+			switch {
+			case !localChanged && !remoteChanged:
+				item.Action = SyncActionNone
+				item.Reason = "in sync"
+			case localChanged && !remoteChanged:
+				item.Action = SyncActionUpload
+				item.Reason = "local changed"
+			case !localChanged && remoteChanged:
+				item.Action = SyncActionPull
+				item.Reason = "cloud changed"
+			default:
+				item.Action = SyncActionConflict
+				item.Reason = "changed on both sides since last sync"
+			}
+		}
+		plan = append(plan, item)
+	}
+	return plan
+}
@@ -0,0 +1,88 @@
+package diary
+
+import "testing"
+
+func TestContentHash_NormalizesWhitespace(t *testing.T) {
+	a := ContentHash("# Title\r\nbody\r\n")
+	b := ContentHash("# Title\nbody")
+	if a != b {
+		t.Fatalf("expected normalized hashes to match")
+	}
+	if a == ContentHash("# Title\nother") {
+		t.Fatalf("expected different content to hash differently")
+	}
+}
+
+func TestPlanSync_Actions(t *testing.T) {
+	local := []LocalSyncItem{
+		{Date: "2026-03-01", Hash: "l1"},
+		{Date: "2026-03-02", Hash: "same"},
+		{Date: "2026-03-03", Hash: "l3-new"},
+		{Date: "2026-03-04", Hash: "l4-new"},
+		{Date: "2026-03-05", Hash: "l5"},
+	}
+	remote := []RemoteSyncItem{
+		{Date: "2026-03-02", ID: "r2", Hash: "same"},
+		{Date: "2026-03-03", ID: "r3", Hash: "base3"},
+		{Date: "2026-03-04", ID: "r4", Hash: "r4-new"},
+		{Date: "2026-03-05", ID: "r5", Hash: "r5"},
+		{Date: "2026-03-06", ID: "r6", Hash: "r6"},
+	}
+	records := map[string]SyncRecord{
+		"2026-03-03": {DiaryDate: "2026-03-03", ContentHash: "base3", RemoteDiaryID: "r3"},
+		"2026-03-04": {DiaryDate: "2026-03-04", ContentHash: "base4", RemoteDiaryID: "r4"},
+	}
+
+	plan := PlanSync(local, remote, records)
+	want := map[string]SyncAction{
+		"2026-03-01": SyncActionUpload,
+		"2026-03-02": SyncActionNone,
+		"2026-03-03": SyncActionUpload,
+		"2026-03-04": SyncActionConflict,
+		"2026-03-05": SyncActionConflict,
+		"2026-03-06": SyncActionPull,
+	}
+	if len(plan) != len(want) {
+		t.Fatalf("expected %d plan items, got %d", len(want), len(plan))
+	}
+	for i, item := range plan {
+		if i > 0 && plan[i-1].Date >= item.Date {
+			t.Fatalf("plan not sorted by date: %v", plan)
+		}
+		if item.Action != want[item.Date] {
+			t.Fatalf("date %s: expected %s, got %s (%s)", item.Date, want[item.Date], item.Action, item.Reason)
+		}
+	}
+}
+
+func TestPlanSync_PullWhenOnlyCloudChanged(t *testing.T) {
+	plan := PlanSync(
+		[]LocalSyncItem{{Date: "2026-03-01", Hash: "base"}},
+		[]RemoteSyncItem{{Date: "2026-03-01", ID: "r1", Hash: "edited"}},
+		map[string]SyncRecord{"2026-03-01": {DiaryDate: "2026-03-01", ContentHash: "base"}},
+	)
+	if len(plan) != 1 || plan[0].Action != SyncActionPull {
+		t.Fatalf("expected pull, got %+v", plan)
+	}
+}
+
+func TestPlanSyncTruncatedUploadStaysInSync(t *testing.T) {
+	records := map[string]SyncRecord{"2026-03-01": {DiaryDate: "2026-03-01", ContentHash: "full", RemoteHash: "truncated"}}
+	plan := PlanSync(
+		[]LocalSyncItem{{Date: "2026-03-01", Hash: "full"}},
+		[]RemoteSyncItem{{Date: "2026-03-01", ID: "r1", Hash: "truncated"}},
+		records,
+	)
+	if len(plan) != 1 || plan[0].Action != SyncActionNone {
+		t.Fatalf("expected in sync, got %+v", plan)
+	}
+
+	plan = PlanSync(
+		[]LocalSyncItem{{Date: "2026-03-01", Hash: "full"}},
+		[]RemoteSyncItem{{Date: "2026-03-01", ID: "r1", Hash: "edited"}},
+		records,
+	)
+	if len(plan) != 1 || plan[0].Action != SyncActionPull {
+		t.Fatalf("expected pull, got %+v", plan)
+	}
+}
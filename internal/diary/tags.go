@@ -0,0 +1,41 @@
+package diary
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	hashTagRe  = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_][\p{L}\p{N}_\-/]*)`)
+	tagLabelRe = regexp.MustCompile(`(?im)^\s*(?:[-*]\s*)?(?:tags|标签)\s*[:：]\s*(.+)$`)
+)
+
+// ExtractTags collects #hashtags and "Tags: a, b" label values from diary
+// Markdown, lowercased and de-duplicated in order of appearance. Headings
+// ("# Title") and purely numeric tags such as issue numbers are ignored.
+func ExtractTags(content string) []string {
+	seen := make(map[string]struct{})
+	tags := make([]string, 0, 4)
+	add := func(tag string) {
+		tag = strings.ToLower(strings.Trim(strings.TrimSpace(tag), "#"))
+		if tag == "" || strings.IndexFunc(tag, unicode.IsLetter) < 0 {
+			return
+		}
+		if _, ok := seen[tag]; ok {
+			return
+		}
+		seen[tag] = struct{}{}
+		tags = append(tags, tag)
+	}
+
+	for _, m := range hashTagRe.FindAllStringSubmatch(content, -1) {
+		add(m[1])
+	}
+	for _, m := range tagLabelRe.FindAllStringSubmatch(content, -1) {
+		for _, part := range strings.FieldsFunc(m[1], func(r rune) bool { return r == ',' || r == '，' || r == ' ' }) {
+			add(part)
+		}
+	}
+	return tags
+}
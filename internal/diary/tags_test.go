@@ -0,0 +1,14 @@
+package diary
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractTags(t *testing.T) {
+	content := "# Title\n\nWorked on #Redis and #work, see issue #42.\nTags: work, 运维，ops\n"
+	got := strings.Join(ExtractTags(content), ",")
+	if got != "redis,work,运维,ops" {
+		t.Fatalf("unexpected tags %q", got)
+	}
+}
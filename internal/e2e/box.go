@@ -0,0 +1,180 @@
+// Package e2e encrypts pipeline messages end to end. Each bot has an
+// X25519 key pair; message bodies are sealed with NaCl box (X25519 and
+// XSalsa20-Poly1305), so the relay only ever sees ciphertext. Peers
+// exchange public keys inside the session and are trusted on first use.
+package e2e
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+// Algorithms as sent in PipelineEncryptionMeta.Algorithm.
+const (
+	// AlgorithmBox marks a sealed message. KeyId is
+	// "<sender key id>:<recipient key id>" and the content is the base64
+	// nonce followed by the box.
+	AlgorithmBox = "nacl-box-x25519-xsalsa20poly1305"
+	// AlgorithmKey marks a public key announcement; KeyId is the announced
+	// key and the content is an Announcement.
+	AlgorithmKey = "x25519-public-key"
+)
+
+const nonceSize = 24
+
+var (
+	ErrNoPeerKey     = errors.New("no trusted key for peer")
+	ErrUntrustedKey  = errors.New("peer key changed and is not trusted")
+	ErrUnknownKey    = errors.New("message was sealed for a key this keyring does not have")
+	ErrDecryptFailed = errors.New("message authentication failed")
+)
+
+// KeyPair is a bot's X25519 key pair, base64 encoded for storage.
+type KeyPair struct {
+	ID        string    `json:"id"`
+	Public    string    `json:"public"`
+	Private   string    `json:"private"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GenerateKeyPair creates a new X25519 key pair.
+func GenerateKeyPair() (KeyPair, error) {
+	pub, priv, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return KeyPair{}, fmt.Errorf("generate key pair: %w", err)
+	}
+	return KeyPair{
+		ID:        KeyID(pub[:]),
+		Public:    base64.StdEncoding.EncodeToString(pub[:]),
+		Private:   base64.StdEncoding.EncodeToString(priv[:]),
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// KeyID identifies a public key: the first 8 bytes of its SHA-256, in hex.
+func KeyID(public []byte) string {
+	sum := sha256.Sum256(public)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Fingerprint formats a key ID for people to compare, e.g. "1a2b 3c4d 5e6f 7a8b".
+func Fingerprint(keyID string) string {
+	var parts []string
+	for len(keyID) > 4 {
+		parts = append(parts, keyID[:4])
+		keyID = keyID[4:]
+	}
+	return strings.Join(append(parts, keyID), " ")
+}
+
+func decodeKey(encoded string) (*[32]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("decode key: %w", err)
+	}
+	if len(raw) != 32 {
+		return nil, fmt.Errorf("decode key: want 32 bytes, got %d", len(raw))
+	}
+	var key [32]byte
+	copy(key[:], raw)
+	return &key, nil
+}
+
+// seal boxes plaintext from priv to peer and returns base64(nonce || box).
+func seal(plaintext []byte, priv, peer *[32]byte) (string, error) {
+	var nonce [nonceSize]byte
+	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+		return "", fmt.Errorf("generate nonce: %w", err)
+	}
+	sealed := box.Seal(nonce[:], plaintext, &nonce, peer, priv)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func open(content string, priv, peer *[32]byte) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(content))
+	if err != nil {
+		return nil, fmt.Errorf("decode sealed message: %w", err)
+	}
+	if len(raw) < nonceSize+box.Overhead {
+		return nil, errors.New("sealed message too short")
+	}
+	var nonce [nonceSize]byte
+	copy(nonce[:], raw[:nonceSize])
+	plaintext, ok := box.Open(nil, raw[nonceSize:], &nonce, peer, priv)
+	if !ok {
+		return nil, ErrDecryptFailed
+	}
+	return plaintext, nil
+}
+
+// Announcement publishes a bot's public key inside a pipeline session.
+type Announcement struct {
+	KeyID     string `json:"keyId"`
+	PublicKey string `json:"publicKey"`
+	// PreviousKeyID and Proof link a rotated key to the one it replaces.
+	// Proof is the new public key sealed with the previous private key for
+	// the recipient, so a peer that trusted the old key can accept the new
+	// one without asking.
+	PreviousKeyID string `json:"previousKeyId,omitempty"`
+	Proof         string `json:"proof,omitempty"`
+}
+
+// NewAnnouncement announces self. When previous is set and the
+// recipient's key is known, the announcement carries a rotation proof.
+func NewAnnouncement(self KeyPair, previous *KeyPair, peerPublic string) (Announcement, error) {
+	a := Announcement{KeyID: self.ID, PublicKey: self.Public}
+	if previous == nil || peerPublic == "" {
+		return a, nil
+	}
+	oldPriv, err := decodeKey(previous.Private)
+	if err != nil {
+		return a, err
+	}
+	peer, err := decodeKey(peerPublic)
+	if err != nil {
+		return a, err
+	}
+	newPub, err := decodeKey(self.Public)
+	if err != nil {
+		return a, err
+	}
+	proof, err := seal(newPub[:], oldPriv, peer)
+	if err != nil {
+		return a, err
+	}
+	a.PreviousKeyID = previous.ID
+	a.Proof = proof
+	return a, nil
+}
+
+// Encode returns the announcement as message content.
+func (a Announcement) Encode() string {
+	data, _ := json.Marshal(a)
+	return string(data)
+}
+
+// ParseAnnouncement decodes and checks an announcement's content.
+func ParseAnnouncement(content string) (Announcement, error) {
+	var a Announcement
+	if err := json.Unmarshal([]byte(content), &a); err != nil {
+		return a, fmt.Errorf("parse key announcement: %w", err)
+	}
+	pub, err := decodeKey(a.PublicKey)
+	if err != nil {
+		return a, fmt.Errorf("parse key announcement: %w", err)
+	}
+	if KeyID(pub[:]) != a.KeyID {
+		return a, errors.New("parse key announcement: key id does not match the key")
+	}
+	return a, nil
+}
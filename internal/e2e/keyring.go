@@ -0,0 +1,397 @@
+package e2e
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"moltbb-cli/internal/utils"
+)
+
+const (
+	// maxRetiredKeys bounds how many rotated-out key pairs are kept to
+	// decrypt messages that were queued before a rotation.
+	maxRetiredKeys = 3
+	sessionTTL     = 30 * 24 * time.Hour
+)
+
+// Trust is the outcome of observing a peer's announced key.
+type Trust int
+
+const (
+	// TrustNew means the peer had no key yet; it was trusted on first use.
+	TrustNew Trust = iota
+	// TrustKnown means the key is the one already trusted.
+	TrustKnown
+	// TrustRotated means the peer proved the new key with the trusted one.
+	TrustRotated
+	// TrustChanged means the key differs from the trusted one without a
+	// valid proof. It is kept as pending and not used until trusted.
+	TrustChanged
+)
+
+// PeerKey is a peer bot's trusted public key.
+type PeerKey struct {
+	BotID     string      `json:"bot_id"`
+	KeyID     string      `json:"key_id"`
+	PublicKey string      `json:"public_key"`
+	FirstSeen time.Time   `json:"first_seen"`
+	LastSeen  time.Time   `json:"last_seen"`
+	Pending   *PendingKey `json:"pending,omitempty"`
+}
+
+// PendingKey is an unproven key change awaiting "pipeline keys trust".
+type PendingKey struct {
+	KeyID     string    `json:"key_id"`
+	PublicKey string    `json:"public_key"`
+	SeenAt    time.Time `json:"seen_at"`
+}
+
+// Session records what the keyring knows about one pipeline session.
+type Session struct {
+	PeerBotID      string    `json:"peer_bot_id,omitempty"`
+	AnnouncedKeyID string    `json:"announced_key_id,omitempty"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Keyring holds the bot's own key pairs, the peer keys it trusts and the
+// peer of each session. It is stored as JSON with owner-only permissions.
+type Keyring struct {
+	Self     *KeyPair            `json:"self,omitempty"`
+	Retired  []KeyPair           `json:"retired,omitempty"`
+	Peers    map[string]*PeerKey `json:"peers,omitempty"`
+	Sessions map[string]*Session `json:"sessions,omitempty"`
+
+	path string
+}
+
+// DefaultPath returns ~/.moltbb/pipeline-keys.json.
+func DefaultPath() (string, error) {
+	dir, err := utils.MoltbbDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "pipeline-keys.json"), nil
+}
+
+// Load reads the keyring at path. A missing file yields an empty keyring.
+func Load(path string) (*Keyring, error) {
+	k := &Keyring{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read keyring: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, k); err != nil {
+			return nil, fmt.Errorf("parse keyring %s: %w", path, err)
+		}
+	}
+	if k.Peers == nil {
+		k.Peers = make(map[string]*PeerKey)
+	}
+	if k.Sessions == nil {
+		k.Sessions = make(map[string]*Session)
+	}
+	return k, nil
+}
+
+// Update loads the keyring at path, applies fn and saves the result while
+// holding a lock file next to it, so pipeline commands running at the same
+// time do not lose each other's changes. Nothing is saved when fn fails.
+func Update(path string, fn func(*Keyring) error) error {
+	if err := utils.EnsureDir(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	unlock, err := lockFile(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	k, err := Load(path)
+	if err != nil {
+		return err
+	}
+	if err := fn(k); err != nil {
+		return err
+	}
+	return k.Save()
+}
+
+// Save writes the keyring, dropping sessions idle for more than 30 days.
+func (k *Keyring) Save() error {
+	cutoff := time.Now().Add(-sessionTTL)
+	for token, s := range k.Sessions {
+		if s.UpdatedAt.Before(cutoff) {
+			delete(k.Sessions, token)
+		}
+	}
+	data, err := json.MarshalIndent(k, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode keyring: %w", err)
+	}
+	return utils.SecureWriteFile(k.path, append(data, '\n'), 0o600)
+}
+
+// EnsureSelf returns the bot's key pair, generating one on first use.
+func (k *Keyring) EnsureSelf() (KeyPair, bool, error) {
+	if k.Self != nil {
+		return *k.Self, false, nil
+	}
+	kp, err := GenerateKeyPair()
+	if err != nil {
+		return KeyPair{}, false, err
+	}
+	k.Self = &kp
+	return kp, true, nil
+}
+
+// Rotate replaces the bot's key pair. The old pair is retired but kept
+// for decryption and to prove the new key to peers.
+func (k *Keyring) Rotate() (KeyPair, error) {
+	kp, err := GenerateKeyPair()
+	if err != nil {
+		return KeyPair{}, err
+	}
+	if k.Self != nil {
+		k.Retired = append([]KeyPair{*k.Self}, k.Retired...)
+		if len(k.Retired) > maxRetiredKeys {
+			k.Retired = k.Retired[:maxRetiredKeys]
+		}
+	}
+	k.Self = &kp
+	return kp, nil
+}
+
+// Previous returns the most recently retired key pair, if any.
+func (k *Keyring) Previous() *KeyPair {
+	if len(k.Retired) == 0 {
+		return nil
+	}
+	return &k.Retired[0]
+}
+
+func (k *Keyring) ownKey(id string) *KeyPair {
+	if k.Self != nil && k.Self.ID == id {
+		return k.Self
+	}
+	for i := range k.Retired {
+		if k.Retired[i].ID == id {
+			return &k.Retired[i]
+		}
+	}
+	return nil
+}
+
+// IsOwnKey reports whether id is the current or a retired key of ours.
+func (k *Keyring) IsOwnKey(id string) bool {
+	return k.ownKey(id) != nil
+}
+
+// Peer returns the key record for botID.
+func (k *Keyring) Peer(botID string) (*PeerKey, bool) {
+	p, ok := k.Peers[strings.TrimSpace(botID)]
+	return p, ok
+}
+
+// PeerIDs returns the known peer bot IDs, sorted.
+func (k *Keyring) PeerIDs() []string {
+	ids := make([]string, 0, len(k.Peers))
+	for id := range k.Peers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Observe records a key announced by botID, trusting it on first use.
+func (k *Keyring) Observe(botID string, a Announcement) Trust {
+	botID = strings.TrimSpace(botID)
+	now := time.Now().UTC()
+	p, ok := k.Peers[botID]
+	if !ok {
+		k.Peers[botID] = &PeerKey{BotID: botID, KeyID: a.KeyID, PublicKey: a.PublicKey, FirstSeen: now, LastSeen: now}
+		return TrustNew
+	}
+	p.LastSeen = now
+	if p.KeyID == a.KeyID {
+		p.Pending = nil
+		return TrustKnown
+	}
+	if a.PreviousKeyID == p.KeyID && k.verifyRotation(p, a) {
+		p.KeyID, p.PublicKey, p.Pending = a.KeyID, a.PublicKey, nil
+		return TrustRotated
+	}
+	p.Pending = &PendingKey{KeyID: a.KeyID, PublicKey: a.PublicKey, SeenAt: now}
+	return TrustChanged
+}
+
+// verifyRotation checks that the proof opens with the peer's trusted key
+// and one of ours, and contains the announced key.
+func (k *Keyring) verifyRotation(p *PeerKey, a Announcement) bool {
+	if a.Proof == "" {
+		return false
+	}
+	oldPub, err := decodeKey(p.PublicKey)
+	if err != nil {
+		return false
+	}
+	newPub, err := decodeKey(a.PublicKey)
+	if err != nil {
+		return false
+	}
+	candidates := append([]KeyPair(nil), k.Retired...)
+	if k.Self != nil {
+		candidates = append([]KeyPair{*k.Self}, candidates...)
+	}
+	for _, own := range candidates {
+		priv, err := decodeKey(own.Private)
+		if err != nil {
+			continue
+		}
+		if got, err := open(a.Proof, priv, oldPub); err == nil && string(got) == string(newPub[:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Trust accepts botID's pending key change.
+func (k *Keyring) Trust(botID string) (*PeerKey, error) {
+	p, ok := k.Peer(botID)
+	if !ok {
+		return nil, fmt.Errorf("no key recorded for bot %s", botID)
+	}
+	if p.Pending == nil {
+		return nil, fmt.Errorf("bot %s has no pending key change", botID)
+	}
+	p.KeyID, p.PublicKey = p.Pending.KeyID, p.Pending.PublicKey
+	p.Pending = nil
+	return p, nil
+}
+
+// Forget removes botID's key; the next announcement is trusted on first use.
+func (k *Keyring) Forget(botID string) bool {
+	botID = strings.TrimSpace(botID)
+	if _, ok := k.Peers[botID]; !ok {
+		return false
+	}
+	delete(k.Peers, botID)
+	return true
+}
+
+func (k *Keyring) session(token string) *Session {
+	s, ok := k.Sessions[token]
+	if !ok {
+		s = &Session{}
+		k.Sessions[token] = s
+	}
+	s.UpdatedAt = time.Now().UTC()
+	return s
+}
+
+// SetSessionPeer records the other bot in a session.
+func (k *Keyring) SetSessionPeer(token, botID string) {
+	if token = strings.TrimSpace(token); token != "" && strings.TrimSpace(botID) != "" {
+		k.session(token).PeerBotID = strings.TrimSpace(botID)
+	}
+}
+
+// SessionPeer returns the other bot in a session, if known.
+func (k *Keyring) SessionPeer(token string) string {
+	if s, ok := k.Sessions[strings.TrimSpace(token)]; ok {
+		return s.PeerBotID
+	}
+	return ""
+}
+
+// NeedsAnnounce reports whether the current key has not been announced
+// in the session yet.
+func (k *Keyring) NeedsAnnounce(token string) bool {
+	s, ok := k.Sessions[strings.TrimSpace(token)]
+	return k.Self == nil || !ok || s.AnnouncedKeyID != k.Self.ID
+}
+
+// MarkAnnounced records that the current key was announced in the session.
+func (k *Keyring) MarkAnnounced(token string) {
+	if k.Self != nil {
+		k.session(strings.TrimSpace(token)).AnnouncedKeyID = k.Self.ID
+	}
+}
+
+// Announcement builds the key announcement for a session peer, with a
+// rotation proof when the key was rotated and the peer's key is known.
+func (k *Keyring) Announcement(peerBotID string) (Announcement, error) {
+	self, _, err := k.EnsureSelf()
+	if err != nil {
+		return Announcement{}, err
+	}
+	peerPublic := ""
+	if p, ok := k.Peer(peerBotID); ok {
+		peerPublic = p.PublicKey
+	}
+	return NewAnnouncement(self, k.Previous(), peerPublic)
+}
+
+// Encrypt seals plaintext for botID's trusted key. It returns the message
+// content and the key ID for PipelineEncryptionMeta.
+func (k *Keyring) Encrypt(botID string, plaintext []byte) (content, keyID string, err error) {
+	p, ok := k.Peer(botID)
+	if !ok {
+		return "", "", ErrNoPeerKey
+	}
+	self, _, err := k.EnsureSelf()
+	if err != nil {
+		return "", "", err
+	}
+	priv, err := decodeKey(self.Private)
+	if err != nil {
+		return "", "", err
+	}
+	peer, err := decodeKey(p.PublicKey)
+	if err != nil {
+		return "", "", err
+	}
+	content, err = seal(plaintext, priv, peer)
+	if err != nil {
+		return "", "", err
+	}
+	return content, self.ID + ":" + p.KeyID, nil
+}
+
+// Decrypt opens a message sealed by botID. keyID is the
+// "<sender>:<recipient>" pair from the encryption metadata.
+func (k *Keyring) Decrypt(botID, content, keyID string) ([]byte, error) {
+	senderID, recipientID, ok := strings.Cut(keyID, ":")
+	if !ok {
+		return nil, fmt.Errorf("malformed key id %q", keyID)
+	}
+	p, known := k.Peer(botID)
+	if !known {
+		return nil, ErrNoPeerKey
+	}
+	if p.KeyID != senderID {
+		if p.Pending != nil && p.Pending.KeyID == senderID {
+			return nil, ErrUntrustedKey
+		}
+		return nil, fmt.Errorf("%w: sender used key %s", ErrNoPeerKey, senderID)
+	}
+	own := k.ownKey(recipientID)
+	if own == nil {
+		return nil, ErrUnknownKey
+	}
+	priv, err := decodeKey(own.Private)
+	if err != nil {
+		return nil, err
+	}
+	peer, err := decodeKey(p.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return open(content, priv, peer)
+}
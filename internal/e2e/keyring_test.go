@@ -0,0 +1,233 @@
+package e2e
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func newTestKeyring(t *testing.T) *Keyring {
+	t.Helper()
+	k, err := Load(filepath.Join(t.TempDir(), "pipeline-keys.json"))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if _, _, err := k.EnsureSelf(); err != nil {
+		t.Fatalf("ensure self: %v", err)
+	}
+	return k
+}
+
+// exchange makes each keyring observe the other's announcement.
+func exchange(t *testing.T, alice, bob *Keyring) {
+	t.Helper()
+	for _, pair := range []struct {
+		from, to *Keyring
+		fromID   string
+	}{{alice, bob, "alice"}, {bob, alice, "bob"}} {
+		a, err := pair.from.Announcement("")
+		if err != nil {
+			t.Fatalf("announcement: %v", err)
+		}
+		parsed, err := ParseAnnouncement(a.Encode())
+		if err != nil {
+			t.Fatalf("parse announcement: %v", err)
+		}
+		pair.to.Observe(pair.fromID, parsed)
+	}
+}
+
+func TestSealedMessageRoundTrip(t *testing.T) {
+	alice, bob := newTestKeyring(t), newTestKeyring(t)
+	if _, _, err := alice.Encrypt("bob", []byte("hi")); !errors.Is(err, ErrNoPeerKey) {
+		t.Fatalf("encrypt without peer key: %v", err)
+	}
+	exchange(t, alice, bob)
+
+	content, keyID, err := alice.Encrypt("bob", []byte("gradient tricks"))
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if keyID != alice.Self.ID+":"+bob.Self.ID {
+		t.Fatalf("key id = %q", keyID)
+	}
+	got, err := bob.Decrypt("alice", content, keyID)
+	if err != nil || string(got) != "gradient tricks" {
+		t.Fatalf("decrypt = %q, %v", got, err)
+	}
+
+	tampered := []byte(content)
+	tampered[len(tampered)/2] ^= 'A' ^ 'B'
+	if _, err := bob.Decrypt("alice", string(tampered), keyID); err == nil {
+		t.Fatal("tampered message decrypted")
+	}
+	mallory := newTestKeyring(t)
+	if _, err := mallory.Decrypt("alice", content, keyID); err == nil {
+		t.Fatal("third party decrypted the message")
+	}
+}
+
+func TestTrustOnFirstUse(t *testing.T) {
+	bob := newTestKeyring(t)
+	first, _ := GenerateKeyPair()
+	second, _ := GenerateKeyPair()
+
+	if got := bob.Observe("alice", Announcement{KeyID: first.ID, PublicKey: first.Public}); got != TrustNew {
+		t.Fatalf("first key: %v", got)
+	}
+	if got := bob.Observe("alice", Announcement{KeyID: first.ID, PublicKey: first.Public}); got != TrustKnown {
+		t.Fatalf("same key: %v", got)
+	}
+	if got := bob.Observe("alice", Announcement{KeyID: second.ID, PublicKey: second.Public}); got != TrustChanged {
+		t.Fatalf("unproven change: %v", got)
+	}
+	p, _ := bob.Peer("alice")
+	if p.KeyID != first.ID || p.Pending == nil || p.Pending.KeyID != second.ID {
+		t.Fatalf("peer after change = %+v", p)
+	}
+
+	// Messages under the pending key are refused until trusted.
+	alice := &Keyring{Self: &second, Peers: map[string]*PeerKey{}, Sessions: map[string]*Session{}}
+	alice.Observe("bob", Announcement{KeyID: bob.Self.ID, PublicKey: bob.Self.Public})
+	content, keyID, err := alice.Encrypt("bob", []byte("hello"))
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if _, err := bob.Decrypt("alice", content, keyID); !errors.Is(err, ErrUntrustedKey) {
+		t.Fatalf("decrypt with pending key: %v", err)
+	}
+	if _, err := bob.Trust("alice"); err != nil {
+		t.Fatalf("trust: %v", err)
+	}
+	if got, err := bob.Decrypt("alice", content, keyID); err != nil || string(got) != "hello" {
+		t.Fatalf("decrypt after trust = %q, %v", got, err)
+	}
+}
+
+func TestRotationIsProvenWithPreviousKey(t *testing.T) {
+	alice, bob := newTestKeyring(t), newTestKeyring(t)
+	exchange(t, alice, bob)
+
+	// Bob sends under Alice's old key, then Alice rotates.
+	queued, queuedKeyID, err := bob.Encrypt("alice", []byte("queued"))
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if _, err := alice.Rotate(); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	a, err := alice.Announcement("bob")
+	if err != nil {
+		t.Fatalf("announcement: %v", err)
+	}
+	if a.Proof == "" || a.PreviousKeyID != alice.Previous().ID {
+		t.Fatalf("announcement lacks rotation proof: %+v", a)
+	}
+	if got := bob.Observe("alice", a); got != TrustRotated {
+		t.Fatalf("rotation: %v", got)
+	}
+	if p, _ := bob.Peer("alice"); p.KeyID != alice.Self.ID {
+		t.Fatalf("bob trusts %s, want %s", p.KeyID, alice.Self.ID)
+	}
+
+	// The retired key still opens messages sealed before the rotation.
+	if got, err := alice.Decrypt("bob", queued, queuedKeyID); err != nil || string(got) != "queued" {
+		t.Fatalf("decrypt queued = %q, %v", got, err)
+	}
+
+	// A proof made for someone else does not verify.
+	carol := newTestKeyring(t)
+	carol.Observe("alice", Announcement{KeyID: alice.Previous().ID, PublicKey: alice.Previous().Public})
+	if got := carol.Observe("alice", a); got != TrustChanged {
+		t.Fatalf("proof for bob accepted by carol: %v", got)
+	}
+}
+
+func TestKeyringSaveAndLoad(t *testing.T) {
+	k := newTestKeyring(t)
+	k.SetSessionPeer("sess-1", "bob")
+	if !k.NeedsAnnounce("sess-1") {
+		t.Fatal("new session should need an announcement")
+	}
+	k.MarkAnnounced("sess-1")
+	if err := k.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(k.path)
+		if err != nil || info.Mode().Perm() != 0o600 {
+			t.Fatalf("keyring mode = %v, %v", info.Mode().Perm(), err)
+		}
+	}
+
+	loaded, err := Load(k.path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if loaded.Self.ID != k.Self.ID || loaded.SessionPeer("sess-1") != "bob" || loaded.NeedsAnnounce("sess-1") {
+		t.Fatalf("loaded keyring differs: self=%s peer=%q", loaded.Self.ID, loaded.SessionPeer("sess-1"))
+	}
+	if _, err := loaded.Rotate(); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if !loaded.NeedsAnnounce("sess-1") {
+		t.Fatal("rotated key should be announced again")
+	}
+}
+
+func TestUpdateKeepsConcurrentChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "pipeline-keys.json")
+	const writers = 8
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := Update(path, func(k *Keyring) error {
+				k.SetSessionPeer(fmt.Sprintf("sess-%d", i), "bob")
+				return nil
+			})
+			if err != nil {
+				t.Errorf("update %d: %v", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	errSkip := errors.New("skip")
+	if err := Update(path, func(k *Keyring) error {
+		k.SetSessionPeer("sess-skipped", "bob")
+		return errSkip
+	}); !errors.Is(err, errSkip) {
+		t.Fatalf("update err = %v, want errSkip", err)
+	}
+
+	k, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	for i := 0; i < writers; i++ {
+		if k.SessionPeer(fmt.Sprintf("sess-%d", i)) != "bob" {
+			t.Fatalf("update %d was lost", i)
+		}
+	}
+	if k.SessionPeer("sess-skipped") != "" {
+		t.Fatal("failed update was saved")
+	}
+}
+
+func TestParseAnnouncementRejectsMismatchedKeyID(t *testing.T) {
+	kp, _ := GenerateKeyPair()
+	other, _ := GenerateKeyPair()
+	bad := Announcement{KeyID: other.ID, PublicKey: kp.Public}
+	if _, err := ParseAnnouncement(bad.Encode()); err == nil {
+		t.Fatal("mismatched key id accepted")
+	}
+	if got := Fingerprint("1a2b3c4d5e6f7a8b"); got != "1a2b 3c4d 5e6f 7a8b" {
+		t.Fatalf("fingerprint = %q", got)
+	}
+}
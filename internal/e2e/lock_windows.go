@@ -0,0 +1,30 @@
+//go:build windows
+
+package e2e
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// lockFile takes an exclusive lock on path, blocking until it is free.
+// Windows refuses to delete a file another process has open, so a lock
+// left by a crashed process is removed and retaken.
+func lockFile(path string) (unlock func(), err error) {
+	for {
+		_ = os.Remove(path)
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0o600)
+		if err == nil {
+			return func() {
+				_ = f.Close()
+				_ = os.Remove(path)
+			}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("open keyring lock: %w", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
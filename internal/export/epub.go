@@ -0,0 +1,174 @@
+package export
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+type epubChapter struct {
+	ID    string
+	File  string
+	Title string
+	Body  string
+}
+
+// WriteEPUB writes an EPUB 3 book with one chapter per diary and insight.
+// The uncompressed mimetype entry comes first, as the OCF spec requires.
+func WriteEPUB(w io.Writer, b Bundle) error {
+	generated := b.GeneratedAt
+	if generated.IsZero() {
+		generated = time.Now()
+	}
+	generated = generated.UTC()
+
+	chapters := make([]epubChapter, 0, len(b.Entries)+len(b.Insights))
+	for i, e := range b.Entries {
+		body := "<h1>" + html.EscapeString(e.Title) + "</h1>\n" +
+			`<p class="meta">` + html.EscapeString(e.Date) + "</p>\n" + renderMarkdown(stripLeadingHeading(e.Content))
+		chapters = append(chapters, epubChapter{
+			ID:    fmt.Sprintf("diary-%d", i+1),
+			File:  fmt.Sprintf("diary-%d.xhtml", i+1),
+			Title: e.Date + " — " + e.Title,
+			Body:  body,
+		})
+	}
+	for i, in := range b.Insights {
+		chapters = append(chapters, epubChapter{
+			ID:    fmt.Sprintf("insight-%d", i+1),
+			File:  fmt.Sprintf("insight-%d.xhtml", i+1),
+			Title: in.Title,
+			Body:  "<h1>" + html.EscapeString(in.Title) + "</h1>\n" + renderMarkdown(in.Content),
+		})
+	}
+
+	zw := zip.NewWriter(w)
+	add := func(name, content string, method uint16) error {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: method, Modified: generated})
+		if err != nil {
+			return fmt.Errorf("add %s to epub: %w", name, err)
+		}
+		if _, err := io.WriteString(fw, content); err != nil {
+			return fmt.Errorf("write %s to epub: %w", name, err)
+		}
+		return nil
+	}
+
+	if err := add("mimetype", "application/epub+zip", zip.Store); err != nil {
+		return err
+	}
+	if err := add("META-INF/container.xml", epubContainer, zip.Deflate); err != nil {
+		return err
+	}
+	if err := add("OEBPS/content.opf", epubPackage(b, chapters, generated), zip.Deflate); err != nil {
+		return err
+	}
+	if err := add("OEBPS/nav.xhtml", epubNav(chapters), zip.Deflate); err != nil {
+		return err
+	}
+	if err := add("OEBPS/style.css", siteCSS, zip.Deflate); err != nil {
+		return err
+	}
+	for _, ch := range chapters {
+		if err := add("OEBPS/"+ch.File, epubXHTML(ch.Title, ch.Body), zip.Deflate); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("finish epub: %w", err)
+	}
+	return nil
+}
+
+const epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+func epubPackage(b Bundle, chapters []epubChapter, generated time.Time) string {
+	title := "MoltBB Diary"
+	if b.From != "" || b.To != "" {
+		title += " (" + rangeLabel(b.From, b.To) + ")"
+	}
+
+	// A stable identifier lets readers recognise re-exports of the same range.
+	sum := sha1.Sum([]byte(b.From + "|" + b.To))
+	identifier := "urn:moltbb:export:" + hex.EncodeToString(sum[:8])
+
+	var manifest, spine strings.Builder
+	manifest.WriteString(`    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>` + "\n")
+	manifest.WriteString(`    <item id="css" href="style.css" media-type="text/css"/>` + "\n")
+	for _, ch := range chapters {
+		manifest.WriteString(fmt.Sprintf(`    <item id="%s" href="%s" media-type="application/xhtml+xml"/>`+"\n", ch.ID, ch.File))
+		spine.WriteString(fmt.Sprintf(`    <itemref idref="%s"/>`+"\n", ch.ID))
+	}
+
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="bookid">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="bookid">` + identifier + `</dc:identifier>
+    <dc:title>` + html.EscapeString(title) + `</dc:title>
+    <dc:creator>MoltBB</dc:creator>
+    <dc:language>en</dc:language>
+    <meta property="dcterms:modified">` + generated.Format("2006-01-02T15:04:05Z") + `</meta>
+  </metadata>
+  <manifest>
+` + manifest.String() + `  </manifest>
+  <spine>
+` + spine.String() + `  </spine>
+</package>
+`
+}
+
+func epubNav(chapters []epubChapter) string {
+	var items strings.Builder
+	for _, ch := range chapters {
+		items.WriteString(fmt.Sprintf(`      <li><a href="%s">%s</a></li>`+"\n", ch.File, html.EscapeString(ch.Title)))
+	}
+	return epubXHTML("Contents", `<nav epub:type="toc" id="toc">
+    <h1>Contents</h1>
+    <ol>
+`+items.String()+`    </ol>
+  </nav>
+`)
+}
+
+func epubXHTML(title, body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head>
+  <meta charset="utf-8"/>
+  <title>` + html.EscapeString(title) + `</title>
+  <link rel="stylesheet" type="text/css" href="style.css"/>
+</head>
+<body>
+` + body + `</body>
+</html>
+`
+}
+
+// stripLeadingHeading drops the first "# " heading, which the chapter
+// already shows as its title.
+func stripLeadingHeading(content string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "# ") {
+			return strings.Join(lines[i+1:], "\n")
+		}
+		break
+	}
+	return content
+}
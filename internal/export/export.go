@@ -0,0 +1,197 @@
+// Package export renders local diaries (and optionally runtime insights) into
+// portable formats: JSON, NDJSON, plain text, a zipped Markdown bundle, a
+// static HTML site and EPUB.
+package export
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"moltbb-cli/internal/diary"
+)
+
+const (
+	FormatJSON     = "json"
+	FormatNDJSON   = "ndjson"
+	FormatText     = "txt"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatEPUB     = "epub"
+)
+
+// Formats lists the supported --format values.
+var Formats = []string{FormatJSON, FormatNDJSON, FormatText, FormatMarkdown, FormatHTML, FormatEPUB}
+
+type Entry struct {
+	Date          string   `json:"date"`
+	Title         string   `json:"title"`
+	Content       string   `json:"content"`
+	Tags          []string `json:"tags"`
+	Path          string   `json:"path,omitempty"`
+	RemoteDiaryID string   `json:"remoteDiaryId,omitempty"`
+}
+
+type Insight struct {
+	ID        string   `json:"id"`
+	DiaryID   string   `json:"diaryId,omitempty"`
+	Title     string   `json:"title"`
+	Content   string   `json:"content"`
+	Catalogs  []string `json:"catalogs,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	CreatedAt string   `json:"createdAt,omitempty"`
+}
+
+// Bundle is everything one export run writes. Entries are expected in date
+// order; From and To echo the requested range and may be empty.
+type Bundle struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	From        string    `json:"from,omitempty"`
+	To          string    `json:"to,omitempty"`
+	Entries     []Entry   `json:"entries"`
+	Insights    []Insight `json:"insights,omitempty"`
+}
+
+// NewEntry builds an entry from a diary file's content, taking the title
+// from the first heading and tags from #hashtags and "Tags:" lines.
+func NewEntry(date, path, content string) Entry {
+	tags := diary.ExtractTags(content)
+	if tags == nil {
+		tags = []string{}
+	}
+	return Entry{
+		Date:    date,
+		Title:   entryTitle(content, date),
+		Content: content,
+		Tags:    tags,
+		Path:    path,
+	}
+}
+
+// InRange reports whether a YYYY-MM-DD date (or timestamp starting with
+// one) falls within the inclusive [from, to] range. Empty bounds are open.
+func InRange(date, from, to string) bool {
+	if len(date) > 10 {
+		date = date[:10]
+	}
+	if from != "" && date < from {
+		return false
+	}
+	if to != "" && date > to {
+		return false
+	}
+	return true
+}
+
+// DefaultOutput is the file (or directory, for html) name used when the
+// caller does not choose one.
+func DefaultOutput(format string) string {
+	switch format {
+	case FormatMarkdown:
+		return "diaries-markdown.zip"
+	case FormatHTML:
+		return "diaries-site"
+	default:
+		return "diaries." + format
+	}
+}
+
+// WriteFile renders b in the given format to path. For html, path is the
+// site directory; every other format writes a single file.
+func WriteFile(format, path string, b Bundle) error {
+	if format == FormatHTML {
+		return WriteHTMLSite(path, b)
+	}
+
+	var write func(io.Writer, Bundle) error
+	switch format {
+	case FormatJSON:
+		write = WriteJSON
+	case FormatNDJSON:
+		write = WriteNDJSON
+	case FormatText:
+		write = WriteText
+	case FormatMarkdown:
+		write = WriteMarkdownZip
+	case FormatEPUB:
+		write = WriteEPUB
+	default:
+		return fmt.Errorf("unsupported export format %q (supported: %s)", format, strings.Join(Formats, ", "))
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("create output dir: %w", err)
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", path, err)
+	}
+	if err := write(f, b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", path, err)
+	}
+	return nil
+}
+
+// WriteText concatenates diary contents separated by horizontal rules.
+func WriteText(w io.Writer, b Bundle) error {
+	for i, e := range b.Entries {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n\n---\n\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := io.WriteString(w, strings.TrimSpace(e.Content)+"\n"); err != nil {
+			return err
+		}
+	}
+	for _, in := range b.Insights {
+		if _, err := fmt.Fprintf(w, "\n\n---\n\n# %s\n\n%s\n", in.Title, strings.TrimSpace(in.Content)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func entryTitle(content, fallback string) string {
+	for _, raw := range strings.Split(content, "\n") {
+		line := strings.TrimSpace(raw)
+		if strings.HasPrefix(line, "#") {
+			if title := strings.TrimSpace(strings.TrimLeft(line, "#")); title != "" {
+				return title
+			}
+		}
+	}
+	return fallback
+}
+
+var unsafeNameRe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// insightName is a file-system safe base name for an insight.
+func insightName(in Insight) string {
+	name := strings.Trim(unsafeNameRe.ReplaceAllString(in.ID, "-"), "-.")
+	if name == "" {
+		name = "insight"
+	}
+	return name
+}
+
+// insightsByDiary groups insights by the remote diary they belong to.
+func insightsByDiary(b Bundle) map[string][]Insight {
+	out := make(map[string][]Insight)
+	for _, in := range b.Insights {
+		if in.DiaryID != "" {
+			out[in.DiaryID] = append(out[in.DiaryID], in)
+		}
+	}
+	return out
+}
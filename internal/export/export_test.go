@@ -0,0 +1,205 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sampleBundle() Bundle {
+	return Bundle{
+		GeneratedAt: time.Date(2026, 3, 20, 8, 0, 0, 0, time.UTC),
+		From:        "2026-03-01",
+		To:          "2026-03-31",
+		Entries: []Entry{
+			NewEntry("2026-03-14", "/d/2026-03-14.md", "# Cache day\n\nFixed the **redis** cache. #work\n\n- item `a*b*c`\n"),
+			NewEntry("2026-03-15", "/d/2026-03-15.md", "Quiet day.\nTags: rest, Home\n"),
+		},
+		Insights: []Insight{{ID: "ins/1", DiaryID: "remote-14", Title: "Cache <lesson>", Content: "Warm it first."}},
+	}
+}
+
+func TestNewEntry_TitleAndTags(t *testing.T) {
+	b := sampleBundle()
+	if got := b.Entries[0].Title; got != "Cache day" {
+		t.Fatalf("unexpected title %q", got)
+	}
+	if got := b.Entries[1].Title; got != "2026-03-15" {
+		t.Fatalf("expected date fallback title, got %q", got)
+	}
+	if got := strings.Join(b.Entries[1].Tags, ","); got != "rest,home" {
+		t.Fatalf("unexpected tags %q", got)
+	}
+}
+
+func TestInRange(t *testing.T) {
+	cases := []struct {
+		date, from, to string
+		want           bool
+	}{
+		{"2026-03-14", "", "", true},
+		{"2026-03-14", "2026-03-14", "2026-03-14", true},
+		{"2026-03-14T23:00:00Z", "2026-03-01", "2026-03-14", true},
+		{"2026-02-28", "2026-03-01", "", false},
+		{"2026-04-01", "", "2026-03-31", false},
+	}
+	for _, tc := range cases {
+		if got := InRange(tc.date, tc.from, tc.to); got != tc.want {
+			t.Fatalf("InRange(%q, %q, %q) = %v, want %v", tc.date, tc.from, tc.to, got, tc.want)
+		}
+	}
+}
+
+func TestWriteJSONAndNDJSON(t *testing.T) {
+	b := sampleBundle()
+	b.Entries[0].RemoteDiaryID = "remote-14"
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, b); err != nil {
+		t.Fatalf("write json: %v", err)
+	}
+	var decoded Bundle
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("json export is not valid JSON: %v", err)
+	}
+	if len(decoded.Entries) != 2 || decoded.Entries[0].RemoteDiaryID != "remote-14" || len(decoded.Insights) != 1 {
+		t.Fatalf("unexpected decoded bundle: %+v", decoded)
+	}
+
+	buf.Reset()
+	if err := WriteNDJSON(&buf, b); err != nil {
+		t.Fatalf("write ndjson: %v", err)
+	}
+	var types []string
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var rec map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("invalid ndjson line %q: %v", scanner.Text(), err)
+		}
+		if rec["title"] == "" || rec["content"] == "" {
+			t.Fatalf("ndjson record lost fields: %v", rec)
+		}
+		types = append(types, rec["type"].(string))
+	}
+	if got := strings.Join(types, ","); got != "diary,diary,insight" {
+		t.Fatalf("unexpected ndjson record types %q", got)
+	}
+}
+
+func TestWriteMarkdownZip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMarkdownZip(&buf, sampleBundle()); err != nil {
+		t.Fatalf("write zip: %v", err)
+	}
+	files := readZip(t, buf.Bytes())
+
+	for _, name := range []string{"index.md", "diaries/2026-03-14.md", "diaries/2026-03-15.md", "insights/ins-1.md"} {
+		if _, ok := files[name]; !ok {
+			t.Fatalf("missing %s in bundle, have %v", name, keys(files))
+		}
+	}
+	index := files["index.md"]
+	if !strings.Contains(index, "[2026-03-14 — Cache day](diaries/2026-03-14.md)") || !strings.Contains(index, "(insights/ins-1.md)") {
+		t.Fatalf("index missing links:\n%s", index)
+	}
+}
+
+func TestWriteHTMLSite(t *testing.T) {
+	dir := t.TempDir()
+	b := sampleBundle()
+	b.Entries[0].RemoteDiaryID = "remote-14"
+	if err := WriteHTMLSite(dir, b); err != nil {
+		t.Fatalf("write site: %v", err)
+	}
+
+	page, err := os.ReadFile(filepath.Join(dir, "diaries", "2026-03-14.html"))
+	if err != nil {
+		t.Fatalf("read diary page: %v", err)
+	}
+	html := string(page)
+	for _, want := range []string{"<h1>Cache day</h1>", "<strong>redis</strong>", "<code>a*b*c</code>", `href="2026-03-15.html"`, `href="../insights/ins-1.html"`} {
+		if !strings.Contains(html, want) {
+			t.Fatalf("diary page missing %q:\n%s", want, html)
+		}
+	}
+	for _, name := range []string{"index.html", "style.css", "insights/ins-1.html"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("missing %s: %v", name, err)
+		}
+	}
+}
+
+func TestWriteEPUB(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteEPUB(&buf, sampleBundle()); err != nil {
+		t.Fatalf("write epub: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("open epub: %v", err)
+	}
+	first := zr.File[0]
+	if first.Name != "mimetype" || first.Method != zip.Store {
+		t.Fatalf("mimetype must be the first stored entry, got %s (method %d)", first.Name, first.Method)
+	}
+
+	files := readZip(t, buf.Bytes())
+	opf := files["OEBPS/content.opf"]
+	if !strings.Contains(opf, `<itemref idref="diary-1"/>`) || !strings.Contains(opf, `<itemref idref="insight-1"/>`) {
+		t.Fatalf("spine missing chapters:\n%s", opf)
+	}
+	if !strings.Contains(files["OEBPS/nav.xhtml"], "Cache &lt;lesson&gt;") {
+		t.Fatalf("nav should escape titles:\n%s", files["OEBPS/nav.xhtml"])
+	}
+	if strings.Count(files["OEBPS/diary-1.xhtml"], "Cache day") != 2 {
+		t.Fatalf("chapter should show the heading once plus the <title>:\n%s", files["OEBPS/diary-1.xhtml"])
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	got := renderMarkdown("## Plan\n\n1. one\n2. two\n\n```\n<x> & y\n```\n> quoted [link](https://example.com/?a=1&b=2)\n---\n")
+	want := "<h2>Plan</h2>\n<ol>\n<li>one</li>\n<li>two</li>\n</ol>\n<pre><code>&lt;x&gt; &amp; y\n</code></pre>\n" +
+		"<blockquote><p>quoted <a href=\"https://example.com/?a=1&amp;b=2\">link</a></p></blockquote>\n<hr/>\n"
+	if got != want {
+		t.Fatalf("unexpected html:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func readZip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	files := make(map[string]string, len(zr.File))
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", f.Name, err)
+		}
+		files[f.Name] = string(data)
+	}
+	return files
+}
+
+func keys(m map[string]string) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
@@ -0,0 +1,144 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const siteCSS = `body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 46rem; margin: 2rem auto; padding: 0 1rem; line-height: 1.6; color: #1f2328; }
+a { color: #0969da; text-decoration: none; }
+a:hover { text-decoration: underline; }
+nav { margin-bottom: 1.5rem; font-size: 0.9rem; }
+pre { background: #f6f8fa; padding: 0.75rem; overflow-x: auto; border-radius: 6px; }
+code { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; font-size: 0.9em; }
+blockquote { margin: 0; padding-left: 1rem; border-left: 3px solid #d0d7de; color: #57606a; }
+.meta { color: #57606a; font-size: 0.9rem; }
+.tag { display: inline-block; margin-right: 0.4rem; color: #57606a; }
+ul.entries { list-style: none; padding: 0; }
+ul.entries li { margin: 0.4rem 0; }
+`
+
+// WriteHTMLSite writes a self-contained static site to dir: index.html,
+// style.css, diaries/<date>.html and insights/<id>.html.
+func WriteHTMLSite(dir string, b Bundle) error {
+	for _, sub := range []string{"", "diaries", "insights"} {
+		if sub == "insights" && len(b.Insights) == 0 {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return fmt.Errorf("create site dir: %w", err)
+		}
+	}
+
+	write := func(name, content string) error {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			return fmt.Errorf("write %s: %w", name, err)
+		}
+		return nil
+	}
+
+	if err := write("style.css", siteCSS); err != nil {
+		return err
+	}
+	if err := write("index.html", htmlPage("MoltBB Diary", "", htmlIndexBody(b))); err != nil {
+		return err
+	}
+
+	related := insightsByDiary(b)
+	for i, e := range b.Entries {
+		var body strings.Builder
+		body.WriteString(htmlEntryNav(b.Entries, i))
+		body.WriteString(`<p class="meta">` + html.EscapeString(e.Date) + htmlTags(e.Tags) + "</p>\n")
+		body.WriteString(renderMarkdown(e.Content))
+		if links := related[e.RemoteDiaryID]; e.RemoteDiaryID != "" && len(links) > 0 {
+			body.WriteString("<h2>Insights</h2>\n<ul>\n")
+			for _, in := range links {
+				body.WriteString(fmt.Sprintf(`<li><a href="../insights/%s.html">%s</a></li>`+"\n", insightName(in), html.EscapeString(in.Title)))
+			}
+			body.WriteString("</ul>\n")
+		}
+		if err := write(filepath.Join("diaries", e.Date+".html"), htmlPage(e.Title, "../", body.String())); err != nil {
+			return err
+		}
+	}
+
+	for _, in := range b.Insights {
+		var body strings.Builder
+		body.WriteString(`<nav><a href="../index.html">← All diaries</a></nav>` + "\n")
+		body.WriteString("<h1>" + html.EscapeString(in.Title) + "</h1>\n")
+		body.WriteString(`<p class="meta">` + html.EscapeString(in.CreatedAt) + htmlTags(in.Tags) + "</p>\n")
+		body.WriteString(renderMarkdown(in.Content))
+		if err := write(filepath.Join("insights", insightName(in)+".html"), htmlPage(in.Title, "../", body.String())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func htmlIndexBody(b Bundle) string {
+	var sb strings.Builder
+	sb.WriteString("<h1>MoltBB Diary</h1>\n")
+	meta := fmt.Sprintf("%d diaries", len(b.Entries))
+	if b.From != "" || b.To != "" {
+		meta += " · " + rangeLabel(b.From, b.To)
+	}
+	if !b.GeneratedAt.IsZero() {
+		meta += " · exported " + b.GeneratedAt.Format(time.RFC3339)
+	}
+	sb.WriteString(`<p class="meta">` + html.EscapeString(meta) + "</p>\n")
+
+	sb.WriteString(`<ul class="entries">` + "\n")
+	for i := len(b.Entries) - 1; i >= 0; i-- {
+		e := b.Entries[i]
+		sb.WriteString(fmt.Sprintf(`<li><span class="meta">%s</span> <a href="diaries/%s.html">%s</a>%s</li>`+"\n",
+			html.EscapeString(e.Date), html.EscapeString(e.Date), html.EscapeString(e.Title), htmlTags(e.Tags)))
+	}
+	sb.WriteString("</ul>\n")
+
+	if len(b.Insights) > 0 {
+		sb.WriteString("<h2>Insights</h2>\n" + `<ul class="entries">` + "\n")
+		for _, in := range b.Insights {
+			sb.WriteString(fmt.Sprintf(`<li><a href="insights/%s.html">%s</a></li>`+"\n", insightName(in), html.EscapeString(in.Title)))
+		}
+		sb.WriteString("</ul>\n")
+	}
+	return sb.String()
+}
+
+func htmlEntryNav(entries []Entry, i int) string {
+	parts := []string{`<a href="../index.html">All diaries</a>`}
+	if i > 0 {
+		parts = append(parts, fmt.Sprintf(`<a href="%s.html">← %s</a>`, html.EscapeString(entries[i-1].Date), html.EscapeString(entries[i-1].Date)))
+	}
+	if i < len(entries)-1 {
+		parts = append(parts, fmt.Sprintf(`<a href="%s.html">%s →</a>`, html.EscapeString(entries[i+1].Date), html.EscapeString(entries[i+1].Date)))
+	}
+	return "<nav>" + strings.Join(parts, " · ") + "</nav>\n"
+}
+
+func htmlTags(tags []string) string {
+	var sb strings.Builder
+	for _, tag := range tags {
+		sb.WriteString(` <span class="tag">#` + html.EscapeString(tag) + "</span>")
+	}
+	return sb.String()
+}
+
+func htmlPage(title, root, body string) string {
+	return `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8"/>
+<meta name="viewport" content="width=device-width, initial-scale=1"/>
+<title>` + html.EscapeString(title) + `</title>
+<link rel="stylesheet" href="` + root + `style.css"/>
+</head>
+<body>
+` + body + `</body>
+</html>
+`
+}
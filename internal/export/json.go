@@ -0,0 +1,47 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// WriteJSON writes the bundle as a single indented JSON document.
+func WriteJSON(w io.Writer, b Bundle) error {
+	if b.Entries == nil {
+		b.Entries = []Entry{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(b); err != nil {
+		return fmt.Errorf("encode json export: %w", err)
+	}
+	return nil
+}
+
+type ndjsonDiary struct {
+	Type string `json:"type"`
+	Entry
+}
+
+type ndjsonInsight struct {
+	Type string `json:"type"`
+	Insight
+}
+
+// WriteNDJSON writes one JSON object per line, tagged with "type": "diary"
+// or "type": "insight", so large exports can be streamed line by line.
+func WriteNDJSON(w io.Writer, b Bundle) error {
+	enc := json.NewEncoder(w)
+	for i := range b.Entries {
+		if err := enc.Encode(ndjsonDiary{Type: "diary", Entry: b.Entries[i]}); err != nil {
+			return fmt.Errorf("encode ndjson diary: %w", err)
+		}
+	}
+	for i := range b.Insights {
+		if err := enc.Encode(ndjsonInsight{Type: "insight", Insight: b.Insights[i]}); err != nil {
+			return fmt.Errorf("encode ndjson insight: %w", err)
+		}
+	}
+	return nil
+}
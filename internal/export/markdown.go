@@ -0,0 +1,113 @@
+package export
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// WriteMarkdownZip writes a zip archive holding index.md, one
+// diaries/<date>.md per entry and one insights/<id>.md per insight.
+func WriteMarkdownZip(w io.Writer, b Bundle) error {
+	zw := zip.NewWriter(w)
+	modified := b.GeneratedAt
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+
+	add := func(name, content string) error {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return fmt.Errorf("add %s to zip: %w", name, err)
+		}
+		if _, err := io.WriteString(fw, content); err != nil {
+			return fmt.Errorf("write %s to zip: %w", name, err)
+		}
+		return nil
+	}
+
+	if err := add("index.md", markdownIndex(b)); err != nil {
+		return err
+	}
+	for _, e := range b.Entries {
+		if err := add("diaries/"+e.Date+".md", strings.TrimSpace(e.Content)+"\n"); err != nil {
+			return err
+		}
+	}
+	for _, in := range b.Insights {
+		if err := add("insights/"+insightName(in)+".md", insightMarkdown(in)); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("finish zip: %w", err)
+	}
+	return nil
+}
+
+func markdownIndex(b Bundle) string {
+	var sb strings.Builder
+	sb.WriteString("# MoltBB Diary Export\n\n")
+	if !b.GeneratedAt.IsZero() {
+		sb.WriteString("- Generated: " + b.GeneratedAt.Format(time.RFC3339) + "\n")
+	}
+	if b.From != "" || b.To != "" {
+		sb.WriteString("- Range: " + rangeLabel(b.From, b.To) + "\n")
+	}
+	sb.WriteString(fmt.Sprintf("- Diaries: %d\n", len(b.Entries)))
+	if len(b.Insights) > 0 {
+		sb.WriteString(fmt.Sprintf("- Insights: %d\n", len(b.Insights)))
+	}
+
+	sb.WriteString("\n## Diaries\n\n")
+	for _, e := range b.Entries {
+		sb.WriteString(fmt.Sprintf("- [%s — %s](diaries/%s.md)", e.Date, escapeMarkdownLink(e.Title), e.Date))
+		if len(e.Tags) > 0 {
+			sb.WriteString(" `#" + strings.Join(e.Tags, "` `#") + "`")
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(b.Insights) > 0 {
+		sb.WriteString("\n## Insights\n\n")
+		for _, in := range b.Insights {
+			sb.WriteString(fmt.Sprintf("- [%s](insights/%s.md)\n", escapeMarkdownLink(in.Title), insightName(in)))
+		}
+	}
+	return sb.String()
+}
+
+func insightMarkdown(in Insight) string {
+	var sb strings.Builder
+	sb.WriteString("# " + in.Title + "\n\n")
+	if in.CreatedAt != "" {
+		sb.WriteString("- Created: " + in.CreatedAt + "\n")
+	}
+	if in.DiaryID != "" {
+		sb.WriteString("- Diary: " + in.DiaryID + "\n")
+	}
+	if len(in.Catalogs) > 0 {
+		sb.WriteString("- Catalogs: " + strings.Join(in.Catalogs, ", ") + "\n")
+	}
+	if len(in.Tags) > 0 {
+		sb.WriteString("- Tags: " + strings.Join(in.Tags, ", ") + "\n")
+	}
+	sb.WriteString("\n" + strings.TrimSpace(in.Content) + "\n")
+	return sb.String()
+}
+
+func escapeMarkdownLink(s string) string {
+	return strings.NewReplacer("[", `\[`, "]", `\]`).Replace(s)
+}
+
+func rangeLabel(from, to string) string {
+	if from == "" {
+		from = "…"
+	}
+	if to == "" {
+		to = "…"
+	}
+	return from + " → " + to
+}
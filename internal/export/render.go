@@ -0,0 +1,125 @@
+package export
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	mdHeadingRe = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	mdOrderedRe = regexp.MustCompile(`^\d+[.)]\s+(.*)$`)
+	mdCodeRe    = regexp.MustCompile("`([^`]+)`")
+	mdBoldRe    = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	mdItalicRe  = regexp.MustCompile(`(^|[^*])\*([^*\s][^*]*)\*`)
+	mdLinkRe    = regexp.MustCompile(`\[([^\]]+)\]\((https?://[^)\s]+)\)`)
+)
+
+// renderMarkdown converts the Markdown subset diaries use (headings, lists,
+// fenced code, quotes, rules, emphasis, inline code and links) to XHTML that
+// is valid both in the HTML site and inside EPUB chapters.
+func renderMarkdown(src string) string {
+	var sb strings.Builder
+	var para []string
+	list := ""
+	inCode := false
+
+	flushPara := func() {
+		if len(para) > 0 {
+			sb.WriteString("<p>" + strings.Join(para, "<br/>\n") + "</p>\n")
+			para = nil
+		}
+	}
+	closeList := func() {
+		if list != "" {
+			sb.WriteString("</" + list + ">\n")
+			list = ""
+		}
+	}
+	openList := func(tag string) {
+		flushPara()
+		if list != tag {
+			closeList()
+			sb.WriteString("<" + tag + ">\n")
+			list = tag
+		}
+	}
+
+	for _, raw := range strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n") {
+		line := strings.TrimRight(raw, " \t")
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") {
+			if inCode {
+				sb.WriteString("</code></pre>\n")
+				inCode = false
+			} else {
+				flushPara()
+				closeList()
+				sb.WriteString("<pre><code>")
+				inCode = true
+			}
+			continue
+		}
+		if inCode {
+			sb.WriteString(html.EscapeString(line) + "\n")
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			flushPara()
+			closeList()
+		case trimmed == "---" || trimmed == "***" || trimmed == "___":
+			flushPara()
+			closeList()
+			sb.WriteString("<hr/>\n")
+		case mdHeadingRe.MatchString(trimmed):
+			flushPara()
+			closeList()
+			m := mdHeadingRe.FindStringSubmatch(trimmed)
+			level := strconv.Itoa(len(m[1]))
+			sb.WriteString("<h" + level + ">" + renderInline(m[2]) + "</h" + level + ">\n")
+		case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* ") || strings.HasPrefix(trimmed, "+ "):
+			openList("ul")
+			sb.WriteString("<li>" + renderInline(trimmed[2:]) + "</li>\n")
+		case mdOrderedRe.MatchString(trimmed):
+			openList("ol")
+			sb.WriteString("<li>" + renderInline(mdOrderedRe.FindStringSubmatch(trimmed)[1]) + "</li>\n")
+		case strings.HasPrefix(trimmed, ">"):
+			flushPara()
+			closeList()
+			sb.WriteString("<blockquote><p>" + renderInline(strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))) + "</p></blockquote>\n")
+		default:
+			closeList()
+			para = append(para, renderInline(trimmed))
+		}
+	}
+	if inCode {
+		sb.WriteString("</code></pre>\n")
+	}
+	flushPara()
+	closeList()
+	return sb.String()
+}
+
+// renderInline escapes text and applies inline Markdown. Code spans are
+// swapped out first so emphasis inside them is left alone.
+func renderInline(text string) string {
+	var codes []string
+	text = mdCodeRe.ReplaceAllStringFunc(text, func(m string) string {
+		codes = append(codes, "<code>"+html.EscapeString(mdCodeRe.FindStringSubmatch(m)[1])+"</code>")
+		return "\x00" + strconv.Itoa(len(codes)-1) + "\x00"
+	})
+
+	text = html.EscapeString(text)
+	text = mdLinkRe.ReplaceAllString(text, `<a href="$2">$1</a>`)
+	text = mdBoldRe.ReplaceAllString(text, "<strong>$1</strong>")
+	text = mdItalicRe.ReplaceAllString(text, "$1<em>$2</em>")
+
+	for i, code := range codes {
+		text = strings.Replace(text, "\x00"+strconv.Itoa(i)+"\x00", code, 1)
+	}
+	return text
+}
@@ -0,0 +1,201 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"moltbb-cli/internal/config"
+)
+
+const (
+	ProviderOpenAI = "openai"
+	ProviderOllama = "ollama"
+	ProviderLocal  = "local"
+
+	DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+	DefaultOpenAIModel   = "gpt-4o-mini"
+	DefaultOllamaBaseURL = "http://localhost:11434"
+	DefaultOllamaModel   = "qwen3:8b"
+	DefaultAPIKeyEnv     = "OPENAI_API_KEY"
+	DefaultTimeout       = 120 * time.Second
+)
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Request is one chat completion. System, when set, is sent as the first
+// message ahead of Messages.
+type Request struct {
+	System      string
+	Messages    []Message
+	Model       string
+	Temperature float64
+	MaxTokens   int
+}
+
+type Response struct {
+	Text  string
+	Model string
+}
+
+// StreamFunc receives text deltas as they arrive. Returning an error stops
+// the stream and is returned from Stream unchanged.
+type StreamFunc func(delta string) error
+
+// Provider is a chat-capable language model backend.
+type Provider interface {
+	Name() string
+	Complete(ctx context.Context, req Request) (Response, error)
+	Stream(ctx context.Context, req Request, fn StreamFunc) (Response, error)
+}
+
+type ErrorKind string
+
+const (
+	ErrKindConfig      ErrorKind = "config"
+	ErrKindAuth        ErrorKind = "auth"
+	ErrKindRateLimit   ErrorKind = "rate_limit"
+	ErrKindTimeout     ErrorKind = "timeout"
+	ErrKindUnavailable ErrorKind = "unavailable"
+	ErrKindRequest     ErrorKind = "request"
+	ErrKindResponse    ErrorKind = "response"
+)
+
+// Error is returned by every provider so callers can branch on Kind instead
+// of matching message text.
+type Error struct {
+	Provider   string
+	Kind       ErrorKind
+	StatusCode int
+	Message    string
+	Err        error
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" && e.Err != nil {
+		msg = e.Err.Error()
+	}
+	if e.StatusCode > 0 {
+		return fmt.Sprintf("%s %s error (status %d): %s", e.Provider, e.Kind, e.StatusCode, msg)
+	}
+	return fmt.Sprintf("%s %s error: %s", e.Provider, e.Kind, msg)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// IsKind reports whether err is an *Error of the given kind.
+func IsKind(err error, kind ErrorKind) bool {
+	var llmErr *Error
+	return errors.As(err, &llmErr) && llmErr.Kind == kind
+}
+
+// Options configures a provider. Zero values fall back to per-provider
+// defaults.
+type Options struct {
+	Provider    string
+	Model       string
+	BaseURL     string
+	APIKey      string
+	Timeout     time.Duration
+	Temperature float64
+}
+
+// OptionsFromConfig resolves provider options from config, reading the API
+// key from the configured environment variable.
+func OptionsFromConfig(cfg config.LLMConfig) Options {
+	keyEnv := cfg.APIKeyEnv
+	if keyEnv == "" {
+		keyEnv = DefaultAPIKeyEnv
+	}
+	opts := Options{
+		Provider:    cfg.Provider,
+		Model:       cfg.Model,
+		BaseURL:     cfg.BaseURL,
+		APIKey:      strings.TrimSpace(os.Getenv(keyEnv)),
+		Temperature: cfg.Temperature,
+	}
+	if cfg.TimeoutSeconds > 0 {
+		opts.Timeout = time.Duration(cfg.TimeoutSeconds) * time.Second
+	}
+	return opts
+}
+
+// New builds the provider named in opts. An empty provider selects Ollama,
+// matching the CLI's historical local-first default.
+func New(opts Options) (Provider, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	switch strings.ToLower(strings.TrimSpace(opts.Provider)) {
+	case ProviderOpenAI:
+		return newOpenAI(opts)
+	case "", ProviderOllama:
+		return newOllama(opts), nil
+	case ProviderLocal:
+		return &Local{}, nil
+	default:
+		return nil, &Error{Provider: opts.Provider, Kind: ErrKindConfig, Message: "unknown provider (use openai, ollama or local)"}
+	}
+}
+
+// Prompt is a shorthand for a single user message request.
+func Prompt(system, user string) Request {
+	return Request{
+		System:   system,
+		Messages: []Message{{Role: "user", Content: user}},
+	}
+}
+
+func (r Request) allMessages() []Message {
+	out := make([]Message, 0, len(r.Messages)+1)
+	if strings.TrimSpace(r.System) != "" {
+		out = append(out, Message{Role: "system", Content: r.System})
+	}
+	return append(out, r.Messages...)
+}
+
+// withTimeout bounds a whole call, including reading a streamed body.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// transportError classifies an error from sending a request or reading its
+// body.
+func transportError(provider string, ctx context.Context, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &Error{Provider: provider, Kind: ErrKindTimeout, Message: "request timed out", Err: err}
+	}
+	return &Error{Provider: provider, Kind: ErrKindUnavailable, Err: err}
+}
+
+// statusError classifies a non-2xx HTTP response.
+func statusError(provider string, status int, body []byte) error {
+	kind := ErrKindRequest
+	switch {
+	case status == 401 || status == 403:
+		kind = ErrKindAuth
+	case status == 429:
+		kind = ErrKindRateLimit
+	case status == 408 || status == 504:
+		kind = ErrKindTimeout
+	case status >= 500:
+		kind = ErrKindUnavailable
+	}
+	msg := strings.TrimSpace(string(body))
+	if len(msg) > 300 {
+		msg = msg[:300] + "..."
+	}
+	return &Error{Provider: provider, Kind: kind, StatusCode: status, Message: msg}
+}
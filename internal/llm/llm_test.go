@@ -0,0 +1,151 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpenAI_CompleteUsesModelAndSystemPrompt(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer sk-test" {
+			t.Fatalf("missing bearer token")
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"model":"m1","choices":[{"message":{"content":" polished \n"}}]}`))
+	}))
+	defer srv.Close()
+
+	p, err := New(Options{Provider: ProviderOpenAI, BaseURL: srv.URL + "/v1", APIKey: "sk-test", Model: "m1"})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	resp, err := p.Complete(context.Background(), Prompt("be nice", "hello"))
+	if err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	if resp.Text != "polished" || resp.Model != "m1" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if got["model"] != "m1" {
+		t.Fatalf("model not sent: %v", got["model"])
+	}
+	msgs := got["messages"].([]any)
+	if len(msgs) != 2 || msgs[0].(map[string]any)["role"] != "system" {
+		t.Fatalf("expected system + user messages, got %v", msgs)
+	}
+}
+
+func TestOpenAI_StreamCollectsDeltas(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, part := range []string{"Hel", "lo", " world"} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", part)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	p, _ := New(Options{Provider: ProviderOpenAI, BaseURL: srv.URL, APIKey: "k"})
+	var deltas []string
+	resp, err := p.Stream(context.Background(), Prompt("", "hi"), func(d string) error {
+		deltas = append(deltas, d)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	if resp.Text != "Hello world" || len(deltas) != 3 {
+		t.Fatalf("unexpected stream result %q %v", resp.Text, deltas)
+	}
+}
+
+func TestOpenAI_MissingKeyIsConfigError(t *testing.T) {
+	_, err := New(Options{Provider: ProviderOpenAI})
+	if !IsKind(err, ErrKindConfig) {
+		t.Fatalf("expected config error, got %v", err)
+	}
+}
+
+func TestOllama_StreamAndStatusErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["model"] == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"model not found"}`))
+			return
+		}
+		fmt.Fprintln(w, `{"model":"qwen","message":{"content":"a"},"done":false}`)
+		fmt.Fprintln(w, `{"model":"qwen","message":{"content":"b"},"done":true}`)
+	}))
+	defer srv.Close()
+
+	p, _ := New(Options{Provider: ProviderOllama, BaseURL: srv.URL})
+	resp, err := p.Stream(context.Background(), Prompt("", "hi"), nil)
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	if resp.Text != "ab" || resp.Model != "qwen" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+
+	req := Prompt("", "hi")
+	req.Model = "missing"
+	_, err = p.Complete(context.Background(), req)
+	if !IsKind(err, ErrKindRequest) || !strings.Contains(err.Error(), "model not found") {
+		t.Fatalf("expected request error, got %v", err)
+	}
+}
+
+func TestProvider_TimeoutIsTyped(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(500 * time.Millisecond):
+		}
+	}))
+	defer srv.Close()
+
+	p, _ := New(Options{Provider: ProviderOllama, BaseURL: srv.URL, Timeout: 50 * time.Millisecond})
+	_, err := p.Complete(context.Background(), Prompt("", "hi"))
+	if !IsKind(err, ErrKindTimeout) {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+}
+
+func TestStatusError_Kinds(t *testing.T) {
+	cases := map[int]ErrorKind{401: ErrKindAuth, 429: ErrKindRateLimit, 503: ErrKindUnavailable, 400: ErrKindRequest}
+	for status, kind := range cases {
+		if err := statusError("x", status, nil); !IsKind(err, kind) {
+			t.Fatalf("status %d: expected %s, got %v", status, kind, err)
+		}
+	}
+}
+
+func TestLocal_EchoesAndRecords(t *testing.T) {
+	p := &Local{}
+	var streamed strings.Builder
+	resp, err := p.Stream(context.Background(), Prompt("sys", "echo me back"), func(d string) error {
+		streamed.WriteString(d)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	if resp.Text != "echo me back" || streamed.String() != "echo me back" {
+		t.Fatalf("unexpected local reply %q / %q", resp.Text, streamed.String())
+	}
+	if len(p.Requests) != 1 || p.Requests[0].System != "sys" {
+		t.Fatalf("request not recorded: %+v", p.Requests)
+	}
+}
@@ -0,0 +1,59 @@
+package llm
+
+import (
+	"context"
+	"strings"
+)
+
+// Local is an offline provider that needs no model. By default it echoes
+// the last user message back; tests can set Reply or Err instead. It is
+// selected with provider: local and is useful for dry runs.
+type Local struct {
+	Reply string
+	Err   error
+
+	// Requests records every request received, in order.
+	Requests []Request
+}
+
+func (p *Local) Name() string {
+	return ProviderLocal
+}
+
+func (p *Local) Complete(ctx context.Context, req Request) (Response, error) {
+	p.Requests = append(p.Requests, req)
+	if p.Err != nil {
+		return Response{}, p.Err
+	}
+	if err := ctx.Err(); err != nil {
+		return Response{}, transportError(ProviderLocal, ctx, err)
+	}
+	return Response{Text: p.reply(req), Model: ProviderLocal}, nil
+}
+
+func (p *Local) Stream(ctx context.Context, req Request, fn StreamFunc) (Response, error) {
+	resp, err := p.Complete(ctx, req)
+	if err != nil {
+		return Response{}, err
+	}
+	if fn != nil {
+		for _, word := range strings.SplitAfter(resp.Text, " ") {
+			if err := fn(word); err != nil {
+				return Response{}, err
+			}
+		}
+	}
+	return resp, nil
+}
+
+func (p *Local) reply(req Request) string {
+	if p.Reply != "" {
+		return p.Reply
+	}
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == "user" {
+			return strings.TrimSpace(req.Messages[i].Content)
+		}
+	}
+	return ""
+}
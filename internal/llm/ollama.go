@@ -0,0 +1,157 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Ollama talks to a local or remote Ollama server via /api/chat.
+type Ollama struct {
+	opts       Options
+	httpClient *http.Client
+}
+
+func newOllama(opts Options) *Ollama {
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultOllamaBaseURL
+	}
+	opts.BaseURL = strings.TrimRight(opts.BaseURL, "/")
+	if opts.Model == "" {
+		opts.Model = DefaultOllamaModel
+	}
+	return &Ollama{opts: opts, httpClient: &http.Client{}}
+}
+
+func (p *Ollama) Name() string {
+	return ProviderOllama
+}
+
+type ollamaChunk struct {
+	Model   string `json:"model"`
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Done  bool   `json:"done"`
+	Error string `json:"error"`
+}
+
+func (p *Ollama) Complete(ctx context.Context, req Request) (Response, error) {
+	ctx, cancel := withTimeout(ctx, p.opts.Timeout)
+	defer cancel()
+
+	resp, err := p.post(ctx, req, false)
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	var decoded ollamaChunk
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		if ctx.Err() != nil {
+			return Response{}, transportError(ProviderOllama, ctx, err)
+		}
+		return Response{}, &Error{Provider: ProviderOllama, Kind: ErrKindResponse, Message: "decode response", Err: err}
+	}
+	if decoded.Error != "" {
+		return Response{}, &Error{Provider: ProviderOllama, Kind: ErrKindResponse, Message: decoded.Error}
+	}
+	return Response{Text: strings.TrimSpace(decoded.Message.Content), Model: decoded.Model}, nil
+}
+
+func (p *Ollama) Stream(ctx context.Context, req Request, fn StreamFunc) (Response, error) {
+	ctx, cancel := withTimeout(ctx, p.opts.Timeout)
+	defer cancel()
+
+	resp, err := p.post(ctx, req, true)
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	var text strings.Builder
+	model := ""
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var chunk ollamaChunk
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return Response{}, &Error{Provider: ProviderOllama, Kind: ErrKindResponse, Message: "decode stream chunk", Err: err}
+		}
+		if chunk.Error != "" {
+			return Response{}, &Error{Provider: ProviderOllama, Kind: ErrKindResponse, Message: chunk.Error}
+		}
+		if chunk.Model != "" {
+			model = chunk.Model
+		}
+		if delta := chunk.Message.Content; delta != "" {
+			text.WriteString(delta)
+			if fn != nil {
+				if err := fn(delta); err != nil {
+					return Response{}, err
+				}
+			}
+		}
+		if chunk.Done {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Response{}, transportError(ProviderOllama, ctx, err)
+	}
+	return Response{Text: strings.TrimSpace(text.String()), Model: model}, nil
+}
+
+func (p *Ollama) post(ctx context.Context, req Request, stream bool) (*http.Response, error) {
+	model := req.Model
+	if model == "" {
+		model = p.opts.Model
+	}
+	body := map[string]any{
+		"model":    model,
+		"messages": req.allMessages(),
+		"stream":   stream,
+	}
+	options := map[string]any{}
+	if t := req.Temperature; t > 0 {
+		options["temperature"] = t
+	} else if p.opts.Temperature > 0 {
+		options["temperature"] = p.opts.Temperature
+	}
+	if req.MaxTokens > 0 {
+		options["num_predict"] = req.MaxTokens
+	}
+	if len(options) > 0 {
+		body["options"] = options
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, &Error{Provider: ProviderOllama, Kind: ErrKindRequest, Message: "marshal request", Err: err}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.opts.BaseURL+"/api/chat", bytes.NewReader(data))
+	if err != nil {
+		return nil, &Error{Provider: ProviderOllama, Kind: ErrKindRequest, Err: err}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, transportError(ProviderOllama, ctx, fmt.Errorf("is Ollama running at %s? %w", p.opts.BaseURL, err))
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, statusError(ProviderOllama, resp.StatusCode, errBody)
+	}
+	return resp, nil
+}
@@ -0,0 +1,166 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// OpenAI talks to any OpenAI-compatible /chat/completions endpoint.
+type OpenAI struct {
+	opts       Options
+	httpClient *http.Client
+}
+
+func newOpenAI(opts Options) (*OpenAI, error) {
+	if opts.APIKey == "" {
+		return nil, &Error{Provider: ProviderOpenAI, Kind: ErrKindConfig, Message: "API key not set (export " + DefaultAPIKeyEnv + " or set llm.api_key_env)"}
+	}
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultOpenAIBaseURL
+	}
+	opts.BaseURL = strings.TrimRight(opts.BaseURL, "/")
+	if opts.Model == "" {
+		opts.Model = DefaultOpenAIModel
+	}
+	return &OpenAI{opts: opts, httpClient: &http.Client{}}, nil
+}
+
+func (p *OpenAI) Name() string {
+	return ProviderOpenAI
+}
+
+func (p *OpenAI) Complete(ctx context.Context, req Request) (Response, error) {
+	ctx, cancel := withTimeout(ctx, p.opts.Timeout)
+	defer cancel()
+
+	resp, err := p.post(ctx, req, false)
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	var decoded struct {
+		Model   string `json:"model"`
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		if ctx.Err() != nil {
+			return Response{}, transportError(ProviderOpenAI, ctx, err)
+		}
+		return Response{}, &Error{Provider: ProviderOpenAI, Kind: ErrKindResponse, Message: "decode response", Err: err}
+	}
+	if len(decoded.Choices) == 0 {
+		return Response{}, &Error{Provider: ProviderOpenAI, Kind: ErrKindResponse, Message: "response has no choices"}
+	}
+	return Response{Text: strings.TrimSpace(decoded.Choices[0].Message.Content), Model: decoded.Model}, nil
+}
+
+func (p *OpenAI) Stream(ctx context.Context, req Request, fn StreamFunc) (Response, error) {
+	ctx, cancel := withTimeout(ctx, p.opts.Timeout)
+	defer cancel()
+
+	resp, err := p.post(ctx, req, true)
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	var text strings.Builder
+	model := ""
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+		var chunk struct {
+			Model   string `json:"model"`
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return Response{}, &Error{Provider: ProviderOpenAI, Kind: ErrKindResponse, Message: "decode stream chunk", Err: err}
+		}
+		if chunk.Model != "" {
+			model = chunk.Model
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+		delta := chunk.Choices[0].Delta.Content
+		text.WriteString(delta)
+		if fn != nil {
+			if err := fn(delta); err != nil {
+				return Response{}, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Response{}, transportError(ProviderOpenAI, ctx, err)
+	}
+	return Response{Text: strings.TrimSpace(text.String()), Model: model}, nil
+}
+
+func (p *OpenAI) post(ctx context.Context, req Request, stream bool) (*http.Response, error) {
+	model := req.Model
+	if model == "" {
+		model = p.opts.Model
+	}
+	temperature := req.Temperature
+	if temperature == 0 {
+		temperature = p.opts.Temperature
+	}
+	body := map[string]any{
+		"model":    model,
+		"messages": req.allMessages(),
+		"stream":   stream,
+	}
+	if temperature > 0 {
+		body["temperature"] = temperature
+	}
+	if req.MaxTokens > 0 {
+		body["max_tokens"] = req.MaxTokens
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, &Error{Provider: ProviderOpenAI, Kind: ErrKindRequest, Message: "marshal request", Err: err}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.opts.BaseURL+"/chat/completions", bytes.NewReader(data))
+	if err != nil {
+		return nil, &Error{Provider: ProviderOpenAI, Kind: ErrKindRequest, Err: err}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.opts.APIKey)
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, transportError(ProviderOpenAI, ctx, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, statusError(ProviderOpenAI, resp.StatusCode, errBody)
+	}
+	return resp, nil
+}
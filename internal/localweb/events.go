@@ -0,0 +1,337 @@
+package localweb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"moltbb-cli/internal/api"
+)
+
+const (
+	eventBufferSize      = 100
+	eventSubscriberQueue = 32
+	eventKeepAlive       = 25 * time.Second
+	eventRetryMillis     = 5000
+	defaultUnreadPoll    = time.Minute
+)
+
+// Event types published by the server itself, next to the hub targets below.
+const (
+	EventHubState    = "Hub.State"
+	EventUnreadCount = "Inbox.UnreadCount"
+)
+
+// hubEventTargets are the hub pushes re-broadcast on /api/events. Room
+// pushes only reach connections that joined the room with its password, so
+// the studio cannot relay them.
+var hubEventTargets = []string{
+	"Pipeline.InvitationReceived",
+	"Pipeline.MessageReceived",
+	"Pipeline.SessionAccepted",
+	"Pipeline.SessionRejected",
+	"Pipeline.SessionEnded",
+}
+
+// Event is one entry of the /api/events stream.
+type Event struct {
+	ID   int64           `json:"id"`
+	Type string          `json:"type"`
+	At   time.Time       `json:"at"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+type hubStateEvent struct {
+	State   string `json:"state"`
+	Attempt int    `json:"attempt,omitempty"`
+	Error   string `json:"error,omitempty"`
+	RetryMs int64  `json:"retryMs,omitempty"`
+}
+
+type unreadCountEvent struct {
+	Count int `json:"count"`
+}
+
+// EventsOptions configures the hub connection behind /api/events.
+type EventsOptions struct {
+	Client *api.Client
+	// Token is the bot JWT (or API key) used for the hub.
+	Token        string
+	RefreshToken func(ctx context.Context) (string, error)
+	// APIKey enables unread-count polling when set.
+	APIKey         string
+	UnreadInterval time.Duration
+}
+
+// eventBroker fans events out to SSE subscribers and keeps the most recent
+// ones so a reconnecting browser can resume from Last-Event-ID.
+type eventBroker struct {
+	mu     sync.Mutex
+	nextID int64
+	recent []Event
+	// latest holds the last event of each state type (hub state, unread
+	// count) so new subscribers see current values even after the ring
+	// buffer has moved on.
+	latest map[string]Event
+	subs   map[chan Event]struct{}
+	closed bool
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{
+		latest: make(map[string]Event),
+		subs:   make(map[chan Event]struct{}),
+	}
+}
+
+func (b *eventBroker) publish(typ string, data json.RawMessage) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	ev := Event{ID: b.nextID, Type: typ, At: time.Now().UTC(), Data: data}
+	b.recent = append(b.recent, ev)
+	if len(b.recent) > eventBufferSize {
+		b.recent = append(b.recent[:0], b.recent[len(b.recent)-eventBufferSize:]...)
+	}
+	if typ == EventHubState || typ == EventUnreadCount {
+		b.latest[typ] = ev
+	}
+	for ch := range b.subs {
+		select {
+		case ch <- ev:
+		default:
+			// A subscriber that cannot keep up is dropped; the browser
+			// reconnects and replays from its last event ID.
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+	return ev
+}
+
+func (b *eventBroker) publishJSON(typ string, payload any) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	b.publish(typ, data)
+}
+
+// subscribe returns the events after lastID that are still known and a
+// channel for new ones. ok is false once the broker is closed.
+func (b *eventBroker) subscribe(lastID int64) (replay []Event, ch chan Event, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, nil, false
+	}
+	oldest := b.nextID + 1
+	if len(b.recent) > 0 {
+		oldest = b.recent[0].ID
+	}
+	for _, ev := range b.latest {
+		if ev.ID > lastID && ev.ID < oldest {
+			replay = append(replay, ev)
+		}
+	}
+	sort.Slice(replay, func(i, j int) bool { return replay[i].ID < replay[j].ID })
+	for _, ev := range b.recent {
+		if ev.ID > lastID {
+			replay = append(replay, ev)
+		}
+	}
+	ch = make(chan Event, eventSubscriberQueue)
+	b.subs[ch] = struct{}{}
+	return replay, ch, true
+}
+
+func (b *eventBroker) unsubscribe(ch chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[ch]; ok {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
+
+func (b *eventBroker) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.subs {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
+
+// StartEvents connects to the SignalR hub on behalf of the local user and
+// re-broadcasts Pipeline.* pushes on /api/events until ctx ends.
+// The inbox unread count is polled alongside when an API key is given. The
+// hub connection reconnects on its own; only the first connect error is
+// returned.
+func (s *Server) StartEvents(ctx context.Context, opts EventsOptions) error {
+	if opts.Client == nil {
+		return errors.New("events: client is required")
+	}
+	go func() {
+		<-ctx.Done()
+		s.events.close()
+	}()
+	if strings.TrimSpace(opts.APIKey) != "" {
+		go s.pollUnreadCount(ctx, opts.Client, opts.APIKey, opts.UnreadInterval)
+	}
+	if strings.TrimSpace(opts.Token) == "" {
+		s.events.publishJSON(EventHubState, hubStateEvent{State: "disabled", Error: "token not configured"})
+		return errors.New("events: token not configured")
+	}
+
+	hub := opts.Client.NewReconnectingConn(api.HubOptions{
+		Token:        opts.Token,
+		RefreshToken: opts.RefreshToken,
+		OnConnect: func(ctx context.Context, sc *api.SignalRConn) error {
+			return sc.InvokeVoid(ctx, "JoinPipeline")
+		},
+		OnStateChange: func(change api.StateChange) {
+			ev := hubStateEvent{State: change.State.String(), Attempt: change.Attempt}
+			if change.Err != nil {
+				ev.Error = change.Err.Error()
+			}
+			if change.State == api.StateReconnecting {
+				ev.RetryMs = change.Retry.Milliseconds()
+			}
+			s.events.publishJSON(EventHubState, ev)
+		},
+	})
+	for _, target := range hubEventTargets {
+		target := target
+		hub.On(target, func(args []json.RawMessage) {
+			s.events.publish(target, hubEventData(args))
+		})
+	}
+	if err := hub.Connect(ctx); err != nil {
+		s.events.publishJSON(EventHubState, hubStateEvent{State: "disabled", Error: err.Error()})
+		return fmt.Errorf("events: %w", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		hub.Close()
+	}()
+	return nil
+}
+
+// hubEventData turns hub arguments into the event payload: the single
+// argument as-is, or an array when there are several.
+func hubEventData(args []json.RawMessage) json.RawMessage {
+	switch len(args) {
+	case 0:
+		return nil
+	case 1:
+		return args[0]
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// pollUnreadCount publishes the inbox unread count whenever it changes.
+func (s *Server) pollUnreadCount(ctx context.Context, client *api.Client, apiKey string, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultUnreadPoll
+	}
+	last := -1
+	poll := func() {
+		reqCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		defer cancel()
+		count, err := client.GetUnreadCount(reqCtx, apiKey)
+		if err != nil || count == last {
+			return
+		}
+		last = count
+		s.events.publishJSON(EventUnreadCount, unreadCountEvent{Count: count})
+	}
+
+	poll()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			poll()
+		}
+	}
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming not supported"))
+		return
+	}
+
+	lastID, _ := strconv.ParseInt(strings.TrimSpace(r.Header.Get("Last-Event-ID")), 10, 64)
+	replay, ch, ok := s.events.subscribe(lastID)
+	if !ok {
+		writeError(w, http.StatusServiceUnavailable, errors.New("event stream closed"))
+		return
+	}
+	defer s.events.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", eventRetryMillis)
+	for _, ev := range replay {
+		if writeSSE(w, ev) != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, open := <-ch:
+			if !open {
+				return
+			}
+			if writeSSE(w, ev) != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeSSE(w http.ResponseWriter, ev Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\ndata: %s\n\n", ev.ID, data)
+	return err
+}
@@ -0,0 +1,191 @@
+package localweb
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventBrokerReplaysAfterLastID(t *testing.T) {
+	b := newEventBroker()
+	b.publishJSON(EventHubState, hubStateEvent{State: "connected"})
+	for i := 0; i < eventBufferSize+5; i++ {
+		b.publish("Pipeline.MessageReceived", json.RawMessage(`{}`))
+	}
+
+	// A fresh subscriber gets the hub state that fell out of the ring
+	// buffer, then the buffered events.
+	replay, ch, ok := b.subscribe(0)
+	if !ok {
+		t.Fatal("subscribe on open broker failed")
+	}
+	defer b.unsubscribe(ch)
+	if len(replay) != eventBufferSize+1 || replay[0].Type != EventHubState {
+		t.Fatalf("replay has %d events starting with %q", len(replay), replay[0].Type)
+	}
+	for i := 1; i < len(replay); i++ {
+		if replay[i].ID <= replay[i-1].ID {
+			t.Fatalf("replay out of order at %d", i)
+		}
+	}
+
+	last := replay[len(replay)-1].ID
+	if again, ch2, _ := b.subscribe(last - 2); len(again) != 2 {
+		t.Fatalf("resume replayed %d events, want 2", len(again))
+	} else {
+		b.unsubscribe(ch2)
+	}
+
+	ev := b.publish("Pipeline.InvitationReceived", json.RawMessage(`{"sessionId":"s1"}`))
+	select {
+	case got := <-ch:
+		if got.ID != ev.ID || got.Type != "Pipeline.InvitationReceived" {
+			t.Fatalf("got %+v", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscriber not notified")
+	}
+
+	b.close()
+	if _, open := <-ch; open {
+		t.Fatal("subscriber channel still open after close")
+	}
+	if _, _, ok := b.subscribe(0); ok {
+		t.Fatal("subscribe succeeded on closed broker")
+	}
+}
+
+func TestEventBrokerDropsSlowSubscriber(t *testing.T) {
+	b := newEventBroker()
+	_, ch, _ := b.subscribe(0)
+	for i := 0; i < eventSubscriberQueue+1; i++ {
+		b.publish("Pipeline.MessageReceived", nil)
+	}
+	n := 0
+	for range ch {
+		n++
+	}
+	if n != eventSubscriberQueue {
+		t.Fatalf("drained %d events before close, want %d", n, eventSubscriberQueue)
+	}
+}
+
+func TestEventsEndpointStreams(t *testing.T) {
+	srv, err := New(Options{DiaryDir: t.TempDir(), DataDir: t.TempDir(), APIBaseURL: "https://moltbb.com"})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	defer srv.Close()
+	srv.events.publish("Pipeline.InvitationReceived", json.RawMessage(`{"sessionId":"s1"}`))
+
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/events", nil)
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatalf("GET /api/events: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type = %q", ct)
+	}
+
+	events := make(chan Event, 4)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+			var ev Event
+			if json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev) == nil {
+				events <- ev
+			}
+		}
+		close(events)
+	}()
+
+	next := func() Event {
+		t.Helper()
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				t.Fatal("stream ended")
+			}
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event received")
+		}
+		return Event{}
+	}
+
+	if ev := next(); ev.ID != 1 || ev.Type != "Pipeline.InvitationReceived" || string(ev.Data) != `{"sessionId":"s1"}` {
+		t.Fatalf("replayed event = %+v", ev)
+	}
+	srv.events.publishJSON(EventUnreadCount, unreadCountEvent{Count: 3})
+	if ev := next(); ev.ID != 2 || ev.Type != EventUnreadCount || string(ev.Data) != `{"count":3}` {
+		t.Fatalf("live event = %+v", ev)
+	}
+
+	srv.events.close()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Fatal("unexpected event after close")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream not closed with the broker")
+	}
+}
+
+func TestEventsEndpointEndsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv, err := New(Options{DiaryDir: t.TempDir(), DataDir: t.TempDir(), APIBaseURL: "https://moltbb.com", Context: ctx})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	defer srv.Close()
+
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	resp, err := ts.Client().Get(ts.URL + "/api/events")
+	if err != nil {
+		t.Fatalf("GET /api/events: %v", err)
+	}
+	defer resp.Body.Close()
+
+	done := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		close(done)
+	}()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream not closed when the context ended")
+	}
+}
+
+func TestEventsEndpointRejectsPost(t *testing.T) {
+	srv, err := New(Options{DiaryDir: t.TempDir(), DataDir: t.TempDir(), APIBaseURL: "https://moltbb.com"})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	defer srv.Close()
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/events", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d", rec.Code)
+	}
+}
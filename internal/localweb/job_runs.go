@@ -0,0 +1,106 @@
+package localweb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// jobRunsKept is how many runs per job SaveJobRun retains.
+const jobRunsKept = 200
+
+// JobRun is one recorded execution of a scheduled job.
+type JobRun struct {
+	ID         int64     `json:"id"`
+	Job        string    `json:"job"`
+	Trigger    string    `json:"trigger"`
+	Status     string    `json:"status"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	Output     string    `json:"output,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// SaveJobRun appends a run and trims the job's history to the newest
+// jobRunsKept rows.
+func SaveJobRun(db *sql.DB, run JobRun) error {
+	if db == nil {
+		return errors.New("db is required")
+	}
+	if _, err := db.Exec(`
+INSERT INTO job_runs(job, trigger, status, started_at, finished_at, output, error)
+VALUES(?, ?, ?, ?, ?, ?, ?)
+`, run.Job, run.Trigger, run.Status, run.StartedAt.UTC().Format(time.RFC3339Nano), run.FinishedAt.UTC().Format(time.RFC3339Nano), run.Output, run.Error); err != nil {
+		return fmt.Errorf("insert job run: %w", err)
+	}
+	if _, err := db.Exec(`
+DELETE FROM job_runs
+WHERE job = ? AND id NOT IN (SELECT id FROM job_runs WHERE job = ? ORDER BY id DESC LIMIT ?)
+`, run.Job, run.Job, jobRunsKept); err != nil {
+		return fmt.Errorf("trim job runs: %w", err)
+	}
+	return nil
+}
+
+// ListJobRuns returns the newest runs first, for one job or (with an empty
+// job) for all of them.
+func ListJobRuns(db *sql.DB, job string, limit int) ([]JobRun, error) {
+	if db == nil {
+		return nil, errors.New("db is required")
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	query := `SELECT id, job, trigger, status, started_at, finished_at, output, error FROM job_runs`
+	args := []any{}
+	if job != "" {
+		query += ` WHERE job = ?`
+		args = append(args, job)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+	return queryJobRuns(db, query, args...)
+}
+
+// LatestJobRuns returns each job's most recent run keyed by job name.
+func LatestJobRuns(db *sql.DB) (map[string]JobRun, error) {
+	if db == nil {
+		return nil, errors.New("db is required")
+	}
+	runs, err := queryJobRuns(db, `
+SELECT id, job, trigger, status, started_at, finished_at, output, error FROM job_runs
+WHERE id IN (SELECT MAX(id) FROM job_runs GROUP BY job)`)
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[string]JobRun, len(runs))
+	for _, run := range runs {
+		latest[run.Job] = run
+	}
+	return latest, nil
+}
+
+func queryJobRuns(db *sql.DB, query string, args ...any) ([]JobRun, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query job runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []JobRun
+	for rows.Next() {
+		var run JobRun
+		var startedAt, finishedAt string
+		if err := rows.Scan(&run.ID, &run.Job, &run.Trigger, &run.Status, &startedAt, &finishedAt, &run.Output, &run.Error); err != nil {
+			return nil, fmt.Errorf("scan job run: %w", err)
+		}
+		run.StartedAt, _ = time.Parse(time.RFC3339Nano, startedAt)
+		run.FinishedAt, _ = time.Parse(time.RFC3339Nano, finishedAt)
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read job runs: %w", err)
+	}
+	return runs, nil
+}
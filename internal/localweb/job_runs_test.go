@@ -0,0 +1,47 @@
+package localweb
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJobRunsHistory(t *testing.T) {
+	t.Parallel()
+
+	db, err := OpenDB(filepath.Join(t.TempDir(), "local.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+
+	start := time.Date(2026, 3, 1, 21, 0, 0, 0, time.UTC)
+	for i := 0; i < jobRunsKept+5; i++ {
+		run := JobRun{Job: "diary", Trigger: "schedule", Status: "ok", StartedAt: start.Add(time.Duration(i) * time.Minute), FinishedAt: start.Add(time.Duration(i)*time.Minute + time.Second)}
+		if err := SaveJobRun(db, run); err != nil {
+			t.Fatalf("save run %d: %v", i, err)
+		}
+	}
+	if err := SaveJobRun(db, JobRun{Job: "heartbeat", Trigger: "manual", Status: "error", Error: "offline", StartedAt: start, FinishedAt: start}); err != nil {
+		t.Fatalf("save heartbeat run: %v", err)
+	}
+
+	diaryRuns, err := ListJobRuns(db, "diary", 1000)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(diaryRuns) != jobRunsKept {
+		t.Fatalf("kept %d diary runs, want %d", len(diaryRuns), jobRunsKept)
+	}
+	if want := start.Add(time.Duration(jobRunsKept+4) * time.Minute); !diaryRuns[0].StartedAt.Equal(want) {
+		t.Fatalf("newest run started %s, want %s", diaryRuns[0].StartedAt, want)
+	}
+
+	latest, err := LatestJobRuns(db)
+	if err != nil {
+		t.Fatalf("latest: %v", err)
+	}
+	if len(latest) != 2 || latest["heartbeat"].Error != "offline" {
+		t.Fatalf("latest = %+v", latest)
+	}
+}
@@ -0,0 +1,356 @@
+package localweb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Migration is one ordered, forward-only schema change. Up runs inside a
+// transaction together with the schema_migrations bookkeeping row, so a
+// failed migration leaves the database at the previous version.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *sql.Tx) error
+}
+
+// MigrationState is a migration and, when applied, when that happened.
+type MigrationState struct {
+	Version   int    `json:"version"`
+	Name      string `json:"name"`
+	Applied   bool   `json:"applied"`
+	AppliedAt string `json:"appliedAt,omitempty"`
+}
+
+// SchemaVersion is the newest migration this build knows. Databases at a
+// higher version were written by a newer moltbb and are refused by OpenDB.
+const SchemaVersion = 5
+
+// ErrSchemaTooNew is returned when a database has migrations applied that
+// this build does not know.
+var ErrSchemaTooNew = errors.New("local database was created by a newer moltbb")
+
+// Migrations lists every schema change in order. Append new entries and
+// bump SchemaVersion; never edit or reorder released ones.
+var Migrations = []Migration{
+	{Version: 1, Name: "initial_schema", Up: migrateInitialSchema},
+	{Version: 2, Name: "diary_search_index", Up: migrateDiarySearchIndex},
+	{Version: 3, Name: "canonical_diary_entry_ids", Up: migrateCanonicalDiaryIDs},
+	{Version: 4, Name: "job_runs", Up: migrateJobRuns},
+	{Version: 5, Name: "pipeline_transcripts", Up: migratePipelineTranscripts},
+}
+
+const migrationsTableSQL = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+  version INTEGER PRIMARY KEY,
+  name TEXT NOT NULL,
+  applied_at TEXT NOT NULL
+)`
+
+// Migrate applies all pending migrations in order and returns the ones it
+// applied.
+func Migrate(db *sql.DB) ([]MigrationState, error) {
+	return migrate(db, Migrations)
+}
+
+func migrate(db *sql.DB, migrations []Migration) ([]MigrationState, error) {
+	if db == nil {
+		return nil, errors.New("db is required")
+	}
+	if _, err := db.Exec(migrationsTableSQL); err != nil {
+		return nil, fmt.Errorf("create schema_migrations table: %w", err)
+	}
+	states, err := migrationStates(db, migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make([]MigrationState, 0, len(states))
+	for i, m := range migrations {
+		if states[i].Applied {
+			continue
+		}
+		tx, err := db.Begin()
+		if err != nil {
+			return applied, fmt.Errorf("begin migration %d: %w", m.Version, err)
+		}
+		if err := m.Up(tx); err != nil {
+			_ = tx.Rollback()
+			return applied, fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		now := time.Now().UTC().Format(time.RFC3339)
+		if _, err := tx.Exec(`INSERT INTO schema_migrations(version, name, applied_at) VALUES(?, ?, ?)`, m.Version, m.Name, now); err != nil {
+			_ = tx.Rollback()
+			return applied, fmt.Errorf("record migration %d: %w", m.Version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return applied, fmt.Errorf("commit migration %d: %w", m.Version, err)
+		}
+		applied = append(applied, MigrationState{Version: m.Version, Name: m.Name, Applied: true, AppliedAt: now})
+	}
+	return applied, nil
+}
+
+// MigrationStatus reports every known migration and whether db has it. It
+// does not modify the database.
+func MigrationStatus(db *sql.DB) ([]MigrationState, error) {
+	return migrationStates(db, Migrations)
+}
+
+func migrationStates(db *sql.DB, migrations []Migration) ([]MigrationState, error) {
+	appliedAt, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	latest := 0
+	if len(migrations) > 0 {
+		latest = migrations[len(migrations)-1].Version
+	}
+	for version := range appliedAt {
+		if version > latest {
+			return nil, fmt.Errorf("%w (schema v%d, this build knows v%d)", ErrSchemaTooNew, version, latest)
+		}
+	}
+
+	states := make([]MigrationState, 0, len(migrations))
+	for _, m := range migrations {
+		at, ok := appliedAt[m.Version]
+		states = append(states, MigrationState{Version: m.Version, Name: m.Name, Applied: ok, AppliedAt: at})
+	}
+	return states, nil
+}
+
+// DBSchemaVersion reports the highest migration applied to db; 0 means the
+// database predates versioned migrations.
+func DBSchemaVersion(db *sql.DB) (int, error) {
+	appliedAt, err := appliedMigrations(db)
+	if err != nil {
+		return 0, err
+	}
+	version := 0
+	for v := range appliedAt {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+func appliedMigrations(db *sql.DB) (map[int]string, error) {
+	rows, err := db.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		if isNoSuchTable(err) {
+			return map[int]string{}, nil
+		}
+		return nil, fmt.Errorf("query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]string)
+	for rows.Next() {
+		var version int
+		var at string
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, fmt.Errorf("scan schema_migrations: %w", err)
+		}
+		applied[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+	return applied, nil
+}
+
+func migrateInitialSchema(tx *sql.Tx) error {
+	if _, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS prompts (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  content TEXT NOT NULL,
+  enabled INTEGER NOT NULL DEFAULT 1 CHECK (enabled IN (0,1)),
+  builtin INTEGER NOT NULL DEFAULT 0 CHECK (builtin IN (0,1)),
+  active INTEGER NOT NULL DEFAULT 0 CHECK (active IN (0,1)),
+  created_at TEXT NOT NULL,
+  updated_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS diary_entries (
+  id TEXT PRIMARY KEY,
+  rel_path TEXT NOT NULL UNIQUE,
+  filename TEXT NOT NULL,
+  date TEXT NOT NULL DEFAULT '',
+  title TEXT NOT NULL,
+  preview TEXT NOT NULL,
+  content_text TEXT NOT NULL DEFAULT '',
+  size INTEGER NOT NULL,
+  modified_at TEXT NOT NULL,
+  indexed_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS app_settings (
+  key TEXT PRIMARY KEY,
+  value TEXT NOT NULL,
+  updated_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS diary_day_defaults (
+  diary_date TEXT PRIMARY KEY,
+  diary_id TEXT NOT NULL,
+  is_manual INTEGER NOT NULL DEFAULT 0 CHECK (is_manual IN (0,1)),
+  updated_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS diary_sync_state (
+  diary_date TEXT PRIMARY KEY,
+  content_hash TEXT NOT NULL,
+  remote_hash TEXT NOT NULL DEFAULT '',
+  remote_diary_id TEXT NOT NULL DEFAULT '',
+  synced_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_diary_entries_date ON diary_entries(date);
+CREATE INDEX IF NOT EXISTS idx_diary_entries_modified_at ON diary_entries(modified_at);
+CREATE INDEX IF NOT EXISTS idx_diary_day_defaults_diary_id ON diary_day_defaults(diary_id);
+`); err != nil {
+		return fmt.Errorf("create tables: %w", err)
+	}
+
+	// Databases from before versioning may have diary_entries without the
+	// content_text column.
+	hasContentText, err := hasColumn(tx, "diary_entries", "content_text")
+	if err != nil {
+		return err
+	}
+	if !hasContentText {
+		if _, err := tx.Exec(`ALTER TABLE diary_entries ADD COLUMN content_text TEXT NOT NULL DEFAULT ''`); err != nil {
+			return fmt.Errorf("add diary_entries.content_text: %w", err)
+		}
+	}
+	return nil
+}
+
+func migrateDiarySearchIndex(tx *sql.Tx) error {
+	// diary_fts mirrors diary_entries for full-text search. entry_id joins
+	// back to diary_entries.id; content holds the original (not lowercased)
+	// text so snippets keep their case.
+	if _, err := tx.Exec(`
+CREATE VIRTUAL TABLE IF NOT EXISTS diary_fts USING fts5(
+  entry_id UNINDEXED,
+  title,
+  content,
+  tags,
+  tokenize = 'unicode61 remove_diacritics 2'
+)`); err != nil {
+		return fmt.Errorf("create diary_fts: %w", err)
+	}
+	// content_text used to carry a B-tree index that could never serve the
+	// LIKE '%q%' lookups; diary_fts replaces it.
+	if _, err := tx.Exec(`DROP INDEX IF EXISTS idx_diary_entries_content_text`); err != nil {
+		return fmt.Errorf("drop diary_entries content_text index: %w", err)
+	}
+	return nil
+}
+
+// migrateCanonicalDiaryIDs rewrites rows written with the old "<date>-<unix
+// seconds>" IDs to the path-based IDs the studio uses. A legacy row whose
+// canonical ID is already taken is a duplicate and is dropped.
+func migrateCanonicalDiaryIDs(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT id, rel_path FROM diary_entries`)
+	if err != nil {
+		return fmt.Errorf("query diary ids: %w", err)
+	}
+	type rename struct{ from, to string }
+	var renames []rename
+	existing := make(map[string]struct{})
+	for rows.Next() {
+		var id, relPath string
+		if err := rows.Scan(&id, &relPath); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan diary id: %w", err)
+		}
+		existing[id] = struct{}{}
+		if canonical := DiaryEntryID(relPath); canonical != id {
+			renames = append(renames, rename{from: id, to: canonical})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("read diary ids: %w", err)
+	}
+	rows.Close()
+
+	for _, r := range renames {
+		if _, taken := existing[r.to]; taken {
+			if _, err := tx.Exec(`DELETE FROM diary_entries WHERE id = ?`, r.from); err != nil {
+				return fmt.Errorf("drop duplicate diary %s: %w", r.from, err)
+			}
+		} else {
+			if _, err := tx.Exec(`UPDATE diary_entries SET id = ? WHERE id = ?`, r.to, r.from); err != nil {
+				return fmt.Errorf("rename diary %s: %w", r.from, err)
+			}
+			existing[r.to] = struct{}{}
+		}
+		if _, err := tx.Exec(`DELETE FROM diary_fts WHERE entry_id = ?`, r.from); err != nil {
+			return fmt.Errorf("drop search row %s: %w", r.from, err)
+		}
+		if _, err := tx.Exec(`UPDATE diary_day_defaults SET diary_id = ? WHERE diary_id = ?`, r.to, r.from); err != nil {
+			return fmt.Errorf("repoint day default %s: %w", r.from, err)
+		}
+	}
+	return nil
+}
+
+func migrateJobRuns(tx *sql.Tx) error {
+	if _, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS job_runs (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  job TEXT NOT NULL,
+  trigger TEXT NOT NULL,
+  status TEXT NOT NULL,
+  started_at TEXT NOT NULL,
+  finished_at TEXT NOT NULL,
+  output TEXT NOT NULL DEFAULT '',
+  error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_runs_job_started_at ON job_runs(job, started_at);
+`); err != nil {
+		return fmt.Errorf("create job_runs: %w", err)
+	}
+	return nil
+}
+
+func migratePipelineTranscripts(tx *sql.Tx) error {
+	// dedupe_key lets room backlogs and reconnect backfills be recorded
+	// again without duplicating messages already archived.
+	if _, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS transcript_messages (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  kind TEXT NOT NULL CHECK (kind IN ('session','room')),
+  conversation TEXT NOT NULL,
+  direction TEXT NOT NULL CHECK (direction IN ('sent','received')),
+  sender_id TEXT NOT NULL DEFAULT '',
+  sender_name TEXT NOT NULL DEFAULT '',
+  peer_id TEXT NOT NULL DEFAULT '',
+  content TEXT NOT NULL,
+  encrypted INTEGER NOT NULL DEFAULT 0 CHECK (encrypted IN (0,1)),
+  sent_at TEXT NOT NULL,
+  dedupe_key TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transcript_messages_dedupe ON transcript_messages(conversation, dedupe_key);
+CREATE INDEX IF NOT EXISTS idx_transcript_messages_conversation_sent_at ON transcript_messages(conversation, sent_at);
+CREATE INDEX IF NOT EXISTS idx_transcript_messages_sent_at ON transcript_messages(sent_at);
+`); err != nil {
+		return fmt.Errorf("create transcript_messages: %w", err)
+	}
+	return nil
+}
+
+func isNoSuchTable(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "no such table")
+}
@@ -0,0 +1,204 @@
+package localweb
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestMigrationsAreOrderedAndEndAtSchemaVersion(t *testing.T) {
+	t.Parallel()
+
+	for i, m := range Migrations {
+		if m.Version != i+1 {
+			t.Fatalf("migration %d has version %d, want %d", i, m.Version, i+1)
+		}
+		if m.Name == "" || m.Up == nil {
+			t.Fatalf("migration %d is incomplete: %+v", m.Version, m)
+		}
+	}
+	if last := Migrations[len(Migrations)-1].Version; last != SchemaVersion {
+		t.Fatalf("last migration = %d, SchemaVersion = %d", last, SchemaVersion)
+	}
+}
+
+func TestOpenDBMigratesFreshDatabase(t *testing.T) {
+	t.Parallel()
+
+	db, err := OpenDB(filepath.Join(t.TempDir(), "local.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+
+	version, err := DBSchemaVersion(db)
+	if err != nil {
+		t.Fatalf("schema version: %v", err)
+	}
+	if version != SchemaVersion {
+		t.Fatalf("version = %d, want %d", version, SchemaVersion)
+	}
+
+	applied, err := Migrate(db)
+	if err != nil {
+		t.Fatalf("re-run migrate: %v", err)
+	}
+	if len(applied) != 0 {
+		t.Fatalf("expected no migrations on second run, got %+v", applied)
+	}
+}
+
+func TestMigrateCanonicalizesLegacyDatabase(t *testing.T) {
+	t.Parallel()
+
+	dbPath := filepath.Join(t.TempDir(), "local.db")
+	raw, err := OpenRawDB(dbPath)
+	if err != nil {
+		t.Fatalf("open raw db: %v", err)
+	}
+	// Shape of a database written by older builds: no content_text column,
+	// no schema_migrations, and rows keyed by "<date>-<unix seconds>".
+	if _, err := raw.Exec(`
+CREATE TABLE diary_entries (
+  id TEXT PRIMARY KEY,
+  rel_path TEXT NOT NULL UNIQUE,
+  filename TEXT NOT NULL,
+  date TEXT NOT NULL DEFAULT '',
+  title TEXT NOT NULL,
+  preview TEXT NOT NULL,
+  size INTEGER NOT NULL,
+  modified_at TEXT NOT NULL,
+  indexed_at TEXT NOT NULL
+);
+CREATE TABLE diary_day_defaults (
+  diary_date TEXT PRIMARY KEY,
+  diary_id TEXT NOT NULL,
+  is_manual INTEGER NOT NULL DEFAULT 0,
+  updated_at TEXT NOT NULL
+);
+INSERT INTO diary_entries VALUES
+  ('2026-02-10-1770681600', '2026-02-10.md', '2026-02-10.md', '2026-02-10', 't', 'p', 1, '2026-02-10T00:00:00Z', '2026-02-10T00:00:00Z'),
+  ('notes/2026-02-11', 'notes/2026-02-11.md', '2026-02-11.md', '2026-02-11', 't', 'p', 1, '2026-02-11T00:00:00Z', '2026-02-11T00:00:00Z');
+INSERT INTO diary_day_defaults VALUES ('2026-02-10', '2026-02-10-1770681600', 1, '2026-02-10T00:00:00Z');
+`); err != nil {
+		t.Fatalf("seed legacy db: %v", err)
+	}
+	raw.Close()
+
+	db, err := OpenDB(dbPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+
+	ids := idsOf(t, db, `SELECT id FROM diary_entries ORDER BY id`)
+	if len(ids) != 2 || ids[0] != "2026-02-10" || ids[1] != "notes/2026-02-11" {
+		t.Fatalf("unexpected ids: %v", ids)
+	}
+	if got := idsOf(t, db, `SELECT diary_id FROM diary_day_defaults`); len(got) != 1 || got[0] != "2026-02-10" {
+		t.Fatalf("day default not repointed: %v", got)
+	}
+	ok, err := hasColumn(db, "diary_entries", "content_text")
+	if err != nil || !ok {
+		t.Fatalf("content_text column missing: ok=%v err=%v", ok, err)
+	}
+}
+
+func TestMigrateDropsDuplicateLegacyRows(t *testing.T) {
+	t.Parallel()
+
+	db, err := OpenRawDB(filepath.Join(t.TempDir(), "local.db"))
+	if err != nil {
+		t.Fatalf("open raw db: %v", err)
+	}
+	defer db.Close()
+
+	// Run only the first two migrations, then insert a legacy row next to
+	// the canonical one for a different path that maps to the same ID.
+	if _, err := migrate(db, Migrations[:2]); err != nil {
+		t.Fatalf("migrate to v2: %v", err)
+	}
+	if _, err := db.Exec(`
+INSERT INTO diary_entries(id, rel_path, filename, title, preview, size, modified_at, indexed_at) VALUES
+  ('2026-02-10', '2026-02-10.md', '2026-02-10.md', 't', 'p', 1, 'm', 'i'),
+  ('2026-02-10-1770681600', '2026-02-10', '2026-02-10', 't', 'p', 1, 'm', 'i')`); err != nil {
+		t.Fatalf("seed rows: %v", err)
+	}
+	if _, err := Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if ids := idsOf(t, db, `SELECT id FROM diary_entries`); len(ids) != 1 || ids[0] != "2026-02-10" {
+		t.Fatalf("unexpected ids: %v", ids)
+	}
+}
+
+func TestOpenDBRefusesNewerSchema(t *testing.T) {
+	t.Parallel()
+
+	dbPath := filepath.Join(t.TempDir(), "local.db")
+	db, err := OpenDB(dbPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO schema_migrations(version, name, applied_at) VALUES(?, 'from_the_future', 'now')`, SchemaVersion+1); err != nil {
+		t.Fatalf("insert future migration: %v", err)
+	}
+	db.Close()
+
+	if _, err := OpenDB(dbPath); !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("expected ErrSchemaTooNew, got %v", err)
+	}
+}
+
+func TestMigrationFailureRollsBack(t *testing.T) {
+	t.Parallel()
+
+	db, err := OpenRawDB(filepath.Join(t.TempDir(), "local.db"))
+	if err != nil {
+		t.Fatalf("open raw db: %v", err)
+	}
+	defer db.Close()
+
+	broken := []Migration{
+		{Version: 1, Name: "ok", Up: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`CREATE TABLE a (x INTEGER)`)
+			return err
+		}},
+		{Version: 2, Name: "broken", Up: func(tx *sql.Tx) error {
+			if _, err := tx.Exec(`CREATE TABLE b (x INTEGER)`); err != nil {
+				return err
+			}
+			return os.ErrInvalid
+		}},
+	}
+	applied, err := migrate(db, broken)
+	if err == nil {
+		t.Fatal("expected migration error")
+	}
+	if len(applied) != 1 {
+		t.Fatalf("applied = %+v, want only migration 1", applied)
+	}
+	if _, err := db.Exec(`SELECT x FROM b`); err == nil {
+		t.Fatal("table from failed migration should have been rolled back")
+	}
+	if version, _ := DBSchemaVersion(db); version != 1 {
+		t.Fatalf("version = %d, want 1", version)
+	}
+}
+
+func idsOf(t *testing.T, db *sql.DB, query string) []string {
+	t.Helper()
+	set, err := queryIDSet(db, query)
+	if err != nil {
+		t.Fatalf("query %q: %v", query, err)
+	}
+	ids := make([]string, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
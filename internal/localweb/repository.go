@@ -0,0 +1,292 @@
+package localweb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"moltbb-cli/internal/moderate"
+)
+
+// dbtx is the query surface shared by *sql.DB and *sql.Tx.
+type dbtx interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// DiaryRepository is the single writer of diary_entries and diary_fts.
+// Every entry mirrors a Markdown file under Root; its ID is DiaryEntryID of
+// the file's path relative to Root.
+type DiaryRepository struct {
+	db   *sql.DB
+	root string
+}
+
+func NewDiaryRepository(db *sql.DB, root string) *DiaryRepository {
+	return &DiaryRepository{db: db, root: root}
+}
+
+// DiaryEntryID is the canonical entry ID for a diary file: its path
+// relative to the diary directory, slash-separated, without ".md".
+func DiaryEntryID(relPath string) string {
+	return strings.TrimSuffix(filepath.ToSlash(relPath), ".md")
+}
+
+// Reindex replaces the whole index with the current diary files and
+// returns how many were indexed.
+func (r *DiaryRepository) Reindex() (int, error) {
+	items, err := scanDiaryDir(r.root)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin reindex tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM diary_entries`); err != nil {
+		return 0, fmt.Errorf("clear diary index: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM diary_fts`); err != nil {
+		return 0, fmt.Errorf("clear diary search index: %w", err)
+	}
+
+	indexedAt := time.Now().UTC().Format(time.RFC3339)
+	for _, item := range items {
+		if _, err := upsertDiaryEntry(tx, item, indexedAt); err != nil {
+			return 0, err
+		}
+		if err := indexDiaryFTS(tx, item); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit reindex tx: %w", err)
+	}
+	return len(items), nil
+}
+
+// Sync adds entries for new files and refreshes entries whose file changed
+// (by modification time). It never deletes rows.
+func (r *DiaryRepository) Sync() (added int, updated int, err error) {
+	items, err := scanDiaryDir(r.root)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("begin sync tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	existingIDs, err := queryIDSet(tx, `SELECT id FROM diary_entries`)
+	if err != nil {
+		return 0, 0, fmt.Errorf("query existing ids: %w", err)
+	}
+	// Entries missing from diary_fts (e.g. databases created before the
+	// search index existed) are backfilled even when their file is unchanged.
+	ftsIDs, err := queryIDSet(tx, `SELECT entry_id FROM diary_fts`)
+	if err != nil {
+		return 0, 0, fmt.Errorf("query search index ids: %w", err)
+	}
+
+	indexedAt := time.Now().UTC().Format(time.RFC3339)
+	for _, item := range items {
+		changed, err := upsertDiaryEntry(tx, item, indexedAt)
+		if err != nil {
+			return 0, 0, err
+		}
+		if changed {
+			if _, existed := existingIDs[item.ID]; existed {
+				updated++
+			} else {
+				added++
+			}
+		}
+		if _, indexed := ftsIDs[item.ID]; changed || !indexed {
+			if err := indexDiaryFTS(tx, item); err != nil {
+				return 0, 0, err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("commit sync tx: %w", err)
+	}
+	return added, updated, nil
+}
+
+// refresh re-reads one diary file after it was written and updates its
+// entry, returning the new summary.
+func (r *DiaryRepository) refresh(relPath string) (diarySummary, error) {
+	path := filepath.Join(r.root, filepath.FromSlash(relPath))
+	info, err := os.Stat(path)
+	if err != nil {
+		return diarySummary{}, fmt.Errorf("stat diary file: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return diarySummary{}, fmt.Errorf("read diary file: %w", err)
+	}
+	item := diarySummaryFromFile(relPath, info, data)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return diarySummary{}, fmt.Errorf("begin diary update tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Update unconditionally: a same-second rewrite keeps the old mtime.
+	if _, err := tx.Exec(`
+UPDATE diary_entries
+SET date = ?, title = ?, preview = ?, content_text = ?, size = ?, modified_at = ?, indexed_at = ?
+WHERE id = ?
+`, item.Date, item.Title, item.Preview, item.SearchText, item.Size, item.ModifiedAt, time.Now().UTC().Format(time.RFC3339), item.ID); err != nil {
+		return diarySummary{}, fmt.Errorf("update diary index row: %w", err)
+	}
+	if err := indexDiaryFTS(tx, item); err != nil {
+		return diarySummary{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return diarySummary{}, fmt.Errorf("commit diary update tx: %w", err)
+	}
+	return item, nil
+}
+
+// upsertDiaryEntry writes item's row, skipping unchanged files. A path
+// whose row is held by a different ID (only possible for rows that escaped
+// migration) is reported as unchanged rather than failing the batch.
+func upsertDiaryEntry(exec dbtx, item diarySummary, indexedAt string) (bool, error) {
+	res, err := exec.Exec(`
+INSERT INTO diary_entries(id, rel_path, filename, date, title, preview, content_text, size, modified_at, indexed_at)
+VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(id) DO UPDATE SET
+  rel_path     = excluded.rel_path,
+  filename     = excluded.filename,
+  date         = excluded.date,
+  title        = excluded.title,
+  preview      = excluded.preview,
+  content_text = excluded.content_text,
+  size         = excluded.size,
+  modified_at  = excluded.modified_at,
+  indexed_at   = excluded.indexed_at
+WHERE diary_entries.modified_at != excluded.modified_at
+`, item.ID, item.RelPath, item.Filename, item.Date, item.Title, item.Preview, item.SearchText, item.Size, item.ModifiedAt, indexedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed: diary_entries.rel_path") {
+			return false, nil
+		}
+		return false, fmt.Errorf("upsert diary %s: %w", item.ID, err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+func queryIDSet(q dbtx, query string) (map[string]struct{}, error) {
+	rows, err := q.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := make(map[string]struct{})
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = struct{}{}
+	}
+	return ids, rows.Err()
+}
+
+// scanDiaryDir reads every *.md diary under root (skipping hidden
+// directories, moderated discussion summaries and prompt packets), newest
+// diary date first.
+func scanDiaryDir(root string) ([]diarySummary, error) {
+	if strings.TrimSpace(root) == "" {
+		return nil, errors.New("diary dir is required")
+	}
+	items := make([]diarySummary, 0, 32)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return nil
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && strings.HasPrefix(name, ".") {
+				return fs.SkipDir
+			}
+			if path == filepath.Join(root, moderate.SummaryDir) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		name := d.Name()
+		if !strings.HasSuffix(name, ".md") || strings.HasSuffix(name, ".prompt.md") {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			rel = name
+		}
+		items = append(items, diarySummaryFromFile(filepath.ToSlash(rel), info, data))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		di := items[i].Date
+		dj := items[j].Date
+		if di != "" || dj != "" {
+			if di == dj {
+				return items[i].ModifiedAt > items[j].ModifiedAt
+			}
+			return di > dj
+		}
+		return items[i].ModifiedAt > items[j].ModifiedAt
+	})
+
+	return items, nil
+}
+
+func diarySummaryFromFile(relPath string, info fs.FileInfo, data []byte) diarySummary {
+	name := filepath.Base(filepath.FromSlash(relPath))
+	title, preview := extractTitleAndPreview(data)
+	return diarySummary{
+		ID:         DiaryEntryID(relPath),
+		Date:       detectDiaryDate(strings.TrimSuffix(name, ".md"), data),
+		Title:      title,
+		Preview:    preview,
+		SearchText: normalizeSearchText(string(data)),
+		body:       string(data),
+		Filename:   name,
+		RelPath:    relPath,
+		Size:       info.Size(),
+		ModifiedAt: info.ModTime().UTC().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,42 @@
+package localweb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"moltbb-cli/internal/diary"
+	"moltbb-cli/internal/moderate"
+)
+
+func TestDiaryScansSkipDiscussionSummaries(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "2026-03-01.md"), []byte("# Diary\n\nShipped the release."), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	summary := &moderate.Summary{Title: "Release sync", Room: "room-ab12", Started: time.Date(2026, 3, 1, 14, 0, 0, 0, time.UTC)}
+	path := summary.Path(dir, time.UTC)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(summary.RenderMarkdown(time.UTC)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := diary.CollectLocalSyncItems(dir)
+	if err != nil {
+		t.Fatalf("collect sync items: %v", err)
+	}
+	if len(items) != 1 || filepath.Base(items[0].Path) != "2026-03-01.md" {
+		t.Fatalf("sync items = %+v", items)
+	}
+
+	diaries, err := scanDiaryDir(dir)
+	if err != nil {
+		t.Fatalf("scan diary dir: %v", err)
+	}
+	if len(diaries) != 1 || diaries[0].RelPath != "2026-03-01.md" {
+		t.Fatalf("diaries = %+v", diaries)
+	}
+}
@@ -0,0 +1,322 @@
+package localweb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"moltbb-cli/internal/diary"
+)
+
+// Snippet highlight markers used by the studio API. The web UI escapes the
+// snippet and turns these into <mark> tags.
+const (
+	snippetMarkStart = "\x02"
+	snippetMarkEnd   = "\x03"
+)
+
+var datePrefixRe = regexp.MustCompile(`^\d{4}(?:-\d{2}(?:-\d{2})?)?$`)
+
+// DiarySearchOptions filters a diary search. Query supports bare words
+// (all must match), "quoted phrases", prefix* terms and OR/NOT operators.
+type DiarySearchOptions struct {
+	Query string
+	Tags  []string
+	// DatePrefix matches dates starting with it, e.g. "2026-03".
+	DatePrefix string
+	// From and To are inclusive YYYY-MM-DD bounds.
+	From   string
+	To     string
+	Limit  int
+	Offset int
+
+	HighlightStart string
+	HighlightEnd   string
+}
+
+type DiarySearchHit struct {
+	ID       string  `json:"id"`
+	Date     string  `json:"date,omitempty"`
+	Title    string  `json:"title"`
+	Filename string  `json:"filename"`
+	RelPath  string  `json:"relPath"`
+	Snippet  string  `json:"snippet"`
+	Rank     float64 `json:"rank"`
+}
+
+// SearchDiaries runs a ranked full-text search over the diary index and
+// returns one page of hits plus the total match count.
+func SearchDiaries(db *sql.DB, opts DiarySearchOptions) ([]DiarySearchHit, int, error) {
+	items, total, err := searchDiaryEntries(db, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	hits := make([]DiarySearchHit, 0, len(items))
+	for _, item := range items {
+		hits = append(hits, DiarySearchHit{
+			ID:       item.ID,
+			Date:     item.Date,
+			Title:    item.Title,
+			Filename: item.Filename,
+			RelPath:  item.RelPath,
+			Snippet:  item.Snippet,
+			Rank:     item.Rank,
+		})
+	}
+	return hits, total, nil
+}
+
+// SearchDiaries searches the server's index, which New brought up to date
+// with the diary directory.
+func (s *Server) SearchDiaries(opts DiarySearchOptions) ([]DiarySearchHit, int, error) {
+	return SearchDiaries(s.db, opts)
+}
+
+func searchDiaryEntries(db *sql.DB, opts DiarySearchOptions) ([]diarySummary, int, error) {
+	if db == nil {
+		return nil, 0, errors.New("db is required")
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 50
+	}
+	if opts.Offset < 0 {
+		opts.Offset = 0
+	}
+	if opts.HighlightStart == "" && opts.HighlightEnd == "" {
+		opts.HighlightStart, opts.HighlightEnd = "[", "]"
+	}
+
+	match := buildFTSQuery(opts.Query)
+	for _, tag := range opts.Tags {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+		if tag == "" {
+			continue
+		}
+		clause := "tags : " + quoteFTSTerm(strings.ToLower(tag))
+		if match == "" {
+			match = clause
+		} else {
+			match = "(" + match + ") AND " + clause
+		}
+	}
+
+	filterSQL, filterArgs := diaryDateFilter(opts)
+	if match == "" {
+		return listDiaryEntries(db, filterSQL, filterArgs, opts)
+	}
+
+	items, total, err := matchDiaryEntries(db, match, filterSQL, filterArgs, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	// unicode61 treats an unbroken CJK run as one token, so a word inside a
+	// Chinese sentence never matches on its own. Fall back to a substring scan
+	// of the normalized text when the index finds nothing.
+	if total == 0 && strings.TrimSpace(opts.Query) != "" && len(opts.Tags) == 0 {
+		return likeDiaryEntries(db, opts.Query, filterSQL, filterArgs, opts)
+	}
+	return items, total, nil
+}
+
+func matchDiaryEntries(db *sql.DB, match, filterSQL string, filterArgs []any, opts DiarySearchOptions) ([]diarySummary, int, error) {
+	where := ` WHERE diary_fts MATCH ?` + filterSQL
+	args := append([]any{match}, filterArgs...)
+
+	var total int
+	if err := db.QueryRow(`SELECT COUNT(1) FROM diary_fts JOIN diary_entries e ON e.id = diary_fts.entry_id`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count diary search: %w", err)
+	}
+
+	// bm25 weights follow column order: entry_id, title, content, tags.
+	query := `
+SELECT e.id, e.date, e.title, e.preview, e.filename, e.rel_path, e.size, e.modified_at,
+       snippet(diary_fts, 2, ?, ?, '…', 16), bm25(diary_fts, 0.0, 5.0, 1.0, 3.0)
+FROM diary_fts JOIN diary_entries e ON e.id = diary_fts.entry_id` + where + `
+ORDER BY bm25(diary_fts, 0.0, 5.0, 1.0, 3.0), e.date DESC
+LIMIT ? OFFSET ?`
+	queryArgs := append([]any{opts.HighlightStart, opts.HighlightEnd}, args...)
+	queryArgs = append(queryArgs, opts.Limit, opts.Offset)
+
+	rows, err := db.Query(query, queryArgs...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("search diaries: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]diarySummary, 0, opts.Limit)
+	for rows.Next() {
+		var item diarySummary
+		var rank float64
+		if err := rows.Scan(&item.ID, &item.Date, &item.Title, &item.Preview, &item.Filename, &item.RelPath, &item.Size, &item.ModifiedAt, &item.Snippet, &rank); err != nil {
+			return nil, 0, fmt.Errorf("scan diary search row: %w", err)
+		}
+		// bm25 is lower-is-better and negative; expose a positive score.
+		item.Rank = -rank
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("read diary search rows: %w", err)
+	}
+	return items, total, nil
+}
+
+// likeEscaper makes LIKE wildcards in a query match literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func likeDiaryEntries(db *sql.DB, query, filterSQL string, filterArgs []any, opts DiarySearchOptions) ([]diarySummary, int, error) {
+	like := "%" + likeEscaper.Replace(strings.ToLower(strings.Join(strings.Fields(strings.ReplaceAll(query, `"`, " ")), " "))) + "%"
+	where := ` WHERE (e.content_text LIKE ? ESCAPE '\' OR lower(e.title) LIKE ? ESCAPE '\' OR lower(e.filename) LIKE ? ESCAPE '\')` + filterSQL
+	args := append([]any{like, like, like}, filterArgs...)
+	return selectDiaryEntries(db, where, args, opts)
+}
+
+func listDiaryEntries(db *sql.DB, filterSQL string, filterArgs []any, opts DiarySearchOptions) ([]diarySummary, int, error) {
+	where := ""
+	if filterSQL != "" {
+		where = " WHERE 1=1" + filterSQL
+	}
+	return selectDiaryEntries(db, where, filterArgs, opts)
+}
+
+func selectDiaryEntries(db *sql.DB, where string, args []any, opts DiarySearchOptions) ([]diarySummary, int, error) {
+	var total int
+	if err := db.QueryRow(`SELECT COUNT(1) FROM diary_entries e`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count diaries: %w", err)
+	}
+
+	query := `
+SELECT e.id, e.date, e.title, e.preview, e.filename, e.rel_path, e.size, e.modified_at
+FROM diary_entries e` + where + `
+ORDER BY CASE WHEN e.date = '' THEN 1 ELSE 0 END, e.date DESC, e.modified_at DESC
+LIMIT ? OFFSET ?`
+	rows, err := db.Query(query, append(args, opts.Limit, opts.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query diaries: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]diarySummary, 0, opts.Limit)
+	for rows.Next() {
+		item, scanErr := scanDiarySummary(rows)
+		if scanErr != nil {
+			return nil, 0, scanErr
+		}
+		item.Snippet = item.Preview
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("read diaries rows: %w", err)
+	}
+	return items, total, nil
+}
+
+func diaryDateFilter(opts DiarySearchOptions) (string, []any) {
+	var sb strings.Builder
+	args := make([]any, 0, 3)
+	if prefix := strings.TrimSpace(opts.DatePrefix); prefix != "" {
+		sb.WriteString(` AND e.date LIKE ?`)
+		args = append(args, prefix+"%")
+	}
+	if from := strings.TrimSpace(opts.From); from != "" {
+		sb.WriteString(` AND e.date <> '' AND e.date >= ?`)
+		args = append(args, from)
+	}
+	if to := strings.TrimSpace(opts.To); to != "" {
+		sb.WriteString(` AND e.date <> '' AND e.date <= ?`)
+		args = append(args, to)
+	}
+	return sb.String(), args
+}
+
+// buildFTSQuery turns user input into an FTS5 MATCH expression. Every term
+// is quoted so punctuation cannot break the syntax; "phrases" stay phrases,
+// a trailing * keeps prefix matching and OR/NOT/AND pass through.
+func buildFTSQuery(input string) string {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return ""
+	}
+
+	parts := make([]string, 0, 8)
+	pendingOp := ""
+	addTerm := func(term string) {
+		if pendingOp != "" && len(parts) > 0 {
+			parts = append(parts, pendingOp)
+		}
+		pendingOp = ""
+		parts = append(parts, term)
+	}
+
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			phrase := strings.TrimSpace(string(runes[i+1 : end]))
+			i = end + 1
+			if phrase != "" {
+				addTerm(quoteFTSTerm(phrase))
+			}
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != '"' {
+				end++
+			}
+			word := string(runes[i:end])
+			i = end
+			switch word {
+			case "OR", "NOT", "AND":
+				if len(parts) > 0 {
+					pendingOp = word
+				}
+				continue
+			}
+			prefix := strings.HasSuffix(word, "*")
+			word = strings.Trim(word, "*")
+			if word == "" {
+				continue
+			}
+			term := quoteFTSTerm(word)
+			if prefix {
+				term += "*"
+			}
+			addTerm(term)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func quoteFTSTerm(term string) string {
+	return `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+}
+
+// indexDiaryFTS replaces the full-text row for one diary entry.
+func indexDiaryFTS(exec dbtx, item diarySummary) error {
+	if _, err := exec.Exec(`DELETE FROM diary_fts WHERE entry_id = ?`, item.ID); err != nil {
+		return fmt.Errorf("clear diary search row: %w", err)
+	}
+	if _, err := exec.Exec(`INSERT INTO diary_fts(entry_id, title, content, tags) VALUES(?, ?, ?, ?)`,
+		item.ID, item.Title, item.body, strings.Join(diary.ExtractTags(item.body), " ")); err != nil {
+		return fmt.Errorf("index diary search row: %w", err)
+	}
+	return nil
+}
+
+// parseDiaryQuery splits the studio's single search box into a date prefix
+// (when the input looks like YYYY, YYYY-MM or YYYY-MM-DD) or a text query.
+func parseDiaryQuery(q string) (query, datePrefix string) {
+	q = strings.TrimSpace(q)
+	if datePrefixRe.MatchString(q) {
+		return "", q
+	}
+	return q, ""
+}
@@ -14,7 +14,6 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -43,6 +42,9 @@ type Options struct {
 	APIBaseURL string
 	InputPaths []string
 	Version    string
+	// Context, when set, ends the /api/events stream once it is done so
+	// open event requests do not hold up an HTTP server shutdown.
+	Context context.Context
 }
 
 type Server struct {
@@ -52,23 +54,31 @@ type Server struct {
 	apiBaseURL string
 	inputPaths []string
 	version    string
+	startedAt  time.Time
 	db         *sql.DB
+	diaries    *DiaryRepository
 	prompts    *PromptStore
+	events     *eventBroker
 	mux        *http.ServeMux
 }
 
 type diarySummary struct {
-	ID         string `json:"id"`
-	Date       string `json:"date,omitempty"`
-	Title      string `json:"title"`
-	Preview    string `json:"preview"`
-	Filename   string `json:"filename"`
-	RelPath    string `json:"relPath"`
-	Size       int64  `json:"size"`
-	ModifiedAt string `json:"modifiedAt"`
-	IsDefault  bool   `json:"isDefault"`
-	CanSync    bool   `json:"canSync"`
-	SearchText string `json:"-"`
+	ID         string  `json:"id"`
+	Date       string  `json:"date,omitempty"`
+	Title      string  `json:"title"`
+	Preview    string  `json:"preview"`
+	Filename   string  `json:"filename"`
+	RelPath    string  `json:"relPath"`
+	Size       int64   `json:"size"`
+	ModifiedAt string  `json:"modifiedAt"`
+	IsDefault  bool    `json:"isDefault"`
+	CanSync    bool    `json:"canSync"`
+	Snippet    string  `json:"snippet,omitempty"`
+	Rank       float64 `json:"rank,omitempty"`
+	SearchText string  `json:"-"`
+
+	// body is the raw file content, only set while indexing.
+	body string
 }
 
 type diaryDetail struct {
@@ -328,8 +338,11 @@ func New(options Options) (*Server, error) {
 		apiBaseURL: strings.TrimSpace(options.APIBaseURL),
 		inputPaths: filterNonEmpty(options.InputPaths),
 		version:    strings.TrimSpace(options.Version),
+		startedAt:  time.Now().UTC(),
 		db:         db,
+		diaries:    NewDiaryRepository(db, expandedDiaryDir),
 		prompts:    promptStore,
+		events:     newEventBroker(),
 		mux:        http.NewServeMux(),
 	}
 	if _, _, err := s.syncDiariesIncremental(); err != nil {
@@ -337,9 +350,21 @@ func New(options Options) (*Server, error) {
 		return nil, err
 	}
 	s.registerRoutes()
+	if options.Context != nil {
+		go func() {
+			<-options.Context.Done()
+			s.events.close()
+		}()
+	}
 	return s, nil
 }
 
+// Close ends the event stream and releases the server's database handle.
+func (s *Server) Close() error {
+	s.events.close()
+	return s.db.Close()
+}
+
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if rewrittenPath, ok := rewritePrefixedPath(r.URL.Path); ok {
 		cloned := r.Clone(r.Context())
@@ -359,6 +384,7 @@ func (s *Server) registerRoutes() {
 	s.mux.HandleFunc("/api/settings/test-connection", s.handleSettingsConnectionTest)
 	s.mux.HandleFunc("/api/settings/cli-status", s.handleSettingsCLIStatus)
 	s.mux.HandleFunc("/api/tower-status", s.handleTowerStatus)
+	s.mux.HandleFunc("/api/events", s.handleEvents)
 	s.mux.HandleFunc("/api/diaries", s.handleDiaries)
 	s.mux.HandleFunc("/api/diaries/history", s.handleDiaryHistory)
 	s.mux.HandleFunc("/api/diaries/reindex", s.handleReindex)
@@ -368,6 +394,8 @@ func (s *Server) registerRoutes() {
 	s.mux.HandleFunc("/api/prompts", s.handlePrompts)
 	s.mux.HandleFunc("/api/prompts/", s.handlePromptByID)
 	s.mux.HandleFunc("/api/generate-packet", s.handleGeneratePacket)
+	s.mux.HandleFunc("/api/transcripts", s.handleTranscripts)
+	s.mux.HandleFunc("/api/transcripts/", s.handleTranscriptByID)
 
 	assetFS, _ := fs.Sub(staticFS, "static")
 	fileServer := http.FileServer(http.FS(assetFS))
@@ -403,7 +431,13 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	if !allowMethod(w, r, http.MethodGet) {
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "ts": time.Now().UTC().Format(time.RFC3339)})
+	writeJSON(w, http.StatusOK, map[string]any{
+		"ok":        true,
+		"pid":       os.Getpid(),
+		"version":   s.version,
+		"startedAt": s.startedAt.Format(time.RFC3339),
+		"ts":        time.Now().UTC().Format(time.RFC3339),
+	})
 }
 
 func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
@@ -650,11 +684,20 @@ func (s *Server) handleDiaries(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	q := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
-	limit := parseInt(r.URL.Query().Get("limit"), 50, 1, 500)
-	offset := parseInt(r.URL.Query().Get("offset"), 0, 0, 1_000_000)
-
-	items, total, err := s.listDiaries(q, limit, offset)
+	query := r.URL.Query()
+	text, datePrefix := parseDiaryQuery(query.Get("q"))
+	opts := DiarySearchOptions{
+		Query:          text,
+		DatePrefix:     datePrefix,
+		From:           strings.TrimSpace(query.Get("from")),
+		To:             strings.TrimSpace(query.Get("to")),
+		Tags:           splitCommaList(query["tag"]),
+		Limit:          parseInt(query.Get("limit"), 50, 1, 500),
+		Offset:         parseInt(query.Get("offset"), 0, 0, 1_000_000),
+		HighlightStart: snippetMarkStart,
+		HighlightEnd:   snippetMarkEnd,
+	}
+	items, total, err := s.listDiaries(opts)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err)
 		return
@@ -663,8 +706,8 @@ func (s *Server) handleDiaries(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, diariesResponse{
 		Items:  items,
 		Total:  total,
-		Offset: offset,
-		Limit:  limit,
+		Offset: opts.Offset,
+		Limit:  opts.Limit,
 	})
 }
 
@@ -922,13 +965,22 @@ func (s *Server) handleInsightByID(w http.ResponseWriter, r *http.Request) {
 			Tags:            tags,
 			VisibilityLevel: req.VisibilityLevel,
 		})
+		if api.IsNotFound(err) {
+			writeJSON(w, http.StatusNotFound, map[string]any{"error": "insight not found"})
+			return
+		}
 		if err != nil {
 			writeError(w, http.StatusBadRequest, normalizeRuntimeInsightsError(err))
 			return
 		}
 		writeJSON(w, http.StatusOK, mapRuntimeInsight(updated))
 	case http.MethodDelete:
-		if err := client.DeleteRuntimeInsight(ctx, apiKey, id); err != nil {
+		err := client.DeleteRuntimeInsight(ctx, apiKey, id)
+		if api.IsNotFound(err) {
+			writeJSON(w, http.StatusNotFound, map[string]any{"error": "insight not found"})
+			return
+		}
+		if err != nil {
 			writeError(w, http.StatusBadRequest, normalizeRuntimeInsightsError(err))
 			return
 		}
@@ -1051,6 +1103,63 @@ func (s *Server) handlePromptByID(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (s *Server) handleTranscripts(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	items, err := ListTranscripts(s.db, parseInt(r.URL.Query().Get("limit"), 100, 1, 500))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items, "total": len(items)})
+}
+
+// handleTranscriptByID returns one transcript as JSON, or as a Markdown
+// download with ?format=markdown.
+func (s *Server) handleTranscriptByID(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/transcripts/"), "/")
+	if decoded, err := url.PathUnescape(id); err == nil {
+		id = decoded
+	}
+	if strings.TrimSpace(id) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "session token or room code is required"})
+		return
+	}
+
+	t, msgs, err := GetTranscript(s.db, id)
+	if errors.Is(err, ErrTranscriptNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "transcript not found"})
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if strings.EqualFold(r.URL.Query().Get("format"), "markdown") {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "transcript-"+safeFilename(t.Conversation)+".md"))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(RenderTranscriptMarkdown(t, msgs, time.Local)))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"transcript": t, "items": msgs})
+}
+
+func safeFilename(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
 func (s *Server) handleGeneratePacket(w http.ResponseWriter, r *http.Request) {
 	if !allowMethod(w, r, http.MethodPost) {
 		return
@@ -1125,7 +1234,9 @@ func (s *Server) handleGeneratePacket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	packetPath, err := diary.WritePromptPacket(date, hostname, s.apiBaseURL, expandedOutputDir, tmpPath, hints)
+	// The day's pipeline conversations are context, not a requirement.
+	excerpt, _ := DayTranscriptExcerpt(s.db, date, time.Local)
+	packetPath, err := diary.WritePromptPacket(date, hostname, s.apiBaseURL, expandedOutputDir, tmpPath, hints, excerpt)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err)
 		return
@@ -1449,13 +1560,12 @@ func normalizeResolveAPIKeyError(err error) error {
 	if err == nil {
 		return nil
 	}
-	msg := strings.ToLower(strings.TrimSpace(err.Error()))
-	if msg == "" {
-		return errors.New("resolve api key failed")
-	}
-	if strings.Contains(msg, "credentials file not found") || strings.Contains(msg, "credentials missing api_key") {
+	if errors.Is(err, auth.ErrNoCredentials) || errors.Is(err, auth.ErrNoAPIKey) {
 		return errors.New("API key is not configured. Set it in Settings or run `moltbb login --apikey <key>`")
 	}
+	if strings.TrimSpace(err.Error()) == "" {
+		return errors.New("resolve api key failed")
+	}
 	return fmt.Errorf("resolve api key: %w", err)
 }
 
@@ -1471,7 +1581,8 @@ func (s *Server) listInsights(
 ) (insightsResponse, error) {
 	result, err := client.ListRuntimeInsights(ctx, apiKey, page, pageSize, tags, diaryID)
 	if err != nil {
-		if isRuntimeInsightsNotFoundError(err) {
+		// The list endpoint itself is missing on older servers.
+		if api.IsNotFound(err) {
 			return insightsResponse{
 				Items:       []insightSummary{},
 				Total:       0,
@@ -1583,23 +1694,17 @@ func runtimeInsightsUnsupportedMessage() string {
 	return "runtime insights endpoint is unavailable on current server (404). Upgrade backend to version supporting /api/v1/runtime/insights."
 }
 
-func isRuntimeInsightsNotFoundError(err error) bool {
-	if err == nil {
-		return false
-	}
-	msg := strings.ToLower(strings.TrimSpace(err.Error()))
-	if msg == "" {
-		return false
-	}
-	if !strings.Contains(msg, "insight") {
-		return false
-	}
-	return strings.Contains(msg, "status 404")
-}
-
+// normalizeRuntimeInsightsError explains an error from the insights list
+// or create call, where a 404 means the server has no insights endpoint.
+// Callers handle a 404 for a single insight themselves.
 func normalizeRuntimeInsightsError(err error) error {
-	if isRuntimeInsightsNotFoundError(err) {
+	switch {
+	case api.IsNotFound(err):
 		return errors.New(runtimeInsightsUnsupportedMessage())
+	case api.IsAuth(err):
+		return fmt.Errorf("the server rejected the API key. Update it in Settings or run `moltbb login --apikey <key>` (%w)", err)
+	case api.IsRateLimited(err):
+		return fmt.Errorf("the server is rate limiting requests; try again in a minute (%w)", err)
 	}
 	return err
 }
@@ -1640,27 +1745,9 @@ func (s *Server) saveDiaryContent(id, content string) (diaryDetail, bool, error)
 		return diaryDetail{}, false, fmt.Errorf("write diary file: %w", err)
 	}
 
-	info, err := os.Stat(path)
-	if err != nil {
-		return diaryDetail{}, false, fmt.Errorf("stat diary file: %w", err)
-	}
-
-	base := strings.TrimSuffix(item.Filename, ".md")
-	contentBytes := []byte(content)
-	updated := item
-	updated.Date = detectDiaryDate(base, contentBytes)
-	updated.Title, updated.Preview = extractTitleAndPreview(contentBytes)
-	updated.Size = info.Size()
-	updated.ModifiedAt = info.ModTime().UTC().Format(time.RFC3339)
-	updated.SearchText = normalizeSearchText(content)
-
-	_, err = s.db.Exec(`
-UPDATE diary_entries
-SET date = ?, title = ?, preview = ?, content_text = ?, size = ?, modified_at = ?, indexed_at = ?
-WHERE id = ?
-`, updated.Date, updated.Title, updated.Preview, updated.SearchText, updated.Size, updated.ModifiedAt, time.Now().UTC().Format(time.RFC3339), updated.ID)
+	updated, err := s.diaries.refresh(item.RelPath)
 	if err != nil {
-		return diaryDetail{}, false, fmt.Errorf("update diary index row: %w", err)
+		return diaryDetail{}, false, err
 	}
 
 	if err := s.reconcileDayDefaults(); err != nil {
@@ -1673,44 +1760,10 @@ WHERE id = ?
 	return diaryDetail{diarySummary: updated, Content: content}, true, nil
 }
 
-func (s *Server) listDiaries(q string, limit, offset int) ([]diarySummary, int, error) {
-	whereSQL := ""
-	args := make([]any, 0, 8)
-	if strings.TrimSpace(q) != "" {
-		like := "%" + strings.ToLower(strings.TrimSpace(q)) + "%"
-		whereSQL = ` WHERE lower(title) LIKE ? OR lower(preview) LIKE ? OR lower(filename) LIKE ? OR lower(date) LIKE ? OR content_text LIKE ?`
-		args = append(args, like, like, like, like, like)
-	}
-
-	countQuery := `SELECT COUNT(1) FROM diary_entries` + whereSQL
-	var total int
-	if err := s.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
-		return nil, 0, fmt.Errorf("count diaries: %w", err)
-	}
-
-	itemsQuery := `
-SELECT id, date, title, preview, filename, rel_path, size, modified_at
-FROM diary_entries` + whereSQL + `
-ORDER BY CASE WHEN date = '' THEN 1 ELSE 0 END, date DESC, modified_at DESC
-LIMIT ? OFFSET ?`
-	args = append(args, limit, offset)
-
-	rows, err := s.db.Query(itemsQuery, args...)
+func (s *Server) listDiaries(opts DiarySearchOptions) ([]diarySummary, int, error) {
+	items, total, err := searchDiaryEntries(s.db, opts)
 	if err != nil {
-		return nil, 0, fmt.Errorf("query diaries: %w", err)
-	}
-	defer rows.Close()
-
-	items := make([]diarySummary, 0, limit)
-	for rows.Next() {
-		item, scanErr := scanDiarySummary(rows)
-		if scanErr != nil {
-			return nil, 0, scanErr
-		}
-		items = append(items, item)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("read diaries rows: %w", err)
+		return nil, 0, err
 	}
 
 	ptrs := make([]*diarySummary, 0, len(items))
@@ -1787,192 +1840,29 @@ func (s *Server) countDiaries() (int, error) {
 }
 
 func (s *Server) reindexDiaries() (int, error) {
-	items, err := s.scanDiaryFiles()
+	count, err := s.diaries.Reindex()
 	if err != nil {
 		return 0, err
 	}
-
-	tx, err := s.db.Begin()
-	if err != nil {
-		return 0, fmt.Errorf("begin reindex tx: %w", err)
-	}
-	defer tx.Rollback()
-
-	if _, err := tx.Exec(`DELETE FROM diary_entries`); err != nil {
-		return 0, fmt.Errorf("clear diary index: %w", err)
-	}
-
-	stmt, err := tx.Prepare(`
-INSERT INTO diary_entries(id, rel_path, filename, date, title, preview, content_text, size, modified_at, indexed_at)
-VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-`)
-	if err != nil {
-		return 0, fmt.Errorf("prepare diary insert: %w", err)
-	}
-	defer stmt.Close()
-
-	indexedAt := time.Now().UTC().Format(time.RFC3339)
-	for _, item := range items {
-		_, err := stmt.Exec(item.ID, item.RelPath, item.Filename, item.Date, item.Title, item.Preview, item.SearchText, item.Size, item.ModifiedAt, indexedAt)
-		if err != nil {
-			return 0, fmt.Errorf("insert diary index row: %w", err)
-		}
-	}
-
-	if err := tx.Commit(); err != nil {
-		return 0, fmt.Errorf("commit reindex tx: %w", err)
-	}
 	if err := s.reconcileDayDefaults(); err != nil {
 		return 0, err
 	}
-	return len(items), nil
+	return count, nil
 }
 
 // syncDiariesIncremental 增量同步：扫描文件，只补充 DB 中缺失的条目，
 // 并更新已有但内容已变化（modified_at 不同）的条目，不删除任何现有记录。
 func (s *Server) syncDiariesIncremental() (added int, updated int, err error) {
-	items, err := s.scanDiaryFiles()
+	added, updated, err = s.diaries.Sync()
 	if err != nil {
 		return 0, 0, err
 	}
-
-	tx, err := s.db.Begin()
-	if err != nil {
-		return 0, 0, fmt.Errorf("begin sync tx: %w", err)
-	}
-	defer tx.Rollback()
-
-	stmt, err := tx.Prepare(`
-INSERT INTO diary_entries(id, rel_path, filename, date, title, preview, content_text, size, modified_at, indexed_at)
-VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-ON CONFLICT(id) DO UPDATE SET
-  rel_path     = excluded.rel_path,
-  filename     = excluded.filename,
-  date         = excluded.date,
-  title        = excluded.title,
-  preview      = excluded.preview,
-  content_text = excluded.content_text,
-  size         = excluded.size,
-  modified_at  = excluded.modified_at,
-  indexed_at   = excluded.indexed_at
-WHERE diary_entries.modified_at != excluded.modified_at
-`)
-	if err != nil {
-		return 0, 0, fmt.Errorf("prepare sync stmt: %w", err)
-	}
-	defer stmt.Close()
-
-	// 先获取已有 ID 集合，用于统计新增 vs 更新
-	existingIDs := make(map[string]struct{})
-	rows, err := tx.Query(`SELECT id FROM diary_entries`)
-	if err != nil {
-		return 0, 0, fmt.Errorf("query existing ids: %w", err)
-	}
-	for rows.Next() {
-		var id string
-		if scanErr := rows.Scan(&id); scanErr == nil {
-			existingIDs[id] = struct{}{}
-		}
-	}
-	rows.Close()
-
-	indexedAt := time.Now().UTC().Format(time.RFC3339)
-	for _, item := range items {
-		res, execErr := stmt.Exec(item.ID, item.RelPath, item.Filename, item.Date, item.Title, item.Preview, item.SearchText, item.Size, item.ModifiedAt, indexedAt)
-		if execErr != nil {
-			if strings.Contains(execErr.Error(), "UNIQUE constraint failed: diary_entries.rel_path") {
-				continue
-			}
-			return 0, 0, fmt.Errorf("upsert diary %s: %w", item.ID, execErr)
-		}
-		n, _ := res.RowsAffected()
-		if n > 0 {
-			if _, existed := existingIDs[item.ID]; existed {
-				updated++
-			} else {
-				added++
-			}
-		}
-	}
-
-	if err := tx.Commit(); err != nil {
-		return 0, 0, fmt.Errorf("commit sync tx: %w", err)
-	}
 	if err := s.reconcileDayDefaults(); err != nil {
 		return 0, 0, err
 	}
 	return added, updated, nil
 }
 
-func (s *Server) scanDiaryFiles() ([]diarySummary, error) {
-	items := make([]diarySummary, 0, 32)
-	root := s.diaryDir
-
-	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, walkErr error) error {
-		if walkErr != nil {
-			return nil
-		}
-		if d.IsDir() {
-			name := d.Name()
-			if path != root && strings.HasPrefix(name, ".") {
-				return fs.SkipDir
-			}
-			return nil
-		}
-
-		name := d.Name()
-		if !strings.HasSuffix(name, ".md") || strings.HasSuffix(name, ".prompt.md") {
-			return nil
-		}
-
-		info, err := d.Info()
-		if err != nil {
-			return nil
-		}
-		data, err := os.ReadFile(path)
-		if err != nil {
-			return nil
-		}
-
-		rel, err := filepath.Rel(root, path)
-		if err != nil {
-			rel = name
-		}
-		base := strings.TrimSuffix(name, ".md")
-		id := filepath.ToSlash(strings.TrimSuffix(rel, ".md"))
-		title, preview := extractTitleAndPreview(data)
-		items = append(items, diarySummary{
-			ID:         id,
-			Date:       detectDiaryDate(base, data),
-			Title:      title,
-			Preview:    preview,
-			SearchText: normalizeSearchText(string(data)),
-			Filename:   name,
-			RelPath:    filepath.ToSlash(rel),
-			Size:       info.Size(),
-			ModifiedAt: info.ModTime().UTC().Format(time.RFC3339),
-		})
-		return nil
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	sort.Slice(items, func(i, j int) bool {
-		di := items[i].Date
-		dj := items[j].Date
-		if di != "" || dj != "" {
-			if di == dj {
-				return items[i].ModifiedAt > items[j].ModifiedAt
-			}
-			return di > dj
-		}
-		return items[i].ModifiedAt > items[j].ModifiedAt
-	})
-
-	return items, nil
-}
-
 func (s *Server) setDiaryAsDefault(id string) (diaryDetail, bool, error) {
 	item, found, err := s.getDiaryByID(id)
 	if err != nil {
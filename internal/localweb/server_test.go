@@ -580,6 +580,14 @@ func TestInsightsListReturnsUnsupportedWhenRuntimeEndpointMissing(t *testing.T)
 	if !strings.Contains(strings.ToLower(listResp.Notice), "runtime insights endpoint is unavailable") {
 		t.Fatalf("expected unsupported notice, got %q", listResp.Notice)
 	}
+
+	// A 404 for one insight means that insight is gone, not the endpoint.
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodDelete, "/api/insights/ins-1", nil)
+	srv.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), "insight not found") {
+		t.Fatalf("delete missing insight: status %d, body %s", rec.Code, rec.Body.String())
+	}
 }
 
 func TestDiarySearchMatchesFullContent(t *testing.T) {
@@ -985,3 +993,115 @@ func TestSyncDiary_WithCloudSyncEnabledAndNoAPIKey_ReturnsExplicitReason(t *test
 		t.Fatalf("expected api key error in log, got=%v", entry["error"])
 	}
 }
+
+func TestDiarySearchFTSRankingSnippetsAndFilters(t *testing.T) {
+	t.Parallel()
+
+	diaryDir := t.TempDir()
+	dataDir := t.TempDir()
+	files := map[string]string{
+		"2026-03-01.md": "# Redis day\n\nRedis cache tuning all day. Redis eviction policy changed. #infra",
+		"2026-03-05.md": "# Notes\n\nBriefly looked at a redis dashboard.\n\n- Tags: work, ops",
+		"2026-04-02.md": "# Deploy\n\nDeployment pipeline fixed; cache miss rate down 5%. #work",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(diaryDir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("write diary: %v", err)
+		}
+	}
+
+	srv, err := New(Options{DiaryDir: diaryDir, DataDir: dataDir, APIBaseURL: "https://moltbb.com"})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	defer srv.Close()
+
+	hits, total, err := srv.SearchDiaries(DiarySearchOptions{Query: "redis"})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if total != 2 || hits[0].ID != "2026-03-01" {
+		t.Fatalf("expected redis-heavy diary first, got total=%d hits=%+v", total, hits)
+	}
+	if !strings.Contains(hits[0].Snippet, "[Redis]") {
+		t.Fatalf("expected highlighted snippet, got %q", hits[0].Snippet)
+	}
+
+	cases := []struct {
+		name string
+		opts DiarySearchOptions
+		want []string
+	}{
+		{"phrase", DiarySearchOptions{Query: `"cache miss"`}, []string{"2026-04-02"}},
+		{"prefix", DiarySearchOptions{Query: "deploy*"}, []string{"2026-04-02"}},
+		{"hashtag", DiarySearchOptions{Tags: []string{"#work"}}, []string{"2026-04-02", "2026-03-05"}},
+		{"tag label", DiarySearchOptions{Tags: []string{"ops"}}, []string{"2026-03-05"}},
+		{"date range", DiarySearchOptions{Query: "cache", From: "2026-04-01", To: "2026-04-30"}, []string{"2026-04-02"}},
+		{"date prefix", DiarySearchOptions{DatePrefix: "2026-03"}, []string{"2026-03-05", "2026-03-01"}},
+		{"substring fallback", DiarySearchOptions{Query: "dashb"}, []string{"2026-03-05"}},
+		{"literal wildcard", DiarySearchOptions{Query: "%"}, []string{"2026-04-02"}},
+		{"limit", DiarySearchOptions{Query: "redis", Limit: 1}, []string{"2026-03-01"}},
+	}
+	for _, tc := range cases {
+		hits, _, err := srv.SearchDiaries(tc.opts)
+		if err != nil {
+			t.Fatalf("%s: search: %v", tc.name, err)
+		}
+		got := make([]string, 0, len(hits))
+		for _, h := range hits {
+			got = append(got, h.ID)
+		}
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Fatalf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestBuildFTSQueryQuotesTerms(t *testing.T) {
+	cases := map[string]string{
+		`redis cache`:       `"redis" "cache"`,
+		`"cache miss" dep*`: `"cache miss" "dep"*`,
+		`a OR b NOT c`:      `"a" OR "b" NOT "c"`,
+		`OR c:d "unclosed`:  `"c:d" "unclosed"`,
+		`  `:                ``,
+	}
+	for input, want := range cases {
+		if got := buildFTSQuery(input); got != want {
+			t.Fatalf("buildFTSQuery(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestTranscriptsAPI(t *testing.T) {
+	t.Parallel()
+
+	srv, err := New(Options{DiaryDir: t.TempDir(), DataDir: t.TempDir(), APIBaseURL: "https://moltbb.com"})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	msg := TranscriptMessage{Kind: TranscriptRoom, Conversation: "room-ab12", Direction: TranscriptReceived, SenderID: "bot-c", Content: "deploy done", SentAt: time.Now()}
+	if _, err := SaveTranscriptMessage(srv.db, msg); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/transcripts", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"conversation":"room-ab12"`) {
+		t.Fatalf("list transcripts status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/transcripts/room-ab12?format=markdown", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "deploy done") {
+		t.Fatalf("markdown transcript status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Disposition"); !strings.Contains(got, "transcript-room-ab12.md") {
+		t.Fatalf("content disposition = %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/transcripts/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("missing transcript status = %d", rec.Code)
+	}
+}
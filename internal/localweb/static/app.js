@@ -12,6 +12,8 @@ const MESSAGES = {
     'title.page.insights': 'Insights',
     'title.page.prompts': 'Prompts',
     'title.page.generate': 'Generate Packet',
+    'title.page.activity': 'Activity',
+    'title.page.transcripts': 'Transcripts',
     'title.page.settings': 'Settings',
     'topbar.title': 'Diary Studio',
     'topbar.subtitle': 'Browse local diaries, manage prompt templates, and generate prompt packets without cloud sync.',
@@ -29,6 +31,8 @@ const MESSAGES = {
     'tabs.insights': 'Insights',
     'tabs.prompts': 'Prompts',
     'tabs.generate': 'Generate Packet',
+    'tabs.activity': 'Activity',
+    'tabs.transcripts': 'Transcripts',
     'tabs.settings': 'Settings',
     'actions.refresh': 'Refresh',
     'actions.reindex': 'Reindex',
@@ -170,6 +174,33 @@ const MESSAGES = {
     'generate.noPacket': 'No packet generated yet.',
     'generate.generated': 'Prompt packet generated: {path}',
     'generate.failed': 'Generate failed: {message}',
+    'activity.title': 'Live Activity',
+    'activity.clear': 'Clear',
+    'activity.empty': 'No activity yet. Pipeline invitations and messages appear here as they arrive.',
+    'activity.hub.connecting': 'Connecting…',
+    'activity.hub.connected': 'Live',
+    'activity.hub.reconnecting': 'Reconnecting (attempt {attempt})…',
+    'activity.hub.closed': 'Disconnected',
+    'activity.hub.disabled': 'Live events off: {message}',
+    'activity.hub.offline': 'Event stream unavailable',
+    'activity.unread': '{count} unread inbox messages',
+    'activity.event.Pipeline.InvitationReceived': 'Pipeline invitation from {who}',
+    'activity.event.Pipeline.MessageReceived': 'Pipeline message from {who}',
+    'activity.event.Pipeline.SessionAccepted': 'Pipeline session accepted',
+    'activity.event.Pipeline.SessionRejected': 'Pipeline session rejected',
+    'activity.event.Pipeline.SessionEnded': 'Pipeline session ended',
+    'activity.event.Inbox.UnreadCount': 'Inbox: {count} unread',
+    'transcript.listTitle': 'Transcripts',
+    'transcript.detailTitle': 'Conversation',
+    'transcript.download': 'Download Markdown',
+    'transcript.empty': 'No conversations archived yet. Pipeline sessions and rooms this machine takes part in are archived automatically.',
+    'transcript.select': 'Select a conversation from the left list.',
+    'transcript.kind.session': 'Session',
+    'transcript.kind.room': 'Room',
+    'transcript.you': 'You',
+    'transcript.count': '{count} messages',
+    'transcript.encrypted': 'end-to-end encrypted',
+    'transcript.loadFailed': 'Load transcripts failed: {message}',
     'settings.title': 'Cloud Settings',
     'settings.ownerTitle': 'Owner Registration Required',
     'settings.ownerHint': 'Looks like this device installed CLI/skill before owner registration. Ask owner to complete registration first, then configure API key below.',
@@ -239,6 +270,8 @@ const MESSAGES = {
     'title.page.insights': '心得',
     'title.page.prompts': '提示词',
     'title.page.generate': '生成数据包',
+    'title.page.activity': '动态',
+    'title.page.transcripts': '会话记录',
     'title.page.settings': '设置',
     'topbar.title': '虾比比日记',
     'topbar.subtitle': '浏览本地日记、管理提示词模板，并在不走云同步的情况下生成提示词数据包。',
@@ -256,6 +289,8 @@ const MESSAGES = {
     'tabs.insights': '心得',
     'tabs.prompts': '提示词',
     'tabs.generate': '生成数据包',
+    'tabs.activity': '动态',
+    'tabs.transcripts': '会话记录',
     'tabs.settings': '设置',
     'actions.refresh': '刷新',
     'actions.reindex': '重建索引',
@@ -397,6 +432,33 @@ const MESSAGES = {
     'generate.noPacket': '尚未生成数据包。',
     'generate.generated': '已生成提示词数据包: {path}',
     'generate.failed': '生成失败: {message}',
+    'activity.title': '实时动态',
+    'activity.clear': '清空',
+    'activity.empty': '暂无动态。Pipeline 邀请和消息会实时显示在这里。',
+    'activity.hub.connecting': '连接中…',
+    'activity.hub.connected': '实时',
+    'activity.hub.reconnecting': '重连中（第 {attempt} 次）…',
+    'activity.hub.closed': '已断开',
+    'activity.hub.disabled': '实时事件未开启: {message}',
+    'activity.hub.offline': '事件流不可用',
+    'activity.unread': '{count} 条未读站内消息',
+    'activity.event.Pipeline.InvitationReceived': '收到来自 {who} 的 Pipeline 邀请',
+    'activity.event.Pipeline.MessageReceived': '来自 {who} 的 Pipeline 消息',
+    'activity.event.Pipeline.SessionAccepted': 'Pipeline 会话已接受',
+    'activity.event.Pipeline.SessionRejected': 'Pipeline 会话被拒绝',
+    'activity.event.Pipeline.SessionEnded': 'Pipeline 会话已结束',
+    'activity.event.Inbox.UnreadCount': '站内信: {count} 条未读',
+    'transcript.listTitle': '会话记录',
+    'transcript.detailTitle': '对话',
+    'transcript.download': '下载 Markdown',
+    'transcript.empty': '暂无存档的对话。本机参与的 Pipeline 会话和房间消息会自动存档。',
+    'transcript.select': '请从左侧列表选择一个对话。',
+    'transcript.kind.session': '会话',
+    'transcript.kind.room': '房间',
+    'transcript.you': '我',
+    'transcript.count': '{count} 条消息',
+    'transcript.encrypted': '端到端加密',
+    'transcript.loadFailed': '加载会话记录失败: {message}',
     'settings.title': '云同步设置',
     'settings.ownerTitle': '需要先完成 Owner 注册',
     'settings.ownerHint': '看起来这个设备是在 Owner 注册前就安装了 CLI/Skill。请先让 Owner 完成平台注册，再在下方配置 API Key。',
@@ -507,6 +569,15 @@ const state = {
   fontSize: 'small',
   currentTab: 'diaries',
   hasGeneratedPacket: false,
+  activity: [],
+  activityUnseen: 0,
+  transcripts: [],
+  transcriptsLoaded: false,
+  currentTranscriptId: '',
+  transcriptMessages: [],
+  hubState: null,
+  streamOnline: false,
+  unreadCount: null,
 };
 
 const el = (id) => document.getElementById(id);
@@ -616,6 +687,14 @@ function escapeHtml(str) {
     .replaceAll("'", '&#039;');
 }
 
+// Search snippets mark hits with \u0002 ... \u0003; escape first so only
+// the highlight tags are trusted HTML.
+function renderSnippet(text) {
+  return escapeHtml(text || '')
+    .replaceAll('\u0002', '<mark>')
+    .replaceAll('\u0003', '</mark>');
+}
+
 function renderInlineMarkdown(text) {
   let out = escapeHtml(text || '');
   out = out.replace(/\[([^\]]+)\]\((https?:\/\/[^\s)]+)\)/g, '<a href="$2" target="_blank" rel="noopener noreferrer">$1</a>');
@@ -1327,6 +1406,17 @@ function switchTab(name) {
       .catch((err) => setStatusKey('insight.loadListFailed', { message: err.message }, true));
     return;
   }
+  if (tab === 'activity') {
+    state.activityUnseen = 0;
+    renderActivityBadge();
+    renderActivity();
+    return;
+  }
+  if (tab === 'transcripts') {
+    ensureTranscriptsLoaded()
+      .catch((err) => setStatusKey('transcript.loadFailed', { message: err.message }, true));
+    return;
+  }
   if (tab === 'settings') {
     maybeAutoTestSettingsConnectionOnEnter();
   }
@@ -2114,7 +2204,7 @@ function renderDiaryList(items) {
                 ${syncButton}
               </div>
               <div class="meta">${escapeHtml(item.date || t('common.na'))} · ${escapeHtml(item.filename)} ${defaultTag}</div>
-              <p class="item-preview">${escapeHtml(item.preview || '')}</p>
+              <p class="item-preview">${item.snippet ? renderSnippet(item.snippet) : escapeHtml(item.preview || '')}</p>
             </div>
           </div>
         </article>
@@ -2653,6 +2743,11 @@ function refreshLocalizedDynamicText() {
   applyDiaryViewModeButton();
   applyInsightViewModeButton();
   renderSettings();
+  renderActivity();
+  renderTranscriptList();
+  renderTranscriptMessages();
+  renderHubState();
+  renderInboxBadge();
 }
 
 function setLocale(locale, persist = true) {
@@ -2715,6 +2810,18 @@ function bindEvents() {
     applyFontSize(event.target.value, true);
   });
 
+  el('btnActivityClear').addEventListener('click', () => {
+    state.activity = [];
+    state.activityUnseen = 0;
+    renderActivityBadge();
+    renderActivity();
+  });
+
+  el('btnTranscriptReload').addEventListener('click', () => {
+    ensureTranscriptsLoaded(true)
+      .catch((err) => setStatusKey('transcript.loadFailed', { message: err.message }, true));
+  });
+
   el('btnReload').addEventListener('click', async () => {
     // Reindex first to scan diary directory for new files
     await reindex();
@@ -2927,6 +3034,234 @@ async function loadTowerStatus() {
   }
 }
 
+const ACTIVITY_LIMIT = 100;
+
+function initEventStream() {
+  if (typeof EventSource === 'undefined') {
+    return;
+  }
+  // EventSource reconnects on its own and resumes from the last event ID.
+  const source = new EventSource(apiPath('/events'));
+  source.onopen = () => {
+    state.streamOnline = true;
+    renderHubState();
+  };
+  source.onerror = () => {
+    state.streamOnline = false;
+    renderHubState();
+  };
+  source.onmessage = (message) => {
+    let event = null;
+    try {
+      event = JSON.parse(message.data);
+    } catch {
+      return;
+    }
+    if (event && event.type) {
+      handleLiveEvent(event);
+    }
+  };
+}
+
+function handleLiveEvent(event) {
+  if (event.type === 'Hub.State') {
+    state.hubState = event.data || null;
+    renderHubState();
+    return;
+  }
+  if (event.type === 'Inbox.UnreadCount') {
+    const count = Number(event.data?.count || 0);
+    const grew = state.unreadCount !== null && count > state.unreadCount;
+    state.unreadCount = count;
+    renderInboxBadge();
+    if (!grew) {
+      return;
+    }
+  }
+  if (state.activity.some((item) => item.id === event.id)) {
+    return;
+  }
+  state.activity.unshift(event);
+  if (state.activity.length > ACTIVITY_LIMIT) {
+    state.activity.length = ACTIVITY_LIMIT;
+  }
+  if (state.currentTab !== 'activity') {
+    state.activityUnseen += 1;
+    renderActivityBadge();
+  }
+  renderActivity();
+}
+
+function describeLiveEvent(event) {
+  const data = event.data;
+  const first = Array.isArray(data) ? data[0] : data;
+  const payload = first && typeof first === 'object' ? first : {};
+  const params = {
+    who: payload.senderBotName || payload.senderBotId || payload.initiatorBotName || payload.initiatorBotId
+      || payload.botName || payload.botId || t('common.na'),
+    count: payload.count ?? 0,
+  };
+  const key = `activity.event.${event.type}`;
+  const title = messageFor(state.locale, key) === key ? event.type : t(key, params);
+
+  let detail = '';
+  if (typeof payload.content === 'string') {
+    detail = payload.content;
+  } else if (event.type === 'Pipeline.SessionRejected' && Array.isArray(data)) {
+    detail = [data[0], data[1]].filter(Boolean).join(' · ');
+  } else if (payload.sessionToken) {
+    detail = payload.status ? `${payload.sessionToken} · ${payload.status}` : payload.sessionToken;
+  }
+  return { title, detail };
+}
+
+function renderActivity() {
+  const container = el('activityList');
+  if (!container) return;
+  if (!state.activity.length) {
+    container.innerHTML = `<div class="muted">${escapeHtml(t('activity.empty'))}</div>`;
+    return;
+  }
+  container.innerHTML = state.activity
+    .map((event) => {
+      const { title, detail } = describeLiveEvent(event);
+      const at = event.at ? new Date(event.at).toLocaleString(state.locale) : '';
+      return `
+        <article class="item activity-item">
+          <h3>${escapeHtml(title)}</h3>
+          <div class="meta">${escapeHtml(at)} · ${escapeHtml(event.type)}</div>
+          ${detail ? `<p>${escapeHtml(detail)}</p>` : ''}
+        </article>
+      `;
+    })
+    .join('');
+}
+
+async function ensureTranscriptsLoaded(forceReload = false) {
+  if (!forceReload && state.transcriptsLoaded) {
+    return;
+  }
+  const data = await api('/transcripts');
+  state.transcripts = data.items || [];
+  state.transcriptsLoaded = true;
+  renderTranscriptList();
+  if (state.currentTranscriptId) {
+    await selectTranscript(state.currentTranscriptId);
+  }
+}
+
+async function selectTranscript(id) {
+  const data = await api(`/transcripts/${encodeURIComponent(id)}`);
+  state.currentTranscriptId = id;
+  state.transcriptMessages = data.items || [];
+  renderTranscriptList();
+  renderTranscriptMessages();
+}
+
+function transcriptTitle(item) {
+  return `${t(`transcript.kind.${item.kind}`)} ${item.conversation}`;
+}
+
+function renderTranscriptList() {
+  const container = el('transcriptList');
+  if (!container) return;
+  if (!state.transcripts.length) {
+    container.innerHTML = `<div class="muted">${escapeHtml(t('transcript.empty'))}</div>`;
+    return;
+  }
+  container.innerHTML = state.transcripts
+    .map((item) => {
+      const active = item.conversation === state.currentTranscriptId ? 'active' : '';
+      const last = item.lastAt ? new Date(item.lastAt).toLocaleString(state.locale) : '';
+      return `
+        <article class="item ${active}" data-id="${escapeHtml(item.conversation)}">
+          <h3>${escapeHtml(transcriptTitle(item))}</h3>
+          <p>${escapeHtml((item.participants || []).join(', '))}</p>
+          <div class="meta">${escapeHtml(t('transcript.count', { count: item.messages }))} · ${escapeHtml(last)}</div>
+        </article>
+      `;
+    })
+    .join('');
+  container.querySelectorAll('.item').forEach((node) => {
+    node.addEventListener('click', () => {
+      selectTranscript(node.dataset.id)
+        .catch((err) => setStatusKey('transcript.loadFailed', { message: err.message }, true));
+    });
+  });
+}
+
+function renderTranscriptMessages() {
+  const container = el('transcriptMessages');
+  const download = el('btnTranscriptDownload');
+  if (!container) return;
+  const current = state.transcripts.find((item) => item.conversation === state.currentTranscriptId);
+  el('transcriptMeta').textContent = current ? transcriptTitle(current) : '';
+  download.hidden = !current;
+  if (!current) {
+    download.removeAttribute('href');
+    container.innerHTML = `<div class="muted">${escapeHtml(t('transcript.select'))}</div>`;
+    return;
+  }
+  download.href = apiPath(`/transcripts/${encodeURIComponent(current.conversation)}?format=markdown`);
+  container.innerHTML = state.transcriptMessages
+    .map((msg) => {
+      const speaker = msg.direction === 'sent' ? t('transcript.you') : (msg.senderName || msg.senderId || t('common.na'));
+      const at = msg.sentAt ? new Date(msg.sentAt).toLocaleString(state.locale) : '';
+      const lock = msg.encrypted ? ` · 🔒 ${t('transcript.encrypted')}` : '';
+      return `
+        <article class="item transcript-message ${msg.direction === 'sent' ? 'is-sent' : ''}">
+          <div class="meta">${escapeHtml(speaker)} · ${escapeHtml(at)}${escapeHtml(lock)}</div>
+          <p>${escapeHtml(msg.content)}</p>
+        </article>
+      `;
+    })
+    .join('');
+}
+
+function renderActivityBadge() {
+  const badge = el('activityBadge');
+  if (!badge) return;
+  badge.hidden = state.activityUnseen <= 0;
+  badge.textContent = state.activityUnseen > 99 ? '99+' : String(state.activityUnseen);
+}
+
+function renderHubState() {
+  const node = el('activityHub');
+  if (!node) return;
+  const hub = state.hubState || {};
+  let text = t('activity.hub.connecting');
+  let tone = '';
+  if (!state.streamOnline) {
+    text = t('activity.hub.offline');
+    tone = 'is-down';
+  } else if (hub.state === 'connected') {
+    text = t('activity.hub.connected');
+    tone = 'is-connected';
+  } else if (hub.state === 'reconnecting') {
+    text = t('activity.hub.reconnecting', { attempt: hub.attempt || 1 });
+    tone = 'is-reconnecting';
+  } else if (hub.state === 'closed') {
+    text = t('activity.hub.closed');
+    tone = 'is-down';
+  } else if (hub.state === 'disabled') {
+    text = t('activity.hub.disabled', { message: hub.error || '-' });
+    tone = 'is-down';
+  }
+  node.textContent = text;
+  node.title = hub.error || '';
+  node.className = `hub-state muted ${tone}`.trim();
+}
+
+function renderInboxBadge() {
+  const wrap = el('inboxStatus');
+  const count = el('inboxCount');
+  if (!wrap || !count) return;
+  const unread = Number(state.unreadCount || 0);
+  wrap.hidden = unread <= 0;
+  count.textContent = unread > 99 ? '99+' : String(unread);
+  wrap.title = t('activity.unread', { count: unread });
+}
+
 async function bootstrap() {
   setStatusKey('status.loading');
   state.insightsLoaded = false;
@@ -2959,6 +3294,9 @@ document.addEventListener('DOMContentLoaded', async () => {
   applyInsightViewModeButton();
   applyCalendarDiaryViewModeButton();
   renderCalendarDiaryDetail();
+  renderActivity();
+  renderHubState();
+  initEventStream();
   try {
     await bootstrap();
   } catch (err) {
@@ -19,51 +19,6 @@ import (
 	"moltbb-cli/internal/utils"
 )
 
-const schemaSQL = `
-CREATE TABLE IF NOT EXISTS prompts (
-  id TEXT PRIMARY KEY,
-  name TEXT NOT NULL,
-  description TEXT NOT NULL DEFAULT '',
-  content TEXT NOT NULL,
-  enabled INTEGER NOT NULL DEFAULT 1 CHECK (enabled IN (0,1)),
-  builtin INTEGER NOT NULL DEFAULT 0 CHECK (builtin IN (0,1)),
-  active INTEGER NOT NULL DEFAULT 0 CHECK (active IN (0,1)),
-  created_at TEXT NOT NULL,
-  updated_at TEXT NOT NULL
-);
-
-CREATE TABLE IF NOT EXISTS diary_entries (
-  id TEXT PRIMARY KEY,
-  rel_path TEXT NOT NULL UNIQUE,
-  filename TEXT NOT NULL,
-  date TEXT NOT NULL DEFAULT '',
-  title TEXT NOT NULL,
-  preview TEXT NOT NULL,
-  content_text TEXT NOT NULL DEFAULT '',
-  size INTEGER NOT NULL,
-  modified_at TEXT NOT NULL,
-  indexed_at TEXT NOT NULL
-);
-
-CREATE TABLE IF NOT EXISTS app_settings (
-  key TEXT PRIMARY KEY,
-  value TEXT NOT NULL,
-  updated_at TEXT NOT NULL
-);
-
-CREATE TABLE IF NOT EXISTS diary_day_defaults (
-  diary_date TEXT PRIMARY KEY,
-  diary_id TEXT NOT NULL,
-  is_manual INTEGER NOT NULL DEFAULT 0 CHECK (is_manual IN (0,1)),
-  updated_at TEXT NOT NULL
-);
-
-CREATE INDEX IF NOT EXISTS idx_diary_entries_date ON diary_entries(date);
-CREATE INDEX IF NOT EXISTS idx_diary_entries_modified_at ON diary_entries(modified_at);
-CREATE INDEX IF NOT EXISTS idx_diary_entries_content_text ON diary_entries(content_text);
-CREATE INDEX IF NOT EXISTS idx_diary_day_defaults_diary_id ON diary_day_defaults(diary_id);
-`
-
 var promptIDRe = regexp.MustCompile(`[^a-z0-9-]+`)
 
 type Prompt struct {
@@ -107,7 +62,23 @@ type legacyPromptCatalog struct {
 	Prompts        []Prompt `json:"prompts"`
 }
 
+// OpenDB opens local.db and applies pending migrations. It fails with
+// ErrSchemaTooNew when the database was migrated by a newer build.
 func OpenDB(dbPath string) (*sql.DB, error) {
+	db, err := OpenRawDB(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := Migrate(db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// OpenRawDB opens local.db with the usual pragmas but without migrating,
+// for status checks and maintenance.
+func OpenRawDB(dbPath string) (*sql.DB, error) {
 	if strings.TrimSpace(dbPath) == "" {
 		return nil, errors.New("db path is required")
 	}
@@ -129,55 +100,10 @@ func OpenDB(dbPath string) (*sql.DB, error) {
 		_ = db.Close()
 		return nil, fmt.Errorf("set sqlite pragma busy_timeout: %w", err)
 	}
-	if _, err := db.Exec(schemaSQL); err != nil {
-		_ = db.Close()
-		return nil, fmt.Errorf("initialize sqlite schema: %w", err)
-	}
-	if err := ensureDiaryEntriesSchema(db); err != nil {
-		_ = db.Close()
-		return nil, err
-	}
-	if err := ensureDiaryDayDefaultsSchema(db); err != nil {
-		_ = db.Close()
-		return nil, err
-	}
-
 	return db, nil
 }
 
-func ensureDiaryDayDefaultsSchema(db *sql.DB) error {
-	if _, err := db.Exec(`
-CREATE TABLE IF NOT EXISTS diary_day_defaults (
-  diary_date TEXT PRIMARY KEY,
-  diary_id TEXT NOT NULL,
-  is_manual INTEGER NOT NULL DEFAULT 0 CHECK (is_manual IN (0,1)),
-  updated_at TEXT NOT NULL
-)`); err != nil {
-		return fmt.Errorf("create diary_day_defaults table: %w", err)
-	}
-	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_diary_day_defaults_diary_id ON diary_day_defaults(diary_id)`); err != nil {
-		return fmt.Errorf("create diary_day_defaults index: %w", err)
-	}
-	return nil
-}
-
-func ensureDiaryEntriesSchema(db *sql.DB) error {
-	hasContentText, err := hasColumn(db, "diary_entries", "content_text")
-	if err != nil {
-		return err
-	}
-	if !hasContentText {
-		if _, err := db.Exec(`ALTER TABLE diary_entries ADD COLUMN content_text TEXT NOT NULL DEFAULT ''`); err != nil {
-			return fmt.Errorf("add diary_entries.content_text: %w", err)
-		}
-	}
-	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_diary_entries_content_text ON diary_entries(content_text)`); err != nil {
-		return fmt.Errorf("create diary_entries content_text index: %w", err)
-	}
-	return nil
-}
-
-func hasColumn(db *sql.DB, tableName, columnName string) (bool, error) {
+func hasColumn(db dbtx, tableName, columnName string) (bool, error) {
 	query := fmt.Sprintf("PRAGMA table_info(%s)", tableName)
 	rows, err := db.Query(query)
 	if err != nil {
@@ -823,38 +749,3 @@ func boolToInt(value bool) int {
 	}
 	return 0
 }
-
-// SaveDiaryEntry saves a diary entry to local database
-func SaveDiaryEntry(db *sql.DB, date, title, summary, content string) error {
-	if db == nil {
-		return errors.New("db is required")
-	}
-
-	// Check if entry exists
-	var exists bool
-	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM diary_entries WHERE date = ?)", date).Scan(&exists)
-	if err != nil {
-		return fmt.Errorf("check diary exists: %w", err)
-	}
-
-	if exists {
-		// Update
-		_, err = db.Exec(`
-			UPDATE diary_entries 
-			SET title = ?, summary = ?, content_text = ?, updated_at = datetime('now')
-			WHERE date = ?`,
-			title, summary, content, date)
-	} else {
-		// Insert
-		_, err = db.Exec(`
-			INSERT INTO diary_entries (date, title, summary, content_text)
-			VALUES (?, ?, ?, ?)`,
-			date, title, summary, content)
-	}
-
-	if err != nil {
-		return fmt.Errorf("save diary: %w", err)
-	}
-
-	return nil
-}
@@ -107,3 +107,33 @@ VALUES('default', 'Default Diary Prompt', '', ?, 1, 1, 1, '2026-02-19T00:00:00Z'
 		t.Fatal("expected legacy default prompt to be upgraded to builtin long template")
 	}
 }
+
+func TestSyncRecordsRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	db, err := OpenDB(filepath.Join(t.TempDir(), "local.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+
+	if err := SaveSyncRecord(db, diary.SyncRecord{DiaryDate: "2026-03-01", ContentHash: "h1", RemoteDiaryID: "r1"}); err != nil {
+		t.Fatalf("save sync record: %v", err)
+	}
+	// An empty remote ID must not erase the known one.
+	if err := SaveSyncRecord(db, diary.SyncRecord{DiaryDate: "2026-03-01", ContentHash: "h2", RemoteHash: "t2"}); err != nil {
+		t.Fatalf("update sync record: %v", err)
+	}
+
+	records, err := LoadSyncRecords(db)
+	if err != nil {
+		t.Fatalf("load sync records: %v", err)
+	}
+	record, ok := records["2026-03-01"]
+	if !ok {
+		t.Fatalf("expected sync record for 2026-03-01")
+	}
+	if record.ContentHash != "h2" || record.RemoteHash != "t2" || record.RemoteDiaryID != "r1" {
+		t.Fatalf("unexpected sync record: %+v", record)
+	}
+}
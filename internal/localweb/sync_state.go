@@ -0,0 +1,64 @@
+package localweb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"moltbb-cli/internal/diary"
+)
+
+// LoadSyncRecords returns the last synced state of every diary date keyed by date.
+func LoadSyncRecords(db *sql.DB) (map[string]diary.SyncRecord, error) {
+	if db == nil {
+		return nil, errors.New("db is required")
+	}
+	rows, err := db.Query(`SELECT diary_date, content_hash, remote_hash, remote_diary_id, synced_at FROM diary_sync_state`)
+	if err != nil {
+		return nil, fmt.Errorf("query diary sync state: %w", err)
+	}
+	defer rows.Close()
+
+	records := make(map[string]diary.SyncRecord, 64)
+	for rows.Next() {
+		var record diary.SyncRecord
+		if err := rows.Scan(&record.DiaryDate, &record.ContentHash, &record.RemoteHash, &record.RemoteDiaryID, &record.SyncedAt); err != nil {
+			return nil, fmt.Errorf("scan diary sync state: %w", err)
+		}
+		records[record.DiaryDate] = record
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read diary sync state rows: %w", err)
+	}
+	return records, nil
+}
+
+// SaveSyncRecord stores the synced hashes and remote diary ID for one date.
+func SaveSyncRecord(db *sql.DB, record diary.SyncRecord) error {
+	if db == nil {
+		return errors.New("db is required")
+	}
+	date := strings.TrimSpace(record.DiaryDate)
+	if date == "" {
+		return errors.New("diary date is required")
+	}
+	if strings.TrimSpace(record.SyncedAt) == "" {
+		record.SyncedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	_, err := db.Exec(`
+INSERT INTO diary_sync_state(diary_date, content_hash, remote_hash, remote_diary_id, synced_at)
+VALUES(?, ?, ?, ?, ?)
+ON CONFLICT(diary_date) DO UPDATE SET
+  content_hash = excluded.content_hash,
+  remote_hash = excluded.remote_hash,
+  remote_diary_id = CASE WHEN excluded.remote_diary_id = '' THEN diary_sync_state.remote_diary_id ELSE excluded.remote_diary_id END,
+  synced_at = excluded.synced_at
+`, date, record.ContentHash, record.RemoteHash, strings.TrimSpace(record.RemoteDiaryID), record.SyncedAt)
+	if err != nil {
+		return fmt.Errorf("save diary sync state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,390 @@
+package localweb
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"moltbb-cli/internal/api"
+)
+
+// Transcript kinds and message directions.
+const (
+	TranscriptSession = "session"
+	TranscriptRoom    = "room"
+
+	TranscriptSent     = "sent"
+	TranscriptReceived = "received"
+)
+
+// transcriptTimeLayout has a fixed width so sent_at sorts as text.
+const transcriptTimeLayout = "2006-01-02T15:04:05.000Z"
+
+// transcriptExcerptMessageRunes caps each message quoted in a digest.
+const transcriptExcerptMessageRunes = 280
+
+// ErrTranscriptNotFound is returned when no messages are archived for a
+// session token or room code.
+var ErrTranscriptNotFound = errors.New("transcript not found")
+
+// TranscriptMessage is one archived pipeline session or room message.
+// Encrypted messages are stored as the decrypted text.
+type TranscriptMessage struct {
+	ID           int64     `json:"id"`
+	Kind         string    `json:"kind"`
+	Conversation string    `json:"conversation"`
+	Direction    string    `json:"direction"`
+	SenderID     string    `json:"senderId,omitempty"`
+	SenderName   string    `json:"senderName,omitempty"`
+	PeerID       string    `json:"peerId,omitempty"`
+	Content      string    `json:"content"`
+	Encrypted    bool      `json:"encrypted,omitempty"`
+	SentAt       time.Time `json:"sentAt"`
+}
+
+// Speaker is the name shown for the message author.
+func (m TranscriptMessage) Speaker() string {
+	switch {
+	case m.SenderName != "":
+		return m.SenderName
+	case m.Direction == TranscriptSent:
+		return "You"
+	case m.SenderID != "":
+		return m.SenderID
+	default:
+		return "unknown"
+	}
+}
+
+// Transcript summarizes the archive of one session or room.
+type Transcript struct {
+	Kind         string    `json:"kind"`
+	Conversation string    `json:"conversation"`
+	Participants []string  `json:"participants"`
+	Messages     int       `json:"messages"`
+	FirstAt      time.Time `json:"firstAt"`
+	LastAt       time.Time `json:"lastAt"`
+}
+
+// RoomTranscriptMessage converts a message received in a room. The bot's
+// own messages are archived when sent, stamped with the local send time,
+// so their echo from the hub or a backlog is skipped: ok is false.
+func RoomTranscriptMessage(roomCode string, msg api.RoomMessageDto, selfBotID string) (m TranscriptMessage, ok bool) {
+	if selfBotID != "" && msg.SenderBotId == selfBotID {
+		return TranscriptMessage{}, false
+	}
+	if msg.RoomCode != "" {
+		roomCode = msg.RoomCode
+	}
+	sentAt, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(msg.SentAt))
+	if err != nil {
+		sentAt = time.Now()
+	}
+	return TranscriptMessage{
+		Kind:         TranscriptRoom,
+		Conversation: roomCode,
+		Direction:    TranscriptReceived,
+		SenderID:     msg.SenderBotId,
+		SenderName:   msg.SenderBotName,
+		Content:      msg.Content,
+		SentAt:       sentAt,
+	}, true
+}
+
+// SaveTranscriptMessage archives m and reports whether it was new. A
+// message already archived with the same sender, time and content is
+// skipped.
+func SaveTranscriptMessage(db *sql.DB, m TranscriptMessage) (bool, error) {
+	if db == nil {
+		return false, errors.New("db is required")
+	}
+	if m.Kind != TranscriptSession && m.Kind != TranscriptRoom {
+		return false, fmt.Errorf("invalid transcript kind %q", m.Kind)
+	}
+	if m.Direction != TranscriptSent && m.Direction != TranscriptReceived {
+		return false, fmt.Errorf("invalid transcript direction %q", m.Direction)
+	}
+	if strings.TrimSpace(m.Conversation) == "" {
+		return false, errors.New("session token or room code is required")
+	}
+	if m.SentAt.IsZero() {
+		m.SentAt = time.Now()
+	}
+	sentAt := m.SentAt.UTC().Format(transcriptTimeLayout)
+	sum := sha256.Sum256([]byte(m.Direction + "\x00" + m.SenderID + "\x00" + sentAt + "\x00" + m.Content))
+
+	res, err := db.Exec(`
+INSERT OR IGNORE INTO transcript_messages(kind, conversation, direction, sender_id, sender_name, peer_id, content, encrypted, sent_at, dedupe_key)
+VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`, m.Kind, strings.TrimSpace(m.Conversation), m.Direction, m.SenderID, m.SenderName, m.PeerID, m.Content, boolToInt(m.Encrypted), sentAt, hex.EncodeToString(sum[:16]))
+	if err != nil {
+		return false, fmt.Errorf("insert transcript message: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ListTranscripts returns archived conversations, most recently active
+// first.
+func ListTranscripts(db *sql.DB, limit int) ([]Transcript, error) {
+	if db == nil {
+		return nil, errors.New("db is required")
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := db.Query(`
+SELECT kind, conversation, COUNT(*), MIN(sent_at), MAX(sent_at),
+       COALESCE(group_concat(DISTINCT CASE WHEN sender_name != '' THEN sender_name ELSE sender_id END), '')
+FROM transcript_messages
+GROUP BY conversation
+ORDER BY MAX(sent_at) DESC
+LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query transcripts: %w", err)
+	}
+	defer rows.Close()
+
+	items := []Transcript{}
+	for rows.Next() {
+		var t Transcript
+		var firstAt, lastAt, participants string
+		if err := rows.Scan(&t.Kind, &t.Conversation, &t.Messages, &firstAt, &lastAt, &participants); err != nil {
+			return nil, fmt.Errorf("scan transcript: %w", err)
+		}
+		t.FirstAt, _ = time.Parse(transcriptTimeLayout, firstAt)
+		t.LastAt, _ = time.Parse(transcriptTimeLayout, lastAt)
+		t.Participants = splitParticipants(participants)
+		items = append(items, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read transcripts: %w", err)
+	}
+	return items, nil
+}
+
+// GetTranscript returns the summary and messages, oldest first, of a
+// session token or room code.
+func GetTranscript(db *sql.DB, conversation string) (Transcript, []TranscriptMessage, error) {
+	if db == nil {
+		return Transcript{}, nil, errors.New("db is required")
+	}
+	conversation = strings.TrimSpace(conversation)
+	msgs, err := queryTranscriptMessages(db, `WHERE conversation = ? ORDER BY sent_at, id`, conversation)
+	if err != nil {
+		return Transcript{}, nil, err
+	}
+	if len(msgs) == 0 {
+		return Transcript{}, nil, fmt.Errorf("%w: %s", ErrTranscriptNotFound, conversation)
+	}
+	return summarizeTranscript(msgs), msgs, nil
+}
+
+// TranscriptMessagesBetween returns every archived message sent in
+// [from, to), oldest first.
+func TranscriptMessagesBetween(db *sql.DB, from, to time.Time) ([]TranscriptMessage, error) {
+	if db == nil {
+		return nil, errors.New("db is required")
+	}
+	return queryTranscriptMessages(db, `WHERE sent_at >= ? AND sent_at < ? ORDER BY sent_at, id`,
+		from.UTC().Format(transcriptTimeLayout), to.UTC().Format(transcriptTimeLayout))
+}
+
+func queryTranscriptMessages(db *sql.DB, where string, args ...any) ([]TranscriptMessage, error) {
+	rows, err := db.Query(`
+SELECT id, kind, conversation, direction, sender_id, sender_name, peer_id, content, encrypted, sent_at
+FROM transcript_messages `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query transcript messages: %w", err)
+	}
+	defer rows.Close()
+
+	var msgs []TranscriptMessage
+	for rows.Next() {
+		var m TranscriptMessage
+		var encrypted int
+		var sentAt string
+		if err := rows.Scan(&m.ID, &m.Kind, &m.Conversation, &m.Direction, &m.SenderID, &m.SenderName, &m.PeerID, &m.Content, &encrypted, &sentAt); err != nil {
+			return nil, fmt.Errorf("scan transcript message: %w", err)
+		}
+		m.Encrypted = encrypted == 1
+		m.SentAt, _ = time.Parse(transcriptTimeLayout, sentAt)
+		msgs = append(msgs, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read transcript messages: %w", err)
+	}
+	return msgs, nil
+}
+
+func summarizeTranscript(msgs []TranscriptMessage) Transcript {
+	t := Transcript{
+		Kind:         msgs[0].Kind,
+		Conversation: msgs[0].Conversation,
+		Messages:     len(msgs),
+		FirstAt:      msgs[0].SentAt,
+		LastAt:       msgs[len(msgs)-1].SentAt,
+		Participants: []string{},
+	}
+	seen := make(map[string]bool)
+	for _, m := range msgs {
+		name := m.SenderName
+		if name == "" {
+			name = m.SenderID
+		}
+		if name != "" && !seen[name] {
+			seen[name] = true
+			t.Participants = append(t.Participants, name)
+		}
+	}
+	return t
+}
+
+func splitParticipants(joined string) []string {
+	out := []string{}
+	for _, p := range strings.Split(joined, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Title names a transcript for headings.
+func (t Transcript) Title() string {
+	if t.Kind == TranscriptRoom {
+		return "Room " + t.Conversation
+	}
+	return "Session " + t.Conversation
+}
+
+// RenderTranscriptMarkdown formats a transcript as a Markdown document,
+// with times in loc.
+func RenderTranscriptMarkdown(t Transcript, msgs []TranscriptMessage, loc *time.Location) string {
+	if loc == nil {
+		loc = time.Local
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", t.Title())
+	if len(t.Participants) > 0 {
+		fmt.Fprintf(&b, "- Participants: %s\n", strings.Join(t.Participants, ", "))
+	}
+	fmt.Fprintf(&b, "- Messages: %d\n", t.Messages)
+	fmt.Fprintf(&b, "- From: %s\n", t.FirstAt.In(loc).Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "- To: %s\n", t.LastAt.In(loc).Format("2006-01-02 15:04:05"))
+
+	day := ""
+	for _, m := range msgs {
+		at := m.SentAt.In(loc)
+		if d := at.Format("2006-01-02"); d != day {
+			day = d
+			fmt.Fprintf(&b, "\n## %s\n", day)
+		}
+		lock := ""
+		if m.Encrypted {
+			lock = " 🔒"
+		}
+		fmt.Fprintf(&b, "\n**%s** · %s%s\n\n", m.Speaker(), at.Format("15:04:05"), lock)
+		b.WriteString(quoteMarkdown(m.Content))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func quoteMarkdown(s string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// TranscriptDigest condenses messages into a plain-text excerpt of at most
+// maxBytes for a diary prompt packet, grouped by conversation. It returns
+// "" when there are no messages.
+func TranscriptDigest(msgs []TranscriptMessage, loc *time.Location, maxBytes int) string {
+	if len(msgs) == 0 {
+		return ""
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	var order []string
+	groups := make(map[string][]TranscriptMessage)
+	for _, m := range msgs {
+		if _, ok := groups[m.Conversation]; !ok {
+			order = append(order, m.Conversation)
+		}
+		groups[m.Conversation] = append(groups[m.Conversation], m)
+	}
+
+	var b strings.Builder
+	written := 0
+	for _, conv := range order {
+		group := groups[conv]
+		count := fmt.Sprintf("%d messages", len(group))
+		if len(group) == 1 {
+			count = "1 message"
+		}
+		header := fmt.Sprintf("%s (%s)\n", summarizeTranscript(group).Title(), count)
+		if b.Len() > 0 {
+			header = "\n" + header
+		}
+		if b.Len()+len(header) > maxBytes {
+			break
+		}
+		b.WriteString(header)
+		for _, m := range group {
+			line := fmt.Sprintf("- %s %s: %s\n", m.SentAt.In(loc).Format("15:04"), m.Speaker(), excerptText(m.Content))
+			if b.Len()+len(line) > maxBytes {
+				break
+			}
+			b.WriteString(line)
+			written++
+		}
+	}
+	if rest := len(msgs) - written; rest > 0 {
+		fmt.Fprintf(&b, "… %d more message(s) not shown\n", rest)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func excerptText(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= transcriptExcerptMessageRunes {
+		return s
+	}
+	return string([]rune(s)[:transcriptExcerptMessageRunes]) + "…"
+}
+
+// transcriptExcerptBytes bounds the pipeline excerpt in a prompt packet.
+const transcriptExcerptBytes = 6000
+
+// DayTranscriptExcerpt returns the digest of the pipeline and room
+// messages archived on date (YYYY-MM-DD in loc) for the prompt packet's
+// memory section, or "" when there are none.
+func DayTranscriptExcerpt(db *sql.DB, date string, loc *time.Location) (string, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	day, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return "", fmt.Errorf("invalid date %q: %w", date, err)
+	}
+	msgs, err := TranscriptMessagesBetween(db, day, day.AddDate(0, 0, 1))
+	if err != nil || len(msgs) == 0 {
+		return "", err
+	}
+	return "Pipeline conversations archived on " + date + ":\n\n" + TranscriptDigest(msgs, loc, transcriptExcerptBytes), nil
+}
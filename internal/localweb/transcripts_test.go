@@ -0,0 +1,117 @@
+package localweb
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"moltbb-cli/internal/api"
+)
+
+func TestTranscriptArchive(t *testing.T) {
+	t.Parallel()
+
+	db, err := OpenDB(filepath.Join(t.TempDir(), "local.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+
+	start := time.Date(2026, 3, 1, 21, 0, 0, 0, time.UTC)
+	msgs := []TranscriptMessage{
+		{Kind: TranscriptSession, Conversation: "sess-1", Direction: TranscriptSent, PeerID: "bot-b", Content: "hello\n\nsecond line", Encrypted: true, SentAt: start},
+		{Kind: TranscriptSession, Conversation: "sess-1", Direction: TranscriptReceived, SenderID: "bot-b", SenderName: "Bee", Content: "hi", SentAt: start.Add(time.Minute)},
+		{Kind: TranscriptRoom, Conversation: "room-ab12", Direction: TranscriptReceived, SenderID: "bot-c", Content: "deploy done", SentAt: start.Add(2 * time.Minute)},
+	}
+	for _, m := range msgs {
+		if added, err := SaveTranscriptMessage(db, m); err != nil || !added {
+			t.Fatalf("save %q: added=%v err=%v", m.Content, added, err)
+		}
+	}
+	// A room backlog replayed after a reconnect is not archived twice.
+	if added, err := SaveTranscriptMessage(db, msgs[2]); err != nil || added {
+		t.Fatalf("duplicate saved: added=%v err=%v", added, err)
+	}
+	if _, err := SaveTranscriptMessage(db, TranscriptMessage{Kind: "dm", Conversation: "x", Direction: TranscriptSent}); err == nil {
+		t.Fatal("invalid kind accepted")
+	}
+
+	list, err := ListTranscripts(db, 10)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(list) != 2 || list[0].Conversation != "room-ab12" || list[1].Messages != 2 {
+		t.Fatalf("list = %+v", list)
+	}
+
+	tr, got, err := GetTranscript(db, "sess-1")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if len(got) != 2 || !got[0].Encrypted || got[1].Speaker() != "Bee" || !tr.LastAt.Equal(start.Add(time.Minute)) {
+		t.Fatalf("transcript = %+v %+v", tr, got)
+	}
+	md := RenderTranscriptMarkdown(tr, got, time.UTC)
+	for _, want := range []string{"# Session sess-1", "## 2026-03-01", "**You** · 21:00:00 🔒", "> hello\n>\n> second line", "**Bee** · 21:01:00"} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("markdown lacks %q:\n%s", want, md)
+		}
+	}
+	if _, _, err := GetTranscript(db, "nope"); !errors.Is(err, ErrTranscriptNotFound) {
+		t.Fatalf("missing transcript: %v", err)
+	}
+
+	day, err := TranscriptMessagesBetween(db, start, start.Add(90*time.Second))
+	if err != nil || len(day) != 2 {
+		t.Fatalf("between = %d, %v", len(day), err)
+	}
+	all, _ := TranscriptMessagesBetween(db, start, start.Add(time.Hour))
+	digest := TranscriptDigest(all, time.UTC, 4096)
+	if !strings.Contains(digest, "Session sess-1 (2 messages)\n- 21:00 You: hello second line") || !strings.Contains(digest, "Room room-ab12 (1 message)") {
+		t.Fatalf("digest:\n%s", digest)
+	}
+	if short := TranscriptDigest(all, time.UTC, 60); !strings.Contains(short, "more message(s) not shown") {
+		t.Fatalf("truncated digest:\n%s", short)
+	}
+}
+
+func TestRoomEchoOfSentMessageIsNotArchived(t *testing.T) {
+	t.Parallel()
+
+	db, err := OpenDB(filepath.Join(t.TempDir(), "local.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+
+	// The send is archived with the local clock...
+	sent := TranscriptMessage{Kind: TranscriptRoom, Conversation: "room-ab12", Direction: TranscriptSent, SenderID: "bot-a", Content: "on it", SentAt: time.Now()}
+	if added, err := SaveTranscriptMessage(db, sent); err != nil || !added {
+		t.Fatalf("save send: added=%v err=%v", added, err)
+	}
+	// ...and its echo carries the server's time.
+	echo := api.RoomMessageDto{RoomCode: "room-ab12", SenderBotId: "bot-a", SenderBotName: "Ay", Content: "on it", SentAt: "2026-03-01T21:00:00.5Z"}
+	if m, ok := RoomTranscriptMessage("room-ab12", echo, "bot-a"); ok {
+		if _, err := SaveTranscriptMessage(db, m); err != nil {
+			t.Fatalf("save echo: %v", err)
+		}
+	}
+	other := api.RoomMessageDto{SenderBotId: "bot-c", Content: "thanks", SentAt: "2026-03-01T21:00:01Z"}
+	m, ok := RoomTranscriptMessage("room-ab12", other, "bot-a")
+	if !ok || m.Direction != TranscriptReceived || m.Conversation != "room-ab12" || !m.SentAt.Equal(time.Date(2026, 3, 1, 21, 0, 1, 0, time.UTC)) {
+		t.Fatalf("message from another bot = %+v ok=%v", m, ok)
+	}
+	if _, err := SaveTranscriptMessage(db, m); err != nil {
+		t.Fatalf("save other: %v", err)
+	}
+
+	_, got, err := GetTranscript(db, "room-ab12")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if len(got) != 2 || got[0].Direction != TranscriptReceived || got[1].Direction != TranscriptSent {
+		t.Fatalf("room transcript = %+v", got)
+	}
+}
@@ -0,0 +1,142 @@
+// Package moderate runs a structured discussion in a room: it posts the
+// topics of an agenda in order, gives the floor to one participant at a
+// time within time boxes, and summarizes decisions and contributions.
+package moderate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Defaults applied to zero agenda settings.
+const (
+	DefaultTurnSeconds = 120
+	DefaultRounds      = 1
+	// MaxParticipants is the largest room; further bots are not addressed.
+	MaxParticipants = 10
+)
+
+// Agenda is a moderated discussion, read from a YAML file.
+type Agenda struct {
+	Title string `yaml:"title"`
+	// Intro is posted before the first topic.
+	Intro string `yaml:"intro,omitempty"`
+	// TurnSeconds and Rounds apply to topics that do not set their own.
+	TurnSeconds int     `yaml:"turn_seconds,omitempty"`
+	Rounds      int     `yaml:"rounds,omitempty"`
+	Topics      []Topic `yaml:"topics"`
+}
+
+// Topic is one agenda item.
+type Topic struct {
+	Title  string `yaml:"title"`
+	Prompt string `yaml:"prompt,omitempty"`
+	// Minutes is the time box of the whole topic; 0 means the turns alone
+	// bound it.
+	Minutes     int `yaml:"minutes,omitempty"`
+	TurnSeconds int `yaml:"turn_seconds,omitempty"`
+	// Rounds is how often every participant gets the floor.
+	Rounds int `yaml:"rounds,omitempty"`
+	// Participants limits the topic to these bots, by ID or name.
+	Participants []string `yaml:"participants,omitempty"`
+}
+
+// LoadAgenda reads and validates the agenda at path.
+func LoadAgenda(path string) (Agenda, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Agenda{}, fmt.Errorf("read agenda: %w", err)
+	}
+	var a Agenda
+	if err := yaml.Unmarshal(data, &a); err != nil {
+		return Agenda{}, fmt.Errorf("parse agenda %s: %w", path, err)
+	}
+	if err := a.Normalize(); err != nil {
+		return Agenda{}, fmt.Errorf("agenda %s: %w", path, err)
+	}
+	return a, nil
+}
+
+// Normalize trims the agenda, fills in defaults and checks the topics.
+func (a *Agenda) Normalize() error {
+	a.Title = strings.TrimSpace(a.Title)
+	if a.Title == "" {
+		a.Title = "Room discussion"
+	}
+	if a.TurnSeconds < 0 || a.Rounds < 0 {
+		return errors.New("turn_seconds and rounds must not be negative")
+	}
+	if a.TurnSeconds == 0 {
+		a.TurnSeconds = DefaultTurnSeconds
+	}
+	if a.Rounds == 0 {
+		a.Rounds = DefaultRounds
+	}
+	if len(a.Topics) == 0 {
+		return errors.New("topics: at least one topic is required")
+	}
+	for i := range a.Topics {
+		t := &a.Topics[i]
+		t.Title = strings.TrimSpace(t.Title)
+		if t.Title == "" {
+			return fmt.Errorf("topics[%d]: title is required", i)
+		}
+		if t.Minutes < 0 || t.TurnSeconds < 0 || t.Rounds < 0 {
+			return fmt.Errorf("topics[%d]: minutes, turn_seconds and rounds must not be negative", i)
+		}
+		if t.TurnSeconds == 0 {
+			t.TurnSeconds = a.TurnSeconds
+		}
+		if t.Rounds == 0 {
+			t.Rounds = a.Rounds
+		}
+		t.Participants = trimList(t.Participants)
+	}
+	return nil
+}
+
+// TurnTimeout is how long a participant holds the floor.
+func (t Topic) TurnTimeout() time.Duration {
+	return time.Duration(t.TurnSeconds) * time.Second
+}
+
+// TimeBox is the time the topic may take with n speakers: its minutes
+// when set, otherwise every turn used in full.
+func (t Topic) TimeBox(n int) time.Duration {
+	if t.Minutes > 0 {
+		return time.Duration(t.Minutes) * time.Minute
+	}
+	return time.Duration(n*t.Rounds) * t.TurnTimeout()
+}
+
+// Speakers returns the participants addressed on the topic, in room order.
+func (t Topic) Speakers(all []Participant) []Participant {
+	if len(t.Participants) == 0 {
+		return all
+	}
+	var out []Participant
+	for _, p := range all {
+		for _, want := range t.Participants {
+			if strings.EqualFold(want, p.ID) || strings.EqualFold(want, p.Name) {
+				out = append(out, p)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func trimList(in []string) []string {
+	out := in[:0]
+	for _, s := range in {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
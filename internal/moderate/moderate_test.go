@@ -0,0 +1,248 @@
+package moderate
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRoom answers every turn from a script: per bot, the messages sent
+// each time it is given the floor. An empty turn is silence, which lets the
+// turn time out.
+type fakeRoom struct {
+	t        *testing.T
+	now      time.Time
+	step     time.Duration
+	expires  time.Time
+	extended []int
+	posts    []string
+	people   []Participant
+	script   map[string][][]Message
+	msgs     chan Message
+	silent   bool
+}
+
+func (r *fakeRoom) Post(ctx context.Context, text string) error {
+	r.posts = append(r.posts, text)
+	r.now = r.now.Add(r.step)
+	r.silent = false
+	for _, p := range r.people {
+		if !strings.HasPrefix(text, "🎤 @"+p.Label()+",") {
+			continue
+		}
+		var turn []Message
+		if turns := r.script[p.ID]; len(turns) > 0 {
+			turn, r.script[p.ID] = turns[0], turns[1:]
+		}
+		r.silent = len(turn) == 0
+		for _, msg := range turn {
+			r.msgs <- msg
+		}
+	}
+	return nil
+}
+
+func (r *fakeRoom) Participants(context.Context) ([]Participant, error) {
+	return append([]Participant{{ID: "mod", Name: "Moderator"}}, r.people...), nil
+}
+
+func (r *fakeRoom) ExpiresAt(context.Context) (time.Time, error) { return r.expires, nil }
+
+func (r *fakeRoom) Extend(ctx context.Context, minutes int) error {
+	r.extended = append(r.extended, minutes)
+	r.expires = r.expires.Add(time.Duration(minutes) * time.Minute)
+	return nil
+}
+
+func (r *fakeRoom) after(d time.Duration) <-chan time.Time {
+	c := make(chan time.Time, 1)
+	if r.silent {
+		r.now = r.now.Add(d)
+		c <- r.now
+	}
+	return c
+}
+
+func newModerator(agenda Agenda, room *fakeRoom) *Moderator {
+	if err := agenda.Normalize(); err != nil {
+		room.t.Fatalf("agenda: %v", err)
+	}
+	return &Moderator{
+		Agenda: agenda,
+		Room:   room,
+		Self:   "mod",
+		now:    func() time.Time { return room.now },
+		after:  room.after,
+	}
+}
+
+func say(id, name, text string) Message {
+	return Message{SenderID: id, SenderName: name, Content: text}
+}
+
+func TestModeratorTurnsDecisionsAndExtension(t *testing.T) {
+	start := time.Date(2026, 3, 1, 14, 0, 0, 0, time.UTC)
+	room := &fakeRoom{
+		t:       t,
+		now:     start,
+		step:    time.Second,
+		expires: start.Add(3*time.Minute + 10*time.Second),
+		people:  []Participant{{ID: "a", Name: "Ant"}, {ID: "b", Name: "Bee"}},
+		msgs:    make(chan Message, 16),
+		script: map[string][][]Message{
+			"a": {{say("b", "Bee", "me first!"), say("a", "Ant", "We should ship.\nDecision: ship on Friday")}},
+			"b": {{say("b", "Bee", "pass")}, nil},
+		},
+	}
+	agenda := Agenda{
+		Title: "Release sync",
+		Topics: []Topic{
+			{Title: "Release date", Minutes: 5},
+			{Title: "Follow-ups", Participants: []string{"bee"}, TurnSeconds: 30},
+		},
+	}
+
+	summary, err := newModerator(agenda, room).Run(context.Background(), "room-ab12", room.msgs)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	if len(room.extended) != 1 || room.extended[0] != 4 {
+		t.Fatalf("extended = %v, want [4]", room.extended)
+	}
+	joined := strings.Join(room.posts, "\n")
+	for _, want := range []string{
+		"Participants: @Ant, @Bee",
+		"📋 Topic 1/2: Release date",
+		"🎤 @Ant, you have the floor",
+		"⏸ @Bee, please wait for your turn; @Ant has the floor.",
+		"1. ship on Friday (Ant)",
+		"⏭ @Bee, your turn is over.",
+		"🏁",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("posts lack %q:\n%s", want, joined)
+		}
+	}
+	if strings.Count(joined, "🎤 @Ant") != 1 || strings.Count(joined, "🎤 @Bee") != 2 {
+		t.Fatalf("turns:\n%s", joined)
+	}
+
+	if len(summary.Topics) != 2 || len(summary.Topics[0].Decisions) != 1 || summary.Topics[0].Decisions[0].By.ID != "a" {
+		t.Fatalf("topics = %+v", summary.Topics)
+	}
+	want := map[string]Contribution{
+		"a": {Participant: Participant{ID: "a", Name: "Ant"}, Turns: 1, Messages: 1, Words: 7},
+		"b": {Participant: Participant{ID: "b", Name: "Bee"}, Turns: 1, Messages: 2, Words: 3, Passes: 1, Timeouts: 1, OutOfTurn: 1},
+	}
+	if len(summary.Contributions) != 2 {
+		t.Fatalf("contributions = %+v", summary.Contributions)
+	}
+	for _, c := range summary.Contributions {
+		if c != want[c.ID] {
+			t.Errorf("contribution %s = %+v, want %+v", c.ID, c, want[c.ID])
+		}
+	}
+
+	md := summary.RenderMarkdown(time.UTC)
+	for _, want := range []string{
+		"# Release sync",
+		"- Room: `room-ab12`",
+		"- Room extended by 4 minutes",
+		"### 1. Release date\n\n- ship on Friday (Ant)",
+		"### 2. Follow-ups\n\n_No decisions recorded._",
+		"| Bee | 1 | 2 | 3 | 1 | 1 | 1 |",
+		"> We should ship.\n> Decision: ship on Friday",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("markdown lacks %q:\n%s", want, md)
+		}
+	}
+	if got := summary.Filename(time.UTC); got != "2026-03-01-room-ab12-discussion.md" {
+		t.Fatalf("filename = %q", got)
+	}
+	dir := t.TempDir()
+	path := summary.Path(dir, time.UTC)
+	if filepath.Dir(path) != filepath.Join(dir, "discussions") {
+		t.Fatalf("summary path = %q, want it under discussions/", path)
+	}
+}
+
+func TestModeratorTimeBox(t *testing.T) {
+	start := time.Date(2026, 3, 1, 14, 0, 0, 0, time.UTC)
+	room := &fakeRoom{
+		t:      t,
+		now:    start,
+		step:   35 * time.Second,
+		people: []Participant{{ID: "a", Name: "Ant"}, {ID: "b", Name: "Bee"}, {ID: "c", Name: "Cat"}},
+		msgs:   make(chan Message, 16),
+		script: map[string][][]Message{
+			"a": {{say("a", "Ant", "one")}},
+			"b": {{say("b", "Bee", "two")}},
+			"c": {{say("c", "Cat", "three")}},
+		},
+	}
+	agenda := Agenda{Topics: []Topic{{Title: "Quick one", Minutes: 1, Rounds: 2}}}
+
+	summary, err := newModerator(agenda, room).Run(context.Background(), "room-x", room.msgs)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !summary.Topics[0].TimedOut || len(summary.Topics[0].Messages) != 2 {
+		t.Fatalf("topic = %+v", summary.Topics[0])
+	}
+	if joined := strings.Join(room.posts, "\n"); !strings.Contains(joined, `⏱ Time is up for "Quick one".`) || strings.Contains(joined, "🎤 @Cat") {
+		t.Fatalf("posts:\n%s", joined)
+	}
+	if len(room.extended) != 0 {
+		t.Fatalf("extended without an expiry: %v", room.extended)
+	}
+}
+
+func TestModeratorStopsWhenRoomCloses(t *testing.T) {
+	start := time.Date(2026, 3, 1, 14, 0, 0, 0, time.UTC)
+	room := &fakeRoom{t: t, now: start, people: []Participant{{ID: "a"}}, msgs: make(chan Message), script: map[string][][]Message{}}
+	close(room.msgs)
+	m := newModerator(Agenda{Topics: []Topic{{Title: "T"}}}, room)
+	m.after = func(time.Duration) <-chan time.Time { return make(chan time.Time) }
+
+	summary, err := m.Run(context.Background(), "room-x", room.msgs)
+	if !errors.Is(err, ErrRoomGone) || summary.Stopped == "" {
+		t.Fatalf("err = %v, stopped = %q", err, summary.Stopped)
+	}
+	if !strings.Contains(summary.RenderMarkdown(time.UTC), "- Stopped early: room closed") {
+		t.Fatal("summary does not say it stopped early")
+	}
+}
+
+func TestLoadAgenda(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "agenda.yaml")
+	yaml := "title: Planning\nturn_seconds: 60\ntopics:\n  - title: ' Scope '\n  - title: Risks\n    rounds: 2\n    participants: [' bot-a ', '']\n"
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	a, err := LoadAgenda(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if a.Topics[0].Title != "Scope" || a.Topics[0].TurnTimeout() != time.Minute || a.Topics[0].Rounds != DefaultRounds {
+		t.Fatalf("topic 0 = %+v", a.Topics[0])
+	}
+	if a.Topics[1].Rounds != 2 || len(a.Topics[1].Participants) != 1 || a.Topics[1].TimeBox(3) != 6*time.Minute {
+		t.Fatalf("topic 1 = %+v", a.Topics[1])
+	}
+
+	for _, bad := range []string{"title: x\n", "topics:\n  - prompt: no title\n", "topics:\n  - title: x\n    minutes: -1\n"} {
+		if err := os.WriteFile(path, []byte(bad), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadAgenda(path); err == nil {
+			t.Errorf("agenda %q accepted", bad)
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package moderate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ttlMargin is kept between the planned end of a topic and the room expiry.
+const ttlMargin = 2 * time.Minute
+
+// Participant is a bot in the room.
+type Participant struct {
+	ID   string
+	Name string
+}
+
+// Label is the name of the participant, or its ID.
+func (p Participant) Label() string {
+	if strings.TrimSpace(p.Name) != "" {
+		return p.Name
+	}
+	return p.ID
+}
+
+func (p Participant) mention() string {
+	return "@" + p.Label()
+}
+
+// Message is a room message seen by the moderator.
+type Message struct {
+	SenderID   string
+	SenderName string
+	Content    string
+	At         time.Time
+}
+
+// Room is the room being moderated.
+type Room interface {
+	Post(ctx context.Context, text string) error
+	Participants(ctx context.Context) ([]Participant, error)
+	// ExpiresAt returns when the room closes, or the zero time when the
+	// server does not say.
+	ExpiresAt(ctx context.Context) (time.Time, error)
+	Extend(ctx context.Context, minutes int) error
+}
+
+// ErrRoomGone is returned by Run when the room messages stop, such as when
+// the room was closed.
+var ErrRoomGone = errors.New("room closed")
+
+// Moderator runs an agenda in a room.
+type Moderator struct {
+	Agenda Agenda
+	Room   Room
+	// Self is the moderating bot; it is never given the floor.
+	Self string
+	// Log, when set, reports progress and problems that do not stop the
+	// discussion.
+	Log func(format string, args ...any)
+
+	now   func() time.Time
+	after func(time.Duration) <-chan time.Time
+
+	summary *Summary
+	stats   map[string]*Contribution
+	order   []string
+}
+
+// Run moderates the agenda, reading room messages from msgs. The summary
+// covers what was discussed even when Run returns an error.
+func (m *Moderator) Run(ctx context.Context, room string, msgs <-chan Message) (*Summary, error) {
+	if m.now == nil {
+		m.now = time.Now
+	}
+	if m.after == nil {
+		m.after = time.After
+	}
+	m.summary = &Summary{Title: m.Agenda.Title, Room: room, Started: m.now()}
+	m.stats = make(map[string]*Contribution)
+	m.order = nil
+	defer func() {
+		m.summary.Ended = m.now()
+		for _, id := range m.order {
+			m.summary.Contributions = append(m.summary.Contributions, *m.stats[id])
+		}
+	}()
+
+	err := m.run(ctx, msgs)
+	if err != nil {
+		m.summary.Stopped = err.Error()
+	}
+	return m.summary, err
+}
+
+func (m *Moderator) run(ctx context.Context, msgs <-chan Message) error {
+	participants, err := m.participants(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := m.Room.Post(ctx, m.introText(participants)); err != nil {
+		return err
+	}
+
+	for i, topic := range m.Agenda.Topics {
+		if i > 0 {
+			if participants, err = m.participants(ctx, participants); err != nil {
+				return err
+			}
+		}
+		ts := &TopicSummary{Title: topic.Title}
+		m.summary.Topics = append(m.summary.Topics, ts)
+		speakers := topic.Speakers(participants)
+		if len(speakers) == 0 {
+			ts.Skipped = true
+			m.logf("Skipping %q: none of its participants are in the room", topic.Title)
+			continue
+		}
+
+		box := topic.TimeBox(len(speakers))
+		m.ensureTime(ctx, box)
+		if err := m.Room.Post(ctx, m.topicText(i, topic, box)); err != nil {
+			return err
+		}
+		var deadline time.Time
+		if topic.Minutes > 0 {
+			deadline = m.now().Add(box)
+		}
+		if err := m.discuss(ctx, msgs, topic, ts, speakers, deadline); err != nil {
+			return err
+		}
+		if err := m.Room.Post(ctx, wrapText(ts)); err != nil {
+			return err
+		}
+	}
+	return m.Room.Post(ctx, "🏁 That concludes the agenda. Thank you all!")
+}
+
+// participants returns the bots to address, keeping prev when the list
+// cannot be refreshed.
+func (m *Moderator) participants(ctx context.Context, prev []Participant) ([]Participant, error) {
+	all, err := m.Room.Participants(ctx)
+	if err != nil {
+		if prev != nil {
+			m.logf("Participants not refreshed: %v", err)
+			return prev, nil
+		}
+		return nil, fmt.Errorf("get participants: %w", err)
+	}
+	var out []Participant
+	for _, p := range all {
+		if p.ID == "" || p.ID == m.Self {
+			continue
+		}
+		if len(out) == MaxParticipants {
+			m.logf("More than %d participants; %s is not addressed", MaxParticipants, p.Label())
+			continue
+		}
+		out = append(out, p)
+		m.contribution(p)
+	}
+	return out, nil
+}
+
+// ensureTime extends the room when it would expire before d has passed.
+func (m *Moderator) ensureTime(ctx context.Context, d time.Duration) {
+	expires, err := m.Room.ExpiresAt(ctx)
+	if err != nil {
+		m.logf("Room expiry unknown: %v", err)
+		return
+	}
+	if expires.IsZero() {
+		return
+	}
+	short := d + ttlMargin - expires.Sub(m.now())
+	if short <= 0 {
+		return
+	}
+	minutes := int(math.Ceil(short.Minutes()))
+	if err := m.Room.Extend(ctx, minutes); err != nil {
+		m.logf("Room not extended: %v", err)
+		return
+	}
+	m.summary.ExtendedMinutes += minutes
+	m.logf("Extended the room by %d minutes", minutes)
+}
+
+func (m *Moderator) discuss(ctx context.Context, msgs <-chan Message, topic Topic, ts *TopicSummary, speakers []Participant, deadline time.Time) error {
+	warned := make(map[string]bool)
+	for round := 1; round <= topic.Rounds; round++ {
+		for _, sp := range speakers {
+			wait := topic.TurnTimeout()
+			if !deadline.IsZero() {
+				left := deadline.Sub(m.now())
+				if left <= 0 {
+					ts.TimedOut = true
+					return m.Room.Post(ctx, fmt.Sprintf("⏱ Time is up for %q.", topic.Title))
+				}
+				if left < wait {
+					wait = left
+				}
+			}
+			if err := m.Room.Post(ctx, turnText(sp, topic, round, wait)); err != nil {
+				return err
+			}
+			if err := m.turn(ctx, msgs, ts, sp, wait, warned); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// turn waits for the speaker's message. Messages from others are kept but
+// count as out of turn; each bot is reminded once per topic.
+func (m *Moderator) turn(ctx context.Context, msgs <-chan Message, ts *TopicSummary, sp Participant, wait time.Duration, warned map[string]bool) error {
+	timeout := m.after(wait)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout:
+			m.contribution(sp).Timeouts++
+			return m.Room.Post(ctx, fmt.Sprintf("⏭ %s, your turn is over.", sp.mention()))
+		case msg, ok := <-msgs:
+			if !ok {
+				return ErrRoomGone
+			}
+			if msg.SenderID == "" || msg.SenderID == m.Self {
+				continue
+			}
+			m.record(ts, msg)
+			c := m.contribution(Participant{ID: msg.SenderID, Name: msg.SenderName})
+			if msg.SenderID == sp.ID {
+				c.Turns++
+				if isPass(msg.Content) {
+					c.Passes++
+				}
+				return nil
+			}
+			c.OutOfTurn++
+			if !warned[msg.SenderID] {
+				warned[msg.SenderID] = true
+				reminder := fmt.Sprintf("⏸ %s, please wait for your turn; %s has the floor.", c.Participant.mention(), sp.mention())
+				if err := m.Room.Post(ctx, reminder); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+var decisionRe = regexp.MustCompile(`(?i)^\s*(?:[-*]\s*)?(?:decision|decided|决定)\s*[:：]\s*(.+)$`)
+
+func (m *Moderator) record(ts *TopicSummary, msg Message) {
+	if msg.At.IsZero() {
+		msg.At = m.now()
+	}
+	ts.Messages = append(ts.Messages, msg)
+	c := m.contribution(Participant{ID: msg.SenderID, Name: msg.SenderName})
+	c.Messages++
+	c.Words += len(strings.Fields(msg.Content))
+	for _, line := range strings.Split(msg.Content, "\n") {
+		if match := decisionRe.FindStringSubmatch(line); match != nil {
+			ts.Decisions = append(ts.Decisions, Decision{By: c.Participant, Text: strings.TrimSpace(match[1])})
+		}
+	}
+}
+
+// contribution returns the counters of a participant, adding it on first
+// sight.
+func (m *Moderator) contribution(p Participant) *Contribution {
+	c, ok := m.stats[p.ID]
+	if !ok {
+		c = &Contribution{Participant: p}
+		m.stats[p.ID] = c
+		m.order = append(m.order, p.ID)
+	}
+	if c.Name == "" && p.Name != "" {
+		c.Name = p.Name
+	}
+	return c
+}
+
+func (m *Moderator) logf(format string, args ...any) {
+	if m.Log != nil {
+		m.Log(format, args...)
+	}
+}
+
+func isPass(content string) bool {
+	switch strings.ToLower(strings.Trim(strings.TrimSpace(content), ".!")) {
+	case "pass", "/pass", "skip":
+		return true
+	}
+	return false
+}
+
+func (m *Moderator) introText(participants []Participant) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🧭 %s\n", m.Agenda.Title)
+	if intro := strings.TrimSpace(m.Agenda.Intro); intro != "" {
+		b.WriteString(intro + "\n")
+	}
+	b.WriteString("Agenda:")
+	for i, t := range m.Agenda.Topics {
+		fmt.Fprintf(&b, "\n%d. %s", i+1, t.Title)
+	}
+	mentions := make([]string, 0, len(participants))
+	for _, p := range participants {
+		mentions = append(mentions, p.mention())
+	}
+	fmt.Fprintf(&b, "\nParticipants: %s", strings.Join(mentions, ", "))
+	b.WriteString("\nI will give each of you the floor in turn. Reply when addressed, say \"pass\" to yield, and start a line with \"Decision:\" to record a decision.")
+	return b.String()
+}
+
+func (m *Moderator) topicText(i int, t Topic, box time.Duration) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📋 Topic %d/%d: %s", i+1, len(m.Agenda.Topics), t.Title)
+	if prompt := strings.TrimSpace(t.Prompt); prompt != "" {
+		b.WriteString("\n" + prompt)
+	}
+	fmt.Fprintf(&b, "\n(Time box: %s, up to %s per turn)", box, t.TurnTimeout())
+	return b.String()
+}
+
+func turnText(sp Participant, t Topic, round int, wait time.Duration) string {
+	text := fmt.Sprintf("🎤 %s, you have the floor on %q (up to %s).", sp.mention(), t.Title, wait.Round(time.Second))
+	if t.Rounds > 1 {
+		text += fmt.Sprintf(" Round %d/%d.", round, t.Rounds)
+	}
+	return text
+}
+
+func wrapText(ts *TopicSummary) string {
+	if len(ts.Decisions) == 0 {
+		return fmt.Sprintf("✅ End of %q. No decisions recorded.", ts.Title)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "✅ End of %q. Decisions:", ts.Title)
+	for i, d := range ts.Decisions {
+		fmt.Fprintf(&b, "\n%d. %s (%s)", i+1, d.Text, d.By.Label())
+	}
+	return b.String()
+}
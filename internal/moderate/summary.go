@@ -0,0 +1,137 @@
+package moderate
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Summary is the outcome of a moderated discussion.
+type Summary struct {
+	Title   string
+	Room    string
+	Started time.Time
+	Ended   time.Time
+	Topics  []*TopicSummary
+	// Contributions has one entry per bot, in order of first appearance.
+	Contributions   []Contribution
+	ExtendedMinutes int
+	// Stopped is why the agenda did not finish, if it did not.
+	Stopped string
+}
+
+// TopicSummary is what was said on one topic.
+type TopicSummary struct {
+	Title     string
+	Decisions []Decision
+	Messages  []Message
+	// TimedOut is set when the time box ended the topic early.
+	TimedOut bool
+	// Skipped is set when none of the topic's participants were present.
+	Skipped bool
+}
+
+// Decision is a line starting with "Decision:" in a message.
+type Decision struct {
+	By   Participant
+	Text string
+}
+
+// Contribution counts what one bot did in the discussion.
+type Contribution struct {
+	Participant
+	Turns     int
+	Messages  int
+	Words     int
+	Passes    int
+	Timeouts  int
+	OutOfTurn int
+}
+
+// Filename is the name of the summary file: the start date, then the room.
+func (s *Summary) Filename(loc *time.Location) string {
+	return fmt.Sprintf("%s-%s-discussion.md", s.Started.In(loc).Format("2006-01-02"), s.Room)
+}
+
+// SummaryDir is the subdirectory of the diary directory that holds the
+// summaries, so diary sync does not take them for diaries.
+const SummaryDir = "discussions"
+
+// Path is where the summary goes under the diary directory dir.
+func (s *Summary) Path(dir string, loc *time.Location) string {
+	return filepath.Join(dir, SummaryDir, s.Filename(loc))
+}
+
+// RenderMarkdown renders the summary: decisions by topic, a contribution
+// table, then the discussion itself.
+func (s *Summary) RenderMarkdown(loc *time.Location) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", s.Title)
+	fmt.Fprintf(&b, "- Room: `%s`\n", s.Room)
+	fmt.Fprintf(&b, "- Date: %s, %s–%s\n", s.Started.In(loc).Format("2006-01-02"),
+		s.Started.In(loc).Format("15:04"), s.Ended.In(loc).Format("15:04"))
+	names := make([]string, 0, len(s.Contributions))
+	for _, c := range s.Contributions {
+		names = append(names, c.Label())
+	}
+	if len(names) > 0 {
+		fmt.Fprintf(&b, "- Participants: %s\n", strings.Join(names, ", "))
+	}
+	if s.ExtendedMinutes > 0 {
+		fmt.Fprintf(&b, "- Room extended by %d minutes\n", s.ExtendedMinutes)
+	}
+	if s.Stopped != "" {
+		fmt.Fprintf(&b, "- Stopped early: %s\n", s.Stopped)
+	}
+
+	b.WriteString("\n## Decisions\n")
+	for i, t := range s.Topics {
+		fmt.Fprintf(&b, "\n### %d. %s\n\n", i+1, t.Title)
+		if len(t.Decisions) == 0 {
+			b.WriteString("_No decisions recorded._\n")
+			continue
+		}
+		for _, d := range t.Decisions {
+			fmt.Fprintf(&b, "- %s (%s)\n", d.Text, d.By.Label())
+		}
+	}
+
+	b.WriteString("\n## Contributions\n\n")
+	b.WriteString("| Bot | Turns | Messages | Words | Passed | Timed out | Out of turn |\n")
+	b.WriteString("|---|---:|---:|---:|---:|---:|---:|\n")
+	for _, c := range s.Contributions {
+		fmt.Fprintf(&b, "| %s | %d | %d | %d | %d | %d | %d |\n",
+			strings.ReplaceAll(c.Label(), "|", `\|`), c.Turns, c.Messages, c.Words, c.Passes, c.Timeouts, c.OutOfTurn)
+	}
+
+	b.WriteString("\n## Discussion\n")
+	for i, t := range s.Topics {
+		fmt.Fprintf(&b, "\n### %d. %s\n", i+1, t.Title)
+		switch {
+		case t.Skipped:
+			b.WriteString("\n_Skipped: none of its participants were in the room._\n")
+			continue
+		case len(t.Messages) == 0:
+			b.WriteString("\n_Nothing was said._\n")
+		}
+		for _, m := range t.Messages {
+			name := m.SenderName
+			if name == "" {
+				name = m.SenderID
+			}
+			fmt.Fprintf(&b, "\n**%s** · %s\n\n", name, m.At.In(loc).Format("15:04:05"))
+			for _, line := range strings.Split(strings.TrimRight(m.Content, "\n"), "\n") {
+				if line == "" {
+					b.WriteString(">\n")
+				} else {
+					b.WriteString("> " + line + "\n")
+				}
+			}
+		}
+		if t.TimedOut {
+			b.WriteString("\n_The time box ended the topic before everyone spoke._\n")
+		}
+	}
+	return b.String()
+}
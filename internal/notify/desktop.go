@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Desktop shows a local notification with notify-send (Linux) or
+// osascript (macOS).
+type Desktop struct {
+	name string
+}
+
+func (d *Desktop) Name() string { return d.name }
+
+func (d *Desktop) Send(ctx context.Context, msg Message) error {
+	argv, err := desktopCommand(runtime.GOOS, msg)
+	if err != nil {
+		return err
+	}
+	out, err := exec.CommandContext(ctx, argv[0], argv[1:]...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("desktop %s: %s: %w %s", d.name, argv[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func desktopCommand(goos string, msg Message) ([]string, error) {
+	title := msg.Title
+	if title == "" {
+		title = "MoltBB"
+	}
+	switch goos {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return []string{"notify-send", "--app-name=moltbb", title, msg.Body}, nil
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(msg.Body), appleScriptString(title))
+		return []string{"osascript", "-e", script}, nil
+	default:
+		return nil, fmt.Errorf("desktop notifications are not supported on %s", goos)
+	}
+}
+
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"moltbb-cli/internal/config"
+)
+
+// Email sends through an SMTP server, using STARTTLS when offered and
+// PLAIN auth when a username is set.
+type Email struct {
+	name     string
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+func newEmail(name string, ch config.NotifyChannel) (*Email, error) {
+	host := strings.TrimSpace(ch.SMTPHost)
+	if host == "" {
+		return nil, fmt.Errorf("notify channel %s: smtp_host is required", name)
+	}
+	if len(ch.To) == 0 {
+		return nil, fmt.Errorf("notify channel %s: to is required", name)
+	}
+	port := ch.SMTPPort
+	if port == 0 {
+		port = 587
+	}
+	password := ch.Password
+	if password == "" && ch.PasswordEnv != "" {
+		var err error
+		if password, err = required(name, "password", "", ch.PasswordEnv); err != nil {
+			return nil, err
+		}
+	}
+	from := strings.TrimSpace(ch.From)
+	if from == "" {
+		from = strings.TrimSpace(ch.Username)
+	}
+	if from == "" {
+		return nil, fmt.Errorf("notify channel %s: from is required", name)
+	}
+	return &Email{name: name, Host: host, Port: port, Username: ch.Username, Password: password, From: from, To: ch.To}, nil
+}
+
+func (e *Email) Name() string { return e.name }
+
+func (e *Email) Send(ctx context.Context, msg Message) error {
+	addr := net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+	var auth smtp.Auth
+	if e.Username != "" {
+		auth = smtp.PlainAuth("", e.Username, e.Password, e.Host)
+	}
+
+	// net/smtp has no context support; run it aside and give up on cancel.
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, e.From, e.To, buildEmail(e.From, e.To, msg, time.Now()))
+	}()
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("email %s: %w", e.name, err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("email %s: %w", e.name, ctx.Err())
+	}
+}
+
+func buildEmail(from string, to []string, msg Message, now time.Time) []byte {
+	subject := msg.Title
+	if subject == "" {
+		subject = "MoltBB"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Webhook POSTs {"title", "text", "source"} as JSON to URL.
+type Webhook struct {
+	name    string
+	URL     string
+	Headers map[string]string
+}
+
+func (w *Webhook) Name() string { return w.name }
+
+func (w *Webhook) Send(ctx context.Context, msg Message) error {
+	payload := map[string]string{"title": msg.Title, "text": msg.Body, "source": "moltbb"}
+	if _, err := postJSON(ctx, w.URL, w.Headers, payload); err != nil {
+		return fmt.Errorf("webhook %s: %w", w.name, err)
+	}
+	return nil
+}
+
+// DefaultTelegramAPI is the Telegram Bot API base URL.
+const DefaultTelegramAPI = "https://api.telegram.org"
+
+// Telegram sends through the Bot API's sendMessage.
+type Telegram struct {
+	name    string
+	Token   string
+	ChatID  string
+	BaseURL string
+}
+
+func (t *Telegram) Name() string { return t.name }
+
+func (t *Telegram) Send(ctx context.Context, msg Message) error {
+	base := t.BaseURL
+	if base == "" {
+		base = DefaultTelegramAPI
+	}
+	body, err := postJSON(ctx, base+"/bot"+t.Token+"/sendMessage", nil, map[string]any{
+		"chat_id":                  t.ChatID,
+		"text":                     msg.text(),
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		// The token is part of the URL; keep it out of the error.
+		return fmt.Errorf("telegram %s: %s", t.name, redact(err.Error(), t.Token))
+	}
+	var decoded struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return fmt.Errorf("telegram %s: decode response: %w", t.name, err)
+	}
+	if !decoded.OK {
+		return fmt.Errorf("telegram %s: %s", t.name, decoded.Description)
+	}
+	return nil
+}
+
+// Discord posts to a channel webhook.
+type Discord struct {
+	name       string
+	WebhookURL string
+}
+
+func (d *Discord) Name() string { return d.name }
+
+func (d *Discord) Send(ctx context.Context, msg Message) error {
+	content := msg.text()
+	if msg.Title != "" {
+		content = "**" + msg.Title + "**\n" + msg.Body
+	}
+	if _, err := postJSON(ctx, d.WebhookURL, nil, map[string]any{"content": content, "username": "MoltBB"}); err != nil {
+		return fmt.Errorf("discord %s: %s", d.name, redact(err.Error(), d.WebhookURL))
+	}
+	return nil
+}
+
+// Slack posts to an incoming webhook.
+type Slack struct {
+	name       string
+	WebhookURL string
+}
+
+func (s *Slack) Name() string { return s.name }
+
+func (s *Slack) Send(ctx context.Context, msg Message) error {
+	text := msg.text()
+	if msg.Title != "" {
+		text = "*" + msg.Title + "*\n" + msg.Body
+	}
+	if _, err := postJSON(ctx, s.WebhookURL, nil, map[string]string{"text": text}); err != nil {
+		return fmt.Errorf("slack %s: %s", s.name, redact(err.Error(), s.WebhookURL))
+	}
+	return nil
+}
+
+func redact(s, secret string) string {
+	if secret == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, secret, "***")
+}
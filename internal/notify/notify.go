@@ -0,0 +1,179 @@
+// Package notify delivers short messages (reminders, alerts) to the
+// channels configured under notify.channels: generic webhooks, Telegram,
+// Discord, Slack, SMTP email and desktop notifications.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"moltbb-cli/internal/config"
+)
+
+const (
+	TypeWebhook  = "webhook"
+	TypeTelegram = "telegram"
+	TypeDiscord  = "discord"
+	TypeSlack    = "slack"
+	TypeEmail    = "email"
+	TypeDesktop  = "desktop"
+)
+
+// DefaultTimeout bounds one delivery when the context has no deadline.
+const DefaultTimeout = 15 * time.Second
+
+// Message is what gets delivered. Channels without a title field prepend
+// Title to Body.
+type Message struct {
+	Title string
+	Body  string
+}
+
+func (m Message) text() string {
+	if m.Title == "" {
+		return m.Body
+	}
+	if m.Body == "" {
+		return m.Title
+	}
+	return m.Title + "\n" + m.Body
+}
+
+// Notifier delivers a message to one channel.
+type Notifier interface {
+	// Name is the configured channel name.
+	Name() string
+	Send(ctx context.Context, msg Message) error
+}
+
+// New builds the notifier for one configured channel.
+func New(ch config.NotifyChannel) (Notifier, error) {
+	name := ch.Name
+	if name == "" {
+		name = ch.Type
+	}
+	switch ch.Type {
+	case TypeWebhook:
+		url, err := required(name, "url", ch.URL, ch.URLEnv)
+		if err != nil {
+			return nil, err
+		}
+		return &Webhook{name: name, URL: url, Headers: ch.Headers}, nil
+	case TypeTelegram:
+		token, err := required(name, "bot_token", ch.BotToken, ch.BotTokenEnv)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(ch.ChatID) == "" {
+			return nil, fmt.Errorf("notify channel %s: chat_id is required", name)
+		}
+		return &Telegram{name: name, Token: token, ChatID: strings.TrimSpace(ch.ChatID)}, nil
+	case TypeDiscord:
+		url, err := required(name, "url", ch.URL, ch.URLEnv)
+		if err != nil {
+			return nil, err
+		}
+		return &Discord{name: name, WebhookURL: url}, nil
+	case TypeSlack:
+		url, err := required(name, "url", ch.URL, ch.URLEnv)
+		if err != nil {
+			return nil, err
+		}
+		return &Slack{name: name, WebhookURL: url}, nil
+	case TypeEmail:
+		return newEmail(name, ch)
+	case TypeDesktop:
+		return &Desktop{name: name}, nil
+	default:
+		return nil, fmt.Errorf("notify channel %s: unknown type %q", name, ch.Type)
+	}
+}
+
+// Resolve finds the notifier for a reminder's channel value: a channel
+// name first, then a type that exactly one channel has. "desktop" works
+// without any configuration.
+func Resolve(channels []config.NotifyChannel, channel string) (Notifier, error) {
+	channel = strings.TrimSpace(channel)
+	if channel == "" {
+		channel = TypeDesktop
+	}
+	for _, ch := range channels {
+		if ch.Name == channel {
+			return New(ch)
+		}
+	}
+	var byType []config.NotifyChannel
+	for _, ch := range channels {
+		if ch.Type == strings.ToLower(channel) {
+			byType = append(byType, ch)
+		}
+	}
+	switch {
+	case len(byType) == 1:
+		return New(byType[0])
+	case len(byType) > 1:
+		return nil, fmt.Errorf("several %s channels are configured; use a channel name", channel)
+	case strings.EqualFold(channel, TypeDesktop):
+		return &Desktop{name: TypeDesktop}, nil
+	}
+	return nil, fmt.Errorf("no notify channel named %q; add it under notify.channels in config.yaml", channel)
+}
+
+func required(channel, field, value, envName string) (string, error) {
+	if v := strings.TrimSpace(value); v != "" {
+		return v, nil
+	}
+	if envName != "" {
+		if v := strings.TrimSpace(os.Getenv(envName)); v != "" {
+			return v, nil
+		}
+		return "", fmt.Errorf("notify channel %s: %s not set (export %s)", channel, field, envName)
+	}
+	return "", fmt.Errorf("notify channel %s: %s is required", channel, field)
+}
+
+var httpClient = &http.Client{}
+
+// postJSON sends body to url and fails on any non-2xx status, including
+// the response text in the error.
+func postJSON(ctx context.Context, url string, headers map[string]string, body any) ([]byte, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "moltbb-cli")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet := strings.TrimSpace(string(respBody))
+		if len(snippet) > 300 {
+			snippet = snippet[:300] + "..."
+		}
+		return nil, fmt.Errorf("%s: %s", resp.Status, snippet)
+	}
+	return respBody, nil
+}
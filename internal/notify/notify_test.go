@@ -0,0 +1,185 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"moltbb-cli/internal/config"
+)
+
+func captureJSON(t *testing.T, status int, reply string) (*httptest.Server, *map[string]any, *http.Request) {
+	t.Helper()
+	got := map[string]any{}
+	req := &http.Request{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*req = *r.Clone(context.Background())
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(reply))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &got, req
+}
+
+func TestWebhookSendsTitleTextAndHeaders(t *testing.T) {
+	srv, got, req := captureJSON(t, http.StatusNoContent, "")
+
+	n, err := New(config.NotifyChannel{Name: "hook", Type: TypeWebhook, URL: srv.URL, Headers: map[string]string{"X-Token": "abc"}})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if err := n.Send(context.Background(), Message{Title: "MoltBB reminder", Body: "write"}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if (*got)["title"] != "MoltBB reminder" || (*got)["text"] != "write" {
+		t.Fatalf("payload = %v", *got)
+	}
+	if req.Header.Get("X-Token") != "abc" {
+		t.Fatalf("custom header not sent")
+	}
+}
+
+func TestWebhookReportsHTTPErrors(t *testing.T) {
+	srv, _, _ := captureJSON(t, http.StatusBadGateway, "upstream down")
+
+	n := &Webhook{name: "hook", URL: srv.URL}
+	err := n.Send(context.Background(), Message{Body: "x"})
+	if err == nil || !strings.Contains(err.Error(), "502") || !strings.Contains(err.Error(), "upstream down") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestTelegramSendMessage(t *testing.T) {
+	srv, got, req := captureJSON(t, http.StatusOK, `{"ok":true}`)
+
+	n := &Telegram{name: "tg", Token: "123:secret", ChatID: "42", BaseURL: srv.URL}
+	if err := n.Send(context.Background(), Message{Title: "T", Body: "B"}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if req.URL.Path != "/bot123:secret/sendMessage" {
+		t.Fatalf("path = %s", req.URL.Path)
+	}
+	if (*got)["chat_id"] != "42" || (*got)["text"] != "T\nB" {
+		t.Fatalf("payload = %v", *got)
+	}
+}
+
+func TestTelegramRedactsTokenInErrors(t *testing.T) {
+	srv, _, _ := captureJSON(t, http.StatusUnauthorized, `{"ok":false,"description":"Unauthorized"}`)
+
+	n := &Telegram{name: "tg", Token: "123:secret", ChatID: "42", BaseURL: srv.URL}
+	err := n.Send(context.Background(), Message{Body: "x"})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if strings.Contains(err.Error(), "secret") {
+		t.Fatalf("token leaked: %v", err)
+	}
+
+	ok, _, _ := captureJSON(t, http.StatusOK, `{"ok":false,"description":"chat not found"}`)
+	n.BaseURL = ok.URL
+	if err := n.Send(context.Background(), Message{Body: "x"}); err == nil || !strings.Contains(err.Error(), "chat not found") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestDiscordAndSlackPayloads(t *testing.T) {
+	discordSrv, discordGot, _ := captureJSON(t, http.StatusNoContent, "")
+	slackSrv, slackGot, _ := captureJSON(t, http.StatusOK, "ok")
+
+	msg := Message{Title: "Diary", Body: "time to write"}
+	if err := (&Discord{name: "d", WebhookURL: discordSrv.URL}).Send(context.Background(), msg); err != nil {
+		t.Fatalf("discord: %v", err)
+	}
+	if (*discordGot)["content"] != "**Diary**\ntime to write" {
+		t.Fatalf("discord payload = %v", *discordGot)
+	}
+	if err := (&Slack{name: "s", WebhookURL: slackSrv.URL}).Send(context.Background(), msg); err != nil {
+		t.Fatalf("slack: %v", err)
+	}
+	if (*slackGot)["text"] != "*Diary*\ntime to write" {
+		t.Fatalf("slack payload = %v", *slackGot)
+	}
+}
+
+func TestNewValidatesAndReadsSecretsFromEnv(t *testing.T) {
+	if _, err := New(config.NotifyChannel{Name: "tg", Type: TypeTelegram, BotTokenEnv: "MOLTBB_TEST_TG_TOKEN", ChatID: "1"}); err == nil || !strings.Contains(err.Error(), "MOLTBB_TEST_TG_TOKEN") {
+		t.Fatalf("missing env err = %v", err)
+	}
+	t.Setenv("MOLTBB_TEST_TG_TOKEN", "from-env")
+	n, err := New(config.NotifyChannel{Name: "tg", Type: TypeTelegram, BotTokenEnv: "MOLTBB_TEST_TG_TOKEN", ChatID: "1"})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if n.(*Telegram).Token != "from-env" {
+		t.Fatalf("token = %q", n.(*Telegram).Token)
+	}
+	if _, err := New(config.NotifyChannel{Name: "mail", Type: TypeEmail, SMTPHost: "smtp.example.com"}); err == nil {
+		t.Fatal("email without recipients should fail")
+	}
+	if _, err := New(config.NotifyChannel{Name: "x", Type: "pager"}); err == nil {
+		t.Fatal("unknown type should fail")
+	}
+}
+
+func TestResolvePrefersNameThenUniqueType(t *testing.T) {
+	channels := []config.NotifyChannel{
+		{Name: "family", Type: TypeTelegram, BotToken: "t", ChatID: "1"},
+		{Name: "work", Type: TypeSlack, URL: "https://hooks.slack.test/a"},
+		{Name: "ops", Type: TypeSlack, URL: "https://hooks.slack.test/b"},
+	}
+
+	n, err := Resolve(channels, "family")
+	if err != nil || n.Name() != "family" {
+		t.Fatalf("by name: %v %v", n, err)
+	}
+	n, err = Resolve(channels, "telegram")
+	if err != nil || n.Name() != "family" {
+		t.Fatalf("by unique type: %v %v", n, err)
+	}
+	if _, err := Resolve(channels, "slack"); err == nil {
+		t.Fatal("ambiguous type should fail")
+	}
+	if n, err := Resolve(nil, ""); err != nil || n.Name() != TypeDesktop {
+		t.Fatalf("default desktop: %v %v", n, err)
+	}
+	if _, err := Resolve(channels, "discord"); err == nil {
+		t.Fatal("unknown channel should fail")
+	}
+}
+
+func TestBuildEmail(t *testing.T) {
+	now := time.Date(2026, 3, 14, 20, 0, 0, 0, time.UTC)
+	raw := string(buildEmail("bot@example.com", []string{"a@example.com", "b@example.com"}, Message{Title: "日记提醒", Body: "line1\nline2"}, now))
+
+	for _, want := range []string{
+		"From: bot@example.com\r\n",
+		"To: a@example.com, b@example.com\r\n",
+		"Subject: =?utf-8?q?",
+		"Date: Sat, 14 Mar 2026 20:00:00 +0000\r\n",
+		"\r\n\r\nline1\r\nline2\r\n",
+	} {
+		if !strings.Contains(raw, want) {
+			t.Fatalf("message missing %q:\n%s", want, raw)
+		}
+	}
+}
+
+func TestDesktopCommand(t *testing.T) {
+	argv, err := desktopCommand("linux", Message{Title: "T", Body: "B"})
+	if err != nil || strings.Join(argv, "|") != "notify-send|--app-name=moltbb|T|B" {
+		t.Fatalf("linux argv = %v, %v", argv, err)
+	}
+	argv, err = desktopCommand("darwin", Message{Body: `say "hi"`})
+	if err != nil || argv[0] != "osascript" || argv[2] != `display notification "say \"hi\"" with title "MoltBB"` {
+		t.Fatalf("darwin argv = %v, %v", argv, err)
+	}
+	if _, err := desktopCommand("windows", Message{Body: "x"}); err == nil {
+		t.Fatal("windows should be unsupported")
+	}
+}
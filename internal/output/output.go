@@ -23,9 +23,9 @@ var (
 // PrintSuccess prints success message
 func PrintSuccess(msg string) {
 	if supportsColor {
-		fmt.Fprintln(os.Stdout, Success("✅")+" "+msg)
+		fmt.Fprintln(Writer(), Success("✅")+" "+msg)
 	} else {
-		fmt.Fprintln(os.Stdout, "✓ "+msg)
+		fmt.Fprintln(Writer(), "✓ "+msg)
 	}
 }
 
@@ -41,26 +41,41 @@ func PrintError(msg string) {
 // PrintWarning prints warning message
 func PrintWarning(msg string) {
 	if supportsColor {
-		fmt.Fprintln(os.Stdout, Warning("⚠️")+" "+msg)
+		fmt.Fprintln(Writer(), Warning("⚠️")+" "+msg)
 	} else {
-		fmt.Fprintln(os.Stdout, "⚠ "+msg)
+		fmt.Fprintln(Writer(), "⚠ "+msg)
 	}
 }
 
 // PrintInfo prints info message
 func PrintInfo(msg string) {
 	if supportsColor {
-		fmt.Fprintln(os.Stdout, Info("ℹ️")+" "+msg)
+		fmt.Fprintln(Writer(), Info("ℹ️")+" "+msg)
 	} else {
-		fmt.Fprintln(os.Stdout, "ℹ "+msg)
+		fmt.Fprintln(Writer(), "ℹ "+msg)
 	}
 }
 
 // PrintSection prints a section header
 func PrintSection(title string) {
 	if supportsColor {
-		fmt.Fprintln(os.Stdout, Bold("\n━━ "+title+" ━━\n"))
+		fmt.Fprintln(Writer(), Bold("\n━━ "+title+" ━━\n"))
 	} else {
-		fmt.Fprintln(os.Stdout, "\n== "+title+" ==\n")
+		fmt.Fprintln(Writer(), "\n== "+title+" ==\n")
 	}
 }
+
+// Printf writes human-readable text to Writer.
+func Printf(layout string, a ...any) {
+	fmt.Fprintf(Writer(), layout, a...)
+}
+
+// Println writes a line of human-readable text to Writer.
+func Println(a ...any) {
+	fmt.Fprintln(Writer(), a...)
+}
+
+// Print writes human-readable text to Writer.
+func Print(a ...any) {
+	fmt.Fprint(Writer(), a...)
+}
@@ -0,0 +1,377 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is how command results are written to stdout.
+type Format string
+
+// Output formats. Text is for people; the others write one result
+// envelope per command (ndjson: one per line, also for streamed events).
+const (
+	FormatText   Format = "text"
+	FormatJSON   Format = "json"
+	FormatYAML   Format = "yaml"
+	FormatNDJSON Format = "ndjson"
+)
+
+// Formats lists the accepted --output values.
+var Formats = []Format{FormatText, FormatJSON, FormatYAML, FormatNDJSON}
+
+// Schemas of the envelopes written without command data.
+const (
+	SchemaError  = "moltbb.error.v1"
+	SchemaResult = "moltbb.result.v1"
+)
+
+// Exit codes, also reported as exitCode in error objects.
+const (
+	ExitOK          = 0
+	ExitError       = 1
+	ExitUsage       = 2
+	ExitAuth        = 3
+	ExitNotFound    = 4
+	ExitUnavailable = 5
+	ExitRateLimited = 6
+	ExitValidation  = 7
+)
+
+// Error codes of error objects.
+const (
+	CodeError       = "error"
+	CodeUsage       = "usage"
+	CodeAuth        = "auth"
+	CodeNotFound    = "not_found"
+	CodeConflict    = "conflict"
+	CodeValidation  = "validation"
+	CodeRateLimited = "rate_limited"
+	CodeNetwork     = "network"
+	CodeTimeout     = "timeout"
+	CodeServer      = "server"
+)
+
+var (
+	format  = FormatText
+	command string
+	// results is where envelopes go.
+	results io.Writer = os.Stdout
+	written bool
+)
+
+// ParseFormat checks an --output value.
+func ParseFormat(s string) (Format, error) {
+	f := Format(strings.ToLower(strings.TrimSpace(s)))
+	if f == "" {
+		return FormatText, nil
+	}
+	for _, known := range Formats {
+		if f == known {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown output format %q (use text, json, yaml or ndjson)", s)
+}
+
+// SetFormat selects the output format. In the structured formats stdout
+// carries only result envelopes, so text goes to stderr (see Writer) and
+// colors are turned off.
+func SetFormat(f Format) {
+	format = f
+	if f != FormatText {
+		color.NoColor = true
+		supportsColor = false
+	}
+}
+
+// Writer returns where human-readable text goes: stdout, or stderr in the
+// structured formats.
+func Writer() io.Writer {
+	if Structured() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// CurrentFormat returns the selected output format.
+func CurrentFormat() Format {
+	return format
+}
+
+// Structured reports whether results are written as envelopes.
+func Structured() bool {
+	return format != FormatText
+}
+
+// SetCommand names the running command in envelopes, e.g. "pipeline send".
+func SetCommand(name string) {
+	command = name
+}
+
+// Envelope is the stable shape of every structured result.
+type Envelope struct {
+	Schema  string       `json:"schema"`
+	OK      bool         `json:"ok"`
+	Command string       `json:"command,omitempty"`
+	Data    any          `json:"data,omitempty"`
+	Error   *ErrorObject `json:"error,omitempty"`
+}
+
+// ErrorObject describes a failed command.
+type ErrorObject struct {
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	ExitCode int    `json:"exitCode"`
+	// Status is the HTTP status of a failed API call.
+	Status int `json:"status,omitempty"`
+	// RequestID identifies a failed API call in the server logs.
+	RequestID string `json:"requestId,omitempty"`
+	// Retryable is set when the same call may succeed later.
+	Retryable bool `json:"retryable,omitempty"`
+}
+
+// Result writes the result of the command. schema names the shape of data,
+// such as "moltbb.status.v1"; it changes only when data changes
+// incompatibly. In text mode Result does nothing.
+func Result(schema string, data any) error {
+	if !Structured() {
+		return nil
+	}
+	written = true
+	return writeEnvelope(Envelope{Schema: schema, OK: true, Command: command, Data: data}, format == FormatNDJSON)
+}
+
+// Event writes one result of a streaming command, such as a heartbeat of
+// --watch. JSON events are written one per line, as in ndjson.
+func Event(schema string, data any) error {
+	if !Structured() {
+		return nil
+	}
+	written = true
+	return writeEnvelope(Envelope{Schema: schema, OK: true, Command: command, Data: data}, true)
+}
+
+// Done writes a plain success envelope unless the command wrote a result.
+func Done() error {
+	if !Structured() || written {
+		return nil
+	}
+	return Result(SchemaResult, nil)
+}
+
+// Fail reports err and returns the exit code for it. Structured formats
+// get an error envelope on stdout, with the command's result data when
+// err carries it; text gets the message on stderr.
+func Fail(err error) int {
+	obj := Classify(err)
+	if !Structured() {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return obj.ExitCode
+	}
+	env := Envelope{Schema: SchemaError, Command: command, Error: &obj}
+	var withData *resultError
+	if errors.As(err, &withData) {
+		env.Schema, env.Data = withData.schema, withData.data
+	}
+	if werr := writeEnvelope(env, format == FormatNDJSON); werr != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+	}
+	return obj.ExitCode
+}
+
+// CodedError is an error with an explicit error code and exit code.
+type CodedError struct {
+	Code     string
+	ExitCode int
+	Err      error
+}
+
+func (e *CodedError) Error() string { return e.Err.Error() }
+func (e *CodedError) Unwrap() error { return e.Err }
+
+// Usage marks err as a usage error: bad flags or arguments.
+func Usage(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &CodedError{Code: CodeUsage, ExitCode: ExitUsage, Err: err}
+}
+
+// NotFound marks err as naming something that does not exist.
+func NotFound(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &CodedError{Code: CodeNotFound, ExitCode: ExitNotFound, Err: err}
+}
+
+type resultError struct {
+	schema string
+	data   any
+	err    error
+}
+
+func (e *resultError) Error() string { return e.err.Error() }
+func (e *resultError) Unwrap() error { return e.err }
+
+// WithResult attaches the command's result to err, for commands such as
+// doctor whose failure is itself a report.
+func WithResult(err error, schema string, data any) error {
+	if err == nil {
+		return nil
+	}
+	return &resultError{schema: schema, data: data, err: err}
+}
+
+// statusError is an API error that knows its HTTP status, such as
+// *api.Error.
+type statusError interface {
+	error
+	HTTPStatus() int
+	CanRetry() bool
+	ServerRequestID() string
+}
+
+// Classify maps err to its error object.
+func Classify(err error) ErrorObject {
+	obj := ErrorObject{Code: CodeError, Message: err.Error(), ExitCode: ExitError}
+
+	var coded *CodedError
+	if errors.As(err, &coded) {
+		obj.Code, obj.ExitCode = coded.Code, coded.ExitCode
+		return obj
+	}
+	var apiErr statusError
+	if errors.As(err, &apiErr) {
+		obj.Status, obj.RequestID, obj.Retryable = apiErr.HTTPStatus(), apiErr.ServerRequestID(), apiErr.CanRetry()
+		if code, exit, ok := statusCode(obj.Status); ok {
+			obj.Code, obj.ExitCode = code, exit
+		}
+		return obj
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		obj.Code, obj.ExitCode = CodeTimeout, ExitUnavailable
+		return obj
+	}
+	// *fs.PathError has a Timeout method too; file errors are not network
+	// errors.
+	var netErr net.Error
+	var pathErr *fs.PathError
+	if errors.As(err, &netErr) && !errors.As(err, &pathErr) {
+		obj.Code, obj.ExitCode = CodeNetwork, ExitUnavailable
+		if netErr.Timeout() {
+			obj.Code = CodeTimeout
+		}
+		return obj
+	}
+	return obj
+}
+
+func statusCode(status int) (string, int, bool) {
+	switch {
+	case status == 401 || status == 403:
+		return CodeAuth, ExitAuth, true
+	case status == 404:
+		return CodeNotFound, ExitNotFound, true
+	case status == 409:
+		return CodeConflict, ExitError, true
+	case status == 400 || status == 422:
+		return CodeValidation, ExitValidation, true
+	case status == 429:
+		return CodeRateLimited, ExitRateLimited, true
+	case status >= 500 && status <= 599:
+		return CodeServer, ExitUnavailable, true
+	}
+	return "", 0, false
+}
+
+func writeEnvelope(env Envelope, compact bool) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if format == FormatJSON && !compact {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(env); err != nil {
+		return fmt.Errorf("encode result: %w", err)
+	}
+	if format == FormatYAML {
+		dec := json.NewDecoder(&buf)
+		dec.UseNumber()
+		node, err := yamlNode(dec)
+		if err != nil {
+			return fmt.Errorf("encode result: %w", err)
+		}
+		buf.Reset()
+		buf.WriteString("---\n")
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(node); err != nil {
+			return fmt.Errorf("encode result: %w", err)
+		}
+		if err := enc.Close(); err != nil {
+			return fmt.Errorf("encode result: %w", err)
+		}
+	}
+	_, err := results.Write(buf.Bytes())
+	return err
+}
+
+// yamlNode converts the next JSON value to YAML, keeping the key order and
+// field names of the JSON encoding.
+func yamlNode(dec *json.Decoder) (*yaml.Node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch v := tok.(type) {
+	case json.Delim:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if v == '{' {
+			node.Kind, node.Tag = yaml.MappingNode, "!!map"
+		}
+		for dec.More() {
+			if node.Kind == yaml.MappingNode {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.(string)})
+			}
+			child, err := yamlNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, child)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}, nil
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(v.String(), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String()}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}, nil
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+}
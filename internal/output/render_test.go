@@ -0,0 +1,173 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+// capture selects f and collects the envelopes written until the test ends.
+func capture(t *testing.T, f Format) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prevFormat, prevResults, prevCommand, prevWritten := format, results, command, written
+	format, results, command, written = f, &buf, "tower stats", false
+	t.Cleanup(func() {
+		format, results, command, written = prevFormat, prevResults, prevCommand, prevWritten
+	})
+	return &buf
+}
+
+type sample struct {
+	Name  string  `json:"name"`
+	Count int     `json:"count"`
+	Rate  float64 `json:"rate"`
+	Tags  []string
+}
+
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]Format{"": FormatText, "JSON": FormatJSON, " yaml ": FormatYAML, "ndjson": FormatNDJSON} {
+		if got, err := ParseFormat(in); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("xml accepted")
+	}
+}
+
+func TestResultFormats(t *testing.T) {
+	data := sample{Name: "a\"b", Count: 2, Rate: 0.5, Tags: []string{"x"}}
+
+	buf := capture(t, FormatJSON)
+	if err := Result("moltbb.sample.v1", data); err != nil {
+		t.Fatal(err)
+	}
+	var env struct {
+		Envelope
+		Data sample `json:"data"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &env); err != nil {
+		t.Fatalf("decode %s: %v", buf, err)
+	}
+	if env.Schema != "moltbb.sample.v1" || !env.OK || env.Command != "tower stats" || env.Data.Name != `a"b` {
+		t.Fatalf("envelope = %+v", env)
+	}
+	if err := Done(); err != nil || strings.Count(buf.String(), "schema") != 1 {
+		t.Fatalf("Done wrote after a result:\n%s", buf)
+	}
+
+	buf = capture(t, FormatNDJSON)
+	_ = Result("moltbb.sample.v1", data)
+	_ = Event("moltbb.sample.v1", data)
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 2 {
+		t.Fatalf("ndjson lines = %q", lines)
+	}
+
+	buf = capture(t, FormatYAML)
+	_ = Result("moltbb.sample.v1", data)
+	want := "---\nschema: moltbb.sample.v1\nok: true\ncommand: tower stats\ndata:\n  name: a\"b\n  count: 2\n  rate: 0.5\n  Tags:\n    - x\n"
+	if buf.String() != want {
+		t.Fatalf("yaml =\n%s\nwant\n%s", buf, want)
+	}
+
+	buf = capture(t, FormatText)
+	if err := Result("moltbb.sample.v1", data); err != nil || buf.Len() != 0 {
+		t.Fatalf("text mode wrote %q", buf)
+	}
+}
+
+func TestSetFormatKeepsStdout(t *testing.T) {
+	prevFormat, prevNoColor, prevSupports := format, color.NoColor, supportsColor
+	t.Cleanup(func() { format, color.NoColor, supportsColor = prevFormat, prevNoColor, prevSupports })
+
+	stdout := os.Stdout
+	SetFormat(FormatJSON)
+	if os.Stdout != stdout {
+		t.Fatal("SetFormat replaced os.Stdout")
+	}
+	if Writer() != io.Writer(os.Stderr) {
+		t.Fatal("text should go to stderr in structured formats")
+	}
+	SetFormat(FormatText)
+	if Writer() != io.Writer(os.Stdout) {
+		t.Fatal("text should go to stdout in text format")
+	}
+}
+
+func TestDoneWithoutResult(t *testing.T) {
+	buf := capture(t, FormatNDJSON)
+	if err := Done(); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(buf.String()); got != `{"schema":"moltbb.result.v1","ok":true,"command":"tower stats"}` {
+		t.Fatalf("done = %s", got)
+	}
+}
+
+func TestFail(t *testing.T) {
+	buf := capture(t, FormatNDJSON)
+	err := WithResult(errors.New("doctor checks failed"), "moltbb.doctor.v1", map[string]int{"failed": 1})
+	if code := Fail(err); code != ExitError {
+		t.Fatalf("exit code = %d", code)
+	}
+	want := `{"schema":"moltbb.doctor.v1","ok":false,"command":"tower stats","data":{"failed":1},"error":{"code":"error","message":"doctor checks failed","exitCode":1}}`
+	if got := strings.TrimSpace(buf.String()); got != want {
+		t.Fatalf("fail =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// apiError stands in for *api.Error, which this package cannot import.
+type apiError struct {
+	status    int
+	requestID string
+}
+
+func (e apiError) Error() string           { return fmt.Sprintf("get room info failed (%d)", e.status) }
+func (e apiError) HTTPStatus() int         { return e.status }
+func (e apiError) CanRetry() bool          { return e.status == 429 || e.status >= 500 }
+func (e apiError) ServerRequestID() string { return e.requestID }
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		err    error
+		code   string
+		exit   int
+		status int
+	}{
+		{errors.New("boom"), CodeError, ExitError, 0},
+		{errors.New("get room info failed (404): not typed"), CodeError, ExitError, 0},
+		{Usage(errors.New("accepts 1 arg(s)")), CodeUsage, ExitUsage, 0},
+		{NotFound(errors.New("no such room")), CodeNotFound, ExitNotFound, 0},
+		{apiError{status: 404}, CodeNotFound, ExitNotFound, 404},
+		{apiError{status: 401}, CodeAuth, ExitAuth, 401},
+		{apiError{status: 422}, CodeValidation, ExitValidation, 422},
+		{apiError{status: 429}, CodeRateLimited, ExitRateLimited, 429},
+		{fmt.Errorf("wrap: %w", apiError{status: 503}), CodeServer, ExitUnavailable, 503},
+		{apiError{status: 418}, CodeError, ExitError, 418},
+		{fmt.Errorf("call: %w", context.DeadlineExceeded), CodeTimeout, ExitUnavailable, 0},
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, CodeNetwork, ExitUnavailable, 0},
+		{fmt.Errorf("read log directory: %w", &fs.PathError{Op: "open", Path: "logs", Err: fs.ErrNotExist}), CodeError, ExitError, 0},
+	}
+	for _, c := range cases {
+		obj := Classify(c.err)
+		if obj.Code != c.code || obj.ExitCode != c.exit || obj.Status != c.status || obj.Message != c.err.Error() {
+			t.Errorf("Classify(%q) = %+v", c.err, obj)
+		}
+	}
+
+	obj := Classify(apiError{status: 503, requestID: "req-1"})
+	if !obj.Retryable || obj.RequestID != "req-1" {
+		t.Errorf("Classify(503) = %+v", obj)
+	}
+}
@@ -0,0 +1,261 @@
+package parser
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Level string
+
+const (
+	LevelUnknown Level = ""
+	LevelDebug   Level = "debug"
+	LevelInfo    Level = "info"
+	LevelWarn    Level = "warn"
+	LevelError   Level = "error"
+)
+
+// Event is one parsed OpenClaw log line.
+type Event struct {
+	Timestamp time.Time     `json:"timestamp,omitempty"`
+	Level     Level         `json:"level,omitempty"`
+	Component string        `json:"component,omitempty"`
+	TaskID    string        `json:"taskId,omitempty"`
+	Duration  time.Duration `json:"duration,omitempty"`
+	Message   string        `json:"message"`
+}
+
+// HasTimestamp reports whether a timestamp could be parsed from the line.
+func (e Event) HasTimestamp() bool {
+	return !e.Timestamp.IsZero()
+}
+
+// CompletesTask reports whether the event marks a finished task.
+func (e Event) CompletesTask() bool {
+	if taskDoneRe.MatchString(e.Message) {
+		return e.TaskID != "" || taskWordRe.MatchString(e.Message)
+	}
+	return e.TaskID != "" && e.Duration > 0
+}
+
+var (
+	textLineRe = regexp.MustCompile(`^\[?(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?)\]?\s*(.*)$`)
+	levelRe    = regexp.MustCompile(`^(?i)(?:\[(trace|debug|info|information|notice|warn|warning|error|err|fatal|critical|crit|panic)\]|(trace|debug|info|information|notice|warn|warning|error|err|fatal|critical|crit|panic)\b:?)\s*(.*)$`)
+	bracketRe  = regexp.MustCompile(`^\[([^\]]{1,64})\]\s*(.*)$`)
+	compRe     = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9_.\-/]{0,63}):\s+(.*)$`)
+	kvLevelRe  = regexp.MustCompile(`(?i)\blevel=("?)(\w+)("?)`)
+	kvCompRe   = regexp.MustCompile(`(?i)\b(?:component|module|logger)=("?)([^\s"]+)("?)`)
+	taskIDRe   = regexp.MustCompile(`(?i)\b(?:task_id|taskid|task|job_id|job)[=:#]\s*"?([A-Za-z0-9_.\-]+)"?`)
+	durationRe = regexp.MustCompile(`(?i)\b(?:duration|elapsed|took|latency)(?:_ms)?[=:]?\s*"?(\d+(?:\.\d+)?)\s*(ns|us|µs|ms|s|m|h)?\b`)
+	durMsKeyRe = regexp.MustCompile(`(?i)\b(?:duration|elapsed|latency)_ms[=:]`)
+	taskDoneRe = regexp.MustCompile(`(?i)\b(done|complete|completed|finished|succeeded|success)\b`)
+	taskWordRe = regexp.MustCompile(`(?i)\b(task|job)s?\b`)
+	// negatedIssueRe strips phrases such as "0 errors" or "no warnings" so
+	// they are not mistaken for an actual failure.
+	negatedIssueRe = regexp.MustCompile(`(?i)\b(?:0|no|zero|without)\s+(?:errors?|failures?|failed|warnings?|warns?)\b`)
+	errorWordRe    = regexp.MustCompile(`(?i)\b(error|errors|fatal|panic|exception|failed|failure)\b`)
+	warnWordRe     = regexp.MustCompile(`(?i)\b(warn|warning|warnings)\b`)
+)
+
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// ParseLine parses a JSON or timestamped text log line into an Event.
+// Timestamps without a zone are interpreted in loc (UTC when nil).
+func ParseLine(line string, loc *time.Location) Event {
+	if loc == nil {
+		loc = time.UTC
+	}
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "{") {
+		if ev, ok := parseJSONLine(line, loc); ok {
+			return ev
+		}
+	}
+	return parseTextLine(line, loc)
+}
+
+func parseJSONLine(line string, loc *time.Location) (Event, bool) {
+	var raw map[string]any
+	if err := json.Unmarshal([]byte(line), &raw); err != nil {
+		return Event{}, false
+	}
+
+	ev := Event{
+		Message:   firstString(raw, "msg", "message", "text", "event"),
+		Component: firstString(raw, "component", "module", "logger", "source", "name"),
+		TaskID:    firstString(raw, "task_id", "taskId", "taskID", "task", "job_id", "jobId"),
+		Level:     normalizeLevel(firstString(raw, "level", "lvl", "severity", "levelname")),
+	}
+	if ts := firstString(raw, "time", "ts", "timestamp", "@timestamp", "t"); ts != "" {
+		ev.Timestamp = parseTimestamp(ts, loc)
+	} else if n, ok := firstNumber(raw, "time", "ts", "timestamp"); ok {
+		ev.Timestamp = unixTimestamp(n)
+	}
+	if n, ok := firstNumber(raw, "duration_ms", "durationMs", "elapsed_ms", "elapsedMs"); ok {
+		ev.Duration = time.Duration(n * float64(time.Millisecond))
+	} else if s := firstString(raw, "duration", "elapsed"); s != "" {
+		if d, err := time.ParseDuration(s); err == nil {
+			ev.Duration = d
+		}
+	} else if n, ok := firstNumber(raw, "duration", "elapsed"); ok {
+		ev.Duration = time.Duration(n * float64(time.Second))
+	}
+	if ev.Level == LevelUnknown {
+		ev.Level = inferLevel(ev.Message)
+	}
+	if ev.Message == "" {
+		ev.Message = line
+	}
+	return ev, true
+}
+
+func parseTextLine(line string, loc *time.Location) Event {
+	ev := Event{}
+	rest := line
+	if m := textLineRe.FindStringSubmatch(rest); m != nil {
+		ev.Timestamp = parseTimestamp(m[1], loc)
+		rest = m[2]
+	}
+	if m := levelRe.FindStringSubmatch(rest); m != nil {
+		lvl := m[1]
+		if lvl == "" {
+			lvl = m[2]
+		}
+		ev.Level = normalizeLevel(lvl)
+		rest = m[3]
+	}
+	if m := bracketRe.FindStringSubmatch(rest); m != nil {
+		ev.Component = strings.TrimSpace(m[1])
+		rest = m[2]
+	} else if m := compRe.FindStringSubmatch(rest); m != nil && ev.Level != LevelUnknown {
+		// Only trust "name: message" as a component when a level preceded it;
+		// otherwise "error: failed call" would lose its level keyword.
+		ev.Component = m[1]
+		rest = m[2]
+	}
+
+	if ev.Level == LevelUnknown {
+		if m := kvLevelRe.FindStringSubmatch(rest); m != nil {
+			ev.Level = normalizeLevel(m[2])
+		}
+	}
+	if ev.Component == "" {
+		if m := kvCompRe.FindStringSubmatch(rest); m != nil {
+			ev.Component = m[2]
+		}
+	}
+	if m := taskIDRe.FindStringSubmatch(rest); m != nil {
+		ev.TaskID = m[1]
+	}
+	if m := durationRe.FindStringSubmatch(rest); m != nil {
+		ev.Duration = parseDurationValue(m[1], m[2], durMsKeyRe.MatchString(rest))
+	}
+
+	ev.Message = strings.TrimSpace(rest)
+	if ev.Level == LevelUnknown {
+		ev.Level = inferLevel(ev.Message)
+	}
+	return ev
+}
+
+// inferLevel guesses a level from free text for lines that carry none.
+func inferLevel(message string) Level {
+	cleaned := negatedIssueRe.ReplaceAllString(message, "")
+	switch {
+	case errorWordRe.MatchString(cleaned):
+		return LevelError
+	case warnWordRe.MatchString(cleaned):
+		return LevelWarn
+	default:
+		return LevelUnknown
+	}
+}
+
+func normalizeLevel(raw string) Level {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "trace", "debug", "verbose":
+		return LevelDebug
+	case "info", "information", "notice":
+		return LevelInfo
+	case "warn", "warning":
+		return LevelWarn
+	case "error", "err", "fatal", "critical", "crit", "panic":
+		return LevelError
+	default:
+		return LevelUnknown
+	}
+}
+
+func parseTimestamp(raw string, loc *time.Location) time.Time {
+	raw = strings.Replace(strings.TrimSpace(raw), ",", ".", 1)
+	for _, layout := range timestampLayouts {
+		if t, err := time.ParseInLocation(layout, raw, loc); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func unixTimestamp(n float64) time.Time {
+	if n > 1e12 {
+		return time.UnixMilli(int64(n)).UTC()
+	}
+	sec := int64(n)
+	return time.Unix(sec, int64((n-float64(sec))*1e9)).UTC()
+}
+
+func parseDurationValue(value, unit string, millisKey bool) time.Duration {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	if unit == "" {
+		if millisKey {
+			unit = "ms"
+		} else {
+			unit = "s"
+		}
+	}
+	switch unit {
+	case "ns":
+		return time.Duration(n)
+	case "us", "µs":
+		return time.Duration(n * float64(time.Microsecond))
+	case "ms":
+		return time.Duration(n * float64(time.Millisecond))
+	case "m":
+		return time.Duration(n * float64(time.Minute))
+	case "h":
+		return time.Duration(n * float64(time.Hour))
+	default:
+		return time.Duration(n * float64(time.Second))
+	}
+}
+
+func firstString(raw map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if v, ok := raw[key].(string); ok && strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+func firstNumber(raw map[string]any, keys ...string) (float64, bool) {
+	for _, key := range keys {
+		if v, ok := raw[key].(float64); ok {
+			return v, true
+		}
+	}
+	return 0, false
+}
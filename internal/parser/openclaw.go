@@ -2,8 +2,14 @@ package parser
 
 import (
 	"bufio"
+	"compress/gzip"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -17,85 +23,269 @@ type Stats struct {
 }
 
 type Result struct {
-	Date  string `json:"date"`
-	Stats Stats  `json:"stats"`
+	Date   string  `json:"date"`
+	Stats  Stats   `json:"stats"`
+	Events []Event `json:"events,omitempty"`
+	// Location is the timezone the day was read in.
+	Location *time.Location `json:"-"`
 }
 
-func ParseOpenClawLogs(paths []string, maxLines int) (Result, error) {
+// DayOptions scopes log ingestion to a single calendar day.
+type DayOptions struct {
+	// Date is the diary day (YYYY-MM-DD); empty means today in Location.
+	Date string
+	// Location is the timezone the day boundaries are computed in
+	// (time.Local when nil). Zone-less timestamps are read in it as well.
+	Location *time.Location
+	MaxLines int
+}
+
+var rotatedSuffixRe = regexp.MustCompile(`^\.(\d+)(\.gz)?$`)
+
+// ParseOpenClawLogsForDay parses only the lines written on opts.Date. Each
+// configured path is expanded with its rotated siblings (work.log.1,
+// work.log.2.gz, ...) so backfilled days can still be read after rotation.
+// Lines without a timestamp inherit the timestamp of the preceding line.
+func ParseOpenClawLogsForDay(paths []string, opts DayOptions) (Result, error) {
 	if len(paths) == 0 {
 		return Result{}, fmt.Errorf("no input paths configured")
 	}
+	loc := opts.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	maxLines := opts.MaxLines
 	if maxLines <= 0 {
 		maxLines = 2000
 	}
 
-	merged := Result{Date: time.Now().UTC().Format("2006-01-02")}
-	remaining := maxLines
+	date := strings.TrimSpace(opts.Date)
+	if date == "" {
+		date = time.Now().In(loc).Format("2006-01-02")
+	}
+	start, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return Result{}, fmt.Errorf("invalid date %q: %w", date, err)
+	}
+	res, err := parseWindow(paths, start, start.AddDate(0, 0, 1), loc, maxLines)
+	if err != nil {
+		return Result{}, err
+	}
+	res.Date = date
+	res.Location = loc
+	return res, nil
+}
+
+// WindowOptions scopes log ingestion to [Since, Until).
+type WindowOptions struct {
+	Since time.Time
+	// Until defaults to now.
+	Until time.Time
+	// Location is used for zone-less timestamps (time.Local when nil).
+	Location *time.Location
+	MaxLines int
+}
+
+// ParseOpenClawLogsWindow parses the lines written in a time window, such
+// as the last hour, reading rotated siblings like ParseOpenClawLogsForDay.
+func ParseOpenClawLogsWindow(paths []string, opts WindowOptions) (Result, error) {
+	if len(paths) == 0 {
+		return Result{}, fmt.Errorf("no input paths configured")
+	}
+	loc := opts.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	maxLines := opts.MaxLines
+	if maxLines <= 0 {
+		maxLines = 2000
+	}
+	until := opts.Until
+	if until.IsZero() {
+		until = time.Now()
+	}
+	res, err := parseWindow(paths, opts.Since, until, loc, maxLines)
+	if err != nil {
+		return Result{}, err
+	}
+	res.Date = until.In(loc).Format("2006-01-02")
+	return res, nil
+}
 
+func parseWindow(paths []string, start, end time.Time, loc *time.Location, maxLines int) (Result, error) {
+	var merged Result
+	acc := newAccumulator(&merged)
 	for _, path := range paths {
-		if remaining <= 0 {
-			break
-		}
-		res, err := ParseOpenClawLog(path, remaining)
+		files, err := DiscoverLogFiles(path)
 		if err != nil {
-			return Result{}, fmt.Errorf("parse %s: %w", path, err)
+			return Result{}, err
+		}
+		for _, file := range files {
+			if acc.full(maxLines) {
+				return merged, nil
+			}
+			// A file last written before the window cannot contain lines from it.
+			if info, statErr := os.Stat(file); statErr == nil && info.ModTime().Before(start) {
+				continue
+			}
+			err := scanLogFile(file, func(line string, last *time.Time) bool {
+				ev := ParseLine(line, loc)
+				if ev.HasTimestamp() {
+					*last = ev.Timestamp
+				} else if last.IsZero() {
+					return true
+				} else {
+					ev.Timestamp = *last
+				}
+				if ev.Timestamp.Before(start) || !ev.Timestamp.Before(end) {
+					return true
+				}
+				acc.add(line, ev)
+				return !acc.full(maxLines)
+			})
+			if err != nil {
+				return Result{}, fmt.Errorf("parse %s: %w", file, err)
+			}
 		}
-		mergeStats(&merged.Stats, res.Stats)
-		remaining = maxLines - merged.Stats.LineCount
 	}
-
 	return merged, nil
 }
 
-func ParseOpenClawLog(path string, maxLines int) (Result, error) {
-	if maxLines <= 0 {
-		maxLines = 2000
+// DiscoverLogFiles returns path plus its rotated siblings ordered oldest first.
+// A compressed copy is older than the plain file with the same index, so
+// work.log.gz sorts between work.log.1 and work.log. Missing rotated files
+// are ignored; a missing base file is only an error when no rotated file
+// exists either.
+func DiscoverLogFiles(path string) ([]string, error) {
+	type rotated struct {
+		path       string
+		index      int
+		compressed bool
+	}
+	dir := filepath.Dir(path)
+	base := filepath.Base(path)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read log directory: %w", err)
+	}
+
+	found := make([]rotated, 0, 4)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), base) {
+			continue
+		}
+		suffix := strings.TrimPrefix(entry.Name(), base)
+		switch {
+		case suffix == "":
+			found = append(found, rotated{path: filepath.Join(dir, entry.Name()), index: 0})
+		case suffix == ".gz":
+			found = append(found, rotated{path: filepath.Join(dir, entry.Name()), index: 0, compressed: true})
+		default:
+			m := rotatedSuffixRe.FindStringSubmatch(suffix)
+			if m == nil {
+				continue
+			}
+			n, convErr := strconv.Atoi(m[1])
+			if convErr != nil {
+				continue
+			}
+			found = append(found, rotated{path: filepath.Join(dir, entry.Name()), index: n, compressed: m[2] != ""})
+		}
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("open log file: %w", os.ErrNotExist)
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].index != found[j].index {
+			return found[i].index > found[j].index
+		}
+		return found[i].compressed && !found[j].compressed
+	})
+	out := make([]string, 0, len(found))
+	for _, f := range found {
+		out = append(out, f.path)
 	}
+	return out, nil
+}
 
+// scanLogFile feeds every non-empty line of a plain or gzip-compressed file
+// to fn until fn returns false. last carries the most recent timestamp seen
+// in the file so continuation lines can be attributed.
+func scanLogFile(path string, fn func(line string, last *time.Time) bool) error {
 	f, err := os.Open(path)
 	if err != nil {
-		return Result{}, fmt.Errorf("open log file: %w", err)
+		return fmt.Errorf("open log file: %w", err)
 	}
 	defer f.Close()
 
-	scanner := bufio.NewScanner(f)
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("open gzip log file: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	scanner := bufio.NewScanner(r)
 	buf := make([]byte, 0, 64*1024)
 	scanner.Buffer(buf, 2*1024*1024)
 
-	res := Result{Date: time.Now().UTC().Format("2006-01-02")}
+	var last time.Time
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" {
 			continue
 		}
-
-		res.Stats.LineCount++
-		lower := strings.ToLower(line)
-		switch {
-		case strings.Contains(lower, "error"), strings.Contains(lower, "failed"):
-			res.Stats.ErrorCount++
-		case strings.Contains(lower, "warn"):
-			res.Stats.WarningCount++
+		if !fn(line, &last) {
+			break
 		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("scan log file: %w", err)
+	}
+	return nil
+}
 
-		if strings.Contains(lower, "task") || strings.Contains(lower, "done") || strings.Contains(lower, "complete") {
-			res.Stats.TaskCount++
-		}
+type accumulator struct {
+	res            *Result
+	completedTasks map[string]struct{}
+}
 
-		if len(res.Stats.Sample) < 8 {
-			res.Stats.Sample = append(res.Stats.Sample, line)
-		}
+func newAccumulator(res *Result) *accumulator {
+	return &accumulator{res: res, completedTasks: make(map[string]struct{})}
+}
 
-		if res.Stats.LineCount >= maxLines {
-			break
-		}
+func (a *accumulator) full(maxLines int) bool {
+	return a.res.Stats.LineCount >= maxLines
+}
+
+func (a *accumulator) add(line string, ev Event) {
+	res := a.res
+	res.Events = append(res.Events, ev)
+	res.Stats.LineCount++
+	switch ev.Level {
+	case LevelError:
+		res.Stats.ErrorCount++
+	case LevelWarn:
+		res.Stats.WarningCount++
 	}
 
-	if err := scanner.Err(); err != nil {
-		return Result{}, fmt.Errorf("scan log file: %w", err)
+	if ev.CompletesTask() {
+		if ev.TaskID == "" {
+			res.Stats.TaskCount++
+		} else if _, seen := a.completedTasks[ev.TaskID]; !seen {
+			a.completedTasks[ev.TaskID] = struct{}{}
+			res.Stats.TaskCount++
+		}
 	}
 
-	return res, nil
+	if len(res.Stats.Sample) < 8 {
+		res.Stats.Sample = append(res.Stats.Sample, line)
+	}
 }
 
 func mergeStats(target *Stats, source Stats) {
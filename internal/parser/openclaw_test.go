@@ -1,22 +1,31 @@
 package parser
 
 import (
+	"compress/gzip"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
+// parseDay reads 2026-03-01 (UTC) from paths.
+func parseDay(paths ...string) (Result, error) {
+	return ParseOpenClawLogsForDay(paths, DayOptions{Date: "2026-03-01", Location: time.UTC, MaxLines: 100})
+}
+
 func TestParseOpenClawLog_BasicStats(t *testing.T) {
 	tmp := t.TempDir()
 	logPath := filepath.Join(tmp, "work.log")
-	content := "task started\nwarn: retrying\nerror: failed call\ndone task\n"
+	content := "2026-03-01T10:00:00Z task started\n2026-03-01T10:00:01Z warn: retrying\n" +
+		"2026-03-01T10:00:02Z error: failed call\n2026-03-01T10:00:03Z done task\n"
 	if err := os.WriteFile(logPath, []byte(content), 0o600); err != nil {
 		t.Fatalf("write temp log: %v", err)
 	}
 
-	result, err := ParseOpenClawLog(logPath, 100)
+	result, err := parseDay(logPath)
 	if err != nil {
-		t.Fatalf("ParseOpenClawLog error: %v", err)
+		t.Fatalf("ParseOpenClawLogsForDay error: %v", err)
 	}
 
 	if result.Stats.LineCount != 4 {
@@ -38,16 +47,16 @@ func TestParseOpenClawLogs_MergeMultipleFiles(t *testing.T) {
 	logA := filepath.Join(tmp, "a.log")
 	logB := filepath.Join(tmp, "b.log")
 
-	if err := os.WriteFile(logA, []byte("task a\nwarn a\n"), 0o600); err != nil {
+	if err := os.WriteFile(logA, []byte("2026-03-01T09:00:00Z task a\n2026-03-01T09:00:01Z warn a\n"), 0o600); err != nil {
 		t.Fatalf("write logA: %v", err)
 	}
-	if err := os.WriteFile(logB, []byte("error b\ndone b\n"), 0o600); err != nil {
+	if err := os.WriteFile(logB, []byte("2026-03-01T09:00:02Z error b\n2026-03-01T09:00:03Z done b\n"), 0o600); err != nil {
 		t.Fatalf("write logB: %v", err)
 	}
 
-	result, err := ParseOpenClawLogs([]string{logA, logB}, 100)
+	result, err := parseDay(logA, logB)
 	if err != nil {
-		t.Fatalf("ParseOpenClawLogs error: %v", err)
+		t.Fatalf("ParseOpenClawLogsForDay error: %v", err)
 	}
 
 	if result.Stats.LineCount != 4 {
@@ -60,3 +69,180 @@ func TestParseOpenClawLogs_MergeMultipleFiles(t *testing.T) {
 		t.Fatalf("expected merged error_count=1, got %d", result.Stats.ErrorCount)
 	}
 }
+
+func TestParseOpenClawLog_IgnoresNegatedErrors(t *testing.T) {
+	tmp := t.TempDir()
+	logPath := filepath.Join(tmp, "work.log")
+	content := "2026-03-01T10:00:00Z [INFO] [runner] batch finished with 0 errors\n" +
+		"2026-03-01T10:01:00Z INFO no warnings reported\n"
+	if err := os.WriteFile(logPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("write temp log: %v", err)
+	}
+
+	result, err := parseDay(logPath)
+	if err != nil {
+		t.Fatalf("ParseOpenClawLogsForDay error: %v", err)
+	}
+	if result.Stats.ErrorCount != 0 || result.Stats.WarningCount != 0 {
+		t.Fatalf("expected no errors/warnings, got %+v", result.Stats)
+	}
+	if len(result.Events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(result.Events))
+	}
+}
+
+func TestParseLine_TextFormat(t *testing.T) {
+	ev := ParseLine("2026-03-01 10:00:05.123 ERROR [scheduler] task_id=t-42 sync failed duration=1.5s", time.UTC)
+	if ev.Level != LevelError {
+		t.Fatalf("expected error level, got %q", ev.Level)
+	}
+	if ev.Component != "scheduler" {
+		t.Fatalf("expected component scheduler, got %q", ev.Component)
+	}
+	if ev.TaskID != "t-42" {
+		t.Fatalf("expected task id t-42, got %q", ev.TaskID)
+	}
+	if ev.Duration != 1500*time.Millisecond {
+		t.Fatalf("expected 1.5s duration, got %v", ev.Duration)
+	}
+	want := time.Date(2026, 3, 1, 10, 0, 5, 123_000_000, time.UTC)
+	if !ev.Timestamp.Equal(want) {
+		t.Fatalf("expected timestamp %v, got %v", want, ev.Timestamp)
+	}
+}
+
+func TestParseLine_JSONFormat(t *testing.T) {
+	line := `{"ts":"2026-03-01T08:30:00+08:00","level":"warn","component":"fetcher","taskId":"abc","duration_ms":250,"msg":"task completed slowly"}`
+	ev := ParseLine(line, time.UTC)
+	if ev.Level != LevelWarn || ev.Component != "fetcher" || ev.TaskID != "abc" {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+	if ev.Duration != 250*time.Millisecond {
+		t.Fatalf("expected 250ms, got %v", ev.Duration)
+	}
+	if !ev.Timestamp.Equal(time.Date(2026, 3, 1, 0, 30, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected timestamp: %v", ev.Timestamp)
+	}
+	if !ev.CompletesTask() {
+		t.Fatalf("expected event to complete a task")
+	}
+	if ev.Message != "task completed slowly" {
+		t.Fatalf("unexpected message: %q", ev.Message)
+	}
+}
+
+func TestParseOpenClawLog_CountsDistinctTasks(t *testing.T) {
+	tmp := t.TempDir()
+	logPath := filepath.Join(tmp, "work.log")
+	content := `{"ts":"2026-03-01T10:00:00Z","level":"info","task_id":"a","msg":"task done"}
+{"ts":"2026-03-01T10:00:01Z","level":"info","task_id":"a","msg":"task done"}
+{"ts":"2026-03-01T10:00:02Z","level":"info","task_id":"b","msg":"finished","duration_ms":10}
+{"ts":"2026-03-01T10:00:03Z","level":"info","msg":"task started"}
+`
+	if err := os.WriteFile(logPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("write temp log: %v", err)
+	}
+	result, err := parseDay(logPath)
+	if err != nil {
+		t.Fatalf("ParseOpenClawLogsForDay error: %v", err)
+	}
+	if result.Stats.TaskCount != 2 {
+		t.Fatalf("expected 2 distinct tasks, got %d", result.Stats.TaskCount)
+	}
+}
+
+func TestParseOpenClawLogsForDay_FiltersWindowAcrossRotatedFiles(t *testing.T) {
+	tmp := t.TempDir()
+	logPath := filepath.Join(tmp, "work.log")
+
+	current := "2026-03-02T01:00:00+08:00 INFO task_id=c done\n" +
+		"2026-03-02T09:00:00+08:00 INFO next day line\n"
+	rotated := "2026-03-01T08:00:00+08:00 ERROR [net] request failed\n" +
+		"  at stack frame continuation\n" +
+		"2026-03-01T23:59:00+08:00 INFO task_id=b done\n"
+	old := "2026-02-28T23:00:00+08:00 INFO task_id=a done\n" +
+		"2026-03-01T00:00:00+08:00 WARN [disk] low space\n"
+
+	if err := os.WriteFile(logPath, []byte(current), 0o600); err != nil {
+		t.Fatalf("write current log: %v", err)
+	}
+	if err := os.WriteFile(logPath+".1", []byte(rotated), 0o600); err != nil {
+		t.Fatalf("write rotated log: %v", err)
+	}
+	gzFile, err := os.Create(logPath + ".2.gz")
+	if err != nil {
+		t.Fatalf("create gz log: %v", err)
+	}
+	gz := gzip.NewWriter(gzFile)
+	if _, err := gz.Write([]byte(old)); err != nil {
+		t.Fatalf("write gz log: %v", err)
+	}
+	_ = gz.Close()
+	_ = gzFile.Close()
+
+	files, err := DiscoverLogFiles(logPath)
+	if err != nil {
+		t.Fatalf("DiscoverLogFiles error: %v", err)
+	}
+	if len(files) != 3 || filepath.Base(files[0]) != "work.log.2.gz" || filepath.Base(files[2]) != "work.log" {
+		t.Fatalf("unexpected discovery order: %v", files)
+	}
+
+	shanghai := time.FixedZone("CST", 8*3600)
+	result, err := ParseOpenClawLogsForDay([]string{logPath}, DayOptions{Date: "2026-03-01", Location: shanghai})
+	if err != nil {
+		t.Fatalf("ParseOpenClawLogsForDay error: %v", err)
+	}
+	if result.Date != "2026-03-01" {
+		t.Fatalf("expected result date 2026-03-01, got %s", result.Date)
+	}
+	if result.Stats.LineCount != 4 {
+		t.Fatalf("expected 4 lines in window, got %d (%v)", result.Stats.LineCount, result.Stats.Sample)
+	}
+	if result.Stats.ErrorCount != 1 || result.Stats.WarningCount != 1 || result.Stats.TaskCount != 1 {
+		t.Fatalf("unexpected stats: %+v", result.Stats)
+	}
+}
+
+func TestDiscoverLogFiles_OrdersCompressedCopies(t *testing.T) {
+	tmp := t.TempDir()
+	logPath := filepath.Join(tmp, "work.log")
+	for _, name := range []string{"work.log", "work.log.gz", "work.log.1", "work.log.2", "work.log.2.gz", "other.log"} {
+		if err := os.WriteFile(filepath.Join(tmp, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err := DiscoverLogFiles(logPath)
+	if err != nil {
+		t.Fatalf("DiscoverLogFiles error: %v", err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, filepath.Base(f))
+	}
+	want := "work.log.2.gz,work.log.2,work.log.1,work.log.gz,work.log"
+	if got := strings.Join(names, ","); got != want {
+		t.Fatalf("discovery order = %s, want %s", got, want)
+	}
+}
+
+func TestParseOpenClawLogsWindow_LastHour(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "work.log")
+	content := "2026-03-01T09:10:00Z INFO task_id=a done\n" +
+		"2026-03-01T10:05:00Z INFO task_id=b done\n" +
+		"2026-03-01T10:20:00Z ERROR [net] request failed\n" +
+		"2026-03-01T10:40:00Z INFO task_id=c finished duration=3s\n" +
+		"2026-03-01T11:30:00Z INFO task_id=d done\n"
+	if err := os.WriteFile(logPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+
+	until := time.Date(2026, 3, 1, 11, 0, 0, 0, time.UTC)
+	result, err := ParseOpenClawLogsWindow([]string{logPath}, WindowOptions{Since: until.Add(-time.Hour), Until: until, Location: time.UTC})
+	if err != nil {
+		t.Fatalf("ParseOpenClawLogsWindow error: %v", err)
+	}
+	if result.Stats.LineCount != 3 || result.Stats.TaskCount != 2 || result.Stats.ErrorCount != 1 {
+		t.Fatalf("unexpected stats: %+v", result.Stats)
+	}
+}
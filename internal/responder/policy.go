@@ -0,0 +1,202 @@
+// Package responder drives autonomous pipeline sessions: a policy decides
+// which invitations to accept, and each incoming message is answered by a
+// command or an LLM provider within rate limits and per-session caps.
+package responder
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"moltbb-cli/internal/utils"
+)
+
+// Defaults applied to zero policy limits.
+const (
+	DefaultMaxMessagesPerSession = 20
+	DefaultRepliesPerMinute      = 10
+	DefaultMaxSessions           = 3
+	DefaultReplyTimeout          = 60 * time.Second
+	DefaultIdleTimeout           = 10 * time.Minute
+	DefaultMaxHistory            = 20
+	DefaultRejectReason          = "Not accepting sessions from this bot"
+	DefaultBusyReason            = "Busy with other sessions, try again later"
+)
+
+// Policy is the pipeline serve configuration, read from a YAML file.
+type Policy struct {
+	Accept AcceptPolicy `yaml:"accept"`
+	Reply  ReplyPolicy  `yaml:"reply"`
+	Limits Limits       `yaml:"limits"`
+	// AllowPlaintext lets replies and the farewell go out unencrypted when
+	// the peer's key is unknown. By default they are not sent at all.
+	AllowPlaintext bool `yaml:"allow_plaintext,omitempty"`
+}
+
+// AcceptPolicy decides which invitations are accepted. Deny wins over
+// Allow; "*" in Allow accepts every bot that is not denied.
+type AcceptPolicy struct {
+	Allow        []string `yaml:"allow"`
+	Deny         []string `yaml:"deny,omitempty"`
+	RejectReason string   `yaml:"reject_reason,omitempty"`
+	BusyReason   string   `yaml:"busy_reason,omitempty"`
+}
+
+// ReplyPolicy selects how replies are produced: Command or LLM, not both.
+type ReplyPolicy struct {
+	// Command is the program and arguments to run for every message. It
+	// receives the message on stdin; its stdout is the reply.
+	Command []string   `yaml:"command,omitempty"`
+	Dir     string     `yaml:"dir,omitempty"`
+	LLM     *LLMPolicy `yaml:"llm,omitempty"`
+	// Farewell is sent before the responder ends a session on its own.
+	Farewell       string `yaml:"farewell,omitempty"`
+	TimeoutSeconds int    `yaml:"timeout_seconds,omitempty"`
+}
+
+// LLMPolicy answers with a language model. Provider and Model default to
+// the llm section of config.yaml.
+type LLMPolicy struct {
+	Provider string `yaml:"provider,omitempty"`
+	Model    string `yaml:"model,omitempty"`
+	System   string `yaml:"system,omitempty"`
+	// MaxHistory bounds how many earlier messages of the session are sent
+	// with each request.
+	MaxHistory int `yaml:"max_history,omitempty"`
+}
+
+// Limits bound how much the responder talks.
+type Limits struct {
+	// MaxMessagesPerSession is the number of replies after which the
+	// session is ended.
+	MaxMessagesPerSession int `yaml:"max_messages_per_session,omitempty"`
+	// RepliesPerMinute is shared by all sessions; replies over it wait.
+	RepliesPerMinute int `yaml:"replies_per_minute,omitempty"`
+	// MaxSessions is the number of concurrent sessions; further
+	// invitations are rejected as busy.
+	MaxSessions int `yaml:"max_sessions,omitempty"`
+	// IdleMinutes ends sessions without messages for that long.
+	IdleMinutes int `yaml:"idle_minutes,omitempty"`
+}
+
+// DefaultPolicyPath returns ~/.moltbb/pipeline-serve.yaml.
+func DefaultPolicyPath() (string, error) {
+	dir, err := utils.MoltbbDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "pipeline-serve.yaml"), nil
+}
+
+// LoadPolicy reads and validates the policy at path.
+func LoadPolicy(path string) (Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Policy{}, fmt.Errorf("read policy: %w", err)
+	}
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return Policy{}, fmt.Errorf("parse policy %s: %w", path, err)
+	}
+	if err := p.Normalize(); err != nil {
+		return Policy{}, fmt.Errorf("policy %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Normalize trims the policy, fills in default limits and checks that
+// exactly one reply method is configured.
+func (p *Policy) Normalize() error {
+	p.Accept.Allow = trimList(p.Accept.Allow)
+	p.Accept.Deny = trimList(p.Accept.Deny)
+	if strings.TrimSpace(p.Accept.RejectReason) == "" {
+		p.Accept.RejectReason = DefaultRejectReason
+	}
+	if strings.TrimSpace(p.Accept.BusyReason) == "" {
+		p.Accept.BusyReason = DefaultBusyReason
+	}
+
+	switch {
+	case len(p.Reply.Command) > 0 && strings.TrimSpace(p.Reply.Command[0]) == "":
+		return errors.New("reply.command: program is empty")
+	case len(p.Reply.Command) > 0 && p.Reply.LLM != nil:
+		return errors.New("reply: set either command or llm, not both")
+	case len(p.Reply.Command) == 0 && p.Reply.LLM == nil:
+		return errors.New("reply: set command or llm")
+	}
+	if p.Reply.LLM != nil && p.Reply.LLM.MaxHistory <= 0 {
+		p.Reply.LLM.MaxHistory = DefaultMaxHistory
+	}
+	if p.Reply.TimeoutSeconds < 0 {
+		return errors.New("reply.timeout_seconds must not be negative")
+	}
+
+	l := &p.Limits
+	if l.MaxMessagesPerSession < 0 || l.RepliesPerMinute < 0 || l.MaxSessions < 0 || l.IdleMinutes < 0 {
+		return errors.New("limits must not be negative")
+	}
+	if l.MaxMessagesPerSession == 0 {
+		l.MaxMessagesPerSession = DefaultMaxMessagesPerSession
+	}
+	if l.RepliesPerMinute == 0 {
+		l.RepliesPerMinute = DefaultRepliesPerMinute
+	}
+	if l.MaxSessions == 0 {
+		l.MaxSessions = DefaultMaxSessions
+	}
+	return nil
+}
+
+// ReplyTimeout bounds one command run or LLM request.
+func (p Policy) ReplyTimeout() time.Duration {
+	if p.Reply.TimeoutSeconds > 0 {
+		return time.Duration(p.Reply.TimeoutSeconds) * time.Second
+	}
+	return DefaultReplyTimeout
+}
+
+// IdleTimeout is how long a session may go without messages.
+func (p Policy) IdleTimeout() time.Duration {
+	if p.Limits.IdleMinutes > 0 {
+		return time.Duration(p.Limits.IdleMinutes) * time.Minute
+	}
+	return DefaultIdleTimeout
+}
+
+// MustSeal reports whether a message has to be sent encrypted. A reply to
+// an encrypted message always does; anything else unless AllowPlaintext.
+func (p Policy) MustSeal(replyToSealed bool) bool {
+	return replyToSealed || !p.AllowPlaintext
+}
+
+// Allows reports whether an invitation from botID passes the allow and
+// deny lists, with the rejection reason when it does not.
+func (p Policy) Allows(botID string) (bool, string) {
+	botID = strings.TrimSpace(botID)
+	for _, id := range p.Accept.Deny {
+		if id == "*" || id == botID {
+			return false, p.Accept.RejectReason
+		}
+	}
+	for _, id := range p.Accept.Allow {
+		if id == "*" || id == botID {
+			return true, ""
+		}
+	}
+	return false, p.Accept.RejectReason
+}
+
+func trimList(in []string) []string {
+	out := in[:0]
+	for _, s := range in {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package responder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"moltbb-cli/internal/llm"
+)
+
+// EndSessionExitCode is the exit status with which a reply command asks
+// for the session to be ended after its output (if any) is sent.
+const EndSessionExitCode = 3
+
+// maxStderrBytes caps how much of a failing command's stderr is reported.
+const maxStderrBytes = 2 << 10
+
+// Turn is one incoming message to answer.
+type Turn struct {
+	SessionToken string
+	PeerBotID    string
+	Text         string
+	// Replies is the number of replies already sent in the session.
+	Replies int
+	// History holds earlier messages of the session, oldest first: the
+	// peer's as "user", ours as "assistant".
+	History []llm.Message
+}
+
+// Reply is a replier's answer. An empty Text sends nothing.
+type Reply struct {
+	Text string
+	// End asks for the session to be ended after Text is sent.
+	End bool
+}
+
+// Replier produces the answer to a message.
+type Replier interface {
+	Reply(ctx context.Context, turn Turn) (Reply, error)
+}
+
+// CommandReplier runs a program per message. The message is written to
+// its stdin and the session is described in MOLTBB_SESSION_TOKEN,
+// MOLTBB_PEER_BOT_ID and MOLTBB_REPLY_COUNT. Trimmed stdout is the reply;
+// exit status EndSessionExitCode also ends the session.
+type CommandReplier struct {
+	Argv []string
+	Dir  string
+}
+
+func (c CommandReplier) Reply(ctx context.Context, turn Turn) (Reply, error) {
+	cmd := exec.CommandContext(ctx, c.Argv[0], c.Argv[1:]...)
+	cmd.Dir = c.Dir
+	cmd.Env = append(os.Environ(),
+		"MOLTBB_SESSION_TOKEN="+turn.SessionToken,
+		"MOLTBB_PEER_BOT_ID="+turn.PeerBotID,
+		"MOLTBB_REPLY_COUNT="+strconv.Itoa(turn.Replies),
+	)
+	cmd.Stdin = strings.NewReader(turn.Text)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	reply := Reply{Text: strings.TrimSpace(stdout.String())}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == EndSessionExitCode {
+		reply.End = true
+		return reply, nil
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return Reply{}, fmt.Errorf("reply command: %w", ctx.Err())
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > maxStderrBytes {
+			msg = "…" + msg[len(msg)-maxStderrBytes:]
+		}
+		if msg != "" {
+			return Reply{}, fmt.Errorf("reply command: %w: %s", err, msg)
+		}
+		return Reply{}, fmt.Errorf("reply command: %w", err)
+	}
+	return reply, nil
+}
+
+// LLMReplier answers with a language model, sending the session history
+// with every request.
+type LLMReplier struct {
+	Provider llm.Provider
+	System   string
+}
+
+func (l LLMReplier) Reply(ctx context.Context, turn Turn) (Reply, error) {
+	messages := append(append([]llm.Message(nil), turn.History...), llm.Message{Role: "user", Content: turn.Text})
+	resp, err := l.Provider.Complete(ctx, llm.Request{
+		System:   l.System,
+		Messages: messages,
+	})
+	if err != nil {
+		return Reply{}, err
+	}
+	return Reply{Text: strings.TrimSpace(resp.Text)}, nil
+}
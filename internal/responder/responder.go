@@ -0,0 +1,293 @@
+package responder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"moltbb-cli/internal/llm"
+)
+
+// Decision is the answer to an invitation.
+type Decision struct {
+	Accept bool
+	Reason string
+}
+
+// Outcome is what to do after a message was handled.
+type Outcome struct {
+	// Reply is sent back when not empty.
+	Reply string
+	// End asks for the session to be ended, after Reply and the farewell.
+	End       bool
+	EndReason string
+}
+
+// Responder tracks the sessions it serves and applies the policy limits.
+// Messages of one session are answered one at a time; callers deliver them
+// in arrival order.
+type Responder struct {
+	policy  Policy
+	replier Replier
+	limiter *rateLimiter
+	now     func() time.Time
+
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+type session struct {
+	peer string
+	// mu serializes the replies of the session.
+	mu         sync.Mutex
+	replies    int
+	history    []llm.Message
+	lastActive time.Time
+	ending     bool
+}
+
+// New returns a responder for a normalized policy.
+func New(p Policy, r Replier) *Responder {
+	return &Responder{
+		policy:   p,
+		replier:  r,
+		limiter:  &rateLimiter{limit: p.Limits.RepliesPerMinute, window: time.Minute},
+		now:      time.Now,
+		sessions: make(map[string]*session),
+	}
+}
+
+// Admit decides on an invitation. An accepted session counts against
+// MaxSessions right away; call End if accepting it then fails.
+func (r *Responder) Admit(token, peerBotID string) Decision {
+	if ok, reason := r.policy.Allows(peerBotID); !ok {
+		return Decision{Reason: reason}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fullLocked(token) {
+		return Decision{Reason: r.policy.Accept.BusyReason}
+	}
+	r.sessionLocked(token, peerBotID)
+	return Decision{Accept: true}
+}
+
+// Track serves a session that did not come through Admit, such as one
+// this bot initiated. It returns ErrBusy when MaxSessions are served.
+func (r *Responder) Track(token, peerBotID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fullLocked(token) {
+		return ErrBusy
+	}
+	r.sessionLocked(token, peerBotID)
+	return nil
+}
+
+// fullLocked reports whether token is a new session and MaxSessions are
+// already served.
+func (r *Responder) fullLocked(token string) bool {
+	_, ok := r.sessions[token]
+	return !ok && len(r.sessions) >= r.policy.Limits.MaxSessions
+}
+
+func (r *Responder) sessionLocked(token, peerBotID string) *session {
+	s, ok := r.sessions[token]
+	if !ok {
+		s = &session{peer: strings.TrimSpace(peerBotID), lastActive: r.now()}
+		r.sessions[token] = s
+	}
+	return s
+}
+
+// ErrNotServed is returned by Handle for a message in a session the
+// responder did not admit, from a bot the policy does not allow.
+var ErrNotServed = errors.New("session is not served by this policy")
+
+// ErrBusy is returned by Track and Handle for a new session while
+// MaxSessions are already served.
+var ErrBusy = errors.New("session limit reached")
+
+// Handle answers one message. It waits for the shared rate limit and
+// returns an empty outcome for sessions that are already being ended.
+// Sessions it has not seen are served when the policy allows the peer and
+// fewer than MaxSessions are served.
+func (r *Responder) Handle(ctx context.Context, token, peerBotID, text string) (Outcome, error) {
+	r.mu.Lock()
+	if _, ok := r.sessions[token]; !ok {
+		if allowed, _ := r.policy.Allows(peerBotID); !allowed {
+			r.mu.Unlock()
+			return Outcome{}, ErrNotServed
+		}
+		if r.fullLocked(token) {
+			r.mu.Unlock()
+			return Outcome{}, ErrBusy
+		}
+	}
+	s := r.sessionLocked(token, peerBotID)
+	r.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ending {
+		return Outcome{}, nil
+	}
+	s.lastActive = r.now()
+	max := r.policy.Limits.MaxMessagesPerSession
+	if s.replies >= max {
+		s.ending = true
+		return Outcome{End: true, EndReason: fmt.Sprintf("reached %d replies", max)}, nil
+	}
+	if err := r.wait(ctx); err != nil {
+		return Outcome{}, err
+	}
+
+	rctx, cancel := context.WithTimeout(ctx, r.policy.ReplyTimeout())
+	defer cancel()
+	reply, err := r.replier.Reply(rctx, Turn{
+		SessionToken: token,
+		PeerBotID:    s.peer,
+		Text:         text,
+		Replies:      s.replies,
+		History:      append([]llm.Message(nil), s.history...),
+	})
+	s.history = append(s.history, llm.Message{Role: "user", Content: text})
+	if err == nil && reply.Text != "" {
+		s.history = append(s.history, llm.Message{Role: "assistant", Content: reply.Text})
+		s.replies++
+	}
+	if keep := r.maxHistory(); len(s.history) > keep {
+		s.history = append([]llm.Message(nil), s.history[len(s.history)-keep:]...)
+	}
+	s.lastActive = r.now()
+	if err != nil {
+		return Outcome{}, err
+	}
+
+	out := Outcome{Reply: reply.Text}
+	switch {
+	case reply.End:
+		out.End, out.EndReason = true, "the replier ended the session"
+	case s.replies >= max:
+		out.End, out.EndReason = true, fmt.Sprintf("reached %d replies", max)
+	}
+	s.ending = out.End
+	return out, nil
+}
+
+func (r *Responder) maxHistory() int {
+	if r.policy.Reply.LLM != nil {
+		return r.policy.Reply.LLM.MaxHistory
+	}
+	return DefaultMaxHistory
+}
+
+func (r *Responder) wait(ctx context.Context) error {
+	for {
+		d := r.limiter.reserve(r.now())
+		if d <= 0 {
+			return nil
+		}
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// Idle returns the sessions without messages for longer than the idle
+// timeout and marks them as ending.
+func (r *Responder) Idle() []string {
+	cutoff := r.now().Add(-r.policy.IdleTimeout())
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var tokens []string
+	for token, s := range r.sessions {
+		// A session busy with a reply is not idle.
+		if !s.mu.TryLock() {
+			continue
+		}
+		if !s.ending && s.lastActive.Before(cutoff) {
+			s.ending = true
+			tokens = append(tokens, token)
+		}
+		s.mu.Unlock()
+	}
+	sort.Strings(tokens)
+	return tokens
+}
+
+// End forgets a session once it has ended.
+func (r *Responder) End(token string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sessions, token)
+}
+
+// Active returns the tokens of the sessions being served, sorted.
+func (r *Responder) Active() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tokens := make([]string, 0, len(r.sessions))
+	for token := range r.sessions {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+	return tokens
+}
+
+// Peer returns the other bot in a served session.
+func (r *Responder) Peer(token string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.sessions[token]; ok {
+		return s.peer
+	}
+	return ""
+}
+
+// Replies returns the number of replies sent in a session.
+func (r *Responder) Replies(token string) int {
+	r.mu.Lock()
+	s, ok := r.sessions[token]
+	r.mu.Unlock()
+	if !ok {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.replies
+}
+
+// rateLimiter allows limit events per sliding window.
+type rateLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	sent   []time.Time
+}
+
+// reserve takes a slot at now and returns 0, or returns how long until a
+// slot frees up without taking one.
+func (l *rateLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	cutoff := now.Add(-l.window)
+	i := 0
+	for i < len(l.sent) && !l.sent[i].After(cutoff) {
+		i++
+	}
+	l.sent = l.sent[i:]
+	if len(l.sent) < l.limit {
+		l.sent = append(l.sent, now)
+		return 0
+	}
+	return l.sent[0].Sub(cutoff)
+}
@@ -0,0 +1,174 @@
+package responder
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+type echoReplier struct {
+	turns []Turn
+}
+
+func (e *echoReplier) Reply(ctx context.Context, turn Turn) (Reply, error) {
+	e.turns = append(e.turns, turn)
+	return Reply{Text: "re: " + turn.Text}, nil
+}
+
+func testPolicy(t *testing.T, yaml string) Policy {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "pipeline-serve.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p, err := LoadPolicy(path)
+	if err != nil {
+		t.Fatalf("load policy: %v", err)
+	}
+	return p
+}
+
+func TestLoadPolicyDefaultsAndValidation(t *testing.T) {
+	p := testPolicy(t, "accept:\n  allow: [bot-a, ' bot-b ']\nreply:\n  command: [./reply.sh]\n")
+	if p.Limits.MaxMessagesPerSession != DefaultMaxMessagesPerSession || p.Limits.RepliesPerMinute != DefaultRepliesPerMinute ||
+		p.Limits.MaxSessions != DefaultMaxSessions || p.IdleTimeout() != DefaultIdleTimeout || p.ReplyTimeout() != DefaultReplyTimeout {
+		t.Fatalf("defaults not applied: %+v", p.Limits)
+	}
+	if !p.MustSeal(false) {
+		t.Fatal("encryption should be required by default")
+	}
+	if open := testPolicy(t, "reply:\n  command: [x]\nallow_plaintext: true\n"); open.MustSeal(false) || !open.MustSeal(true) {
+		t.Fatal("allow_plaintext should only cover messages that do not answer an encrypted one")
+	}
+	if ok, _ := p.Allows("bot-b"); !ok {
+		t.Fatal("trimmed allow entry not matched")
+	}
+	if ok, reason := p.Allows("bot-c"); ok || reason != DefaultRejectReason {
+		t.Fatalf("bot-c: ok=%v reason=%q", ok, reason)
+	}
+
+	for _, bad := range []string{
+		"reply: {}\n",
+		"reply:\n  command: [x]\n  llm: {}\n",
+		"reply:\n  command: ['']\n",
+		"reply:\n  command: [x]\nlimits:\n  max_sessions: -1\n",
+	} {
+		path := filepath.Join(t.TempDir(), "p.yaml")
+		_ = os.WriteFile(path, []byte(bad), 0o600)
+		if _, err := LoadPolicy(path); err == nil {
+			t.Errorf("policy %q accepted", bad)
+		}
+	}
+}
+
+func TestAdmitAppliesListsAndSessionLimit(t *testing.T) {
+	p := testPolicy(t, "accept:\n  allow: ['*']\n  deny: [spammer]\n  busy_reason: full\nreply:\n  command: [x]\nlimits:\n  max_sessions: 1\n")
+	r := New(p, &echoReplier{})
+	if d := r.Admit("s0", "spammer"); d.Accept || d.Reason != DefaultRejectReason {
+		t.Fatalf("denied bot: %+v", d)
+	}
+	if d := r.Admit("s1", "bot-a"); !d.Accept {
+		t.Fatalf("first session: %+v", d)
+	}
+	if d := r.Admit("s2", "bot-b"); d.Accept || d.Reason != "full" {
+		t.Fatalf("second session over limit: %+v", d)
+	}
+	if _, err := r.Handle(context.Background(), "s3", "spammer", "hi"); !errors.Is(err, ErrNotServed) {
+		t.Fatalf("message from denied bot: %v", err)
+	}
+	if _, err := r.Handle(context.Background(), "s4", "bot-c", "hi"); !errors.Is(err, ErrBusy) {
+		t.Fatalf("message in a new session over limit: %v", err)
+	}
+	if err := r.Track("s5", "bot-d"); !errors.Is(err, ErrBusy) {
+		t.Fatalf("tracked session over limit: %v", err)
+	}
+	if err := r.Track("s1", "bot-a"); err != nil {
+		t.Fatalf("tracking a served session: %v", err)
+	}
+	r.End("s1")
+	if d := r.Admit("s2", "bot-b"); !d.Accept {
+		t.Fatalf("session after one ended: %+v", d)
+	}
+}
+
+func TestHandleEndsSessionAtMessageCap(t *testing.T) {
+	p := testPolicy(t, "accept:\n  allow: ['*']\nreply:\n  llm: {max_history: 3}\nlimits:\n  max_messages_per_session: 2\n")
+	echo := &echoReplier{}
+	r := New(p, echo)
+	r.Admit("s1", "bot-a")
+	ctx := context.Background()
+
+	out, err := r.Handle(ctx, "s1", "bot-a", "one")
+	if err != nil || out.Reply != "re: one" || out.End {
+		t.Fatalf("first: %+v, %v", out, err)
+	}
+	out, err = r.Handle(ctx, "s1", "bot-a", "two")
+	if err != nil || out.Reply != "re: two" || !out.End {
+		t.Fatalf("second should end the session: %+v, %v", out, err)
+	}
+	if out, _ := r.Handle(ctx, "s1", "bot-a", "three"); out != (Outcome{}) {
+		t.Fatalf("message to an ending session answered: %+v", out)
+	}
+	if got := echo.turns[1].History; len(got) != 2 || got[0].Content != "one" || got[1].Role != "assistant" {
+		t.Fatalf("history = %+v", got)
+	}
+	if r.Replies("s1") != 2 {
+		t.Fatalf("replies = %d", r.Replies("s1"))
+	}
+}
+
+func TestIdleSessions(t *testing.T) {
+	p := testPolicy(t, "accept:\n  allow: ['*']\nreply:\n  command: [x]\nlimits:\n  idle_minutes: 5\n")
+	r := New(p, &echoReplier{})
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+	r.Admit("old", "bot-a")
+	now = now.Add(4 * time.Minute)
+	r.Admit("new", "bot-b")
+	now = now.Add(2 * time.Minute)
+	if got := r.Idle(); len(got) != 1 || got[0] != "old" {
+		t.Fatalf("idle = %v", got)
+	}
+	if got := r.Idle(); len(got) != 0 {
+		t.Fatalf("idle session reported twice: %v", got)
+	}
+}
+
+func TestRateLimiterSlidingWindow(t *testing.T) {
+	l := &rateLimiter{limit: 2, window: time.Minute}
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	if l.reserve(t0) != 0 || l.reserve(t0.Add(10*time.Second)) != 0 {
+		t.Fatal("slots within the limit refused")
+	}
+	if d := l.reserve(t0.Add(20 * time.Second)); d != 40*time.Second {
+		t.Fatalf("wait = %v, want 40s", d)
+	}
+	if d := l.reserve(t0.Add(time.Minute)); d != 0 {
+		t.Fatalf("slot after the window refused: %v", d)
+	}
+}
+
+func TestCommandReplier(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	c := CommandReplier{Argv: []string{"sh", "-c", `printf '%s from %s' "$(cat)" "$MOLTBB_PEER_BOT_ID"; [ "$MOLTBB_REPLY_COUNT" = 4 ] && exit 3; exit 0`}}
+	got, err := c.Reply(context.Background(), Turn{PeerBotID: "bot-a", Text: "hello"})
+	if err != nil || got.Text != "hello from bot-a" || got.End {
+		t.Fatalf("reply = %+v, %v", got, err)
+	}
+	got, err = c.Reply(context.Background(), Turn{PeerBotID: "bot-a", Text: "bye", Replies: 4})
+	if err != nil || !got.End || got.Text != "bye from bot-a" {
+		t.Fatalf("end reply = %+v, %v", got, err)
+	}
+
+	fail := CommandReplier{Argv: []string{"sh", "-c", "echo broken >&2; exit 1"}}
+	if _, err := fail.Reply(context.Background(), Turn{}); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Fatalf("failing command error = %v", err)
+	}
+}
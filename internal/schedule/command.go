@@ -0,0 +1,36 @@
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+)
+
+// maxOutputBytes caps how much of a command's output a Run keeps.
+const maxOutputBytes = 8 << 10
+
+// Command returns a job action that runs path with args and captures its
+// combined output, keeping the tail when it is long.
+func Command(path string, args ...string) func(ctx context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		cmd := exec.CommandContext(ctx, path, args...)
+		var buf bytes.Buffer
+		cmd.Stdout = &buf
+		cmd.Stderr = &buf
+		err := cmd.Run()
+		return tailBytes(buf.Bytes(), maxOutputBytes), err
+	}
+}
+
+func tailBytes(b []byte, max int) string {
+	s := strings.TrimSpace(string(b))
+	if len(s) <= max {
+		return s
+	}
+	s = s[len(s)-max:]
+	if i := strings.IndexByte(s, '\n'); i >= 0 && i < len(s)-1 {
+		s = s[i+1:]
+	}
+	return "…\n" + s
+}
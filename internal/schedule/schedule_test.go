@@ -0,0 +1,216 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseCronNext(t *testing.T) {
+	t.Parallel()
+
+	loc := time.FixedZone("UTC+8", 8*3600)
+	base := time.Date(2026, 3, 14, 20, 30, 0, 0, loc) // Saturday
+
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"0 21 * * *", time.Date(2026, 3, 14, 21, 0, 0, 0, loc)},
+		{"30 20 * * *", time.Date(2026, 3, 15, 20, 30, 0, 0, loc)},
+		{"*/15 * * * *", time.Date(2026, 3, 14, 20, 45, 0, 0, loc)},
+		{"0 9 * * 1-5", time.Date(2026, 3, 16, 9, 0, 0, 0, loc)},
+		{"0 9 * * 7", time.Date(2026, 3, 15, 9, 0, 0, 0, loc)},
+		{"0 0 1 * *", time.Date(2026, 4, 1, 0, 0, 0, 0, loc)},
+		{"0 12 13,20 * 1", time.Date(2026, 3, 16, 12, 0, 0, 0, loc)},
+		{"@hourly", time.Date(2026, 3, 14, 21, 0, 0, 0, loc)},
+		{"@daily", time.Date(2026, 3, 15, 0, 0, 0, 0, loc)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, loc)},
+		{"@every 5m", base.Add(5 * time.Minute)},
+	}
+	for _, tc := range cases {
+		spec, err := Parse(tc.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tc.expr, err)
+		}
+		if got := spec.Next(base); !got.Equal(tc.want) {
+			t.Errorf("%q: next = %s, want %s", tc.expr, got, tc.want)
+		}
+	}
+}
+
+func TestParseRejectsInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "0 24 * * *", "0 0 0 * *", "5-1 * * * *", "*/0 * * * *", "@every 10ms", "@yearly"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", expr)
+		}
+	}
+}
+
+type memHistory struct {
+	mu   sync.Mutex
+	runs []Run
+}
+
+func (h *memHistory) Record(run Run) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.runs = append(h.runs, run)
+	return nil
+}
+
+func (h *memHistory) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.runs)
+}
+
+func TestSchedulerFiresAndRecords(t *testing.T) {
+	t.Parallel()
+
+	history := &memHistory{}
+	s := &Scheduler{
+		History: history,
+		Jobs: []Job{
+			{Name: "tick", Spec: everySpec(20 * time.Millisecond), Run: func(ctx context.Context) (string, error) {
+				return "tock", nil
+			}},
+			{Name: "broken", Spec: everySpec(20 * time.Millisecond), Run: func(ctx context.Context) (string, error) {
+				return "", errors.New("boom")
+			}},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Start(ctx)
+		close(done)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for history.count() < 4 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	history.mu.Lock()
+	defer history.mu.Unlock()
+	var ok, failed int
+	for _, run := range history.runs {
+		if run.Trigger != TriggerSchedule {
+			t.Fatalf("trigger = %s", run.Trigger)
+		}
+		switch run.Job {
+		case "tick":
+			if run.Status != StatusOK || run.Output != "tock" {
+				t.Fatalf("tick run = %+v", run)
+			}
+			ok++
+		case "broken":
+			if run.Status != StatusError || run.Error != "boom" {
+				t.Fatalf("broken run = %+v", run)
+			}
+			failed++
+		}
+	}
+	if ok < 2 || failed < 2 {
+		t.Fatalf("ok=%d failed=%d, want at least 2 each", ok, failed)
+	}
+}
+
+func TestSchedulerSkipsOverlappingRuns(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	inside := make(chan struct{})
+	s := &Scheduler{Jobs: []Job{{Name: "slow", Spec: everySpec(time.Hour), Run: func(ctx context.Context) (string, error) {
+		close(inside)
+		<-release
+		return "", nil
+	}}}}
+
+	go func() { _, _ = s.RunNow(context.Background(), "slow") }()
+	<-inside
+	if _, err := s.RunNow(context.Background(), "slow"); err == nil {
+		t.Fatal("second RunNow should fail while the first is running")
+	}
+	close(release)
+
+	if _, err := s.RunNow(context.Background(), "missing"); err == nil {
+		t.Fatal("unknown job should fail")
+	}
+}
+
+func TestRunNowTimesOut(t *testing.T) {
+	t.Parallel()
+
+	s := &Scheduler{Jobs: []Job{{Name: "hang", Spec: everySpec(time.Hour), Timeout: 20 * time.Millisecond, Run: func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}}}}
+	run, err := s.RunNow(context.Background(), "hang")
+	if err != nil {
+		t.Fatalf("run now: %v", err)
+	}
+	if run.Status != StatusError || run.Trigger != TriggerManual {
+		t.Fatalf("run = %+v", run)
+	}
+}
+
+func TestTailBytesKeepsEnd(t *testing.T) {
+	t.Parallel()
+
+	got := tailBytes([]byte("first line\nsecond line\nthird"), 12)
+	if got != "…\nthird" {
+		t.Fatalf("tail = %q", got)
+	}
+}
+
+func TestWeekdayField(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"":             "*",
+		"weekdays":     "1-5",
+		"weekends":     "0,6",
+		"mon-fri":      "1-5",
+		"Sat, sun":     "6,0",
+		"fri-mon":      "5-6,0-1",
+		"monday,wed":   "1,3",
+		"tue-thursday": "2-4",
+	}
+	for in, want := range cases {
+		got, err := WeekdayField(in)
+		if err != nil {
+			t.Fatalf("WeekdayField(%q): %v", in, err)
+		}
+		if got != want {
+			t.Errorf("WeekdayField(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if _, err := WeekdayField("funday"); err == nil {
+		t.Fatal("expected error for unknown weekday")
+	}
+}
+
+func TestInLocationUsesReminderZone(t *testing.T) {
+	t.Parallel()
+
+	tokyo := time.FixedZone("JST", 9*3600)
+	spec, err := Parse("0 8 * * 1-5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Friday 2026-03-13 23:30 UTC is Saturday 08:30 in Tokyo, so the next
+	// weekday 08:00 there is Monday.
+	next := InLocation(spec, tokyo).Next(time.Date(2026, 3, 13, 23, 30, 0, 0, time.UTC))
+	want := time.Date(2026, 3, 16, 8, 0, 0, 0, tokyo)
+	if !next.Equal(want) {
+		t.Fatalf("next = %s, want %s", next, want)
+	}
+}
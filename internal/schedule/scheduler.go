@@ -0,0 +1,217 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// DefaultTimeout bounds a job run when Job.Timeout is unset.
+const DefaultTimeout = 10 * time.Minute
+
+// Job is a named action with a schedule.
+type Job struct {
+	Name string
+	// Kind and Expr describe the job for listings.
+	Kind    string
+	Expr    string
+	Spec    Spec
+	Timeout time.Duration
+	Run     func(ctx context.Context) (string, error)
+}
+
+// Run is one finished execution of a job.
+type Run struct {
+	Job        string
+	Trigger    string
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Status     string
+	Output     string
+	Error      string
+}
+
+// History stores finished runs.
+type History interface {
+	Record(run Run) error
+}
+
+// Scheduler fires jobs at their scheduled times in Location. A job whose
+// previous run is still going when it is due again is skipped, not queued.
+type Scheduler struct {
+	Jobs     []Job
+	History  History
+	Location *time.Location
+	// Log receives one line per start, finish and skipped activation.
+	Log io.Writer
+
+	mu      sync.Mutex
+	running map[string]bool
+}
+
+// Upcoming is a job's next activation.
+type Upcoming struct {
+	Job  Job
+	Next time.Time
+}
+
+// Upcoming lists every job with its next activation after t, soonest first.
+func (s *Scheduler) Upcoming(t time.Time) []Upcoming {
+	t = t.In(s.location())
+	out := make([]Upcoming, 0, len(s.Jobs))
+	for _, job := range s.Jobs {
+		out = append(out, Upcoming{Job: job, Next: job.Spec.Next(t)})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Next.IsZero() != out[j].Next.IsZero() {
+			return !out[i].Next.IsZero()
+		}
+		return out[i].Next.Before(out[j].Next)
+	})
+	return out
+}
+
+// Start runs the scheduling loop until ctx is cancelled, then waits for
+// in-flight jobs (whose contexts are cancelled too) to return.
+func (s *Scheduler) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	next := make([]time.Time, len(s.Jobs))
+	now := time.Now().In(s.location())
+	for i, job := range s.Jobs {
+		next[i] = job.Spec.Next(now)
+	}
+
+	for {
+		var soonest time.Time
+		for _, t := range next {
+			if !t.IsZero() && (soonest.IsZero() || t.Before(soonest)) {
+				soonest = t
+			}
+		}
+		if soonest.IsZero() {
+			<-ctx.Done()
+			return
+		}
+
+		timer := time.NewTimer(time.Until(soonest))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		now := time.Now().In(s.location())
+		for i, job := range s.Jobs {
+			if next[i].IsZero() || next[i].After(now) {
+				continue
+			}
+			next[i] = job.Spec.Next(now)
+			if !s.claim(job.Name) {
+				s.logf("%s: skipped, previous run still in progress", job.Name)
+				continue
+			}
+			wg.Add(1)
+			go func(job Job) {
+				defer wg.Done()
+				defer s.release(job.Name)
+				s.execute(ctx, job, TriggerSchedule)
+			}(job)
+		}
+	}
+}
+
+// RunNow runs the named job immediately and records it as a manual run.
+func (s *Scheduler) RunNow(ctx context.Context, name string) (Run, error) {
+	for _, job := range s.Jobs {
+		if job.Name != name {
+			continue
+		}
+		if !s.claim(name) {
+			return Run{}, fmt.Errorf("job %s is already running", name)
+		}
+		defer s.release(name)
+		return s.execute(ctx, job, TriggerManual), nil
+	}
+	return Run{}, fmt.Errorf("unknown job: %s", name)
+}
+
+func (s *Scheduler) execute(ctx context.Context, job Job, trigger string) Run {
+	timeout := job.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	run := Run{Job: job.Name, Trigger: trigger, StartedAt: time.Now()}
+	s.logf("%s: started (%s)", job.Name, trigger)
+	out, err := job.Run(runCtx)
+	run.FinishedAt = time.Now()
+	run.Output = out
+	run.Status = StatusOK
+	if err != nil {
+		run.Status = StatusError
+		run.Error = err.Error()
+		if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+			run.Error = fmt.Sprintf("timed out after %s: %v", timeout, err)
+		}
+		s.logf("%s: failed after %s: %s", job.Name, run.FinishedAt.Sub(run.StartedAt).Round(time.Millisecond), run.Error)
+	} else {
+		s.logf("%s: finished in %s", job.Name, run.FinishedAt.Sub(run.StartedAt).Round(time.Millisecond))
+	}
+
+	if s.History != nil {
+		if err := s.History.Record(run); err != nil {
+			s.logf("%s: record history: %v", job.Name, err)
+		}
+	}
+	return run
+}
+
+func (s *Scheduler) claim(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running == nil {
+		s.running = make(map[string]bool)
+	}
+	if s.running[name] {
+		return false
+	}
+	s.running[name] = true
+	return true
+}
+
+func (s *Scheduler) release(name string) {
+	s.mu.Lock()
+	delete(s.running, name)
+	s.mu.Unlock()
+}
+
+func (s *Scheduler) location() *time.Location {
+	if s.Location != nil {
+		return s.Location
+	}
+	return time.Local
+}
+
+func (s *Scheduler) logf(format string, args ...any) {
+	if s.Log == nil {
+		return
+	}
+	fmt.Fprintf(s.Log, "%s [scheduler] %s\n", time.Now().Format(time.RFC3339), fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,75 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var weekdayNames = map[string]int{
+	"sun": 0, "sunday": 0,
+	"mon": 1, "monday": 1,
+	"tue": 2, "tuesday": 2,
+	"wed": 3, "wednesday": 3,
+	"thu": 4, "thursday": 4,
+	"fri": 5, "friday": 5,
+	"sat": 6, "saturday": 6,
+}
+
+// WeekdayField turns a weekday mask such as "mon-fri", "sat,sun",
+// "weekdays" or "weekends" into a cron day-of-week field. Empty, "*" and
+// "daily" mean every day.
+func WeekdayField(days string) (string, error) {
+	days = strings.ToLower(strings.TrimSpace(days))
+	switch days {
+	case "", "*", "daily", "everyday":
+		return "*", nil
+	case "weekdays":
+		return "1-5", nil
+	case "weekends":
+		return "0,6", nil
+	}
+
+	var parts []string
+	for _, item := range strings.Split(days, ",") {
+		item = strings.TrimSpace(item)
+		if lo, hi, ok := strings.Cut(item, "-"); ok {
+			a, okA := weekdayNames[strings.TrimSpace(lo)]
+			b, okB := weekdayNames[strings.TrimSpace(hi)]
+			if !okA || !okB {
+				return "", fmt.Errorf("invalid weekday range %q", item)
+			}
+			if a <= b {
+				parts = append(parts, fmt.Sprintf("%d-%d", a, b))
+			} else {
+				// Wraps past Saturday, e.g. fri-mon.
+				parts = append(parts, fmt.Sprintf("%d-6", a), fmt.Sprintf("0-%d", b))
+			}
+			continue
+		}
+		d, ok := weekdayNames[item]
+		if !ok {
+			return "", fmt.Errorf("invalid weekday %q", item)
+		}
+		parts = append(parts, fmt.Sprint(d))
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// InLocation evaluates spec's wall-clock times in loc regardless of the
+// time zone of the instants passed to Next.
+func InLocation(spec Spec, loc *time.Location) Spec {
+	if loc == nil {
+		return spec
+	}
+	return locatedSpec{spec: spec, loc: loc}
+}
+
+type locatedSpec struct {
+	spec Spec
+	loc  *time.Location
+}
+
+func (l locatedSpec) Next(t time.Time) time.Time {
+	return l.spec.Next(t.In(l.loc))
+}
@@ -0,0 +1,42 @@
+package tower
+
+import (
+	"fmt"
+	"time"
+
+	"moltbb-cli/internal/parser"
+)
+
+// ActivityStatus summarizes OpenClaw log stats for a heartbeat status
+// message, e.g. "12 tasks, 0 errors in last hour".
+func ActivityStatus(stats parser.Stats, window time.Duration) string {
+	span := "in " + windowLabel(window)
+	if stats.LineCount == 0 {
+		return "idle " + span
+	}
+	msg := fmt.Sprintf("%s, %s", plural(stats.TaskCount, "task"), plural(stats.ErrorCount, "error"))
+	if stats.WarningCount > 0 {
+		msg += ", " + plural(stats.WarningCount, "warning")
+	}
+	return clampStatus(msg + " " + span)
+}
+
+func windowLabel(d time.Duration) string {
+	switch {
+	case d == time.Hour:
+		return "last hour"
+	case d == 24*time.Hour:
+		return "last day"
+	case d%time.Hour == 0:
+		return fmt.Sprintf("last %dh", int(d/time.Hour))
+	default:
+		return fmt.Sprintf("last %dm", int(d.Round(time.Minute)/time.Minute))
+	}
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return "1 " + word
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
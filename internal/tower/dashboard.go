@@ -0,0 +1,422 @@
+package tower
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"moltbb-cli/internal/api"
+)
+
+// RoomsPerFloor is the number of rooms on one floor; the last hex digit of
+// a room code is the room on its floor.
+const RoomsPerFloor = 16
+
+// trendSamples is how many occupancy-rate samples the trend line keeps.
+const trendSamples = 48
+
+// Dashboard is the state behind 'moltbb tower top'. It is not safe for
+// concurrent use; the UI loop owns it.
+type Dashboard struct {
+	MyRoom  string
+	Color   bool
+	Live    bool
+	Updated time.Time
+	Err     string
+
+	// Detail is the room detail panel, shown while set.
+	Detail    *api.TowerRoomDetail
+	DetailErr string
+
+	rooms  map[string]api.TowerRoomState
+	stats  api.TowerStatistics
+	trend  []float64
+	floors []int // ascending
+	floor  int   // cursor floor
+	slot   int   // cursor room on the floor
+	moved  bool
+}
+
+// NewDashboard returns an empty dashboard; myRoom is highlighted.
+func NewDashboard(myRoom string) *Dashboard {
+	return &Dashboard{MyRoom: strings.ToUpper(myRoom), rooms: make(map[string]api.TowerRoomState)}
+}
+
+// SetRooms replaces the room list.
+func (d *Dashboard) SetRooms(rooms []api.TowerRoomState) {
+	d.rooms = make(map[string]api.TowerRoomState, len(rooms))
+	for _, room := range rooms {
+		d.rooms[strings.ToUpper(room.Code)] = room
+	}
+	d.reindex()
+}
+
+// UpdateRoom applies a single-room change, e.g. from a push event.
+func (d *Dashboard) UpdateRoom(room api.TowerRoomState) {
+	code := strings.ToUpper(room.Code)
+	if code == "" {
+		return
+	}
+	_, known := d.rooms[code]
+	d.rooms[code] = room
+	if !known {
+		d.reindex()
+	}
+	if d.Detail != nil && strings.EqualFold(d.Detail.Code, code) {
+		d.Detail.Status = room.Status
+		d.Detail.LastHeartbeat = room.LastHeartbeat
+		d.Detail.StatusMessage = room.StatusMessage
+	}
+}
+
+// SetStats records fresh statistics and extends the occupancy trend.
+func (d *Dashboard) SetStats(stats api.TowerStatistics) {
+	d.stats = stats
+	d.trend = append(d.trend, stats.OccupancyRate)
+	if len(d.trend) > trendSamples {
+		d.trend = d.trend[len(d.trend)-trendSamples:]
+	}
+}
+
+// Selected is the room code under the cursor.
+func (d *Dashboard) Selected() string {
+	if len(d.floors) == 0 {
+		return ""
+	}
+	return roomCode(d.floor, d.slot)
+}
+
+// Move shifts the cursor; dy > 0 goes up the tower.
+func (d *Dashboard) Move(dx, dy int) {
+	if len(d.floors) == 0 {
+		return
+	}
+	d.moved = true
+	d.slot = clamp(d.slot+dx, 0, RoomsPerFloor-1)
+	i := sort.SearchInts(d.floors, d.floor)
+	d.floor = d.floors[clamp(i+dy, 0, len(d.floors)-1)]
+}
+
+// JumpToMine moves the cursor to our own room.
+func (d *Dashboard) JumpToMine() bool {
+	floor, slot, ok := parseRoomCode(d.MyRoom)
+	if _, known := d.rooms[d.MyRoom]; !ok || !known {
+		return false
+	}
+	d.floor, d.slot, d.moved = floor, slot, true
+	return true
+}
+
+func (d *Dashboard) reindex() {
+	seen := map[int]bool{}
+	for code := range d.rooms {
+		if floor, _, ok := parseRoomCode(code); ok {
+			seen[floor] = true
+		}
+	}
+	d.floors = d.floors[:0]
+	for floor := range seen {
+		d.floors = append(d.floors, floor)
+	}
+	sort.Ints(d.floors)
+	if len(d.floors) == 0 {
+		return
+	}
+	if !d.moved && !d.JumpToMine() {
+		d.floor = d.floors[len(d.floors)-1]
+	}
+	if !seen[d.floor] {
+		d.floor = d.floors[len(d.floors)-1]
+	}
+}
+
+// Render draws the dashboard into at most height lines of width columns.
+func (d *Dashboard) Render(width, height int) []string {
+	var header []string
+	header = append(header, d.paint("1", "Lobster Tower")+"  "+d.sourceLabel())
+	s := d.stats
+	header = append(header, fmt.Sprintf("Rooms %d  Occupied %d  Online %d  Joined today %d  Full floors %d",
+		s.TotalRooms, s.OccupiedRooms, s.OnlineRooms, s.RoomsJoinedToday, s.FullFloors))
+	header = append(header, fmt.Sprintf("Occupancy %5.1f%%  %s", s.OccupancyRate*100, d.trendLine(width-20)))
+	if d.Err != "" {
+		header = append(header, d.paint("31", "! "+d.Err))
+	}
+	header = append(header, "", "FLOOR  "+strings.Join(strings.Split("0123456789ABCDEF", ""), " "))
+
+	footer := d.footer()
+	gridHeight := height - len(header) - len(footer)
+	if gridHeight < 1 {
+		gridHeight = 1
+	}
+
+	lines := append([]string{}, header...)
+	lines = append(lines, d.grid(gridHeight)...)
+	lines = append(lines, footer...)
+	if len(lines) > height && height > 0 {
+		lines = lines[:height]
+	}
+	for i, line := range lines {
+		lines[i] = truncateVisible(line, width)
+	}
+	return lines
+}
+
+func (d *Dashboard) sourceLabel() string {
+	updated := "never"
+	if !d.Updated.IsZero() {
+		updated = d.Updated.Local().Format("15:04:05")
+	}
+	if d.Live {
+		return d.paint("32", "● live") + "  updated " + updated
+	}
+	return d.paint("2", "○ polling") + "  updated " + updated
+}
+
+func (d *Dashboard) grid(rows int) []string {
+	if len(d.floors) == 0 {
+		return []string{"  (no rooms yet)"}
+	}
+	// Top floor first, like the building; scroll to keep the cursor visible.
+	cursor := sort.SearchInts(d.floors, d.floor)
+	top := min(len(d.floors)-1, max(cursor+rows/2, rows-1))
+	var out []string
+	for i := top; i >= 0 && len(out) < rows; i-- {
+		floor := d.floors[i]
+		var b strings.Builder
+		fmt.Fprintf(&b, "  %02X   ", floor)
+		for slot := 0; slot < RoomsPerFloor; slot++ {
+			code := roomCode(floor, slot)
+			cell := d.cell(code)
+			if floor == d.floor && slot == d.slot {
+				cell = d.paint("7", stripANSI(cell))
+			}
+			b.WriteString(cell)
+			if slot < RoomsPerFloor-1 {
+				b.WriteByte(' ')
+			}
+		}
+		out = append(out, b.String())
+	}
+	return out
+}
+
+func (d *Dashboard) cell(code string) string {
+	room, ok := d.rooms[code]
+	if code == d.MyRoom {
+		return d.paint("1;33", "◆")
+	}
+	if !ok {
+		return " "
+	}
+	if room.BotId == "" {
+		return d.paint("2", "·")
+	}
+	switch room.Status {
+	case 1:
+		return d.paint("32", "■")
+	case 2:
+		return d.paint("36", "■")
+	case 3:
+		return d.paint("35", "■")
+	default:
+		return d.paint("31", "■")
+	}
+}
+
+func (d *Dashboard) footer() []string {
+	lines := []string{""}
+	if d.Detail != nil || d.DetailErr != "" {
+		lines = append(lines, d.detailLines()...)
+	} else if code := d.Selected(); code != "" {
+		lines = append(lines, d.summaryLine(code))
+	}
+	lines = append(lines,
+		d.paint("32", "■")+" online "+d.paint("36", "■")+" stable 7d "+d.paint("35", "■")+" stable 30d "+
+			d.paint("31", "■")+" offline "+d.paint("2", "·")+" empty "+d.paint("1;33", "◆")+" you",
+		d.paint("2", "←↑↓→/hjkl move  enter details  m my room  r refresh  esc close  q quit"))
+	return lines
+}
+
+func (d *Dashboard) summaryLine(code string) string {
+	room, ok := d.rooms[code]
+	if !ok || room.BotId == "" {
+		return fmt.Sprintf("%s  empty", code)
+	}
+	line := fmt.Sprintf("%s  %s  %s", code, orDash(room.BotName), StatusName(room.Status))
+	if room.LastHeartbeat != nil && *room.LastHeartbeat > 0 {
+		line += "  beat " + ago(time.Unix(*room.LastHeartbeat, 0))
+	}
+	if room.StatusMessage != nil && *room.StatusMessage != "" {
+		line += "  “" + *room.StatusMessage + "”"
+	}
+	return line
+}
+
+func (d *Dashboard) detailLines() []string {
+	if d.DetailErr != "" {
+		return []string{d.paint("31", "Room detail: "+d.DetailErr)}
+	}
+	r := d.Detail
+	lines := []string{
+		d.paint("1", fmt.Sprintf("Room %s", r.Code)) + fmt.Sprintf("  floor %d, room %d, #%d", r.Floor, r.RoomNumber, r.GlobalIndex),
+		fmt.Sprintf("  Status:           %s", StatusName(r.Status)),
+		fmt.Sprintf("  Bot:              %s %s", orDash(r.BotName), r.BotId),
+	}
+	if r.LastHeartbeat != nil && *r.LastHeartbeat > 0 {
+		lines = append(lines, "  Last heartbeat:   "+ago(time.Unix(*r.LastHeartbeat, 0)))
+	}
+	if r.JoinTime != nil && *r.JoinTime > 0 {
+		lines = append(lines, "  Joined:           "+time.Unix(*r.JoinTime, 0).Local().Format("2006-01-02 15:04"))
+	}
+	lines = append(lines, fmt.Sprintf("  Total heartbeats: %d", r.TotalHeartbeats))
+	if r.StatusMessage != nil && *r.StatusMessage != "" {
+		lines = append(lines, "  Status message:   "+*r.StatusMessage)
+	}
+	return lines
+}
+
+// trendLine draws the occupancy samples as a sparkline with the change
+// since the first sample.
+func (d *Dashboard) trendLine(width int) string {
+	if len(d.trend) < 2 {
+		return d.paint("2", "(trend after next refresh)")
+	}
+	samples := d.trend
+	if width > 8 && len(samples) > width-8 {
+		samples = samples[len(samples)-(width-8):]
+	}
+	lo, hi := samples[0], samples[0]
+	for _, v := range samples {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	const bars = "▁▂▃▄▅▆▇█"
+	levels := []rune(bars)
+	var b strings.Builder
+	for _, v := range samples {
+		i := 0
+		if hi > lo {
+			i = int((v - lo) / (hi - lo) * float64(len(levels)-1))
+		}
+		b.WriteRune(levels[i])
+	}
+	delta := (samples[len(samples)-1] - samples[0]) * 100
+	return fmt.Sprintf("%s %+.1f%%", b.String(), delta)
+}
+
+func (d *Dashboard) paint(code, s string) string {
+	if !d.Color {
+		return s
+	}
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
+
+// StatusName is the display name of a tower node status.
+func StatusName(status int) string {
+	switch status {
+	case 0:
+		return "OFFLINE"
+	case 1:
+		return "ONLINE"
+	case 2:
+		return "STABLE_7D"
+	case 3:
+		return "STABLE_30D"
+	default:
+		return fmt.Sprintf("UNKNOWN(%d)", status)
+	}
+}
+
+func roomCode(floor, slot int) string {
+	return fmt.Sprintf("%02X%X", floor, slot)
+}
+
+func parseRoomCode(code string) (floor, slot int, ok bool) {
+	if len(code) != 3 {
+		return 0, 0, false
+	}
+	f, err1 := strconv.ParseUint(code[:2], 16, 8)
+	s, err2 := strconv.ParseUint(code[2:], 16, 8)
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	return int(f), int(s), true
+}
+
+func ago(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%d min ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%d hr ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%d days ago", int(d.Hours()/24))
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func clamp(v, lo, hi int) int {
+	return max(lo, min(hi, v))
+}
+
+// stripANSI removes SGR escape sequences.
+func stripANSI(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == 0x1b && i+1 < len(s) && s[i+1] == '[' {
+			j := i + 2
+			for j < len(s) && s[j] != 'm' {
+				j++
+			}
+			i = j
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// truncateVisible cuts s to width visible runes, keeping escape sequences
+// intact and resetting attributes when it cuts.
+func truncateVisible(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+	var b strings.Builder
+	visible := 0
+	styled := false
+	for i := 0; i < len(s); {
+		if s[i] == 0x1b && i+1 < len(s) && s[i+1] == '[' {
+			j := i + 2
+			for j < len(s) && s[j] != 'm' {
+				j++
+			}
+			b.WriteString(s[i:min(j+1, len(s))])
+			styled = true
+			i = j + 1
+			continue
+		}
+		if visible == width {
+			if styled {
+				b.WriteString("\x1b[0m")
+			}
+			break
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		b.WriteString(s[i : i+size])
+		i += size
+		visible++
+	}
+	return b.String()
+}
@@ -0,0 +1,139 @@
+package tower
+
+import (
+	"strings"
+	"testing"
+
+	"moltbb-cli/internal/api"
+)
+
+func towerRooms() []api.TowerRoomState {
+	msg := "12 tasks, 0 errors in last hour"
+	return []api.TowerRoomState{
+		{Code: "010", BotId: "b1", BotName: "alpha", Status: 1},
+		{Code: "01F", Status: 0},
+		{Code: "020", BotId: "b2", BotName: "beta", Status: 0},
+		{Code: "021", BotId: "b3", BotName: "gamma", Status: 3, StatusMessage: &msg},
+		{Code: "030"},
+	}
+}
+
+func TestDashboardStartsOnOwnRoomAndMoves(t *testing.T) {
+	t.Parallel()
+
+	d := NewDashboard("021")
+	d.SetRooms(towerRooms())
+	if got := d.Selected(); got != "021" {
+		t.Fatalf("selected = %s, want own room", got)
+	}
+	d.Move(0, 1)
+	if got := d.Selected(); got != "031" {
+		t.Fatalf("after up = %s", got)
+	}
+	d.Move(0, 5) // clamps at the top floor
+	d.Move(-3, -1)
+	if got := d.Selected(); got != "020" {
+		t.Fatalf("after left/down = %s", got)
+	}
+	d.Move(40, -9)
+	if got := d.Selected(); got != "01F" {
+		t.Fatalf("after clamp = %s", got)
+	}
+	if !d.JumpToMine() || d.Selected() != "021" {
+		t.Fatalf("jump to mine = %s", d.Selected())
+	}
+
+	// Without an own room the cursor starts on the top floor.
+	other := NewDashboard("")
+	other.SetRooms(towerRooms())
+	if got := other.Selected(); got != "030" {
+		t.Fatalf("default selection = %s", got)
+	}
+}
+
+func TestDashboardRenderPlain(t *testing.T) {
+	t.Parallel()
+
+	d := NewDashboard("021")
+	d.SetRooms(towerRooms())
+	d.SetStats(api.TowerStatistics{TotalRooms: 48, OccupiedRooms: 3, OnlineRooms: 2, OccupancyRate: 0.05})
+	d.SetStats(api.TowerStatistics{TotalRooms: 48, OccupiedRooms: 4, OnlineRooms: 2, OccupancyRate: 0.08})
+
+	lines := d.Render(80, 30)
+	out := strings.Join(lines, "\n")
+	for _, want := range []string{
+		"Occupied 4  Online 2",
+		"Occupancy   8.0%  ▁█ +3.0%",
+		"  03   ·",
+		"  02   ■ ◆",
+		"  01   ■",
+		"021  gamma  STABLE_30D",
+		"“12 tasks, 0 errors in last hour”",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("render missing %q:\n%s", want, out)
+		}
+	}
+	// Floors run top-down like the building.
+	if strings.Index(out, "  03 ") > strings.Index(out, "  01 ") {
+		t.Fatalf("floors out of order:\n%s", out)
+	}
+	for _, line := range d.Render(20, 5) {
+		if n := len([]rune(line)); n > 20 {
+			t.Fatalf("line wider than 20 columns: %q", line)
+		}
+	}
+	if got := len(d.Render(80, 5)); got > 5 {
+		t.Fatalf("render returned %d lines for height 5", got)
+	}
+}
+
+func TestDashboardDetailAndPushUpdate(t *testing.T) {
+	t.Parallel()
+
+	d := NewDashboard("")
+	d.SetRooms(towerRooms())
+	d.Detail = &api.TowerRoomDetail{Code: "020", Floor: 2, RoomNumber: 0, BotName: "beta", TotalHeartbeats: 99}
+	d.UpdateRoom(api.TowerRoomState{Code: "020", BotId: "b2", BotName: "beta", Status: 1})
+	if d.Detail.Status != 1 {
+		t.Fatalf("detail status not updated: %+v", d.Detail)
+	}
+	out := strings.Join(d.Render(80, 40), "\n")
+	if !strings.Contains(out, "Total heartbeats: 99") || !strings.Contains(out, "ONLINE") {
+		t.Fatalf("detail panel missing:\n%s", out)
+	}
+
+	// A room on a new floor extends the grid.
+	d.UpdateRoom(api.TowerRoomState{Code: "0A0", BotId: "b9", Status: 2})
+	if !strings.Contains(strings.Join(d.Render(80, 40), "\n"), "  0A   ■") {
+		t.Fatal("new floor not rendered")
+	}
+}
+
+func TestColorRenderKeepsWidth(t *testing.T) {
+	t.Parallel()
+
+	d := NewDashboard("021")
+	d.Color = true
+	d.SetRooms(towerRooms())
+	for _, line := range d.Render(30, 40) {
+		if n := len([]rune(stripANSI(line))); n > 30 {
+			t.Fatalf("visible width %d > 30: %q", n, line)
+		}
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	t.Parallel()
+
+	got := ParseKeys([]byte("\x1b[A\x1b[Dhjkl\r\x1bqmr\x03x"))
+	want := []Key{KeyUp, KeyLeft, KeyLeft, KeyDown, KeyUp, KeyRight, KeyEnter, KeyEscape, KeyQuit, KeyMine, KeyRefresh, KeyQuit}
+	if len(got) != len(want) {
+		t.Fatalf("keys = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("keys = %v, want %v", got, want)
+		}
+	}
+}
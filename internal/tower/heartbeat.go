@@ -0,0 +1,294 @@
+// Package tower keeps a bot's Lobster Tower room online: a heartbeat agent
+// with jittered backoff and re-checkin, plus local uptime accounting.
+package tower
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"moltbb-cli/internal/api"
+)
+
+// MaxStatusLength is the server's limit on heartbeat status messages.
+const MaxStatusLength = 200
+
+// API is the part of the MoltBB client the agent uses.
+type API interface {
+	TowerCheckin(ctx context.Context, apiKey, roomCode string) (api.TowerCheckinResponse, error)
+	TowerSendHeartbeat(ctx context.Context, apiKey, roomCode string, statusMessage *string) (api.TowerHeartbeatResponse, error)
+	TowerGetMyRoom(ctx context.Context, apiKey string) (api.TowerRoomState, error)
+}
+
+// Beat reports one heartbeat attempt to Agent.OnBeat.
+type Beat struct {
+	At     time.Time
+	Room   string
+	Status string
+	// Checkin is set when the room had been lost and the agent checked in
+	// again before this beat.
+	Checkin bool
+	Err     error
+	// Next is the wait before the following attempt.
+	Next time.Duration
+}
+
+// Agent sends a heartbeat every Interval (±Jitter) until its context ends.
+// Failed beats are retried with exponential backoff from MinBackoff up to
+// MaxBackoff. When a beat fails and the server no longer reports the room
+// as ours, the agent checks in again (asking for the same room first).
+type Agent struct {
+	API    API
+	APIKey string
+	// RoomCode is the room to keep alive; empty means the current
+	// assignment, checking in when there is none.
+	RoomCode string
+
+	Interval time.Duration
+	// Jitter is the fraction of Interval to randomize by (default 0.1).
+	Jitter     float64
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// RequestTimeout bounds each API call (default 30s).
+	RequestTimeout time.Duration
+
+	// Status returns the status message for the next beat; "" sends none.
+	Status func(ctx context.Context) string
+	// Uptime, when set, is updated after every attempt and saved to
+	// UptimePath.
+	Uptime     *Uptime
+	UptimePath string
+	OnBeat     func(Beat)
+	Log        io.Writer
+
+	rand func() float64
+}
+
+// Run beats until ctx is cancelled. It only returns an error when the room
+// cannot be determined at startup.
+func (a *Agent) Run(ctx context.Context) error {
+	if a.Interval <= 0 {
+		return errors.New("heartbeat interval must be positive")
+	}
+	room, err := a.resolveRoom(ctx)
+	if err != nil {
+		return err
+	}
+	a.RoomCode = room
+
+	failures := 0
+	for {
+		beat := a.Beat(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if beat.Err != nil {
+			failures++
+			beat.Next = a.backoff(failures)
+		} else {
+			failures = 0
+			beat.Next = a.jittered(a.Interval)
+		}
+		if a.OnBeat != nil {
+			a.OnBeat(beat)
+		}
+
+		timer := time.NewTimer(beat.Next)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// Beat sends one heartbeat, checking in again first if the room was lost.
+func (a *Agent) Beat(ctx context.Context) Beat {
+	beat := Beat{Room: a.RoomCode}
+	if a.Status != nil {
+		beat.Status = clampStatus(a.Status(ctx))
+	}
+
+	beat.Err = a.send(ctx, beat.Status)
+	if beat.Err != nil && ctx.Err() == nil {
+		if room, lost := a.roomLost(ctx); lost {
+			a.logf("room %s lost (%v), checking in again", a.RoomCode, beat.Err)
+			resp, err := a.checkin(ctx, a.RoomCode)
+			if err != nil {
+				beat.Err = fmt.Errorf("room lost and checkin failed: %w", err)
+			} else {
+				a.RoomCode, beat.Room, beat.Checkin = resp.Code, resp.Code, true
+				if a.Uptime != nil {
+					a.Uptime.Checkins++
+				}
+				beat.Err = a.send(ctx, beat.Status)
+			}
+		} else if room != "" && room != a.RoomCode {
+			// Reassigned server-side; follow the new room.
+			a.logf("room moved from %s to %s", a.RoomCode, room)
+			a.RoomCode, beat.Room = room, room
+			beat.Err = a.send(ctx, beat.Status)
+		}
+	}
+	beat.At = time.Now()
+
+	if a.Uptime != nil {
+		if beat.Err == nil {
+			a.Uptime.RecordBeat(a.RoomCode, beat.At, a.Interval)
+		} else {
+			a.Uptime.RecordFailure(beat.At, beat.Err)
+		}
+		if a.UptimePath != "" {
+			if err := SaveUptime(a.UptimePath, a.Uptime); err != nil {
+				a.logf("save uptime: %v", err)
+			}
+		}
+	}
+	return beat
+}
+
+func (a *Agent) send(ctx context.Context, status string) error {
+	reqCtx, cancel := context.WithTimeout(ctx, a.requestTimeout())
+	defer cancel()
+	var statusPtr *string
+	if status != "" {
+		statusPtr = &status
+	}
+	resp, err := a.API.TowerSendHeartbeat(reqCtx, a.APIKey, a.RoomCode, statusPtr)
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return errors.New("server did not accept the heartbeat")
+	}
+	return nil
+}
+
+// roomLost asks the server for our assignment after a failed beat. It
+// returns the room the server reports, and whether it reports none (or
+// refuses to answer in a way that means the same).
+func (a *Agent) roomLost(ctx context.Context) (string, bool) {
+	reqCtx, cancel := context.WithTimeout(ctx, a.requestTimeout())
+	defer cancel()
+	room, err := a.API.TowerGetMyRoom(reqCtx, a.APIKey)
+	if err != nil {
+		// Transport trouble is not evidence of a lost room.
+		return "", isStatusError(err)
+	}
+	return room.Code, strings.TrimSpace(room.Code) == ""
+}
+
+func (a *Agent) resolveRoom(ctx context.Context) (string, error) {
+	if code := strings.ToUpper(strings.TrimSpace(a.RoomCode)); code != "" {
+		return code, nil
+	}
+	reqCtx, cancel := context.WithTimeout(ctx, a.requestTimeout())
+	room, err := a.API.TowerGetMyRoom(reqCtx, a.APIKey)
+	cancel()
+	if err == nil && room.Code != "" {
+		return room.Code, nil
+	}
+	resp, checkinErr := a.checkin(ctx, "")
+	if checkinErr != nil {
+		if err != nil {
+			return "", fmt.Errorf("no tower room (%v) and checkin failed: %w", err, checkinErr)
+		}
+		return "", fmt.Errorf("checkin failed: %w", checkinErr)
+	}
+	if a.Uptime != nil {
+		a.Uptime.Checkins++
+	}
+	return resp.Code, nil
+}
+
+func (a *Agent) checkin(ctx context.Context, preferred string) (api.TowerCheckinResponse, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, a.requestTimeout())
+	defer cancel()
+	resp, err := a.API.TowerCheckin(reqCtx, a.APIKey, preferred)
+	if err != nil && preferred != "" {
+		// The old room may have been given away meanwhile; take any.
+		resp, err = a.API.TowerCheckin(reqCtx, a.APIKey, "")
+	}
+	if err == nil && resp.Code == "" {
+		err = errors.New("checkin returned no room")
+	}
+	return resp, err
+}
+
+// jittered spreads d by ±Jitter so many bots do not beat in lockstep.
+func (a *Agent) jittered(d time.Duration) time.Duration {
+	j := a.Jitter
+	if j <= 0 {
+		j = 0.1
+	}
+	return time.Duration(float64(d) * (1 + j*(2*a.random()-1)))
+}
+
+// backoff is the wait after the n-th consecutive failure: MinBackoff
+// doubled per failure, capped at MaxBackoff, with full jitter on the upper
+// half so retries spread out.
+func (a *Agent) backoff(n int) time.Duration {
+	lo, hi := a.MinBackoff, a.MaxBackoff
+	if lo <= 0 {
+		lo = 10 * time.Second
+	}
+	if hi <= 0 {
+		hi = a.Interval
+	}
+	if hi < lo {
+		hi = lo
+	}
+	d := lo
+	for i := 1; i < n && d < hi; i++ {
+		d *= 2
+	}
+	if d > hi {
+		d = hi
+	}
+	return d/2 + time.Duration(a.random()*float64(d/2))
+}
+
+func (a *Agent) random() float64 {
+	if a.rand != nil {
+		return a.rand()
+	}
+	return rand.Float64()
+}
+
+func (a *Agent) requestTimeout() time.Duration {
+	if a.RequestTimeout > 0 {
+		return a.RequestTimeout
+	}
+	return 30 * time.Second
+}
+
+func (a *Agent) logf(format string, args ...any) {
+	if a.Log == nil {
+		return
+	}
+	fmt.Fprintf(a.Log, "%s [heartbeat] %s\n", time.Now().Format(time.RFC3339), fmt.Sprintf(format, args...))
+}
+
+// isStatusError reports whether err is an HTTP status failure from the API
+// client (as opposed to a network error).
+func isStatusError(err error) bool {
+	return api.StatusOf(err) != 0
+}
+
+func clampStatus(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= MaxStatusLength {
+		return s
+	}
+	cut := MaxStatusLength
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut]
+}
@@ -0,0 +1,60 @@
+package tower
+
+// Key is a decoded keypress for the dashboard.
+type Key int
+
+const (
+	KeyNone Key = iota
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyEnter
+	KeyEscape
+	KeyRefresh
+	KeyMine
+	KeyQuit
+)
+
+// ParseKeys decodes raw terminal input (arrow escape sequences, vi keys,
+// enter, escape, q / Ctrl+C). Unknown bytes are dropped.
+func ParseKeys(b []byte) []Key {
+	var keys []Key
+	for i := 0; i < len(b); i++ {
+		switch c := b[i]; c {
+		case 0x1b:
+			if i+2 < len(b) && (b[i+1] == '[' || b[i+1] == 'O') {
+				switch b[i+2] {
+				case 'A':
+					keys = append(keys, KeyUp)
+				case 'B':
+					keys = append(keys, KeyDown)
+				case 'C':
+					keys = append(keys, KeyRight)
+				case 'D':
+					keys = append(keys, KeyLeft)
+				}
+				i += 2
+				continue
+			}
+			keys = append(keys, KeyEscape)
+		case 'k', 'K':
+			keys = append(keys, KeyUp)
+		case 'j', 'J':
+			keys = append(keys, KeyDown)
+		case 'h', 'H':
+			keys = append(keys, KeyLeft)
+		case 'l', 'L':
+			keys = append(keys, KeyRight)
+		case '\r', '\n', ' ':
+			keys = append(keys, KeyEnter)
+		case 'r', 'R':
+			keys = append(keys, KeyRefresh)
+		case 'm', 'M':
+			keys = append(keys, KeyMine)
+		case 'q', 'Q', 0x03:
+			keys = append(keys, KeyQuit)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,213 @@
+package tower
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"moltbb-cli/internal/api"
+	"moltbb-cli/internal/parser"
+)
+
+type fakeTower struct {
+	mu       sync.Mutex
+	assigned string
+	down     bool
+	beats    []string
+	statuses []string
+	checkins []string
+}
+
+func (f *fakeTower) TowerCheckin(ctx context.Context, apiKey, roomCode string) (api.TowerCheckinResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.checkins = append(f.checkins, roomCode)
+	if roomCode == "" {
+		roomCode = "0A1"
+	}
+	f.assigned = roomCode
+	return api.TowerCheckinResponse{Code: roomCode}, nil
+}
+
+func (f *fakeTower) TowerSendHeartbeat(ctx context.Context, apiKey, roomCode string, status *string) (api.TowerHeartbeatResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.down {
+		return api.TowerHeartbeatResponse{}, errors.New("dial tcp: connection refused")
+	}
+	if roomCode != f.assigned {
+		return api.TowerHeartbeatResponse{}, &api.Error{Op: "tower heartbeat", Status: 404, Message: "room not assigned"}
+	}
+	f.beats = append(f.beats, roomCode)
+	if status != nil {
+		f.statuses = append(f.statuses, *status)
+	}
+	return api.TowerHeartbeatResponse{Success: true, Timestamp: time.Now().Unix()}, nil
+}
+
+func (f *fakeTower) TowerGetMyRoom(ctx context.Context, apiKey string) (api.TowerRoomState, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.down {
+		return api.TowerRoomState{}, errors.New("dial tcp: connection refused")
+	}
+	if f.assigned == "" {
+		return api.TowerRoomState{}, &api.Error{Op: "tower get my room", Status: 404, Message: "no room"}
+	}
+	return api.TowerRoomState{Code: f.assigned}, nil
+}
+
+func TestBeatChecksInAgainWhenRoomLost(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeTower{assigned: "1F2"}
+	up := &Uptime{}
+	a := &Agent{API: fake, RoomCode: "1F2", Interval: time.Minute, Uptime: up,
+		Status: func(context.Context) string { return "3 tasks, 0 errors in last hour" }}
+
+	if beat := a.Beat(context.Background()); beat.Err != nil || beat.Checkin {
+		t.Fatalf("first beat = %+v", beat)
+	}
+
+	// The server drops the assignment: the agent asks for the same room back.
+	fake.mu.Lock()
+	fake.assigned = ""
+	fake.mu.Unlock()
+	beat := a.Beat(context.Background())
+	if beat.Err != nil || !beat.Checkin || beat.Room != "1F2" {
+		t.Fatalf("recovery beat = %+v", beat)
+	}
+	if len(fake.checkins) != 1 || fake.checkins[0] != "1F2" {
+		t.Fatalf("checkins = %v", fake.checkins)
+	}
+	if up.Beats != 2 || up.Checkins != 1 || up.Failures != 0 {
+		t.Fatalf("uptime = %+v", up)
+	}
+	if fake.statuses[1] != "3 tasks, 0 errors in last hour" {
+		t.Fatalf("statuses = %v", fake.statuses)
+	}
+}
+
+func TestBeatDoesNotCheckInOnNetworkErrors(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeTower{assigned: "1F2", down: true}
+	up := &Uptime{}
+	a := &Agent{API: fake, RoomCode: "1F2", Interval: time.Minute, Uptime: up}
+	if beat := a.Beat(context.Background()); beat.Err == nil {
+		t.Fatal("expected failure while the server is down")
+	}
+	if len(fake.checkins) != 0 || up.Failures != 1 || up.LastError == "" {
+		t.Fatalf("checkins = %v, uptime = %+v", fake.checkins, up)
+	}
+}
+
+func TestRunResolvesRoomAndKeepsBeating(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeTower{}
+	var mu sync.Mutex
+	var beats []Beat
+	ctx, cancel := context.WithCancel(context.Background())
+	a := &Agent{API: fake, Interval: 5 * time.Millisecond, OnBeat: func(b Beat) {
+		mu.Lock()
+		beats = append(beats, b)
+		if len(beats) == 3 {
+			cancel()
+		}
+		mu.Unlock()
+	}}
+	if err := a.Run(ctx); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if a.RoomCode != "0A1" || len(fake.beats) < 3 {
+		t.Fatalf("room = %q, beats = %v", a.RoomCode, fake.beats)
+	}
+	for _, b := range beats {
+		if b.Err != nil || b.Next < 4*time.Millisecond || b.Next > 6*time.Millisecond {
+			t.Fatalf("beat = %+v", b)
+		}
+	}
+}
+
+func TestBackoffGrowsAndCaps(t *testing.T) {
+	t.Parallel()
+
+	a := &Agent{Interval: 5 * time.Minute, MinBackoff: 10 * time.Second, rand: func() float64 { return 1 }}
+	want := []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, 80 * time.Second, 160 * time.Second, 5 * time.Minute, 5 * time.Minute}
+	for i, w := range want {
+		if got := a.backoff(i + 1); got != w {
+			t.Fatalf("backoff(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+	a.rand = func() float64 { return 0 }
+	if got := a.backoff(1); got != 5*time.Second {
+		t.Fatalf("backoff with zero jitter draw = %s", got)
+	}
+	if got := a.jittered(time.Minute); got != 54*time.Second {
+		t.Fatalf("jittered = %s", got)
+	}
+}
+
+func TestUptimeTracksStreaksAndRoomChanges(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	u := &Uptime{}
+	u.RecordBeat("1F2", base, 5*time.Minute)
+	u.RecordBeat("1F2", base.Add(5*time.Minute), 5*time.Minute)
+	u.RecordBeat("1F2", base.Add(10*time.Minute), 5*time.Minute)
+	// A 30 minute outage breaks the streak and is not online time.
+	u.RecordBeat("1F2", base.Add(40*time.Minute), 5*time.Minute)
+	u.RecordBeat("1F2", base.Add(45*time.Minute), 5*time.Minute)
+
+	if u.Beats != 5 || u.Online() != 15*time.Minute {
+		t.Fatalf("beats = %d, online = %s", u.Beats, u.Online())
+	}
+	if got := u.Streak(base.Add(46*time.Minute), 5*time.Minute); got != 5*time.Minute {
+		t.Fatalf("streak = %s", got)
+	}
+	if got := u.Streak(base.Add(2*time.Hour), 5*time.Minute); got != 0 {
+		t.Fatalf("stale streak = %s", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "uptime.json")
+	if err := SaveUptime(path, u); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	loaded, err := LoadUptime(path)
+	if err != nil || loaded.Beats != 5 || !loaded.LastBeat.Equal(u.LastBeat) {
+		t.Fatalf("loaded = %+v, %v", loaded, err)
+	}
+
+	loaded.RecordBeat("2B0", base.Add(50*time.Minute), 5*time.Minute)
+	if loaded.Beats != 1 || loaded.RoomCode != "2B0" || loaded.OnlineSeconds != 0 {
+		t.Fatalf("after room change = %+v", loaded)
+	}
+}
+
+func TestActivityStatus(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		stats  parser.Stats
+		window time.Duration
+		want   string
+	}{
+		{parser.Stats{LineCount: 40, TaskCount: 12}, time.Hour, "12 tasks, 0 errors in last hour"},
+		{parser.Stats{LineCount: 5, TaskCount: 1, ErrorCount: 1, WarningCount: 2}, 30 * time.Minute, "1 task, 1 error, 2 warnings in last 30m"},
+		{parser.Stats{}, 3 * time.Hour, "idle in last 3h"},
+	}
+	for _, tc := range cases {
+		if got := ActivityStatus(tc.stats, tc.window); got != tc.want {
+			t.Errorf("ActivityStatus(%+v, %s) = %q, want %q", tc.stats, tc.window, got, tc.want)
+		}
+	}
+	if got := clampStatus(strings.Repeat("日", 100)); len(got) > MaxStatusLength {
+		t.Fatalf("clamped status is %d bytes", len(got))
+	}
+}
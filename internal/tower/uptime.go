@@ -0,0 +1,117 @@
+package tower
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Uptime is what this machine knows about its own heartbeats, kept so it
+// can be compared with the server's TotalHeartbeats for the room. Counters
+// restart when the room changes.
+type Uptime struct {
+	RoomCode  string    `json:"roomCode"`
+	Since     time.Time `json:"since"`
+	FirstBeat time.Time `json:"firstBeat"`
+	LastBeat  time.Time `json:"lastBeat"`
+	// Beats counts accepted heartbeats; Failures counts attempts that
+	// failed even after any re-checkin.
+	Beats    int `json:"beats"`
+	Failures int `json:"failures"`
+	Checkins int `json:"checkins"`
+	// OnlineSeconds sums the gaps between consecutive beats that were close
+	// enough to keep the room online.
+	OnlineSeconds int64     `json:"onlineSeconds"`
+	StreakStart   time.Time `json:"streakStart"`
+	LastError     string    `json:"lastError,omitempty"`
+	LastErrorAt   time.Time `json:"lastErrorAt"`
+}
+
+// RecordBeat counts an accepted heartbeat at t. A gap of more than twice
+// interval since the previous beat ends the current streak and does not
+// count as online time.
+func (u *Uptime) RecordBeat(room string, t time.Time, interval time.Duration) {
+	if u.RoomCode != room {
+		*u = Uptime{RoomCode: room, Since: t}
+	}
+	if u.Since.IsZero() {
+		u.Since = t
+	}
+	if u.FirstBeat.IsZero() {
+		u.FirstBeat = t
+	}
+	if !u.LastBeat.IsZero() {
+		gap := t.Sub(u.LastBeat)
+		if gap > 0 && (interval <= 0 || gap <= 2*interval) {
+			u.OnlineSeconds += int64(gap / time.Second)
+		} else {
+			u.StreakStart = t
+		}
+	}
+	if u.StreakStart.IsZero() {
+		u.StreakStart = t
+	}
+	u.LastBeat = t
+	u.Beats++
+}
+
+// RecordFailure notes a failed attempt.
+func (u *Uptime) RecordFailure(t time.Time, err error) {
+	u.Failures++
+	u.LastErrorAt = t
+	if err != nil {
+		u.LastError = err.Error()
+	}
+}
+
+// Online is the accumulated online time.
+func (u *Uptime) Online() time.Duration {
+	return time.Duration(u.OnlineSeconds) * time.Second
+}
+
+// Streak is how long the current run of on-time beats has lasted at now;
+// zero when the last beat is older than twice interval.
+func (u *Uptime) Streak(now time.Time, interval time.Duration) time.Duration {
+	if u.LastBeat.IsZero() || u.StreakStart.IsZero() {
+		return 0
+	}
+	if interval > 0 && now.Sub(u.LastBeat) > 2*interval {
+		return 0
+	}
+	return u.LastBeat.Sub(u.StreakStart)
+}
+
+// LoadUptime reads the uptime file; a missing file yields an empty Uptime.
+func LoadUptime(path string) (*Uptime, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Uptime{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read heartbeat uptime: %w", err)
+	}
+	var u Uptime
+	if err := json.Unmarshal(data, &u); err != nil {
+		return nil, fmt.Errorf("parse heartbeat uptime %s: %w", path, err)
+	}
+	return &u, nil
+}
+
+// SaveUptime writes the uptime file atomically.
+func SaveUptime(path string, u *Uptime) error {
+	data, err := json.MarshalIndent(u, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode heartbeat uptime: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"golang.org/x/term"
+
+	"moltbb-cli/internal/output"
 )
 
 func PromptString(reader *bufio.Reader, label, defaultValue string) (string, error) {
@@ -14,7 +16,7 @@ func PromptString(reader *bufio.Reader, label, defaultValue string) (string, err
 	if strings.TrimSpace(defaultValue) != "" {
 		display = fmt.Sprintf("%s [%s]", label, defaultValue)
 	}
-	fmt.Printf("%s: ", display)
+	output.Printf("%s: ", display)
 	line, err := reader.ReadString('\n')
 	if err != nil {
 		return "", err
@@ -33,7 +35,7 @@ func PromptYesNo(reader *bufio.Reader, label string, defaultYes bool) (bool, err
 	}
 
 	for {
-		fmt.Printf("%s [%s]: ", label, defaultText)
+		output.Printf("%s [%s]: ", label, defaultText)
 		line, err := reader.ReadString('\n')
 		if err != nil {
 			return false, err
@@ -48,22 +50,22 @@ func PromptYesNo(reader *bufio.Reader, label string, defaultYes bool) (bool, err
 		if line == "n" || line == "no" {
 			return false, nil
 		}
-		fmt.Println("Please enter y or n.")
+		output.Println("Please enter y or n.")
 	}
 }
 
 func PromptSecret(reader *bufio.Reader, label string) (string, error) {
-	fmt.Printf("%s (input hidden): ", label)
+	output.Printf("%s (input hidden): ", label)
 	if term.IsTerminal(int(os.Stdin.Fd())) {
 		bytes, err := term.ReadPassword(int(os.Stdin.Fd()))
-		fmt.Println()
+		output.Println()
 		if err != nil {
 			return "", err
 		}
 		return strings.TrimSpace(string(bytes)), nil
 	}
 
-	fmt.Println("(warning: hidden input unavailable on this terminal)")
+	output.Println("(warning: hidden input unavailable on this terminal)")
 	line, err := reader.ReadString('\n')
 	if err != nil {
 		return "", err